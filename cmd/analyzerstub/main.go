@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/cartomix/cancun/internal/analyzer"
+	"google.golang.org/grpc"
+)
+
+// analyzerstub serves synthetic analyses over the AnalyzerWorker gRPC API so the
+// engine can be run and tested without the Swift worker.
+func main() {
+	port := flag.Int("port", 50052, "gRPC port to listen on; the engine dials localhost:50052 by default")
+	stageDelay := flag.Duration("stage-delay", 0, "artificial delay per analysis stage")
+	flag.Parse()
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
+	if err != nil {
+		log.Fatalf("listen: %v", err)
+	}
+
+	s := grpc.NewServer()
+	analyzer.NewStubWorker(*stageDelay).Register(s)
+
+	log.Printf("analyzer stub listening on %s (stage delay %s)", lis.Addr(), *stageDelay)
+	if err := s.Serve(lis); err != nil {
+		log.Fatalf("serve: %v", err)
+	}
+}
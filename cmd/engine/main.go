@@ -8,22 +8,32 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/cartomix/cancun/gen/go/engine"
 	"github.com/cartomix/cancun/internal/analyzer"
+	"github.com/cartomix/cancun/internal/ann"
 	"github.com/cartomix/cancun/internal/auth"
 	"github.com/cartomix/cancun/internal/config"
 	"github.com/cartomix/cancun/internal/httpapi"
+	"github.com/cartomix/cancun/internal/live"
+	"github.com/cartomix/cancun/internal/scanner"
 	"github.com/cartomix/cancun/internal/server"
+	"github.com/cartomix/cancun/internal/similarity"
 	"github.com/cartomix/cancun/internal/storage"
+	"github.com/cartomix/cancun/internal/worker"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
+// similarityGraphBatch is how many tracks one similarity graph job refreshes
+// before letting other jobs in.
+const similarityGraphBatch = 500
+
 func main() {
 	cfg := config.Parse()
 
@@ -54,15 +64,104 @@ func main() {
 	}
 	defer db.Close()
 
-	// Connect to Swift analyzer worker (required)
-	analysisBackend, err := analyzer.NewClient(cfg.AnalyzerAddr, logger)
-	if err != nil {
-		logger.Error("failed to connect to analyzer worker", "addr", cfg.AnalyzerAddr, "error", err)
+	// Index OpenL3 embeddings for similarity search. The index catches up with
+	// the database in the background; searches compare every track until then
+	var embeddingIndex *ann.Index
+	indexSynced := make(chan struct{})
+	indexPath := filepath.Join(cfg.DataDir, "openl3.ann")
+	if cfg.ANNIndex {
+		var since time.Time
+		if cfg.PersistANNIndex {
+			if info, err := os.Stat(indexPath); err == nil {
+				if idx, err := ann.Load(indexPath); err != nil {
+					logger.Warn("failed to load the embedding index, rebuilding it", "path", indexPath, "error", err)
+				} else {
+					embeddingIndex, since = idx, info.ModTime()
+				}
+			}
+		}
+		if embeddingIndex == nil {
+			embeddingIndex = ann.New(similarity.EmbeddingDim, ann.Config{})
+		}
+		db.EnableEmbeddingIndex(embeddingIndex)
+		go func() {
+			start := time.Now()
+			indexed, dropped, err := db.SyncEmbeddingIndex(context.Background(), since)
+			if err != nil {
+				logger.Error("failed to build the embedding index", "error", err)
+				return
+			}
+			close(indexSynced)
+			logger.Info("embedding index ready",
+				"tracks", embeddingIndex.Len(), "indexed", indexed, "dropped", dropped, "took", time.Since(start))
+		}()
+	}
+
+	// Connect to the analysis backend: the Swift analyzer worker, or the built-in
+	// reference analyzer for platforms without one
+	var analysisBackend analyzer.Analyzer
+	switch cfg.AnalyzerBackend {
+	case "local":
+		analysisBackend = analyzer.NewLocal(logger)
+		logger.Info("using local reference analyzer")
+	case "worker":
+		client, err := analyzer.NewClient(cfg.AnalyzerAddr, logger)
+		if err != nil {
+			logger.Error("failed to connect to analyzer worker", "addr", cfg.AnalyzerAddr, "error", err)
+			os.Exit(1)
+		}
+		analysisBackend = client
+		logger.Info("connected to analyzer worker", "addr", cfg.AnalyzerAddr)
+	default:
+		logger.Error("unknown analyzer backend", "backend", cfg.AnalyzerBackend)
 		os.Exit(1)
 	}
-	logger.Info("connected to analyzer worker", "addr", cfg.AnalyzerAddr)
 	defer analysisBackend.Close()
 
+	// Start background workers that drain queued analysis jobs
+	var jobPool *worker.Pool
+	if cfg.AnalysisWorkers > 0 {
+		jobPool = worker.NewPool(db, worker.Config{
+			Concurrency: cfg.AnalysisWorkers,
+			JobTimeout:  cfg.JobTimeout,
+		}, logger)
+		jobPool.Handle(storage.JobTypeAnalyze, worker.AnalyzeHandler(db, analysisBackend))
+		jobPool.Handle(storage.JobTypeSimilarityGraph, worker.SimilarityGraphHandler(db, similarityGraphBatch))
+		db.OnJobQueued(jobPool.Notify)
+		// Catch the similarity graph up with what changed while we were down
+		if err := db.EnqueueSimilarityGraphRefresh(); err != nil {
+			logger.Warn("failed to queue a similarity graph refresh", "error", err)
+		}
+		if err := jobPool.Start(context.Background()); err != nil {
+			logger.Error("failed to start job workers", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Re-key tracks hashed before the audio-payload content identity; analyses
+	// stay attached because track IDs don't change
+	go func() {
+		result, err := scanner.NewScanner(db, logger).RekeyLegacyTracks(context.Background())
+		if err != nil {
+			logger.Warn("failed to re-key legacy tracks", "error", err)
+			return
+		}
+		if result.Rekeyed > 0 || result.Missing > 0 || result.Collisions > 0 {
+			logger.Info("re-keyed legacy tracks",
+				"rekeyed", result.Rekeyed, "missing", result.Missing, "collisions", result.Collisions)
+		}
+	}()
+
+	// Keep the library roots in sync with the filesystem; roots can be managed
+	// through the API even when watching is disabled
+	watcher := scanner.NewWatcher(db, logger, scanner.WatchConfig{PollInterval: cfg.WatchPollInterval})
+	if cfg.WatchLibrary {
+		if err := watcher.Start(context.Background()); err != nil {
+			logger.Error("failed to start library watcher", "error", err)
+			os.Exit(1)
+		}
+	}
+
 	// Create gRPC server with chained interceptors (logging, metrics, recovery, auth)
 	authCfg := auth.Config{Enabled: cfg.AuthEnabled}
 	grpcServer := grpc.NewServer(
@@ -82,6 +181,9 @@ func main() {
 
 	// Register engine API
 	engineServer := server.NewEngineServer(cfg, logger, db, analysisBackend)
+	engineServer.SetWatcher(watcher)
+	liveSessions := live.NewManager(db, logger)
+	engineServer.SetLiveSessions(liveSessions)
 	engine.RegisterEngineAPIServer(grpcServer, engineServer)
 
 	// Register health service
@@ -118,11 +220,21 @@ func main() {
 			httpLis.Shutdown(ctx)
 		}
 
+		// Stop watching before the job workers so no new work is queued
+		watcher.Stop()
+
+		// Stop job workers; interrupted jobs are requeued for the next start
+		if jobPool != nil {
+			jobPool.Stop()
+		}
+
 		grpcServer.GracefulStop()
 	}()
 
 	// Start HTTP server
 	httpServer := httpapi.NewServer(cfg, logger, db, analysisBackend)
+	httpServer.SetWatcher(watcher)
+	httpServer.SetLiveSessions(liveSessions)
 	httpAddr := fmt.Sprintf(":%d", cfg.HTTPPort)
 	httpLis = &http.Server{
 		Addr:    httpAddr,
@@ -147,4 +259,17 @@ func main() {
 		logger.Error("server error", "error", err)
 		os.Exit(1)
 	}
+
+	// Save the index only once it has caught up, so the next start can trust
+	// it for everything analyzed before now
+	if cfg.PersistANNIndex && embeddingIndex != nil {
+		select {
+		case <-indexSynced:
+			if err := embeddingIndex.Save(indexPath); err != nil {
+				logger.Warn("failed to save the embedding index", "path", indexPath, "error", err)
+			}
+		default:
+			logger.Info("embedding index not saved: it hadn't caught up yet")
+		}
+	}
 }
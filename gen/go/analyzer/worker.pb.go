@@ -275,6 +275,7 @@ type AnalyzeResult struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Analysis      *common.TrackAnalysis  `protobuf:"bytes,1,opt,name=analysis,proto3" json:"analysis,omitempty"`
 	WaveformTiles []byte                 `protobuf:"bytes,2,opt,name=waveform_tiles,json=waveformTiles,proto3" json:"waveform_tiles,omitempty"` // optional packed multiresolution tiles
+	Openl3Windows []*OpenL3Window        `protobuf:"bytes,3,rep,name=openl3_windows,json=openl3Windows,proto3" json:"openl3_windows,omitempty"` // per-window embeddings, in time order
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -323,6 +324,232 @@ func (x *AnalyzeResult) GetWaveformTiles() []byte {
 	return nil
 }
 
+func (x *AnalyzeResult) GetOpenl3Windows() []*OpenL3Window {
+	if x != nil {
+		return x.Openl3Windows
+	}
+	return nil
+}
+
+// OpenL3 embedding of one stretch of a track, for section-level similarity
+type OpenL3Window struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	StartSeconds    float64                `protobuf:"fixed64,1,opt,name=start_seconds,json=startSeconds,proto3" json:"start_seconds,omitempty"`
+	DurationSeconds float64                `protobuf:"fixed64,2,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"`
+	Vector          []float32              `protobuf:"fixed32,3,rep,packed,name=vector,proto3" json:"vector,omitempty"` // 512-dim
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *OpenL3Window) Reset() {
+	*x = OpenL3Window{}
+	mi := &file_analyzer_worker_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpenL3Window) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenL3Window) ProtoMessage() {}
+
+func (x *OpenL3Window) ProtoReflect() protoreflect.Message {
+	mi := &file_analyzer_worker_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenL3Window.ProtoReflect.Descriptor instead.
+func (*OpenL3Window) Descriptor() ([]byte, []int) {
+	return file_analyzer_worker_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *OpenL3Window) GetStartSeconds() float64 {
+	if x != nil {
+		return x.StartSeconds
+	}
+	return 0
+}
+
+func (x *OpenL3Window) GetDurationSeconds() float64 {
+	if x != nil {
+		return x.DurationSeconds
+	}
+	return 0
+}
+
+func (x *OpenL3Window) GetVector() []float32 {
+	if x != nil {
+		return x.Vector
+	}
+	return nil
+}
+
+type StageEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stage         string                 `protobuf:"bytes,1,opt,name=stage,proto3" json:"stage,omitempty"`                              // decode / beatgrid / key / loudness / embeddings / sections / cues
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`                            // started / completed / error
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`                          // human-readable stage description
+	Percent       float32                `protobuf:"fixed32,4,opt,name=percent,proto3" json:"percent,omitempty"`                        // track progress 0-100
+	DurationMs    int64                  `protobuf:"varint,5,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"` // time spent in the stage (set when completed)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StageEvent) Reset() {
+	*x = StageEvent{}
+	mi := &file_analyzer_worker_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StageEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StageEvent) ProtoMessage() {}
+
+func (x *StageEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_analyzer_worker_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StageEvent.ProtoReflect.Descriptor instead.
+func (*StageEvent) Descriptor() ([]byte, []int) {
+	return file_analyzer_worker_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StageEvent) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *StageEvent) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *StageEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *StageEvent) GetPercent() float32 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *StageEvent) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+type AnalyzeEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*AnalyzeEvent_Stage
+	//	*AnalyzeEvent_Result
+	Event         isAnalyzeEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnalyzeEvent) Reset() {
+	*x = AnalyzeEvent{}
+	mi := &file_analyzer_worker_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnalyzeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalyzeEvent) ProtoMessage() {}
+
+func (x *AnalyzeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_analyzer_worker_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalyzeEvent.ProtoReflect.Descriptor instead.
+func (*AnalyzeEvent) Descriptor() ([]byte, []int) {
+	return file_analyzer_worker_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AnalyzeEvent) GetEvent() isAnalyzeEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *AnalyzeEvent) GetStage() *StageEvent {
+	if x != nil {
+		if x, ok := x.Event.(*AnalyzeEvent_Stage); ok {
+			return x.Stage
+		}
+	}
+	return nil
+}
+
+func (x *AnalyzeEvent) GetResult() *AnalyzeResult {
+	if x != nil {
+		if x, ok := x.Event.(*AnalyzeEvent_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isAnalyzeEvent_Event interface {
+	isAnalyzeEvent_Event()
+}
+
+type AnalyzeEvent_Stage struct {
+	Stage *StageEvent `protobuf:"bytes,1,opt,name=stage,proto3,oneof"`
+}
+
+type AnalyzeEvent_Result struct {
+	Result *AnalyzeResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"` // always the last event on success
+}
+
+func (*AnalyzeEvent_Stage) isAnalyzeEvent_Event() {}
+
+func (*AnalyzeEvent_Result) isAnalyzeEvent_Event() {}
+
 var File_analyzer_worker_proto protoreflect.FileDescriptor
 
 const file_analyzer_worker_proto_rawDesc = "" +
@@ -347,12 +574,30 @@ const file_analyzer_worker_proto_rawDesc = "" +
 	"\x06decode\x18\x03 \x01(\v2\x1f.cartomix.analyzer.DecodeParamsR\x06decode\x12=\n" +
 	"\bbeatgrid\x18\x04 \x01(\v2!.cartomix.analyzer.BeatgridParamsR\bbeatgrid\x120\n" +
 	"\x04cues\x18\x05 \x01(\v2\x1c.cartomix.analyzer.CueParamsR\x04cues\x12)\n" +
-	"\x10analysis_version\x18\x06 \x01(\x05R\x0fanalysisVersion\"r\n" +
+	"\x10analysis_version\x18\x06 \x01(\x05R\x0fanalysisVersion\"\xba\x01\n" +
 	"\rAnalyzeResult\x12:\n" +
 	"\banalysis\x18\x01 \x01(\v2\x1e.cartomix.common.TrackAnalysisR\banalysis\x12%\n" +
-	"\x0ewaveform_tiles\x18\x02 \x01(\fR\rwaveformTiles2a\n" +
+	"\x0ewaveform_tiles\x18\x02 \x01(\fR\rwaveformTiles\x12F\n" +
+	"\x0eopenl3_windows\x18\x03 \x03(\v2\x1f.cartomix.analyzer.OpenL3WindowR\ropenl3Windows\"v\n" +
+	"\fOpenL3Window\x12#\n" +
+	"\rstart_seconds\x18\x01 \x01(\x01R\fstartSeconds\x12)\n" +
+	"\x10duration_seconds\x18\x02 \x01(\x01R\x0fdurationSeconds\x12\x16\n" +
+	"\x06vector\x18\x03 \x03(\x02R\x06vector\"\x8f\x01\n" +
+	"\n" +
+	"StageEvent\x12\x14\n" +
+	"\x05stage\x18\x01 \x01(\tR\x05stage\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x18\n" +
+	"\apercent\x18\x04 \x01(\x02R\apercent\x12\x1f\n" +
+	"\vduration_ms\x18\x05 \x01(\x03R\n" +
+	"durationMs\"\x8a\x01\n" +
+	"\fAnalyzeEvent\x125\n" +
+	"\x05stage\x18\x01 \x01(\v2\x1d.cartomix.analyzer.StageEventH\x00R\x05stage\x12:\n" +
+	"\x06result\x18\x02 \x01(\v2 .cartomix.analyzer.AnalyzeResultH\x00R\x06resultB\a\n" +
+	"\x05event2\xb9\x01\n" +
 	"\x0eAnalyzerWorker\x12O\n" +
-	"\fAnalyzeTrack\x12\x1d.cartomix.analyzer.AnalyzeJob\x1a .cartomix.analyzer.AnalyzeResultB\xb5\x01\n" +
+	"\fAnalyzeTrack\x12\x1d.cartomix.analyzer.AnalyzeJob\x1a .cartomix.analyzer.AnalyzeResult\x12V\n" +
+	"\x12AnalyzeTrackStream\x12\x1d.cartomix.analyzer.AnalyzeJob\x1a\x1f.cartomix.analyzer.AnalyzeEvent0\x01B\xb5\x01\n" +
 	"\x15com.cartomix.analyzerB\vWorkerProtoP\x01Z*github.com/cartomix/cancun/gen/go/analyzer\xa2\x02\x03CAX\xaa\x02\x11Cartomix.Analyzer\xca\x02\x11Cartomix\\Analyzer\xe2\x02\x1dCartomix\\Analyzer\\GPBMetadata\xea\x02\x12Cartomix::Analyzerb\x06proto3"
 
 var (
@@ -367,29 +612,37 @@ func file_analyzer_worker_proto_rawDescGZIP() []byte {
 	return file_analyzer_worker_proto_rawDescData
 }
 
-var file_analyzer_worker_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_analyzer_worker_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_analyzer_worker_proto_goTypes = []any{
 	(*DecodeParams)(nil),         // 0: cartomix.analyzer.DecodeParams
 	(*BeatgridParams)(nil),       // 1: cartomix.analyzer.BeatgridParams
 	(*CueParams)(nil),            // 2: cartomix.analyzer.CueParams
 	(*AnalyzeJob)(nil),           // 3: cartomix.analyzer.AnalyzeJob
 	(*AnalyzeResult)(nil),        // 4: cartomix.analyzer.AnalyzeResult
-	(*common.TrackId)(nil),       // 5: cartomix.common.TrackId
-	(*common.TrackAnalysis)(nil), // 6: cartomix.common.TrackAnalysis
+	(*OpenL3Window)(nil),         // 5: cartomix.analyzer.OpenL3Window
+	(*StageEvent)(nil),           // 6: cartomix.analyzer.StageEvent
+	(*AnalyzeEvent)(nil),         // 7: cartomix.analyzer.AnalyzeEvent
+	(*common.TrackId)(nil),       // 8: cartomix.common.TrackId
+	(*common.TrackAnalysis)(nil), // 9: cartomix.common.TrackAnalysis
 }
 var file_analyzer_worker_proto_depIdxs = []int32{
-	5, // 0: cartomix.analyzer.AnalyzeJob.id:type_name -> cartomix.common.TrackId
-	0, // 1: cartomix.analyzer.AnalyzeJob.decode:type_name -> cartomix.analyzer.DecodeParams
-	1, // 2: cartomix.analyzer.AnalyzeJob.beatgrid:type_name -> cartomix.analyzer.BeatgridParams
-	2, // 3: cartomix.analyzer.AnalyzeJob.cues:type_name -> cartomix.analyzer.CueParams
-	6, // 4: cartomix.analyzer.AnalyzeResult.analysis:type_name -> cartomix.common.TrackAnalysis
-	3, // 5: cartomix.analyzer.AnalyzerWorker.AnalyzeTrack:input_type -> cartomix.analyzer.AnalyzeJob
-	4, // 6: cartomix.analyzer.AnalyzerWorker.AnalyzeTrack:output_type -> cartomix.analyzer.AnalyzeResult
-	6, // [6:7] is the sub-list for method output_type
-	5, // [5:6] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	8,  // 0: cartomix.analyzer.AnalyzeJob.id:type_name -> cartomix.common.TrackId
+	0,  // 1: cartomix.analyzer.AnalyzeJob.decode:type_name -> cartomix.analyzer.DecodeParams
+	1,  // 2: cartomix.analyzer.AnalyzeJob.beatgrid:type_name -> cartomix.analyzer.BeatgridParams
+	2,  // 3: cartomix.analyzer.AnalyzeJob.cues:type_name -> cartomix.analyzer.CueParams
+	9,  // 4: cartomix.analyzer.AnalyzeResult.analysis:type_name -> cartomix.common.TrackAnalysis
+	5,  // 5: cartomix.analyzer.AnalyzeResult.openl3_windows:type_name -> cartomix.analyzer.OpenL3Window
+	6,  // 6: cartomix.analyzer.AnalyzeEvent.stage:type_name -> cartomix.analyzer.StageEvent
+	4,  // 7: cartomix.analyzer.AnalyzeEvent.result:type_name -> cartomix.analyzer.AnalyzeResult
+	3,  // 8: cartomix.analyzer.AnalyzerWorker.AnalyzeTrack:input_type -> cartomix.analyzer.AnalyzeJob
+	3,  // 9: cartomix.analyzer.AnalyzerWorker.AnalyzeTrackStream:input_type -> cartomix.analyzer.AnalyzeJob
+	4,  // 10: cartomix.analyzer.AnalyzerWorker.AnalyzeTrack:output_type -> cartomix.analyzer.AnalyzeResult
+	7,  // 11: cartomix.analyzer.AnalyzerWorker.AnalyzeTrackStream:output_type -> cartomix.analyzer.AnalyzeEvent
+	10, // [10:12] is the sub-list for method output_type
+	8,  // [8:10] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_analyzer_worker_proto_init() }
@@ -397,13 +650,17 @@ func file_analyzer_worker_proto_init() {
 	if File_analyzer_worker_proto != nil {
 		return
 	}
+	file_analyzer_worker_proto_msgTypes[7].OneofWrappers = []any{
+		(*AnalyzeEvent_Stage)(nil),
+		(*AnalyzeEvent_Result)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_analyzer_worker_proto_rawDesc), len(file_analyzer_worker_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
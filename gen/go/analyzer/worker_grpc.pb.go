@@ -19,7 +19,8 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	AnalyzerWorker_AnalyzeTrack_FullMethodName = "/cartomix.analyzer.AnalyzerWorker/AnalyzeTrack"
+	AnalyzerWorker_AnalyzeTrack_FullMethodName       = "/cartomix.analyzer.AnalyzerWorker/AnalyzeTrack"
+	AnalyzerWorker_AnalyzeTrackStream_FullMethodName = "/cartomix.analyzer.AnalyzerWorker/AnalyzeTrackStream"
 )
 
 // AnalyzerWorkerClient is the client API for AnalyzerWorker service.
@@ -28,6 +29,9 @@ const (
 type AnalyzerWorkerClient interface {
 	// Analyze a single track. The engine handles scheduling and retries.
 	AnalyzeTrack(ctx context.Context, in *AnalyzeJob, opts ...grpc.CallOption) (*AnalyzeResult, error)
+	// Analyze a single track, streaming per-stage progress followed by the final result.
+	// Workers that don't implement this return UNIMPLEMENTED and the engine falls back to AnalyzeTrack.
+	AnalyzeTrackStream(ctx context.Context, in *AnalyzeJob, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnalyzeEvent], error)
 }
 
 type analyzerWorkerClient struct {
@@ -48,12 +52,34 @@ func (c *analyzerWorkerClient) AnalyzeTrack(ctx context.Context, in *AnalyzeJob,
 	return out, nil
 }
 
+func (c *analyzerWorkerClient) AnalyzeTrackStream(ctx context.Context, in *AnalyzeJob, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AnalyzeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AnalyzerWorker_ServiceDesc.Streams[0], AnalyzerWorker_AnalyzeTrackStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AnalyzeJob, AnalyzeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnalyzerWorker_AnalyzeTrackStreamClient = grpc.ServerStreamingClient[AnalyzeEvent]
+
 // AnalyzerWorkerServer is the server API for AnalyzerWorker service.
 // All implementations must embed UnimplementedAnalyzerWorkerServer
 // for forward compatibility.
 type AnalyzerWorkerServer interface {
 	// Analyze a single track. The engine handles scheduling and retries.
 	AnalyzeTrack(context.Context, *AnalyzeJob) (*AnalyzeResult, error)
+	// Analyze a single track, streaming per-stage progress followed by the final result.
+	// Workers that don't implement this return UNIMPLEMENTED and the engine falls back to AnalyzeTrack.
+	AnalyzeTrackStream(*AnalyzeJob, grpc.ServerStreamingServer[AnalyzeEvent]) error
 	mustEmbedUnimplementedAnalyzerWorkerServer()
 }
 
@@ -67,6 +93,9 @@ type UnimplementedAnalyzerWorkerServer struct{}
 func (UnimplementedAnalyzerWorkerServer) AnalyzeTrack(context.Context, *AnalyzeJob) (*AnalyzeResult, error) {
 	return nil, status.Error(codes.Unimplemented, "method AnalyzeTrack not implemented")
 }
+func (UnimplementedAnalyzerWorkerServer) AnalyzeTrackStream(*AnalyzeJob, grpc.ServerStreamingServer[AnalyzeEvent]) error {
+	return status.Error(codes.Unimplemented, "method AnalyzeTrackStream not implemented")
+}
 func (UnimplementedAnalyzerWorkerServer) mustEmbedUnimplementedAnalyzerWorkerServer() {}
 func (UnimplementedAnalyzerWorkerServer) testEmbeddedByValue()                        {}
 
@@ -106,6 +135,17 @@ func _AnalyzerWorker_AnalyzeTrack_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AnalyzerWorker_AnalyzeTrackStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(AnalyzeJob)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnalyzerWorkerServer).AnalyzeTrackStream(m, &grpc.GenericServerStream[AnalyzeJob, AnalyzeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AnalyzerWorker_AnalyzeTrackStreamServer = grpc.ServerStreamingServer[AnalyzeEvent]
+
 // AnalyzerWorker_ServiceDesc is the grpc.ServiceDesc for AnalyzerWorker service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -118,6 +158,12 @@ var AnalyzerWorker_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _AnalyzerWorker_AnalyzeTrack_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AnalyzeTrackStream",
+			Handler:       _AnalyzerWorker_AnalyzeTrackStream_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "analyzer/worker.proto",
 }
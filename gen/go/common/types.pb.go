@@ -1287,20 +1287,23 @@ func (x *QAFlag) GetDismissed() bool {
 
 // Similarity result with explanation
 type SimilarTrack struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            *TrackId               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Artist        string                 `protobuf:"bytes,3,opt,name=artist,proto3" json:"artist,omitempty"`
-	Score         float32                `protobuf:"fixed32,4,opt,name=score,proto3" json:"score,omitempty"`                          // 0..1 combined score
-	Explanation   string                 `protobuf:"bytes,5,opt,name=explanation,proto3" json:"explanation,omitempty"`                // Human-readable
-	VibeMatch     float32                `protobuf:"fixed32,6,opt,name=vibe_match,json=vibeMatch,proto3" json:"vibe_match,omitempty"` // OpenL3 cosine similarity %
-	TempoMatch    float32                `protobuf:"fixed32,7,opt,name=tempo_match,json=tempoMatch,proto3" json:"tempo_match,omitempty"`
-	KeyMatch      float32                `protobuf:"fixed32,8,opt,name=key_match,json=keyMatch,proto3" json:"key_match,omitempty"`
-	EnergyMatch   float32                `protobuf:"fixed32,9,opt,name=energy_match,json=energyMatch,proto3" json:"energy_match,omitempty"`
-	BpmDelta      float32                `protobuf:"fixed32,10,opt,name=bpm_delta,json=bpmDelta,proto3" json:"bpm_delta,omitempty"`
-	KeyRelation   string                 `protobuf:"bytes,11,opt,name=key_relation,json=keyRelation,proto3" json:"key_relation,omitempty"` // same, compatible, harmonic, clash
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             *TrackId               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title          string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Artist         string                 `protobuf:"bytes,3,opt,name=artist,proto3" json:"artist,omitempty"`
+	Score          float32                `protobuf:"fixed32,4,opt,name=score,proto3" json:"score,omitempty"`                          // 0..1 combined score
+	Explanation    string                 `protobuf:"bytes,5,opt,name=explanation,proto3" json:"explanation,omitempty"`                // Human-readable
+	VibeMatch      float32                `protobuf:"fixed32,6,opt,name=vibe_match,json=vibeMatch,proto3" json:"vibe_match,omitempty"` // OpenL3 cosine similarity %
+	TempoMatch     float32                `protobuf:"fixed32,7,opt,name=tempo_match,json=tempoMatch,proto3" json:"tempo_match,omitempty"`
+	KeyMatch       float32                `protobuf:"fixed32,8,opt,name=key_match,json=keyMatch,proto3" json:"key_match,omitempty"`
+	EnergyMatch    float32                `protobuf:"fixed32,9,opt,name=energy_match,json=energyMatch,proto3" json:"energy_match,omitempty"`
+	BpmDelta       float32                `protobuf:"fixed32,10,opt,name=bpm_delta,json=bpmDelta,proto3" json:"bpm_delta,omitempty"`
+	KeyRelation    string                 `protobuf:"bytes,11,opt,name=key_relation,json=keyRelation,proto3" json:"key_relation,omitempty"`           // same, compatible, harmonic, clash
+	DuplicateCount int32                  `protobuf:"varint,12,opt,name=duplicate_count,json=duplicateCount,proto3" json:"duplicate_count,omitempty"` // other copies collapsed into this result
+	Seed           *TrackId               `protobuf:"bytes,13,opt,name=seed,proto3" json:"seed,omitempty"`                                            // the seed a multi-seed match relates to
+	Section        *SectionSpan           `protobuf:"bytes,14,opt,name=section,proto3" json:"section,omitempty"`                                      // the matching section, in section queries
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *SimilarTrack) Reset() {
@@ -1410,6 +1413,88 @@ func (x *SimilarTrack) GetKeyRelation() string {
 	return ""
 }
 
+func (x *SimilarTrack) GetDuplicateCount() int32 {
+	if x != nil {
+		return x.DuplicateCount
+	}
+	return 0
+}
+
+func (x *SimilarTrack) GetSeed() *TrackId {
+	if x != nil {
+		return x.Seed
+	}
+	return nil
+}
+
+func (x *SimilarTrack) GetSection() *SectionSpan {
+	if x != nil {
+		return x.Section
+	}
+	return nil
+}
+
+// A stretch of a track in time, usually a labelled section
+type SectionSpan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         SectionLabel           `protobuf:"varint,1,opt,name=label,proto3,enum=cartomix.common.SectionLabel" json:"label,omitempty"` // unspecified for a plain time range
+	StartSeconds  float64                `protobuf:"fixed64,2,opt,name=start_seconds,json=startSeconds,proto3" json:"start_seconds,omitempty"`
+	EndSeconds    float64                `protobuf:"fixed64,3,opt,name=end_seconds,json=endSeconds,proto3" json:"end_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SectionSpan) Reset() {
+	*x = SectionSpan{}
+	mi := &file_common_types_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SectionSpan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SectionSpan) ProtoMessage() {}
+
+func (x *SectionSpan) ProtoReflect() protoreflect.Message {
+	mi := &file_common_types_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SectionSpan.ProtoReflect.Descriptor instead.
+func (*SectionSpan) Descriptor() ([]byte, []int) {
+	return file_common_types_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SectionSpan) GetLabel() SectionLabel {
+	if x != nil {
+		return x.Label
+	}
+	return SectionLabel_SECTION_LABEL_UNSPECIFIED
+}
+
+func (x *SectionSpan) GetStartSeconds() float64 {
+	if x != nil {
+		return x.StartSeconds
+	}
+	return 0
+}
+
+func (x *SectionSpan) GetEndSeconds() float64 {
+	if x != nil {
+		return x.EndSeconds
+	}
+	return 0
+}
+
 // Training label for custom model training
 type TrainingLabel struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
@@ -1430,7 +1515,7 @@ type TrainingLabel struct {
 
 func (x *TrainingLabel) Reset() {
 	*x = TrainingLabel{}
-	mi := &file_common_types_proto_msgTypes[16]
+	mi := &file_common_types_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1442,7 +1527,7 @@ func (x *TrainingLabel) String() string {
 func (*TrainingLabel) ProtoMessage() {}
 
 func (x *TrainingLabel) ProtoReflect() protoreflect.Message {
-	mi := &file_common_types_proto_msgTypes[16]
+	mi := &file_common_types_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1455,7 +1540,7 @@ func (x *TrainingLabel) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrainingLabel.ProtoReflect.Descriptor instead.
 func (*TrainingLabel) Descriptor() ([]byte, []int) {
-	return file_common_types_proto_rawDescGZIP(), []int{16}
+	return file_common_types_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *TrainingLabel) GetId() int64 {
@@ -1558,7 +1643,7 @@ type TrainingJob struct {
 
 func (x *TrainingJob) Reset() {
 	*x = TrainingJob{}
-	mi := &file_common_types_proto_msgTypes[17]
+	mi := &file_common_types_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1570,7 +1655,7 @@ func (x *TrainingJob) String() string {
 func (*TrainingJob) ProtoMessage() {}
 
 func (x *TrainingJob) ProtoReflect() protoreflect.Message {
-	mi := &file_common_types_proto_msgTypes[17]
+	mi := &file_common_types_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1583,7 +1668,7 @@ func (x *TrainingJob) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrainingJob.ProtoReflect.Descriptor instead.
 func (*TrainingJob) Descriptor() ([]byte, []int) {
-	return file_common_types_proto_rawDescGZIP(), []int{17}
+	return file_common_types_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *TrainingJob) GetJobId() string {
@@ -1702,7 +1787,7 @@ type ModelVersion struct {
 
 func (x *ModelVersion) Reset() {
 	*x = ModelVersion{}
-	mi := &file_common_types_proto_msgTypes[18]
+	mi := &file_common_types_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1714,7 +1799,7 @@ func (x *ModelVersion) String() string {
 func (*ModelVersion) ProtoMessage() {}
 
 func (x *ModelVersion) ProtoReflect() protoreflect.Message {
-	mi := &file_common_types_proto_msgTypes[18]
+	mi := &file_common_types_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1727,7 +1812,7 @@ func (x *ModelVersion) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ModelVersion.ProtoReflect.Descriptor instead.
 func (*ModelVersion) Descriptor() ([]byte, []int) {
-	return file_common_types_proto_rawDescGZIP(), []int{18}
+	return file_common_types_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *ModelVersion) GetVersion() int32 {
@@ -1808,7 +1893,7 @@ type TrainingLabelStats struct {
 
 func (x *TrainingLabelStats) Reset() {
 	*x = TrainingLabelStats{}
-	mi := &file_common_types_proto_msgTypes[19]
+	mi := &file_common_types_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1820,7 +1905,7 @@ func (x *TrainingLabelStats) String() string {
 func (*TrainingLabelStats) ProtoMessage() {}
 
 func (x *TrainingLabelStats) ProtoReflect() protoreflect.Message {
-	mi := &file_common_types_proto_msgTypes[19]
+	mi := &file_common_types_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1833,7 +1918,7 @@ func (x *TrainingLabelStats) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrainingLabelStats.ProtoReflect.Descriptor instead.
 func (*TrainingLabelStats) Descriptor() ([]byte, []int) {
-	return file_common_types_proto_rawDescGZIP(), []int{19}
+	return file_common_types_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *TrainingLabelStats) GetTotalLabels() int32 {
@@ -1886,13 +1971,14 @@ type MLSettings struct {
 	DjSectionModelEnabled bool                   `protobuf:"varint,3,opt,name=dj_section_model_enabled,json=djSectionModelEnabled,proto3" json:"dj_section_model_enabled,omitempty"`
 	ShowExplanations      bool                   `protobuf:"varint,4,opt,name=show_explanations,json=showExplanations,proto3" json:"show_explanations,omitempty"`
 	SimilarityThreshold   float32                `protobuf:"fixed32,5,opt,name=similarity_threshold,json=similarityThreshold,proto3" json:"similarity_threshold,omitempty"`
+	SimilarityWeights     *SimilarityWeights     `protobuf:"bytes,6,opt,name=similarity_weights,json=similarityWeights,proto3" json:"similarity_weights,omitempty"` // unset or all zero restores the defaults
 	unknownFields         protoimpl.UnknownFields
 	sizeCache             protoimpl.SizeCache
 }
 
 func (x *MLSettings) Reset() {
 	*x = MLSettings{}
-	mi := &file_common_types_proto_msgTypes[20]
+	mi := &file_common_types_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1904,7 +1990,7 @@ func (x *MLSettings) String() string {
 func (*MLSettings) ProtoMessage() {}
 
 func (x *MLSettings) ProtoReflect() protoreflect.Message {
-	mi := &file_common_types_proto_msgTypes[20]
+	mi := &file_common_types_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1917,7 +2003,7 @@ func (x *MLSettings) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MLSettings.ProtoReflect.Descriptor instead.
 func (*MLSettings) Descriptor() ([]byte, []int) {
-	return file_common_types_proto_rawDescGZIP(), []int{20}
+	return file_common_types_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *MLSettings) GetSoundAnalysisEnabled() bool {
@@ -1955,6 +2041,83 @@ func (x *MLSettings) GetSimilarityThreshold() float32 {
 	return 0
 }
 
+func (x *MLSettings) GetSimilarityWeights() *SimilarityWeights {
+	if x != nil {
+		return x.SimilarityWeights
+	}
+	return nil
+}
+
+// Weights of the matches a similarity score combines. They are scaled to sum
+// to 1; all zero means the defaults (vibe 0.5, tempo 0.2, key 0.2, energy 0.1).
+type SimilarityWeights struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Vibe          float64                `protobuf:"fixed64,1,opt,name=vibe,proto3" json:"vibe,omitempty"`     // OpenL3 embedding similarity
+	Tempo         float64                `protobuf:"fixed64,2,opt,name=tempo,proto3" json:"tempo,omitempty"`   // BPM compatibility
+	Key           float64                `protobuf:"fixed64,3,opt,name=key,proto3" json:"key,omitempty"`       // Key compatibility
+	Energy        float64                `protobuf:"fixed64,4,opt,name=energy,proto3" json:"energy,omitempty"` // Energy level similarity
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimilarityWeights) Reset() {
+	*x = SimilarityWeights{}
+	mi := &file_common_types_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimilarityWeights) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimilarityWeights) ProtoMessage() {}
+
+func (x *SimilarityWeights) ProtoReflect() protoreflect.Message {
+	mi := &file_common_types_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimilarityWeights.ProtoReflect.Descriptor instead.
+func (*SimilarityWeights) Descriptor() ([]byte, []int) {
+	return file_common_types_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SimilarityWeights) GetVibe() float64 {
+	if x != nil {
+		return x.Vibe
+	}
+	return 0
+}
+
+func (x *SimilarityWeights) GetTempo() float64 {
+	if x != nil {
+		return x.Tempo
+	}
+	return 0
+}
+
+func (x *SimilarityWeights) GetKey() float64 {
+	if x != nil {
+		return x.Key
+	}
+	return 0
+}
+
+func (x *SimilarityWeights) GetEnergy() float64 {
+	if x != nil {
+		return x.Energy
+	}
+	return 0
+}
+
 type TrackAnalysis struct {
 	state                  protoimpl.MessageState `protogen:"open.v1"`
 	Id                     *TrackId               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -1980,7 +2143,7 @@ type TrackAnalysis struct {
 
 func (x *TrackAnalysis) Reset() {
 	*x = TrackAnalysis{}
-	mi := &file_common_types_proto_msgTypes[21]
+	mi := &file_common_types_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1992,7 +2155,7 @@ func (x *TrackAnalysis) String() string {
 func (*TrackAnalysis) ProtoMessage() {}
 
 func (x *TrackAnalysis) ProtoReflect() protoreflect.Message {
-	mi := &file_common_types_proto_msgTypes[21]
+	mi := &file_common_types_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2005,7 +2168,7 @@ func (x *TrackAnalysis) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrackAnalysis.ProtoReflect.Descriptor instead.
 func (*TrackAnalysis) Descriptor() ([]byte, []int) {
-	return file_common_types_proto_rawDescGZIP(), []int{21}
+	return file_common_types_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *TrackAnalysis) GetId() *TrackId {
@@ -2143,7 +2306,7 @@ type TrackSummary struct {
 
 func (x *TrackSummary) Reset() {
 	*x = TrackSummary{}
-	mi := &file_common_types_proto_msgTypes[22]
+	mi := &file_common_types_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2155,7 +2318,7 @@ func (x *TrackSummary) String() string {
 func (*TrackSummary) ProtoMessage() {}
 
 func (x *TrackSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_common_types_proto_msgTypes[22]
+	mi := &file_common_types_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2168,7 +2331,7 @@ func (x *TrackSummary) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrackSummary.ProtoReflect.Descriptor instead.
 func (*TrackSummary) Descriptor() ([]byte, []int) {
-	return file_common_types_proto_rawDescGZIP(), []int{22}
+	return file_common_types_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *TrackSummary) GetId() *TrackId {
@@ -2237,14 +2400,16 @@ type EdgeExplanation struct {
 	EnergyDelta   int32                  `protobuf:"varint,6,opt,name=energy_delta,json=energyDelta,proto3" json:"energy_delta,omitempty"`
 	KeyRelation   string                 `protobuf:"bytes,7,opt,name=key_relation,json=keyRelation,proto3" json:"key_relation,omitempty"` // e.g., "same key", "+1 Camelot"
 	WindowOverlap string                 `protobuf:"bytes,8,opt,name=window_overlap,json=windowOverlap,proto3" json:"window_overlap,omitempty"`
-	VibeMatch     float32                `protobuf:"fixed32,9,opt,name=vibe_match,json=vibeMatch,proto3" json:"vibe_match,omitempty"` // OpenL3 cosine similarity %
+	VibeMatch     float32                `protobuf:"fixed32,9,opt,name=vibe_match,json=vibeMatch,proto3" json:"vibe_match,omitempty"`  // OpenL3 cosine similarity %
+	AtSeconds     float64                `protobuf:"fixed64,10,opt,name=at_seconds,json=atSeconds,proto3" json:"at_seconds,omitempty"` // estimated running time when the incoming track starts
+	Suggestion    *TransitionSuggestion  `protobuf:"bytes,11,opt,name=suggestion,proto3" json:"suggestion,omitempty"`                  // best place to mix, when both tracks have a tempo
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *EdgeExplanation) Reset() {
 	*x = EdgeExplanation{}
-	mi := &file_common_types_proto_msgTypes[23]
+	mi := &file_common_types_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2256,7 +2421,7 @@ func (x *EdgeExplanation) String() string {
 func (*EdgeExplanation) ProtoMessage() {}
 
 func (x *EdgeExplanation) ProtoReflect() protoreflect.Message {
-	mi := &file_common_types_proto_msgTypes[23]
+	mi := &file_common_types_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2269,7 +2434,7 @@ func (x *EdgeExplanation) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EdgeExplanation.ProtoReflect.Descriptor instead.
 func (*EdgeExplanation) Descriptor() ([]byte, []int) {
-	return file_common_types_proto_rawDescGZIP(), []int{23}
+	return file_common_types_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *EdgeExplanation) GetFrom() *TrackId {
@@ -2335,6 +2500,145 @@ func (x *EdgeExplanation) GetVibeMatch() float32 {
 	return 0
 }
 
+func (x *EdgeExplanation) GetAtSeconds() float64 {
+	if x != nil {
+		return x.AtSeconds
+	}
+	return 0
+}
+
+func (x *EdgeExplanation) GetSuggestion() *TransitionSuggestion {
+	if x != nil {
+		return x.Suggestion
+	}
+	return nil
+}
+
+// A phrase-aligned place to mix from one track into the next.
+type TransitionSuggestion struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OutBeat        int32                  `protobuf:"varint,1,opt,name=out_beat,json=outBeat,proto3" json:"out_beat,omitempty"`                // outgoing beat where the incoming track comes in
+	InBeat         int32                  `protobuf:"varint,2,opt,name=in_beat,json=inBeat,proto3" json:"in_beat,omitempty"`                   // incoming beat that lands on out_beat
+	OverlapBeats   int32                  `protobuf:"varint,3,opt,name=overlap_beats,json=overlapBeats,proto3" json:"overlap_beats,omitempty"` // beats of the outgoing track both play for
+	OverlapSeconds float64                `protobuf:"fixed64,4,opt,name=overlap_seconds,json=overlapSeconds,proto3" json:"overlap_seconds,omitempty"`
+	OutSeconds     float64                `protobuf:"fixed64,5,opt,name=out_seconds,json=outSeconds,proto3" json:"out_seconds,omitempty"`
+	InSeconds      float64                `protobuf:"fixed64,6,opt,name=in_seconds,json=inSeconds,proto3" json:"in_seconds,omitempty"`
+	EnergyChange   int32                  `protobuf:"varint,7,opt,name=energy_change,json=energyChange,proto3" json:"energy_change,omitempty"` // incoming energy after the mix minus outgoing energy before it
+	Score          float32                `protobuf:"fixed32,8,opt,name=score,proto3" json:"score,omitempty"`
+	OutLabel       string                 `protobuf:"bytes,9,opt,name=out_label,json=outLabel,proto3" json:"out_label,omitempty"` // what's at the mix-out point, e.g. "outro"
+	InLabel        string                 `protobuf:"bytes,10,opt,name=in_label,json=inLabel,proto3" json:"in_label,omitempty"`   // what's at the mix-in point, e.g. "intro"
+	Explanation    string                 `protobuf:"bytes,11,opt,name=explanation,proto3" json:"explanation,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TransitionSuggestion) Reset() {
+	*x = TransitionSuggestion{}
+	mi := &file_common_types_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransitionSuggestion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransitionSuggestion) ProtoMessage() {}
+
+func (x *TransitionSuggestion) ProtoReflect() protoreflect.Message {
+	mi := &file_common_types_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransitionSuggestion.ProtoReflect.Descriptor instead.
+func (*TransitionSuggestion) Descriptor() ([]byte, []int) {
+	return file_common_types_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *TransitionSuggestion) GetOutBeat() int32 {
+	if x != nil {
+		return x.OutBeat
+	}
+	return 0
+}
+
+func (x *TransitionSuggestion) GetInBeat() int32 {
+	if x != nil {
+		return x.InBeat
+	}
+	return 0
+}
+
+func (x *TransitionSuggestion) GetOverlapBeats() int32 {
+	if x != nil {
+		return x.OverlapBeats
+	}
+	return 0
+}
+
+func (x *TransitionSuggestion) GetOverlapSeconds() float64 {
+	if x != nil {
+		return x.OverlapSeconds
+	}
+	return 0
+}
+
+func (x *TransitionSuggestion) GetOutSeconds() float64 {
+	if x != nil {
+		return x.OutSeconds
+	}
+	return 0
+}
+
+func (x *TransitionSuggestion) GetInSeconds() float64 {
+	if x != nil {
+		return x.InSeconds
+	}
+	return 0
+}
+
+func (x *TransitionSuggestion) GetEnergyChange() int32 {
+	if x != nil {
+		return x.EnergyChange
+	}
+	return 0
+}
+
+func (x *TransitionSuggestion) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *TransitionSuggestion) GetOutLabel() string {
+	if x != nil {
+		return x.OutLabel
+	}
+	return ""
+}
+
+func (x *TransitionSuggestion) GetInLabel() string {
+	if x != nil {
+		return x.InLabel
+	}
+	return ""
+}
+
+func (x *TransitionSuggestion) GetExplanation() string {
+	if x != nil {
+		return x.Explanation
+	}
+	return ""
+}
+
 var File_common_types_proto protoreflect.FileDescriptor
 
 const file_common_types_proto_rawDesc = "" +
@@ -2432,7 +2736,7 @@ const file_common_types_proto_rawDesc = "" +
 	"\x04type\x18\x01 \x01(\tR\x04type\x12\x16\n" +
 	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x1a\n" +
 	"\bseverity\x18\x03 \x01(\x05R\bseverity\x12\x1c\n" +
-	"\tdismissed\x18\x04 \x01(\bR\tdismissed\"\xde\x02\n" +
+	"\tdismissed\x18\x04 \x01(\bR\tdismissed\"\xed\x03\n" +
 	"\fSimilarTrack\x12(\n" +
 	"\x02id\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
@@ -2447,7 +2751,15 @@ const file_common_types_proto_rawDesc = "" +
 	"\fenergy_match\x18\t \x01(\x02R\venergyMatch\x12\x1b\n" +
 	"\tbpm_delta\x18\n" +
 	" \x01(\x02R\bbpmDelta\x12!\n" +
-	"\fkey_relation\x18\v \x01(\tR\vkeyRelation\"\x87\x03\n" +
+	"\fkey_relation\x18\v \x01(\tR\vkeyRelation\x12'\n" +
+	"\x0fduplicate_count\x18\f \x01(\x05R\x0eduplicateCount\x12,\n" +
+	"\x04seed\x18\r \x01(\v2\x18.cartomix.common.TrackIdR\x04seed\x126\n" +
+	"\asection\x18\x0e \x01(\v2\x1c.cartomix.common.SectionSpanR\asection\"\x88\x01\n" +
+	"\vSectionSpan\x123\n" +
+	"\x05label\x18\x01 \x01(\x0e2\x1d.cartomix.common.SectionLabelR\x05label\x12#\n" +
+	"\rstart_seconds\x18\x02 \x01(\x01R\fstartSeconds\x12\x1f\n" +
+	"\vend_seconds\x18\x03 \x01(\x01R\n" +
+	"endSeconds\"\x87\x03\n" +
 	"\rTrainingLabel\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x19\n" +
 	"\btrack_id\x18\x02 \x01(\x03R\atrackId\x12!\n" +
@@ -2511,14 +2823,20 @@ const file_common_types_proto_rawDesc = "" +
 	"\x14min_samples_required\x18\x06 \x01(\x05R\x12minSamplesRequired\x1a>\n" +
 	"\x10LabelCountsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\x82\x02\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\xd5\x02\n" +
 	"\n" +
 	"MLSettings\x124\n" +
 	"\x16sound_analysis_enabled\x18\x01 \x01(\bR\x14soundAnalysisEnabled\x12%\n" +
 	"\x0eopenl3_enabled\x18\x02 \x01(\bR\ropenl3Enabled\x127\n" +
 	"\x18dj_section_model_enabled\x18\x03 \x01(\bR\x15djSectionModelEnabled\x12+\n" +
 	"\x11show_explanations\x18\x04 \x01(\bR\x10showExplanations\x121\n" +
-	"\x14similarity_threshold\x18\x05 \x01(\x02R\x13similarityThreshold\"\xa1\a\n" +
+	"\x14similarity_threshold\x18\x05 \x01(\x02R\x13similarityThreshold\x12Q\n" +
+	"\x12similarity_weights\x18\x06 \x01(\v2\".cartomix.common.SimilarityWeightsR\x11similarityWeights\"g\n" +
+	"\x11SimilarityWeights\x12\x12\n" +
+	"\x04vibe\x18\x01 \x01(\x01R\x04vibe\x12\x14\n" +
+	"\x05tempo\x18\x02 \x01(\x01R\x05tempo\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\x01R\x03key\x12\x16\n" +
+	"\x06energy\x18\x04 \x01(\x01R\x06energy\"\xa1\a\n" +
 	"\rTrackAnalysis\x12(\n" +
 	"\x02id\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\x02id\x12)\n" +
 	"\x10duration_seconds\x18\x02 \x01(\x01R\x0fdurationSeconds\x125\n" +
@@ -2548,7 +2866,7 @@ const file_common_types_proto_rawDesc = "" +
 	"\x03key\x18\x05 \x01(\v2\x1b.cartomix.common.MusicalKeyR\x03key\x12\x16\n" +
 	"\x06energy\x18\x06 \x01(\x05R\x06energy\x12\x1b\n" +
 	"\tcue_count\x18\a \x01(\x05R\bcueCount\x12\x16\n" +
-	"\x06status\x18\b \x01(\tR\x06status\"\xc4\x02\n" +
+	"\x06status\x18\b \x01(\tR\x06status\"\xaa\x03\n" +
 	"\x0fEdgeExplanation\x12,\n" +
 	"\x04from\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\x04from\x12(\n" +
 	"\x02to\x18\x02 \x01(\v2\x18.cartomix.common.TrackIdR\x02to\x12\x14\n" +
@@ -2560,7 +2878,28 @@ const file_common_types_proto_rawDesc = "" +
 	"\fkey_relation\x18\a \x01(\tR\vkeyRelation\x12%\n" +
 	"\x0ewindow_overlap\x18\b \x01(\tR\rwindowOverlap\x12\x1d\n" +
 	"\n" +
-	"vibe_match\x18\t \x01(\x02R\tvibeMatch*r\n" +
+	"vibe_match\x18\t \x01(\x02R\tvibeMatch\x12\x1d\n" +
+	"\n" +
+	"at_seconds\x18\n" +
+	" \x01(\x01R\tatSeconds\x12E\n" +
+	"\n" +
+	"suggestion\x18\v \x01(\v2%.cartomix.common.TransitionSuggestionR\n" +
+	"suggestion\"\xed\x02\n" +
+	"\x14TransitionSuggestion\x12\x19\n" +
+	"\bout_beat\x18\x01 \x01(\x05R\aoutBeat\x12\x17\n" +
+	"\ain_beat\x18\x02 \x01(\x05R\x06inBeat\x12#\n" +
+	"\roverlap_beats\x18\x03 \x01(\x05R\foverlapBeats\x12'\n" +
+	"\x0foverlap_seconds\x18\x04 \x01(\x01R\x0eoverlapSeconds\x12\x1f\n" +
+	"\vout_seconds\x18\x05 \x01(\x01R\n" +
+	"outSeconds\x12\x1d\n" +
+	"\n" +
+	"in_seconds\x18\x06 \x01(\x01R\tinSeconds\x12#\n" +
+	"\renergy_change\x18\a \x01(\x05R\fenergyChange\x12\x14\n" +
+	"\x05score\x18\b \x01(\x02R\x05score\x12\x1b\n" +
+	"\tout_label\x18\t \x01(\tR\boutLabel\x12\x19\n" +
+	"\bin_label\x18\n" +
+	" \x01(\tR\ainLabel\x12 \n" +
+	"\vexplanation\x18\v \x01(\tR\vexplanation*r\n" +
 	"\fSectionLabel\x12\x1d\n" +
 	"\x19SECTION_LABEL_UNSPECIFIED\x10\x00\x12\t\n" +
 	"\x05INTRO\x10\x01\x12\t\n" +
@@ -2616,46 +2955,49 @@ func file_common_types_proto_rawDescGZIP() []byte {
 }
 
 var file_common_types_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
-var file_common_types_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
+var file_common_types_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
 var file_common_types_proto_goTypes = []any{
-	(SectionLabel)(0),           // 0: cartomix.common.SectionLabel
-	(CueType)(0),                // 1: cartomix.common.CueType
-	(KeyFormat)(0),              // 2: cartomix.common.KeyFormat
-	(DJSectionLabel)(0),         // 3: cartomix.common.DJSectionLabel
-	(TrainingStatus)(0),         // 4: cartomix.common.TrainingStatus
-	(*TrackId)(nil),             // 5: cartomix.common.TrackId
-	(*BeatMarker)(nil),          // 6: cartomix.common.BeatMarker
-	(*Section)(nil),             // 7: cartomix.common.Section
-	(*CuePoint)(nil),            // 8: cartomix.common.CuePoint
-	(*TransitionWindow)(nil),    // 9: cartomix.common.TransitionWindow
-	(*MusicalKey)(nil),          // 10: cartomix.common.MusicalKey
-	(*EnergySegment)(nil),       // 11: cartomix.common.EnergySegment
-	(*WaveformTile)(nil),        // 12: cartomix.common.WaveformTile
-	(*TempoMapNode)(nil),        // 13: cartomix.common.TempoMapNode
-	(*Beatgrid)(nil),            // 14: cartomix.common.Beatgrid
-	(*Loudness)(nil),            // 15: cartomix.common.Loudness
-	(*OpenL3Embedding)(nil),     // 16: cartomix.common.OpenL3Embedding
-	(*SoundClassification)(nil), // 17: cartomix.common.SoundClassification
-	(*SoundEvent)(nil),          // 18: cartomix.common.SoundEvent
-	(*QAFlag)(nil),              // 19: cartomix.common.QAFlag
-	(*SimilarTrack)(nil),        // 20: cartomix.common.SimilarTrack
-	(*TrainingLabel)(nil),       // 21: cartomix.common.TrainingLabel
-	(*TrainingJob)(nil),         // 22: cartomix.common.TrainingJob
-	(*ModelVersion)(nil),        // 23: cartomix.common.ModelVersion
-	(*TrainingLabelStats)(nil),  // 24: cartomix.common.TrainingLabelStats
-	(*MLSettings)(nil),          // 25: cartomix.common.MLSettings
-	(*TrackAnalysis)(nil),       // 26: cartomix.common.TrackAnalysis
-	(*TrackSummary)(nil),        // 27: cartomix.common.TrackSummary
-	(*EdgeExplanation)(nil),     // 28: cartomix.common.EdgeExplanation
-	nil,                         // 29: cartomix.common.TrainingJob.LabelCountsEntry
-	nil,                         // 30: cartomix.common.ModelVersion.LabelCountsEntry
-	nil,                         // 31: cartomix.common.TrainingLabelStats.LabelCountsEntry
-	(*durationpb.Duration)(nil), // 32: google.protobuf.Duration
+	(SectionLabel)(0),            // 0: cartomix.common.SectionLabel
+	(CueType)(0),                 // 1: cartomix.common.CueType
+	(KeyFormat)(0),               // 2: cartomix.common.KeyFormat
+	(DJSectionLabel)(0),          // 3: cartomix.common.DJSectionLabel
+	(TrainingStatus)(0),          // 4: cartomix.common.TrainingStatus
+	(*TrackId)(nil),              // 5: cartomix.common.TrackId
+	(*BeatMarker)(nil),           // 6: cartomix.common.BeatMarker
+	(*Section)(nil),              // 7: cartomix.common.Section
+	(*CuePoint)(nil),             // 8: cartomix.common.CuePoint
+	(*TransitionWindow)(nil),     // 9: cartomix.common.TransitionWindow
+	(*MusicalKey)(nil),           // 10: cartomix.common.MusicalKey
+	(*EnergySegment)(nil),        // 11: cartomix.common.EnergySegment
+	(*WaveformTile)(nil),         // 12: cartomix.common.WaveformTile
+	(*TempoMapNode)(nil),         // 13: cartomix.common.TempoMapNode
+	(*Beatgrid)(nil),             // 14: cartomix.common.Beatgrid
+	(*Loudness)(nil),             // 15: cartomix.common.Loudness
+	(*OpenL3Embedding)(nil),      // 16: cartomix.common.OpenL3Embedding
+	(*SoundClassification)(nil),  // 17: cartomix.common.SoundClassification
+	(*SoundEvent)(nil),           // 18: cartomix.common.SoundEvent
+	(*QAFlag)(nil),               // 19: cartomix.common.QAFlag
+	(*SimilarTrack)(nil),         // 20: cartomix.common.SimilarTrack
+	(*SectionSpan)(nil),          // 21: cartomix.common.SectionSpan
+	(*TrainingLabel)(nil),        // 22: cartomix.common.TrainingLabel
+	(*TrainingJob)(nil),          // 23: cartomix.common.TrainingJob
+	(*ModelVersion)(nil),         // 24: cartomix.common.ModelVersion
+	(*TrainingLabelStats)(nil),   // 25: cartomix.common.TrainingLabelStats
+	(*MLSettings)(nil),           // 26: cartomix.common.MLSettings
+	(*SimilarityWeights)(nil),    // 27: cartomix.common.SimilarityWeights
+	(*TrackAnalysis)(nil),        // 28: cartomix.common.TrackAnalysis
+	(*TrackSummary)(nil),         // 29: cartomix.common.TrackSummary
+	(*EdgeExplanation)(nil),      // 30: cartomix.common.EdgeExplanation
+	(*TransitionSuggestion)(nil), // 31: cartomix.common.TransitionSuggestion
+	nil,                          // 32: cartomix.common.TrainingJob.LabelCountsEntry
+	nil,                          // 33: cartomix.common.ModelVersion.LabelCountsEntry
+	nil,                          // 34: cartomix.common.TrainingLabelStats.LabelCountsEntry
+	(*durationpb.Duration)(nil),  // 35: google.protobuf.Duration
 }
 var file_common_types_proto_depIdxs = []int32{
-	32, // 0: cartomix.common.BeatMarker.time:type_name -> google.protobuf.Duration
+	35, // 0: cartomix.common.BeatMarker.time:type_name -> google.protobuf.Duration
 	0,  // 1: cartomix.common.Section.label:type_name -> cartomix.common.SectionLabel
-	32, // 2: cartomix.common.CuePoint.time:type_name -> google.protobuf.Duration
+	35, // 2: cartomix.common.CuePoint.time:type_name -> google.protobuf.Duration
 	1,  // 3: cartomix.common.CuePoint.type:type_name -> cartomix.common.CueType
 	2,  // 4: cartomix.common.MusicalKey.format:type_name -> cartomix.common.KeyFormat
 	6,  // 5: cartomix.common.Beatgrid.beats:type_name -> cartomix.common.BeatMarker
@@ -2663,30 +3005,35 @@ var file_common_types_proto_depIdxs = []int32{
 	18, // 7: cartomix.common.SoundClassification.events:type_name -> cartomix.common.SoundEvent
 	19, // 8: cartomix.common.SoundClassification.qa_flags:type_name -> cartomix.common.QAFlag
 	5,  // 9: cartomix.common.SimilarTrack.id:type_name -> cartomix.common.TrackId
-	3,  // 10: cartomix.common.TrainingLabel.label_value:type_name -> cartomix.common.DJSectionLabel
-	4,  // 11: cartomix.common.TrainingJob.status:type_name -> cartomix.common.TrainingStatus
-	29, // 12: cartomix.common.TrainingJob.label_counts:type_name -> cartomix.common.TrainingJob.LabelCountsEntry
-	30, // 13: cartomix.common.ModelVersion.label_counts:type_name -> cartomix.common.ModelVersion.LabelCountsEntry
-	31, // 14: cartomix.common.TrainingLabelStats.label_counts:type_name -> cartomix.common.TrainingLabelStats.LabelCountsEntry
-	5,  // 15: cartomix.common.TrackAnalysis.id:type_name -> cartomix.common.TrackId
-	14, // 16: cartomix.common.TrackAnalysis.beatgrid:type_name -> cartomix.common.Beatgrid
-	10, // 17: cartomix.common.TrackAnalysis.key:type_name -> cartomix.common.MusicalKey
-	11, // 18: cartomix.common.TrackAnalysis.energy_segments:type_name -> cartomix.common.EnergySegment
-	7,  // 19: cartomix.common.TrackAnalysis.sections:type_name -> cartomix.common.Section
-	8,  // 20: cartomix.common.TrackAnalysis.cue_points:type_name -> cartomix.common.CuePoint
-	9,  // 21: cartomix.common.TrackAnalysis.transition_windows:type_name -> cartomix.common.TransitionWindow
-	15, // 22: cartomix.common.TrackAnalysis.loudness:type_name -> cartomix.common.Loudness
-	16, // 23: cartomix.common.TrackAnalysis.openl3_embedding:type_name -> cartomix.common.OpenL3Embedding
-	17, // 24: cartomix.common.TrackAnalysis.sound_classification:type_name -> cartomix.common.SoundClassification
-	5,  // 25: cartomix.common.TrackSummary.id:type_name -> cartomix.common.TrackId
-	10, // 26: cartomix.common.TrackSummary.key:type_name -> cartomix.common.MusicalKey
-	5,  // 27: cartomix.common.EdgeExplanation.from:type_name -> cartomix.common.TrackId
-	5,  // 28: cartomix.common.EdgeExplanation.to:type_name -> cartomix.common.TrackId
-	29, // [29:29] is the sub-list for method output_type
-	29, // [29:29] is the sub-list for method input_type
-	29, // [29:29] is the sub-list for extension type_name
-	29, // [29:29] is the sub-list for extension extendee
-	0,  // [0:29] is the sub-list for field type_name
+	5,  // 10: cartomix.common.SimilarTrack.seed:type_name -> cartomix.common.TrackId
+	21, // 11: cartomix.common.SimilarTrack.section:type_name -> cartomix.common.SectionSpan
+	0,  // 12: cartomix.common.SectionSpan.label:type_name -> cartomix.common.SectionLabel
+	3,  // 13: cartomix.common.TrainingLabel.label_value:type_name -> cartomix.common.DJSectionLabel
+	4,  // 14: cartomix.common.TrainingJob.status:type_name -> cartomix.common.TrainingStatus
+	32, // 15: cartomix.common.TrainingJob.label_counts:type_name -> cartomix.common.TrainingJob.LabelCountsEntry
+	33, // 16: cartomix.common.ModelVersion.label_counts:type_name -> cartomix.common.ModelVersion.LabelCountsEntry
+	34, // 17: cartomix.common.TrainingLabelStats.label_counts:type_name -> cartomix.common.TrainingLabelStats.LabelCountsEntry
+	27, // 18: cartomix.common.MLSettings.similarity_weights:type_name -> cartomix.common.SimilarityWeights
+	5,  // 19: cartomix.common.TrackAnalysis.id:type_name -> cartomix.common.TrackId
+	14, // 20: cartomix.common.TrackAnalysis.beatgrid:type_name -> cartomix.common.Beatgrid
+	10, // 21: cartomix.common.TrackAnalysis.key:type_name -> cartomix.common.MusicalKey
+	11, // 22: cartomix.common.TrackAnalysis.energy_segments:type_name -> cartomix.common.EnergySegment
+	7,  // 23: cartomix.common.TrackAnalysis.sections:type_name -> cartomix.common.Section
+	8,  // 24: cartomix.common.TrackAnalysis.cue_points:type_name -> cartomix.common.CuePoint
+	9,  // 25: cartomix.common.TrackAnalysis.transition_windows:type_name -> cartomix.common.TransitionWindow
+	15, // 26: cartomix.common.TrackAnalysis.loudness:type_name -> cartomix.common.Loudness
+	16, // 27: cartomix.common.TrackAnalysis.openl3_embedding:type_name -> cartomix.common.OpenL3Embedding
+	17, // 28: cartomix.common.TrackAnalysis.sound_classification:type_name -> cartomix.common.SoundClassification
+	5,  // 29: cartomix.common.TrackSummary.id:type_name -> cartomix.common.TrackId
+	10, // 30: cartomix.common.TrackSummary.key:type_name -> cartomix.common.MusicalKey
+	5,  // 31: cartomix.common.EdgeExplanation.from:type_name -> cartomix.common.TrackId
+	5,  // 32: cartomix.common.EdgeExplanation.to:type_name -> cartomix.common.TrackId
+	31, // 33: cartomix.common.EdgeExplanation.suggestion:type_name -> cartomix.common.TransitionSuggestion
+	34, // [34:34] is the sub-list for method output_type
+	34, // [34:34] is the sub-list for method input_type
+	34, // [34:34] is the sub-list for extension type_name
+	34, // [34:34] is the sub-list for extension extendee
+	0,  // [0:34] is the sub-list for field type_name
 }
 
 func init() { file_common_types_proto_init() }
@@ -2700,7 +3047,7 @@ func file_common_types_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_common_types_proto_rawDesc), len(file_common_types_proto_rawDesc)),
 			NumEnums:      5,
-			NumMessages:   27,
+			NumMessages:   30,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
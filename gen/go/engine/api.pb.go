@@ -76,6 +76,53 @@ func (SetMode) EnumDescriptor() ([]byte, []int) {
 	return file_engine_api_proto_rawDescGZIP(), []int{0}
 }
 
+// SeedAggregation is how a query with several seeds scores a candidate.
+type SeedAggregation int32
+
+const (
+	SeedAggregation_SEED_AGGREGATION_CENTROID SeedAggregation = 0 // vibe against the seeds' average embedding
+	SeedAggregation_SEED_AGGREGATION_MAX      SeedAggregation = 1 // best match to any one seed
+)
+
+// Enum value maps for SeedAggregation.
+var (
+	SeedAggregation_name = map[int32]string{
+		0: "SEED_AGGREGATION_CENTROID",
+		1: "SEED_AGGREGATION_MAX",
+	}
+	SeedAggregation_value = map[string]int32{
+		"SEED_AGGREGATION_CENTROID": 0,
+		"SEED_AGGREGATION_MAX":      1,
+	}
+)
+
+func (x SeedAggregation) Enum() *SeedAggregation {
+	p := new(SeedAggregation)
+	*p = x
+	return p
+}
+
+func (x SeedAggregation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SeedAggregation) Descriptor() protoreflect.EnumDescriptor {
+	return file_engine_api_proto_enumTypes[1].Descriptor()
+}
+
+func (SeedAggregation) Type() protoreflect.EnumType {
+	return &file_engine_api_proto_enumTypes[1]
+}
+
+func (x SeedAggregation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SeedAggregation.Descriptor instead.
+func (SeedAggregation) EnumDescriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{1}
+}
+
 type ScanRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Roots         []string               `protobuf:"bytes,1,rep,name=roots,proto3" json:"roots,omitempty"` // folders or DJ export roots
@@ -131,7 +178,7 @@ func (x *ScanRequest) GetForceRescan() bool {
 type ScanProgress struct {
 	state     protoimpl.MessageState `protogen:"open.v1"`
 	Path      string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	Status    string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // queued / analyzing / done / skipped / error
+	Status    string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"` // queued / analyzing / done / updated / moved / changed / collision / skipped / error
 	Error     string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 	Processed int64                  `protobuf:"varint,4,opt,name=processed,proto3" json:"processed,omitempty"`
 	Total     int64                  `protobuf:"varint,5,opt,name=total,proto3" json:"total,omitempty"`
@@ -144,8 +191,12 @@ type ScanProgress struct {
 	SkippedCached  int64   `protobuf:"varint,11,opt,name=skipped_cached,json=skippedCached,proto3" json:"skipped_cached,omitempty"`      // Count of tracks skipped (already in DB)
 	BytesProcessed int64   `protobuf:"varint,12,opt,name=bytes_processed,json=bytesProcessed,proto3" json:"bytes_processed,omitempty"`   // Total bytes processed so far
 	BytesTotal     int64   `protobuf:"varint,13,opt,name=bytes_total,json=bytesTotal,proto3" json:"bytes_total,omitempty"`               // Total bytes to process (if known)
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// Content identity
+	ContentHash   string `protobuf:"bytes,14,opt,name=content_hash,json=contentHash,proto3" json:"content_hash,omitempty"`    // Audio payload hash (tags excluded)
+	CollidesWith  string `protobuf:"bytes,15,opt,name=collides_with,json=collidesWith,proto3" json:"collides_with,omitempty"` // With status "collision": path of the track owning this audio
+	Collisions    int64  `protobuf:"varint,16,opt,name=collisions,proto3" json:"collisions,omitempty"`                        // Count of collisions so far
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ScanProgress) Reset() {
@@ -269,6 +320,27 @@ func (x *ScanProgress) GetBytesTotal() int64 {
 	return 0
 }
 
+func (x *ScanProgress) GetContentHash() string {
+	if x != nil {
+		return x.ContentHash
+	}
+	return ""
+}
+
+func (x *ScanProgress) GetCollidesWith() string {
+	if x != nil {
+		return x.CollidesWith
+	}
+	return ""
+}
+
+func (x *ScanProgress) GetCollisions() int64 {
+	if x != nil {
+		return x.Collisions
+	}
+	return 0
+}
+
 type AnalyzeRequest struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
 	Paths           []string               `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
@@ -685,15 +757,35 @@ func (x *GetTrackRequest) GetId() *common.TrackId {
 }
 
 type SetPlanRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TrackIds      []*common.TrackId      `protobuf:"bytes,1,rep,name=track_ids,json=trackIds,proto3" json:"track_ids,omitempty"`
-	Mode          SetMode                `protobuf:"varint,2,opt,name=mode,proto3,enum=cartomix.engine.SetMode" json:"mode,omitempty"`
-	AllowKeyJumps bool                   `protobuf:"varint,3,opt,name=allow_key_jumps,json=allowKeyJumps,proto3" json:"allow_key_jumps,omitempty"`
-	MaxBpmStep    float64                `protobuf:"fixed64,4,opt,name=max_bpm_step,json=maxBpmStep,proto3" json:"max_bpm_step,omitempty"`
-	MustPlay      []*common.TrackId      `protobuf:"bytes,5,rep,name=must_play,json=mustPlay,proto3" json:"must_play,omitempty"`
-	Ban           []*common.TrackId      `protobuf:"bytes,6,rep,name=ban,proto3" json:"ban,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	TrackIds           []*common.TrackId      `protobuf:"bytes,1,rep,name=track_ids,json=trackIds,proto3" json:"track_ids,omitempty"`
+	Mode               SetMode                `protobuf:"varint,2,opt,name=mode,proto3,enum=cartomix.engine.SetMode" json:"mode,omitempty"`
+	AllowKeyJumps      bool                   `protobuf:"varint,3,opt,name=allow_key_jumps,json=allowKeyJumps,proto3" json:"allow_key_jumps,omitempty"`
+	MaxBpmStep         float64                `protobuf:"fixed64,4,opt,name=max_bpm_step,json=maxBpmStep,proto3" json:"max_bpm_step,omitempty"`
+	MustPlay           []*common.TrackId      `protobuf:"bytes,5,rep,name=must_play,json=mustPlay,proto3" json:"must_play,omitempty"`
+	Ban                []*common.TrackId      `protobuf:"bytes,6,rep,name=ban,proto3" json:"ban,omitempty"`
+	CollapseDuplicates bool                   `protobuf:"varint,7,opt,name=collapse_duplicates,json=collapseDuplicates,proto3" json:"collapse_duplicates,omitempty"`                                                     // plan one copy per duplicate group
+	TimeBudgetMs       int32                  `protobuf:"varint,8,opt,name=time_budget_ms,json=timeBudgetMs,proto3" json:"time_budget_ms,omitempty"`                                                                     // optimizer search time (default 250)
+	EnergyCurve        *EnergyCurve           `protobuf:"bytes,9,opt,name=energy_curve,json=energyCurve,proto3" json:"energy_curve,omitempty"`                                                                           // optional target energy arc; frees the opener from mode
+	TargetMinutes      float64                `protobuf:"fixed64,10,opt,name=target_minutes,json=targetMinutes,proto3" json:"target_minutes,omitempty"`                                                                  // plan a subset that fits this length; every track when 0
+	ToleranceMinutes   float64                `protobuf:"fixed64,11,opt,name=tolerance_minutes,json=toleranceMinutes,proto3" json:"tolerance_minutes,omitempty"`                                                         // allowed over/under (default 5% of the target)
+	PlayFraction       float64                `protobuf:"fixed64,12,opt,name=play_fraction,json=playFraction,proto3" json:"play_fraction,omitempty"`                                                                     // share of each track expected to play (default 1)
+	KeyWeights         map[string]float64     `protobuf:"bytes,13,rep,name=key_weights,json=keyWeights,proto3" json:"key_weights,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"` // score per key move (same, relative, adjacent, diagonal,
+	// energy_boost, modulation, clash, missing, invalid)
+	PitchRange        float64          `protobuf:"fixed64,14,opt,name=pitch_range,json=pitchRange,proto3" json:"pitch_range,omitempty"`   // deck pitch range either way, percent (default 8)
+	MasterTempo       bool             `protobuf:"varint,15,opt,name=master_tempo,json=masterTempo,proto3" json:"master_tempo,omitempty"` // key lock on: pitching doesn't shift keys
+	VibeWeight        float64          `protobuf:"fixed64,16,opt,name=vibe_weight,json=vibeWeight,proto3" json:"vibe_weight,omitempty"`   // weight of the OpenL3 vibe term (default 3, negative turns it off)
+	Opener            *common.TrackId  `protobuf:"bytes,17,opt,name=opener,proto3" json:"opener,omitempty"`                               // must open the set
+	Closer            *common.TrackId  `protobuf:"bytes,18,opt,name=closer,proto3" json:"closer,omitempty"`                               // must close the set
+	LockedChains      []*LockedChain   `protobuf:"bytes,19,rep,name=locked_chains,json=lockedChains,proto3" json:"locked_chains,omitempty"`
+	Precedences       []*Precedence    `protobuf:"bytes,20,rep,name=precedences,proto3" json:"precedences,omitempty"`
+	ArtistSpacing     []*ArtistSpacing `protobuf:"bytes,21,rep,name=artist_spacing,json=artistSpacing,proto3" json:"artist_spacing,omitempty"`
+	Alternatives      int32            `protobuf:"varint,22,opt,name=alternatives,proto3" json:"alternatives,omitempty"`                                      // runner-up plans to return besides the best (at most 10)
+	MinDifferentEdges int32            `protobuf:"varint,23,opt,name=min_different_edges,json=minDifferentEdges,proto3" json:"min_different_edges,omitempty"` // transitions each alternative must not share with an earlier plan
+	// (default a quarter of the set's)
+	DifferentOpeners bool `protobuf:"varint,24,opt,name=different_openers,json=differentOpeners,proto3" json:"different_openers,omitempty"` // each alternative opens with a new track
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *SetPlanRequest) Reset() {
@@ -768,167 +860,155 @@ func (x *SetPlanRequest) GetBan() []*common.TrackId {
 	return nil
 }
 
-type SetPlanResponse struct {
-	state         protoimpl.MessageState    `protogen:"open.v1"`
-	Order         []*common.TrackId         `protobuf:"bytes,1,rep,name=order,proto3" json:"order,omitempty"`
-	Explanations  []*common.EdgeExplanation `protobuf:"bytes,2,rep,name=explanations,proto3" json:"explanations,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *SetPlanRequest) GetCollapseDuplicates() bool {
+	if x != nil {
+		return x.CollapseDuplicates
+	}
+	return false
 }
 
-func (x *SetPlanResponse) Reset() {
-	*x = SetPlanResponse{}
-	mi := &file_engine_api_proto_msgTypes[8]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *SetPlanRequest) GetTimeBudgetMs() int32 {
+	if x != nil {
+		return x.TimeBudgetMs
+	}
+	return 0
 }
 
-func (x *SetPlanResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *SetPlanRequest) GetEnergyCurve() *EnergyCurve {
+	if x != nil {
+		return x.EnergyCurve
+	}
+	return nil
 }
 
-func (*SetPlanResponse) ProtoMessage() {}
-
-func (x *SetPlanResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[8]
+func (x *SetPlanRequest) GetTargetMinutes() float64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.TargetMinutes
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use SetPlanResponse.ProtoReflect.Descriptor instead.
-func (*SetPlanResponse) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{8}
+func (x *SetPlanRequest) GetToleranceMinutes() float64 {
+	if x != nil {
+		return x.ToleranceMinutes
+	}
+	return 0
 }
 
-func (x *SetPlanResponse) GetOrder() []*common.TrackId {
+func (x *SetPlanRequest) GetPlayFraction() float64 {
 	if x != nil {
-		return x.Order
+		return x.PlayFraction
 	}
-	return nil
+	return 0
 }
 
-func (x *SetPlanResponse) GetExplanations() []*common.EdgeExplanation {
+func (x *SetPlanRequest) GetKeyWeights() map[string]float64 {
 	if x != nil {
-		return x.Explanations
+		return x.KeyWeights
 	}
 	return nil
 }
 
-type ExportRequest struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	TrackIds         []*common.TrackId      `protobuf:"bytes,1,rep,name=track_ids,json=trackIds,proto3" json:"track_ids,omitempty"`
-	OutputDir        string                 `protobuf:"bytes,2,opt,name=output_dir,json=outputDir,proto3" json:"output_dir,omitempty"` // e.g., ./exports/set001
-	PlaylistName     string                 `protobuf:"bytes,3,opt,name=playlist_name,json=playlistName,proto3" json:"playlist_name,omitempty"`
-	IncludeRekordbox bool                   `protobuf:"varint,4,opt,name=include_rekordbox,json=includeRekordbox,proto3" json:"include_rekordbox,omitempty"`
-	IncludeSerato    bool                   `protobuf:"varint,5,opt,name=include_serato,json=includeSerato,proto3" json:"include_serato,omitempty"`
-	IncludeTraktor   bool                   `protobuf:"varint,6,opt,name=include_traktor,json=includeTraktor,proto3" json:"include_traktor,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *SetPlanRequest) GetPitchRange() float64 {
+	if x != nil {
+		return x.PitchRange
+	}
+	return 0
 }
 
-func (x *ExportRequest) Reset() {
-	*x = ExportRequest{}
-	mi := &file_engine_api_proto_msgTypes[9]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *SetPlanRequest) GetMasterTempo() bool {
+	if x != nil {
+		return x.MasterTempo
+	}
+	return false
 }
 
-func (x *ExportRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *SetPlanRequest) GetVibeWeight() float64 {
+	if x != nil {
+		return x.VibeWeight
+	}
+	return 0
 }
 
-func (*ExportRequest) ProtoMessage() {}
-
-func (x *ExportRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[9]
+func (x *SetPlanRequest) GetOpener() *common.TrackId {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Opener
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
-func (*ExportRequest) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{9}
+func (x *SetPlanRequest) GetCloser() *common.TrackId {
+	if x != nil {
+		return x.Closer
+	}
+	return nil
 }
 
-func (x *ExportRequest) GetTrackIds() []*common.TrackId {
+func (x *SetPlanRequest) GetLockedChains() []*LockedChain {
 	if x != nil {
-		return x.TrackIds
+		return x.LockedChains
 	}
 	return nil
 }
 
-func (x *ExportRequest) GetOutputDir() string {
+func (x *SetPlanRequest) GetPrecedences() []*Precedence {
 	if x != nil {
-		return x.OutputDir
+		return x.Precedences
 	}
-	return ""
+	return nil
 }
 
-func (x *ExportRequest) GetPlaylistName() string {
+func (x *SetPlanRequest) GetArtistSpacing() []*ArtistSpacing {
 	if x != nil {
-		return x.PlaylistName
+		return x.ArtistSpacing
 	}
-	return ""
+	return nil
 }
 
-func (x *ExportRequest) GetIncludeRekordbox() bool {
+func (x *SetPlanRequest) GetAlternatives() int32 {
 	if x != nil {
-		return x.IncludeRekordbox
+		return x.Alternatives
 	}
-	return false
+	return 0
 }
 
-func (x *ExportRequest) GetIncludeSerato() bool {
+func (x *SetPlanRequest) GetMinDifferentEdges() int32 {
 	if x != nil {
-		return x.IncludeSerato
+		return x.MinDifferentEdges
 	}
-	return false
+	return 0
 }
 
-func (x *ExportRequest) GetIncludeTraktor() bool {
+func (x *SetPlanRequest) GetDifferentOpeners() bool {
 	if x != nil {
-		return x.IncludeTraktor
+		return x.DifferentOpeners
 	}
 	return false
 }
 
-type ExportResponse struct {
+// Tracks that must play back to back, in this order.
+type LockedChain struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	PlaylistPath  string                 `protobuf:"bytes,1,opt,name=playlist_path,json=playlistPath,proto3" json:"playlist_path,omitempty"`
-	AnalysisJson  string                 `protobuf:"bytes,2,opt,name=analysis_json,json=analysisJson,proto3" json:"analysis_json,omitempty"`
-	CuesCsv       string                 `protobuf:"bytes,3,opt,name=cues_csv,json=cuesCsv,proto3" json:"cues_csv,omitempty"`
-	VendorExports []string               `protobuf:"bytes,4,rep,name=vendor_exports,json=vendorExports,proto3" json:"vendor_exports,omitempty"` // paths per DJ ecosystem
+	Tracks        []*common.TrackId      `protobuf:"bytes,1,rep,name=tracks,proto3" json:"tracks,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ExportResponse) Reset() {
-	*x = ExportResponse{}
-	mi := &file_engine_api_proto_msgTypes[10]
+func (x *LockedChain) Reset() {
+	*x = LockedChain{}
+	mi := &file_engine_api_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ExportResponse) String() string {
+func (x *LockedChain) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ExportResponse) ProtoMessage() {}
+func (*LockedChain) ProtoMessage() {}
 
-func (x *ExportResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[10]
+func (x *LockedChain) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -939,64 +1019,42 @@ func (x *ExportResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ExportResponse.ProtoReflect.Descriptor instead.
-func (*ExportResponse) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{10}
-}
-
-func (x *ExportResponse) GetPlaylistPath() string {
-	if x != nil {
-		return x.PlaylistPath
-	}
-	return ""
-}
-
-func (x *ExportResponse) GetAnalysisJson() string {
-	if x != nil {
-		return x.AnalysisJson
-	}
-	return ""
-}
-
-func (x *ExportResponse) GetCuesCsv() string {
-	if x != nil {
-		return x.CuesCsv
-	}
-	return ""
+// Deprecated: Use LockedChain.ProtoReflect.Descriptor instead.
+func (*LockedChain) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *ExportResponse) GetVendorExports() []string {
+func (x *LockedChain) GetTracks() []*common.TrackId {
 	if x != nil {
-		return x.VendorExports
+		return x.Tracks
 	}
 	return nil
 }
 
-type SimilarTracksRequest struct {
+// One track that must play somewhere before another.
+type Precedence struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TrackId       *common.TrackId        `protobuf:"bytes,1,opt,name=track_id,json=trackId,proto3" json:"track_id,omitempty"`
-	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`                        // Max results (default 10)
-	MinScore      float32                `protobuf:"fixed32,3,opt,name=min_score,json=minScore,proto3" json:"min_score,omitempty"` // Minimum similarity score (0..1)
-	Constraints   *SimilarityConstraints `protobuf:"bytes,4,opt,name=constraints,proto3" json:"constraints,omitempty"`
+	Before        *common.TrackId        `protobuf:"bytes,1,opt,name=before,proto3" json:"before,omitempty"`
+	After         *common.TrackId        `protobuf:"bytes,2,opt,name=after,proto3" json:"after,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SimilarTracksRequest) Reset() {
-	*x = SimilarTracksRequest{}
-	mi := &file_engine_api_proto_msgTypes[11]
+func (x *Precedence) Reset() {
+	*x = Precedence{}
+	mi := &file_engine_api_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SimilarTracksRequest) String() string {
+func (x *Precedence) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SimilarTracksRequest) ProtoMessage() {}
+func (*Precedence) ProtoMessage() {}
 
-func (x *SimilarTracksRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[11]
+func (x *Precedence) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1007,63 +1065,51 @@ func (x *SimilarTracksRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SimilarTracksRequest.ProtoReflect.Descriptor instead.
-func (*SimilarTracksRequest) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use Precedence.ProtoReflect.Descriptor instead.
+func (*Precedence) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *SimilarTracksRequest) GetTrackId() *common.TrackId {
+func (x *Precedence) GetBefore() *common.TrackId {
 	if x != nil {
-		return x.TrackId
+		return x.Before
 	}
 	return nil
 }
 
-func (x *SimilarTracksRequest) GetLimit() int32 {
-	if x != nil {
-		return x.Limit
-	}
-	return 0
-}
-
-func (x *SimilarTracksRequest) GetMinScore() float32 {
-	if x != nil {
-		return x.MinScore
-	}
-	return 0
-}
-
-func (x *SimilarTracksRequest) GetConstraints() *SimilarityConstraints {
+func (x *Precedence) GetAfter() *common.TrackId {
 	if x != nil {
-		return x.Constraints
+		return x.After
 	}
 	return nil
 }
 
-type SimilarityConstraints struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	MaxBpmDelta    float64                `protobuf:"fixed64,1,opt,name=max_bpm_delta,json=maxBpmDelta,proto3" json:"max_bpm_delta,omitempty"`         // Max BPM difference
-	SameKeyOnly    bool                   `protobuf:"varint,2,opt,name=same_key_only,json=sameKeyOnly,proto3" json:"same_key_only,omitempty"`          // Only same/compatible keys
-	MaxEnergyDelta int32                  `protobuf:"varint,3,opt,name=max_energy_delta,json=maxEnergyDelta,proto3" json:"max_energy_delta,omitempty"` // Max energy level difference
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+// At least min_tracks other tracks between any track by artist_a and any by
+// artist_b. Both may be the same artist, to spread out one artist's tracks.
+type ArtistSpacing struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ArtistA       string                 `protobuf:"bytes,1,opt,name=artist_a,json=artistA,proto3" json:"artist_a,omitempty"`
+	ArtistB       string                 `protobuf:"bytes,2,opt,name=artist_b,json=artistB,proto3" json:"artist_b,omitempty"`
+	MinTracks     int32                  `protobuf:"varint,3,opt,name=min_tracks,json=minTracks,proto3" json:"min_tracks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SimilarityConstraints) Reset() {
-	*x = SimilarityConstraints{}
-	mi := &file_engine_api_proto_msgTypes[12]
+func (x *ArtistSpacing) Reset() {
+	*x = ArtistSpacing{}
+	mi := &file_engine_api_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SimilarityConstraints) String() string {
+func (x *ArtistSpacing) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SimilarityConstraints) ProtoMessage() {}
+func (*ArtistSpacing) ProtoMessage() {}
 
-func (x *SimilarityConstraints) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[12]
+func (x *ArtistSpacing) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1074,55 +1120,57 @@ func (x *SimilarityConstraints) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SimilarityConstraints.ProtoReflect.Descriptor instead.
-func (*SimilarityConstraints) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use ArtistSpacing.ProtoReflect.Descriptor instead.
+func (*ArtistSpacing) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *SimilarityConstraints) GetMaxBpmDelta() float64 {
+func (x *ArtistSpacing) GetArtistA() string {
 	if x != nil {
-		return x.MaxBpmDelta
+		return x.ArtistA
 	}
-	return 0
+	return ""
 }
 
-func (x *SimilarityConstraints) GetSameKeyOnly() bool {
+func (x *ArtistSpacing) GetArtistB() string {
 	if x != nil {
-		return x.SameKeyOnly
+		return x.ArtistB
 	}
-	return false
+	return ""
 }
 
-func (x *SimilarityConstraints) GetMaxEnergyDelta() int32 {
+func (x *ArtistSpacing) GetMinTracks() int32 {
 	if x != nil {
-		return x.MaxEnergyDelta
+		return x.MinTracks
 	}
 	return 0
 }
 
-type SimilarTracksResponse struct {
+// A target energy arc: a named preset or explicit points.
+type EnergyCurve struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	QueryTrack    *common.TrackId        `protobuf:"bytes,1,opt,name=query_track,json=queryTrack,proto3" json:"query_track,omitempty"`
-	Similar       []*common.SimilarTrack `protobuf:"bytes,2,rep,name=similar,proto3" json:"similar,omitempty"`
+	Preset        string                 `protobuf:"bytes,1,opt,name=preset,proto3" json:"preset,omitempty"` // slow_burn / double_peak / sunrise_closer
+	Points        []*EnergyPoint         `protobuf:"bytes,2,rep,name=points,proto3" json:"points,omitempty"`
+	Weight        float32                `protobuf:"fixed32,3,opt,name=weight,proto3" json:"weight,omitempty"` // score lost per energy level missed (default 1.5)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SimilarTracksResponse) Reset() {
-	*x = SimilarTracksResponse{}
-	mi := &file_engine_api_proto_msgTypes[13]
+func (x *EnergyCurve) Reset() {
+	*x = EnergyCurve{}
+	mi := &file_engine_api_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SimilarTracksResponse) String() string {
+func (x *EnergyCurve) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SimilarTracksResponse) ProtoMessage() {}
+func (*EnergyCurve) ProtoMessage() {}
 
-func (x *SimilarTracksResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[13]
+func (x *EnergyCurve) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1133,50 +1181,149 @@ func (x *SimilarTracksResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SimilarTracksResponse.ProtoReflect.Descriptor instead.
-func (*SimilarTracksResponse) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use EnergyCurve.ProtoReflect.Descriptor instead.
+func (*EnergyCurve) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *SimilarTracksResponse) GetQueryTrack() *common.TrackId {
+func (x *EnergyCurve) GetPreset() string {
 	if x != nil {
-		return x.QueryTrack
+		return x.Preset
+	}
+	return ""
+}
+
+func (x *EnergyCurve) GetPoints() []*EnergyPoint {
+	if x != nil {
+		return x.Points
 	}
 	return nil
 }
 
-func (x *SimilarTracksResponse) GetSimilar() []*common.SimilarTrack {
+func (x *EnergyCurve) GetWeight() float32 {
 	if x != nil {
-		return x.Similar
+		return x.Weight
+	}
+	return 0
+}
+
+type EnergyPoint struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to At:
+	//
+	//	*EnergyPoint_Position
+	//	*EnergyPoint_Minutes
+	At            isEnergyPoint_At `protobuf_oneof:"at"`
+	Energy        float32          `protobuf:"fixed32,3,opt,name=energy,proto3" json:"energy,omitempty"` // 1-10
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnergyPoint) Reset() {
+	*x = EnergyPoint{}
+	mi := &file_engine_api_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnergyPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnergyPoint) ProtoMessage() {}
+
+func (x *EnergyPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnergyPoint.ProtoReflect.Descriptor instead.
+func (*EnergyPoint) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *EnergyPoint) GetAt() isEnergyPoint_At {
+	if x != nil {
+		return x.At
 	}
 	return nil
 }
 
-type ListLabelsRequest struct {
+func (x *EnergyPoint) GetPosition() float64 {
+	if x != nil {
+		if x, ok := x.At.(*EnergyPoint_Position); ok {
+			return x.Position
+		}
+	}
+	return 0
+}
+
+func (x *EnergyPoint) GetMinutes() float64 {
+	if x != nil {
+		if x, ok := x.At.(*EnergyPoint_Minutes); ok {
+			return x.Minutes
+		}
+	}
+	return 0
+}
+
+func (x *EnergyPoint) GetEnergy() float32 {
+	if x != nil {
+		return x.Energy
+	}
+	return 0
+}
+
+type isEnergyPoint_At interface {
+	isEnergyPoint_At()
+}
+
+type EnergyPoint_Position struct {
+	Position float64 `protobuf:"fixed64,1,opt,name=position,proto3,oneof"` // 0..1 through the set
+}
+
+type EnergyPoint_Minutes struct {
+	Minutes float64 `protobuf:"fixed64,2,opt,name=minutes,proto3,oneof"` // from the start of the set
+}
+
+func (*EnergyPoint_Position) isEnergyPoint_At() {}
+
+func (*EnergyPoint_Minutes) isEnergyPoint_At() {}
+
+// Target vs. actual energy for one slot of a planned set.
+type EnergySlot struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TrackId       int64                  `protobuf:"varint,1,opt,name=track_id,json=trackId,proto3" json:"track_id,omitempty"`         // Optional filter by track
-	LabelValue    string                 `protobuf:"bytes,2,opt,name=label_value,json=labelValue,proto3" json:"label_value,omitempty"` // Optional filter by label type
-	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
-	Offset        int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	Slot          int32                  `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	Id            *common.TrackId        `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Position      float64                `protobuf:"fixed64,3,opt,name=position,proto3" json:"position,omitempty"`                             // 0..1, where the slot starts
+	TargetEnergy  float32                `protobuf:"fixed32,4,opt,name=target_energy,json=targetEnergy,proto3" json:"target_energy,omitempty"` // curve averaged over the slot
+	ActualEnergy  float32                `protobuf:"fixed32,5,opt,name=actual_energy,json=actualEnergy,proto3" json:"actual_energy,omitempty"` // track energy averaged over its segments
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListLabelsRequest) Reset() {
-	*x = ListLabelsRequest{}
-	mi := &file_engine_api_proto_msgTypes[14]
+func (x *EnergySlot) Reset() {
+	*x = EnergySlot{}
+	mi := &file_engine_api_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListLabelsRequest) String() string {
+func (x *EnergySlot) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListLabelsRequest) ProtoMessage() {}
+func (*EnergySlot) ProtoMessage() {}
 
-func (x *ListLabelsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[14]
+func (x *EnergySlot) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1187,62 +1334,76 @@ func (x *ListLabelsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListLabelsRequest.ProtoReflect.Descriptor instead.
-func (*ListLabelsRequest) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use EnergySlot.ProtoReflect.Descriptor instead.
+func (*EnergySlot) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *ListLabelsRequest) GetTrackId() int64 {
+func (x *EnergySlot) GetSlot() int32 {
 	if x != nil {
-		return x.TrackId
+		return x.Slot
 	}
 	return 0
 }
 
-func (x *ListLabelsRequest) GetLabelValue() string {
+func (x *EnergySlot) GetId() *common.TrackId {
 	if x != nil {
-		return x.LabelValue
+		return x.Id
 	}
-	return ""
+	return nil
 }
 
-func (x *ListLabelsRequest) GetLimit() int32 {
+func (x *EnergySlot) GetPosition() float64 {
 	if x != nil {
-		return x.Limit
+		return x.Position
 	}
 	return 0
 }
 
-func (x *ListLabelsRequest) GetOffset() int32 {
+func (x *EnergySlot) GetTargetEnergy() float32 {
 	if x != nil {
-		return x.Offset
+		return x.TargetEnergy
 	}
 	return 0
 }
 
-type ListLabelsResponse struct {
-	state         protoimpl.MessageState  `protogen:"open.v1"`
-	Labels        []*common.TrainingLabel `protobuf:"bytes,1,rep,name=labels,proto3" json:"labels,omitempty"`
-	Total         int32                   `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *EnergySlot) GetActualEnergy() float32 {
+	if x != nil {
+		return x.ActualEnergy
+	}
+	return 0
 }
 
-func (x *ListLabelsResponse) Reset() {
-	*x = ListLabelsResponse{}
-	mi := &file_engine_api_proto_msgTypes[15]
+type SetPlanResponse struct {
+	state            protoimpl.MessageState    `protogen:"open.v1"`
+	Order            []*common.TrackId         `protobuf:"bytes,1,rep,name=order,proto3" json:"order,omitempty"`
+	Explanations     []*common.EdgeExplanation `protobuf:"bytes,2,rep,name=explanations,proto3" json:"explanations,omitempty"`
+	Collapsed        []*common.TrackId         `protobuf:"bytes,3,rep,name=collapsed,proto3" json:"collapsed,omitempty"`                                         // duplicates dropped before planning
+	TotalScore       float64                   `protobuf:"fixed64,4,opt,name=total_score,json=totalScore,proto3" json:"total_score,omitempty"`                   // sum of the transition scores
+	WeakestEdges     []*common.EdgeExplanation `protobuf:"bytes,5,rep,name=weakest_edges,json=weakestEdges,proto3" json:"weakest_edges,omitempty"`               // lowest-scoring transitions, weakest first
+	EnergySlots      []*EnergySlot             `protobuf:"bytes,6,rep,name=energy_slots,json=energySlots,proto3" json:"energy_slots,omitempty"`                  // set when an energy curve was given
+	EnergyError      float32                   `protobuf:"fixed32,7,opt,name=energy_error,json=energyError,proto3" json:"energy_error,omitempty"`                // mean energy levels missed per slot
+	EstimatedSeconds float64                   `protobuf:"fixed64,8,opt,name=estimated_seconds,json=estimatedSeconds,proto3" json:"estimated_seconds,omitempty"` // estimated mixed running time of the set
+	Alternatives     []*AlternativePlan        `protobuf:"bytes,9,rep,name=alternatives,proto3" json:"alternatives,omitempty"`                                   // runner-up plans, highest total score first
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SetPlanResponse) Reset() {
+	*x = SetPlanResponse{}
+	mi := &file_engine_api_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListLabelsResponse) String() string {
+func (x *SetPlanResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListLabelsResponse) ProtoMessage() {}
+func (*SetPlanResponse) ProtoMessage() {}
 
-func (x *ListLabelsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[15]
+func (x *SetPlanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1253,53 +1414,104 @@ func (x *ListLabelsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListLabelsResponse.ProtoReflect.Descriptor instead.
-func (*ListLabelsResponse) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use SetPlanResponse.ProtoReflect.Descriptor instead.
+func (*SetPlanResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *ListLabelsResponse) GetLabels() []*common.TrainingLabel {
+func (x *SetPlanResponse) GetOrder() []*common.TrackId {
 	if x != nil {
-		return x.Labels
+		return x.Order
 	}
 	return nil
 }
 
-func (x *ListLabelsResponse) GetTotal() int32 {
+func (x *SetPlanResponse) GetExplanations() []*common.EdgeExplanation {
 	if x != nil {
-		return x.Total
+		return x.Explanations
+	}
+	return nil
+}
+
+func (x *SetPlanResponse) GetCollapsed() []*common.TrackId {
+	if x != nil {
+		return x.Collapsed
+	}
+	return nil
+}
+
+func (x *SetPlanResponse) GetTotalScore() float64 {
+	if x != nil {
+		return x.TotalScore
 	}
 	return 0
 }
 
-type AddLabelRequest struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	TrackId          int64                  `protobuf:"varint,1,opt,name=track_id,json=trackId,proto3" json:"track_id,omitempty"`
-	LabelValue       string                 `protobuf:"bytes,2,opt,name=label_value,json=labelValue,proto3" json:"label_value,omitempty"` // intro, build, drop, break, outro, verse, chorus
-	StartBeat        int32                  `protobuf:"varint,3,opt,name=start_beat,json=startBeat,proto3" json:"start_beat,omitempty"`
-	EndBeat          int32                  `protobuf:"varint,4,opt,name=end_beat,json=endBeat,proto3" json:"end_beat,omitempty"`
-	StartTimeSeconds float64                `protobuf:"fixed64,5,opt,name=start_time_seconds,json=startTimeSeconds,proto3" json:"start_time_seconds,omitempty"`
-	EndTimeSeconds   float64                `protobuf:"fixed64,6,opt,name=end_time_seconds,json=endTimeSeconds,proto3" json:"end_time_seconds,omitempty"`
-	Source           string                 `protobuf:"bytes,7,opt,name=source,proto3" json:"source,omitempty"` // user, auto_detected, imported
+func (x *SetPlanResponse) GetWeakestEdges() []*common.EdgeExplanation {
+	if x != nil {
+		return x.WeakestEdges
+	}
+	return nil
+}
+
+func (x *SetPlanResponse) GetEnergySlots() []*EnergySlot {
+	if x != nil {
+		return x.EnergySlots
+	}
+	return nil
+}
+
+func (x *SetPlanResponse) GetEnergyError() float32 {
+	if x != nil {
+		return x.EnergyError
+	}
+	return 0
+}
+
+func (x *SetPlanResponse) GetEstimatedSeconds() float64 {
+	if x != nil {
+		return x.EstimatedSeconds
+	}
+	return 0
+}
+
+func (x *SetPlanResponse) GetAlternatives() []*AlternativePlan {
+	if x != nil {
+		return x.Alternatives
+	}
+	return nil
+}
+
+// Another plan for the same request that differs from the best one.
+type AlternativePlan struct {
+	state            protoimpl.MessageState    `protogen:"open.v1"`
+	Order            []*common.TrackId         `protobuf:"bytes,1,rep,name=order,proto3" json:"order,omitempty"`
+	Explanations     []*common.EdgeExplanation `protobuf:"bytes,2,rep,name=explanations,proto3" json:"explanations,omitempty"`
+	TotalScore       float64                   `protobuf:"fixed64,3,opt,name=total_score,json=totalScore,proto3" json:"total_score,omitempty"`
+	WeakestEdges     []*common.EdgeExplanation `protobuf:"bytes,4,rep,name=weakest_edges,json=weakestEdges,proto3" json:"weakest_edges,omitempty"`
+	EnergySlots      []*EnergySlot             `protobuf:"bytes,5,rep,name=energy_slots,json=energySlots,proto3" json:"energy_slots,omitempty"`
+	EnergyError      float32                   `protobuf:"fixed32,6,opt,name=energy_error,json=energyError,proto3" json:"energy_error,omitempty"`
+	EstimatedSeconds float64                   `protobuf:"fixed64,7,opt,name=estimated_seconds,json=estimatedSeconds,proto3" json:"estimated_seconds,omitempty"`
+	Diff             *PlanDiff                 `protobuf:"bytes,8,opt,name=diff,proto3" json:"diff,omitempty"` // how it differs from the best plan
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
 
-func (x *AddLabelRequest) Reset() {
-	*x = AddLabelRequest{}
-	mi := &file_engine_api_proto_msgTypes[16]
+func (x *AlternativePlan) Reset() {
+	*x = AlternativePlan{}
+	mi := &file_engine_api_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AddLabelRequest) String() string {
+func (x *AlternativePlan) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AddLabelRequest) ProtoMessage() {}
+func (*AlternativePlan) ProtoMessage() {}
 
-func (x *AddLabelRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[16]
+func (x *AlternativePlan) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1310,83 +1522,2576 @@ func (x *AddLabelRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AddLabelRequest.ProtoReflect.Descriptor instead.
-func (*AddLabelRequest) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use AlternativePlan.ProtoReflect.Descriptor instead.
+func (*AlternativePlan) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *AddLabelRequest) GetTrackId() int64 {
+func (x *AlternativePlan) GetOrder() []*common.TrackId {
 	if x != nil {
-		return x.TrackId
+		return x.Order
+	}
+	return nil
+}
+
+func (x *AlternativePlan) GetExplanations() []*common.EdgeExplanation {
+	if x != nil {
+		return x.Explanations
+	}
+	return nil
+}
+
+func (x *AlternativePlan) GetTotalScore() float64 {
+	if x != nil {
+		return x.TotalScore
 	}
 	return 0
 }
 
-func (x *AddLabelRequest) GetLabelValue() string {
+func (x *AlternativePlan) GetWeakestEdges() []*common.EdgeExplanation {
 	if x != nil {
-		return x.LabelValue
+		return x.WeakestEdges
 	}
-	return ""
+	return nil
 }
 
-func (x *AddLabelRequest) GetStartBeat() int32 {
+func (x *AlternativePlan) GetEnergySlots() []*EnergySlot {
 	if x != nil {
-		return x.StartBeat
+		return x.EnergySlots
+	}
+	return nil
+}
+
+func (x *AlternativePlan) GetEnergyError() float32 {
+	if x != nil {
+		return x.EnergyError
+	}
+	return 0
+}
+
+func (x *AlternativePlan) GetEstimatedSeconds() float64 {
+	if x != nil {
+		return x.EstimatedSeconds
 	}
 	return 0
 }
 
-func (x *AddLabelRequest) GetEndBeat() int32 {
+func (x *AlternativePlan) GetDiff() *PlanDiff {
+	if x != nil {
+		return x.Diff
+	}
+	return nil
+}
+
+// How an alternative plan differs from the best one.
+type PlanDiff struct {
+	state           protoimpl.MessageState    `protogen:"open.v1"`
+	DifferentEdges  int32                     `protobuf:"varint,1,opt,name=different_edges,json=differentEdges,proto3" json:"different_edges,omitempty"` // transitions the best plan doesn't make
+	NewEdges        []*common.EdgeExplanation `protobuf:"bytes,2,rep,name=new_edges,json=newEdges,proto3" json:"new_edges,omitempty"`                    // those transitions, in set order
+	DifferentOpener bool                      `protobuf:"varint,3,opt,name=different_opener,json=differentOpener,proto3" json:"different_opener,omitempty"`
+	FirstDifference int32                     `protobuf:"varint,4,opt,name=first_difference,json=firstDifference,proto3" json:"first_difference,omitempty"` // first position where the order departs from the best
+	Added           []*common.TrackId         `protobuf:"bytes,5,rep,name=added,proto3" json:"added,omitempty"`                                             // tracks the best plan leaves out
+	Dropped         []*common.TrackId         `protobuf:"bytes,6,rep,name=dropped,proto3" json:"dropped,omitempty"`                                         // tracks of the best plan this one leaves out
+	ScoreDelta      float64                   `protobuf:"fixed64,7,opt,name=score_delta,json=scoreDelta,proto3" json:"score_delta,omitempty"`               // total score minus the best plan's
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *PlanDiff) Reset() {
+	*x = PlanDiff{}
+	mi := &file_engine_api_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanDiff) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanDiff) ProtoMessage() {}
+
+func (x *PlanDiff) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanDiff.ProtoReflect.Descriptor instead.
+func (*PlanDiff) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *PlanDiff) GetDifferentEdges() int32 {
+	if x != nil {
+		return x.DifferentEdges
+	}
+	return 0
+}
+
+func (x *PlanDiff) GetNewEdges() []*common.EdgeExplanation {
+	if x != nil {
+		return x.NewEdges
+	}
+	return nil
+}
+
+func (x *PlanDiff) GetDifferentOpener() bool {
+	if x != nil {
+		return x.DifferentOpener
+	}
+	return false
+}
+
+func (x *PlanDiff) GetFirstDifference() int32 {
+	if x != nil {
+		return x.FirstDifference
+	}
+	return 0
+}
+
+func (x *PlanDiff) GetAdded() []*common.TrackId {
+	if x != nil {
+		return x.Added
+	}
+	return nil
+}
+
+func (x *PlanDiff) GetDropped() []*common.TrackId {
+	if x != nil {
+		return x.Dropped
+	}
+	return nil
+}
+
+func (x *PlanDiff) GetScoreDelta() float64 {
+	if x != nil {
+		return x.ScoreDelta
+	}
+	return 0
+}
+
+type SuggestTransitionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          *common.TrackId        `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            *common.TrackId        `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`                              // suggestions to return (default 3)
+	PitchRange    float64                `protobuf:"fixed64,4,opt,name=pitch_range,json=pitchRange,proto3" json:"pitch_range,omitempty"` // deck pitch range either way, percent (default 8)
+	MasterTempo   bool                   `protobuf:"varint,5,opt,name=master_tempo,json=masterTempo,proto3" json:"master_tempo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuggestTransitionRequest) Reset() {
+	*x = SuggestTransitionRequest{}
+	mi := &file_engine_api_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuggestTransitionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuggestTransitionRequest) ProtoMessage() {}
+
+func (x *SuggestTransitionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuggestTransitionRequest.ProtoReflect.Descriptor instead.
+func (*SuggestTransitionRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SuggestTransitionRequest) GetFrom() *common.TrackId {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *SuggestTransitionRequest) GetTo() *common.TrackId {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *SuggestTransitionRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SuggestTransitionRequest) GetPitchRange() float64 {
+	if x != nil {
+		return x.PitchRange
+	}
+	return 0
+}
+
+func (x *SuggestTransitionRequest) GetMasterTempo() bool {
+	if x != nil {
+		return x.MasterTempo
+	}
+	return false
+}
+
+type SuggestTransitionResponse struct {
+	state         protoimpl.MessageState         `protogen:"open.v1"`
+	Suggestions   []*common.TransitionSuggestion `protobuf:"bytes,1,rep,name=suggestions,proto3" json:"suggestions,omitempty"` // best first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuggestTransitionResponse) Reset() {
+	*x = SuggestTransitionResponse{}
+	mi := &file_engine_api_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuggestTransitionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuggestTransitionResponse) ProtoMessage() {}
+
+func (x *SuggestTransitionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuggestTransitionResponse.ProtoReflect.Descriptor instead.
+func (*SuggestTransitionResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SuggestTransitionResponse) GetSuggestions() []*common.TransitionSuggestion {
+	if x != nil {
+		return x.Suggestions
+	}
+	return nil
+}
+
+// A set being played, with the remainder replanned from the track playing now.
+type LiveSession struct {
+	state            protoimpl.MessageState    `protogen:"open.v1"`
+	SessionId        int64                     `protobuf:"varint,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	StartedAt        int64                     `protobuf:"varint,2,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`                       // Unix timestamp
+	EndedAt          int64                     `protobuf:"varint,3,opt,name=ended_at,json=endedAt,proto3" json:"ended_at,omitempty"`                             // Unix timestamp; 0 while the set runs
+	Played           []*LivePlay               `protobuf:"bytes,4,rep,name=played,proto3" json:"played,omitempty"`                                               // in play order, including the track playing now
+	NowPlaying       *LivePlay                 `protobuf:"bytes,5,opt,name=now_playing,json=nowPlaying,proto3" json:"now_playing,omitempty"`                     // unset until the first play
+	NextUp           []*common.TrackId         `protobuf:"bytes,6,rep,name=next_up,json=nextUp,proto3" json:"next_up,omitempty"`                                 // the replanned rest of the set, in order
+	Explanations     []*common.EdgeExplanation `protobuf:"bytes,7,rep,name=explanations,proto3" json:"explanations,omitempty"`                                   // transitions from the track playing now on
+	ElapsedSeconds   float64                   `protobuf:"fixed64,8,opt,name=elapsed_seconds,json=elapsedSeconds,proto3" json:"elapsed_seconds,omitempty"`       // from the first play to the track playing now
+	EstimatedSeconds float64                   `protobuf:"fixed64,9,opt,name=estimated_seconds,json=estimatedSeconds,proto3" json:"estimated_seconds,omitempty"` // running time of the rest, from the track playing now
+	Note             string                    `protobuf:"bytes,10,opt,name=note,proto3" json:"note,omitempty"`                                                  // why next_up is empty, when it is
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *LiveSession) Reset() {
+	*x = LiveSession{}
+	mi := &file_engine_api_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LiveSession) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LiveSession) ProtoMessage() {}
+
+func (x *LiveSession) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LiveSession.ProtoReflect.Descriptor instead.
+func (*LiveSession) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *LiveSession) GetSessionId() int64 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+func (x *LiveSession) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *LiveSession) GetEndedAt() int64 {
+	if x != nil {
+		return x.EndedAt
+	}
+	return 0
+}
+
+func (x *LiveSession) GetPlayed() []*LivePlay {
+	if x != nil {
+		return x.Played
+	}
+	return nil
+}
+
+func (x *LiveSession) GetNowPlaying() *LivePlay {
+	if x != nil {
+		return x.NowPlaying
+	}
+	return nil
+}
+
+func (x *LiveSession) GetNextUp() []*common.TrackId {
+	if x != nil {
+		return x.NextUp
+	}
+	return nil
+}
+
+func (x *LiveSession) GetExplanations() []*common.EdgeExplanation {
+	if x != nil {
+		return x.Explanations
+	}
+	return nil
+}
+
+func (x *LiveSession) GetElapsedSeconds() float64 {
+	if x != nil {
+		return x.ElapsedSeconds
+	}
+	return 0
+}
+
+func (x *LiveSession) GetEstimatedSeconds() float64 {
+	if x != nil {
+		return x.EstimatedSeconds
+	}
+	return 0
+}
+
+func (x *LiveSession) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+type LivePlay struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            *common.TrackId        `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	StartedAt     int64                  `protobuf:"varint,2,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"` // Unix timestamp
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LivePlay) Reset() {
+	*x = LivePlay{}
+	mi := &file_engine_api_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LivePlay) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LivePlay) ProtoMessage() {}
+
+func (x *LivePlay) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LivePlay.ProtoReflect.Descriptor instead.
+func (*LivePlay) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *LivePlay) GetId() *common.TrackId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *LivePlay) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+type LiveSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     int64                  `protobuf:"varint,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LiveSessionRequest) Reset() {
+	*x = LiveSessionRequest{}
+	mi := &file_engine_api_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LiveSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LiveSessionRequest) ProtoMessage() {}
+
+func (x *LiveSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LiveSessionRequest.ProtoReflect.Descriptor instead.
+func (*LiveSessionRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *LiveSessionRequest) GetSessionId() int64 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+type LivePlayRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     int64                  `protobuf:"varint,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Id            *common.TrackId        `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`                                 // the track that started playing
+	StartedAt     int64                  `protobuf:"varint,3,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"` // Unix timestamp; now when 0
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LivePlayRequest) Reset() {
+	*x = LivePlayRequest{}
+	mi := &file_engine_api_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LivePlayRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LivePlayRequest) ProtoMessage() {}
+
+func (x *LivePlayRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LivePlayRequest.ProtoReflect.Descriptor instead.
+func (*LivePlayRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *LivePlayRequest) GetSessionId() int64 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+func (x *LivePlayRequest) GetId() *common.TrackId {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *LivePlayRequest) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+// One version of a saved set.
+type SavedSet struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	SetId         int64                     `protobuf:"varint,1,opt,name=set_id,json=setId,proto3" json:"set_id,omitempty"`
+	Version       int32                     `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Name          string                    `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Notes         string                    `protobuf:"bytes,4,opt,name=notes,proto3" json:"notes,omitempty"`
+	TrackIds      []*common.TrackId         `protobuf:"bytes,5,rep,name=track_ids,json=trackIds,proto3" json:"track_ids,omitempty"`     // in play order
+	Explanations  []*common.EdgeExplanation `protobuf:"bytes,6,rep,name=explanations,proto3" json:"explanations,omitempty"`             // transition i runs from track i to track i+1; left out of lists
+	CreatedAt     int64                     `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // Unix timestamp of the first version
+	UpdatedAt     int64                     `protobuf:"varint,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // Unix timestamp of this version
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SavedSet) Reset() {
+	*x = SavedSet{}
+	mi := &file_engine_api_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SavedSet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SavedSet) ProtoMessage() {}
+
+func (x *SavedSet) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SavedSet.ProtoReflect.Descriptor instead.
+func (*SavedSet) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SavedSet) GetSetId() int64 {
+	if x != nil {
+		return x.SetId
+	}
+	return 0
+}
+
+func (x *SavedSet) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *SavedSet) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SavedSet) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *SavedSet) GetTrackIds() []*common.TrackId {
+	if x != nil {
+		return x.TrackIds
+	}
+	return nil
+}
+
+func (x *SavedSet) GetExplanations() []*common.EdgeExplanation {
+	if x != nil {
+		return x.Explanations
+	}
+	return nil
+}
+
+func (x *SavedSet) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *SavedSet) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type SaveSetRequest struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	SetId         int64                     `protobuf:"varint,1,opt,name=set_id,json=setId,proto3" json:"set_id,omitempty"` // the set to update; ignored by CreateSet
+	Name          string                    `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Notes         string                    `protobuf:"bytes,3,opt,name=notes,proto3" json:"notes,omitempty"`
+	TrackIds      []*common.TrackId         `protobuf:"bytes,4,rep,name=track_ids,json=trackIds,proto3" json:"track_ids,omitempty"` // in play order
+	Explanations  []*common.EdgeExplanation `protobuf:"bytes,5,rep,name=explanations,proto3" json:"explanations,omitempty"`         // optional, one per transition, e.g. from ProposeSet
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SaveSetRequest) Reset() {
+	*x = SaveSetRequest{}
+	mi := &file_engine_api_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveSetRequest) ProtoMessage() {}
+
+func (x *SaveSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveSetRequest.ProtoReflect.Descriptor instead.
+func (*SaveSetRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SaveSetRequest) GetSetId() int64 {
+	if x != nil {
+		return x.SetId
+	}
+	return 0
+}
+
+func (x *SaveSetRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SaveSetRequest) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *SaveSetRequest) GetTrackIds() []*common.TrackId {
+	if x != nil {
+		return x.TrackIds
+	}
+	return nil
+}
+
+func (x *SaveSetRequest) GetExplanations() []*common.EdgeExplanation {
+	if x != nil {
+		return x.Explanations
+	}
+	return nil
+}
+
+type GetSetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SetId         int64                  `protobuf:"varint,1,opt,name=set_id,json=setId,proto3" json:"set_id,omitempty"`
+	Version       int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"` // the latest when 0
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSetRequest) Reset() {
+	*x = GetSetRequest{}
+	mi := &file_engine_api_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSetRequest) ProtoMessage() {}
+
+func (x *GetSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSetRequest.ProtoReflect.Descriptor instead.
+func (*GetSetRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetSetRequest) GetSetId() int64 {
+	if x != nil {
+		return x.SetId
+	}
+	return 0
+}
+
+func (x *GetSetRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ListSetsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sets          []*SavedSet            `protobuf:"bytes,1,rep,name=sets,proto3" json:"sets,omitempty"` // latest versions, most recently edited first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSetsResponse) Reset() {
+	*x = ListSetsResponse{}
+	mi := &file_engine_api_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSetsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSetsResponse) ProtoMessage() {}
+
+func (x *ListSetsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSetsResponse.ProtoReflect.Descriptor instead.
+func (*ListSetsResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ListSetsResponse) GetSets() []*SavedSet {
+	if x != nil {
+		return x.Sets
+	}
+	return nil
+}
+
+type ListSetVersionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SetId         int64                  `protobuf:"varint,1,opt,name=set_id,json=setId,proto3" json:"set_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSetVersionsRequest) Reset() {
+	*x = ListSetVersionsRequest{}
+	mi := &file_engine_api_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSetVersionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSetVersionsRequest) ProtoMessage() {}
+
+func (x *ListSetVersionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSetVersionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSetVersionsRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListSetVersionsRequest) GetSetId() int64 {
+	if x != nil {
+		return x.SetId
+	}
+	return 0
+}
+
+type ListSetVersionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Versions      []*SavedSet            `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"` // newest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSetVersionsResponse) Reset() {
+	*x = ListSetVersionsResponse{}
+	mi := &file_engine_api_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSetVersionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSetVersionsResponse) ProtoMessage() {}
+
+func (x *ListSetVersionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSetVersionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSetVersionsResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListSetVersionsResponse) GetVersions() []*SavedSet {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type DeleteSetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SetId         int64                  `protobuf:"varint,1,opt,name=set_id,json=setId,proto3" json:"set_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSetRequest) Reset() {
+	*x = DeleteSetRequest{}
+	mi := &file_engine_api_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSetRequest) ProtoMessage() {}
+
+func (x *DeleteSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSetRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSetRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *DeleteSetRequest) GetSetId() int64 {
+	if x != nil {
+		return x.SetId
+	}
+	return 0
+}
+
+type ExportRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	TrackIds         []*common.TrackId      `protobuf:"bytes,1,rep,name=track_ids,json=trackIds,proto3" json:"track_ids,omitempty"`
+	OutputDir        string                 `protobuf:"bytes,2,opt,name=output_dir,json=outputDir,proto3" json:"output_dir,omitempty"` // e.g., ./exports/set001
+	PlaylistName     string                 `protobuf:"bytes,3,opt,name=playlist_name,json=playlistName,proto3" json:"playlist_name,omitempty"`
+	IncludeRekordbox bool                   `protobuf:"varint,4,opt,name=include_rekordbox,json=includeRekordbox,proto3" json:"include_rekordbox,omitempty"`
+	IncludeSerato    bool                   `protobuf:"varint,5,opt,name=include_serato,json=includeSerato,proto3" json:"include_serato,omitempty"`
+	IncludeTraktor   bool                   `protobuf:"varint,6,opt,name=include_traktor,json=includeTraktor,proto3" json:"include_traktor,omitempty"`
+	SetId            int64                  `protobuf:"varint,7,opt,name=set_id,json=setId,proto3" json:"set_id,omitempty"`                // export a saved set instead of track_ids
+	SetVersion       int32                  `protobuf:"varint,8,opt,name=set_version,json=setVersion,proto3" json:"set_version,omitempty"` // version of set_id; the latest when 0
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	mi := &file_engine_api_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ExportRequest) GetTrackIds() []*common.TrackId {
+	if x != nil {
+		return x.TrackIds
+	}
+	return nil
+}
+
+func (x *ExportRequest) GetOutputDir() string {
+	if x != nil {
+		return x.OutputDir
+	}
+	return ""
+}
+
+func (x *ExportRequest) GetPlaylistName() string {
+	if x != nil {
+		return x.PlaylistName
+	}
+	return ""
+}
+
+func (x *ExportRequest) GetIncludeRekordbox() bool {
+	if x != nil {
+		return x.IncludeRekordbox
+	}
+	return false
+}
+
+func (x *ExportRequest) GetIncludeSerato() bool {
+	if x != nil {
+		return x.IncludeSerato
+	}
+	return false
+}
+
+func (x *ExportRequest) GetIncludeTraktor() bool {
+	if x != nil {
+		return x.IncludeTraktor
+	}
+	return false
+}
+
+func (x *ExportRequest) GetSetId() int64 {
+	if x != nil {
+		return x.SetId
+	}
+	return 0
+}
+
+func (x *ExportRequest) GetSetVersion() int32 {
+	if x != nil {
+		return x.SetVersion
+	}
+	return 0
+}
+
+type ExportResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlaylistPath  string                 `protobuf:"bytes,1,opt,name=playlist_path,json=playlistPath,proto3" json:"playlist_path,omitempty"`
+	AnalysisJson  string                 `protobuf:"bytes,2,opt,name=analysis_json,json=analysisJson,proto3" json:"analysis_json,omitempty"`
+	CuesCsv       string                 `protobuf:"bytes,3,opt,name=cues_csv,json=cuesCsv,proto3" json:"cues_csv,omitempty"`
+	VendorExports []string               `protobuf:"bytes,4,rep,name=vendor_exports,json=vendorExports,proto3" json:"vendor_exports,omitempty"` // paths per DJ ecosystem
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportResponse) Reset() {
+	*x = ExportResponse{}
+	mi := &file_engine_api_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportResponse) ProtoMessage() {}
+
+func (x *ExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportResponse.ProtoReflect.Descriptor instead.
+func (*ExportResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ExportResponse) GetPlaylistPath() string {
+	if x != nil {
+		return x.PlaylistPath
+	}
+	return ""
+}
+
+func (x *ExportResponse) GetAnalysisJson() string {
+	if x != nil {
+		return x.AnalysisJson
+	}
+	return ""
+}
+
+func (x *ExportResponse) GetCuesCsv() string {
+	if x != nil {
+		return x.CuesCsv
+	}
+	return ""
+}
+
+func (x *ExportResponse) GetVendorExports() []string {
+	if x != nil {
+		return x.VendorExports
+	}
+	return nil
+}
+
+type SimilarTracksRequest struct {
+	state              protoimpl.MessageState    `protogen:"open.v1"`
+	TrackId            *common.TrackId           `protobuf:"bytes,1,opt,name=track_id,json=trackId,proto3" json:"track_id,omitempty"`
+	Limit              int32                     `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`                        // Max results (default 10)
+	MinScore           float32                   `protobuf:"fixed32,3,opt,name=min_score,json=minScore,proto3" json:"min_score,omitempty"` // Minimum similarity score (0..1)
+	Constraints        *SimilarityConstraints    `protobuf:"bytes,4,opt,name=constraints,proto3" json:"constraints,omitempty"`
+	CollapseDuplicates bool                      `protobuf:"varint,5,opt,name=collapse_duplicates,json=collapseDuplicates,proto3" json:"collapse_duplicates,omitempty"` // one result per duplicate group, none from the query's
+	Exact              bool                      `protobuf:"varint,6,opt,name=exact,proto3" json:"exact,omitempty"`                                                     // compare every track rather than search the index, e.g. to measure recall
+	Weights            *common.SimilarityWeights `protobuf:"bytes,7,opt,name=weights,proto3" json:"weights,omitempty"`                                                  // overrides the profile and the settings' weights
+	Profile            string                    `protobuf:"bytes,8,opt,name=profile,proto3" json:"profile,omitempty"`                                                  // score with a saved similarity profile's weights
+	Seeds              []*common.TrackId         `protobuf:"bytes,9,rep,name=seeds,proto3" json:"seeds,omitempty"`                                                      // more tracks to be similar to, along with track_id
+	NegativeSeeds      []*common.TrackId         `protobuf:"bytes,10,rep,name=negative_seeds,json=negativeSeeds,proto3" json:"negative_seeds,omitempty"`                // tracks to be unlike
+	Aggregation        SeedAggregation           `protobuf:"varint,11,opt,name=aggregation,proto3,enum=cartomix.engine.SeedAggregation" json:"aggregation,omitempty"`   // how several seeds combine
+	Section            *SectionQuery             `protobuf:"bytes,12,opt,name=section,proto3" json:"section,omitempty"`                                                 // compare a section of track_id with sections of other tracks
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *SimilarTracksRequest) Reset() {
+	*x = SimilarTracksRequest{}
+	mi := &file_engine_api_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimilarTracksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimilarTracksRequest) ProtoMessage() {}
+
+func (x *SimilarTracksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimilarTracksRequest.ProtoReflect.Descriptor instead.
+func (*SimilarTracksRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *SimilarTracksRequest) GetTrackId() *common.TrackId {
+	if x != nil {
+		return x.TrackId
+	}
+	return nil
+}
+
+func (x *SimilarTracksRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SimilarTracksRequest) GetMinScore() float32 {
+	if x != nil {
+		return x.MinScore
+	}
+	return 0
+}
+
+func (x *SimilarTracksRequest) GetConstraints() *SimilarityConstraints {
+	if x != nil {
+		return x.Constraints
+	}
+	return nil
+}
+
+func (x *SimilarTracksRequest) GetCollapseDuplicates() bool {
+	if x != nil {
+		return x.CollapseDuplicates
+	}
+	return false
+}
+
+func (x *SimilarTracksRequest) GetExact() bool {
+	if x != nil {
+		return x.Exact
+	}
+	return false
+}
+
+func (x *SimilarTracksRequest) GetWeights() *common.SimilarityWeights {
+	if x != nil {
+		return x.Weights
+	}
+	return nil
+}
+
+func (x *SimilarTracksRequest) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *SimilarTracksRequest) GetSeeds() []*common.TrackId {
+	if x != nil {
+		return x.Seeds
+	}
+	return nil
+}
+
+func (x *SimilarTracksRequest) GetNegativeSeeds() []*common.TrackId {
+	if x != nil {
+		return x.NegativeSeeds
+	}
+	return nil
+}
+
+func (x *SimilarTracksRequest) GetAggregation() SeedAggregation {
+	if x != nil {
+		return x.Aggregation
+	}
+	return SeedAggregation_SEED_AGGREGATION_CENTROID
+}
+
+func (x *SimilarTracksRequest) GetSection() *SectionQuery {
+	if x != nil {
+		return x.Section
+	}
+	return nil
+}
+
+// SectionQuery picks the part of the query track a section query compares,
+// and the sections of other tracks it is compared with.
+type SectionQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         common.SectionLabel    `protobuf:"varint,1,opt,name=label,proto3,enum=cartomix.common.SectionLabel" json:"label,omitempty"`  // the query track's first section with this label, e.g. OUTRO
+	StartSeconds  float64                `protobuf:"fixed64,2,opt,name=start_seconds,json=startSeconds,proto3" json:"start_seconds,omitempty"` // or this time range of it, when end_seconds is past start_seconds
+	EndSeconds    float64                `protobuf:"fixed64,3,opt,name=end_seconds,json=endSeconds,proto3" json:"end_seconds,omitempty"`
+	MatchLabel    common.SectionLabel    `protobuf:"varint,4,opt,name=match_label,json=matchLabel,proto3,enum=cartomix.common.SectionLabel" json:"match_label,omitempty"` // only compare other tracks' sections with this label, e.g. INTRO
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SectionQuery) Reset() {
+	*x = SectionQuery{}
+	mi := &file_engine_api_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SectionQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SectionQuery) ProtoMessage() {}
+
+func (x *SectionQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SectionQuery.ProtoReflect.Descriptor instead.
+func (*SectionQuery) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *SectionQuery) GetLabel() common.SectionLabel {
+	if x != nil {
+		return x.Label
+	}
+	return common.SectionLabel(0)
+}
+
+func (x *SectionQuery) GetStartSeconds() float64 {
+	if x != nil {
+		return x.StartSeconds
+	}
+	return 0
+}
+
+func (x *SectionQuery) GetEndSeconds() float64 {
+	if x != nil {
+		return x.EndSeconds
+	}
+	return 0
+}
+
+func (x *SectionQuery) GetMatchLabel() common.SectionLabel {
+	if x != nil {
+		return x.MatchLabel
+	}
+	return common.SectionLabel(0)
+}
+
+type SimilarityProfile struct {
+	state         protoimpl.MessageState    `protogen:"open.v1"`
+	Name          string                    `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Weights       *common.SimilarityWeights `protobuf:"bytes,2,opt,name=weights,proto3" json:"weights,omitempty"` // normalized to sum to 1 when saved
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimilarityProfile) Reset() {
+	*x = SimilarityProfile{}
+	mi := &file_engine_api_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimilarityProfile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimilarityProfile) ProtoMessage() {}
+
+func (x *SimilarityProfile) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimilarityProfile.ProtoReflect.Descriptor instead.
+func (*SimilarityProfile) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *SimilarityProfile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SimilarityProfile) GetWeights() *common.SimilarityWeights {
+	if x != nil {
+		return x.Weights
+	}
+	return nil
+}
+
+type ListSimilarityProfilesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Profiles      []*SimilarityProfile   `protobuf:"bytes,1,rep,name=profiles,proto3" json:"profiles,omitempty"` // by name
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSimilarityProfilesResponse) Reset() {
+	*x = ListSimilarityProfilesResponse{}
+	mi := &file_engine_api_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSimilarityProfilesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSimilarityProfilesResponse) ProtoMessage() {}
+
+func (x *ListSimilarityProfilesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSimilarityProfilesResponse.ProtoReflect.Descriptor instead.
+func (*ListSimilarityProfilesResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ListSimilarityProfilesResponse) GetProfiles() []*SimilarityProfile {
+	if x != nil {
+		return x.Profiles
+	}
+	return nil
+}
+
+type DeleteSimilarityProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSimilarityProfileRequest) Reset() {
+	*x = DeleteSimilarityProfileRequest{}
+	mi := &file_engine_api_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSimilarityProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSimilarityProfileRequest) ProtoMessage() {}
+
+func (x *DeleteSimilarityProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSimilarityProfileRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSimilarityProfileRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *DeleteSimilarityProfileRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type SimilarityConstraints struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MaxBpmDelta    float64                `protobuf:"fixed64,1,opt,name=max_bpm_delta,json=maxBpmDelta,proto3" json:"max_bpm_delta,omitempty"`         // Max BPM difference
+	SameKeyOnly    bool                   `protobuf:"varint,2,opt,name=same_key_only,json=sameKeyOnly,proto3" json:"same_key_only,omitempty"`          // Only same/compatible keys
+	MaxEnergyDelta int32                  `protobuf:"varint,3,opt,name=max_energy_delta,json=maxEnergyDelta,proto3" json:"max_energy_delta,omitempty"` // Max energy level difference
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SimilarityConstraints) Reset() {
+	*x = SimilarityConstraints{}
+	mi := &file_engine_api_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimilarityConstraints) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimilarityConstraints) ProtoMessage() {}
+
+func (x *SimilarityConstraints) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimilarityConstraints.ProtoReflect.Descriptor instead.
+func (*SimilarityConstraints) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *SimilarityConstraints) GetMaxBpmDelta() float64 {
+	if x != nil {
+		return x.MaxBpmDelta
+	}
+	return 0
+}
+
+func (x *SimilarityConstraints) GetSameKeyOnly() bool {
+	if x != nil {
+		return x.SameKeyOnly
+	}
+	return false
+}
+
+func (x *SimilarityConstraints) GetMaxEnergyDelta() int32 {
+	if x != nil {
+		return x.MaxEnergyDelta
+	}
+	return 0
+}
+
+type SimilarTracksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	QueryTrack    *common.TrackId        `protobuf:"bytes,1,opt,name=query_track,json=queryTrack,proto3" json:"query_track,omitempty"`
+	Similar       []*common.SimilarTrack `protobuf:"bytes,2,rep,name=similar,proto3" json:"similar,omitempty"`
+	QuerySection  *common.SectionSpan    `protobuf:"bytes,3,opt,name=query_section,json=querySection,proto3" json:"query_section,omitempty"` // the part of the query track compared, in section queries
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SimilarTracksResponse) Reset() {
+	*x = SimilarTracksResponse{}
+	mi := &file_engine_api_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SimilarTracksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimilarTracksResponse) ProtoMessage() {}
+
+func (x *SimilarTracksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimilarTracksResponse.ProtoReflect.Descriptor instead.
+func (*SimilarTracksResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *SimilarTracksResponse) GetQueryTrack() *common.TrackId {
+	if x != nil {
+		return x.QueryTrack
+	}
+	return nil
+}
+
+func (x *SimilarTracksResponse) GetSimilar() []*common.SimilarTrack {
+	if x != nil {
+		return x.Similar
+	}
+	return nil
+}
+
+func (x *SimilarTracksResponse) GetQuerySection() *common.SectionSpan {
+	if x != nil {
+		return x.QuerySection
+	}
+	return nil
+}
+
+type ListLabelsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TrackId       int64                  `protobuf:"varint,1,opt,name=track_id,json=trackId,proto3" json:"track_id,omitempty"`         // Optional filter by track
+	LabelValue    string                 `protobuf:"bytes,2,opt,name=label_value,json=labelValue,proto3" json:"label_value,omitempty"` // Optional filter by label type
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLabelsRequest) Reset() {
+	*x = ListLabelsRequest{}
+	mi := &file_engine_api_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLabelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLabelsRequest) ProtoMessage() {}
+
+func (x *ListLabelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLabelsRequest.ProtoReflect.Descriptor instead.
+func (*ListLabelsRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ListLabelsRequest) GetTrackId() int64 {
+	if x != nil {
+		return x.TrackId
+	}
+	return 0
+}
+
+func (x *ListLabelsRequest) GetLabelValue() string {
+	if x != nil {
+		return x.LabelValue
+	}
+	return ""
+}
+
+func (x *ListLabelsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListLabelsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListLabelsResponse struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Labels        []*common.TrainingLabel `protobuf:"bytes,1,rep,name=labels,proto3" json:"labels,omitempty"`
+	Total         int32                   `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLabelsResponse) Reset() {
+	*x = ListLabelsResponse{}
+	mi := &file_engine_api_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLabelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLabelsResponse) ProtoMessage() {}
+
+func (x *ListLabelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLabelsResponse.ProtoReflect.Descriptor instead.
+func (*ListLabelsResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ListLabelsResponse) GetLabels() []*common.TrainingLabel {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *ListLabelsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type AddLabelRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	TrackId          int64                  `protobuf:"varint,1,opt,name=track_id,json=trackId,proto3" json:"track_id,omitempty"`
+	LabelValue       string                 `protobuf:"bytes,2,opt,name=label_value,json=labelValue,proto3" json:"label_value,omitempty"` // intro, build, drop, break, outro, verse, chorus
+	StartBeat        int32                  `protobuf:"varint,3,opt,name=start_beat,json=startBeat,proto3" json:"start_beat,omitempty"`
+	EndBeat          int32                  `protobuf:"varint,4,opt,name=end_beat,json=endBeat,proto3" json:"end_beat,omitempty"`
+	StartTimeSeconds float64                `protobuf:"fixed64,5,opt,name=start_time_seconds,json=startTimeSeconds,proto3" json:"start_time_seconds,omitempty"`
+	EndTimeSeconds   float64                `protobuf:"fixed64,6,opt,name=end_time_seconds,json=endTimeSeconds,proto3" json:"end_time_seconds,omitempty"`
+	Source           string                 `protobuf:"bytes,7,opt,name=source,proto3" json:"source,omitempty"` // user, auto_detected, imported
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *AddLabelRequest) Reset() {
+	*x = AddLabelRequest{}
+	mi := &file_engine_api_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddLabelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddLabelRequest) ProtoMessage() {}
+
+func (x *AddLabelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddLabelRequest.ProtoReflect.Descriptor instead.
+func (*AddLabelRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *AddLabelRequest) GetTrackId() int64 {
+	if x != nil {
+		return x.TrackId
+	}
+	return 0
+}
+
+func (x *AddLabelRequest) GetLabelValue() string {
+	if x != nil {
+		return x.LabelValue
+	}
+	return ""
+}
+
+func (x *AddLabelRequest) GetStartBeat() int32 {
+	if x != nil {
+		return x.StartBeat
+	}
+	return 0
+}
+
+func (x *AddLabelRequest) GetEndBeat() int32 {
+	if x != nil {
+		return x.EndBeat
+	}
+	return 0
+}
+
+func (x *AddLabelRequest) GetStartTimeSeconds() float64 {
+	if x != nil {
+		return x.StartTimeSeconds
+	}
+	return 0
+}
+
+func (x *AddLabelRequest) GetEndTimeSeconds() float64 {
+	if x != nil {
+		return x.EndTimeSeconds
+	}
+	return 0
+}
+
+func (x *AddLabelRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+type AddLabelResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddLabelResponse) Reset() {
+	*x = AddLabelResponse{}
+	mi := &file_engine_api_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddLabelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddLabelResponse) ProtoMessage() {}
+
+func (x *AddLabelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddLabelResponse.ProtoReflect.Descriptor instead.
+func (*AddLabelResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *AddLabelResponse) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AddLabelResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type DeleteLabelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteLabelRequest) Reset() {
+	*x = DeleteLabelRequest{}
+	mi := &file_engine_api_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteLabelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteLabelRequest) ProtoMessage() {}
+
+func (x *DeleteLabelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteLabelRequest.ProtoReflect.Descriptor instead.
+func (*DeleteLabelRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *DeleteLabelRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type StartTrainingRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	MaxEpochs       int32                  `protobuf:"varint,1,opt,name=max_epochs,json=maxEpochs,proto3" json:"max_epochs,omitempty"`                    // Optional, default 10
+	ValidationSplit float32                `protobuf:"fixed32,2,opt,name=validation_split,json=validationSplit,proto3" json:"validation_split,omitempty"` // Optional, default 0.2
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *StartTrainingRequest) Reset() {
+	*x = StartTrainingRequest{}
+	mi := &file_engine_api_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartTrainingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartTrainingRequest) ProtoMessage() {}
+
+func (x *StartTrainingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartTrainingRequest.ProtoReflect.Descriptor instead.
+func (*StartTrainingRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *StartTrainingRequest) GetMaxEpochs() int32 {
+	if x != nil {
+		return x.MaxEpochs
+	}
+	return 0
+}
+
+func (x *StartTrainingRequest) GetValidationSplit() float32 {
+	if x != nil {
+		return x.ValidationSplit
+	}
+	return 0
+}
+
+type StartTrainingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartTrainingResponse) Reset() {
+	*x = StartTrainingResponse{}
+	mi := &file_engine_api_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartTrainingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartTrainingResponse) ProtoMessage() {}
+
+func (x *StartTrainingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartTrainingResponse.ProtoReflect.Descriptor instead.
+func (*StartTrainingResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *StartTrainingResponse) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *StartTrainingResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type GetJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobRequest) Reset() {
+	*x = GetJobRequest{}
+	mi := &file_engine_api_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobRequest) ProtoMessage() {}
+
+func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobRequest.ProtoReflect.Descriptor instead.
+func (*GetJobRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *GetJobRequest) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+type ListJobsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsRequest) Reset() {
+	*x = ListJobsRequest{}
+	mi := &file_engine_api_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsRequest) ProtoMessage() {}
+
+func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *ListJobsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListJobsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Jobs          []*common.TrainingJob  `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsResponse) Reset() {
+	*x = ListJobsResponse{}
+	mi := &file_engine_api_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsResponse) ProtoMessage() {}
+
+func (x *ListJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListJobsResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *ListJobsResponse) GetJobs() []*common.TrainingJob {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+type TrainingProgressUpdate struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	JobId        string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Status       common.TrainingStatus  `protobuf:"varint,2,opt,name=status,proto3,enum=cartomix.common.TrainingStatus" json:"status,omitempty"`
+	Progress     float32                `protobuf:"fixed32,3,opt,name=progress,proto3" json:"progress,omitempty"`
+	CurrentEpoch int32                  `protobuf:"varint,4,opt,name=current_epoch,json=currentEpoch,proto3" json:"current_epoch,omitempty"`
+	TotalEpochs  int32                  `protobuf:"varint,5,opt,name=total_epochs,json=totalEpochs,proto3" json:"total_epochs,omitempty"`
+	CurrentLoss  float32                `protobuf:"fixed32,6,opt,name=current_loss,json=currentLoss,proto3" json:"current_loss,omitempty"`
+	Message      string                 `protobuf:"bytes,7,opt,name=message,proto3" json:"message,omitempty"`
+	// Enhanced progress fields (v1.0)
+	ElapsedMs          int64   `protobuf:"varint,8,opt,name=elapsed_ms,json=elapsedMs,proto3" json:"elapsed_ms,omitempty"`                              // Elapsed time in milliseconds
+	EtaMs              int64   `protobuf:"varint,9,opt,name=eta_ms,json=etaMs,proto3" json:"eta_ms,omitempty"`                                          // Estimated time remaining in milliseconds
+	ValidationLoss     float32 `protobuf:"fixed32,10,opt,name=validation_loss,json=validationLoss,proto3" json:"validation_loss,omitempty"`             // Validation loss (if available)
+	ValidationAccuracy float32 `protobuf:"fixed32,11,opt,name=validation_accuracy,json=validationAccuracy,proto3" json:"validation_accuracy,omitempty"` // Validation accuracy (if available)
+	SamplesProcessed   int32   `protobuf:"varint,12,opt,name=samples_processed,json=samplesProcessed,proto3" json:"samples_processed,omitempty"`        // Training samples processed this epoch
+	TotalSamples       int32   `protobuf:"varint,13,opt,name=total_samples,json=totalSamples,proto3" json:"total_samples,omitempty"`                    // Total training samples
+	// Stage breakdown
+	CurrentStage  string         `protobuf:"bytes,14,opt,name=current_stage,json=currentStage,proto3" json:"current_stage,omitempty"` // prepare_data / feature_extract / training / validation / export
+	StageTimings  []*StageTiming `protobuf:"bytes,15,rep,name=stage_timings,json=stageTimings,proto3" json:"stage_timings,omitempty"` // Completed stage timings
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrainingProgressUpdate) Reset() {
+	*x = TrainingProgressUpdate{}
+	mi := &file_engine_api_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrainingProgressUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrainingProgressUpdate) ProtoMessage() {}
+
+func (x *TrainingProgressUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrainingProgressUpdate.ProtoReflect.Descriptor instead.
+func (*TrainingProgressUpdate) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *TrainingProgressUpdate) GetJobId() string {
+	if x != nil {
+		return x.JobId
+	}
+	return ""
+}
+
+func (x *TrainingProgressUpdate) GetStatus() common.TrainingStatus {
+	if x != nil {
+		return x.Status
+	}
+	return common.TrainingStatus(0)
+}
+
+func (x *TrainingProgressUpdate) GetProgress() float32 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *TrainingProgressUpdate) GetCurrentEpoch() int32 {
+	if x != nil {
+		return x.CurrentEpoch
+	}
+	return 0
+}
+
+func (x *TrainingProgressUpdate) GetTotalEpochs() int32 {
+	if x != nil {
+		return x.TotalEpochs
+	}
+	return 0
+}
+
+func (x *TrainingProgressUpdate) GetCurrentLoss() float32 {
+	if x != nil {
+		return x.CurrentLoss
+	}
+	return 0
+}
+
+func (x *TrainingProgressUpdate) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TrainingProgressUpdate) GetElapsedMs() int64 {
+	if x != nil {
+		return x.ElapsedMs
+	}
+	return 0
+}
+
+func (x *TrainingProgressUpdate) GetEtaMs() int64 {
+	if x != nil {
+		return x.EtaMs
+	}
+	return 0
+}
+
+func (x *TrainingProgressUpdate) GetValidationLoss() float32 {
+	if x != nil {
+		return x.ValidationLoss
+	}
+	return 0
+}
+
+func (x *TrainingProgressUpdate) GetValidationAccuracy() float32 {
+	if x != nil {
+		return x.ValidationAccuracy
+	}
+	return 0
+}
+
+func (x *TrainingProgressUpdate) GetSamplesProcessed() int32 {
+	if x != nil {
+		return x.SamplesProcessed
+	}
+	return 0
+}
+
+func (x *TrainingProgressUpdate) GetTotalSamples() int32 {
+	if x != nil {
+		return x.TotalSamples
+	}
+	return 0
+}
+
+func (x *TrainingProgressUpdate) GetCurrentStage() string {
+	if x != nil {
+		return x.CurrentStage
+	}
+	return ""
+}
+
+func (x *TrainingProgressUpdate) GetStageTimings() []*StageTiming {
+	if x != nil {
+		return x.StageTimings
+	}
+	return nil
+}
+
+type ListModelsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelType     string                 `protobuf:"bytes,1,opt,name=model_type,json=modelType,proto3" json:"model_type,omitempty"` // e.g., "dj_section"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListModelsRequest) Reset() {
+	*x = ListModelsRequest{}
+	mi := &file_engine_api_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListModelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListModelsRequest) ProtoMessage() {}
+
+func (x *ListModelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListModelsRequest.ProtoReflect.Descriptor instead.
+func (*ListModelsRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *ListModelsRequest) GetModelType() string {
+	if x != nil {
+		return x.ModelType
+	}
+	return ""
+}
+
+type ListModelsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Versions      []*common.ModelVersion `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListModelsResponse) Reset() {
+	*x = ListModelsResponse{}
+	mi := &file_engine_api_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListModelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListModelsResponse) ProtoMessage() {}
+
+func (x *ListModelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListModelsResponse.ProtoReflect.Descriptor instead.
+func (*ListModelsResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *ListModelsResponse) GetVersions() []*common.ModelVersion {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type ActivateModelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelType     string                 `protobuf:"bytes,1,opt,name=model_type,json=modelType,proto3" json:"model_type,omitempty"`
+	Version       int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ActivateModelRequest) Reset() {
+	*x = ActivateModelRequest{}
+	mi := &file_engine_api_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActivateModelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActivateModelRequest) ProtoMessage() {}
+
+func (x *ActivateModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActivateModelRequest.ProtoReflect.Descriptor instead.
+func (*ActivateModelRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *ActivateModelRequest) GetModelType() string {
+	if x != nil {
+		return x.ModelType
+	}
+	return ""
+}
+
+func (x *ActivateModelRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type DeleteModelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelType     string                 `protobuf:"bytes,1,opt,name=model_type,json=modelType,proto3" json:"model_type,omitempty"`
+	Version       int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteModelRequest) Reset() {
+	*x = DeleteModelRequest{}
+	mi := &file_engine_api_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteModelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteModelRequest) ProtoMessage() {}
+
+func (x *DeleteModelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteModelRequest.ProtoReflect.Descriptor instead.
+func (*DeleteModelRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *DeleteModelRequest) GetModelType() string {
+	if x != nil {
+		return x.ModelType
+	}
+	return ""
+}
+
+func (x *DeleteModelRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type HealthResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	UptimeSeconds int64                  `protobuf:"varint,3,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	Services      map[string]string      `protobuf:"bytes,4,rep,name=services,proto3" json:"services,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // service name -> status
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	mi := &file_engine_api_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *HealthResponse) GetHealthy() bool {
 	if x != nil {
-		return x.EndBeat
+		return x.Healthy
 	}
-	return 0
+	return false
 }
 
-func (x *AddLabelRequest) GetStartTimeSeconds() float64 {
+func (x *HealthResponse) GetVersion() string {
 	if x != nil {
-		return x.StartTimeSeconds
+		return x.Version
 	}
-	return 0
+	return ""
 }
 
-func (x *AddLabelRequest) GetEndTimeSeconds() float64 {
+func (x *HealthResponse) GetUptimeSeconds() int64 {
 	if x != nil {
-		return x.EndTimeSeconds
+		return x.UptimeSeconds
 	}
 	return 0
 }
 
-func (x *AddLabelRequest) GetSource() string {
+func (x *HealthResponse) GetServices() map[string]string {
 	if x != nil {
-		return x.Source
+		return x.Services
 	}
-	return ""
+	return nil
 }
 
-type AddLabelResponse struct {
+type LibraryRoot struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Watching      bool                   `protobuf:"varint,3,opt,name=watching,proto3" json:"watching,omitempty"`                                  // false when the engine runs without --watch
+	CreatedAt     int64                  `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`               // Unix timestamp
+	LastScannedAt int64                  `protobuf:"varint,5,opt,name=last_scanned_at,json=lastScannedAt,proto3" json:"last_scanned_at,omitempty"` // Unix timestamp, 0 until the first full scan
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AddLabelResponse) Reset() {
-	*x = AddLabelResponse{}
-	mi := &file_engine_api_proto_msgTypes[17]
+func (x *LibraryRoot) Reset() {
+	*x = LibraryRoot{}
+	mi := &file_engine_api_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AddLabelResponse) String() string {
+func (x *LibraryRoot) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AddLabelResponse) ProtoMessage() {}
+func (*LibraryRoot) ProtoMessage() {}
 
-func (x *AddLabelResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[17]
+func (x *LibraryRoot) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1397,47 +4102,68 @@ func (x *AddLabelResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AddLabelResponse.ProtoReflect.Descriptor instead.
-func (*AddLabelResponse) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use LibraryRoot.ProtoReflect.Descriptor instead.
+func (*LibraryRoot) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{55}
 }
 
-func (x *AddLabelResponse) GetId() int64 {
+func (x *LibraryRoot) GetId() int64 {
 	if x != nil {
 		return x.Id
 	}
 	return 0
 }
 
-func (x *AddLabelResponse) GetMessage() string {
+func (x *LibraryRoot) GetPath() string {
 	if x != nil {
-		return x.Message
+		return x.Path
 	}
 	return ""
 }
 
-type DeleteLabelRequest struct {
+func (x *LibraryRoot) GetWatching() bool {
+	if x != nil {
+		return x.Watching
+	}
+	return false
+}
+
+func (x *LibraryRoot) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *LibraryRoot) GetLastScannedAt() int64 {
+	if x != nil {
+		return x.LastScannedAt
+	}
+	return 0
+}
+
+type ListLibraryRootsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Roots         []*LibraryRoot         `protobuf:"bytes,1,rep,name=roots,proto3" json:"roots,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteLabelRequest) Reset() {
-	*x = DeleteLabelRequest{}
-	mi := &file_engine_api_proto_msgTypes[18]
+func (x *ListLibraryRootsResponse) Reset() {
+	*x = ListLibraryRootsResponse{}
+	mi := &file_engine_api_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteLabelRequest) String() string {
+func (x *ListLibraryRootsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteLabelRequest) ProtoMessage() {}
+func (*ListLibraryRootsResponse) ProtoMessage() {}
 
-func (x *DeleteLabelRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[18]
+func (x *ListLibraryRootsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1448,41 +4174,40 @@ func (x *DeleteLabelRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteLabelRequest.ProtoReflect.Descriptor instead.
-func (*DeleteLabelRequest) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use ListLibraryRootsResponse.ProtoReflect.Descriptor instead.
+func (*ListLibraryRootsResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{56}
 }
 
-func (x *DeleteLabelRequest) GetId() int64 {
+func (x *ListLibraryRootsResponse) GetRoots() []*LibraryRoot {
 	if x != nil {
-		return x.Id
+		return x.Roots
 	}
-	return 0
+	return nil
 }
 
-type StartTrainingRequest struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	MaxEpochs       int32                  `protobuf:"varint,1,opt,name=max_epochs,json=maxEpochs,proto3" json:"max_epochs,omitempty"`                    // Optional, default 10
-	ValidationSplit float32                `protobuf:"fixed32,2,opt,name=validation_split,json=validationSplit,proto3" json:"validation_split,omitempty"` // Optional, default 0.2
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+type AddLibraryRootRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StartTrainingRequest) Reset() {
-	*x = StartTrainingRequest{}
-	mi := &file_engine_api_proto_msgTypes[19]
+func (x *AddLibraryRootRequest) Reset() {
+	*x = AddLibraryRootRequest{}
+	mi := &file_engine_api_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StartTrainingRequest) String() string {
+func (x *AddLibraryRootRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StartTrainingRequest) ProtoMessage() {}
+func (*AddLibraryRootRequest) ProtoMessage() {}
 
-func (x *StartTrainingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[19]
+func (x *AddLibraryRootRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1493,48 +4218,41 @@ func (x *StartTrainingRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StartTrainingRequest.ProtoReflect.Descriptor instead.
-func (*StartTrainingRequest) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{19}
-}
-
-func (x *StartTrainingRequest) GetMaxEpochs() int32 {
-	if x != nil {
-		return x.MaxEpochs
-	}
-	return 0
+// Deprecated: Use AddLibraryRootRequest.ProtoReflect.Descriptor instead.
+func (*AddLibraryRootRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{57}
 }
 
-func (x *StartTrainingRequest) GetValidationSplit() float32 {
+func (x *AddLibraryRootRequest) GetPath() string {
 	if x != nil {
-		return x.ValidationSplit
+		return x.Path
 	}
-	return 0
+	return ""
 }
 
-type StartTrainingResponse struct {
+type RemoveLibraryRootRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"` // alternative to id
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StartTrainingResponse) Reset() {
-	*x = StartTrainingResponse{}
-	mi := &file_engine_api_proto_msgTypes[20]
+func (x *RemoveLibraryRootRequest) Reset() {
+	*x = RemoveLibraryRootRequest{}
+	mi := &file_engine_api_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StartTrainingResponse) String() string {
+func (x *RemoveLibraryRootRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StartTrainingResponse) ProtoMessage() {}
+func (*RemoveLibraryRootRequest) ProtoMessage() {}
 
-func (x *StartTrainingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[20]
+func (x *RemoveLibraryRootRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1545,47 +4263,50 @@ func (x *StartTrainingResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StartTrainingResponse.ProtoReflect.Descriptor instead.
-func (*StartTrainingResponse) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{20}
+// Deprecated: Use RemoveLibraryRootRequest.ProtoReflect.Descriptor instead.
+func (*RemoveLibraryRootRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{58}
 }
 
-func (x *StartTrainingResponse) GetJobId() string {
+func (x *RemoveLibraryRootRequest) GetId() int64 {
 	if x != nil {
-		return x.JobId
+		return x.Id
 	}
-	return ""
+	return 0
 }
 
-func (x *StartTrainingResponse) GetMessage() string {
+func (x *RemoveLibraryRootRequest) GetPath() string {
 	if x != nil {
-		return x.Message
+		return x.Path
 	}
 	return ""
 }
 
-type GetJobRequest struct {
+type MissingTrack struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	JobId         string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	TrackId       int64                  `protobuf:"varint,1,opt,name=track_id,json=trackId,proto3" json:"track_id,omitempty"`
+	ContentHash   string                 `protobuf:"bytes,2,opt,name=content_hash,json=contentHash,proto3" json:"content_hash,omitempty"`
+	Path          string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`                                      // last known path
+	MissingSince  int64                  `protobuf:"varint,4,opt,name=missing_since,json=missingSince,proto3" json:"missing_since,omitempty"` // Unix timestamp
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetJobRequest) Reset() {
-	*x = GetJobRequest{}
-	mi := &file_engine_api_proto_msgTypes[21]
+func (x *MissingTrack) Reset() {
+	*x = MissingTrack{}
+	mi := &file_engine_api_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetJobRequest) String() string {
+func (x *MissingTrack) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetJobRequest) ProtoMessage() {}
+func (*MissingTrack) ProtoMessage() {}
 
-func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[21]
+func (x *MissingTrack) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1596,40 +4317,64 @@ func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetJobRequest.ProtoReflect.Descriptor instead.
-func (*GetJobRequest) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{21}
+// Deprecated: Use MissingTrack.ProtoReflect.Descriptor instead.
+func (*MissingTrack) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{59}
 }
 
-func (x *GetJobRequest) GetJobId() string {
+func (x *MissingTrack) GetTrackId() int64 {
 	if x != nil {
-		return x.JobId
+		return x.TrackId
+	}
+	return 0
+}
+
+func (x *MissingTrack) GetContentHash() string {
+	if x != nil {
+		return x.ContentHash
 	}
 	return ""
 }
 
-type ListJobsRequest struct {
+func (x *MissingTrack) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *MissingTrack) GetMissingSince() int64 {
+	if x != nil {
+		return x.MissingSince
+	}
+	return 0
+}
+
+type LibraryHealthResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Checked       int32                  `protobuf:"varint,1,opt,name=checked,proto3" json:"checked,omitempty"`
+	NewlyMissing  int32                  `protobuf:"varint,2,opt,name=newly_missing,json=newlyMissing,proto3" json:"newly_missing,omitempty"` // flagged by this pass
+	Recovered     int32                  `protobuf:"varint,3,opt,name=recovered,proto3" json:"recovered,omitempty"`                           // files back at their recorded path
+	Missing       []*MissingTrack        `protobuf:"bytes,4,rep,name=missing,proto3" json:"missing,omitempty"`                                // every track whose file is gone
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListJobsRequest) Reset() {
-	*x = ListJobsRequest{}
-	mi := &file_engine_api_proto_msgTypes[22]
+func (x *LibraryHealthResponse) Reset() {
+	*x = LibraryHealthResponse{}
+	mi := &file_engine_api_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListJobsRequest) String() string {
+func (x *LibraryHealthResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListJobsRequest) ProtoMessage() {}
+func (*LibraryHealthResponse) ProtoMessage() {}
 
-func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[22]
+func (x *LibraryHealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1640,40 +4385,65 @@ func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListJobsRequest.ProtoReflect.Descriptor instead.
-func (*ListJobsRequest) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{22}
+// Deprecated: Use LibraryHealthResponse.ProtoReflect.Descriptor instead.
+func (*LibraryHealthResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{60}
 }
 
-func (x *ListJobsRequest) GetLimit() int32 {
+func (x *LibraryHealthResponse) GetChecked() int32 {
 	if x != nil {
-		return x.Limit
+		return x.Checked
 	}
 	return 0
 }
 
-type ListJobsResponse struct {
+func (x *LibraryHealthResponse) GetNewlyMissing() int32 {
+	if x != nil {
+		return x.NewlyMissing
+	}
+	return 0
+}
+
+func (x *LibraryHealthResponse) GetRecovered() int32 {
+	if x != nil {
+		return x.Recovered
+	}
+	return 0
+}
+
+func (x *LibraryHealthResponse) GetMissing() []*MissingTrack {
+	if x != nil {
+		return x.Missing
+	}
+	return nil
+}
+
+// Either old_prefix and new_prefix, or search_root.
+type RelocateTracksRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Jobs          []*common.TrainingJob  `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	OldPrefix     string                 `protobuf:"bytes,1,opt,name=old_prefix,json=oldPrefix,proto3" json:"old_prefix,omitempty"`
+	NewPrefix     string                 `protobuf:"bytes,2,opt,name=new_prefix,json=newPrefix,proto3" json:"new_prefix,omitempty"`
+	SearchRoot    string                 `protobuf:"bytes,3,opt,name=search_root,json=searchRoot,proto3" json:"search_root,omitempty"`
+	DryRun        bool                   `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListJobsResponse) Reset() {
-	*x = ListJobsResponse{}
-	mi := &file_engine_api_proto_msgTypes[23]
+func (x *RelocateTracksRequest) Reset() {
+	*x = RelocateTracksRequest{}
+	mi := &file_engine_api_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListJobsResponse) String() string {
+func (x *RelocateTracksRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListJobsResponse) ProtoMessage() {}
+func (*RelocateTracksRequest) ProtoMessage() {}
 
-func (x *ListJobsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[23]
+func (x *RelocateTracksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1684,56 +4454,65 @@ func (x *ListJobsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListJobsResponse.ProtoReflect.Descriptor instead.
-func (*ListJobsResponse) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{23}
+// Deprecated: Use RelocateTracksRequest.ProtoReflect.Descriptor instead.
+func (*RelocateTracksRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{61}
 }
 
-func (x *ListJobsResponse) GetJobs() []*common.TrainingJob {
+func (x *RelocateTracksRequest) GetOldPrefix() string {
 	if x != nil {
-		return x.Jobs
+		return x.OldPrefix
 	}
-	return nil
+	return ""
 }
 
-type TrainingProgressUpdate struct {
-	state        protoimpl.MessageState `protogen:"open.v1"`
-	JobId        string                 `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
-	Status       common.TrainingStatus  `protobuf:"varint,2,opt,name=status,proto3,enum=cartomix.common.TrainingStatus" json:"status,omitempty"`
-	Progress     float32                `protobuf:"fixed32,3,opt,name=progress,proto3" json:"progress,omitempty"`
-	CurrentEpoch int32                  `protobuf:"varint,4,opt,name=current_epoch,json=currentEpoch,proto3" json:"current_epoch,omitempty"`
-	TotalEpochs  int32                  `protobuf:"varint,5,opt,name=total_epochs,json=totalEpochs,proto3" json:"total_epochs,omitempty"`
-	CurrentLoss  float32                `protobuf:"fixed32,6,opt,name=current_loss,json=currentLoss,proto3" json:"current_loss,omitempty"`
-	Message      string                 `protobuf:"bytes,7,opt,name=message,proto3" json:"message,omitempty"`
-	// Enhanced progress fields (v1.0)
-	ElapsedMs          int64   `protobuf:"varint,8,opt,name=elapsed_ms,json=elapsedMs,proto3" json:"elapsed_ms,omitempty"`                              // Elapsed time in milliseconds
-	EtaMs              int64   `protobuf:"varint,9,opt,name=eta_ms,json=etaMs,proto3" json:"eta_ms,omitempty"`                                          // Estimated time remaining in milliseconds
-	ValidationLoss     float32 `protobuf:"fixed32,10,opt,name=validation_loss,json=validationLoss,proto3" json:"validation_loss,omitempty"`             // Validation loss (if available)
-	ValidationAccuracy float32 `protobuf:"fixed32,11,opt,name=validation_accuracy,json=validationAccuracy,proto3" json:"validation_accuracy,omitempty"` // Validation accuracy (if available)
-	SamplesProcessed   int32   `protobuf:"varint,12,opt,name=samples_processed,json=samplesProcessed,proto3" json:"samples_processed,omitempty"`        // Training samples processed this epoch
-	TotalSamples       int32   `protobuf:"varint,13,opt,name=total_samples,json=totalSamples,proto3" json:"total_samples,omitempty"`                    // Total training samples
-	// Stage breakdown
-	CurrentStage  string         `protobuf:"bytes,14,opt,name=current_stage,json=currentStage,proto3" json:"current_stage,omitempty"` // prepare_data / feature_extract / training / validation / export
-	StageTimings  []*StageTiming `protobuf:"bytes,15,rep,name=stage_timings,json=stageTimings,proto3" json:"stage_timings,omitempty"` // Completed stage timings
+func (x *RelocateTracksRequest) GetNewPrefix() string {
+	if x != nil {
+		return x.NewPrefix
+	}
+	return ""
+}
+
+func (x *RelocateTracksRequest) GetSearchRoot() string {
+	if x != nil {
+		return x.SearchRoot
+	}
+	return ""
+}
+
+func (x *RelocateTracksRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type Relocation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TrackId       int64                  `protobuf:"varint,1,opt,name=track_id,json=trackId,proto3" json:"track_id,omitempty"`
+	ContentHash   string                 `protobuf:"bytes,2,opt,name=content_hash,json=contentHash,proto3" json:"content_hash,omitempty"`
+	OldPath       string                 `protobuf:"bytes,3,opt,name=old_path,json=oldPath,proto3" json:"old_path,omitempty"`
+	NewPath       string                 `protobuf:"bytes,4,opt,name=new_path,json=newPath,proto3" json:"new_path,omitempty"`
+	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"` // matched / hash_mismatch / conflict / not_found
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TrainingProgressUpdate) Reset() {
-	*x = TrainingProgressUpdate{}
-	mi := &file_engine_api_proto_msgTypes[24]
+func (x *Relocation) Reset() {
+	*x = Relocation{}
+	mi := &file_engine_api_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TrainingProgressUpdate) String() string {
+func (x *Relocation) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TrainingProgressUpdate) ProtoMessage() {}
+func (*Relocation) ProtoMessage() {}
 
-func (x *TrainingProgressUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[24]
+func (x *Relocation) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1741,141 +4520,140 @@ func (x *TrainingProgressUpdate) ProtoReflect() protoreflect.Message {
 		}
 		return ms
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use TrainingProgressUpdate.ProtoReflect.Descriptor instead.
-func (*TrainingProgressUpdate) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{24}
-}
-
-func (x *TrainingProgressUpdate) GetJobId() string {
-	if x != nil {
-		return x.JobId
-	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *TrainingProgressUpdate) GetStatus() common.TrainingStatus {
-	if x != nil {
-		return x.Status
-	}
-	return common.TrainingStatus(0)
+// Deprecated: Use Relocation.ProtoReflect.Descriptor instead.
+func (*Relocation) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{62}
 }
 
-func (x *TrainingProgressUpdate) GetProgress() float32 {
+func (x *Relocation) GetTrackId() int64 {
 	if x != nil {
-		return x.Progress
+		return x.TrackId
 	}
 	return 0
 }
 
-func (x *TrainingProgressUpdate) GetCurrentEpoch() int32 {
+func (x *Relocation) GetContentHash() string {
 	if x != nil {
-		return x.CurrentEpoch
+		return x.ContentHash
 	}
-	return 0
+	return ""
 }
 
-func (x *TrainingProgressUpdate) GetTotalEpochs() int32 {
+func (x *Relocation) GetOldPath() string {
 	if x != nil {
-		return x.TotalEpochs
+		return x.OldPath
 	}
-	return 0
+	return ""
 }
 
-func (x *TrainingProgressUpdate) GetCurrentLoss() float32 {
+func (x *Relocation) GetNewPath() string {
 	if x != nil {
-		return x.CurrentLoss
+		return x.NewPath
 	}
-	return 0
+	return ""
 }
 
-func (x *TrainingProgressUpdate) GetMessage() string {
+func (x *Relocation) GetStatus() string {
 	if x != nil {
-		return x.Message
+		return x.Status
 	}
 	return ""
 }
 
-func (x *TrainingProgressUpdate) GetElapsedMs() int64 {
-	if x != nil {
-		return x.ElapsedMs
-	}
-	return 0
+type RelocateTracksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DryRun        bool                   `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	Matched       int32                  `protobuf:"varint,2,opt,name=matched,proto3" json:"matched,omitempty"`
+	Unmatched     int32                  `protobuf:"varint,3,opt,name=unmatched,proto3" json:"unmatched,omitempty"`
+	Relocations   []*Relocation          `protobuf:"bytes,4,rep,name=relocations,proto3" json:"relocations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TrainingProgressUpdate) GetEtaMs() int64 {
-	if x != nil {
-		return x.EtaMs
-	}
-	return 0
+func (x *RelocateTracksResponse) Reset() {
+	*x = RelocateTracksResponse{}
+	mi := &file_engine_api_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *TrainingProgressUpdate) GetValidationLoss() float32 {
-	if x != nil {
-		return x.ValidationLoss
-	}
-	return 0
+func (x *RelocateTracksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *TrainingProgressUpdate) GetValidationAccuracy() float32 {
+func (*RelocateTracksResponse) ProtoMessage() {}
+
+func (x *RelocateTracksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[63]
 	if x != nil {
-		return x.ValidationAccuracy
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *TrainingProgressUpdate) GetSamplesProcessed() int32 {
+// Deprecated: Use RelocateTracksResponse.ProtoReflect.Descriptor instead.
+func (*RelocateTracksResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *RelocateTracksResponse) GetDryRun() bool {
 	if x != nil {
-		return x.SamplesProcessed
+		return x.DryRun
 	}
-	return 0
+	return false
 }
 
-func (x *TrainingProgressUpdate) GetTotalSamples() int32 {
+func (x *RelocateTracksResponse) GetMatched() int32 {
 	if x != nil {
-		return x.TotalSamples
+		return x.Matched
 	}
 	return 0
 }
 
-func (x *TrainingProgressUpdate) GetCurrentStage() string {
+func (x *RelocateTracksResponse) GetUnmatched() int32 {
 	if x != nil {
-		return x.CurrentStage
+		return x.Unmatched
 	}
-	return ""
+	return 0
 }
 
-func (x *TrainingProgressUpdate) GetStageTimings() []*StageTiming {
+func (x *RelocateTracksResponse) GetRelocations() []*Relocation {
 	if x != nil {
-		return x.StageTimings
+		return x.Relocations
 	}
 	return nil
 }
 
-type ListModelsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ModelType     string                 `protobuf:"bytes,1,opt,name=model_type,json=modelType,proto3" json:"model_type,omitempty"` // e.g., "dj_section"
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type ListDuplicateGroupsRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	MinVibe          float32                `protobuf:"fixed32,1,opt,name=min_vibe,json=minVibe,proto3" json:"min_vibe,omitempty"`                              // embedding cosine threshold (default 0.97)
+	MaxDurationDelta float64                `protobuf:"fixed64,2,opt,name=max_duration_delta,json=maxDurationDelta,proto3" json:"max_duration_delta,omitempty"` // seconds (default 2)
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *ListModelsRequest) Reset() {
-	*x = ListModelsRequest{}
-	mi := &file_engine_api_proto_msgTypes[25]
+func (x *ListDuplicateGroupsRequest) Reset() {
+	*x = ListDuplicateGroupsRequest{}
+	mi := &file_engine_api_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListModelsRequest) String() string {
+func (x *ListDuplicateGroupsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListModelsRequest) ProtoMessage() {}
+func (*ListDuplicateGroupsRequest) ProtoMessage() {}
 
-func (x *ListModelsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[25]
+func (x *ListDuplicateGroupsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1886,40 +4664,56 @@ func (x *ListModelsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListModelsRequest.ProtoReflect.Descriptor instead.
-func (*ListModelsRequest) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{25}
+// Deprecated: Use ListDuplicateGroupsRequest.ProtoReflect.Descriptor instead.
+func (*ListDuplicateGroupsRequest) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{64}
 }
 
-func (x *ListModelsRequest) GetModelType() string {
+func (x *ListDuplicateGroupsRequest) GetMinVibe() float32 {
 	if x != nil {
-		return x.ModelType
+		return x.MinVibe
 	}
-	return ""
+	return 0
 }
 
-type ListModelsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Versions      []*common.ModelVersion `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *ListDuplicateGroupsRequest) GetMaxDurationDelta() float64 {
+	if x != nil {
+		return x.MaxDurationDelta
+	}
+	return 0
 }
 
-func (x *ListModelsResponse) Reset() {
-	*x = ListModelsResponse{}
-	mi := &file_engine_api_proto_msgTypes[26]
+type DuplicateMember struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              *common.TrackId        `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Path            string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Title           string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Artist          string                 `protobuf:"bytes,4,opt,name=artist,proto3" json:"artist,omitempty"`
+	Format          string                 `protobuf:"bytes,5,opt,name=format,proto3" json:"format,omitempty"` // file extension, e.g. "flac"
+	Lossless        bool                   `protobuf:"varint,6,opt,name=lossless,proto3" json:"lossless,omitempty"`
+	BitrateKbps     float64                `protobuf:"fixed64,7,opt,name=bitrate_kbps,json=bitrateKbps,proto3" json:"bitrate_kbps,omitempty"` // estimated from file size and duration
+	DurationSeconds float64                `protobuf:"fixed64,8,opt,name=duration_seconds,json=durationSeconds,proto3" json:"duration_seconds,omitempty"`
+	VibeMatch       float32                `protobuf:"fixed32,9,opt,name=vibe_match,json=vibeMatch,proto3" json:"vibe_match,omitempty"` // embedding cosine to the preferred member, 0 if unknown
+	Preferred       bool                   `protobuf:"varint,10,opt,name=preferred,proto3" json:"preferred,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DuplicateMember) Reset() {
+	*x = DuplicateMember{}
+	mi := &file_engine_api_proto_msgTypes[65]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListModelsResponse) String() string {
+func (x *DuplicateMember) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListModelsResponse) ProtoMessage() {}
+func (*DuplicateMember) ProtoMessage() {}
 
-func (x *ListModelsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[26]
+func (x *DuplicateMember) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[65]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1930,93 +4724,105 @@ func (x *ListModelsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListModelsResponse.ProtoReflect.Descriptor instead.
-func (*ListModelsResponse) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{26}
+// Deprecated: Use DuplicateMember.ProtoReflect.Descriptor instead.
+func (*DuplicateMember) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{65}
 }
 
-func (x *ListModelsResponse) GetVersions() []*common.ModelVersion {
+func (x *DuplicateMember) GetId() *common.TrackId {
 	if x != nil {
-		return x.Versions
+		return x.Id
 	}
 	return nil
 }
 
-type ActivateModelRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ModelType     string                 `protobuf:"bytes,1,opt,name=model_type,json=modelType,proto3" json:"model_type,omitempty"`
-	Version       int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *DuplicateMember) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
 }
 
-func (x *ActivateModelRequest) Reset() {
-	*x = ActivateModelRequest{}
-	mi := &file_engine_api_proto_msgTypes[27]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *DuplicateMember) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
 }
 
-func (x *ActivateModelRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *DuplicateMember) GetArtist() string {
+	if x != nil {
+		return x.Artist
+	}
+	return ""
 }
 
-func (*ActivateModelRequest) ProtoMessage() {}
+func (x *DuplicateMember) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
 
-func (x *ActivateModelRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[27]
+func (x *DuplicateMember) GetLossless() bool {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Lossless
 	}
-	return mi.MessageOf(x)
+	return false
 }
 
-// Deprecated: Use ActivateModelRequest.ProtoReflect.Descriptor instead.
-func (*ActivateModelRequest) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{27}
+func (x *DuplicateMember) GetBitrateKbps() float64 {
+	if x != nil {
+		return x.BitrateKbps
+	}
+	return 0
 }
 
-func (x *ActivateModelRequest) GetModelType() string {
+func (x *DuplicateMember) GetDurationSeconds() float64 {
 	if x != nil {
-		return x.ModelType
+		return x.DurationSeconds
 	}
-	return ""
+	return 0
 }
 
-func (x *ActivateModelRequest) GetVersion() int32 {
+func (x *DuplicateMember) GetVibeMatch() float32 {
 	if x != nil {
-		return x.Version
+		return x.VibeMatch
 	}
 	return 0
 }
 
-type DeleteModelRequest struct {
+func (x *DuplicateMember) GetPreferred() bool {
+	if x != nil {
+		return x.Preferred
+	}
+	return false
+}
+
+type DuplicateGroup struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ModelType     string                 `protobuf:"bytes,1,opt,name=model_type,json=modelType,proto3" json:"model_type,omitempty"`
-	Version       int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Preferred     *common.TrackId        `protobuf:"bytes,1,opt,name=preferred,proto3" json:"preferred,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`   // embedding / tags
+	Members       []*DuplicateMember     `protobuf:"bytes,3,rep,name=members,proto3" json:"members,omitempty"` // best quality first
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteModelRequest) Reset() {
-	*x = DeleteModelRequest{}
-	mi := &file_engine_api_proto_msgTypes[28]
+func (x *DuplicateGroup) Reset() {
+	*x = DuplicateGroup{}
+	mi := &file_engine_api_proto_msgTypes[66]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteModelRequest) String() string {
+func (x *DuplicateGroup) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteModelRequest) ProtoMessage() {}
+func (*DuplicateGroup) ProtoMessage() {}
 
-func (x *DeleteModelRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[28]
+func (x *DuplicateGroup) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[66]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2027,50 +4833,54 @@ func (x *DeleteModelRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteModelRequest.ProtoReflect.Descriptor instead.
-func (*DeleteModelRequest) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{28}
+// Deprecated: Use DuplicateGroup.ProtoReflect.Descriptor instead.
+func (*DuplicateGroup) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{66}
 }
 
-func (x *DeleteModelRequest) GetModelType() string {
+func (x *DuplicateGroup) GetPreferred() *common.TrackId {
 	if x != nil {
-		return x.ModelType
+		return x.Preferred
+	}
+	return nil
+}
+
+func (x *DuplicateGroup) GetReason() string {
+	if x != nil {
+		return x.Reason
 	}
 	return ""
 }
 
-func (x *DeleteModelRequest) GetVersion() int32 {
+func (x *DuplicateGroup) GetMembers() []*DuplicateMember {
 	if x != nil {
-		return x.Version
+		return x.Members
 	}
-	return 0
+	return nil
 }
 
-type HealthResponse struct {
+type ListDuplicateGroupsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
-	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
-	UptimeSeconds int64                  `protobuf:"varint,3,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
-	Services      map[string]string      `protobuf:"bytes,4,rep,name=services,proto3" json:"services,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // service name -> status
+	Groups        []*DuplicateGroup      `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthResponse) Reset() {
-	*x = HealthResponse{}
-	mi := &file_engine_api_proto_msgTypes[29]
+func (x *ListDuplicateGroupsResponse) Reset() {
+	*x = ListDuplicateGroupsResponse{}
+	mi := &file_engine_api_proto_msgTypes[67]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthResponse) String() string {
+func (x *ListDuplicateGroupsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthResponse) ProtoMessage() {}
+func (*ListDuplicateGroupsResponse) ProtoMessage() {}
 
-func (x *HealthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_engine_api_proto_msgTypes[29]
+func (x *ListDuplicateGroupsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_engine_api_proto_msgTypes[67]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2081,35 +4891,14 @@ func (x *HealthResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
-func (*HealthResponse) Descriptor() ([]byte, []int) {
-	return file_engine_api_proto_rawDescGZIP(), []int{29}
-}
-
-func (x *HealthResponse) GetHealthy() bool {
-	if x != nil {
-		return x.Healthy
-	}
-	return false
-}
-
-func (x *HealthResponse) GetVersion() string {
-	if x != nil {
-		return x.Version
-	}
-	return ""
-}
-
-func (x *HealthResponse) GetUptimeSeconds() int64 {
-	if x != nil {
-		return x.UptimeSeconds
-	}
-	return 0
+// Deprecated: Use ListDuplicateGroupsResponse.ProtoReflect.Descriptor instead.
+func (*ListDuplicateGroupsResponse) Descriptor() ([]byte, []int) {
+	return file_engine_api_proto_rawDescGZIP(), []int{67}
 }
 
-func (x *HealthResponse) GetServices() map[string]string {
+func (x *ListDuplicateGroupsResponse) GetGroups() []*DuplicateGroup {
 	if x != nil {
-		return x.Services
+		return x.Groups
 	}
 	return nil
 }
@@ -2121,7 +4910,7 @@ const file_engine_api_proto_rawDesc = "" +
 	"\x10engine/api.proto\x12\x0fcartomix.engine\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x12common/types.proto\"F\n" +
 	"\vScanRequest\x12\x14\n" +
 	"\x05roots\x18\x01 \x03(\tR\x05roots\x12!\n" +
-	"\fforce_rescan\x18\x02 \x01(\bR\vforceRescan\"\x92\x03\n" +
+	"\fforce_rescan\x18\x02 \x01(\bR\vforceRescan\"\xfa\x03\n" +
 	"\fScanProgress\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\x12\x14\n" +
@@ -2138,7 +4927,12 @@ const file_engine_api_proto_rawDesc = "" +
 	"\x0eskipped_cached\x18\v \x01(\x03R\rskippedCached\x12'\n" +
 	"\x0fbytes_processed\x18\f \x01(\x03R\x0ebytesProcessed\x12\x1f\n" +
 	"\vbytes_total\x18\r \x01(\x03R\n" +
-	"bytesTotal\"\xc1\x01\n" +
+	"bytesTotal\x12!\n" +
+	"\fcontent_hash\x18\x0e \x01(\tR\vcontentHash\x12#\n" +
+	"\rcollides_with\x18\x0f \x01(\tR\fcollidesWith\x12\x1e\n" +
+	"\n" +
+	"collisions\x18\x10 \x01(\x03R\n" +
+	"collisions\"\xc1\x01\n" +
 	"\x0eAnalyzeRequest\x12\x14\n" +
 	"\x05paths\x18\x01 \x03(\tR\x05paths\x125\n" +
 	"\ttrack_ids\x18\x02 \x03(\v2\x18.cartomix.common.TrackIdR\btrackIds\x12\x14\n" +
@@ -2176,7 +4970,7 @@ const file_engine_api_proto_rawDesc = "" +
 	"\border_by\x18\x03 \x01(\tR\aorderBy\x12\x14\n" +
 	"\x05limit\x18\x04 \x01(\x05R\x05limit\";\n" +
 	"\x0fGetTrackRequest\x12(\n" +
-	"\x02id\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\x02id\"\xa2\x02\n" +
+	"\x02id\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\x02id\"\xd7\t\n" +
 	"\x0eSetPlanRequest\x125\n" +
 	"\ttrack_ids\x18\x01 \x03(\v2\x18.cartomix.common.TrackIdR\btrackIds\x12,\n" +
 	"\x04mode\x18\x02 \x01(\x0e2\x18.cartomix.engine.SetModeR\x04mode\x12&\n" +
@@ -2184,10 +4978,154 @@ const file_engine_api_proto_rawDesc = "" +
 	"\fmax_bpm_step\x18\x04 \x01(\x01R\n" +
 	"maxBpmStep\x125\n" +
 	"\tmust_play\x18\x05 \x03(\v2\x18.cartomix.common.TrackIdR\bmustPlay\x12*\n" +
-	"\x03ban\x18\x06 \x03(\v2\x18.cartomix.common.TrackIdR\x03ban\"\x87\x01\n" +
+	"\x03ban\x18\x06 \x03(\v2\x18.cartomix.common.TrackIdR\x03ban\x12/\n" +
+	"\x13collapse_duplicates\x18\a \x01(\bR\x12collapseDuplicates\x12$\n" +
+	"\x0etime_budget_ms\x18\b \x01(\x05R\ftimeBudgetMs\x12?\n" +
+	"\fenergy_curve\x18\t \x01(\v2\x1c.cartomix.engine.EnergyCurveR\venergyCurve\x12%\n" +
+	"\x0etarget_minutes\x18\n" +
+	" \x01(\x01R\rtargetMinutes\x12+\n" +
+	"\x11tolerance_minutes\x18\v \x01(\x01R\x10toleranceMinutes\x12#\n" +
+	"\rplay_fraction\x18\f \x01(\x01R\fplayFraction\x12P\n" +
+	"\vkey_weights\x18\r \x03(\v2/.cartomix.engine.SetPlanRequest.KeyWeightsEntryR\n" +
+	"keyWeights\x12\x1f\n" +
+	"\vpitch_range\x18\x0e \x01(\x01R\n" +
+	"pitchRange\x12!\n" +
+	"\fmaster_tempo\x18\x0f \x01(\bR\vmasterTempo\x12\x1f\n" +
+	"\vvibe_weight\x18\x10 \x01(\x01R\n" +
+	"vibeWeight\x120\n" +
+	"\x06opener\x18\x11 \x01(\v2\x18.cartomix.common.TrackIdR\x06opener\x120\n" +
+	"\x06closer\x18\x12 \x01(\v2\x18.cartomix.common.TrackIdR\x06closer\x12A\n" +
+	"\rlocked_chains\x18\x13 \x03(\v2\x1c.cartomix.engine.LockedChainR\flockedChains\x12=\n" +
+	"\vprecedences\x18\x14 \x03(\v2\x1b.cartomix.engine.PrecedenceR\vprecedences\x12E\n" +
+	"\x0eartist_spacing\x18\x15 \x03(\v2\x1e.cartomix.engine.ArtistSpacingR\rartistSpacing\x12\"\n" +
+	"\falternatives\x18\x16 \x01(\x05R\falternatives\x12.\n" +
+	"\x13min_different_edges\x18\x17 \x01(\x05R\x11minDifferentEdges\x12+\n" +
+	"\x11different_openers\x18\x18 \x01(\bR\x10differentOpeners\x1a=\n" +
+	"\x0fKeyWeightsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"?\n" +
+	"\vLockedChain\x120\n" +
+	"\x06tracks\x18\x01 \x03(\v2\x18.cartomix.common.TrackIdR\x06tracks\"n\n" +
+	"\n" +
+	"Precedence\x120\n" +
+	"\x06before\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\x06before\x12.\n" +
+	"\x05after\x18\x02 \x01(\v2\x18.cartomix.common.TrackIdR\x05after\"d\n" +
+	"\rArtistSpacing\x12\x19\n" +
+	"\bartist_a\x18\x01 \x01(\tR\aartistA\x12\x19\n" +
+	"\bartist_b\x18\x02 \x01(\tR\aartistB\x12\x1d\n" +
+	"\n" +
+	"min_tracks\x18\x03 \x01(\x05R\tminTracks\"s\n" +
+	"\vEnergyCurve\x12\x16\n" +
+	"\x06preset\x18\x01 \x01(\tR\x06preset\x124\n" +
+	"\x06points\x18\x02 \x03(\v2\x1c.cartomix.engine.EnergyPointR\x06points\x12\x16\n" +
+	"\x06weight\x18\x03 \x01(\x02R\x06weight\"e\n" +
+	"\vEnergyPoint\x12\x1c\n" +
+	"\bposition\x18\x01 \x01(\x01H\x00R\bposition\x12\x1a\n" +
+	"\aminutes\x18\x02 \x01(\x01H\x00R\aminutes\x12\x16\n" +
+	"\x06energy\x18\x03 \x01(\x02R\x06energyB\x04\n" +
+	"\x02at\"\xb0\x01\n" +
+	"\n" +
+	"EnergySlot\x12\x12\n" +
+	"\x04slot\x18\x01 \x01(\x05R\x04slot\x12(\n" +
+	"\x02id\x18\x02 \x01(\v2\x18.cartomix.common.TrackIdR\x02id\x12\x1a\n" +
+	"\bposition\x18\x03 \x01(\x01R\bposition\x12#\n" +
+	"\rtarget_energy\x18\x04 \x01(\x02R\ftargetEnergy\x12#\n" +
+	"\ractual_energy\x18\x05 \x01(\x02R\factualEnergy\"\xfd\x03\n" +
 	"\x0fSetPlanResponse\x12.\n" +
 	"\x05order\x18\x01 \x03(\v2\x18.cartomix.common.TrackIdR\x05order\x12D\n" +
-	"\fexplanations\x18\x02 \x03(\v2 .cartomix.common.EdgeExplanationR\fexplanations\"\x87\x02\n" +
+	"\fexplanations\x18\x02 \x03(\v2 .cartomix.common.EdgeExplanationR\fexplanations\x126\n" +
+	"\tcollapsed\x18\x03 \x03(\v2\x18.cartomix.common.TrackIdR\tcollapsed\x12\x1f\n" +
+	"\vtotal_score\x18\x04 \x01(\x01R\n" +
+	"totalScore\x12E\n" +
+	"\rweakest_edges\x18\x05 \x03(\v2 .cartomix.common.EdgeExplanationR\fweakestEdges\x12>\n" +
+	"\fenergy_slots\x18\x06 \x03(\v2\x1b.cartomix.engine.EnergySlotR\venergySlots\x12!\n" +
+	"\fenergy_error\x18\a \x01(\x02R\venergyError\x12+\n" +
+	"\x11estimated_seconds\x18\b \x01(\x01R\x10estimatedSeconds\x12D\n" +
+	"\falternatives\x18\t \x03(\v2 .cartomix.engine.AlternativePlanR\falternatives\"\xae\x03\n" +
+	"\x0fAlternativePlan\x12.\n" +
+	"\x05order\x18\x01 \x03(\v2\x18.cartomix.common.TrackIdR\x05order\x12D\n" +
+	"\fexplanations\x18\x02 \x03(\v2 .cartomix.common.EdgeExplanationR\fexplanations\x12\x1f\n" +
+	"\vtotal_score\x18\x03 \x01(\x01R\n" +
+	"totalScore\x12E\n" +
+	"\rweakest_edges\x18\x04 \x03(\v2 .cartomix.common.EdgeExplanationR\fweakestEdges\x12>\n" +
+	"\fenergy_slots\x18\x05 \x03(\v2\x1b.cartomix.engine.EnergySlotR\venergySlots\x12!\n" +
+	"\fenergy_error\x18\x06 \x01(\x02R\venergyError\x12+\n" +
+	"\x11estimated_seconds\x18\a \x01(\x01R\x10estimatedSeconds\x12-\n" +
+	"\x04diff\x18\b \x01(\v2\x19.cartomix.engine.PlanDiffR\x04diff\"\xcd\x02\n" +
+	"\bPlanDiff\x12'\n" +
+	"\x0fdifferent_edges\x18\x01 \x01(\x05R\x0edifferentEdges\x12=\n" +
+	"\tnew_edges\x18\x02 \x03(\v2 .cartomix.common.EdgeExplanationR\bnewEdges\x12)\n" +
+	"\x10different_opener\x18\x03 \x01(\bR\x0fdifferentOpener\x12)\n" +
+	"\x10first_difference\x18\x04 \x01(\x05R\x0ffirstDifference\x12.\n" +
+	"\x05added\x18\x05 \x03(\v2\x18.cartomix.common.TrackIdR\x05added\x122\n" +
+	"\adropped\x18\x06 \x03(\v2\x18.cartomix.common.TrackIdR\adropped\x12\x1f\n" +
+	"\vscore_delta\x18\a \x01(\x01R\n" +
+	"scoreDelta\"\xcc\x01\n" +
+	"\x18SuggestTransitionRequest\x12,\n" +
+	"\x04from\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\x04from\x12(\n" +
+	"\x02to\x18\x02 \x01(\v2\x18.cartomix.common.TrackIdR\x02to\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x1f\n" +
+	"\vpitch_range\x18\x04 \x01(\x01R\n" +
+	"pitchRange\x12!\n" +
+	"\fmaster_tempo\x18\x05 \x01(\bR\vmasterTempo\"d\n" +
+	"\x19SuggestTransitionResponse\x12G\n" +
+	"\vsuggestions\x18\x01 \x03(\v2%.cartomix.common.TransitionSuggestionR\vsuggestions\"\xb8\x03\n" +
+	"\vLiveSession\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\x03R\tsessionId\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x02 \x01(\x03R\tstartedAt\x12\x19\n" +
+	"\bended_at\x18\x03 \x01(\x03R\aendedAt\x121\n" +
+	"\x06played\x18\x04 \x03(\v2\x19.cartomix.engine.LivePlayR\x06played\x12:\n" +
+	"\vnow_playing\x18\x05 \x01(\v2\x19.cartomix.engine.LivePlayR\n" +
+	"nowPlaying\x121\n" +
+	"\anext_up\x18\x06 \x03(\v2\x18.cartomix.common.TrackIdR\x06nextUp\x12D\n" +
+	"\fexplanations\x18\a \x03(\v2 .cartomix.common.EdgeExplanationR\fexplanations\x12'\n" +
+	"\x0felapsed_seconds\x18\b \x01(\x01R\x0eelapsedSeconds\x12+\n" +
+	"\x11estimated_seconds\x18\t \x01(\x01R\x10estimatedSeconds\x12\x12\n" +
+	"\x04note\x18\n" +
+	" \x01(\tR\x04note\"S\n" +
+	"\bLivePlay\x12(\n" +
+	"\x02id\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\x02id\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x02 \x01(\x03R\tstartedAt\"3\n" +
+	"\x12LiveSessionRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\x03R\tsessionId\"y\n" +
+	"\x0fLivePlayRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\x03R\tsessionId\x12(\n" +
+	"\x02id\x18\x02 \x01(\v2\x18.cartomix.common.TrackIdR\x02id\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x03 \x01(\x03R\tstartedAt\"\xa0\x02\n" +
+	"\bSavedSet\x12\x15\n" +
+	"\x06set_id\x18\x01 \x01(\x03R\x05setId\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x14\n" +
+	"\x05notes\x18\x04 \x01(\tR\x05notes\x125\n" +
+	"\ttrack_ids\x18\x05 \x03(\v2\x18.cartomix.common.TrackIdR\btrackIds\x12D\n" +
+	"\fexplanations\x18\x06 \x03(\v2 .cartomix.common.EdgeExplanationR\fexplanations\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\x03R\tupdatedAt\"\xce\x01\n" +
+	"\x0eSaveSetRequest\x12\x15\n" +
+	"\x06set_id\x18\x01 \x01(\x03R\x05setId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05notes\x18\x03 \x01(\tR\x05notes\x125\n" +
+	"\ttrack_ids\x18\x04 \x03(\v2\x18.cartomix.common.TrackIdR\btrackIds\x12D\n" +
+	"\fexplanations\x18\x05 \x03(\v2 .cartomix.common.EdgeExplanationR\fexplanations\"@\n" +
+	"\rGetSetRequest\x12\x15\n" +
+	"\x06set_id\x18\x01 \x01(\x03R\x05setId\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\"A\n" +
+	"\x10ListSetsResponse\x12-\n" +
+	"\x04sets\x18\x01 \x03(\v2\x19.cartomix.engine.SavedSetR\x04sets\"/\n" +
+	"\x16ListSetVersionsRequest\x12\x15\n" +
+	"\x06set_id\x18\x01 \x01(\x03R\x05setId\"P\n" +
+	"\x17ListSetVersionsResponse\x125\n" +
+	"\bversions\x18\x01 \x03(\v2\x19.cartomix.engine.SavedSetR\bversions\")\n" +
+	"\x10DeleteSetRequest\x12\x15\n" +
+	"\x06set_id\x18\x01 \x01(\x03R\x05setId\"\xbf\x02\n" +
 	"\rExportRequest\x125\n" +
 	"\ttrack_ids\x18\x01 \x03(\v2\x18.cartomix.common.TrackIdR\btrackIds\x12\x1d\n" +
 	"\n" +
@@ -2195,25 +5133,52 @@ const file_engine_api_proto_rawDesc = "" +
 	"\rplaylist_name\x18\x03 \x01(\tR\fplaylistName\x12+\n" +
 	"\x11include_rekordbox\x18\x04 \x01(\bR\x10includeRekordbox\x12%\n" +
 	"\x0einclude_serato\x18\x05 \x01(\bR\rincludeSerato\x12'\n" +
-	"\x0finclude_traktor\x18\x06 \x01(\bR\x0eincludeTraktor\"\x9c\x01\n" +
+	"\x0finclude_traktor\x18\x06 \x01(\bR\x0eincludeTraktor\x12\x15\n" +
+	"\x06set_id\x18\a \x01(\x03R\x05setId\x12\x1f\n" +
+	"\vset_version\x18\b \x01(\x05R\n" +
+	"setVersion\"\x9c\x01\n" +
 	"\x0eExportResponse\x12#\n" +
 	"\rplaylist_path\x18\x01 \x01(\tR\fplaylistPath\x12#\n" +
 	"\ranalysis_json\x18\x02 \x01(\tR\fanalysisJson\x12\x19\n" +
 	"\bcues_csv\x18\x03 \x01(\tR\acuesCsv\x12%\n" +
-	"\x0evendor_exports\x18\x04 \x03(\tR\rvendorExports\"\xc8\x01\n" +
+	"\x0evendor_exports\x18\x04 \x03(\tR\rvendorExports\"\xd5\x04\n" +
 	"\x14SimilarTracksRequest\x123\n" +
 	"\btrack_id\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\atrackId\x12\x14\n" +
 	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x1b\n" +
 	"\tmin_score\x18\x03 \x01(\x02R\bminScore\x12H\n" +
-	"\vconstraints\x18\x04 \x01(\v2&.cartomix.engine.SimilarityConstraintsR\vconstraints\"\x89\x01\n" +
+	"\vconstraints\x18\x04 \x01(\v2&.cartomix.engine.SimilarityConstraintsR\vconstraints\x12/\n" +
+	"\x13collapse_duplicates\x18\x05 \x01(\bR\x12collapseDuplicates\x12\x14\n" +
+	"\x05exact\x18\x06 \x01(\bR\x05exact\x12<\n" +
+	"\aweights\x18\a \x01(\v2\".cartomix.common.SimilarityWeightsR\aweights\x12\x18\n" +
+	"\aprofile\x18\b \x01(\tR\aprofile\x12.\n" +
+	"\x05seeds\x18\t \x03(\v2\x18.cartomix.common.TrackIdR\x05seeds\x12?\n" +
+	"\x0enegative_seeds\x18\n" +
+	" \x03(\v2\x18.cartomix.common.TrackIdR\rnegativeSeeds\x12B\n" +
+	"\vaggregation\x18\v \x01(\x0e2 .cartomix.engine.SeedAggregationR\vaggregation\x127\n" +
+	"\asection\x18\f \x01(\v2\x1d.cartomix.engine.SectionQueryR\asection\"\xc9\x01\n" +
+	"\fSectionQuery\x123\n" +
+	"\x05label\x18\x01 \x01(\x0e2\x1d.cartomix.common.SectionLabelR\x05label\x12#\n" +
+	"\rstart_seconds\x18\x02 \x01(\x01R\fstartSeconds\x12\x1f\n" +
+	"\vend_seconds\x18\x03 \x01(\x01R\n" +
+	"endSeconds\x12>\n" +
+	"\vmatch_label\x18\x04 \x01(\x0e2\x1d.cartomix.common.SectionLabelR\n" +
+	"matchLabel\"e\n" +
+	"\x11SimilarityProfile\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12<\n" +
+	"\aweights\x18\x02 \x01(\v2\".cartomix.common.SimilarityWeightsR\aweights\"`\n" +
+	"\x1eListSimilarityProfilesResponse\x12>\n" +
+	"\bprofiles\x18\x01 \x03(\v2\".cartomix.engine.SimilarityProfileR\bprofiles\"4\n" +
+	"\x1eDeleteSimilarityProfileRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x89\x01\n" +
 	"\x15SimilarityConstraints\x12\"\n" +
 	"\rmax_bpm_delta\x18\x01 \x01(\x01R\vmaxBpmDelta\x12\"\n" +
 	"\rsame_key_only\x18\x02 \x01(\bR\vsameKeyOnly\x12(\n" +
-	"\x10max_energy_delta\x18\x03 \x01(\x05R\x0emaxEnergyDelta\"\x8b\x01\n" +
+	"\x10max_energy_delta\x18\x03 \x01(\x05R\x0emaxEnergyDelta\"\xce\x01\n" +
 	"\x15SimilarTracksResponse\x129\n" +
 	"\vquery_track\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\n" +
 	"queryTrack\x127\n" +
-	"\asimilar\x18\x02 \x03(\v2\x1d.cartomix.common.SimilarTrackR\asimilar\"}\n" +
+	"\asimilar\x18\x02 \x03(\v2\x1d.cartomix.common.SimilarTrackR\asimilar\x12A\n" +
+	"\rquery_section\x18\x03 \x01(\v2\x1c.cartomix.common.SectionSpanR\fquerySection\"}\n" +
 	"\x11ListLabelsRequest\x12\x19\n" +
 	"\btrack_id\x18\x01 \x01(\x03R\atrackId\x12\x1f\n" +
 	"\vlabel_value\x18\x02 \x01(\tR\n" +
@@ -2289,12 +5254,81 @@ const file_engine_api_proto_rawDesc = "" +
 	"\bservices\x18\x04 \x03(\v2-.cartomix.engine.HealthResponse.ServicesEntryR\bservices\x1a;\n" +
 	"\rServicesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01*P\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x94\x01\n" +
+	"\vLibraryRoot\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x1a\n" +
+	"\bwatching\x18\x03 \x01(\bR\bwatching\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\x03R\tcreatedAt\x12&\n" +
+	"\x0flast_scanned_at\x18\x05 \x01(\x03R\rlastScannedAt\"N\n" +
+	"\x18ListLibraryRootsResponse\x122\n" +
+	"\x05roots\x18\x01 \x03(\v2\x1c.cartomix.engine.LibraryRootR\x05roots\"+\n" +
+	"\x15AddLibraryRootRequest\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\">\n" +
+	"\x18RemoveLibraryRootRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"\x85\x01\n" +
+	"\fMissingTrack\x12\x19\n" +
+	"\btrack_id\x18\x01 \x01(\x03R\atrackId\x12!\n" +
+	"\fcontent_hash\x18\x02 \x01(\tR\vcontentHash\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\x12#\n" +
+	"\rmissing_since\x18\x04 \x01(\x03R\fmissingSince\"\xad\x01\n" +
+	"\x15LibraryHealthResponse\x12\x18\n" +
+	"\achecked\x18\x01 \x01(\x05R\achecked\x12#\n" +
+	"\rnewly_missing\x18\x02 \x01(\x05R\fnewlyMissing\x12\x1c\n" +
+	"\trecovered\x18\x03 \x01(\x05R\trecovered\x127\n" +
+	"\amissing\x18\x04 \x03(\v2\x1d.cartomix.engine.MissingTrackR\amissing\"\x8f\x01\n" +
+	"\x15RelocateTracksRequest\x12\x1d\n" +
+	"\n" +
+	"old_prefix\x18\x01 \x01(\tR\toldPrefix\x12\x1d\n" +
+	"\n" +
+	"new_prefix\x18\x02 \x01(\tR\tnewPrefix\x12\x1f\n" +
+	"\vsearch_root\x18\x03 \x01(\tR\n" +
+	"searchRoot\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\"\x98\x01\n" +
+	"\n" +
+	"Relocation\x12\x19\n" +
+	"\btrack_id\x18\x01 \x01(\x03R\atrackId\x12!\n" +
+	"\fcontent_hash\x18\x02 \x01(\tR\vcontentHash\x12\x19\n" +
+	"\bold_path\x18\x03 \x01(\tR\aoldPath\x12\x19\n" +
+	"\bnew_path\x18\x04 \x01(\tR\anewPath\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\"\xa8\x01\n" +
+	"\x16RelocateTracksResponse\x12\x17\n" +
+	"\adry_run\x18\x01 \x01(\bR\x06dryRun\x12\x18\n" +
+	"\amatched\x18\x02 \x01(\x05R\amatched\x12\x1c\n" +
+	"\tunmatched\x18\x03 \x01(\x05R\tunmatched\x12=\n" +
+	"\vrelocations\x18\x04 \x03(\v2\x1b.cartomix.engine.RelocationR\vrelocations\"e\n" +
+	"\x1aListDuplicateGroupsRequest\x12\x19\n" +
+	"\bmin_vibe\x18\x01 \x01(\x02R\aminVibe\x12,\n" +
+	"\x12max_duration_delta\x18\x02 \x01(\x01R\x10maxDurationDelta\"\xbc\x02\n" +
+	"\x0fDuplicateMember\x12(\n" +
+	"\x02id\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\x02id\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x16\n" +
+	"\x06artist\x18\x04 \x01(\tR\x06artist\x12\x16\n" +
+	"\x06format\x18\x05 \x01(\tR\x06format\x12\x1a\n" +
+	"\blossless\x18\x06 \x01(\bR\blossless\x12!\n" +
+	"\fbitrate_kbps\x18\a \x01(\x01R\vbitrateKbps\x12)\n" +
+	"\x10duration_seconds\x18\b \x01(\x01R\x0fdurationSeconds\x12\x1d\n" +
+	"\n" +
+	"vibe_match\x18\t \x01(\x02R\tvibeMatch\x12\x1c\n" +
+	"\tpreferred\x18\n" +
+	" \x01(\bR\tpreferred\"\x9c\x01\n" +
+	"\x0eDuplicateGroup\x126\n" +
+	"\tpreferred\x18\x01 \x01(\v2\x18.cartomix.common.TrackIdR\tpreferred\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12:\n" +
+	"\amembers\x18\x03 \x03(\v2 .cartomix.engine.DuplicateMemberR\amembers\"V\n" +
+	"\x1bListDuplicateGroupsResponse\x127\n" +
+	"\x06groups\x18\x01 \x03(\v2\x1f.cartomix.engine.DuplicateGroupR\x06groups*P\n" +
 	"\aSetMode\x12\x18\n" +
 	"\x14SET_MODE_UNSPECIFIED\x10\x00\x12\v\n" +
 	"\aWARM_UP\x10\x01\x12\r\n" +
 	"\tPEAK_TIME\x10\x02\x12\x0f\n" +
-	"\vOPEN_FORMAT\x10\x032\x8d\x0e\n" +
+	"\vOPEN_FORMAT\x10\x03*J\n" +
+	"\x0fSeedAggregation\x12\x1d\n" +
+	"\x19SEED_AGGREGATION_CENTROID\x10\x00\x12\x18\n" +
+	"\x14SEED_AGGREGATION_MAX\x10\x012\xc7\x1c\n" +
 	"\tEngineAPI\x12L\n" +
 	"\vScanLibrary\x12\x1c.cartomix.engine.ScanRequest\x1a\x1d.cartomix.engine.ScanProgress0\x01\x12T\n" +
 	"\rAnalyzeTracks\x12\x1f.cartomix.engine.AnalyzeRequest\x1a .cartomix.engine.AnalyzeProgress0\x01\x12Q\n" +
@@ -2302,11 +5336,32 @@ const file_engine_api_proto_rawDesc = "" +
 	"ListTracks\x12\".cartomix.engine.ListTracksRequest\x1a\x1d.cartomix.common.TrackSummary0\x01\x12L\n" +
 	"\bGetTrack\x12 .cartomix.engine.GetTrackRequest\x1a\x1e.cartomix.common.TrackAnalysis\x12O\n" +
 	"\n" +
-	"ProposeSet\x12\x1f.cartomix.engine.SetPlanRequest\x1a .cartomix.engine.SetPlanResponse\x12L\n" +
-	"\tExportSet\x12\x1e.cartomix.engine.ExportRequest\x1a\x1f.cartomix.engine.ExportResponse\x12a\n" +
+	"ProposeSet\x12\x1f.cartomix.engine.SetPlanRequest\x1a .cartomix.engine.SetPlanResponse\x12j\n" +
+	"\x11SuggestTransition\x12).cartomix.engine.SuggestTransitionRequest\x1a*.cartomix.engine.SuggestTransitionResponse\x12L\n" +
+	"\tExportSet\x12\x1e.cartomix.engine.ExportRequest\x1a\x1f.cartomix.engine.ExportResponse\x12U\n" +
+	"\x10ListLibraryRoots\x12\x16.google.protobuf.Empty\x1a).cartomix.engine.ListLibraryRootsResponse\x12V\n" +
+	"\x0eAddLibraryRoot\x12&.cartomix.engine.AddLibraryRootRequest\x1a\x1c.cartomix.engine.LibraryRoot\x12V\n" +
+	"\x11RemoveLibraryRoot\x12).cartomix.engine.RemoveLibraryRootRequest\x1a\x16.google.protobuf.Empty\x12T\n" +
+	"\x12CheckLibraryHealth\x12\x16.google.protobuf.Empty\x1a&.cartomix.engine.LibraryHealthResponse\x12a\n" +
+	"\x0eRelocateTracks\x12&.cartomix.engine.RelocateTracksRequest\x1a'.cartomix.engine.RelocateTracksResponse\x12p\n" +
+	"\x13ListDuplicateGroups\x12+.cartomix.engine.ListDuplicateGroupsRequest\x1a,.cartomix.engine.ListDuplicateGroupsResponse\x12Q\n" +
+	"\x10StartLiveSession\x12\x1f.cartomix.engine.SetPlanRequest\x1a\x1c.cartomix.engine.LiveSession\x12S\n" +
+	"\x0eGetLiveSession\x12#.cartomix.engine.LiveSessionRequest\x1a\x1c.cartomix.engine.LiveSession\x12P\n" +
+	"\x0eRecordLivePlay\x12 .cartomix.engine.LivePlayRequest\x1a\x1c.cartomix.engine.LiveSession\x12S\n" +
+	"\x0eEndLiveSession\x12#.cartomix.engine.LiveSessionRequest\x1a\x1c.cartomix.engine.LiveSession\x12W\n" +
+	"\x10WatchLiveSession\x12#.cartomix.engine.LiveSessionRequest\x1a\x1c.cartomix.engine.LiveSession0\x01\x12G\n" +
+	"\tCreateSet\x12\x1f.cartomix.engine.SaveSetRequest\x1a\x19.cartomix.engine.SavedSet\x12G\n" +
+	"\tUpdateSet\x12\x1f.cartomix.engine.SaveSetRequest\x1a\x19.cartomix.engine.SavedSet\x12C\n" +
+	"\x06GetSet\x12\x1e.cartomix.engine.GetSetRequest\x1a\x19.cartomix.engine.SavedSet\x12E\n" +
+	"\bListSets\x12\x16.google.protobuf.Empty\x1a!.cartomix.engine.ListSetsResponse\x12d\n" +
+	"\x0fListSetVersions\x12'.cartomix.engine.ListSetVersionsRequest\x1a(.cartomix.engine.ListSetVersionsResponse\x12F\n" +
+	"\tDeleteSet\x12!.cartomix.engine.DeleteSetRequest\x1a\x16.google.protobuf.Empty\x12a\n" +
 	"\x10GetSimilarTracks\x12%.cartomix.engine.SimilarTracksRequest\x1a&.cartomix.engine.SimilarTracksResponse\x12D\n" +
 	"\rGetMLSettings\x12\x16.google.protobuf.Empty\x1a\x1b.cartomix.common.MLSettings\x12L\n" +
-	"\x10UpdateMLSettings\x12\x1b.cartomix.common.MLSettings\x1a\x1b.cartomix.common.MLSettings\x12]\n" +
+	"\x10UpdateMLSettings\x12\x1b.cartomix.common.MLSettings\x1a\x1b.cartomix.common.MLSettings\x12a\n" +
+	"\x16ListSimilarityProfiles\x12\x16.google.protobuf.Empty\x1a/.cartomix.engine.ListSimilarityProfilesResponse\x12_\n" +
+	"\x15SaveSimilarityProfile\x12\".cartomix.engine.SimilarityProfile\x1a\".cartomix.engine.SimilarityProfile\x12b\n" +
+	"\x17DeleteSimilarityProfile\x12/.cartomix.engine.DeleteSimilarityProfileRequest\x1a\x16.google.protobuf.Empty\x12]\n" +
 	"\x12ListTrainingLabels\x12\".cartomix.engine.ListLabelsRequest\x1a#.cartomix.engine.ListLabelsResponse\x12W\n" +
 	"\x10AddTrainingLabel\x12 .cartomix.engine.AddLabelRequest\x1a!.cartomix.engine.AddLabelResponse\x12R\n" +
 	"\x13DeleteTrainingLabel\x12#.cartomix.engine.DeleteLabelRequest\x1a\x16.google.protobuf.Empty\x12T\n" +
@@ -2333,123 +5388,265 @@ func file_engine_api_proto_rawDescGZIP() []byte {
 	return file_engine_api_proto_rawDescData
 }
 
-var file_engine_api_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_engine_api_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
+var file_engine_api_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_engine_api_proto_msgTypes = make([]protoimpl.MessageInfo, 70)
 var file_engine_api_proto_goTypes = []any{
-	(SetMode)(0),                      // 0: cartomix.engine.SetMode
-	(*ScanRequest)(nil),               // 1: cartomix.engine.ScanRequest
-	(*ScanProgress)(nil),              // 2: cartomix.engine.ScanProgress
-	(*AnalyzeRequest)(nil),            // 3: cartomix.engine.AnalyzeRequest
-	(*AnalyzeProgress)(nil),           // 4: cartomix.engine.AnalyzeProgress
-	(*StageTiming)(nil),               // 5: cartomix.engine.StageTiming
-	(*ListTracksRequest)(nil),         // 6: cartomix.engine.ListTracksRequest
-	(*GetTrackRequest)(nil),           // 7: cartomix.engine.GetTrackRequest
-	(*SetPlanRequest)(nil),            // 8: cartomix.engine.SetPlanRequest
-	(*SetPlanResponse)(nil),           // 9: cartomix.engine.SetPlanResponse
-	(*ExportRequest)(nil),             // 10: cartomix.engine.ExportRequest
-	(*ExportResponse)(nil),            // 11: cartomix.engine.ExportResponse
-	(*SimilarTracksRequest)(nil),      // 12: cartomix.engine.SimilarTracksRequest
-	(*SimilarityConstraints)(nil),     // 13: cartomix.engine.SimilarityConstraints
-	(*SimilarTracksResponse)(nil),     // 14: cartomix.engine.SimilarTracksResponse
-	(*ListLabelsRequest)(nil),         // 15: cartomix.engine.ListLabelsRequest
-	(*ListLabelsResponse)(nil),        // 16: cartomix.engine.ListLabelsResponse
-	(*AddLabelRequest)(nil),           // 17: cartomix.engine.AddLabelRequest
-	(*AddLabelResponse)(nil),          // 18: cartomix.engine.AddLabelResponse
-	(*DeleteLabelRequest)(nil),        // 19: cartomix.engine.DeleteLabelRequest
-	(*StartTrainingRequest)(nil),      // 20: cartomix.engine.StartTrainingRequest
-	(*StartTrainingResponse)(nil),     // 21: cartomix.engine.StartTrainingResponse
-	(*GetJobRequest)(nil),             // 22: cartomix.engine.GetJobRequest
-	(*ListJobsRequest)(nil),           // 23: cartomix.engine.ListJobsRequest
-	(*ListJobsResponse)(nil),          // 24: cartomix.engine.ListJobsResponse
-	(*TrainingProgressUpdate)(nil),    // 25: cartomix.engine.TrainingProgressUpdate
-	(*ListModelsRequest)(nil),         // 26: cartomix.engine.ListModelsRequest
-	(*ListModelsResponse)(nil),        // 27: cartomix.engine.ListModelsResponse
-	(*ActivateModelRequest)(nil),      // 28: cartomix.engine.ActivateModelRequest
-	(*DeleteModelRequest)(nil),        // 29: cartomix.engine.DeleteModelRequest
-	(*HealthResponse)(nil),            // 30: cartomix.engine.HealthResponse
-	nil,                               // 31: cartomix.engine.HealthResponse.ServicesEntry
-	(*common.TrackId)(nil),            // 32: cartomix.common.TrackId
-	(*common.EdgeExplanation)(nil),    // 33: cartomix.common.EdgeExplanation
-	(*common.SimilarTrack)(nil),       // 34: cartomix.common.SimilarTrack
-	(*common.TrainingLabel)(nil),      // 35: cartomix.common.TrainingLabel
-	(*common.TrainingJob)(nil),        // 36: cartomix.common.TrainingJob
-	(common.TrainingStatus)(0),        // 37: cartomix.common.TrainingStatus
-	(*common.ModelVersion)(nil),       // 38: cartomix.common.ModelVersion
-	(*emptypb.Empty)(nil),             // 39: google.protobuf.Empty
-	(*common.MLSettings)(nil),         // 40: cartomix.common.MLSettings
-	(*common.TrackSummary)(nil),       // 41: cartomix.common.TrackSummary
-	(*common.TrackAnalysis)(nil),      // 42: cartomix.common.TrackAnalysis
-	(*common.TrainingLabelStats)(nil), // 43: cartomix.common.TrainingLabelStats
+	(SetMode)(0),                           // 0: cartomix.engine.SetMode
+	(SeedAggregation)(0),                   // 1: cartomix.engine.SeedAggregation
+	(*ScanRequest)(nil),                    // 2: cartomix.engine.ScanRequest
+	(*ScanProgress)(nil),                   // 3: cartomix.engine.ScanProgress
+	(*AnalyzeRequest)(nil),                 // 4: cartomix.engine.AnalyzeRequest
+	(*AnalyzeProgress)(nil),                // 5: cartomix.engine.AnalyzeProgress
+	(*StageTiming)(nil),                    // 6: cartomix.engine.StageTiming
+	(*ListTracksRequest)(nil),              // 7: cartomix.engine.ListTracksRequest
+	(*GetTrackRequest)(nil),                // 8: cartomix.engine.GetTrackRequest
+	(*SetPlanRequest)(nil),                 // 9: cartomix.engine.SetPlanRequest
+	(*LockedChain)(nil),                    // 10: cartomix.engine.LockedChain
+	(*Precedence)(nil),                     // 11: cartomix.engine.Precedence
+	(*ArtistSpacing)(nil),                  // 12: cartomix.engine.ArtistSpacing
+	(*EnergyCurve)(nil),                    // 13: cartomix.engine.EnergyCurve
+	(*EnergyPoint)(nil),                    // 14: cartomix.engine.EnergyPoint
+	(*EnergySlot)(nil),                     // 15: cartomix.engine.EnergySlot
+	(*SetPlanResponse)(nil),                // 16: cartomix.engine.SetPlanResponse
+	(*AlternativePlan)(nil),                // 17: cartomix.engine.AlternativePlan
+	(*PlanDiff)(nil),                       // 18: cartomix.engine.PlanDiff
+	(*SuggestTransitionRequest)(nil),       // 19: cartomix.engine.SuggestTransitionRequest
+	(*SuggestTransitionResponse)(nil),      // 20: cartomix.engine.SuggestTransitionResponse
+	(*LiveSession)(nil),                    // 21: cartomix.engine.LiveSession
+	(*LivePlay)(nil),                       // 22: cartomix.engine.LivePlay
+	(*LiveSessionRequest)(nil),             // 23: cartomix.engine.LiveSessionRequest
+	(*LivePlayRequest)(nil),                // 24: cartomix.engine.LivePlayRequest
+	(*SavedSet)(nil),                       // 25: cartomix.engine.SavedSet
+	(*SaveSetRequest)(nil),                 // 26: cartomix.engine.SaveSetRequest
+	(*GetSetRequest)(nil),                  // 27: cartomix.engine.GetSetRequest
+	(*ListSetsResponse)(nil),               // 28: cartomix.engine.ListSetsResponse
+	(*ListSetVersionsRequest)(nil),         // 29: cartomix.engine.ListSetVersionsRequest
+	(*ListSetVersionsResponse)(nil),        // 30: cartomix.engine.ListSetVersionsResponse
+	(*DeleteSetRequest)(nil),               // 31: cartomix.engine.DeleteSetRequest
+	(*ExportRequest)(nil),                  // 32: cartomix.engine.ExportRequest
+	(*ExportResponse)(nil),                 // 33: cartomix.engine.ExportResponse
+	(*SimilarTracksRequest)(nil),           // 34: cartomix.engine.SimilarTracksRequest
+	(*SectionQuery)(nil),                   // 35: cartomix.engine.SectionQuery
+	(*SimilarityProfile)(nil),              // 36: cartomix.engine.SimilarityProfile
+	(*ListSimilarityProfilesResponse)(nil), // 37: cartomix.engine.ListSimilarityProfilesResponse
+	(*DeleteSimilarityProfileRequest)(nil), // 38: cartomix.engine.DeleteSimilarityProfileRequest
+	(*SimilarityConstraints)(nil),          // 39: cartomix.engine.SimilarityConstraints
+	(*SimilarTracksResponse)(nil),          // 40: cartomix.engine.SimilarTracksResponse
+	(*ListLabelsRequest)(nil),              // 41: cartomix.engine.ListLabelsRequest
+	(*ListLabelsResponse)(nil),             // 42: cartomix.engine.ListLabelsResponse
+	(*AddLabelRequest)(nil),                // 43: cartomix.engine.AddLabelRequest
+	(*AddLabelResponse)(nil),               // 44: cartomix.engine.AddLabelResponse
+	(*DeleteLabelRequest)(nil),             // 45: cartomix.engine.DeleteLabelRequest
+	(*StartTrainingRequest)(nil),           // 46: cartomix.engine.StartTrainingRequest
+	(*StartTrainingResponse)(nil),          // 47: cartomix.engine.StartTrainingResponse
+	(*GetJobRequest)(nil),                  // 48: cartomix.engine.GetJobRequest
+	(*ListJobsRequest)(nil),                // 49: cartomix.engine.ListJobsRequest
+	(*ListJobsResponse)(nil),               // 50: cartomix.engine.ListJobsResponse
+	(*TrainingProgressUpdate)(nil),         // 51: cartomix.engine.TrainingProgressUpdate
+	(*ListModelsRequest)(nil),              // 52: cartomix.engine.ListModelsRequest
+	(*ListModelsResponse)(nil),             // 53: cartomix.engine.ListModelsResponse
+	(*ActivateModelRequest)(nil),           // 54: cartomix.engine.ActivateModelRequest
+	(*DeleteModelRequest)(nil),             // 55: cartomix.engine.DeleteModelRequest
+	(*HealthResponse)(nil),                 // 56: cartomix.engine.HealthResponse
+	(*LibraryRoot)(nil),                    // 57: cartomix.engine.LibraryRoot
+	(*ListLibraryRootsResponse)(nil),       // 58: cartomix.engine.ListLibraryRootsResponse
+	(*AddLibraryRootRequest)(nil),          // 59: cartomix.engine.AddLibraryRootRequest
+	(*RemoveLibraryRootRequest)(nil),       // 60: cartomix.engine.RemoveLibraryRootRequest
+	(*MissingTrack)(nil),                   // 61: cartomix.engine.MissingTrack
+	(*LibraryHealthResponse)(nil),          // 62: cartomix.engine.LibraryHealthResponse
+	(*RelocateTracksRequest)(nil),          // 63: cartomix.engine.RelocateTracksRequest
+	(*Relocation)(nil),                     // 64: cartomix.engine.Relocation
+	(*RelocateTracksResponse)(nil),         // 65: cartomix.engine.RelocateTracksResponse
+	(*ListDuplicateGroupsRequest)(nil),     // 66: cartomix.engine.ListDuplicateGroupsRequest
+	(*DuplicateMember)(nil),                // 67: cartomix.engine.DuplicateMember
+	(*DuplicateGroup)(nil),                 // 68: cartomix.engine.DuplicateGroup
+	(*ListDuplicateGroupsResponse)(nil),    // 69: cartomix.engine.ListDuplicateGroupsResponse
+	nil,                                    // 70: cartomix.engine.SetPlanRequest.KeyWeightsEntry
+	nil,                                    // 71: cartomix.engine.HealthResponse.ServicesEntry
+	(*common.TrackId)(nil),                 // 72: cartomix.common.TrackId
+	(*common.EdgeExplanation)(nil),         // 73: cartomix.common.EdgeExplanation
+	(*common.TransitionSuggestion)(nil),    // 74: cartomix.common.TransitionSuggestion
+	(*common.SimilarityWeights)(nil),       // 75: cartomix.common.SimilarityWeights
+	(common.SectionLabel)(0),               // 76: cartomix.common.SectionLabel
+	(*common.SimilarTrack)(nil),            // 77: cartomix.common.SimilarTrack
+	(*common.SectionSpan)(nil),             // 78: cartomix.common.SectionSpan
+	(*common.TrainingLabel)(nil),           // 79: cartomix.common.TrainingLabel
+	(*common.TrainingJob)(nil),             // 80: cartomix.common.TrainingJob
+	(common.TrainingStatus)(0),             // 81: cartomix.common.TrainingStatus
+	(*common.ModelVersion)(nil),            // 82: cartomix.common.ModelVersion
+	(*emptypb.Empty)(nil),                  // 83: google.protobuf.Empty
+	(*common.MLSettings)(nil),              // 84: cartomix.common.MLSettings
+	(*common.TrackSummary)(nil),            // 85: cartomix.common.TrackSummary
+	(*common.TrackAnalysis)(nil),           // 86: cartomix.common.TrackAnalysis
+	(*common.TrainingLabelStats)(nil),      // 87: cartomix.common.TrainingLabelStats
 }
 var file_engine_api_proto_depIdxs = []int32{
-	32, // 0: cartomix.engine.AnalyzeRequest.track_ids:type_name -> cartomix.common.TrackId
-	32, // 1: cartomix.engine.AnalyzeProgress.id:type_name -> cartomix.common.TrackId
-	5,  // 2: cartomix.engine.AnalyzeProgress.stage_timings:type_name -> cartomix.engine.StageTiming
-	32, // 3: cartomix.engine.GetTrackRequest.id:type_name -> cartomix.common.TrackId
-	32, // 4: cartomix.engine.SetPlanRequest.track_ids:type_name -> cartomix.common.TrackId
-	0,  // 5: cartomix.engine.SetPlanRequest.mode:type_name -> cartomix.engine.SetMode
-	32, // 6: cartomix.engine.SetPlanRequest.must_play:type_name -> cartomix.common.TrackId
-	32, // 7: cartomix.engine.SetPlanRequest.ban:type_name -> cartomix.common.TrackId
-	32, // 8: cartomix.engine.SetPlanResponse.order:type_name -> cartomix.common.TrackId
-	33, // 9: cartomix.engine.SetPlanResponse.explanations:type_name -> cartomix.common.EdgeExplanation
-	32, // 10: cartomix.engine.ExportRequest.track_ids:type_name -> cartomix.common.TrackId
-	32, // 11: cartomix.engine.SimilarTracksRequest.track_id:type_name -> cartomix.common.TrackId
-	13, // 12: cartomix.engine.SimilarTracksRequest.constraints:type_name -> cartomix.engine.SimilarityConstraints
-	32, // 13: cartomix.engine.SimilarTracksResponse.query_track:type_name -> cartomix.common.TrackId
-	34, // 14: cartomix.engine.SimilarTracksResponse.similar:type_name -> cartomix.common.SimilarTrack
-	35, // 15: cartomix.engine.ListLabelsResponse.labels:type_name -> cartomix.common.TrainingLabel
-	36, // 16: cartomix.engine.ListJobsResponse.jobs:type_name -> cartomix.common.TrainingJob
-	37, // 17: cartomix.engine.TrainingProgressUpdate.status:type_name -> cartomix.common.TrainingStatus
-	5,  // 18: cartomix.engine.TrainingProgressUpdate.stage_timings:type_name -> cartomix.engine.StageTiming
-	38, // 19: cartomix.engine.ListModelsResponse.versions:type_name -> cartomix.common.ModelVersion
-	31, // 20: cartomix.engine.HealthResponse.services:type_name -> cartomix.engine.HealthResponse.ServicesEntry
-	1,  // 21: cartomix.engine.EngineAPI.ScanLibrary:input_type -> cartomix.engine.ScanRequest
-	3,  // 22: cartomix.engine.EngineAPI.AnalyzeTracks:input_type -> cartomix.engine.AnalyzeRequest
-	6,  // 23: cartomix.engine.EngineAPI.ListTracks:input_type -> cartomix.engine.ListTracksRequest
-	7,  // 24: cartomix.engine.EngineAPI.GetTrack:input_type -> cartomix.engine.GetTrackRequest
-	8,  // 25: cartomix.engine.EngineAPI.ProposeSet:input_type -> cartomix.engine.SetPlanRequest
-	10, // 26: cartomix.engine.EngineAPI.ExportSet:input_type -> cartomix.engine.ExportRequest
-	12, // 27: cartomix.engine.EngineAPI.GetSimilarTracks:input_type -> cartomix.engine.SimilarTracksRequest
-	39, // 28: cartomix.engine.EngineAPI.GetMLSettings:input_type -> google.protobuf.Empty
-	40, // 29: cartomix.engine.EngineAPI.UpdateMLSettings:input_type -> cartomix.common.MLSettings
-	15, // 30: cartomix.engine.EngineAPI.ListTrainingLabels:input_type -> cartomix.engine.ListLabelsRequest
-	17, // 31: cartomix.engine.EngineAPI.AddTrainingLabel:input_type -> cartomix.engine.AddLabelRequest
-	19, // 32: cartomix.engine.EngineAPI.DeleteTrainingLabel:input_type -> cartomix.engine.DeleteLabelRequest
-	39, // 33: cartomix.engine.EngineAPI.GetTrainingLabelStats:input_type -> google.protobuf.Empty
-	20, // 34: cartomix.engine.EngineAPI.StartTraining:input_type -> cartomix.engine.StartTrainingRequest
-	22, // 35: cartomix.engine.EngineAPI.GetTrainingJob:input_type -> cartomix.engine.GetJobRequest
-	23, // 36: cartomix.engine.EngineAPI.ListTrainingJobs:input_type -> cartomix.engine.ListJobsRequest
-	22, // 37: cartomix.engine.EngineAPI.StreamTrainingProgress:input_type -> cartomix.engine.GetJobRequest
-	26, // 38: cartomix.engine.EngineAPI.ListModelVersions:input_type -> cartomix.engine.ListModelsRequest
-	28, // 39: cartomix.engine.EngineAPI.ActivateModelVersion:input_type -> cartomix.engine.ActivateModelRequest
-	29, // 40: cartomix.engine.EngineAPI.DeleteModelVersion:input_type -> cartomix.engine.DeleteModelRequest
-	39, // 41: cartomix.engine.EngineAPI.HealthCheck:input_type -> google.protobuf.Empty
-	2,  // 42: cartomix.engine.EngineAPI.ScanLibrary:output_type -> cartomix.engine.ScanProgress
-	4,  // 43: cartomix.engine.EngineAPI.AnalyzeTracks:output_type -> cartomix.engine.AnalyzeProgress
-	41, // 44: cartomix.engine.EngineAPI.ListTracks:output_type -> cartomix.common.TrackSummary
-	42, // 45: cartomix.engine.EngineAPI.GetTrack:output_type -> cartomix.common.TrackAnalysis
-	9,  // 46: cartomix.engine.EngineAPI.ProposeSet:output_type -> cartomix.engine.SetPlanResponse
-	11, // 47: cartomix.engine.EngineAPI.ExportSet:output_type -> cartomix.engine.ExportResponse
-	14, // 48: cartomix.engine.EngineAPI.GetSimilarTracks:output_type -> cartomix.engine.SimilarTracksResponse
-	40, // 49: cartomix.engine.EngineAPI.GetMLSettings:output_type -> cartomix.common.MLSettings
-	40, // 50: cartomix.engine.EngineAPI.UpdateMLSettings:output_type -> cartomix.common.MLSettings
-	16, // 51: cartomix.engine.EngineAPI.ListTrainingLabels:output_type -> cartomix.engine.ListLabelsResponse
-	18, // 52: cartomix.engine.EngineAPI.AddTrainingLabel:output_type -> cartomix.engine.AddLabelResponse
-	39, // 53: cartomix.engine.EngineAPI.DeleteTrainingLabel:output_type -> google.protobuf.Empty
-	43, // 54: cartomix.engine.EngineAPI.GetTrainingLabelStats:output_type -> cartomix.common.TrainingLabelStats
-	21, // 55: cartomix.engine.EngineAPI.StartTraining:output_type -> cartomix.engine.StartTrainingResponse
-	36, // 56: cartomix.engine.EngineAPI.GetTrainingJob:output_type -> cartomix.common.TrainingJob
-	24, // 57: cartomix.engine.EngineAPI.ListTrainingJobs:output_type -> cartomix.engine.ListJobsResponse
-	25, // 58: cartomix.engine.EngineAPI.StreamTrainingProgress:output_type -> cartomix.engine.TrainingProgressUpdate
-	27, // 59: cartomix.engine.EngineAPI.ListModelVersions:output_type -> cartomix.engine.ListModelsResponse
-	38, // 60: cartomix.engine.EngineAPI.ActivateModelVersion:output_type -> cartomix.common.ModelVersion
-	39, // 61: cartomix.engine.EngineAPI.DeleteModelVersion:output_type -> google.protobuf.Empty
-	30, // 62: cartomix.engine.EngineAPI.HealthCheck:output_type -> cartomix.engine.HealthResponse
-	42, // [42:63] is the sub-list for method output_type
-	21, // [21:42] is the sub-list for method input_type
-	21, // [21:21] is the sub-list for extension type_name
-	21, // [21:21] is the sub-list for extension extendee
-	0,  // [0:21] is the sub-list for field type_name
+	72,  // 0: cartomix.engine.AnalyzeRequest.track_ids:type_name -> cartomix.common.TrackId
+	72,  // 1: cartomix.engine.AnalyzeProgress.id:type_name -> cartomix.common.TrackId
+	6,   // 2: cartomix.engine.AnalyzeProgress.stage_timings:type_name -> cartomix.engine.StageTiming
+	72,  // 3: cartomix.engine.GetTrackRequest.id:type_name -> cartomix.common.TrackId
+	72,  // 4: cartomix.engine.SetPlanRequest.track_ids:type_name -> cartomix.common.TrackId
+	0,   // 5: cartomix.engine.SetPlanRequest.mode:type_name -> cartomix.engine.SetMode
+	72,  // 6: cartomix.engine.SetPlanRequest.must_play:type_name -> cartomix.common.TrackId
+	72,  // 7: cartomix.engine.SetPlanRequest.ban:type_name -> cartomix.common.TrackId
+	13,  // 8: cartomix.engine.SetPlanRequest.energy_curve:type_name -> cartomix.engine.EnergyCurve
+	70,  // 9: cartomix.engine.SetPlanRequest.key_weights:type_name -> cartomix.engine.SetPlanRequest.KeyWeightsEntry
+	72,  // 10: cartomix.engine.SetPlanRequest.opener:type_name -> cartomix.common.TrackId
+	72,  // 11: cartomix.engine.SetPlanRequest.closer:type_name -> cartomix.common.TrackId
+	10,  // 12: cartomix.engine.SetPlanRequest.locked_chains:type_name -> cartomix.engine.LockedChain
+	11,  // 13: cartomix.engine.SetPlanRequest.precedences:type_name -> cartomix.engine.Precedence
+	12,  // 14: cartomix.engine.SetPlanRequest.artist_spacing:type_name -> cartomix.engine.ArtistSpacing
+	72,  // 15: cartomix.engine.LockedChain.tracks:type_name -> cartomix.common.TrackId
+	72,  // 16: cartomix.engine.Precedence.before:type_name -> cartomix.common.TrackId
+	72,  // 17: cartomix.engine.Precedence.after:type_name -> cartomix.common.TrackId
+	14,  // 18: cartomix.engine.EnergyCurve.points:type_name -> cartomix.engine.EnergyPoint
+	72,  // 19: cartomix.engine.EnergySlot.id:type_name -> cartomix.common.TrackId
+	72,  // 20: cartomix.engine.SetPlanResponse.order:type_name -> cartomix.common.TrackId
+	73,  // 21: cartomix.engine.SetPlanResponse.explanations:type_name -> cartomix.common.EdgeExplanation
+	72,  // 22: cartomix.engine.SetPlanResponse.collapsed:type_name -> cartomix.common.TrackId
+	73,  // 23: cartomix.engine.SetPlanResponse.weakest_edges:type_name -> cartomix.common.EdgeExplanation
+	15,  // 24: cartomix.engine.SetPlanResponse.energy_slots:type_name -> cartomix.engine.EnergySlot
+	17,  // 25: cartomix.engine.SetPlanResponse.alternatives:type_name -> cartomix.engine.AlternativePlan
+	72,  // 26: cartomix.engine.AlternativePlan.order:type_name -> cartomix.common.TrackId
+	73,  // 27: cartomix.engine.AlternativePlan.explanations:type_name -> cartomix.common.EdgeExplanation
+	73,  // 28: cartomix.engine.AlternativePlan.weakest_edges:type_name -> cartomix.common.EdgeExplanation
+	15,  // 29: cartomix.engine.AlternativePlan.energy_slots:type_name -> cartomix.engine.EnergySlot
+	18,  // 30: cartomix.engine.AlternativePlan.diff:type_name -> cartomix.engine.PlanDiff
+	73,  // 31: cartomix.engine.PlanDiff.new_edges:type_name -> cartomix.common.EdgeExplanation
+	72,  // 32: cartomix.engine.PlanDiff.added:type_name -> cartomix.common.TrackId
+	72,  // 33: cartomix.engine.PlanDiff.dropped:type_name -> cartomix.common.TrackId
+	72,  // 34: cartomix.engine.SuggestTransitionRequest.from:type_name -> cartomix.common.TrackId
+	72,  // 35: cartomix.engine.SuggestTransitionRequest.to:type_name -> cartomix.common.TrackId
+	74,  // 36: cartomix.engine.SuggestTransitionResponse.suggestions:type_name -> cartomix.common.TransitionSuggestion
+	22,  // 37: cartomix.engine.LiveSession.played:type_name -> cartomix.engine.LivePlay
+	22,  // 38: cartomix.engine.LiveSession.now_playing:type_name -> cartomix.engine.LivePlay
+	72,  // 39: cartomix.engine.LiveSession.next_up:type_name -> cartomix.common.TrackId
+	73,  // 40: cartomix.engine.LiveSession.explanations:type_name -> cartomix.common.EdgeExplanation
+	72,  // 41: cartomix.engine.LivePlay.id:type_name -> cartomix.common.TrackId
+	72,  // 42: cartomix.engine.LivePlayRequest.id:type_name -> cartomix.common.TrackId
+	72,  // 43: cartomix.engine.SavedSet.track_ids:type_name -> cartomix.common.TrackId
+	73,  // 44: cartomix.engine.SavedSet.explanations:type_name -> cartomix.common.EdgeExplanation
+	72,  // 45: cartomix.engine.SaveSetRequest.track_ids:type_name -> cartomix.common.TrackId
+	73,  // 46: cartomix.engine.SaveSetRequest.explanations:type_name -> cartomix.common.EdgeExplanation
+	25,  // 47: cartomix.engine.ListSetsResponse.sets:type_name -> cartomix.engine.SavedSet
+	25,  // 48: cartomix.engine.ListSetVersionsResponse.versions:type_name -> cartomix.engine.SavedSet
+	72,  // 49: cartomix.engine.ExportRequest.track_ids:type_name -> cartomix.common.TrackId
+	72,  // 50: cartomix.engine.SimilarTracksRequest.track_id:type_name -> cartomix.common.TrackId
+	39,  // 51: cartomix.engine.SimilarTracksRequest.constraints:type_name -> cartomix.engine.SimilarityConstraints
+	75,  // 52: cartomix.engine.SimilarTracksRequest.weights:type_name -> cartomix.common.SimilarityWeights
+	72,  // 53: cartomix.engine.SimilarTracksRequest.seeds:type_name -> cartomix.common.TrackId
+	72,  // 54: cartomix.engine.SimilarTracksRequest.negative_seeds:type_name -> cartomix.common.TrackId
+	1,   // 55: cartomix.engine.SimilarTracksRequest.aggregation:type_name -> cartomix.engine.SeedAggregation
+	35,  // 56: cartomix.engine.SimilarTracksRequest.section:type_name -> cartomix.engine.SectionQuery
+	76,  // 57: cartomix.engine.SectionQuery.label:type_name -> cartomix.common.SectionLabel
+	76,  // 58: cartomix.engine.SectionQuery.match_label:type_name -> cartomix.common.SectionLabel
+	75,  // 59: cartomix.engine.SimilarityProfile.weights:type_name -> cartomix.common.SimilarityWeights
+	36,  // 60: cartomix.engine.ListSimilarityProfilesResponse.profiles:type_name -> cartomix.engine.SimilarityProfile
+	72,  // 61: cartomix.engine.SimilarTracksResponse.query_track:type_name -> cartomix.common.TrackId
+	77,  // 62: cartomix.engine.SimilarTracksResponse.similar:type_name -> cartomix.common.SimilarTrack
+	78,  // 63: cartomix.engine.SimilarTracksResponse.query_section:type_name -> cartomix.common.SectionSpan
+	79,  // 64: cartomix.engine.ListLabelsResponse.labels:type_name -> cartomix.common.TrainingLabel
+	80,  // 65: cartomix.engine.ListJobsResponse.jobs:type_name -> cartomix.common.TrainingJob
+	81,  // 66: cartomix.engine.TrainingProgressUpdate.status:type_name -> cartomix.common.TrainingStatus
+	6,   // 67: cartomix.engine.TrainingProgressUpdate.stage_timings:type_name -> cartomix.engine.StageTiming
+	82,  // 68: cartomix.engine.ListModelsResponse.versions:type_name -> cartomix.common.ModelVersion
+	71,  // 69: cartomix.engine.HealthResponse.services:type_name -> cartomix.engine.HealthResponse.ServicesEntry
+	57,  // 70: cartomix.engine.ListLibraryRootsResponse.roots:type_name -> cartomix.engine.LibraryRoot
+	61,  // 71: cartomix.engine.LibraryHealthResponse.missing:type_name -> cartomix.engine.MissingTrack
+	64,  // 72: cartomix.engine.RelocateTracksResponse.relocations:type_name -> cartomix.engine.Relocation
+	72,  // 73: cartomix.engine.DuplicateMember.id:type_name -> cartomix.common.TrackId
+	72,  // 74: cartomix.engine.DuplicateGroup.preferred:type_name -> cartomix.common.TrackId
+	67,  // 75: cartomix.engine.DuplicateGroup.members:type_name -> cartomix.engine.DuplicateMember
+	68,  // 76: cartomix.engine.ListDuplicateGroupsResponse.groups:type_name -> cartomix.engine.DuplicateGroup
+	2,   // 77: cartomix.engine.EngineAPI.ScanLibrary:input_type -> cartomix.engine.ScanRequest
+	4,   // 78: cartomix.engine.EngineAPI.AnalyzeTracks:input_type -> cartomix.engine.AnalyzeRequest
+	7,   // 79: cartomix.engine.EngineAPI.ListTracks:input_type -> cartomix.engine.ListTracksRequest
+	8,   // 80: cartomix.engine.EngineAPI.GetTrack:input_type -> cartomix.engine.GetTrackRequest
+	9,   // 81: cartomix.engine.EngineAPI.ProposeSet:input_type -> cartomix.engine.SetPlanRequest
+	19,  // 82: cartomix.engine.EngineAPI.SuggestTransition:input_type -> cartomix.engine.SuggestTransitionRequest
+	32,  // 83: cartomix.engine.EngineAPI.ExportSet:input_type -> cartomix.engine.ExportRequest
+	83,  // 84: cartomix.engine.EngineAPI.ListLibraryRoots:input_type -> google.protobuf.Empty
+	59,  // 85: cartomix.engine.EngineAPI.AddLibraryRoot:input_type -> cartomix.engine.AddLibraryRootRequest
+	60,  // 86: cartomix.engine.EngineAPI.RemoveLibraryRoot:input_type -> cartomix.engine.RemoveLibraryRootRequest
+	83,  // 87: cartomix.engine.EngineAPI.CheckLibraryHealth:input_type -> google.protobuf.Empty
+	63,  // 88: cartomix.engine.EngineAPI.RelocateTracks:input_type -> cartomix.engine.RelocateTracksRequest
+	66,  // 89: cartomix.engine.EngineAPI.ListDuplicateGroups:input_type -> cartomix.engine.ListDuplicateGroupsRequest
+	9,   // 90: cartomix.engine.EngineAPI.StartLiveSession:input_type -> cartomix.engine.SetPlanRequest
+	23,  // 91: cartomix.engine.EngineAPI.GetLiveSession:input_type -> cartomix.engine.LiveSessionRequest
+	24,  // 92: cartomix.engine.EngineAPI.RecordLivePlay:input_type -> cartomix.engine.LivePlayRequest
+	23,  // 93: cartomix.engine.EngineAPI.EndLiveSession:input_type -> cartomix.engine.LiveSessionRequest
+	23,  // 94: cartomix.engine.EngineAPI.WatchLiveSession:input_type -> cartomix.engine.LiveSessionRequest
+	26,  // 95: cartomix.engine.EngineAPI.CreateSet:input_type -> cartomix.engine.SaveSetRequest
+	26,  // 96: cartomix.engine.EngineAPI.UpdateSet:input_type -> cartomix.engine.SaveSetRequest
+	27,  // 97: cartomix.engine.EngineAPI.GetSet:input_type -> cartomix.engine.GetSetRequest
+	83,  // 98: cartomix.engine.EngineAPI.ListSets:input_type -> google.protobuf.Empty
+	29,  // 99: cartomix.engine.EngineAPI.ListSetVersions:input_type -> cartomix.engine.ListSetVersionsRequest
+	31,  // 100: cartomix.engine.EngineAPI.DeleteSet:input_type -> cartomix.engine.DeleteSetRequest
+	34,  // 101: cartomix.engine.EngineAPI.GetSimilarTracks:input_type -> cartomix.engine.SimilarTracksRequest
+	83,  // 102: cartomix.engine.EngineAPI.GetMLSettings:input_type -> google.protobuf.Empty
+	84,  // 103: cartomix.engine.EngineAPI.UpdateMLSettings:input_type -> cartomix.common.MLSettings
+	83,  // 104: cartomix.engine.EngineAPI.ListSimilarityProfiles:input_type -> google.protobuf.Empty
+	36,  // 105: cartomix.engine.EngineAPI.SaveSimilarityProfile:input_type -> cartomix.engine.SimilarityProfile
+	38,  // 106: cartomix.engine.EngineAPI.DeleteSimilarityProfile:input_type -> cartomix.engine.DeleteSimilarityProfileRequest
+	41,  // 107: cartomix.engine.EngineAPI.ListTrainingLabels:input_type -> cartomix.engine.ListLabelsRequest
+	43,  // 108: cartomix.engine.EngineAPI.AddTrainingLabel:input_type -> cartomix.engine.AddLabelRequest
+	45,  // 109: cartomix.engine.EngineAPI.DeleteTrainingLabel:input_type -> cartomix.engine.DeleteLabelRequest
+	83,  // 110: cartomix.engine.EngineAPI.GetTrainingLabelStats:input_type -> google.protobuf.Empty
+	46,  // 111: cartomix.engine.EngineAPI.StartTraining:input_type -> cartomix.engine.StartTrainingRequest
+	48,  // 112: cartomix.engine.EngineAPI.GetTrainingJob:input_type -> cartomix.engine.GetJobRequest
+	49,  // 113: cartomix.engine.EngineAPI.ListTrainingJobs:input_type -> cartomix.engine.ListJobsRequest
+	48,  // 114: cartomix.engine.EngineAPI.StreamTrainingProgress:input_type -> cartomix.engine.GetJobRequest
+	52,  // 115: cartomix.engine.EngineAPI.ListModelVersions:input_type -> cartomix.engine.ListModelsRequest
+	54,  // 116: cartomix.engine.EngineAPI.ActivateModelVersion:input_type -> cartomix.engine.ActivateModelRequest
+	55,  // 117: cartomix.engine.EngineAPI.DeleteModelVersion:input_type -> cartomix.engine.DeleteModelRequest
+	83,  // 118: cartomix.engine.EngineAPI.HealthCheck:input_type -> google.protobuf.Empty
+	3,   // 119: cartomix.engine.EngineAPI.ScanLibrary:output_type -> cartomix.engine.ScanProgress
+	5,   // 120: cartomix.engine.EngineAPI.AnalyzeTracks:output_type -> cartomix.engine.AnalyzeProgress
+	85,  // 121: cartomix.engine.EngineAPI.ListTracks:output_type -> cartomix.common.TrackSummary
+	86,  // 122: cartomix.engine.EngineAPI.GetTrack:output_type -> cartomix.common.TrackAnalysis
+	16,  // 123: cartomix.engine.EngineAPI.ProposeSet:output_type -> cartomix.engine.SetPlanResponse
+	20,  // 124: cartomix.engine.EngineAPI.SuggestTransition:output_type -> cartomix.engine.SuggestTransitionResponse
+	33,  // 125: cartomix.engine.EngineAPI.ExportSet:output_type -> cartomix.engine.ExportResponse
+	58,  // 126: cartomix.engine.EngineAPI.ListLibraryRoots:output_type -> cartomix.engine.ListLibraryRootsResponse
+	57,  // 127: cartomix.engine.EngineAPI.AddLibraryRoot:output_type -> cartomix.engine.LibraryRoot
+	83,  // 128: cartomix.engine.EngineAPI.RemoveLibraryRoot:output_type -> google.protobuf.Empty
+	62,  // 129: cartomix.engine.EngineAPI.CheckLibraryHealth:output_type -> cartomix.engine.LibraryHealthResponse
+	65,  // 130: cartomix.engine.EngineAPI.RelocateTracks:output_type -> cartomix.engine.RelocateTracksResponse
+	69,  // 131: cartomix.engine.EngineAPI.ListDuplicateGroups:output_type -> cartomix.engine.ListDuplicateGroupsResponse
+	21,  // 132: cartomix.engine.EngineAPI.StartLiveSession:output_type -> cartomix.engine.LiveSession
+	21,  // 133: cartomix.engine.EngineAPI.GetLiveSession:output_type -> cartomix.engine.LiveSession
+	21,  // 134: cartomix.engine.EngineAPI.RecordLivePlay:output_type -> cartomix.engine.LiveSession
+	21,  // 135: cartomix.engine.EngineAPI.EndLiveSession:output_type -> cartomix.engine.LiveSession
+	21,  // 136: cartomix.engine.EngineAPI.WatchLiveSession:output_type -> cartomix.engine.LiveSession
+	25,  // 137: cartomix.engine.EngineAPI.CreateSet:output_type -> cartomix.engine.SavedSet
+	25,  // 138: cartomix.engine.EngineAPI.UpdateSet:output_type -> cartomix.engine.SavedSet
+	25,  // 139: cartomix.engine.EngineAPI.GetSet:output_type -> cartomix.engine.SavedSet
+	28,  // 140: cartomix.engine.EngineAPI.ListSets:output_type -> cartomix.engine.ListSetsResponse
+	30,  // 141: cartomix.engine.EngineAPI.ListSetVersions:output_type -> cartomix.engine.ListSetVersionsResponse
+	83,  // 142: cartomix.engine.EngineAPI.DeleteSet:output_type -> google.protobuf.Empty
+	40,  // 143: cartomix.engine.EngineAPI.GetSimilarTracks:output_type -> cartomix.engine.SimilarTracksResponse
+	84,  // 144: cartomix.engine.EngineAPI.GetMLSettings:output_type -> cartomix.common.MLSettings
+	84,  // 145: cartomix.engine.EngineAPI.UpdateMLSettings:output_type -> cartomix.common.MLSettings
+	37,  // 146: cartomix.engine.EngineAPI.ListSimilarityProfiles:output_type -> cartomix.engine.ListSimilarityProfilesResponse
+	36,  // 147: cartomix.engine.EngineAPI.SaveSimilarityProfile:output_type -> cartomix.engine.SimilarityProfile
+	83,  // 148: cartomix.engine.EngineAPI.DeleteSimilarityProfile:output_type -> google.protobuf.Empty
+	42,  // 149: cartomix.engine.EngineAPI.ListTrainingLabels:output_type -> cartomix.engine.ListLabelsResponse
+	44,  // 150: cartomix.engine.EngineAPI.AddTrainingLabel:output_type -> cartomix.engine.AddLabelResponse
+	83,  // 151: cartomix.engine.EngineAPI.DeleteTrainingLabel:output_type -> google.protobuf.Empty
+	87,  // 152: cartomix.engine.EngineAPI.GetTrainingLabelStats:output_type -> cartomix.common.TrainingLabelStats
+	47,  // 153: cartomix.engine.EngineAPI.StartTraining:output_type -> cartomix.engine.StartTrainingResponse
+	80,  // 154: cartomix.engine.EngineAPI.GetTrainingJob:output_type -> cartomix.common.TrainingJob
+	50,  // 155: cartomix.engine.EngineAPI.ListTrainingJobs:output_type -> cartomix.engine.ListJobsResponse
+	51,  // 156: cartomix.engine.EngineAPI.StreamTrainingProgress:output_type -> cartomix.engine.TrainingProgressUpdate
+	53,  // 157: cartomix.engine.EngineAPI.ListModelVersions:output_type -> cartomix.engine.ListModelsResponse
+	82,  // 158: cartomix.engine.EngineAPI.ActivateModelVersion:output_type -> cartomix.common.ModelVersion
+	83,  // 159: cartomix.engine.EngineAPI.DeleteModelVersion:output_type -> google.protobuf.Empty
+	56,  // 160: cartomix.engine.EngineAPI.HealthCheck:output_type -> cartomix.engine.HealthResponse
+	119, // [119:161] is the sub-list for method output_type
+	77,  // [77:119] is the sub-list for method input_type
+	77,  // [77:77] is the sub-list for extension type_name
+	77,  // [77:77] is the sub-list for extension extendee
+	0,   // [0:77] is the sub-list for field type_name
 }
 
 func init() { file_engine_api_proto_init() }
@@ -2457,13 +5654,17 @@ func file_engine_api_proto_init() {
 	if File_engine_api_proto != nil {
 		return
 	}
+	file_engine_api_proto_msgTypes[12].OneofWrappers = []any{
+		(*EnergyPoint_Position)(nil),
+		(*EnergyPoint_Minutes)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_engine_api_proto_rawDesc), len(file_engine_api_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   31,
+			NumEnums:      2,
+			NumMessages:   70,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
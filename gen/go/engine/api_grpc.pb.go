@@ -21,27 +21,48 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	EngineAPI_ScanLibrary_FullMethodName            = "/cartomix.engine.EngineAPI/ScanLibrary"
-	EngineAPI_AnalyzeTracks_FullMethodName          = "/cartomix.engine.EngineAPI/AnalyzeTracks"
-	EngineAPI_ListTracks_FullMethodName             = "/cartomix.engine.EngineAPI/ListTracks"
-	EngineAPI_GetTrack_FullMethodName               = "/cartomix.engine.EngineAPI/GetTrack"
-	EngineAPI_ProposeSet_FullMethodName             = "/cartomix.engine.EngineAPI/ProposeSet"
-	EngineAPI_ExportSet_FullMethodName              = "/cartomix.engine.EngineAPI/ExportSet"
-	EngineAPI_GetSimilarTracks_FullMethodName       = "/cartomix.engine.EngineAPI/GetSimilarTracks"
-	EngineAPI_GetMLSettings_FullMethodName          = "/cartomix.engine.EngineAPI/GetMLSettings"
-	EngineAPI_UpdateMLSettings_FullMethodName       = "/cartomix.engine.EngineAPI/UpdateMLSettings"
-	EngineAPI_ListTrainingLabels_FullMethodName     = "/cartomix.engine.EngineAPI/ListTrainingLabels"
-	EngineAPI_AddTrainingLabel_FullMethodName       = "/cartomix.engine.EngineAPI/AddTrainingLabel"
-	EngineAPI_DeleteTrainingLabel_FullMethodName    = "/cartomix.engine.EngineAPI/DeleteTrainingLabel"
-	EngineAPI_GetTrainingLabelStats_FullMethodName  = "/cartomix.engine.EngineAPI/GetTrainingLabelStats"
-	EngineAPI_StartTraining_FullMethodName          = "/cartomix.engine.EngineAPI/StartTraining"
-	EngineAPI_GetTrainingJob_FullMethodName         = "/cartomix.engine.EngineAPI/GetTrainingJob"
-	EngineAPI_ListTrainingJobs_FullMethodName       = "/cartomix.engine.EngineAPI/ListTrainingJobs"
-	EngineAPI_StreamTrainingProgress_FullMethodName = "/cartomix.engine.EngineAPI/StreamTrainingProgress"
-	EngineAPI_ListModelVersions_FullMethodName      = "/cartomix.engine.EngineAPI/ListModelVersions"
-	EngineAPI_ActivateModelVersion_FullMethodName   = "/cartomix.engine.EngineAPI/ActivateModelVersion"
-	EngineAPI_DeleteModelVersion_FullMethodName     = "/cartomix.engine.EngineAPI/DeleteModelVersion"
-	EngineAPI_HealthCheck_FullMethodName            = "/cartomix.engine.EngineAPI/HealthCheck"
+	EngineAPI_ScanLibrary_FullMethodName             = "/cartomix.engine.EngineAPI/ScanLibrary"
+	EngineAPI_AnalyzeTracks_FullMethodName           = "/cartomix.engine.EngineAPI/AnalyzeTracks"
+	EngineAPI_ListTracks_FullMethodName              = "/cartomix.engine.EngineAPI/ListTracks"
+	EngineAPI_GetTrack_FullMethodName                = "/cartomix.engine.EngineAPI/GetTrack"
+	EngineAPI_ProposeSet_FullMethodName              = "/cartomix.engine.EngineAPI/ProposeSet"
+	EngineAPI_SuggestTransition_FullMethodName       = "/cartomix.engine.EngineAPI/SuggestTransition"
+	EngineAPI_ExportSet_FullMethodName               = "/cartomix.engine.EngineAPI/ExportSet"
+	EngineAPI_ListLibraryRoots_FullMethodName        = "/cartomix.engine.EngineAPI/ListLibraryRoots"
+	EngineAPI_AddLibraryRoot_FullMethodName          = "/cartomix.engine.EngineAPI/AddLibraryRoot"
+	EngineAPI_RemoveLibraryRoot_FullMethodName       = "/cartomix.engine.EngineAPI/RemoveLibraryRoot"
+	EngineAPI_CheckLibraryHealth_FullMethodName      = "/cartomix.engine.EngineAPI/CheckLibraryHealth"
+	EngineAPI_RelocateTracks_FullMethodName          = "/cartomix.engine.EngineAPI/RelocateTracks"
+	EngineAPI_ListDuplicateGroups_FullMethodName     = "/cartomix.engine.EngineAPI/ListDuplicateGroups"
+	EngineAPI_StartLiveSession_FullMethodName        = "/cartomix.engine.EngineAPI/StartLiveSession"
+	EngineAPI_GetLiveSession_FullMethodName          = "/cartomix.engine.EngineAPI/GetLiveSession"
+	EngineAPI_RecordLivePlay_FullMethodName          = "/cartomix.engine.EngineAPI/RecordLivePlay"
+	EngineAPI_EndLiveSession_FullMethodName          = "/cartomix.engine.EngineAPI/EndLiveSession"
+	EngineAPI_WatchLiveSession_FullMethodName        = "/cartomix.engine.EngineAPI/WatchLiveSession"
+	EngineAPI_CreateSet_FullMethodName               = "/cartomix.engine.EngineAPI/CreateSet"
+	EngineAPI_UpdateSet_FullMethodName               = "/cartomix.engine.EngineAPI/UpdateSet"
+	EngineAPI_GetSet_FullMethodName                  = "/cartomix.engine.EngineAPI/GetSet"
+	EngineAPI_ListSets_FullMethodName                = "/cartomix.engine.EngineAPI/ListSets"
+	EngineAPI_ListSetVersions_FullMethodName         = "/cartomix.engine.EngineAPI/ListSetVersions"
+	EngineAPI_DeleteSet_FullMethodName               = "/cartomix.engine.EngineAPI/DeleteSet"
+	EngineAPI_GetSimilarTracks_FullMethodName        = "/cartomix.engine.EngineAPI/GetSimilarTracks"
+	EngineAPI_GetMLSettings_FullMethodName           = "/cartomix.engine.EngineAPI/GetMLSettings"
+	EngineAPI_UpdateMLSettings_FullMethodName        = "/cartomix.engine.EngineAPI/UpdateMLSettings"
+	EngineAPI_ListSimilarityProfiles_FullMethodName  = "/cartomix.engine.EngineAPI/ListSimilarityProfiles"
+	EngineAPI_SaveSimilarityProfile_FullMethodName   = "/cartomix.engine.EngineAPI/SaveSimilarityProfile"
+	EngineAPI_DeleteSimilarityProfile_FullMethodName = "/cartomix.engine.EngineAPI/DeleteSimilarityProfile"
+	EngineAPI_ListTrainingLabels_FullMethodName      = "/cartomix.engine.EngineAPI/ListTrainingLabels"
+	EngineAPI_AddTrainingLabel_FullMethodName        = "/cartomix.engine.EngineAPI/AddTrainingLabel"
+	EngineAPI_DeleteTrainingLabel_FullMethodName     = "/cartomix.engine.EngineAPI/DeleteTrainingLabel"
+	EngineAPI_GetTrainingLabelStats_FullMethodName   = "/cartomix.engine.EngineAPI/GetTrainingLabelStats"
+	EngineAPI_StartTraining_FullMethodName           = "/cartomix.engine.EngineAPI/StartTraining"
+	EngineAPI_GetTrainingJob_FullMethodName          = "/cartomix.engine.EngineAPI/GetTrainingJob"
+	EngineAPI_ListTrainingJobs_FullMethodName        = "/cartomix.engine.EngineAPI/ListTrainingJobs"
+	EngineAPI_StreamTrainingProgress_FullMethodName  = "/cartomix.engine.EngineAPI/StreamTrainingProgress"
+	EngineAPI_ListModelVersions_FullMethodName       = "/cartomix.engine.EngineAPI/ListModelVersions"
+	EngineAPI_ActivateModelVersion_FullMethodName    = "/cartomix.engine.EngineAPI/ActivateModelVersion"
+	EngineAPI_DeleteModelVersion_FullMethodName      = "/cartomix.engine.EngineAPI/DeleteModelVersion"
+	EngineAPI_HealthCheck_FullMethodName             = "/cartomix.engine.EngineAPI/HealthCheck"
 )
 
 // EngineAPIClient is the client API for EngineAPI service.
@@ -58,13 +79,43 @@ type EngineAPIClient interface {
 	GetTrack(ctx context.Context, in *GetTrackRequest, opts ...grpc.CallOption) (*common.TrackAnalysis, error)
 	// Propose an ordering for a set with human-readable explanations.
 	ProposeSet(ctx context.Context, in *SetPlanRequest, opts ...grpc.CallOption) (*SetPlanResponse, error)
+	// Rank beat-accurate places to mix from one track into another.
+	SuggestTransition(ctx context.Context, in *SuggestTransitionRequest, opts ...grpc.CallOption) (*SuggestTransitionResponse, error)
 	// Export playlist + cues + analysis artifacts.
 	ExportSet(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error)
+	// Persisted library roots, watched for added, moved and deleted files.
+	ListLibraryRoots(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListLibraryRootsResponse, error)
+	AddLibraryRoot(ctx context.Context, in *AddLibraryRootRequest, opts ...grpc.CallOption) (*LibraryRoot, error)
+	RemoveLibraryRoot(ctx context.Context, in *RemoveLibraryRootRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Flag tracks whose file is gone, and find them again by content hash.
+	CheckLibraryHealth(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*LibraryHealthResponse, error)
+	RelocateTracks(ctx context.Context, in *RelocateTracksRequest, opts ...grpc.CallOption) (*RelocateTracksResponse, error)
+	// Group copies of the same recording across formats and bitrates.
+	ListDuplicateGroups(ctx context.Context, in *ListDuplicateGroupsRequest, opts ...grpc.CallOption) (*ListDuplicateGroupsResponse, error)
+	// Follow a set as it's played, replanning what's left from the track playing now.
+	StartLiveSession(ctx context.Context, in *SetPlanRequest, opts ...grpc.CallOption) (*LiveSession, error)
+	GetLiveSession(ctx context.Context, in *LiveSessionRequest, opts ...grpc.CallOption) (*LiveSession, error)
+	RecordLivePlay(ctx context.Context, in *LivePlayRequest, opts ...grpc.CallOption) (*LiveSession, error)
+	EndLiveSession(ctx context.Context, in *LiveSessionRequest, opts ...grpc.CallOption) (*LiveSession, error)
+	// Stream the session each time it's replanned, until it ends.
+	WatchLiveSession(ctx context.Context, in *LiveSessionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LiveSession], error)
+	// Saved sets keep every version; each update adds one. ExportSet exports
+	// them by set_id without replanning.
+	CreateSet(ctx context.Context, in *SaveSetRequest, opts ...grpc.CallOption) (*SavedSet, error)
+	UpdateSet(ctx context.Context, in *SaveSetRequest, opts ...grpc.CallOption) (*SavedSet, error)
+	GetSet(ctx context.Context, in *GetSetRequest, opts ...grpc.CallOption) (*SavedSet, error)
+	ListSets(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListSetsResponse, error)
+	ListSetVersions(ctx context.Context, in *ListSetVersionsRequest, opts ...grpc.CallOption) (*ListSetVersionsResponse, error)
+	DeleteSet(ctx context.Context, in *DeleteSetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Find tracks similar to a given track with explainable scoring.
 	GetSimilarTracks(ctx context.Context, in *SimilarTracksRequest, opts ...grpc.CallOption) (*SimilarTracksResponse, error)
 	// Get/update ML settings.
 	GetMLSettings(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*common.MLSettings, error)
 	UpdateMLSettings(ctx context.Context, in *common.MLSettings, opts ...grpc.CallOption) (*common.MLSettings, error)
+	// Named similarity weights, e.g. "vibe-first" or "harmonic-first".
+	ListSimilarityProfiles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListSimilarityProfilesResponse, error)
+	SaveSimilarityProfile(ctx context.Context, in *SimilarityProfile, opts ...grpc.CallOption) (*SimilarityProfile, error)
+	DeleteSimilarityProfile(ctx context.Context, in *DeleteSimilarityProfileRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// Training label CRUD
 	ListTrainingLabels(ctx context.Context, in *ListLabelsRequest, opts ...grpc.CallOption) (*ListLabelsResponse, error)
 	AddTrainingLabel(ctx context.Context, in *AddLabelRequest, opts ...grpc.CallOption) (*AddLabelResponse, error)
@@ -168,6 +219,16 @@ func (c *engineAPIClient) ProposeSet(ctx context.Context, in *SetPlanRequest, op
 	return out, nil
 }
 
+func (c *engineAPIClient) SuggestTransition(ctx context.Context, in *SuggestTransitionRequest, opts ...grpc.CallOption) (*SuggestTransitionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SuggestTransitionResponse)
+	err := c.cc.Invoke(ctx, EngineAPI_SuggestTransition_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *engineAPIClient) ExportSet(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ExportResponse)
@@ -178,6 +239,185 @@ func (c *engineAPIClient) ExportSet(ctx context.Context, in *ExportRequest, opts
 	return out, nil
 }
 
+func (c *engineAPIClient) ListLibraryRoots(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListLibraryRootsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListLibraryRootsResponse)
+	err := c.cc.Invoke(ctx, EngineAPI_ListLibraryRoots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) AddLibraryRoot(ctx context.Context, in *AddLibraryRootRequest, opts ...grpc.CallOption) (*LibraryRoot, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LibraryRoot)
+	err := c.cc.Invoke(ctx, EngineAPI_AddLibraryRoot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) RemoveLibraryRoot(ctx context.Context, in *RemoveLibraryRootRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, EngineAPI_RemoveLibraryRoot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) CheckLibraryHealth(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*LibraryHealthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LibraryHealthResponse)
+	err := c.cc.Invoke(ctx, EngineAPI_CheckLibraryHealth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) RelocateTracks(ctx context.Context, in *RelocateTracksRequest, opts ...grpc.CallOption) (*RelocateTracksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RelocateTracksResponse)
+	err := c.cc.Invoke(ctx, EngineAPI_RelocateTracks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) ListDuplicateGroups(ctx context.Context, in *ListDuplicateGroupsRequest, opts ...grpc.CallOption) (*ListDuplicateGroupsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDuplicateGroupsResponse)
+	err := c.cc.Invoke(ctx, EngineAPI_ListDuplicateGroups_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) StartLiveSession(ctx context.Context, in *SetPlanRequest, opts ...grpc.CallOption) (*LiveSession, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LiveSession)
+	err := c.cc.Invoke(ctx, EngineAPI_StartLiveSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) GetLiveSession(ctx context.Context, in *LiveSessionRequest, opts ...grpc.CallOption) (*LiveSession, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LiveSession)
+	err := c.cc.Invoke(ctx, EngineAPI_GetLiveSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) RecordLivePlay(ctx context.Context, in *LivePlayRequest, opts ...grpc.CallOption) (*LiveSession, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LiveSession)
+	err := c.cc.Invoke(ctx, EngineAPI_RecordLivePlay_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) EndLiveSession(ctx context.Context, in *LiveSessionRequest, opts ...grpc.CallOption) (*LiveSession, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LiveSession)
+	err := c.cc.Invoke(ctx, EngineAPI_EndLiveSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) WatchLiveSession(ctx context.Context, in *LiveSessionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LiveSession], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EngineAPI_ServiceDesc.Streams[3], EngineAPI_WatchLiveSession_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LiveSessionRequest, LiveSession]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EngineAPI_WatchLiveSessionClient = grpc.ServerStreamingClient[LiveSession]
+
+func (c *engineAPIClient) CreateSet(ctx context.Context, in *SaveSetRequest, opts ...grpc.CallOption) (*SavedSet, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SavedSet)
+	err := c.cc.Invoke(ctx, EngineAPI_CreateSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) UpdateSet(ctx context.Context, in *SaveSetRequest, opts ...grpc.CallOption) (*SavedSet, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SavedSet)
+	err := c.cc.Invoke(ctx, EngineAPI_UpdateSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) GetSet(ctx context.Context, in *GetSetRequest, opts ...grpc.CallOption) (*SavedSet, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SavedSet)
+	err := c.cc.Invoke(ctx, EngineAPI_GetSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) ListSets(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListSetsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSetsResponse)
+	err := c.cc.Invoke(ctx, EngineAPI_ListSets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) ListSetVersions(ctx context.Context, in *ListSetVersionsRequest, opts ...grpc.CallOption) (*ListSetVersionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSetVersionsResponse)
+	err := c.cc.Invoke(ctx, EngineAPI_ListSetVersions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) DeleteSet(ctx context.Context, in *DeleteSetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, EngineAPI_DeleteSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *engineAPIClient) GetSimilarTracks(ctx context.Context, in *SimilarTracksRequest, opts ...grpc.CallOption) (*SimilarTracksResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SimilarTracksResponse)
@@ -208,6 +448,36 @@ func (c *engineAPIClient) UpdateMLSettings(ctx context.Context, in *common.MLSet
 	return out, nil
 }
 
+func (c *engineAPIClient) ListSimilarityProfiles(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListSimilarityProfilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSimilarityProfilesResponse)
+	err := c.cc.Invoke(ctx, EngineAPI_ListSimilarityProfiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) SaveSimilarityProfile(ctx context.Context, in *SimilarityProfile, opts ...grpc.CallOption) (*SimilarityProfile, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SimilarityProfile)
+	err := c.cc.Invoke(ctx, EngineAPI_SaveSimilarityProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *engineAPIClient) DeleteSimilarityProfile(ctx context.Context, in *DeleteSimilarityProfileRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, EngineAPI_DeleteSimilarityProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *engineAPIClient) ListTrainingLabels(ctx context.Context, in *ListLabelsRequest, opts ...grpc.CallOption) (*ListLabelsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ListLabelsResponse)
@@ -280,7 +550,7 @@ func (c *engineAPIClient) ListTrainingJobs(ctx context.Context, in *ListJobsRequ
 
 func (c *engineAPIClient) StreamTrainingProgress(ctx context.Context, in *GetJobRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TrainingProgressUpdate], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &EngineAPI_ServiceDesc.Streams[3], EngineAPI_StreamTrainingProgress_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &EngineAPI_ServiceDesc.Streams[4], EngineAPI_StreamTrainingProgress_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -351,13 +621,43 @@ type EngineAPIServer interface {
 	GetTrack(context.Context, *GetTrackRequest) (*common.TrackAnalysis, error)
 	// Propose an ordering for a set with human-readable explanations.
 	ProposeSet(context.Context, *SetPlanRequest) (*SetPlanResponse, error)
+	// Rank beat-accurate places to mix from one track into another.
+	SuggestTransition(context.Context, *SuggestTransitionRequest) (*SuggestTransitionResponse, error)
 	// Export playlist + cues + analysis artifacts.
 	ExportSet(context.Context, *ExportRequest) (*ExportResponse, error)
+	// Persisted library roots, watched for added, moved and deleted files.
+	ListLibraryRoots(context.Context, *emptypb.Empty) (*ListLibraryRootsResponse, error)
+	AddLibraryRoot(context.Context, *AddLibraryRootRequest) (*LibraryRoot, error)
+	RemoveLibraryRoot(context.Context, *RemoveLibraryRootRequest) (*emptypb.Empty, error)
+	// Flag tracks whose file is gone, and find them again by content hash.
+	CheckLibraryHealth(context.Context, *emptypb.Empty) (*LibraryHealthResponse, error)
+	RelocateTracks(context.Context, *RelocateTracksRequest) (*RelocateTracksResponse, error)
+	// Group copies of the same recording across formats and bitrates.
+	ListDuplicateGroups(context.Context, *ListDuplicateGroupsRequest) (*ListDuplicateGroupsResponse, error)
+	// Follow a set as it's played, replanning what's left from the track playing now.
+	StartLiveSession(context.Context, *SetPlanRequest) (*LiveSession, error)
+	GetLiveSession(context.Context, *LiveSessionRequest) (*LiveSession, error)
+	RecordLivePlay(context.Context, *LivePlayRequest) (*LiveSession, error)
+	EndLiveSession(context.Context, *LiveSessionRequest) (*LiveSession, error)
+	// Stream the session each time it's replanned, until it ends.
+	WatchLiveSession(*LiveSessionRequest, grpc.ServerStreamingServer[LiveSession]) error
+	// Saved sets keep every version; each update adds one. ExportSet exports
+	// them by set_id without replanning.
+	CreateSet(context.Context, *SaveSetRequest) (*SavedSet, error)
+	UpdateSet(context.Context, *SaveSetRequest) (*SavedSet, error)
+	GetSet(context.Context, *GetSetRequest) (*SavedSet, error)
+	ListSets(context.Context, *emptypb.Empty) (*ListSetsResponse, error)
+	ListSetVersions(context.Context, *ListSetVersionsRequest) (*ListSetVersionsResponse, error)
+	DeleteSet(context.Context, *DeleteSetRequest) (*emptypb.Empty, error)
 	// Find tracks similar to a given track with explainable scoring.
 	GetSimilarTracks(context.Context, *SimilarTracksRequest) (*SimilarTracksResponse, error)
 	// Get/update ML settings.
 	GetMLSettings(context.Context, *emptypb.Empty) (*common.MLSettings, error)
 	UpdateMLSettings(context.Context, *common.MLSettings) (*common.MLSettings, error)
+	// Named similarity weights, e.g. "vibe-first" or "harmonic-first".
+	ListSimilarityProfiles(context.Context, *emptypb.Empty) (*ListSimilarityProfilesResponse, error)
+	SaveSimilarityProfile(context.Context, *SimilarityProfile) (*SimilarityProfile, error)
+	DeleteSimilarityProfile(context.Context, *DeleteSimilarityProfileRequest) (*emptypb.Empty, error)
 	// Training label CRUD
 	ListTrainingLabels(context.Context, *ListLabelsRequest) (*ListLabelsResponse, error)
 	AddTrainingLabel(context.Context, *AddLabelRequest) (*AddLabelResponse, error)
@@ -399,9 +699,63 @@ func (UnimplementedEngineAPIServer) GetTrack(context.Context, *GetTrackRequest)
 func (UnimplementedEngineAPIServer) ProposeSet(context.Context, *SetPlanRequest) (*SetPlanResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ProposeSet not implemented")
 }
+func (UnimplementedEngineAPIServer) SuggestTransition(context.Context, *SuggestTransitionRequest) (*SuggestTransitionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SuggestTransition not implemented")
+}
 func (UnimplementedEngineAPIServer) ExportSet(context.Context, *ExportRequest) (*ExportResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ExportSet not implemented")
 }
+func (UnimplementedEngineAPIServer) ListLibraryRoots(context.Context, *emptypb.Empty) (*ListLibraryRootsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListLibraryRoots not implemented")
+}
+func (UnimplementedEngineAPIServer) AddLibraryRoot(context.Context, *AddLibraryRootRequest) (*LibraryRoot, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddLibraryRoot not implemented")
+}
+func (UnimplementedEngineAPIServer) RemoveLibraryRoot(context.Context, *RemoveLibraryRootRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method RemoveLibraryRoot not implemented")
+}
+func (UnimplementedEngineAPIServer) CheckLibraryHealth(context.Context, *emptypb.Empty) (*LibraryHealthResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CheckLibraryHealth not implemented")
+}
+func (UnimplementedEngineAPIServer) RelocateTracks(context.Context, *RelocateTracksRequest) (*RelocateTracksResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RelocateTracks not implemented")
+}
+func (UnimplementedEngineAPIServer) ListDuplicateGroups(context.Context, *ListDuplicateGroupsRequest) (*ListDuplicateGroupsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListDuplicateGroups not implemented")
+}
+func (UnimplementedEngineAPIServer) StartLiveSession(context.Context, *SetPlanRequest) (*LiveSession, error) {
+	return nil, status.Error(codes.Unimplemented, "method StartLiveSession not implemented")
+}
+func (UnimplementedEngineAPIServer) GetLiveSession(context.Context, *LiveSessionRequest) (*LiveSession, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetLiveSession not implemented")
+}
+func (UnimplementedEngineAPIServer) RecordLivePlay(context.Context, *LivePlayRequest) (*LiveSession, error) {
+	return nil, status.Error(codes.Unimplemented, "method RecordLivePlay not implemented")
+}
+func (UnimplementedEngineAPIServer) EndLiveSession(context.Context, *LiveSessionRequest) (*LiveSession, error) {
+	return nil, status.Error(codes.Unimplemented, "method EndLiveSession not implemented")
+}
+func (UnimplementedEngineAPIServer) WatchLiveSession(*LiveSessionRequest, grpc.ServerStreamingServer[LiveSession]) error {
+	return status.Error(codes.Unimplemented, "method WatchLiveSession not implemented")
+}
+func (UnimplementedEngineAPIServer) CreateSet(context.Context, *SaveSetRequest) (*SavedSet, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateSet not implemented")
+}
+func (UnimplementedEngineAPIServer) UpdateSet(context.Context, *SaveSetRequest) (*SavedSet, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateSet not implemented")
+}
+func (UnimplementedEngineAPIServer) GetSet(context.Context, *GetSetRequest) (*SavedSet, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetSet not implemented")
+}
+func (UnimplementedEngineAPIServer) ListSets(context.Context, *emptypb.Empty) (*ListSetsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListSets not implemented")
+}
+func (UnimplementedEngineAPIServer) ListSetVersions(context.Context, *ListSetVersionsRequest) (*ListSetVersionsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListSetVersions not implemented")
+}
+func (UnimplementedEngineAPIServer) DeleteSet(context.Context, *DeleteSetRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteSet not implemented")
+}
 func (UnimplementedEngineAPIServer) GetSimilarTracks(context.Context, *SimilarTracksRequest) (*SimilarTracksResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetSimilarTracks not implemented")
 }
@@ -411,6 +765,15 @@ func (UnimplementedEngineAPIServer) GetMLSettings(context.Context, *emptypb.Empt
 func (UnimplementedEngineAPIServer) UpdateMLSettings(context.Context, *common.MLSettings) (*common.MLSettings, error) {
 	return nil, status.Error(codes.Unimplemented, "method UpdateMLSettings not implemented")
 }
+func (UnimplementedEngineAPIServer) ListSimilarityProfiles(context.Context, *emptypb.Empty) (*ListSimilarityProfilesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListSimilarityProfiles not implemented")
+}
+func (UnimplementedEngineAPIServer) SaveSimilarityProfile(context.Context, *SimilarityProfile) (*SimilarityProfile, error) {
+	return nil, status.Error(codes.Unimplemented, "method SaveSimilarityProfile not implemented")
+}
+func (UnimplementedEngineAPIServer) DeleteSimilarityProfile(context.Context, *DeleteSimilarityProfileRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteSimilarityProfile not implemented")
+}
 func (UnimplementedEngineAPIServer) ListTrainingLabels(context.Context, *ListLabelsRequest) (*ListLabelsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListTrainingLabels not implemented")
 }
@@ -537,6 +900,24 @@ func _EngineAPI_ProposeSet_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EngineAPI_SuggestTransition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuggestTransitionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).SuggestTransition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_SuggestTransition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).SuggestTransition(ctx, req.(*SuggestTransitionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _EngineAPI_ExportSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ExportRequest)
 	if err := dec(in); err != nil {
@@ -555,6 +936,305 @@ func _EngineAPI_ExportSet_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EngineAPI_ListLibraryRoots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).ListLibraryRoots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_ListLibraryRoots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).ListLibraryRoots(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_AddLibraryRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddLibraryRootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).AddLibraryRoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_AddLibraryRoot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).AddLibraryRoot(ctx, req.(*AddLibraryRootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_RemoveLibraryRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveLibraryRootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).RemoveLibraryRoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_RemoveLibraryRoot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).RemoveLibraryRoot(ctx, req.(*RemoveLibraryRootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_CheckLibraryHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).CheckLibraryHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_CheckLibraryHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).CheckLibraryHealth(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_RelocateTracks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RelocateTracksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).RelocateTracks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_RelocateTracks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).RelocateTracks(ctx, req.(*RelocateTracksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_ListDuplicateGroups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDuplicateGroupsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).ListDuplicateGroups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_ListDuplicateGroups_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).ListDuplicateGroups(ctx, req.(*ListDuplicateGroupsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_StartLiveSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPlanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).StartLiveSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_StartLiveSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).StartLiveSession(ctx, req.(*SetPlanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_GetLiveSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LiveSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).GetLiveSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_GetLiveSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).GetLiveSession(ctx, req.(*LiveSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_RecordLivePlay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LivePlayRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).RecordLivePlay(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_RecordLivePlay_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).RecordLivePlay(ctx, req.(*LivePlayRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_EndLiveSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LiveSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).EndLiveSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_EndLiveSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).EndLiveSession(ctx, req.(*LiveSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_WatchLiveSession_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LiveSessionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EngineAPIServer).WatchLiveSession(m, &grpc.GenericServerStream[LiveSessionRequest, LiveSession]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EngineAPI_WatchLiveSessionServer = grpc.ServerStreamingServer[LiveSession]
+
+func _EngineAPI_CreateSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).CreateSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_CreateSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).CreateSet(ctx, req.(*SaveSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_UpdateSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).UpdateSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_UpdateSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).UpdateSet(ctx, req.(*SaveSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_GetSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).GetSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_GetSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).GetSet(ctx, req.(*GetSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_ListSets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).ListSets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_ListSets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).ListSets(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_ListSetVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSetVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).ListSetVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_ListSetVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).ListSetVersions(ctx, req.(*ListSetVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_DeleteSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).DeleteSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_DeleteSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).DeleteSet(ctx, req.(*DeleteSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _EngineAPI_GetSimilarTracks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SimilarTracksRequest)
 	if err := dec(in); err != nil {
@@ -609,6 +1289,60 @@ func _EngineAPI_UpdateMLSettings_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EngineAPI_ListSimilarityProfiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).ListSimilarityProfiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_ListSimilarityProfiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).ListSimilarityProfiles(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_SaveSimilarityProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimilarityProfile)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).SaveSimilarityProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_SaveSimilarityProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).SaveSimilarityProfile(ctx, req.(*SimilarityProfile))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EngineAPI_DeleteSimilarityProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSimilarityProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EngineAPIServer).DeleteSimilarityProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EngineAPI_DeleteSimilarityProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EngineAPIServer).DeleteSimilarityProfile(ctx, req.(*DeleteSimilarityProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _EngineAPI_ListTrainingLabels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListLabelsRequest)
 	if err := dec(in); err != nil {
@@ -833,10 +1567,78 @@ var EngineAPI_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ProposeSet",
 			Handler:    _EngineAPI_ProposeSet_Handler,
 		},
+		{
+			MethodName: "SuggestTransition",
+			Handler:    _EngineAPI_SuggestTransition_Handler,
+		},
 		{
 			MethodName: "ExportSet",
 			Handler:    _EngineAPI_ExportSet_Handler,
 		},
+		{
+			MethodName: "ListLibraryRoots",
+			Handler:    _EngineAPI_ListLibraryRoots_Handler,
+		},
+		{
+			MethodName: "AddLibraryRoot",
+			Handler:    _EngineAPI_AddLibraryRoot_Handler,
+		},
+		{
+			MethodName: "RemoveLibraryRoot",
+			Handler:    _EngineAPI_RemoveLibraryRoot_Handler,
+		},
+		{
+			MethodName: "CheckLibraryHealth",
+			Handler:    _EngineAPI_CheckLibraryHealth_Handler,
+		},
+		{
+			MethodName: "RelocateTracks",
+			Handler:    _EngineAPI_RelocateTracks_Handler,
+		},
+		{
+			MethodName: "ListDuplicateGroups",
+			Handler:    _EngineAPI_ListDuplicateGroups_Handler,
+		},
+		{
+			MethodName: "StartLiveSession",
+			Handler:    _EngineAPI_StartLiveSession_Handler,
+		},
+		{
+			MethodName: "GetLiveSession",
+			Handler:    _EngineAPI_GetLiveSession_Handler,
+		},
+		{
+			MethodName: "RecordLivePlay",
+			Handler:    _EngineAPI_RecordLivePlay_Handler,
+		},
+		{
+			MethodName: "EndLiveSession",
+			Handler:    _EngineAPI_EndLiveSession_Handler,
+		},
+		{
+			MethodName: "CreateSet",
+			Handler:    _EngineAPI_CreateSet_Handler,
+		},
+		{
+			MethodName: "UpdateSet",
+			Handler:    _EngineAPI_UpdateSet_Handler,
+		},
+		{
+			MethodName: "GetSet",
+			Handler:    _EngineAPI_GetSet_Handler,
+		},
+		{
+			MethodName: "ListSets",
+			Handler:    _EngineAPI_ListSets_Handler,
+		},
+		{
+			MethodName: "ListSetVersions",
+			Handler:    _EngineAPI_ListSetVersions_Handler,
+		},
+		{
+			MethodName: "DeleteSet",
+			Handler:    _EngineAPI_DeleteSet_Handler,
+		},
 		{
 			MethodName: "GetSimilarTracks",
 			Handler:    _EngineAPI_GetSimilarTracks_Handler,
@@ -849,6 +1651,18 @@ var EngineAPI_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateMLSettings",
 			Handler:    _EngineAPI_UpdateMLSettings_Handler,
 		},
+		{
+			MethodName: "ListSimilarityProfiles",
+			Handler:    _EngineAPI_ListSimilarityProfiles_Handler,
+		},
+		{
+			MethodName: "SaveSimilarityProfile",
+			Handler:    _EngineAPI_SaveSimilarityProfile_Handler,
+		},
+		{
+			MethodName: "DeleteSimilarityProfile",
+			Handler:    _EngineAPI_DeleteSimilarityProfile_Handler,
+		},
 		{
 			MethodName: "ListTrainingLabels",
 			Handler:    _EngineAPI_ListTrainingLabels_Handler,
@@ -910,6 +1724,11 @@ var EngineAPI_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _EngineAPI_ListTracks_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchLiveSession",
+			Handler:       _EngineAPI_WatchLiveSession_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "StreamTrainingProgress",
 			Handler:       _EngineAPI_StreamTrainingProgress_Handler,
@@ -11,3 +11,12 @@ type Analyzer interface {
 	AnalyzeTrack(ctx context.Context, job *analyzer.AnalyzeJob) (*analyzer.AnalyzeResult, error)
 	Close() error
 }
+
+// StageFunc receives per-stage progress events while a track is being analyzed.
+type StageFunc func(event *analyzer.StageEvent)
+
+// StageAnalyzer is implemented by backends that can report real per-stage progress.
+type StageAnalyzer interface {
+	Analyzer
+	AnalyzeTrackStages(ctx context.Context, job *analyzer.AnalyzeJob, onStage StageFunc) (*analyzer.AnalyzeResult, error)
+}
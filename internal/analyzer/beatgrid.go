@@ -0,0 +1,530 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+)
+
+// onsetRate is the frame rate of the onset envelope in frames per second.
+const onsetRate = 200.0
+
+// beatgridResult is the output of detectBeats.
+type beatgridResult struct {
+	times         []float64 // beat positions in seconds
+	firstDownbeat int       // index of the first downbeat in times
+	bpm           float64   // global tempo
+	tempoMap      []tempoNode
+	dynamic       bool
+	confidence    float64
+}
+
+type tempoNode struct {
+	beat int
+	bpm  float64
+}
+
+// onsetEnvelope holds onset novelty curves sampled at fps.
+type onsetEnvelope struct {
+	fps    float64
+	offset float64 // seconds to add to frame times (half the analysis window)
+	full   []float64
+	low    []float64 // level rises below 200 Hz, used to find kick-driven downbeats
+}
+
+func (e *onsetEnvelope) time(frame float64) float64 { return frame/e.fps + e.offset }
+
+// computeOnsets decimates x to ~12 kHz and computes an onset novelty curve: log-magnitude
+// spectral flux plus broadband level rises. Flux catches hats, clicks and note changes;
+// level rises catch low kicks that barely change the log spectrum under a bass line.
+func computeOnsets(x []float32, sampleRate int) *onsetEnvelope {
+	factor := sampleRate / 12000
+	if factor < 1 {
+		factor = 1
+	}
+	y := make([]float32, len(x)/factor)
+	for i := range y {
+		var sum float32
+		for _, v := range x[i*factor : (i+1)*factor] {
+			sum += v
+		}
+		y[i] = sum / float32(factor)
+	}
+	rate := float64(sampleRate) / float64(factor)
+
+	hop := int(math.Round(rate / onsetRate))
+	size := nextPow2(hop * 8) // ~23 Hz bins, so neighbouring partials don't beat within a bin
+	lowBins := int(200 / (rate / float64(size)))
+
+	env := &onsetEnvelope{fps: rate / float64(hop), offset: float64(size) / 2 / rate}
+	prev := make([]float64, size/2+1)
+	logMag := make([]float64, size/2+1)
+	var flux, totalDB, lowDB []float64
+	spectrogram(y, size, hop, func(frame int, mag []float64) {
+		var f, power, lowPower float64
+		for k, m := range mag {
+			m /= float64(size) / 4 // a full-scale sine peaks at 1
+			logMag[k] = math.Log1p(100 * m)
+			// Compare against the loudest neighbouring bin of the previous frame
+			// (SuperFlux-style) so leakage wobble around steady partials isn't flux.
+			ref := prev[k]
+			if k > 0 {
+				ref = math.Max(ref, prev[k-1])
+			}
+			if k+1 < len(prev) {
+				ref = math.Max(ref, prev[k+1])
+			}
+			if d := logMag[k] - ref; d > 0 && frame > 0 {
+				f += d
+			}
+			power += m * m
+			if k > 0 && k <= lowBins {
+				lowPower += m * m
+			}
+		}
+		copy(prev, logMag)
+		flux = append(flux, f)
+		totalDB = append(totalDB, dbfs(power))
+		lowDB = append(lowDB, dbfs(lowPower))
+	})
+
+	// Note releases and abruptly truncated samples splash broadband flux while the
+	// signal gets quieter, so flux is faded out where the level falls. Level rises are
+	// measured over half a window and need to clear 3 dB of wobble to count.
+	env.full = make([]float64, len(flux))
+	env.low = make([]float64, len(flux))
+	lag := size / hop / 2
+	for i := range flux {
+		lo, hi := max(0, i-2), min(len(flux)-1, i+2)
+		weight := math.Max(0, math.Min(1, (totalDB[hi]-totalDB[lo]+3)/3))
+		var rise, lowRise float64
+		if i >= lag {
+			rise = math.Max(0, totalDB[i]-totalDB[i-lag]-3)
+			lowRise = math.Max(0, lowDB[i]-lowDB[i-lag]-3)
+		}
+		env.full[i] = flux[i]*weight + 3*rise
+		env.low[i] = lowRise
+	}
+
+	// Subtract a 0.25 s moving average so sustained textures don't mask transients.
+	win := int(env.fps / 4)
+	env.full = highPass(env.full, win)
+	env.low = highPass(env.low, win)
+	return env
+}
+
+func highPass(x []float64, win int) []float64 {
+	out := make([]float64, len(x))
+	var sum float64
+	for i := range x {
+		sum += x[i]
+		if i >= win {
+			sum -= x[i-win]
+		}
+		n := i + 1
+		if n > win {
+			n = win
+		}
+		if v := x[i] - sum/float64(n); v > 0 {
+			out[i] = v
+		}
+	}
+	return out
+}
+
+// estimatePeriod returns the dominant beat period in envelope frames within [floor, ceil]
+// BPM, plus its normalised autocovariance as a 0-1 pulse strength. The biased
+// autocovariance favours the shorter of two octave-related periods, and a broad prior
+// centred on 120 BPM breaks near-ties. When the only clear periodicity is slower than
+// floor (e.g. a kick on every bar and nothing in between) it is folded into range.
+func estimatePeriod(env []float64, fps, floor, ceil float64) (float64, float64) {
+	minLag := int(math.Floor(fps * 60 / ceil))
+	maxLag := int(math.Ceil(fps * 60 / floor))
+	if minLag < 1 {
+		minLag = 1
+	}
+	longLag := 4 * maxLag
+	if longLag >= len(env)-1 {
+		longLag = len(env) - 2
+	}
+	if maxLag > longLag {
+		maxLag = longLag
+	}
+	if maxLag <= minLag {
+		return fps * 60 / 120, 0
+	}
+
+	// Remove the mean so that a dense noisy envelope doesn't look periodic.
+	var mean float64
+	for _, v := range env {
+		mean += v / float64(len(env))
+	}
+	acf := make([]float64, longLag+2)
+	for lag := 0; lag <= longLag+1; lag++ {
+		var sum float64
+		for i := 0; i+lag < len(env); i++ {
+			sum += (env[i] - mean) * (env[i+lag] - mean)
+		}
+		acf[lag] = sum
+	}
+	if acf[0] <= 0 {
+		return fps * 60 / 120, 0
+	}
+	isPeak := func(lag int) bool { return acf[lag] >= acf[lag-1] && acf[lag] >= acf[lag+1] }
+
+	best, bestScore := 0, -1.0
+	for lag := minLag; lag <= maxLag; lag++ {
+		if !isPeak(lag) {
+			continue
+		}
+		bpm := fps * 60 / float64(lag)
+		prior := math.Exp(-0.5 * math.Pow(math.Log2(bpm/120), 2))
+		if score := acf[lag] * prior; score > bestScore {
+			best, bestScore = lag, score
+		}
+	}
+
+	longBest := 0
+	for lag := maxLag + 1; lag <= longLag; lag++ {
+		if isPeak(lag) && (longBest == 0 || acf[lag] > acf[longBest]) {
+			longBest = lag
+		}
+	}
+	if longBest > 0 && (best == 0 || acf[longBest] > 2*acf[best]) {
+		period := refinePeak(acf, longBest)
+		strength := acf[longBest] / acf[0]
+		for fps*60/period < floor || math.Abs(math.Log2(fps*60/(period/2)/120)) < math.Abs(math.Log2(fps*60/period/120)) {
+			if period/2 < float64(minLag) {
+				break
+			}
+			period /= 2
+		}
+		return period, math.Max(0, strength)
+	}
+	if best == 0 {
+		return fps * 60 / 120, 0
+	}
+	return refinePeak(acf, best), math.Max(0, acf[best]/acf[0])
+}
+
+// refinePeak interpolates a parabola through acf around lag for a sub-frame position.
+func refinePeak(acf []float64, lag int) float64 {
+	a, b, c := acf[lag-1], acf[lag], acf[lag+1]
+	if d := a - 2*b + c; d != 0 {
+		return float64(lag) + 0.5*(a-c)/d
+	}
+	return float64(lag)
+}
+
+// detectBeats tracks beats through the onset envelope, refines them against the
+// full-rate signal and fits either a static grid or a tempo map.
+func detectBeats(x []float32, sampleRate int, floor, ceil float64, allowDynamic bool) beatgridResult {
+	if floor <= 0 {
+		floor = 60
+	}
+	if ceil <= floor {
+		ceil = 180
+	}
+	duration := float64(len(x)) / float64(sampleRate)
+
+	env := computeOnsets(x, sampleRate)
+	if len(env.full) < int(env.fps*2) {
+		return beatgridResult{}
+	}
+	period, strength := estimatePeriod(env.full, env.fps, floor, ceil)
+
+	// Start from the local tempo at the beginning so ramps can be followed, unless the
+	// opening has no clear pulse of its own (e.g. a beatless intro).
+	startPeriod := period
+	if allowDynamic {
+		head := env.full
+		if n := int(env.fps * 5); len(head) > n {
+			head = head[:n]
+		}
+		local, localStrength := estimatePeriod(head, env.fps, 60*env.fps/(period*1.2), 60*env.fps/(period*0.8))
+		if localStrength >= 0.3 {
+			startPeriod = local
+		}
+		// A tempo that drifts smears the whole-track autocorrelation; a clear local
+		// pulse is just as good evidence of a beat.
+		strength = math.Max(strength, localStrength)
+	}
+
+	// Phase: offset in [0, period) whose comb collects the most onset energy. The comb spans 64
+	// beats for a steady tempo but only 16 when the tempo may drift, and each tooth
+	// looks ±2 frames either side to tolerate a slightly-off period.
+	combLen := 64
+	if allowDynamic {
+		combLen = 16
+	}
+	phase, bestComb, combBeats := 0, -1.0, 1
+	for p := 0; p < int(math.Ceil(startPeriod)) && p < len(env.full); p++ {
+		var sum float64
+		k := 0
+		for ; k < combLen; k++ {
+			i := int(math.Round(float64(p) + float64(k)*startPeriod))
+			if i >= len(env.full) {
+				break
+			}
+			tooth := 0.0
+			for j := max(0, i-2); j <= i+2 && j < len(env.full); j++ {
+				tooth = math.Max(tooth, env.full[j])
+			}
+			sum += tooth
+		}
+		if sum > bestComb {
+			phase, bestComb, combBeats = p, sum, max(1, k)
+		}
+	}
+
+	threshold := envelopeThreshold(env.full)
+	fine := newFineOnsets(x, sampleRate)
+
+	// Beats lock onto onsets at least half as strong as recent ones (a decaying
+	// reference seeded from the phase comb); weaker onsets don't move the grid, so
+	// sparse kicks aren't pulled around by noise in between.
+	ref := bestComb / float64(combBeats)
+	var times []float64
+	var snapped []bool
+	cur := float64(phase)
+	p := startPeriod
+	lastHit := -1.0
+	for cur < float64(len(env.full)) {
+		// Snap to the strongest onset within ±10% of a period.
+		radius := int(math.Max(1, p*0.1))
+		center := int(math.Round(cur))
+		peak, peakVal := center, 0.0
+		for i := center - radius; i <= center+radius; i++ {
+			if i >= 0 && i < len(env.full) && env.full[i] > peakVal {
+				peak, peakVal = i, env.full[i]
+			}
+		}
+		strong := peakVal > threshold && peakVal >= 0.5*ref
+		ref = math.Max(peakVal, 0.9*ref)
+
+		pos := cur
+		if strong {
+			pos = float64(peak)
+			if allowDynamic && lastHit >= 0 {
+				gap := pos - lastHit
+				if beats := math.Round(gap / p); beats >= 1 && beats <= 4 {
+					p = 0.7*p + 0.3*gap/beats
+				}
+			}
+			lastHit = pos
+		}
+
+		t := env.time(pos)
+		if strong {
+			t = fine.refine(t)
+		}
+		times = append(times, t)
+		snapped = append(snapped, strong)
+		cur = pos + p
+	}
+
+	res := beatgridResult{}
+	// Confidence: pulse clarity, scaled by the share of bars the grid actually locked
+	// onto an onset in (kick-on-the-one patterns count as fully supported).
+	var bars, supported int
+	for i := 0; i < len(snapped); i += 4 {
+		bars++
+		for _, h := range snapped[i:min(i+4, len(snapped))] {
+			if h {
+				supported++
+				break
+			}
+		}
+	}
+	if bars > 0 {
+		res.confidence = math.Min(1, 1.25*strength) * float64(supported) / float64(bars)
+	}
+
+	slope, intercept, rms := fitGrid(times, snapped)
+	// Without a clear pulse, timing jitter is noise rather than tempo drift.
+	res.dynamic = allowDynamic && rms > 0.025 && strength >= 0.2
+	if slope > 0 && !res.dynamic {
+		// Static tempo: regularise to a perfect grid covering the whole track.
+		res.bpm = 60 / slope
+		for intercept-slope > -0.02 {
+			intercept -= slope
+		}
+		times = times[:0]
+		for k := 0; intercept+float64(k)*slope < duration; k++ {
+			times = append(times, math.Max(0, intercept+float64(k)*slope))
+		}
+		res.tempoMap = []tempoNode{{beat: 0, bpm: res.bpm}}
+	} else {
+		// Extend the tracked beats back to the start of the track at the opening tempo.
+		if len(times) > 1 {
+			gap := times[1] - times[0]
+			for gap > 0 && times[0]-gap > -0.02 {
+				times = append([]float64{math.Max(0, times[0]-gap)}, times...)
+			}
+		}
+		res.tempoMap = tempoMapFromBeats(times)
+		res.bpm = medianBPM(times)
+	}
+	res.times = times
+	res.firstDownbeat = downbeatOffset(env, times)
+	return res
+}
+
+// envelopeThreshold separates onsets from noise: mean plus half a standard deviation.
+func envelopeThreshold(env []float64) float64 {
+	var sum, sq float64
+	for _, v := range env {
+		sum += v
+		sq += v * v
+	}
+	n := float64(len(env))
+	mean := sum / n
+	std := math.Sqrt(math.Max(0, sq/n-mean*mean))
+	return mean + 0.5*std
+}
+
+// fitGrid regresses snapped beat times on beat index and returns the slope, intercept and RMS residual.
+func fitGrid(times []float64, snapped []bool) (slope, intercept, rms float64) {
+	var n, sx, sy, sxx, sxy float64
+	for i, t := range times {
+		if !snapped[i] {
+			continue
+		}
+		x := float64(i)
+		n++
+		sx += x
+		sy += t
+		sxx += x * x
+		sxy += x * t
+	}
+	if n < 2 {
+		return 0, 0, 0
+	}
+	den := n*sxx - sx*sx
+	if den == 0 {
+		return 0, 0, 0
+	}
+	slope = (n*sxy - sx*sy) / den
+	intercept = (sy - slope*sx) / n
+
+	var ss float64
+	for i, t := range times {
+		if snapped[i] {
+			r := t - (intercept + slope*float64(i))
+			ss += r * r
+		}
+	}
+	return slope, intercept, math.Sqrt(ss / n)
+}
+
+// tempoMapFromBeats emits a node per bar whenever the local tempo moves by more than half a BPM.
+func tempoMapFromBeats(times []float64) []tempoNode {
+	var nodes []tempoNode
+	for i := 0; i+4 < len(times); i += 4 {
+		bpm := 60 * 4 / (times[i+4] - times[i])
+		if len(nodes) == 0 || math.Abs(bpm-nodes[len(nodes)-1].bpm) > 0.5 {
+			nodes = append(nodes, tempoNode{beat: i, bpm: math.Round(bpm*100) / 100})
+		}
+	}
+	return nodes
+}
+
+func medianBPM(times []float64) float64 {
+	if len(times) < 2 {
+		return 0
+	}
+	gaps := make([]float64, 0, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		gaps = append(gaps, times[i]-times[i-1])
+	}
+	sort.Float64s(gaps)
+	return 60 / gaps[len(gaps)/2]
+}
+
+// downbeatOffset picks the beat phase (0-3) with the most low-frequency onset energy,
+// i.e. where the kick lands on the one. Without a clear winner the first beat is used.
+func downbeatOffset(env *onsetEnvelope, times []float64) int {
+	var sums [4]float64
+	for i, t := range times {
+		frame := int(math.Round((t - env.offset) * env.fps))
+		best := 0.0
+		for f := frame - 2; f <= frame+2; f++ {
+			if f >= 0 && f < len(env.low) && env.low[f] > best {
+				best = env.low[f]
+			}
+		}
+		sums[i%4] += best
+	}
+	best := 0
+	for i := 1; i < 4; i++ {
+		if sums[i] > sums[best] {
+			best = i
+		}
+	}
+	var others float64
+	for i, s := range sums {
+		if i != best {
+			others += s / 3
+		}
+	}
+	if sums[best] < 1.15*others {
+		return 0
+	}
+	return best
+}
+
+// fineOnsets is a 1 ms energy envelope used to place beats precisely.
+type fineOnsets struct {
+	power []float64
+}
+
+func newFineOnsets(x []float32, sampleRate int) *fineOnsets {
+	block := sampleRate / 1000
+	if block < 1 {
+		block = 1
+	}
+	f := &fineOnsets{power: make([]float64, len(x)/block)}
+	for i := range f.power {
+		f.power[i] = meanSquare(x, i*block, (i+1)*block)
+	}
+	return f
+}
+
+// refine moves t to the 1 ms block boundary within ±25 ms where the level over the
+// following 5 ms jumps above the preceding 5 ms. Comparing 5 ms spans rather than single
+// blocks keeps low kicks, whose per-millisecond energy follows the waveform, from
+// snapping to a zero crossing. The earliest jump at least half as large as the biggest
+// (and at least 6 dB) wins, since a drum's body can rise again after its attack.
+func (f *fineOnsets) refine(t float64) float64 {
+	const span = 5
+	level := func(from int) float64 {
+		var sum float64
+		for _, p := range f.power[from : from+span] {
+			sum += p
+		}
+		return dbfs(sum / span)
+	}
+	center := int(t * 1000)
+	lo, hi := max(span, center-25), min(len(f.power)-span, center+25)
+	if lo > hi {
+		return t
+	}
+	rises := make([]float64, hi-lo+1)
+	maxRise := 0.0
+	for m := lo; m <= hi; m++ {
+		rises[m-lo] = level(m) - level(m-span)
+		maxRise = math.Max(maxRise, rises[m-lo])
+	}
+	if maxRise < 6 {
+		return t
+	}
+	for i, r := range rises {
+		if r < math.Max(6, maxRise/2) {
+			continue
+		}
+		if i+1 < len(rises) && rises[i+1] > r {
+			continue // still climbing towards the onset
+		}
+		return float64(lo+i) / 1000
+	}
+	return t
+}
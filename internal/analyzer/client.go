@@ -2,12 +2,17 @@ package analyzer
 
 import (
 	"context"
+	"errors"
+	"io"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/cartomix/cancun/gen/go/analyzer"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 // Client wraps the gRPC analyzer worker client with connection management.
@@ -15,6 +20,9 @@ type Client struct {
 	conn   *grpc.ClientConn
 	client analyzer.AnalyzerWorkerClient
 	logger *slog.Logger
+
+	// streamUnsupported is set once the worker answers AnalyzeTrackStream with UNIMPLEMENTED.
+	streamUnsupported atomic.Bool
 }
 
 // NewClient creates a gRPC client for the Swift analyzer worker.
@@ -60,6 +68,60 @@ func (c *Client) AnalyzeTrack(ctx context.Context, job *analyzer.AnalyzeJob) (*a
 	return result, nil
 }
 
+// AnalyzeTrackStages sends an analysis job and relays the worker's per-stage events
+// to onStage. Workers without streaming support fall back to AnalyzeTrack, in which
+// case onStage is never called.
+func (c *Client) AnalyzeTrackStages(ctx context.Context, job *analyzer.AnalyzeJob, onStage StageFunc) (*analyzer.AnalyzeResult, error) {
+	if c.streamUnsupported.Load() {
+		return c.AnalyzeTrack(ctx, job)
+	}
+
+	c.logger.Debug("sending streaming analysis job to worker",
+		"track_id", job.GetId().GetContentHash(),
+		"path", job.GetPath(),
+	)
+
+	start := time.Now()
+	stream, err := c.client.AnalyzeTrackStream(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("analyzer stream ended without a result")
+		}
+		if err != nil {
+			if status.Code(err) == codes.Unimplemented {
+				c.streamUnsupported.Store(true)
+				c.logger.Info("analyzer worker does not stream stages, falling back to unary analysis")
+				return c.AnalyzeTrack(ctx, job)
+			}
+			c.logger.Error("analysis failed",
+				"track_id", job.GetId().GetContentHash(),
+				"error", err,
+				"duration", time.Since(start),
+			)
+			return nil, err
+		}
+
+		switch e := event.GetEvent().(type) {
+		case *analyzer.AnalyzeEvent_Stage:
+			if onStage != nil {
+				onStage(e.Stage)
+			}
+		case *analyzer.AnalyzeEvent_Result:
+			c.logger.Info("analysis complete",
+				"track_id", job.GetId().GetContentHash(),
+				"duration", time.Since(start),
+				"bpm", e.Result.GetAnalysis().GetBeatgrid().GetTempoMap(),
+			)
+			return e.Result, nil
+		}
+	}
+}
+
 // Close closes the gRPC connection.
 func (c *Client) Close() error {
 	return c.conn.Close()
@@ -0,0 +1,116 @@
+package analyzer
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/analyzer"
+	"github.com/cartomix/cancun/gen/go/common"
+	"google.golang.org/grpc"
+)
+
+// unaryOnlyWorker mimics an older worker that predates AnalyzeTrackStream.
+type unaryOnlyWorker struct {
+	analyzer.UnimplementedAnalyzerWorkerServer
+	stub *StubWorker
+}
+
+func (w *unaryOnlyWorker) AnalyzeTrack(ctx context.Context, job *analyzer.AnalyzeJob) (*analyzer.AnalyzeResult, error) {
+	return w.stub.AnalyzeTrack(ctx, job)
+}
+
+func startWorker(t *testing.T, srv analyzer.AnalyzerWorkerServer) *Client {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := grpc.NewServer()
+	analyzer.RegisterAnalyzerWorkerServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	client, err := NewClient(lis.Addr().String(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func testJob() *analyzer.AnalyzeJob {
+	return NewJob(&common.TrackId{ContentHash: "abc", Path: "/music/a.wav"}, "/music/a.wav", 1)
+}
+
+func TestAnalyzeTrackStagesRelaysEvents(t *testing.T) {
+	client := startWorker(t, NewStubWorker(time.Millisecond))
+
+	var events []*analyzer.StageEvent
+	res, err := client.AnalyzeTrackStages(context.Background(), testJob(), func(ev *analyzer.StageEvent) {
+		events = append(events, ev)
+	})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if res.GetAnalysis().GetId().GetContentHash() != "abc" {
+		t.Errorf("unexpected analysis id %v", res.GetAnalysis().GetId())
+	}
+
+	if len(events) != 2*len(StubStages) {
+		t.Fatalf("expected %d events, got %d", 2*len(StubStages), len(events))
+	}
+	var lastPercent float32
+	for i, stage := range StubStages {
+		started, completed := events[2*i], events[2*i+1]
+		if started.Stage != stage.Name || started.Status != "started" {
+			t.Errorf("event %d: got %s/%s, want %s/started", 2*i, started.Stage, started.Status, stage.Name)
+		}
+		if completed.Stage != stage.Name || completed.Status != "completed" {
+			t.Errorf("event %d: got %s/%s, want %s/completed", 2*i+1, completed.Stage, completed.Status, stage.Name)
+		}
+		if completed.Percent < lastPercent {
+			t.Errorf("percent went backwards at %s: %.1f < %.1f", stage.Name, completed.Percent, lastPercent)
+		}
+		lastPercent = completed.Percent
+	}
+	if lastPercent != 100 {
+		t.Errorf("expected final percent 100, got %.1f", lastPercent)
+	}
+}
+
+func TestAnalyzeTrackStagesFallsBackToUnary(t *testing.T) {
+	client := startWorker(t, &unaryOnlyWorker{stub: NewStubWorker(0)})
+
+	for i := 0; i < 2; i++ {
+		called := false
+		res, err := client.AnalyzeTrackStages(context.Background(), testJob(), func(*analyzer.StageEvent) { called = true })
+		if err != nil {
+			t.Fatalf("analyze: %v", err)
+		}
+		if res.GetAnalysis() == nil {
+			t.Fatal("expected analysis from unary fallback")
+		}
+		if called {
+			t.Error("stage callback should not fire for unary workers")
+		}
+	}
+	if !client.streamUnsupported.Load() {
+		t.Error("expected client to remember that streaming is unsupported")
+	}
+}
+
+func TestStubAnalysisIsDeterministic(t *testing.T) {
+	a := syntheticAnalysis(testJob())
+	b := syntheticAnalysis(testJob())
+	if a.GetKey().GetValue() != b.GetKey().GetValue() || a.GetDurationSeconds() != b.GetDurationSeconds() {
+		t.Error("expected identical analyses for the same path")
+	}
+	bpm := a.GetBeatgrid().GetTempoMap()[0].GetBpm()
+	if bpm < 118 || bpm > 138 {
+		t.Errorf("bpm %.1f outside expected range", bpm)
+	}
+}
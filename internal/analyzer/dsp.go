@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// fft computes an in-place radix-2 FFT. len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*w
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
+
+// hann returns a periodic Hann window of length n.
+func hann(n int) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+	}
+	return w
+}
+
+// nextPow2 returns the smallest power of two >= n.
+func nextPow2(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// spectrogram computes magnitude spectra of Hann-windowed frames and calls fn for each.
+// Frames start at i*hop; the final partial frame is zero-padded.
+func spectrogram(x []float32, size, hop int, fn func(frame int, mag []float64)) {
+	win := hann(size)
+	buf := make([]complex128, size)
+	mag := make([]float64, size/2+1)
+	for frame, start := 0, 0; start < len(x); frame, start = frame+1, start+hop {
+		for i := range buf {
+			v := 0.0
+			if start+i < len(x) {
+				v = float64(x[start+i]) * win[i]
+			}
+			buf[i] = complex(v, 0)
+		}
+		fft(buf)
+		for k := range mag {
+			mag[k] = cmplx.Abs(buf[k])
+		}
+		fn(frame, mag)
+	}
+}
+
+// dbfs converts a mean-square power to decibels relative to full scale.
+func dbfs(meanSquare float64) float64 {
+	if meanSquare <= 1e-12 {
+		return -120
+	}
+	return 10 * math.Log10(meanSquare)
+}
+
+// meanSquare returns the mean of x[i]^2 over [start, end).
+func meanSquare(x []float32, start, end int) float64 {
+	if start < 0 {
+		start = 0
+	}
+	if end > len(x) {
+		end = len(x)
+	}
+	if end <= start {
+		return 0
+	}
+	var sum float64
+	for _, v := range x[start:end] {
+		sum += float64(v) * float64(v)
+	}
+	return sum / float64(end-start)
+}
@@ -0,0 +1,28 @@
+package analyzer
+
+import (
+	"github.com/cartomix/cancun/gen/go/analyzer"
+	"github.com/cartomix/cancun/gen/go/common"
+)
+
+// NewJob builds an analysis job with the engine's default decode, beatgrid and cue parameters.
+func NewJob(id *common.TrackId, path string, version int32) *analyzer.AnalyzeJob {
+	return &analyzer.AnalyzeJob{
+		Id:   id,
+		Path: path,
+		Decode: &analyzer.DecodeParams{
+			TargetSampleRate: 48000,
+			Mono:             false,
+		},
+		Beatgrid: &analyzer.BeatgridParams{
+			DynamicAllowed: true,
+			TempoFloor:     60,
+			TempoCeil:      180,
+		},
+		Cues: &analyzer.CueParams{
+			MaxCues:        8,
+			SnapToDownbeat: true,
+		},
+		AnalysisVersion: version,
+	}
+}
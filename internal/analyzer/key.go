@@ -0,0 +1,117 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+)
+
+// Krumhansl-Kessler key profiles, indexed from the tonic.
+var (
+	majorProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+// detectKey builds a pitch-class profile from spectral peaks between 80 Hz and 5 kHz
+// and correlates it with the 24 major/minor key profiles. It returns the Camelot key
+// and a 0-1 confidence derived from the winning correlation.
+func detectKey(x []float32, sampleRate int) (string, float64) {
+	factor := sampleRate / 11025
+	if factor < 1 {
+		factor = 1
+	}
+	y := make([]float32, len(x)/factor)
+	for i := range y {
+		var sum float32
+		for _, v := range x[i*factor : (i+1)*factor] {
+			sum += v
+		}
+		y[i] = sum / float32(factor)
+	}
+	rate := float64(sampleRate) / float64(factor)
+
+	const size = 4096
+	binHz := rate / size
+	lo, hi := int(80/binHz), int(5000/binHz)
+	if hi > size/2-1 {
+		hi = size/2 - 1
+	}
+
+	var chroma [12]float64
+	spectrogram(y, size, size/2, func(_ int, mag []float64) {
+		var peak float64
+		for k := lo; k <= hi; k++ {
+			peak = math.Max(peak, mag[k])
+		}
+		if peak == 0 {
+			return
+		}
+		for k := lo; k <= hi; k++ {
+			m := mag[k]
+			if m < peak*0.05 || m < mag[k-1] || m <= mag[k+1] {
+				continue
+			}
+			// Parabolic interpolation of the peak frequency.
+			a, b, c := mag[k-1], m, mag[k+1]
+			offset := 0.0
+			if d := a - 2*b + c; d != 0 {
+				offset = 0.5 * (a - c) / d
+			}
+			freq := (float64(k) + offset) * binHz
+			midi := 69 + 12*math.Log2(freq/440)
+			pc := int(math.Round(midi)) % 12
+			if pc < 0 {
+				pc += 12
+			}
+			chroma[pc] += m / peak
+		}
+	})
+
+	bestKey, bestCorr := "", math.Inf(-1)
+	for tonic := 0; tonic < 12; tonic++ {
+		if r := correlate(chroma, majorProfile, tonic); r > bestCorr {
+			bestKey, bestCorr = camelotKey(tonic, false), r
+		}
+		if r := correlate(chroma, minorProfile, tonic); r > bestCorr {
+			bestKey, bestCorr = camelotKey(tonic, true), r
+		}
+	}
+	if math.IsNaN(bestCorr) || math.IsInf(bestCorr, -1) {
+		return "", 0
+	}
+	return bestKey, math.Max(0, math.Min(1, bestCorr))
+}
+
+// correlate returns the Pearson correlation between chroma and profile rotated to tonic.
+func correlate(chroma [12]float64, profile [12]float64, tonic int) float64 {
+	var mc, mp float64
+	for i := 0; i < 12; i++ {
+		mc += chroma[i] / 12
+		mp += profile[i] / 12
+	}
+	var num, dc, dp float64
+	for i := 0; i < 12; i++ {
+		c := chroma[(tonic+i)%12] - mc
+		p := profile[i] - mp
+		num += c * p
+		dc += c * c
+		dp += p * p
+	}
+	if dc == 0 || dp == 0 {
+		return math.NaN()
+	}
+	return num / math.Sqrt(dc*dp)
+}
+
+// camelotKey maps a tonic pitch class (C=0) and mode to Camelot notation.
+// C major is 8B and each step around the circle of fifths adds one; minor keys
+// share the number of their relative major.
+func camelotKey(tonic int, minor bool) string {
+	major := tonic
+	letter := "B"
+	if minor {
+		major = (tonic + 3) % 12
+		letter = "A"
+	}
+	n := (major*7%12+7)%12 + 1
+	return fmt.Sprintf("%d%s", n, letter)
+}
@@ -0,0 +1,190 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/analyzer"
+	"github.com/cartomix/cancun/gen/go/common"
+	"github.com/cartomix/cancun/internal/audio"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// localStages lists the pipeline stages the local analyzer reports, in order.
+var localStages = []struct {
+	name    string
+	message string
+}{
+	{"decode", "Decoding audio file..."},
+	{"beatgrid", "Detecting beats and tempo..."},
+	{"key", "Analyzing musical key..."},
+	{"loudness", "Measuring loudness (EBU R128)..."},
+	{"sections", "Detecting track sections..."},
+	{"cues", "Generating cue points..."},
+}
+
+// Local is a pure-Go reference analyzer for platforms without the Swift worker.
+// It decodes WAV, AIFF and FLAC and computes an onset-based beatgrid, a chroma key,
+// RMS energy, EBU R128 loudness and phrase-aligned sections. It produces no ML
+// embeddings or sound classification.
+type Local struct {
+	logger *slog.Logger
+}
+
+// NewLocal creates the in-process analyzer backend.
+func NewLocal(logger *slog.Logger) *Local {
+	return &Local{logger: logger}
+}
+
+// AnalyzeTrack analyzes the file at job.Path.
+func (l *Local) AnalyzeTrack(ctx context.Context, job *analyzer.AnalyzeJob) (*analyzer.AnalyzeResult, error) {
+	return l.AnalyzeTrackStages(ctx, job, nil)
+}
+
+// AnalyzeTrackStages analyzes the file at job.Path, reporting each stage to onStage.
+func (l *Local) AnalyzeTrackStages(ctx context.Context, job *analyzer.AnalyzeJob, onStage StageFunc) (*analyzer.AnalyzeResult, error) {
+	start := time.Now()
+	stage := 0
+	var stageStart time.Time
+	begin := func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		stageStart = time.Now()
+		if onStage != nil {
+			onStage(&analyzer.StageEvent{
+				Stage:   localStages[stage].name,
+				Status:  "started",
+				Message: localStages[stage].message,
+				Percent: float32(stage) / float32(len(localStages)) * 100,
+			})
+		}
+		return nil
+	}
+	end := func() {
+		if onStage != nil {
+			onStage(&analyzer.StageEvent{
+				Stage:      localStages[stage].name,
+				Status:     "completed",
+				Message:    localStages[stage].message,
+				Percent:    float32(stage+1) / float32(len(localStages)) * 100,
+				DurationMs: time.Since(stageStart).Milliseconds(),
+			})
+		}
+		stage++
+	}
+
+	// decode
+	if err := begin(); err != nil {
+		return nil, err
+	}
+	buf, err := audio.Decode(job.GetPath())
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", job.GetPath(), err)
+	}
+	if buf.Frames() == 0 || buf.SampleRate == 0 {
+		return nil, fmt.Errorf("decode %s: no audio samples", job.GetPath())
+	}
+	mono := buf.Mono()
+	end()
+
+	// beatgrid
+	if err := begin(); err != nil {
+		return nil, err
+	}
+	params := job.GetBeatgrid()
+	grid := detectBeats(mono, buf.SampleRate, params.GetTempoFloor(), params.GetTempoCeil(), params.GetDynamicAllowed())
+	end()
+
+	// key
+	if err := begin(); err != nil {
+		return nil, err
+	}
+	key, keyConf := detectKey(mono, buf.SampleRate)
+	end()
+
+	// loudness
+	if err := begin(); err != nil {
+		return nil, err
+	}
+	loud := measureLoudness(buf.Channels, buf.SampleRate)
+	end()
+
+	// sections
+	if err := begin(); err != nil {
+		return nil, err
+	}
+	bars := barLevels(mono, buf.SampleRate, grid.times, grid.firstDownbeat)
+	sections := detectSections(bars)
+	end()
+
+	// cues
+	if err := begin(); err != nil {
+		return nil, err
+	}
+	maxCues := int(job.GetCues().GetMaxCues())
+	cues := sectionCues(sections, grid.times, grid.firstDownbeat, maxCues)
+	end()
+
+	analysis := &common.TrackAnalysis{
+		Id:                job.GetId(),
+		DurationSeconds:   buf.Duration(),
+		Beatgrid:          beatgridProto(grid),
+		EnergyGlobal:      globalEnergy(bars),
+		EnergySegments:    energySegments(bars),
+		Sections:          sections,
+		CuePoints:         cues,
+		TransitionWindows: transitionWindows(sections, len(grid.times)),
+		Loudness: &common.Loudness{
+			IntegratedLufs: float32(loud.integrated),
+			TruePeakDb:     float32(loud.truePeak),
+			MomentaryLufs:  float32(loud.momentary),
+			ShortTermLufs:  float32(loud.shortTerm),
+			LoudnessRange:  float32(loud.lra),
+		},
+		AnalysisVersion: job.GetAnalysisVersion(),
+		SoundContext:    "music",
+	}
+	if key != "" {
+		analysis.Key = &common.MusicalKey{Value: key, Format: common.KeyFormat_CAMELOT, Confidence: float32(keyConf)}
+	}
+
+	l.logger.Info("local analysis complete",
+		"path", job.GetPath(),
+		"bpm", grid.bpm,
+		"key", key,
+		"lufs", loud.integrated,
+		"duration", time.Since(start),
+	)
+	return &analyzer.AnalyzeResult{Analysis: analysis}, nil
+}
+
+// Close is a no-op; the local analyzer holds no resources.
+func (l *Local) Close() error { return nil }
+
+func beatgridProto(grid beatgridResult) *common.Beatgrid {
+	beats := make([]*common.BeatMarker, len(grid.times))
+	for i, t := range grid.times {
+		beats[i] = &common.BeatMarker{
+			Index:      int32(i),
+			Time:       secondsToDuration(t),
+			IsDownbeat: i >= grid.firstDownbeat && (i-grid.firstDownbeat)%4 == 0,
+		}
+	}
+	tempoMap := make([]*common.TempoMapNode, len(grid.tempoMap))
+	for i, n := range grid.tempoMap {
+		tempoMap[i] = &common.TempoMapNode{BeatIndex: int32(n.beat), Bpm: n.bpm}
+	}
+	return &common.Beatgrid{
+		Beats:      beats,
+		TempoMap:   tempoMap,
+		Confidence: float32(grid.confidence),
+		IsDynamic:  grid.dynamic,
+	}
+}
+
+func secondsToDuration(s float64) *durationpb.Duration {
+	return durationpb.New(time.Duration(s * float64(time.Second)))
+}
@@ -0,0 +1,328 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cartomix/cancun/gen/go/analyzer"
+	"github.com/cartomix/cancun/gen/go/common"
+	"github.com/cartomix/cancun/internal/audio"
+	"github.com/cartomix/cancun/internal/fixtures"
+)
+
+// The fixture generator output is the accuracy suite for the local analyzer: every
+// fixture has a known tempo, key or structure to check against.
+
+func newTestLocal() *Local {
+	return NewLocal(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func generateFixtures(t *testing.T, cfg fixtures.Config) (string, map[string]fixtures.ManifestFixture) {
+	t.Helper()
+	cfg.OutputDir = t.TempDir()
+	cfg.SampleRate = 48000
+	cfg.Seed = 1337
+	manifest, err := fixtures.Generate(cfg)
+	if err != nil {
+		t.Fatalf("generate fixtures: %v", err)
+	}
+	byFile := make(map[string]fixtures.ManifestFixture, len(manifest.Fixtures))
+	for _, f := range manifest.Fixtures {
+		byFile[f.File] = f
+	}
+	return cfg.OutputDir, byFile
+}
+
+func analyzeFixture(t *testing.T, l *Local, dir, file string) *common.TrackAnalysis {
+	t.Helper()
+	path := filepath.Join(dir, file)
+	res, err := l.AnalyzeTrack(context.Background(), NewJob(&common.TrackId{ContentHash: file, Path: path}, path, 1))
+	if err != nil {
+		t.Fatalf("analyze %s: %v", file, err)
+	}
+	return res.GetAnalysis()
+}
+
+func beatSeconds(a *common.TrackAnalysis) []float64 {
+	beats := a.GetBeatgrid().GetBeats()
+	out := make([]float64, len(beats))
+	for i, b := range beats {
+		out[i] = b.GetTime().AsDuration().Seconds()
+	}
+	return out
+}
+
+func TestLocalClickTempoAndPhase(t *testing.T) {
+	ladder := []float64{80, 100, 120, 128, 140, 160}
+	dir, manifest := generateFixtures(t, fixtures.Config{BPMLadder: ladder})
+	l := newTestLocal()
+
+	for _, bpm := range ladder {
+		f := manifest[fmt.Sprintf("click_%dbpm.wav", int(bpm))]
+		a := analyzeFixture(t, l, dir, f.File)
+		grid := a.GetBeatgrid()
+		if grid.GetIsDynamic() {
+			t.Errorf("%s: expected a static grid", f.File)
+		}
+		if got := grid.GetTempoMap()[0].GetBpm(); math.Abs(got-bpm) > 0.5 {
+			t.Errorf("%s: bpm = %.2f, want %.0f", f.File, got, bpm)
+		}
+		if grid.GetConfidence() < 0.8 {
+			t.Errorf("%s: confidence = %.2f, want >= 0.8", f.File, grid.GetConfidence())
+		}
+		times := beatSeconds(a)
+		if len(times) < f.Beats {
+			t.Fatalf("%s: %d beats, want >= %d", f.File, len(times), f.Beats)
+		}
+		for i := 0; i < f.Beats; i++ {
+			want := float64(i) * 60 / bpm
+			if math.Abs(times[i]-want) > 0.005 {
+				t.Errorf("%s: beat %d at %.4fs, want %.4fs", f.File, i, times[i], want)
+				break
+			}
+		}
+	}
+}
+
+func TestLocalSwingFindsBeatOrHalfTime(t *testing.T) {
+	dir, manifest := generateFixtures(t, fixtures.Config{BPMLadder: []float64{128}, SwingRatio: 0.6, IncludeSwing: true})
+	f := manifest["swing_click.wav"]
+	a := analyzeFixture(t, newTestLocal(), dir, f.File)
+
+	// Swung off-beats make the bar pulse ambiguous; either the beat or half-time is a
+	// usable grid, anything else is not.
+	got := a.GetBeatgrid().GetTempoMap()[0].GetBpm()
+	if math.Abs(got-f.BPM) > 1 && math.Abs(got-f.BPM/2) > 1 {
+		t.Errorf("swing bpm = %.2f, want %.0f or %.0f", got, f.BPM, f.BPM/2)
+	}
+}
+
+func TestLocalTempoRampIsDynamic(t *testing.T) {
+	dir, manifest := generateFixtures(t, fixtures.Config{IncludeRamp: true, RampStartBPM: 128, RampEndBPM: 100})
+	f := manifest["tempo_ramp.wav"]
+	a := analyzeFixture(t, newTestLocal(), dir, f.File)
+
+	grid := a.GetBeatgrid()
+	if !grid.GetIsDynamic() {
+		t.Fatalf("expected a dynamic grid for a %v->%v ramp", f.BPM, f.TargetBPM)
+	}
+	nodes := grid.GetTempoMap()
+	if len(nodes) < 2 {
+		t.Fatalf("expected a multi-node tempo map, got %d nodes", len(nodes))
+	}
+	if first := nodes[0].GetBpm(); math.Abs(first-f.BPM) > 3 {
+		t.Errorf("opening tempo = %.2f, want ~%.0f", first, f.BPM)
+	}
+	if last := nodes[len(nodes)-1].GetBpm(); math.Abs(last-f.TargetBPM) > 3 {
+		t.Errorf("closing tempo = %.2f, want ~%.0f", last, f.TargetBPM)
+	}
+	if n := len(grid.GetBeats()); n < f.Beats-1 || n > f.Beats+1 {
+		t.Errorf("tracked %d beats, want %d", n, f.Beats)
+	}
+}
+
+func TestLocalKeyDetection(t *testing.T) {
+	keys := []string{"8A", "9A", "7A", "8B"}
+	dir, manifest := generateFixtures(t, fixtures.Config{
+		IncludeChord:       true,
+		ChordKey:           "8A",
+		IncludeHarmonicSet: true,
+		HarmonicSetKeys:    keys,
+	})
+	l := newTestLocal()
+
+	files := []string{"chord_8A.wav"}
+	for i, key := range keys {
+		files = append(files, fmt.Sprintf("harmonic_set_%d_%s.wav", i+1, key))
+	}
+	for _, file := range files {
+		f := manifest[file]
+		a := analyzeFixture(t, l, dir, file)
+		if got := a.GetKey().GetValue(); got != f.Key {
+			t.Errorf("%s: key = %q, want %q", file, got, f.Key)
+		}
+		if a.GetKey().GetFormat() != common.KeyFormat_CAMELOT {
+			t.Errorf("%s: key format = %v, want CAMELOT", file, a.GetKey().GetFormat())
+		}
+	}
+}
+
+func TestLocalHarmonicSetTempo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping full-length fixture analysis in short mode")
+	}
+	dir, manifest := generateFixtures(t, fixtures.Config{IncludeHarmonicSet: true})
+	l := newTestLocal()
+
+	for _, f := range manifest {
+		a := analyzeFixture(t, l, dir, f.File)
+		grid := a.GetBeatgrid()
+		// Only the downbeat carries a kick, so the tempo has to come from the bar pulse.
+		if got := grid.GetTempoMap()[0].GetBpm(); math.Abs(got-f.BPM) > 1 {
+			t.Errorf("%s: bpm = %.2f, want %.0f", f.File, got, f.BPM)
+		}
+		if first := beatSeconds(a)[0]; first > 0.015 {
+			t.Errorf("%s: first beat at %.3fs, want ~0", f.File, first)
+		}
+		if grid.GetConfidence() < 0.6 {
+			t.Errorf("%s: confidence = %.2f, want >= 0.6", f.File, grid.GetConfidence())
+		}
+	}
+}
+
+func TestLocalPhraseTrackSections(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping full-length fixture analysis in short mode")
+	}
+	dir, manifest := generateFixtures(t, fixtures.Config{IncludePhrase: true, PhraseBPM: 128})
+	f := manifest["phrase_track.wav"]
+	a := analyzeFixture(t, newTestLocal(), dir, f.File)
+
+	if got := a.GetBeatgrid().GetTempoMap()[0].GetBpm(); math.Abs(got-f.BPM) > 0.5 {
+		t.Errorf("bpm = %.2f, want %.0f", got, f.BPM)
+	}
+	if a.GetKey().GetValue() != f.Key {
+		t.Errorf("key = %q, want %q", a.GetKey().GetValue(), f.Key)
+	}
+
+	wantLabels := map[string]common.SectionLabel{
+		"intro":     common.SectionLabel_INTRO,
+		"verse":     common.SectionLabel_VERSE,
+		"build":     common.SectionLabel_BUILD,
+		"drop":      common.SectionLabel_DROP,
+		"breakdown": common.SectionLabel_BREAKDOWN,
+		"outro":     common.SectionLabel_OUTRO,
+	}
+	got := a.GetSections()
+	if len(got) != len(f.Sections) {
+		t.Fatalf("got %d sections, want %d: %v", len(got), len(f.Sections), got)
+	}
+	for i, want := range f.Sections {
+		s := got[i]
+		if s.GetLabel() != wantLabels[want.Type] {
+			t.Errorf("section %d: label %v, want %s", i, s.GetLabel(), want.Type)
+		}
+		// Boundaries must land within a bar of the fixture's phrase boundaries.
+		if d := int(s.GetStartBeat()) - want.StartBeat; d < -4 || d > 4 {
+			t.Errorf("section %d (%s): starts at beat %d, want %d", i, want.Type, s.GetStartBeat(), want.StartBeat)
+		}
+	}
+
+	// Energy per section follows the fixture's ordering.
+	levelAt := func(beat int) int32 {
+		for _, seg := range a.GetEnergySegments() {
+			if beat >= int(seg.GetStartBeat()) && beat < int(seg.GetEndBeat()) {
+				return seg.GetLevel()
+			}
+		}
+		return 0
+	}
+	for i := range f.Sections {
+		for j := range f.Sections {
+			wi, wj := f.Sections[i], f.Sections[j]
+			if wi.Energy <= wj.Energy {
+				continue
+			}
+			mi, mj := (wi.StartBeat+wi.EndBeat)/2, (wj.StartBeat+wj.EndBeat)/2
+			if levelAt(mi) < levelAt(mj) {
+				t.Errorf("%s energy %d should not be below %s energy %d", wi.Type, levelAt(mi), wj.Type, levelAt(mj))
+			}
+		}
+	}
+
+	var types []common.CueType
+	for _, c := range a.GetCuePoints() {
+		types = append(types, c.GetType())
+	}
+	for _, want := range []common.CueType{common.CueType_CUE_LOAD, common.CueType_CUE_DROP, common.CueType_CUE_OUTRO_START} {
+		found := false
+		for _, typ := range types {
+			found = found || typ == want
+		}
+		if !found {
+			t.Errorf("missing %v cue in %v", want, types)
+		}
+	}
+}
+
+func TestLocalBeatlessAudioHasLowConfidence(t *testing.T) {
+	dir, manifest := generateFixtures(t, fixtures.Config{IncludeChord: true, ChordKey: "8A", IncludeClubNoise: true})
+	l := newTestLocal()
+
+	for _, f := range manifest {
+		a := analyzeFixture(t, l, dir, f.File)
+		if c := a.GetBeatgrid().GetConfidence(); c > 0.3 {
+			t.Errorf("%s: beatgrid confidence = %.2f, want <= 0.3", f.File, c)
+		}
+		if a.GetBeatgrid().GetIsDynamic() {
+			t.Errorf("%s: noise should not produce a dynamic tempo map", f.File)
+		}
+	}
+}
+
+func TestLocalLoudness(t *testing.T) {
+	dir, manifest := generateFixtures(t, fixtures.Config{IncludeChord: true, ChordKey: "8A"})
+	f := manifest["chord_8A.wav"]
+	a := analyzeFixture(t, newTestLocal(), dir, f.File)
+
+	// Three 0.2-amplitude sines: about -12 dBFS RMS, peaking at -4.4 dBFS.
+	loud := a.GetLoudness()
+	if l := loud.GetIntegratedLufs(); l < -16 || l > -10 {
+		t.Errorf("integrated loudness = %.1f LUFS, want about -13", l)
+	}
+	if tp := loud.GetTruePeakDb(); tp < -6 || tp > -3 {
+		t.Errorf("true peak = %.1f dBTP, want about -4.4", tp)
+	}
+	if lra := loud.GetLoudnessRange(); lra > 1 {
+		t.Errorf("loudness range = %.1f LU for a steady pad, want ~0", lra)
+	}
+	if math.Abs(a.GetDurationSeconds()-f.DurationSec) > 0.01 {
+		t.Errorf("duration = %.3f, want %.3f", a.GetDurationSeconds(), f.DurationSec)
+	}
+}
+
+func TestLocalReportsStages(t *testing.T) {
+	dir, _ := generateFixtures(t, fixtures.Config{BPMLadder: []float64{120}})
+	path := filepath.Join(dir, "click_120bpm.wav")
+
+	var events []*analyzer.StageEvent
+	_, err := newTestLocal().AnalyzeTrackStages(context.Background(), NewJob(&common.TrackId{Path: path}, path, 1), func(ev *analyzer.StageEvent) {
+		events = append(events, ev)
+	})
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if len(events) != 2*len(localStages) {
+		t.Fatalf("got %d stage events, want %d", len(events), 2*len(localStages))
+	}
+	for i, stage := range localStages {
+		start, done := events[2*i], events[2*i+1]
+		if start.GetStage() != stage.name || start.GetStatus() != "started" {
+			t.Errorf("event %d = %s/%s, want %s/started", 2*i, start.GetStage(), start.GetStatus(), stage.name)
+		}
+		if done.GetStage() != stage.name || done.GetStatus() != "completed" {
+			t.Errorf("event %d = %s/%s, want %s/completed", 2*i+1, done.GetStage(), done.GetStatus(), stage.name)
+		}
+	}
+	if last := events[len(events)-1].GetPercent(); last != 100 {
+		t.Errorf("final percent = %v, want 100", last)
+	}
+}
+
+func TestLocalRejectsUnsupportedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.mp3")
+	if err := os.WriteFile(path, []byte("ID3\x04\x00\x00\x00\x00\x00\x00not audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := newTestLocal().AnalyzeTrack(context.Background(), NewJob(&common.TrackId{Path: path}, path, 1))
+	if !errors.Is(err, audio.ErrUnsupportedFormat) {
+		t.Fatalf("err = %v, want ErrUnsupportedFormat", err)
+	}
+}
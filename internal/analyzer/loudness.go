@@ -0,0 +1,231 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+)
+
+// loudnessResult holds EBU R128 / ITU-R BS.1770-4 measurements.
+type loudnessResult struct {
+	integrated float64 // LUFS
+	momentary  float64 // maximum momentary (400 ms) loudness, LUFS
+	shortTerm  float64 // maximum short-term (3 s) loudness, LUFS
+	lra        float64 // loudness range, LU
+	truePeak   float64 // dBTP
+}
+
+// biquad is a direct form I second-order section.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting returns the BS.1770 pre-filter (high shelf) and RLB high-pass for any
+// sample rate, using the analogue prototypes from libebur128.
+func kWeighting(sampleRate int) (biquad, biquad) {
+	fs := float64(sampleRate)
+
+	f0, g, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, g/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+	highpass := biquad{
+		b0: 1, b1: -2, b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, highpass
+}
+
+// measureLoudness computes gated integrated loudness, maximum momentary and short-term
+// loudness, loudness range and true peak for the given channels.
+func measureLoudness(channels [][]float32, sampleRate int) loudnessResult {
+	const silence = -70.0
+	res := loudnessResult{integrated: silence, momentary: silence, shortTerm: silence, truePeak: -120}
+	if len(channels) == 0 || len(channels[0]) == 0 {
+		return res
+	}
+
+	// Per-100 ms weighted mean-square energy summed over channels.
+	step := sampleRate / 10
+	nSteps := len(channels[0]) / step
+	energy := make([]float64, nSteps)
+	for c, ch := range channels {
+		weight := 1.0
+		if c >= 3 { // surround channels in a 5.1 layout
+			weight = 1.41
+		}
+		shelf, hp := kWeighting(sampleRate)
+		for i := 0; i < nSteps; i++ {
+			var sum float64
+			for _, v := range ch[i*step : (i+1)*step] {
+				y := hp.process(shelf.process(float64(v)))
+				sum += y * y
+			}
+			energy[i] += weight * sum / float64(step)
+		}
+	}
+
+	toLUFS := func(ms float64) float64 {
+		if ms <= 0 {
+			return math.Inf(-1)
+		}
+		return -0.691 + 10*math.Log10(ms)
+	}
+	windowed := func(n int) []float64 {
+		var out []float64
+		for i := 0; i+n <= len(energy); i++ {
+			var sum float64
+			for _, e := range energy[i : i+n] {
+				sum += e
+			}
+			out = append(out, sum/float64(n))
+		}
+		return out
+	}
+
+	// Integrated: 400 ms blocks, absolute gate at -70 LUFS, relative gate 10 LU down.
+	blocks := windowed(4)
+	if len(blocks) == 0 {
+		blocks = windowed(len(energy))
+	}
+	res.integrated = gatedLoudness(blocks, -10, toLUFS)
+	for _, b := range blocks {
+		res.momentary = math.Max(res.momentary, toLUFS(b))
+	}
+
+	// Short-term: 3 s windows; LRA spans the 10th-95th percentile after a 20 LU relative gate.
+	short := windowed(30)
+	for _, s := range short {
+		res.shortTerm = math.Max(res.shortTerm, toLUFS(s))
+	}
+	res.lra = loudnessRange(short, toLUFS)
+
+	res.truePeak = truePeak(channels)
+	return res
+}
+
+func gatedLoudness(blocks []float64, relGate float64, toLUFS func(float64) float64) float64 {
+	var sum float64
+	var n int
+	for _, b := range blocks {
+		if toLUFS(b) > -70 {
+			sum += b
+			n++
+		}
+	}
+	if n == 0 {
+		return -70
+	}
+	threshold := toLUFS(sum/float64(n)) + relGate
+
+	sum, n = 0, 0
+	for _, b := range blocks {
+		if l := toLUFS(b); l > -70 && l > threshold {
+			sum += b
+			n++
+		}
+	}
+	if n == 0 {
+		return -70
+	}
+	return toLUFS(sum / float64(n))
+}
+
+func loudnessRange(short []float64, toLUFS func(float64) float64) float64 {
+	var sum float64
+	var n int
+	for _, s := range short {
+		if toLUFS(s) > -70 {
+			sum += s
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	threshold := toLUFS(sum/float64(n)) - 20
+
+	var levels []float64
+	for _, s := range short {
+		if l := toLUFS(s); l > -70 && l > threshold {
+			levels = append(levels, l)
+		}
+	}
+	if len(levels) < 2 {
+		return 0
+	}
+	sort.Float64s(levels)
+	lo := levels[int(0.10*float64(len(levels)-1))]
+	hi := levels[int(0.95*float64(len(levels)-1))]
+	return hi - lo
+}
+
+// truePeak estimates the inter-sample peak with 4x windowed-sinc oversampling around
+// samples within 6 dB of the sample peak (the only places a true peak can hide).
+func truePeak(channels [][]float32) float64 {
+	const (
+		factor = 4
+		taps   = 12 // per phase
+	)
+	var kernel [factor][taps]float64
+	for p := 0; p < factor; p++ {
+		for t := 0; t < taps; t++ {
+			x := float64(t-taps/2+1) - float64(p)/factor
+			// Sinc with a Hann window spanning the kernel.
+			s := 1.0
+			if x != 0 {
+				s = math.Sin(math.Pi*x) / (math.Pi * x)
+			}
+			w := 0.5 + 0.5*math.Cos(math.Pi*x/(taps/2))
+			kernel[p][t] = s * w
+		}
+	}
+
+	var samplePeak float64
+	for _, ch := range channels {
+		for _, v := range ch {
+			samplePeak = math.Max(samplePeak, math.Abs(float64(v)))
+		}
+	}
+	if samplePeak == 0 {
+		return -120
+	}
+
+	peak := samplePeak
+	gate := samplePeak / 2
+	for _, ch := range channels {
+		for i := taps / 2; i+taps/2 < len(ch); i++ {
+			if math.Abs(float64(ch[i])) < gate && math.Abs(float64(ch[i-1])) < gate {
+				continue
+			}
+			for p := 1; p < factor; p++ {
+				var sum float64
+				for t := 0; t < taps; t++ {
+					sum += float64(ch[i-taps/2+t]) * kernel[p][t]
+				}
+				peak = math.Max(peak, math.Abs(sum))
+			}
+		}
+	}
+	return 20 * math.Log10(peak)
+}
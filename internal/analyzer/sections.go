@@ -0,0 +1,268 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+
+	"github.com/cartomix/cancun/gen/go/common"
+)
+
+// phraseBars is the phrase length section boundaries snap to.
+const phraseBars = 8
+
+// bar is a four-beat span with its RMS level.
+type bar struct {
+	startBeat, endBeat int
+	db                 float64
+}
+
+// barLevels measures each bar from the first downbeat on. Pickup beats before the
+// first downbeat are folded into the first bar.
+func barLevels(x []float32, sampleRate int, times []float64, firstDownbeat int) []bar {
+	sampleAt := func(beat int) int {
+		if beat >= len(times) {
+			return len(x)
+		}
+		return int(times[beat] * float64(sampleRate))
+	}
+
+	var bars []bar
+	for start := firstDownbeat; start < len(times); start += 4 {
+		end := start + 4
+		if end > len(times) {
+			end = len(times)
+		}
+		b := bar{startBeat: start, endBeat: end}
+		if len(bars) == 0 {
+			b.startBeat = 0
+		}
+		b.db = dbfs(meanSquare(x, sampleAt(b.startBeat), sampleAt(end)))
+		bars = append(bars, b)
+	}
+	return bars
+}
+
+// energyLevel maps an RMS level in dBFS onto the 1-10 energy scale (~3 dB per step).
+func energyLevel(db float64) int32 {
+	level := int32(math.Round((db + 40) / 3.2))
+	if level < 1 {
+		return 1
+	}
+	if level > 10 {
+		return 10
+	}
+	return level
+}
+
+// meanDB averages bar levels in the power domain.
+func meanDB(bars []bar) float64 {
+	if len(bars) == 0 {
+		return -120
+	}
+	var sum float64
+	for _, b := range bars {
+		sum += math.Pow(10, b.db/10)
+	}
+	return dbfs(sum / float64(len(bars)))
+}
+
+// globalEnergy rates the track by its 75th-percentile bar level, so quiet intros
+// and outros don't drag the score down.
+func globalEnergy(bars []bar) int32 {
+	if len(bars) == 0 {
+		return 1
+	}
+	levels := make([]float64, len(bars))
+	for i, b := range bars {
+		levels[i] = b.db
+	}
+	sort.Float64s(levels)
+	return energyLevel(levels[len(levels)*3/4])
+}
+
+// energySegments reports one energy level per phrase.
+func energySegments(bars []bar) []*common.EnergySegment {
+	var segs []*common.EnergySegment
+	for i := 0; i < len(bars); i += phraseBars {
+		end := i + phraseBars
+		if end > len(bars) {
+			end = len(bars)
+		}
+		segs = append(segs, &common.EnergySegment{
+			StartBeat: int32(bars[i].startBeat),
+			EndBeat:   int32(bars[end-1].endBeat),
+			Level:     energyLevel(meanDB(bars[i:end])),
+		})
+	}
+	return segs
+}
+
+// detectSections splits the track at phrase boundaries where the level changes by at
+// least 2 dB between the neighbouring phrases, then labels the pieces by energy shape.
+func detectSections(bars []bar) []*common.Section {
+	if len(bars) == 0 {
+		return nil
+	}
+
+	novelty := func(b int) float64 {
+		w := phraseBars
+		if b-w < 0 {
+			w = b
+		}
+		if b+w > len(bars) {
+			w = len(bars) - b
+		}
+		if w < phraseBars/2 {
+			return 0
+		}
+		return meanDB(bars[b:b+w]) - meanDB(bars[b-w:b])
+	}
+
+	cuts := []int{0}
+	strength := []float64{1}
+	for b := phraseBars; b < len(bars); b += phraseBars {
+		d := math.Abs(novelty(b))
+		if d < 2 {
+			continue
+		}
+		if d < math.Abs(novelty(b-phraseBars)) || (b+phraseBars < len(bars) && d < math.Abs(novelty(b+phraseBars))) {
+			continue
+		}
+		cuts = append(cuts, b)
+		strength = append(strength, d)
+	}
+	cuts = append(cuts, len(bars))
+
+	type span struct {
+		start, end int // bar indices
+		db         float64
+		confidence float64
+	}
+	spans := make([]span, 0, len(cuts)-1)
+	for i := 0; i+1 < len(cuts); i++ {
+		spans = append(spans, span{
+			start:      cuts[i],
+			end:        cuts[i+1],
+			db:         meanDB(bars[cuts[i]:cuts[i+1]]),
+			confidence: math.Min(1, strength[i]/6),
+		})
+	}
+
+	loudest := 0
+	for i, s := range spans {
+		if s.db > spans[loudest].db {
+			loudest = i
+		}
+	}
+
+	labels := make([]common.SectionLabel, len(spans))
+	for i, s := range spans {
+		switch {
+		case len(spans) == 1:
+			labels[i] = common.SectionLabel_VERSE
+		case i == 0:
+			labels[i] = common.SectionLabel_INTRO
+		case i == len(spans)-1:
+			labels[i] = common.SectionLabel_OUTRO
+		case s.db >= spans[loudest].db-1.5:
+			labels[i] = common.SectionLabel_DROP
+		}
+	}
+	for i := 1; i < len(spans)-1; i++ {
+		if labels[i] != common.SectionLabel_SECTION_LABEL_UNSPECIFIED {
+			continue
+		}
+		switch {
+		case labels[i+1] == common.SectionLabel_DROP && spans[i].db > spans[i-1].db:
+			labels[i] = common.SectionLabel_BUILD
+		case labels[i-1] == common.SectionLabel_DROP && spans[i].db < spans[i-1].db:
+			labels[i] = common.SectionLabel_BREAKDOWN
+		default:
+			labels[i] = common.SectionLabel_VERSE
+		}
+	}
+
+	sections := make([]*common.Section, len(spans))
+	for i, s := range spans {
+		conf := s.confidence
+		if i+1 < len(spans) {
+			conf = math.Min(conf, spans[i+1].confidence)
+		}
+		sections[i] = &common.Section{
+			StartBeat:  int32(bars[s.start].startBeat),
+			EndBeat:    int32(bars[s.end-1].endBeat),
+			Label:      labels[i],
+			Confidence: float32(math.Max(0.3, conf)),
+		}
+	}
+	return sections
+}
+
+// sectionCues places a load cue, the first downbeat and one cue per section start.
+func sectionCues(sections []*common.Section, times []float64, firstDownbeat int, maxCues int) []*common.CuePoint {
+	if len(times) == 0 {
+		return nil
+	}
+	cue := func(beat int, typ common.CueType, conf float32) *common.CuePoint {
+		if beat >= len(times) {
+			beat = len(times) - 1
+		}
+		return &common.CuePoint{BeatIndex: int32(beat), Time: secondsToDuration(times[beat]), Type: typ, Confidence: conf}
+	}
+
+	cues := []*common.CuePoint{
+		cue(0, common.CueType_CUE_LOAD, 1),
+		cue(firstDownbeat, common.CueType_CUE_FIRST_DOWNBEAT, 0.8),
+	}
+	for _, s := range sections {
+		var typ common.CueType
+		switch s.GetLabel() {
+		case common.SectionLabel_INTRO:
+			typ = common.CueType_CUE_INTRO_START
+		case common.SectionLabel_BUILD:
+			typ = common.CueType_CUE_BUILD
+		case common.SectionLabel_DROP:
+			typ = common.CueType_CUE_DROP
+		case common.SectionLabel_BREAKDOWN:
+			typ = common.CueType_CUE_BREAKDOWN
+		case common.SectionLabel_OUTRO:
+			typ = common.CueType_CUE_OUTRO_START
+		default:
+			continue
+		}
+		beat := int(s.GetStartBeat())
+		if s.GetLabel() == common.SectionLabel_INTRO && beat < firstDownbeat {
+			beat = firstDownbeat
+		}
+		cues = append(cues, cue(beat, typ, s.GetConfidence()))
+	}
+
+	if maxCues > 0 && len(cues) > maxCues {
+		cues = cues[:maxCues]
+	}
+	return cues
+}
+
+// transitionWindows exposes the intro and outro as mix-in/mix-out windows. Tracks
+// without a detected intro or outro fall back to their first and last 16 bars.
+func transitionWindows(sections []*common.Section, totalBeats int) []*common.TransitionWindow {
+	if totalBeats == 0 {
+		return nil
+	}
+	fallback := 64
+	if fallback > totalBeats/2 {
+		fallback = totalBeats / 2
+	}
+
+	in := &common.TransitionWindow{StartBeat: 0, EndBeat: int32(fallback), Tag: "intro", Confidence: 0.3}
+	out := &common.TransitionWindow{StartBeat: int32(totalBeats - fallback), EndBeat: int32(totalBeats), Tag: "outro", Confidence: 0.3}
+	for _, s := range sections {
+		switch s.GetLabel() {
+		case common.SectionLabel_INTRO:
+			in.StartBeat, in.EndBeat, in.Confidence = s.GetStartBeat(), s.GetEndBeat(), s.GetConfidence()
+		case common.SectionLabel_OUTRO:
+			out.StartBeat, out.EndBeat, out.Confidence = s.GetStartBeat(), s.GetEndBeat(), s.GetConfidence()
+		}
+	}
+	return []*common.TransitionWindow{in, out}
+}
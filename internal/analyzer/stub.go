@@ -0,0 +1,248 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/analyzer"
+	"github.com/cartomix/cancun/gen/go/common"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// StubStages lists the pipeline stages the stub worker reports, in order.
+var StubStages = []struct {
+	Name    string
+	Message string
+}{
+	{"decode", "Decoding audio file..."},
+	{"beatgrid", "Detecting beats and tempo..."},
+	{"key", "Analyzing musical key..."},
+	{"loudness", "Measuring loudness (EBU R128)..."},
+	{"embeddings", "Generating ML embeddings..."},
+	{"sections", "Detecting track sections..."},
+	{"cues", "Generating cue points..."},
+}
+
+// StubWorker is a Go implementation of the AnalyzerWorker service that returns
+// deterministic synthetic analyses. It lets the engine's streaming and scheduling
+// paths be exercised on Linux without the macOS Swift worker.
+type StubWorker struct {
+	analyzer.UnimplementedAnalyzerWorkerServer
+
+	// StageDelay is slept in every stage to make progress observable.
+	StageDelay time.Duration
+}
+
+// NewStubWorker creates a stub worker with the given per-stage delay.
+func NewStubWorker(stageDelay time.Duration) *StubWorker {
+	return &StubWorker{StageDelay: stageDelay}
+}
+
+// Register adds the stub worker to a gRPC server.
+func (w *StubWorker) Register(s *grpc.Server) {
+	analyzer.RegisterAnalyzerWorkerServer(s, w)
+}
+
+// AnalyzeTrack implements the unary RPC.
+func (w *StubWorker) AnalyzeTrack(ctx context.Context, job *analyzer.AnalyzeJob) (*analyzer.AnalyzeResult, error) {
+	return w.run(ctx, job, nil)
+}
+
+// AnalyzeTrackStream implements the streaming RPC, emitting started/completed events per stage.
+func (w *StubWorker) AnalyzeTrackStream(job *analyzer.AnalyzeJob, stream grpc.ServerStreamingServer[analyzer.AnalyzeEvent]) error {
+	res, err := w.run(stream.Context(), job, func(ev *analyzer.StageEvent) error {
+		return stream.Send(&analyzer.AnalyzeEvent{Event: &analyzer.AnalyzeEvent_Stage{Stage: ev}})
+	})
+	if err != nil {
+		return err
+	}
+	return stream.Send(&analyzer.AnalyzeEvent{Event: &analyzer.AnalyzeEvent_Result{Result: res}})
+}
+
+func (w *StubWorker) run(ctx context.Context, job *analyzer.AnalyzeJob, emit func(*analyzer.StageEvent) error) (*analyzer.AnalyzeResult, error) {
+	if job.GetPath() == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	for i, stage := range StubStages {
+		start := time.Now()
+		if emit != nil {
+			if err := emit(&analyzer.StageEvent{
+				Stage:   stage.Name,
+				Status:  "started",
+				Message: stage.Message,
+				Percent: float32(i) / float32(len(StubStages)) * 100,
+			}); err != nil {
+				return nil, err
+			}
+		}
+
+		if w.StageDelay > 0 {
+			select {
+			case <-time.After(w.StageDelay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		if emit != nil {
+			if err := emit(&analyzer.StageEvent{
+				Stage:      stage.Name,
+				Status:     "completed",
+				Message:    stage.Message,
+				Percent:    float32(i+1) / float32(len(StubStages)) * 100,
+				DurationMs: time.Since(start).Milliseconds(),
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	analysis := syntheticAnalysis(job)
+	return &analyzer.AnalyzeResult{Analysis: analysis, Openl3Windows: syntheticWindows(job, analysis)}, nil
+}
+
+// stubWindowSeconds is how long each synthetic OpenL3 window lasts.
+const stubWindowSeconds = 5.0
+
+// syntheticWindows derives per-window OpenL3 embeddings that drift from the
+// track's embedding by section, so each section sounds a little different.
+func syntheticWindows(job *analyzer.AnalyzeJob, a *common.TrackAnalysis) []*analyzer.OpenL3Window {
+	sum := sha256.Sum256([]byte(job.GetPath()))
+	rng := rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(sum[8:16]))))
+
+	track := a.GetOpenl3Embedding().GetVector()
+	beatSec := 60 / a.GetBeatgrid().GetTempoMap()[0].GetBpm()
+	sectionVectors := make([][]float32, len(a.GetSections()))
+	for i := range sectionVectors {
+		sectionVectors[i] = noisyUnit(rng, track, 0.5)
+	}
+
+	var windows []*analyzer.OpenL3Window
+	for start := 0.0; start < a.GetDurationSeconds(); start += stubWindowSeconds {
+		length := min(stubWindowSeconds, a.GetDurationSeconds()-start)
+		base := track
+		beat := int32((start + length/2) / beatSec)
+		for i, sec := range a.GetSections() {
+			if beat >= sec.GetStartBeat() && beat < sec.GetEndBeat() {
+				base = sectionVectors[i]
+			}
+		}
+		windows = append(windows, &analyzer.OpenL3Window{
+			StartSeconds:    start,
+			DurationSeconds: length,
+			Vector:          noisyUnit(rng, base, 0.1),
+		})
+	}
+	return windows
+}
+
+// noisyUnit adds Gaussian noise of the given scale to v and rescales it to unit length.
+func noisyUnit(rng *rand.Rand, v []float32, scale float64) []float32 {
+	out := make([]float32, len(v))
+	var norm float64
+	for i, x := range v {
+		out[i] = x + float32(rng.NormFloat64()*scale/math.Sqrt(float64(len(v))))
+		norm += float64(out[i]) * float64(out[i])
+	}
+	norm = math.Sqrt(norm)
+	for i := range out {
+		out[i] = float32(float64(out[i]) / norm)
+	}
+	return out
+}
+
+// syntheticAnalysis derives a plausible, deterministic analysis from the track path.
+func syntheticAnalysis(job *analyzer.AnalyzeJob) *common.TrackAnalysis {
+	sum := sha256.Sum256([]byte(job.GetPath()))
+	seed := int64(binary.LittleEndian.Uint64(sum[:8]))
+	rng := rand.New(rand.NewSource(seed))
+
+	bpm := float64(118 + rng.Intn(12))
+	duration := 240.0 + float64(rng.Intn(120))
+	beatSec := 60 / bpm
+	totalBeats := int32(duration / beatSec)
+
+	beats := make([]*common.BeatMarker, 0, totalBeats)
+	for i := int32(0); i < totalBeats; i++ {
+		beats = append(beats, &common.BeatMarker{
+			Index:      i,
+			Time:       durationpb.New(time.Duration(float64(i) * beatSec * float64(time.Second))),
+			IsDownbeat: i%4 == 0,
+		})
+	}
+
+	mode := "A"
+	if rng.Intn(2) == 1 {
+		mode = "B"
+	}
+	key := fmt.Sprintf("%d%s", 1+rng.Intn(12), mode)
+	energy := int32(4 + rng.Intn(5))
+
+	// Phrase-aligned layout: 32-beat intro and outro, drop in the middle.
+	introEnd := int32(64)
+	outroStart := totalBeats - 64
+	dropStart := totalBeats / 2
+	sections := []*common.Section{
+		{StartBeat: 0, EndBeat: introEnd, Label: common.SectionLabel_INTRO, Confidence: 0.8},
+		{StartBeat: introEnd, EndBeat: dropStart, Label: common.SectionLabel_BUILD, Confidence: 0.6},
+		{StartBeat: dropStart, EndBeat: outroStart, Label: common.SectionLabel_DROP, Confidence: 0.7},
+		{StartBeat: outroStart, EndBeat: totalBeats, Label: common.SectionLabel_OUTRO, Confidence: 0.8},
+	}
+
+	beatTime := func(b int32) *durationpb.Duration {
+		return durationpb.New(time.Duration(float64(b) * beatSec * float64(time.Second)))
+	}
+
+	embedding := make([]float32, 512)
+	var norm float64
+	for i := range embedding {
+		embedding[i] = float32(rng.NormFloat64())
+		norm += float64(embedding[i]) * float64(embedding[i])
+	}
+	norm = math.Sqrt(norm)
+	for i := range embedding {
+		embedding[i] = float32(float64(embedding[i]) / norm)
+	}
+
+	return &common.TrackAnalysis{
+		Id:              job.GetId(),
+		DurationSeconds: duration,
+		Beatgrid: &common.Beatgrid{
+			Beats:      beats,
+			TempoMap:   []*common.TempoMapNode{{BeatIndex: 0, Bpm: bpm}},
+			Confidence: 0.9,
+		},
+		Key:          &common.MusicalKey{Value: key, Format: common.KeyFormat_CAMELOT, Confidence: 0.8},
+		EnergyGlobal: energy,
+		EnergySegments: []*common.EnergySegment{
+			{StartBeat: 0, EndBeat: introEnd, Level: energy - 2},
+			{StartBeat: introEnd, EndBeat: dropStart, Level: energy},
+			{StartBeat: dropStart, EndBeat: outroStart, Level: energy + 1},
+			{StartBeat: outroStart, EndBeat: totalBeats, Level: energy - 2},
+		},
+		Sections: sections,
+		CuePoints: []*common.CuePoint{
+			{BeatIndex: 0, Time: beatTime(0), Type: common.CueType_CUE_LOAD, Confidence: 1},
+			{BeatIndex: introEnd, Time: beatTime(introEnd), Type: common.CueType_CUE_BUILD, Confidence: 0.6},
+			{BeatIndex: dropStart, Time: beatTime(dropStart), Type: common.CueType_CUE_DROP, Confidence: 0.7},
+			{BeatIndex: outroStart, Time: beatTime(outroStart), Type: common.CueType_CUE_OUTRO_START, Confidence: 0.8},
+		},
+		TransitionWindows: []*common.TransitionWindow{
+			{StartBeat: 0, EndBeat: introEnd, Tag: "intro", Confidence: 0.8},
+			{StartBeat: outroStart, EndBeat: totalBeats, Tag: "outro", Confidence: 0.8},
+		},
+		Loudness: &common.Loudness{
+			IntegratedLufs: float32(-12 + rng.Float64()*4),
+			TruePeakDb:     float32(-1 + rng.Float64()*0.8),
+		},
+		Openl3Embedding: &common.OpenL3Embedding{Vector: embedding, WindowCount: int32(duration)},
+		AnalysisVersion: job.GetAnalysisVersion(),
+	}
+}
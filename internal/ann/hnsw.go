@@ -0,0 +1,392 @@
+// Package ann is an in-memory approximate nearest neighbour index over
+// embedding vectors. It is a hierarchical navigable small world (HNSW) graph
+// searched by cosine similarity, and takes inserts, replacements and removals
+// while it serves searches.
+package ann
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sync"
+)
+
+// Defaults for the zero fields of Config.
+const (
+	DefaultM              = 16
+	DefaultEfConstruction = 100
+	DefaultEfSearch       = 64
+)
+
+// ErrZeroVector is returned for a vector that has no direction to compare.
+var ErrZeroVector = errors.New("zero vector")
+
+// Config tunes the graph. Zero fields take the defaults.
+type Config struct {
+	M              int   // links per node above the bottom layer; twice as many at the bottom
+	EfConstruction int   // candidates kept while inserting; more builds a better graph, slower
+	EfSearch       int   // candidates kept while searching; more finds more true neighbours, slower
+	Seed           int64 // seeds the layer assignment, for reproducible graphs
+}
+
+// Neighbor is a search result.
+type Neighbor struct {
+	Key        int64
+	Similarity float64 // cosine similarity normalized to 0-1, as similarity.CosineSimilarity
+}
+
+// Index is an HNSW graph of unit vectors keyed by int64. It is safe for
+// concurrent use.
+type Index struct {
+	mu    sync.RWMutex
+	dim   int
+	cfg   Config
+	rng   *rand.Rand
+	nodes []node
+	live  map[int64]int32 // key -> node holding its current vector
+	entry int32           // -1 while empty
+	top   int             // layer of the entry point
+}
+
+// node is a vector in the graph. Replaced and removed vectors stay in the
+// graph as waypoints but are never returned.
+type node struct {
+	Key     int64
+	Vec     []float32
+	Links   [][]int32 // neighbours per layer, bottom first
+	Deleted bool
+}
+
+// New creates an empty index for vectors of dim dimensions.
+func New(dim int, cfg Config) *Index {
+	if cfg.M <= 0 {
+		cfg.M = DefaultM
+	}
+	if cfg.EfConstruction <= 0 {
+		cfg.EfConstruction = DefaultEfConstruction
+	}
+	if cfg.EfSearch <= 0 {
+		cfg.EfSearch = DefaultEfSearch
+	}
+	return &Index{
+		dim:   dim,
+		cfg:   cfg,
+		rng:   rand.New(rand.NewSource(cfg.Seed)),
+		live:  make(map[int64]int32),
+		entry: -1,
+	}
+}
+
+// Len returns the number of keys in the index.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.live)
+}
+
+// Contains reports whether key is in the index.
+func (x *Index) Contains(key int64) bool {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	_, ok := x.live[key]
+	return ok
+}
+
+// Keys returns every key in the index, in no particular order.
+func (x *Index) Keys() []int64 {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	keys := make([]int64, 0, len(x.live))
+	for k := range x.live {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Add inserts the vector for key, replacing any it had.
+func (x *Index) Add(key int64, vec []float32) error {
+	q, err := x.normalize(vec)
+	if err != nil {
+		return err
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if old, ok := x.live[key]; ok {
+		x.nodes[old].Deleted = true
+	}
+	x.live[key] = x.insert(key, q)
+	return nil
+}
+
+// Remove takes key out of the index. Removing a missing key does nothing.
+func (x *Index) Remove(key int64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if old, ok := x.live[key]; ok {
+		x.nodes[old].Deleted = true
+		delete(x.live, key)
+	}
+}
+
+// Search returns up to k keys whose vectors are most similar to vec, most
+// similar first. Like any approximate search it can miss some of the true
+// nearest neighbours; raising EfSearch makes that rarer.
+func (x *Index) Search(vec []float32, k int) ([]Neighbor, error) {
+	q, err := x.normalize(vec)
+	if err != nil {
+		return nil, err
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if x.entry < 0 || k <= 0 {
+		return nil, nil
+	}
+
+	ep := x.descend(q, x.entry, x.top, 0)
+	// Removed nodes still fill candidate slots, so widen the search by as
+	// many as there are.
+	ef := max(x.cfg.EfSearch, k) + min(len(x.nodes)-len(x.live), k)
+	found := x.searchLayer(q, []candidate{ep}, ef, 0)
+
+	out := make([]Neighbor, 0, k)
+	for _, c := range found {
+		if x.nodes[c.id].Deleted {
+			continue
+		}
+		out = append(out, Neighbor{Key: x.nodes[c.id].Key, Similarity: (2 - c.dist) / 2})
+		if len(out) == k {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (x *Index) normalize(vec []float32) ([]float32, error) {
+	if len(vec) != x.dim {
+		return nil, fmt.Errorf("vector has %d dimensions, index has %d", len(vec), x.dim)
+	}
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return nil, ErrZeroVector
+	}
+	scale := 1 / math.Sqrt(norm)
+	q := make([]float32, len(vec))
+	for i, v := range vec {
+		q[i] = float32(float64(v) * scale)
+	}
+	return q, nil
+}
+
+// insert links a new node into the graph and returns its id.
+func (x *Index) insert(key int64, q []float32) int32 {
+	id := int32(len(x.nodes))
+	level := x.randomLevel()
+	x.nodes = append(x.nodes, node{Key: key, Vec: q, Links: make([][]int32, level+1)})
+	if x.entry < 0 {
+		x.entry, x.top = id, level
+		return id
+	}
+
+	eps := []candidate{x.descend(q, x.entry, x.top, level)}
+	for l := min(level, x.top); l >= 0; l-- {
+		found := x.searchLayer(q, eps, x.cfg.EfConstruction, l)
+		neighbours := x.selectNeighbours(found, x.cfg.M)
+		x.nodes[id].Links[l] = ids(neighbours)
+		for _, n := range neighbours {
+			x.link(n.id, id, l)
+		}
+		eps = found
+	}
+	if level > x.top {
+		x.entry, x.top = id, level
+	}
+	return id
+}
+
+// link adds a link from node from to node to on layer l. Past the layer's
+// limit, from keeps its closest links.
+func (x *Index) link(from, to int32, l int) {
+	links := append(x.nodes[from].Links[l], to)
+	limit := x.cfg.M
+	if l == 0 {
+		limit *= 2
+	}
+	if len(links) > limit {
+		cands := make([]candidate, len(links))
+		for i, n := range links {
+			cands[i] = candidate{id: n, dist: x.dist(x.nodes[from].Vec, x.nodes[n].Vec)}
+		}
+		slices.SortFunc(cands, compareCandidates)
+		links = ids(cands[:limit])
+	}
+	x.nodes[from].Links[l] = links
+}
+
+// descend walks greedily from ep on layer from down to the layer above to,
+// returning the closest node it reaches.
+func (x *Index) descend(q []float32, ep int32, from, to int) candidate {
+	best := candidate{id: ep, dist: x.dist(q, x.nodes[ep].Vec)}
+	for l := from; l > to; l-- {
+		for changed := true; changed; {
+			changed = false
+			for _, n := range x.nodes[best.id].Links[l] {
+				if d := x.dist(q, x.nodes[n].Vec); d < best.dist {
+					best, changed = candidate{id: n, dist: d}, true
+				}
+			}
+		}
+	}
+	return best
+}
+
+// searchLayer returns the ef nodes closest to q on layer l it finds starting
+// from eps, closest first.
+func (x *Index) searchLayer(q []float32, eps []candidate, ef, l int) []candidate {
+	visited := make(map[int32]bool, ef*4)
+	frontier := &minHeap{}
+	results := &maxHeap{}
+	for _, ep := range eps {
+		visited[ep.id] = true
+		heap.Push(frontier, ep)
+		heap.Push(results, ep)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+
+	for frontier.Len() > 0 {
+		c := heap.Pop(frontier).(candidate)
+		if c.dist > (*results)[0].dist && results.Len() >= ef {
+			break
+		}
+		for _, n := range x.nodes[c.id].Links[l] {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			d := x.dist(q, x.nodes[n].Vec)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(frontier, candidate{id: n, dist: d})
+				heap.Push(results, candidate{id: n, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := []candidate(*results)
+	slices.SortFunc(out, compareCandidates)
+	return out
+}
+
+// selectNeighbours picks up to m of the candidates, closest first, skipping
+// any that is closer to an already picked neighbour than to the new node so
+// links spread in every direction. Skipped candidates fill what's left.
+func (x *Index) selectNeighbours(cands []candidate, m int) []candidate {
+	if len(cands) <= m {
+		return cands
+	}
+	picked := make([]candidate, 0, m)
+	var skipped []candidate
+	for _, c := range cands {
+		if len(picked) == m {
+			break
+		}
+		diverse := true
+		for _, p := range picked {
+			if x.dist(x.nodes[c.id].Vec, x.nodes[p.id].Vec) < c.dist {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			picked = append(picked, c)
+		} else {
+			skipped = append(skipped, c)
+		}
+	}
+	for _, c := range skipped {
+		if len(picked) == m {
+			break
+		}
+		picked = append(picked, c)
+	}
+	return picked
+}
+
+func (x *Index) randomLevel() int {
+	return int(math.Floor(-math.Log(1-x.rng.Float64()) / math.Log(float64(x.cfg.M))))
+}
+
+// dist is the cosine distance between unit vectors, 0 to 2.
+func (x *Index) dist(a, b []float32) float64 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return 1 - float64(s0+s1+s2+s3)
+}
+
+type candidate struct {
+	id   int32
+	dist float64
+}
+
+func compareCandidates(a, b candidate) int {
+	switch {
+	case a.dist < b.dist:
+		return -1
+	case a.dist > b.dist:
+		return 1
+	}
+	return int(a.id - b.id)
+}
+
+func ids(cands []candidate) []int32 {
+	out := make([]int32, len(cands))
+	for i, c := range cands {
+		out[i] = c.id
+	}
+	return out
+}
+
+type minHeap []candidate
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(v any)        { *h = append(*h, v.(candidate)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
+
+type maxHeap []candidate
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i].dist > h[j].dist }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(v any)        { *h = append(*h, v.(candidate)) }
+func (h *maxHeap) Pop() any {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
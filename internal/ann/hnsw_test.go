@@ -0,0 +1,199 @@
+package ann
+
+import (
+	"bytes"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+const testDim = 32
+
+// clustered returns n vectors scattered around a few centres, like embeddings
+// of a library with a handful of genres.
+func clustered(rng *rand.Rand, n int) [][]float32 {
+	centres := make([][]float32, 8)
+	for i := range centres {
+		centres[i] = make([]float32, testDim)
+		for d := range centres[i] {
+			centres[i][d] = float32(rng.NormFloat64())
+		}
+	}
+	vecs := make([][]float32, n)
+	for i := range vecs {
+		c := centres[rng.Intn(len(centres))]
+		vecs[i] = make([]float32, testDim)
+		for d := range vecs[i] {
+			vecs[i][d] = c[d] + float32(rng.NormFloat64()*0.6)
+		}
+	}
+	return vecs
+}
+
+// exact returns the keys of the k vectors most similar to q by brute force.
+func exact(vecs map[int64][]float32, q []float32, k int) []int64 {
+	x := New(testDim, Config{})
+	qn, _ := x.normalize(q)
+	type scored struct {
+		key  int64
+		dist float64
+	}
+	var all []scored
+	for key, v := range vecs {
+		vn, _ := x.normalize(v)
+		all = append(all, scored{key, x.dist(qn, vn)})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].dist < all[j].dist })
+	keys := make([]int64, 0, k)
+	for _, s := range all[:min(k, len(all))] {
+		keys = append(keys, s.key)
+	}
+	return keys
+}
+
+// recall is the share of the exact top k that the index finds.
+func recall(t *testing.T, x *Index, vecs map[int64][]float32, queries [][]float32, k int) float64 {
+	t.Helper()
+	hits, total := 0, 0
+	for _, q := range queries {
+		got, err := x.Search(q, k)
+		if err != nil {
+			t.Fatalf("search: %v", err)
+		}
+		found := make(map[int64]bool, len(got))
+		for _, n := range got {
+			found[n.Key] = true
+		}
+		for _, key := range exact(vecs, q, k) {
+			if found[key] {
+				hits++
+			}
+			total++
+		}
+	}
+	return float64(hits) / float64(total)
+}
+
+func TestSearchRecall(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	x := New(testDim, Config{Seed: 1, EfSearch: 200})
+	vecs := make(map[int64][]float32)
+	for i, v := range clustered(rng, 3000) {
+		vecs[int64(i)] = v
+		if err := x.Add(int64(i), v); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+
+	queries := clustered(rng, 50)
+	if r := recall(t, x, vecs, queries, 10); r < 0.95 {
+		t.Errorf("recall@10 = %.3f, want >= 0.95", r)
+	}
+
+	got, err := x.Search(vecs[42], 5)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(got) != 5 || got[0].Key != 42 || got[0].Similarity < 0.999 {
+		t.Errorf("searching a stored vector should find it first, got %+v", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Similarity > got[i-1].Similarity {
+			t.Errorf("results out of order: %+v", got)
+		}
+	}
+}
+
+func TestReplaceAndRemove(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	x := New(testDim, Config{Seed: 2, EfSearch: 200})
+	vecs := make(map[int64][]float32)
+	for i, v := range clustered(rng, 1000) {
+		vecs[int64(i)] = v
+		if err := x.Add(int64(i), v); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+
+	// Re-analysis: half the keys get new vectors, and some tracks go away.
+	for i, v := range clustered(rng, 500) {
+		vecs[int64(i)] = v
+		if err := x.Add(int64(i), v); err != nil {
+			t.Fatalf("replace: %v", err)
+		}
+	}
+	for i := int64(900); i < 1000; i++ {
+		delete(vecs, i)
+		x.Remove(i)
+	}
+	if x.Len() != 900 || x.Contains(950) || !x.Contains(10) {
+		t.Fatalf("len = %d, contains 950 = %v, contains 10 = %v", x.Len(), x.Contains(950), x.Contains(10))
+	}
+
+	queries := clustered(rng, 30)
+	if r := recall(t, x, vecs, queries, 10); r < 0.9 {
+		t.Errorf("recall@10 after updates = %.3f, want >= 0.9", r)
+	}
+	for _, q := range queries {
+		got, _ := x.Search(q, 20)
+		seen := make(map[int64]bool)
+		for _, n := range got {
+			if n.Key >= 900 || seen[n.Key] {
+				t.Fatalf("search returned a removed or duplicate key: %+v", got)
+			}
+			seen[n.Key] = true
+		}
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	x := New(testDim, Config{Seed: 3})
+	for i, v := range clustered(rng, 500) {
+		if err := x.Add(int64(i), v); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	x.Remove(7)
+
+	var buf bytes.Buffer
+	if _, err := x.WriteTo(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	loaded, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if loaded.Len() != x.Len() || loaded.Contains(7) {
+		t.Fatalf("loaded len = %d, want %d", loaded.Len(), x.Len())
+	}
+
+	q := clustered(rng, 1)[0]
+	want, _ := x.Search(q, 10)
+	got, _ := loaded.Search(q, 10)
+	if !slices.Equal(got, want) {
+		t.Errorf("loaded index searches differently:\n got %+v\nwant %+v", got, want)
+	}
+
+	// Keys added after loading link into the loaded graph.
+	if err := loaded.Add(1000, q); err != nil {
+		t.Fatalf("add after load: %v", err)
+	}
+	if got, _ := loaded.Search(q, 1); len(got) != 1 || got[0].Key != 1000 {
+		t.Errorf("search after add = %+v", got)
+	}
+}
+
+func TestAddRejectsBadVectors(t *testing.T) {
+	x := New(testDim, Config{})
+	if err := x.Add(1, make([]float32, testDim)); err != ErrZeroVector {
+		t.Errorf("zero vector: got %v", err)
+	}
+	if err := x.Add(1, make([]float32, testDim+1)); err == nil {
+		t.Error("wrong dimensions: expected an error")
+	}
+	if got, err := x.Search(make([]float32, testDim), 3); err != ErrZeroVector || got != nil {
+		t.Errorf("search with zero vector = %v, %v", got, err)
+	}
+}
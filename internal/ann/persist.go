@@ -0,0 +1,115 @@
+package ann
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// snapshotVersion changes whenever the snapshot layout does; older snapshots
+// fail to load and the index is built again.
+const snapshotVersion = 1
+
+type snapshot struct {
+	Version int
+	Dim     int
+	Config  Config
+	Nodes   []node
+	Entry   int32
+	Top     int
+}
+
+// WriteTo writes the index to w.
+func (x *Index) WriteTo(w io.Writer) (int64, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	cw := &countingWriter{w: w}
+	err := gob.NewEncoder(cw).Encode(snapshot{
+		Version: snapshotVersion,
+		Dim:     x.dim,
+		Config:  x.cfg,
+		Nodes:   x.nodes,
+		Entry:   x.entry,
+		Top:     x.top,
+	})
+	return cw.n, err
+}
+
+// Read reads an index written by WriteTo. When more of the snapshot's nodes
+// were replaced or removed than are live, the graph is rebuilt from the live
+// ones.
+func Read(r io.Reader) (*Index, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	if s.Version != snapshotVersion {
+		return nil, fmt.Errorf("index snapshot version %d, want %d", s.Version, snapshotVersion)
+	}
+
+	x := New(s.Dim, s.Config)
+	x.nodes, x.entry, x.top = s.Nodes, s.Entry, s.Top
+	for i, n := range x.nodes {
+		if len(n.Vec) != s.Dim || len(n.Links) == 0 {
+			return nil, fmt.Errorf("index snapshot node %d is malformed", i)
+		}
+		if !n.Deleted {
+			x.live[n.Key] = int32(i)
+		}
+	}
+	if len(x.nodes)-len(x.live) > len(x.live) {
+		return x.rebuilt(), nil
+	}
+	return x, nil
+}
+
+// Save writes the index to path, replacing the file only once it is complete.
+func (x *Index) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := x.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads an index saved to path.
+func Load(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// rebuilt returns a new index holding only the live vectors.
+func (x *Index) rebuilt() *Index {
+	fresh := New(x.dim, x.cfg)
+	for i, n := range x.nodes {
+		if !n.Deleted {
+			fresh.live[n.Key] = fresh.insert(n.Key, x.nodes[i].Vec)
+		}
+	}
+	return fresh
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,102 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// decodeAIFF parses AIFF and uncompressed AIFF-C streams.
+func decodeAIFF(r *bufio.Reader) (*Buffer, error) {
+	var form [12]byte
+	if _, err := io.ReadFull(r, form[:]); err != nil {
+		return nil, err
+	}
+	isAIFC := string(form[8:12]) == "AIFC"
+
+	format := pcmFormat{bigEndian: true}
+	var sampleRate int
+	haveComm := false
+
+	for {
+		id, size, err := readChunkHeader(r, true)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("aiff: missing SSND chunk")
+			}
+			return nil, err
+		}
+
+		switch id {
+		case "COMM":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("aiff: read COMM: %w", err)
+			}
+			if size&1 == 1 {
+				r.Discard(1)
+			}
+			if len(body) < 18 {
+				return nil, errors.New("aiff: short COMM chunk")
+			}
+			format.channels = int(binary.BigEndian.Uint16(body[0:2]))
+			format.bits = int(binary.BigEndian.Uint16(body[6:8]))
+			sampleRate = int(math.Round(extendedToFloat(body[8:18])))
+
+			if isAIFC && len(body) >= 22 {
+				switch string(body[18:22]) {
+				case "NONE", "twos":
+				case "sowt":
+					format.bigEndian = false
+				case "fl32", "FL32":
+					format.float, format.bits = true, 32
+				case "fl64", "FL64":
+					format.float, format.bits = true, 64
+				default:
+					return nil, fmt.Errorf("aiff: unsupported compression %q", body[18:22])
+				}
+			}
+			haveComm = true
+
+		case "SSND":
+			if !haveComm {
+				return nil, errors.New("aiff: SSND chunk before COMM chunk")
+			}
+			var hdr [8]byte
+			if _, err := io.ReadFull(r, hdr[:]); err != nil {
+				return nil, fmt.Errorf("aiff: read SSND header: %w", err)
+			}
+			offset := binary.BigEndian.Uint32(hdr[0:4])
+			if _, err := r.Discard(int(offset)); err != nil {
+				return nil, fmt.Errorf("aiff: skip SSND offset: %w", err)
+			}
+			channels, err := readInterleaved(r, int64(size)-8-int64(offset), format)
+			if err != nil {
+				return nil, fmt.Errorf("aiff: %w", err)
+			}
+			return &Buffer{SampleRate: sampleRate, Channels: channels}, nil
+
+		default:
+			if err := skipChunk(r, size); err != nil {
+				return nil, fmt.Errorf("aiff: skip %q: %w", id, err)
+			}
+		}
+	}
+}
+
+// extendedToFloat converts an 80-bit IEEE 754 extended value (AIFF sample rate).
+func extendedToFloat(b []byte) float64 {
+	sign := 1.0
+	if b[0]&0x80 != 0 {
+		sign = -1
+	}
+	exp := int(binary.BigEndian.Uint16(b[0:2]) & 0x7FFF)
+	mant := binary.BigEndian.Uint64(b[2:10])
+	if exp == 0 && mant == 0 {
+		return 0
+	}
+	return sign * math.Ldexp(float64(mant), exp-16383-63)
+}
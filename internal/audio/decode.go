@@ -0,0 +1,197 @@
+// Package audio decodes uncompressed and lossless audio files into float PCM.
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// ErrUnsupportedFormat is returned when a file is not WAV, AIFF or FLAC.
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+// Buffer holds decoded PCM samples in the range [-1, 1], one slice per channel.
+type Buffer struct {
+	SampleRate int
+	Channels   [][]float32
+}
+
+// Frames returns the number of samples per channel.
+func (b *Buffer) Frames() int {
+	if len(b.Channels) == 0 {
+		return 0
+	}
+	return len(b.Channels[0])
+}
+
+// Duration returns the length of the buffer in seconds.
+func (b *Buffer) Duration() float64 {
+	if b.SampleRate == 0 {
+		return 0
+	}
+	return float64(b.Frames()) / float64(b.SampleRate)
+}
+
+// Mono returns the average of all channels.
+func (b *Buffer) Mono() []float32 {
+	if len(b.Channels) == 1 {
+		return b.Channels[0]
+	}
+	out := make([]float32, b.Frames())
+	scale := 1 / float32(len(b.Channels))
+	for _, ch := range b.Channels {
+		for i, v := range ch {
+			out[i] += v * scale
+		}
+	}
+	return out
+}
+
+// Decode reads the file at path, sniffing the container from its magic bytes.
+func Decode(path string) (*Buffer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 1<<16)
+	magic, err := r.Peek(12)
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	switch {
+	case (bytes.Equal(magic[:4], []byte("RIFF")) || bytes.Equal(magic[:4], []byte("RF64"))) && bytes.Equal(magic[8:12], []byte("WAVE")):
+		return decodeWAV(r)
+	case bytes.Equal(magic[:4], []byte("FORM")) && (bytes.Equal(magic[8:12], []byte("AIFF")) || bytes.Equal(magic[8:12], []byte("AIFC"))):
+		return decodeAIFF(r)
+	case bytes.Equal(magic[:4], []byte("fLaC")):
+		return decodeFLAC(r)
+	case bytes.Equal(magic[:3], []byte("ID3")):
+		// FLAC files are occasionally prefixed with an ID3v2 tag.
+		size := int64(magic[6])<<21 | int64(magic[7])<<14 | int64(magic[8])<<7 | int64(magic[9])
+		if magic[5]&0x10 != 0 {
+			size += 10 // footer present
+		}
+		if _, err := r.Discard(int(10 + size)); err != nil {
+			return nil, fmt.Errorf("skip id3 tag: %w", err)
+		}
+		head, err := r.Peek(4)
+		if err != nil || !bytes.Equal(head, []byte("fLaC")) {
+			return nil, ErrUnsupportedFormat
+		}
+		return decodeFLAC(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// readChunkHeader reads a 4-byte ID and a 32-bit size in the given byte order.
+func readChunkHeader(r io.Reader, bigEndian bool) (string, uint32, error) {
+	var hdr [8]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return "", 0, err
+	}
+	var size uint32
+	if bigEndian {
+		size = uint32(hdr[4])<<24 | uint32(hdr[5])<<16 | uint32(hdr[6])<<8 | uint32(hdr[7])
+	} else {
+		size = uint32(hdr[7])<<24 | uint32(hdr[6])<<16 | uint32(hdr[5])<<8 | uint32(hdr[4])
+	}
+	return string(hdr[:4]), size, nil
+}
+
+// skipChunk discards a chunk body including the pad byte for odd sizes.
+func skipChunk(r *bufio.Reader, size uint32) error {
+	n := int64(size) + int64(size&1)
+	_, err := io.CopyN(io.Discard, r, n)
+	return err
+}
+
+// pcmFormat describes how interleaved sample bytes are laid out.
+type pcmFormat struct {
+	channels  int
+	bits      int
+	float     bool
+	bigEndian bool
+	unsigned8 bool // WAV stores 8-bit samples unsigned
+}
+
+// readInterleaved decodes up to size bytes of interleaved samples into per-channel slices.
+func readInterleaved(r io.Reader, size int64, f pcmFormat) ([][]float32, error) {
+	if f.channels <= 0 {
+		return nil, errors.New("invalid channel count")
+	}
+	bytesPer := (f.bits + 7) / 8
+	if bytesPer == 0 || (!f.float && bytesPer > 4) {
+		return nil, fmt.Errorf("unsupported bit depth %d", f.bits)
+	}
+	if f.float && bytesPer != 4 && bytesPer != 8 {
+		return nil, fmt.Errorf("unsupported float width %d", f.bits)
+	}
+	frameSize := int64(bytesPer * f.channels)
+	frames := size / frameSize
+
+	capHint := frames
+	if capHint > 1<<26 {
+		capHint = 0 // unknown length (streamed WAV); let append grow
+	}
+	out := make([][]float32, f.channels)
+	for c := range out {
+		out[c] = make([]float32, 0, capHint)
+	}
+
+	scale := 1 / float64(int64(1)<<(bytesPer*8-1))
+	buf := make([]byte, frameSize*4096)
+	for remaining := frames; remaining > 0; {
+		n := int64(4096)
+		if remaining < n {
+			n = remaining
+		}
+		chunk := buf[:n*frameSize]
+		read, err := io.ReadFull(r, chunk)
+		chunk = chunk[:int64(read)/frameSize*frameSize]
+		for off := 0; off < len(chunk); {
+			for c := 0; c < f.channels; c++ {
+				b := chunk[off : off+bytesPer]
+				off += bytesPer
+				var raw uint64
+				if f.bigEndian {
+					for _, x := range b {
+						raw = raw<<8 | uint64(x)
+					}
+				} else {
+					for i := len(b) - 1; i >= 0; i-- {
+						raw = raw<<8 | uint64(b[i])
+					}
+				}
+				var v float64
+				switch {
+				case f.float && bytesPer == 4:
+					v = float64(math.Float32frombits(uint32(raw)))
+				case f.float:
+					v = math.Float64frombits(raw)
+				case bytesPer == 1 && f.unsigned8:
+					v = (float64(raw) - 128) / 128
+				default:
+					shift := 64 - uint(bytesPer*8)
+					v = float64(int64(raw<<shift)>>shift) * scale
+				}
+				out[c] = append(out[c], float32(v))
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+				break // truncated data chunk: keep what we have
+			}
+			return nil, err
+		}
+		remaining -= n
+	}
+	return out, nil
+}
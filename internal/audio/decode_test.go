@@ -0,0 +1,287 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cartomix/cancun/internal/fixtures"
+)
+
+func sine(n int, freq, sampleRate float64, amp float64) []int64 {
+	out := make([]int64, n)
+	for i := range out {
+		out[i] = int64(math.Round(amp * math.Sin(2*math.Pi*freq*float64(i)/sampleRate)))
+	}
+	return out
+}
+
+func TestDecodeWAVFixture(t *testing.T) {
+	dir := t.TempDir()
+	manifest, err := fixtures.Generate(fixtures.Config{OutputDir: dir, SampleRate: 44100, BPMLadder: []float64{120}})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	buf, err := Decode(filepath.Join(dir, manifest.Fixtures[0].File))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if buf.SampleRate != 44100 || len(buf.Channels) != 1 {
+		t.Fatalf("unexpected format: %d Hz, %d channels", buf.SampleRate, len(buf.Channels))
+	}
+	if got, want := buf.Duration(), manifest.Fixtures[0].DurationSec; math.Abs(got-want) > 0.001 {
+		t.Errorf("duration %.3f, want %.3f", got, want)
+	}
+	// First click starts at full scale.
+	if buf.Channels[0][0] < 0.99 {
+		t.Errorf("expected click at t=0, got %.3f", buf.Channels[0][0])
+	}
+}
+
+func TestDecodeAIFF(t *testing.T) {
+	const rate = 48000
+	left := sine(4800, 440, rate, 16000)
+	right := sine(4800, 880, rate, -8000)
+
+	var ssnd bytes.Buffer
+	binary.Write(&ssnd, binary.BigEndian, uint32(0)) // offset
+	binary.Write(&ssnd, binary.BigEndian, uint32(0)) // block size
+	for i := range left {
+		binary.Write(&ssnd, binary.BigEndian, int16(left[i]))
+		binary.Write(&ssnd, binary.BigEndian, int16(right[i]))
+	}
+
+	var comm bytes.Buffer
+	binary.Write(&comm, binary.BigEndian, uint16(2))
+	binary.Write(&comm, binary.BigEndian, uint32(len(left)))
+	binary.Write(&comm, binary.BigEndian, uint16(16))
+	// 48000 as 80-bit extended: exponent 16383+15, mantissa 48000<<48.
+	binary.Write(&comm, binary.BigEndian, uint16(16383+15))
+	binary.Write(&comm, binary.BigEndian, uint64(48000)<<48)
+
+	var file bytes.Buffer
+	file.WriteString("FORM")
+	binary.Write(&file, binary.BigEndian, uint32(4+8+comm.Len()+8+ssnd.Len()))
+	file.WriteString("AIFF")
+	file.WriteString("COMM")
+	binary.Write(&file, binary.BigEndian, uint32(comm.Len()))
+	file.Write(comm.Bytes())
+	file.WriteString("SSND")
+	binary.Write(&file, binary.BigEndian, uint32(ssnd.Len()))
+	file.Write(ssnd.Bytes())
+
+	path := filepath.Join(t.TempDir(), "tone.aiff")
+	if err := os.WriteFile(path, file.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := Decode(path)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if buf.SampleRate != rate || len(buf.Channels) != 2 || buf.Frames() != len(left) {
+		t.Fatalf("unexpected format: %d Hz, %d channels, %d frames", buf.SampleRate, len(buf.Channels), buf.Frames())
+	}
+	for i := range left {
+		if got := int64(math.Round(float64(buf.Channels[0][i]) * 32768)); got != left[i] {
+			t.Fatalf("left[%d] = %d, want %d", i, got, left[i])
+		}
+		if got := int64(math.Round(float64(buf.Channels[1][i]) * 32768)); got != right[i] {
+			t.Fatalf("right[%d] = %d, want %d", i, got, right[i])
+		}
+	}
+}
+
+// flacWriter is a minimal encoder for exercising the decoder's subframe types.
+type flacWriter struct {
+	buf   bytes.Buffer
+	acc   uint64
+	nbits uint
+}
+
+func (w *flacWriter) bits(v uint64, n uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		w.acc = w.acc<<1 | (v>>uint(i))&1
+		w.nbits++
+		if w.nbits == 8 {
+			w.buf.WriteByte(byte(w.acc))
+			w.acc, w.nbits = 0, 0
+		}
+	}
+}
+
+func (w *flacWriter) signed(v int64, n uint) { w.bits(uint64(v)&(1<<n-1), n) }
+
+func (w *flacWriter) align() {
+	for w.nbits != 0 {
+		w.bits(0, 1)
+	}
+}
+
+func (w *flacWriter) rice(residual []int64, param uint) {
+	w.bits(0, 2) // method 0: 4-bit parameters
+	w.bits(0, 4) // partition order 0
+	w.bits(uint64(param), 4)
+	for _, r := range residual {
+		u := uint64(r<<1) ^ uint64(r>>63)
+		for q := u >> param; q > 0; q-- {
+			w.bits(0, 1)
+		}
+		w.bits(1, 1)
+		w.bits(u&(1<<param-1), param)
+	}
+}
+
+func (w *flacWriter) frameHeader(num int, blockSize int, chanCode uint64) {
+	w.bits(0x3FFE, 14)
+	w.bits(0, 2)
+	w.bits(7, 4) // 16-bit block size follows
+	w.bits(0, 4) // sample rate from STREAMINFO
+	w.bits(chanCode, 4)
+	w.bits(0, 3) // sample size from STREAMINFO
+	w.bits(0, 1)
+	w.bits(uint64(num), 8)
+	w.bits(uint64(blockSize-1), 16)
+	w.bits(0, 8) // CRC-8 (not verified)
+}
+
+func (w *flacWriter) fixed2(samples []int64, bps uint) {
+	w.bits(0, 1)
+	w.bits(8|2, 6)
+	w.bits(0, 1)
+	w.signed(samples[0], bps)
+	w.signed(samples[1], bps)
+	res := make([]int64, 0, len(samples)-2)
+	for i := 2; i < len(samples); i++ {
+		res = append(res, samples[i]-2*samples[i-1]+samples[i-2])
+	}
+	w.rice(res, 4)
+}
+
+func (w *flacWriter) lpc1(samples []int64, bps uint) {
+	// Order-1 LPC with a single 15/16 coefficient; the residual absorbs the error.
+	w.bits(0, 1)
+	w.bits(32, 6) // LPC order 1
+	w.bits(0, 1)
+	w.signed(samples[0], bps)
+	w.bits(5-1, 4) // 5-bit coefficient precision
+	w.signed(4, 5) // shift 4
+	w.signed(15, 5)
+	res := make([]int64, 0, len(samples)-1)
+	for i := 1; i < len(samples); i++ {
+		res = append(res, samples[i]-(15*samples[i-1])>>4)
+	}
+	w.rice(res, 10)
+}
+
+func (w *flacWriter) verbatim(samples []int64, bps uint) {
+	w.bits(0, 1)
+	w.bits(1, 6)
+	w.bits(0, 1)
+	for _, s := range samples {
+		w.signed(s, bps)
+	}
+}
+
+func (w *flacWriter) constant(v int64, bps uint) {
+	w.bits(0, 1)
+	w.bits(0, 6)
+	w.bits(0, 1)
+	w.signed(v, bps)
+}
+
+func TestDecodeFLAC(t *testing.T) {
+	const rate, block = 44100, 1024
+	left := sine(4*block, 440, rate, 12000)
+	right := sine(4*block, 660, rate, 9000)
+
+	w := &flacWriter{}
+	w.buf.WriteString("fLaC")
+	// STREAMINFO (last metadata block).
+	w.bits(1, 1)
+	w.bits(0, 7)
+	w.bits(34, 24)
+	w.bits(block, 16)
+	w.bits(block, 16)
+	w.bits(0, 24)
+	w.bits(0, 24)
+	w.bits(rate, 20)
+	w.bits(2-1, 3)
+	w.bits(16-1, 5)
+	w.bits(uint64(len(left)), 36)
+	w.bits(0, 64)
+	w.bits(0, 64)
+
+	for f := 0; f < 4; f++ {
+		l := left[f*block : (f+1)*block]
+		r := right[f*block : (f+1)*block]
+		switch f {
+		case 0: // independent, fixed order 2
+			w.frameHeader(f, block, 1)
+			w.fixed2(l, 16)
+			w.fixed2(r, 16)
+		case 1: // left/side, LPC + verbatim side
+			w.frameHeader(f, block, 8)
+			w.lpc1(l, 16)
+			side := make([]int64, block)
+			for i := range side {
+				side[i] = l[i] - r[i]
+			}
+			w.verbatim(side, 17)
+		case 2: // mid/side
+			w.frameHeader(f, block, 10)
+			mid := make([]int64, block)
+			side := make([]int64, block)
+			for i := range mid {
+				mid[i] = (l[i] + r[i]) >> 1
+				side[i] = l[i] - r[i]
+			}
+			w.fixed2(mid, 16)
+			w.fixed2(side, 17)
+		case 3: // independent, verbatim left and constant right (silence)
+			w.frameHeader(f, block, 1)
+			w.verbatim(l, 16)
+			w.constant(0, 16)
+			for i := range r {
+				right[3*block+i] = 0
+			}
+		}
+		w.align()
+		w.bits(0, 16) // CRC-16 (not verified)
+	}
+
+	path := filepath.Join(t.TempDir(), "tone.flac")
+	if err := os.WriteFile(path, w.buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := Decode(path)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if buf.SampleRate != rate || len(buf.Channels) != 2 || buf.Frames() != len(left) {
+		t.Fatalf("unexpected format: %d Hz, %d channels, %d frames", buf.SampleRate, len(buf.Channels), buf.Frames())
+	}
+	for i := range left {
+		if got := int64(buf.Channels[0][i] * 32768); got != left[i] {
+			t.Fatalf("left[%d] = %d, want %d", i, got, left[i])
+		}
+		if got := int64(buf.Channels[1][i] * 32768); got != right[i] {
+			t.Fatalf("right[%d] = %d, want %d", i, got, right[i])
+		}
+	}
+}
+
+func TestDecodeRejectsUnknownFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "track.mp3")
+	if err := os.WriteFile(path, []byte("\xff\xfb\x90\x00not really audio"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Decode(path); err != ErrUnsupportedFormat {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
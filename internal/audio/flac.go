@@ -0,0 +1,536 @@
+package audio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// FLAC decoding follows https://xiph.org/flac/format.html. Frame CRCs are read
+// but not verified; analysis tolerates the rare corrupt frame better than a hard failure.
+
+const (
+	flacSubframeConstant = iota
+	flacSubframeVerbatim
+	flacSubframeFixed
+	flacSubframeLPC
+)
+
+var errFLACSync = errors.New("lost frame sync")
+
+type flacStreamInfo struct {
+	sampleRate   int
+	channels     int
+	bitsPerSamp  int
+	totalSamples uint64
+}
+
+// decodeFLAC parses a native FLAC stream positioned at the "fLaC" marker.
+func decodeFLAC(r *bufio.Reader) (*Buffer, error) {
+	var marker [4]byte
+	if _, err := io.ReadFull(r, marker[:]); err != nil {
+		return nil, err
+	}
+
+	info, err := readFLACMetadata(r)
+	if err != nil {
+		return nil, err
+	}
+
+	capHint := info.totalSamples
+	if capHint > 1<<26 {
+		capHint = 0
+	}
+	channels := make([][]float32, info.channels)
+	for c := range channels {
+		channels[c] = make([]float32, 0, capHint)
+	}
+
+	br := &bitReader{r: r}
+	samples := make([][]int64, info.channels)
+	for {
+		n, err := readFLACFrame(br, info, samples)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if (errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, errFLACSync)) && len(channels[0]) > 0 {
+				break // truncated final frame or trailing tag
+			}
+			return nil, fmt.Errorf("flac: frame at sample %d: %w", len(channels[0]), err)
+		}
+		scale := 1 / float32(int64(1)<<(info.bitsPerSamp-1))
+		for c := range channels {
+			for _, v := range samples[c][:n] {
+				channels[c] = append(channels[c], float32(v)*scale)
+			}
+		}
+	}
+
+	return &Buffer{SampleRate: info.sampleRate, Channels: channels}, nil
+}
+
+func readFLACMetadata(r *bufio.Reader) (*flacStreamInfo, error) {
+	var info *flacStreamInfo
+	for {
+		var hdr [4]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, fmt.Errorf("flac: read metadata header: %w", err)
+		}
+		last := hdr[0]&0x80 != 0
+		blockType := hdr[0] & 0x7F
+		length := int(hdr[1])<<16 | int(hdr[2])<<8 | int(hdr[3])
+
+		if blockType == 0 {
+			body := make([]byte, length)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("flac: read STREAMINFO: %w", err)
+			}
+			if len(body) < 18 {
+				return nil, errors.New("flac: short STREAMINFO")
+			}
+			info = &flacStreamInfo{
+				sampleRate:  int(body[10])<<12 | int(body[11])<<4 | int(body[12])>>4,
+				channels:    int(body[12]>>1&0x07) + 1,
+				bitsPerSamp: int(body[12]&0x01)<<4 | int(body[13]>>4) + 1,
+				totalSamples: uint64(body[13]&0x0F)<<32 | uint64(body[14])<<24 |
+					uint64(body[15])<<16 | uint64(body[16])<<8 | uint64(body[17]),
+			}
+		} else if _, err := r.Discard(length); err != nil {
+			return nil, fmt.Errorf("flac: skip metadata block %d: %w", blockType, err)
+		}
+
+		if last {
+			break
+		}
+	}
+	if info == nil {
+		return nil, errors.New("flac: missing STREAMINFO")
+	}
+	return info, nil
+}
+
+// readFLACFrame decodes one frame into samples (resized as needed) and returns its block size.
+func readFLACFrame(br *bitReader, info *flacStreamInfo, samples [][]int64) (int, error) {
+	br.align()
+	sync, err := br.read(14)
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) && br.consumedInFrame() == 0 {
+			return 0, io.EOF
+		}
+		return 0, err
+	}
+	if sync != 0x3FFE {
+		return 0, errFLACSync
+	}
+	if _, err := br.read(2); err != nil { // reserved + blocking strategy
+		return 0, err
+	}
+
+	bsCode, _ := br.read(4)
+	srCode, _ := br.read(4)
+	chanCode, _ := br.read(4)
+	ssCode, _ := br.read(3)
+	if _, err := br.read(1); err != nil {
+		return 0, err
+	}
+
+	// Coded frame/sample number (UTF-8 style); only its length matters here.
+	first, err := br.read(8)
+	if err != nil {
+		return 0, err
+	}
+	if extra := bits.LeadingZeros8(^uint8(first)); extra > 1 {
+		if _, err := br.read(8 * (extra - 1)); err != nil {
+			return 0, err
+		}
+	}
+
+	var blockSize int
+	switch {
+	case bsCode == 1:
+		blockSize = 192
+	case bsCode >= 2 && bsCode <= 5:
+		blockSize = 576 << (bsCode - 2)
+	case bsCode == 6:
+		v, err := br.read(8)
+		if err != nil {
+			return 0, err
+		}
+		blockSize = int(v) + 1
+	case bsCode == 7:
+		v, err := br.read(16)
+		if err != nil {
+			return 0, err
+		}
+		blockSize = int(v) + 1
+	case bsCode >= 8:
+		blockSize = 256 << (bsCode - 8)
+	default:
+		return 0, errors.New("reserved block size")
+	}
+
+	switch srCode {
+	case 12:
+		_, err = br.read(8)
+	case 13, 14:
+		_, err = br.read(16)
+	case 15:
+		return 0, errors.New("invalid sample rate code")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	bps := info.bitsPerSamp
+	switch ssCode {
+	case 0:
+	case 1:
+		bps = 8
+	case 2:
+		bps = 12
+	case 4:
+		bps = 16
+	case 5:
+		bps = 20
+	case 6:
+		bps = 24
+	case 7:
+		bps = 32
+	default:
+		return 0, errors.New("reserved sample size")
+	}
+
+	if _, err := br.read(8); err != nil { // CRC-8
+		return 0, err
+	}
+
+	nch := info.channels
+	if chanCode <= 7 {
+		nch = int(chanCode) + 1
+	} else if chanCode <= 10 {
+		nch = 2
+	} else {
+		return 0, errors.New("reserved channel assignment")
+	}
+	if nch != info.channels {
+		return 0, fmt.Errorf("frame has %d channels, stream has %d", nch, info.channels)
+	}
+
+	for c := 0; c < nch; c++ {
+		if cap(samples[c]) < blockSize {
+			samples[c] = make([]int64, blockSize)
+		}
+		samples[c] = samples[c][:blockSize]
+
+		chBps := bps
+		switch {
+		case chanCode == 8 && c == 1, chanCode == 9 && c == 0, chanCode == 10 && c == 1:
+			chBps++ // side channel carries one extra bit
+		}
+		if err := readFLACSubframe(br, samples[c], chBps); err != nil {
+			return 0, fmt.Errorf("subframe %d: %w", c, err)
+		}
+	}
+
+	switch chanCode {
+	case 8: // left/side
+		for i := range samples[0] {
+			samples[1][i] = samples[0][i] - samples[1][i]
+		}
+	case 9: // side/right
+		for i := range samples[0] {
+			samples[0][i] += samples[1][i]
+		}
+	case 10: // mid/side
+		for i := range samples[0] {
+			mid := samples[0][i]<<1 | samples[1][i]&1
+			side := samples[1][i]
+			samples[0][i] = (mid + side) >> 1
+			samples[1][i] = (mid - side) >> 1
+		}
+	}
+
+	br.align()
+	if _, err := br.read(16); err != nil { // CRC-16
+		return 0, err
+	}
+	br.resetFrame()
+	return blockSize, nil
+}
+
+func readFLACSubframe(br *bitReader, out []int64, bps int) error {
+	if _, err := br.read(1); err != nil {
+		return err
+	}
+	typ, err := br.read(6)
+	if err != nil {
+		return err
+	}
+	wasted := 0
+	if flag, _ := br.read(1); flag == 1 {
+		k, err := br.unary()
+		if err != nil {
+			return err
+		}
+		wasted = int(k) + 1
+		bps -= wasted
+	}
+
+	var kind, order int
+	switch {
+	case typ == 0:
+		kind = flacSubframeConstant
+	case typ == 1:
+		kind = flacSubframeVerbatim
+	case typ >= 8 && typ <= 12:
+		kind, order = flacSubframeFixed, int(typ&0x07)
+	case typ >= 32:
+		kind, order = flacSubframeLPC, int(typ&0x1F)+1
+	default:
+		return fmt.Errorf("reserved subframe type %d", typ)
+	}
+	if order > len(out) {
+		return errors.New("predictor order exceeds block size")
+	}
+
+	switch kind {
+	case flacSubframeConstant:
+		v, err := br.readSigned(bps)
+		if err != nil {
+			return err
+		}
+		for i := range out {
+			out[i] = v
+		}
+
+	case flacSubframeVerbatim:
+		for i := range out {
+			if out[i], err = br.readSigned(bps); err != nil {
+				return err
+			}
+		}
+
+	case flacSubframeFixed:
+		for i := 0; i < order; i++ {
+			if out[i], err = br.readSigned(bps); err != nil {
+				return err
+			}
+		}
+		if err := readFLACResidual(br, out, order); err != nil {
+			return err
+		}
+		for i := order; i < len(out); i++ {
+			switch order {
+			case 1:
+				out[i] += out[i-1]
+			case 2:
+				out[i] += 2*out[i-1] - out[i-2]
+			case 3:
+				out[i] += 3*out[i-1] - 3*out[i-2] + out[i-3]
+			case 4:
+				out[i] += 4*out[i-1] - 6*out[i-2] + 4*out[i-3] - out[i-4]
+			}
+		}
+
+	case flacSubframeLPC:
+		for i := 0; i < order; i++ {
+			if out[i], err = br.readSigned(bps); err != nil {
+				return err
+			}
+		}
+		precision, err := br.read(4)
+		if err != nil {
+			return err
+		}
+		if precision == 0x0F {
+			return errors.New("invalid LPC precision")
+		}
+		shift, err := br.readSigned(5)
+		if err != nil {
+			return err
+		}
+		if shift < 0 {
+			return errors.New("negative LPC shift")
+		}
+		coefs := make([]int64, order)
+		for i := range coefs {
+			if coefs[i], err = br.readSigned(int(precision) + 1); err != nil {
+				return err
+			}
+		}
+		if err := readFLACResidual(br, out, order); err != nil {
+			return err
+		}
+		for i := order; i < len(out); i++ {
+			var sum int64
+			for j, c := range coefs {
+				sum += c * out[i-1-j]
+			}
+			out[i] += sum >> uint(shift)
+		}
+	}
+
+	if wasted > 0 {
+		for i := range out {
+			out[i] <<= uint(wasted)
+		}
+	}
+	return nil
+}
+
+// readFLACResidual decodes Rice-coded residuals into out[order:].
+func readFLACResidual(br *bitReader, out []int64, order int) error {
+	method, err := br.read(2)
+	if err != nil {
+		return err
+	}
+	paramBits, escape := 4, uint64(0x0F)
+	switch method {
+	case 0:
+	case 1:
+		paramBits, escape = 5, 0x1F
+	default:
+		return errors.New("reserved residual coding method")
+	}
+
+	partOrder, err := br.read(4)
+	if err != nil {
+		return err
+	}
+	partitions := 1 << partOrder
+	partSize := len(out) >> partOrder
+	if partSize < order || partSize<<partOrder != len(out) {
+		return errors.New("invalid residual partition order")
+	}
+
+	i := order
+	for p := 0; p < partitions; p++ {
+		n := partSize
+		if p == 0 {
+			n -= order
+		}
+		param, err := br.read(paramBits)
+		if err != nil {
+			return err
+		}
+		if param == escape {
+			raw, err := br.read(5)
+			if err != nil {
+				return err
+			}
+			for k := 0; k < n; k++ {
+				if raw == 0 {
+					out[i] = 0
+				} else if out[i], err = br.readSigned(int(raw)); err != nil {
+					return err
+				}
+				i++
+			}
+			continue
+		}
+		for k := 0; k < n; k++ {
+			q, err := br.unary()
+			if err != nil {
+				return err
+			}
+			low, err := br.read(int(param))
+			if err != nil {
+				return err
+			}
+			u := q<<param | low
+			out[i] = int64(u>>1) ^ -int64(u&1)
+			i++
+		}
+	}
+	return nil
+}
+
+// bitReader reads MSB-first bit fields from a byte stream.
+type bitReader struct {
+	r     io.ByteReader
+	cache uint64
+	n     uint // valid bits in cache (right-aligned)
+	bytes int  // bytes consumed since the last frame boundary
+}
+
+func (b *bitReader) fill() error {
+	c, err := b.r.ReadByte()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	b.cache = b.cache<<8 | uint64(c)
+	b.n += 8
+	b.bytes++
+	return nil
+}
+
+func (b *bitReader) read(n int) (uint64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	var v uint64
+	for n > 0 {
+		if b.n == 0 {
+			if err := b.fill(); err != nil {
+				return 0, err
+			}
+		}
+		take := uint(n)
+		if take > b.n {
+			take = b.n
+		}
+		v = v<<take | (b.cache>>(b.n-take))&(1<<take-1)
+		b.n -= take
+		b.cache &= 1<<b.n - 1
+		n -= int(take)
+	}
+	return v, nil
+}
+
+func (b *bitReader) readSigned(n int) (int64, error) {
+	v, err := b.read(n)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	shift := 64 - uint(n)
+	return int64(v<<shift) >> shift, nil
+}
+
+// unary counts zero bits up to the next set bit.
+func (b *bitReader) unary() (uint64, error) {
+	var count uint64
+	for {
+		if b.n == 0 {
+			if err := b.fill(); err != nil {
+				return 0, err
+			}
+		}
+		if b.cache == 0 {
+			count += uint64(b.n)
+			b.n = 0
+			continue
+		}
+		for b.cache>>(b.n-1)&1 == 0 {
+			count++
+			b.n--
+		}
+		b.n--
+		b.cache &= 1<<b.n - 1
+		return count, nil
+	}
+}
+
+// align drops any bits left in the current byte.
+func (b *bitReader) align() {
+	b.n -= b.n % 8
+	b.cache &= 1<<b.n - 1
+}
+
+func (b *bitReader) consumedInFrame() int { return b.bytes }
+func (b *bitReader) resetFrame()          { b.bytes = 0 }
@@ -0,0 +1,86 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// decodeWAV parses a RIFF/WAVE stream with PCM or IEEE float samples.
+func decodeWAV(r *bufio.Reader) (*Buffer, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, err
+	}
+
+	var format pcmFormat
+	var sampleRate int
+	haveFmt := false
+
+	for {
+		id, size, err := readChunkHeader(r, false)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("wav: missing data chunk")
+			}
+			return nil, err
+		}
+
+		switch id {
+		case "fmt ":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, fmt.Errorf("wav: read fmt: %w", err)
+			}
+			if size&1 == 1 {
+				r.Discard(1)
+			}
+			if len(body) < 16 {
+				return nil, errors.New("wav: short fmt chunk")
+			}
+			tag := binary.LittleEndian.Uint16(body[0:2])
+			if tag == wavFormatExtensible && len(body) >= 26 {
+				tag = binary.LittleEndian.Uint16(body[24:26])
+			}
+			if tag != wavFormatPCM && tag != wavFormatFloat {
+				return nil, fmt.Errorf("wav: unsupported format tag 0x%x", tag)
+			}
+			format = pcmFormat{
+				channels:  int(binary.LittleEndian.Uint16(body[2:4])),
+				bits:      int(binary.LittleEndian.Uint16(body[14:16])),
+				float:     tag == wavFormatFloat,
+				unsigned8: true,
+			}
+			sampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+			haveFmt = true
+
+		case "data":
+			if !haveFmt {
+				return nil, errors.New("wav: data chunk before fmt chunk")
+			}
+			dataSize := int64(size)
+			if size == 0xFFFFFFFF {
+				dataSize = math.MaxInt64 // RF64 or streamed: read to EOF
+			}
+			channels, err := readInterleaved(r, dataSize, format)
+			if err != nil {
+				return nil, fmt.Errorf("wav: %w", err)
+			}
+			return &Buffer{SampleRate: sampleRate, Channels: channels}, nil
+
+		default:
+			if err := skipChunk(r, size); err != nil {
+				return nil, fmt.Errorf("wav: skip %q: %w", id, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Package camelot names and scores harmonic mixing moves between keys on the
+// Camelot wheel. A Camelot key is a position on the circle of fifths (1-12) and
+// a letter for the mode: A for minor, B for major. Moves wrap around the wheel,
+// so 12A to 1A is as close as 8A to 9A.
+package camelot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownMove is returned when overriding the weight of a move that doesn't exist.
+var ErrUnknownMove = errors.New("unknown key move")
+
+// Key is a key in Camelot notation.
+type Key struct {
+	Number int  // 1-12
+	Minor  bool // A keys are minor, B keys major
+}
+
+// Parse reads Camelot notation such as "8A" or "12b".
+func Parse(value string) (Key, bool) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return Key{}, false
+	}
+	num, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || num < 1 || num > 12 {
+		return Key{}, false
+	}
+	switch value[len(value)-1] {
+	case 'A':
+		return Key{Number: num, Minor: true}, true
+	case 'B':
+		return Key{Number: num}, true
+	}
+	return Key{}, false
+}
+
+func (k Key) String() string {
+	if k.Minor {
+		return strconv.Itoa(k.Number) + "A"
+	}
+	return strconv.Itoa(k.Number) + "B"
+}
+
+// Transpose returns the key shifted by semitones. Each semitone up is seven
+// steps clockwise around the wheel; the mode is unchanged.
+func (k Key) Transpose(semitones int) Key {
+	k.Number = ((k.Number-1+7*semitones)%12+12)%12 + 1
+	return k
+}
+
+// Move is a kind of key change between two tracks.
+type Move int
+
+const (
+	Missing     Move = iota // either key is unknown
+	Invalid                 // either key isn't Camelot notation
+	Clash                   // no harmonic relation
+	Same                    // 8A → 8A
+	Relative                // same number, other mode: 8A → 8B
+	Adjacent                // one step either way, same mode: 8A → 9A, 12A → 1A
+	Diagonal                // minor up one to major, or major down one to minor: 8A → 9B, 8B → 7A
+	EnergyBoost             // two steps up, same mode; a whole tone up: 8A → 10A
+	Modulation              // seven steps up, same mode; a semitone up: 8A → 3A
+)
+
+var moveNames = [...]string{
+	Missing:     "missing",
+	Invalid:     "invalid",
+	Clash:       "clash",
+	Same:        "same",
+	Relative:    "relative",
+	Adjacent:    "adjacent",
+	Diagonal:    "diagonal",
+	EnergyBoost: "energy_boost",
+	Modulation:  "modulation",
+}
+
+// String returns the move's name as used in weight overrides, e.g. "energy_boost".
+func (m Move) String() string {
+	if m < 0 || int(m) >= len(moveNames) {
+		return "Move(" + strconv.Itoa(int(m)) + ")"
+	}
+	return moveNames[m]
+}
+
+// ParseMove looks a move up by name.
+func ParseMove(name string) (Move, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for m, n := range moveNames {
+		if n == name {
+			return Move(m), true
+		}
+	}
+	return 0, false
+}
+
+// Relation is the move from one key to another.
+type Relation struct {
+	Move     Move
+	From, To Key // zero for Missing and Invalid
+	Steps    int // signed steps around the wheel, -5 to 6
+}
+
+// Relate classifies the move between two keys in Camelot notation. Moves are
+// directional: EnergyBoost and Modulation only go up the wheel, so the same
+// change played downwards is a Clash. Every other move relates two keys the
+// same way in either order.
+func Relate(from, to string) Relation {
+	if strings.TrimSpace(from) == "" || strings.TrimSpace(to) == "" {
+		return Relation{Move: Missing}
+	}
+	a, okA := Parse(from)
+	b, okB := Parse(to)
+	if !okA || !okB {
+		return Relation{Move: Invalid}
+	}
+
+	r := Relation{From: a, To: b, Steps: (b.Number - a.Number + 12) % 12}
+	if r.Steps > 6 {
+		r.Steps -= 12
+	}
+	switch {
+	case a.Minor == b.Minor && r.Steps == 0:
+		r.Move = Same
+	case r.Steps == 0:
+		r.Move = Relative
+	case a.Minor == b.Minor && (r.Steps == 1 || r.Steps == -1):
+		r.Move = Adjacent
+	case a.Minor && !b.Minor && r.Steps == 1, !a.Minor && b.Minor && r.Steps == -1:
+		r.Move = Diagonal
+	case a.Minor == b.Minor && r.Steps == 2:
+		r.Move = EnergyBoost
+	case a.Minor == b.Minor && r.Steps == -5: // seven steps up
+		r.Move = Modulation
+	default:
+		r.Move = Clash
+	}
+	return r
+}
+
+// String names the relation for explanations, e.g. "+1 Camelot" or "relative minor".
+func (r Relation) String() string {
+	switch r.Move {
+	case Missing:
+		return "unknown key"
+	case Invalid:
+		return "invalid key"
+	case Same:
+		return "same key"
+	case Relative:
+		if r.To.Minor {
+			return "relative minor"
+		}
+		return "relative major"
+	case Adjacent:
+		return fmt.Sprintf("%+d Camelot", r.Steps)
+	case Diagonal:
+		return fmt.Sprintf("%+d diagonal", r.Steps)
+	case EnergyBoost:
+		return "+2 energy boost"
+	case Modulation:
+		return "+7 semitone modulation"
+	}
+	return "distant key"
+}
+
+// Weights scores each kind of move. Moves without an entry score zero.
+type Weights map[Move]float64
+
+// Score relates two keys and returns the weight of the move between them.
+func (w Weights) Score(from, to string) (float64, Relation) {
+	r := Relate(from, to)
+	return w[r.Move], r
+}
+
+// Override returns a copy of w with the weights of the named moves replaced.
+func (w Weights) Override(named map[string]float64) (Weights, error) {
+	out := make(Weights, len(w))
+	for m, v := range w {
+		out[m] = v
+	}
+	for name, v := range named {
+		m, ok := ParseMove(name)
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownMove, name)
+		}
+		out[m] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,78 @@
+package camelot
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRelate(t *testing.T) {
+	tests := []struct {
+		from, to string
+		move     Move
+		name     string
+	}{
+		{"8A", "8A", Same, "same key"},
+		{"8a", " 8A ", Same, "same key"},
+		{"8A", "8B", Relative, "relative major"},
+		{"8B", "8A", Relative, "relative minor"},
+		{"8A", "9A", Adjacent, "+1 Camelot"},
+		{"8A", "7A", Adjacent, "-1 Camelot"},
+		{"12A", "1A", Adjacent, "+1 Camelot"},
+		{"1B", "12B", Adjacent, "-1 Camelot"},
+		{"8A", "9B", Diagonal, "+1 diagonal"},
+		{"8B", "7A", Diagonal, "-1 diagonal"},
+		{"8A", "7B", Clash, "distant key"},
+		{"8A", "10A", EnergyBoost, "+2 energy boost"},
+		{"11B", "1B", EnergyBoost, "+2 energy boost"},
+		{"10A", "8A", Clash, "distant key"},
+		{"8A", "3A", Modulation, "+7 semitone modulation"},
+		{"6B", "1B", Modulation, "+7 semitone modulation"},
+		{"8A", "2A", Clash, "distant key"},
+		{"", "8A", Missing, "unknown key"},
+		{"Am", "8A", Invalid, "invalid key"},
+		{"13A", "8A", Invalid, "invalid key"},
+	}
+	for _, tt := range tests {
+		r := Relate(tt.from, tt.to)
+		if r.Move != tt.move || r.String() != tt.name {
+			t.Errorf("Relate(%q, %q) = %v %q, want %v %q", tt.from, tt.to, r.Move, r.String(), tt.move, tt.name)
+		}
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	k, _ := Parse("8A")
+	if got := k.Transpose(1).String(); got != "3A" {
+		t.Errorf("8A up a semitone = %s, want 3A", got)
+	}
+	if got := k.Transpose(-1).String(); got != "1A" {
+		t.Errorf("8A down a semitone = %s, want 1A", got)
+	}
+	if got := k.Transpose(12); got != k {
+		t.Errorf("8A up an octave = %s", got)
+	}
+}
+
+func TestWeightsOverride(t *testing.T) {
+	base := Weights{Same: 4, EnergyBoost: 2}
+	w, err := base.Override(map[string]float64{"energy_boost": 3, "Modulation": 1.5})
+	if err != nil {
+		t.Fatalf("override: %v", err)
+	}
+	if score, _ := w.Score("8A", "10A"); score != 3 {
+		t.Errorf("energy boost = %v, want 3", score)
+	}
+	if score, _ := w.Score("8A", "3A"); score != 1.5 {
+		t.Errorf("modulation = %v, want 1.5", score)
+	}
+	if base[EnergyBoost] != 2 {
+		t.Error("override changed the base weights")
+	}
+	if score, _ := w.Score("8A", "2A"); score != 0 {
+		t.Errorf("unweighted clash = %v, want 0", score)
+	}
+
+	if _, err := base.Override(map[string]float64{"tritone": 1}); !errors.Is(err, ErrUnknownMove) {
+		t.Errorf("err = %v, want ErrUnknownMove", err)
+	}
+}
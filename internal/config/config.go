@@ -3,6 +3,7 @@ package config
 import (
 	"flag"
 	"os"
+	"time"
 )
 
 type Config struct {
@@ -16,7 +17,20 @@ type Config struct {
 	WebRoot string
 
 	// Analyzer settings
-	AnalyzerAddr string
+	AnalyzerBackend string
+	AnalyzerAddr    string
+
+	// Background job settings
+	AnalysisWorkers int
+	JobTimeout      time.Duration
+
+	// Library watch settings
+	WatchLibrary      bool
+	WatchPollInterval time.Duration
+
+	// Similarity search settings
+	ANNIndex        bool
+	PersistANNIndex bool
 
 	// Auth settings
 	AuthEnabled bool
@@ -30,7 +44,14 @@ func Parse() *Config {
 	flag.StringVar(&cfg.DataDir, "data-dir", defaultDataDir(), "data directory for SQLite and blobs")
 	flag.StringVar(&cfg.LogLevel, "log-level", "info", "log level (debug, info, warn, error)")
 	flag.StringVar(&cfg.WebRoot, "web-root", "", "static file directory to serve (empty = disabled)")
+	flag.StringVar(&cfg.AnalyzerBackend, "analyzer-backend", "worker", "analysis backend: worker (analyzer worker over gRPC) or local (built-in pure-Go reference analyzer)")
 	flag.StringVar(&cfg.AnalyzerAddr, "analyzer-addr", "localhost:50052", "analyzer worker gRPC address")
+	flag.IntVar(&cfg.AnalysisWorkers, "analysis-workers", 2, "background analysis workers draining the job queue (0 = disabled)")
+	flag.DurationVar(&cfg.JobTimeout, "job-timeout", 10*time.Minute, "maximum time a single background job may run")
+	flag.BoolVar(&cfg.WatchLibrary, "watch", true, "watch persisted library roots for added, moved and deleted files")
+	flag.DurationVar(&cfg.WatchPollInterval, "watch-poll-interval", 30*time.Second, "directory polling interval where native file notifications are unavailable")
+	flag.BoolVar(&cfg.ANNIndex, "ann-index", true, "search OpenL3 similarity through an in-memory nearest neighbour index (false = compare every track)")
+	flag.BoolVar(&cfg.PersistANNIndex, "persist-ann-index", false, "save the OpenL3 index to the data directory on shutdown and load it at startup")
 	flag.BoolVar(&cfg.AuthEnabled, "auth", false, "enable API authentication (default: open for local use)")
 
 	flag.Parse()
@@ -0,0 +1,379 @@
+// Package duplicates groups library tracks that hold the same recording in
+// different files, such as a 320k MP3, a FLAC and a promo WAV of one release.
+// Content hashes can't catch these since the encoded audio differs, so tracks are
+// compared on their OpenL3 embeddings, duration, BPM, key and tags.
+package duplicates
+
+import (
+	"cmp"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+// Defaults for Options fields left at zero.
+const (
+	DefaultMinVibe          = 0.97 // cosine similarity of OpenL3 embeddings
+	DefaultMaxDurationDelta = 2.0  // seconds; covers encoder padding and trimmed silence
+	DefaultMaxBPMDelta      = 1.0
+)
+
+// Reasons a group was formed.
+const (
+	ReasonEmbedding = "embedding" // embeddings matched (tags may differ, e.g. untagged promos)
+	ReasonTags      = "tags"      // no embeddings to compare; artist and title matched
+)
+
+// losslessKbps is the estimated bitrate above which an .m4a is taken to be ALAC.
+const losslessKbps = 500
+
+// Candidate is a track considered for grouping.
+type Candidate struct {
+	TrackID         int64
+	ContentHash     string
+	Path            string
+	Title           string
+	Artist          string
+	DurationSeconds float64
+	BPM             float64
+	Key             string // Camelot notation
+	FileSize        int64
+	Embedding       []float32 // OpenL3; nil when the track has none
+}
+
+// Options tunes how closely tracks must agree to be grouped.
+type Options struct {
+	MinVibe          float64
+	MaxDurationDelta float64
+	MaxBPMDelta      float64
+}
+
+func (o Options) withDefaults() Options {
+	if o.MinVibe <= 0 {
+		o.MinVibe = DefaultMinVibe
+	}
+	if o.MaxDurationDelta <= 0 {
+		o.MaxDurationDelta = DefaultMaxDurationDelta
+	}
+	if o.MaxBPMDelta <= 0 {
+		o.MaxBPMDelta = DefaultMaxBPMDelta
+	}
+	return o
+}
+
+// Member is one track of a duplicate group.
+type Member struct {
+	Candidate
+	Format      string // file extension without the dot, e.g. "flac"
+	Lossless    bool
+	BitrateKbps float64 // estimated from file size and duration
+	VibeMatch   float64 // embedding cosine similarity to the preferred member; 0 when unknown
+}
+
+// Group is a set of tracks holding the same recording. Members are ordered best
+// quality first; Members[0] is the preferred track.
+type Group struct {
+	Preferred int64
+	Reason    string
+	Members   []Member
+}
+
+// Find groups candidates that are duplicates of each other. Tracks without a
+// duration are never grouped. Groups are returned largest first.
+func Find(candidates []*Candidate, opts Options) []Group {
+	opts = opts.withDefaults()
+
+	sorted := make([]*Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		if c.DurationSeconds > 0 {
+			sorted = append(sorted, c)
+		}
+	}
+	slices.SortFunc(sorted, func(a, b *Candidate) int {
+		return cmp.Or(cmp.Compare(a.DurationSeconds, b.DurationSeconds), cmp.Compare(a.TrackID, b.TrackID))
+	})
+
+	// Duplicates have near-equal durations, so only a sliding window of the
+	// duration-sorted list needs pairwise comparison.
+	parent := make([]int, len(sorted))
+	byEmbedding := make([]bool, len(sorted))
+	for i := range parent {
+		parent[i] = i
+	}
+	root := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	for i, a := range sorted {
+		for j := i + 1; j < len(sorted) && sorted[j].DurationSeconds-a.DurationSeconds <= opts.MaxDurationDelta; j++ {
+			reason := opts.match(a, sorted[j])
+			if reason == "" {
+				continue
+			}
+			ri, rj := root(i), root(j)
+			if ri != rj {
+				parent[rj] = ri
+				byEmbedding[ri] = byEmbedding[ri] || byEmbedding[rj]
+			}
+			if reason == ReasonEmbedding {
+				byEmbedding[ri] = true
+			}
+		}
+	}
+
+	members := make(map[int][]Member)
+	for i, c := range sorted {
+		r := root(i)
+		members[r] = append(members[r], newMember(c))
+	}
+
+	var groups []Group
+	for r, ms := range members {
+		if len(ms) < 2 {
+			continue
+		}
+		slices.SortFunc(ms, compareQuality)
+		for k := range ms {
+			ms[k].VibeMatch = cosine(ms[0].Embedding, ms[k].Embedding)
+		}
+		reason := ReasonTags
+		if byEmbedding[r] {
+			reason = ReasonEmbedding
+		}
+		groups = append(groups, Group{Preferred: ms[0].TrackID, Reason: reason, Members: ms})
+	}
+	slices.SortFunc(groups, func(a, b Group) int {
+		return cmp.Or(cmp.Compare(len(b.Members), len(a.Members)), cmp.Compare(a.Preferred, b.Preferred))
+	})
+	return groups
+}
+
+// match reports why a and b are duplicates, or "" when they aren't. Durations
+// have already been checked. Embeddings decide when both tracks have one; tags
+// are the fallback. A key disagreement needs matching tags to be overlooked.
+func (o Options) match(a, b *Candidate) string {
+	if a.BPM > 0 && b.BPM > 0 && !sameTempo(a.BPM, b.BPM, o.MaxBPMDelta) {
+		return ""
+	}
+	sameTags := tagsMatch(a, b)
+	keyClash := a.Key != "" && b.Key != "" && !strings.EqualFold(strings.TrimSpace(a.Key), strings.TrimSpace(b.Key))
+	if keyClash && !sameTags {
+		return ""
+	}
+	if len(a.Embedding) > 0 && len(b.Embedding) > 0 {
+		if cosine(a.Embedding, b.Embedding) >= o.MinVibe {
+			return ReasonEmbedding
+		}
+		return ""
+	}
+	if sameTags {
+		return ReasonTags
+	}
+	return ""
+}
+
+// sameTempo compares BPMs, allowing for one detector halving or doubling.
+func sameTempo(a, b, delta float64) bool {
+	return math.Abs(a-b) <= delta || math.Abs(a*2-b) <= delta || math.Abs(a-b*2) <= delta
+}
+
+func tagsMatch(a, b *Candidate) bool {
+	ta, tb := normalizeTag(a.Title), normalizeTag(b.Title)
+	aa, ab := normalizeTag(a.Artist), normalizeTag(b.Artist)
+	return ta != "" && aa != "" && ta == tb && aa == ab
+}
+
+// normalizeTag lowercases s and drops everything but letters and digits, so
+// "Track (Original Mix)" and "track original mix" compare equal.
+func normalizeTag(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func newMember(c *Candidate) Member {
+	m := Member{Candidate: *c, Format: strings.TrimPrefix(strings.ToLower(filepath.Ext(c.Path)), ".")}
+	if c.FileSize > 0 && c.DurationSeconds > 0 {
+		m.BitrateKbps = float64(c.FileSize) * 8 / c.DurationSeconds / 1000
+	}
+	switch m.Format {
+	case "flac", "wav", "aiff", "aif":
+		m.Lossless = true
+	case "m4a":
+		m.Lossless = m.BitrateKbps >= losslessKbps
+	}
+	return m
+}
+
+// formatRank orders lossless formats by how well they carry tags and cues.
+var formatRank = map[string]int{"flac": 3, "aiff": 2, "aif": 2, "m4a": 1}
+
+// compareQuality sorts the best copy first: lossless over lossy, then formats
+// that hold tags, then bitrate, then tagged over untagged.
+func compareQuality(a, b Member) int {
+	if a.Lossless != b.Lossless {
+		if a.Lossless {
+			return -1
+		}
+		return 1
+	}
+	if a.Lossless && formatRank[a.Format] != formatRank[b.Format] {
+		return cmp.Compare(formatRank[b.Format], formatRank[a.Format])
+	}
+	if !a.Lossless && math.Round(a.BitrateKbps) != math.Round(b.BitrateKbps) {
+		return cmp.Compare(b.BitrateKbps, a.BitrateKbps)
+	}
+	aTagged, bTagged := a.Title != "" && a.Artist != "", b.Title != "" && b.Artist != ""
+	if aTagged != bTagged {
+		if aTagged {
+			return -1
+		}
+		return 1
+	}
+	return cmp.Compare(a.TrackID, b.TrackID)
+}
+
+// Index looks up the duplicate group of a track.
+type Index struct {
+	groups map[int64]*Group
+}
+
+// NewIndex indexes groups by member track ID.
+func NewIndex(groups []Group) *Index {
+	ix := &Index{groups: make(map[int64]*Group)}
+	for i := range groups {
+		for _, m := range groups[i].Members {
+			ix.groups[m.TrackID] = &groups[i]
+		}
+	}
+	return ix
+}
+
+// Group returns the group holding trackID, or nil when it has no duplicates.
+func (ix *Index) Group(trackID int64) *Group {
+	return ix.groups[trackID]
+}
+
+// SameGroup reports whether a and b are duplicates of each other.
+func (ix *Index) SameGroup(a, b int64) bool {
+	g := ix.groups[a]
+	return g != nil && g == ix.groups[b]
+}
+
+// Collapse keeps one track per duplicate group from ids, preserving input order.
+// Within a group it keeps the best quality track in prefer, if any, otherwise the best
+// quality track not in avoid. Tracks without duplicates among ids are always
+// kept. The tracks that were dropped are returned too.
+func (ix *Index) Collapse(ids []int64, prefer, avoid map[int64]bool) (kept, dropped []int64) {
+	present := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		present[id] = true
+	}
+
+	chosen := make(map[*Group]int64)
+	for _, id := range ids {
+		g := ix.groups[id]
+		if g == nil {
+			continue
+		}
+		if _, done := chosen[g]; done {
+			continue
+		}
+		chosen[g] = pick(g, present, prefer, avoid)
+	}
+
+	for _, id := range ids {
+		g := ix.groups[id]
+		if g == nil || chosen[g] == id {
+			kept = append(kept, id)
+			continue
+		}
+		dropped = append(dropped, id)
+	}
+	return kept, dropped
+}
+
+// pick chooses which of g's members present in a collapsed list survives.
+func pick(g *Group, present, prefer, avoid map[int64]bool) int64 {
+	for _, m := range g.Members {
+		if present[m.TrackID] && prefer[m.TrackID] {
+			return m.TrackID
+		}
+	}
+	fallback := int64(-1)
+	for _, m := range g.Members {
+		if !present[m.TrackID] {
+			continue
+		}
+		if !avoid[m.TrackID] {
+			return m.TrackID
+		}
+		if fallback < 0 {
+			fallback = m.TrackID
+		}
+	}
+	return fallback
+}
+
+// CollapseSimilar prepares similarity candidates for a query: copies of the
+// query tracks themselves are dropped, and each other group is reduced to its
+// best quality candidate. The returned counts give, per kept track ID, how
+// many copies were folded into it.
+func (ix *Index) CollapseSimilar(queryIDs []int64, candidates []*similarity.TrackFeatures) ([]*similarity.TrackFeatures, map[int64]int) {
+	ids := make([]int64, 0, len(candidates))
+	for _, c := range candidates {
+		if !slices.ContainsFunc(queryIDs, func(q int64) bool { return ix.SameGroup(q, c.TrackID) }) {
+			ids = append(ids, c.TrackID)
+		}
+	}
+	kept, dropped := ix.Collapse(ids, nil, nil)
+
+	keep := make(map[int64]bool, len(kept))
+	for _, id := range kept {
+		keep[id] = true
+	}
+	counts := make(map[int64]int)
+	for _, id := range dropped {
+		for _, m := range ix.groups[id].Members {
+			if keep[m.TrackID] {
+				counts[m.TrackID]++
+				break
+			}
+		}
+	}
+
+	out := make([]*similarity.TrackFeatures, 0, len(kept))
+	for _, c := range candidates {
+		if keep[c.TrackID] {
+			out = append(out, c)
+		}
+	}
+	return out, counts
+}
@@ -0,0 +1,141 @@
+package duplicates
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+// embedding returns a unit-ish vector derived from seed, nudged by noise.
+func embedding(seed int64, noise float32) []float32 {
+	r := rand.New(rand.NewSource(seed))
+	n := rand.New(rand.NewSource(seed + 1000))
+	v := make([]float32, similarity.EmbeddingDim)
+	for i := range v {
+		v[i] = float32(r.NormFloat64()) + noise*float32(n.NormFloat64())
+	}
+	return v
+}
+
+// library is one recording as MP3, FLAC and promo WAV, plus an unrelated track of
+// the same length and a tagged copy pair without embeddings.
+func library() []*Candidate {
+	return []*Candidate{
+		{TrackID: 1, Path: "/lib/a.mp3", Title: "Night Drive", Artist: "Kova", DurationSeconds: 361.2, BPM: 124, Key: "8A", FileSize: 320 * 1000 / 8 * 361, Embedding: embedding(1, 0.05)},
+		{TrackID: 2, Path: "/lib/a.flac", Title: "Night Drive (Original Mix)", Artist: "Kova", DurationSeconds: 361.0, BPM: 124.1, Key: "8A", FileSize: 900 * 1000 / 8 * 361, Embedding: embedding(1, 0)},
+		{TrackID: 3, Path: "/promos/KOVA_ND_MASTER.wav", DurationSeconds: 362.4, BPM: 62, Key: "8A", FileSize: 1411 * 1000 / 8 * 362, Embedding: embedding(1, 0.08)},
+		{TrackID: 4, Path: "/lib/other.mp3", Title: "Other", Artist: "Someone", DurationSeconds: 361.1, BPM: 124, Key: "8A", FileSize: 320 * 1000 / 8 * 361, Embedding: embedding(2, 0)},
+		{TrackID: 5, Path: "/lib/b.mp3", Title: "Low Tide", Artist: "Mara", DurationSeconds: 300, FileSize: 192 * 1000 / 8 * 300},
+		{TrackID: 6, Path: "/lib/b.m4a", Title: "low tide", Artist: "MARA", DurationSeconds: 300.5, FileSize: 256 * 1000 / 8 * 300},
+		{TrackID: 7, Path: "/lib/b-edit.mp3", Title: "Low Tide", Artist: "Mara", DurationSeconds: 210, FileSize: 320 * 1000 / 8 * 210},
+	}
+}
+
+func memberIDs(g Group) []int64 {
+	var ids []int64
+	for _, m := range g.Members {
+		ids = append(ids, m.TrackID)
+	}
+	return ids
+}
+
+func TestFindGroupsCopiesAcrossFormats(t *testing.T) {
+	groups := Find(library(), Options{})
+	if len(groups) != 2 {
+		t.Fatalf("groups = %d, want 2: %+v", len(groups), groups)
+	}
+
+	g := groups[0]
+	if got := memberIDs(g); !slices.Equal(got, []int64{2, 3, 1}) {
+		t.Fatalf("members = %v, want FLAC, WAV, MP3", got)
+	}
+	if g.Preferred != 2 || g.Reason != ReasonEmbedding {
+		t.Fatalf("preferred = %d reason = %s", g.Preferred, g.Reason)
+	}
+	if !g.Members[1].Lossless || g.Members[2].Lossless {
+		t.Fatalf("lossless flags wrong: %+v", g.Members)
+	}
+	if g.Members[0].VibeMatch < 0.999 || g.Members[2].VibeMatch < DefaultMinVibe {
+		t.Fatalf("vibe matches = %v, %v", g.Members[0].VibeMatch, g.Members[2].VibeMatch)
+	}
+
+	g = groups[1]
+	if got := memberIDs(g); !slices.Equal(got, []int64{6, 5}) {
+		t.Fatalf("tag group members = %v, want m4a then mp3", got)
+	}
+	if g.Reason != ReasonTags {
+		t.Fatalf("reason = %s", g.Reason)
+	}
+}
+
+func TestFindRespectsKeyAndTempo(t *testing.T) {
+	lib := library()
+	lib[0].Key = "3B" // clashes, and the MP3's tags differ from the WAV's (none)
+	lib[1].BPM = 128
+	groups := Find(lib, Options{})
+	for _, g := range groups {
+		for _, id := range memberIDs(g) {
+			if id == 2 {
+				t.Fatalf("track with a different tempo grouped: %v", memberIDs(g))
+			}
+		}
+	}
+	// The MP3 and FLAC still share tags, but the FLAC's tempo rules it out, and
+	// the MP3's key clash with the untagged WAV keeps those apart too.
+	for _, g := range groups {
+		if slices.Contains(memberIDs(g), 1) {
+			t.Fatalf("mp3 grouped despite key clash: %v", memberIDs(g))
+		}
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	ix := NewIndex(Find(library(), Options{}))
+
+	kept, dropped := ix.Collapse([]int64{1, 4, 3, 2, 5}, nil, nil)
+	if !slices.Equal(kept, []int64{4, 2, 5}) || !slices.Equal(dropped, []int64{1, 3}) {
+		t.Fatalf("kept %v dropped %v", kept, dropped)
+	}
+
+	// A must-play copy wins over a better one; a banned copy yields to the next.
+	kept, _ = ix.Collapse([]int64{1, 2, 3}, map[int64]bool{1: true}, nil)
+	if !slices.Equal(kept, []int64{1}) {
+		t.Fatalf("prefer: kept %v", kept)
+	}
+	kept, _ = ix.Collapse([]int64{1, 2, 3}, nil, map[int64]bool{2: true})
+	if !slices.Equal(kept, []int64{3}) {
+		t.Fatalf("avoid: kept %v", kept)
+	}
+
+	// Only copies present in the list count.
+	kept, dropped = ix.Collapse([]int64{1, 3}, nil, nil)
+	if !slices.Equal(kept, []int64{3}) || !slices.Equal(dropped, []int64{1}) {
+		t.Fatalf("subset: kept %v dropped %v", kept, dropped)
+	}
+}
+
+func TestCollapseSimilar(t *testing.T) {
+	lib := library()
+	ix := NewIndex(Find(lib, Options{}))
+
+	var candidates []*similarity.TrackFeatures
+	for _, c := range lib {
+		if c.TrackID != 1 {
+			candidates = append(candidates, &similarity.TrackFeatures{TrackID: c.TrackID})
+		}
+	}
+
+	kept, counts := ix.CollapseSimilar([]int64{1}, candidates)
+	var ids []int64
+	for _, c := range kept {
+		ids = append(ids, c.TrackID)
+	}
+	if !slices.Equal(ids, []int64{4, 6, 7}) {
+		t.Fatalf("kept %v, want the query's copies gone and one of the tag pair", ids)
+	}
+	if counts[6] != 1 || counts[4] != 0 {
+		t.Fatalf("counts = %v", counts)
+	}
+}
@@ -13,10 +13,27 @@ import (
 	"github.com/cartomix/cancun/gen/go/common"
 )
 
-// TrackExport bundles a track path with its analysis.
+// TrackExport bundles a track path with its analysis and tag metadata. Tag
+// fields are optional; exporters fall back to the filename when Title is empty.
 type TrackExport struct {
 	Path     string
 	Analysis *common.TrackAnalysis
+
+	Title   string
+	Artist  string
+	Album   string
+	Genre   string
+	Label   string
+	Year    int
+	Comment string
+}
+
+// displayTitle returns the tagged title, or the filename without its extension.
+func (t TrackExport) displayTitle() string {
+	if t.Title != "" {
+		return t.Title
+	}
+	return strings.TrimSuffix(filepath.Base(t.Path), filepath.Ext(t.Path))
 }
 
 // Result contains paths to generated export artifacts.
@@ -81,6 +98,12 @@ func writeM3U(path string, tracks []TrackExport) error {
 		if meta := t.Analysis.GetId(); meta != nil && meta.Path != "" {
 			title = filepath.Base(meta.Path)
 		}
+		if t.Title != "" {
+			title = t.Title
+			if t.Artist != "" {
+				title = t.Artist + " - " + t.Title
+			}
+		}
 		b.WriteString(fmt.Sprintf("#EXTINF:0,%s\n", title))
 		b.WriteString(fmt.Sprintln(t.Path))
 	}
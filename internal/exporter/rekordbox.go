@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 )
 
@@ -39,6 +38,9 @@ type RekordboxTrack struct {
 	Artist      string `xml:"Artist,attr"`
 	Album       string `xml:"Album,attr,omitempty"`
 	Genre       string `xml:"Genre,attr,omitempty"`
+	Label       string `xml:"Label,attr,omitempty"`
+	Year        int    `xml:"Year,attr,omitempty"`
+	Comments    string `xml:"Comments,attr,omitempty"`
 	Kind        string `xml:"Kind,attr,omitempty"`
 	Size        int64  `xml:"Size,attr,omitempty"`
 	TotalTime   int    `xml:"TotalTime,attr"`
@@ -164,10 +166,20 @@ func WriteRekordbox(outputDir, playlistName string, tracks []TrackExport) (strin
 			avgBpm = grid.GetTempoMap()[0].GetBpm()
 		}
 
+		artist := t.Artist
+		if artist == "" {
+			artist = analysis.GetId().GetPath() // untagged: keep the path visible in Rekordbox
+		}
+
 		rbTracks = append(rbTracks, RekordboxTrack{
 			TrackID:       trackID,
-			Name:          filepath.Base(strings.TrimSuffix(t.Path, filepath.Ext(t.Path))),
-			Artist:        analysis.GetId().GetPath(), // Placeholder - should come from tags
+			Name:          t.displayTitle(),
+			Artist:        artist,
+			Album:         t.Album,
+			Genre:         t.Genre,
+			Label:         t.Label,
+			Year:          t.Year,
+			Comments:      t.Comment,
 			TotalTime:     totalTime,
 			DateAdded:     time.Now().Format("2006-01-02"),
 			AverageBpm:    fmt.Sprintf("%.2f", avgBpm),
@@ -215,8 +215,9 @@ func WriteTraktor(outputDir, playlistName string, tracks []TrackExport) (string,
 		locationKey := "/:file://localhost" + strings.ReplaceAll(absPath, "/", "/:")
 
 		entry := TraktorEntry{
-			Title:  strings.TrimSuffix(file, filepath.Ext(file)),
-			Artist: "", // Would come from tags
+			Title:  t.displayTitle(),
+			Artist: t.Artist,
+			Album:  TraktorAlbum{Title: t.Album},
 			Location: TraktorLocation{
 				Dir:    strings.ReplaceAll(dir, "/", "/:") + "/:",
 				File:   file,
@@ -226,6 +227,9 @@ func WriteTraktor(outputDir, playlistName string, tracks []TrackExport) (string,
 				Playtime:   playtime,
 				PlaytimeF:  playtimeF,
 				Key:        analysis.GetKey().GetValue(),
+				Genre:      t.Genre,
+				Label:      t.Label,
+				Comment:    t.Comment,
 				ImportDate: "2026/01/29",
 			},
 			Tempo: TraktorTempo{
@@ -40,6 +40,37 @@ func TestWriteRekordboxCreatesXML(t *testing.T) {
 	}
 }
 
+func TestVendorExportsUseTags(t *testing.T) {
+	dir := t.TempDir()
+	tracks := makeTestTracks()
+	tracks[0].Title = "Tagged Title"
+	tracks[0].Artist = "Tagged Artist"
+	tracks[0].Genre = "Techno"
+	tracks[0].Label = "Tagged Label"
+
+	rbPath, err := WriteRekordbox(dir, "tags", tracks)
+	if err != nil {
+		t.Fatalf("WriteRekordbox failed: %v", err)
+	}
+	tkPath, err := WriteTraktor(dir, "tags", tracks)
+	if err != nil {
+		t.Fatalf("WriteTraktor failed: %v", err)
+	}
+
+	rb, _ := os.ReadFile(rbPath)
+	for _, want := range []string{`Name="Tagged Title"`, `Artist="Tagged Artist"`, `Genre="Techno"`, `Label="Tagged Label"`, `Name="track2"`} {
+		if !strings.Contains(string(rb), want) {
+			t.Errorf("rekordbox XML missing %s", want)
+		}
+	}
+	tk, _ := os.ReadFile(tkPath)
+	for _, want := range []string{`TITLE="Tagged Title"`, `ARTIST="Tagged Artist"`, `GENRE="Techno"`, `LABEL="Tagged Label"`, `TITLE="track2"`} {
+		if !strings.Contains(string(tk), want) {
+			t.Errorf("traktor NML missing %s", want)
+		}
+	}
+}
+
 func TestWriteSeratoCreatesCrate(t *testing.T) {
 	dir := t.TempDir()
 	tracks := makeTestTracks()
@@ -340,9 +340,9 @@ func camelotFrequencies(key string) []float64 {
 	case "8A": // A minor
 		return []float64{220.0, 261.63, 329.63}
 	case "9A": // E minor
-		return []float64{164.81, 246.94, 329.63}
+		return []float64{164.81, 196.0, 246.94}
 	case "7A": // D minor
-		return []float64{146.83, 220.0, 293.66}
+		return []float64{146.83, 174.61, 220.0}
 	case "8B": // C major
 		return []float64{261.63, 329.63, 392.0}
 	case "9B": // G major
@@ -417,7 +417,7 @@ func renderPhraseTrack(path string, sampleRate int, bpm float64) (float64, []Man
 				for i := 0; i < kickLen && beatSample+i < totalSamples; i++ {
 					t := float64(i) / float64(sampleRate)
 					kickFreq := 60.0 * math.Exp(-15*t) // Pitch envelope
-					amplitude := energy * 0.7 * math.Exp(-10*t)
+					amplitude := energy * 0.7 * math.Exp(-10*t) * kickRelease(i, kickLen, sampleRate)
 					data[beatSample+i] += amplitude * math.Sin(2*math.Pi*kickFreq*t)
 				}
 			}
@@ -477,6 +477,11 @@ func renderPhraseTrack(path string, sampleRate int, bpm float64) (float64, []Man
 	return totalDuration, sections
 }
 
+// kickRelease fades the last 10ms of a kick so it doesn't end on a click.
+func kickRelease(i, kickLen, sampleRate int) float64 {
+	return math.Min(1, float64(kickLen-i)/(0.01*float64(sampleRate)))
+}
+
 // renderHarmonicSetTrack creates a shorter track for harmonic set testing
 func renderHarmonicSetTrack(path string, sampleRate int, key string, bpm float64, trackIndex int64) (float64, []ManifestSection) {
 	secondsPerBeat := 60.0 / bpm
@@ -516,7 +521,7 @@ func renderHarmonicSetTrack(path string, sampleRate int, key string, bpm float64
 				for i := 0; i < kickLen && beatSample+i < totalSamples; i++ {
 					t := float64(i) / float64(sampleRate)
 					kickFreq := 55.0 * math.Exp(-12*t)
-					amplitude := (energy + variation) * 0.6 * math.Exp(-8*t)
+					amplitude := (energy + variation) * 0.6 * math.Exp(-8*t) * kickRelease(i, kickLen, sampleRate)
 					data[beatSample+i] += amplitude * math.Sin(2*math.Pi*kickFreq*t)
 				}
 			}
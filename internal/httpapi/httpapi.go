@@ -2,22 +2,29 @@ package httpapi
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	analyzerpb "github.com/cartomix/cancun/gen/go/analyzer"
 	"github.com/cartomix/cancun/gen/go/common"
 	"github.com/cartomix/cancun/gen/go/engine"
 	"github.com/cartomix/cancun/internal/analyzer"
 	"github.com/cartomix/cancun/internal/config"
+	"github.com/cartomix/cancun/internal/duplicates"
 	"github.com/cartomix/cancun/internal/exporter"
+	"github.com/cartomix/cancun/internal/live"
 	"github.com/cartomix/cancun/internal/planner"
 	"github.com/cartomix/cancun/internal/scanner"
 	"github.com/cartomix/cancun/internal/similarity"
@@ -31,6 +38,8 @@ type Server struct {
 	db       *storage.DB
 	analyzer analyzer.Analyzer
 	scanner  *scanner.Scanner
+	watcher  *scanner.Watcher
+	live     *live.Manager
 	mux      *http.ServeMux
 }
 
@@ -48,6 +57,16 @@ func NewServer(cfg *config.Config, logger *slog.Logger, db *storage.DB, az analy
 	return s
 }
 
+// SetWatcher enables the library root endpoints.
+func (s *Server) SetWatcher(w *scanner.Watcher) {
+	s.watcher = w
+}
+
+// SetLiveSessions enables the live set endpoints.
+func (s *Server) SetLiveSessions(m *live.Manager) {
+	s.live = m
+}
+
 // Handler returns the HTTP handler for the server.
 func (s *Server) Handler() http.Handler {
 	return deprecationMiddleware(corsMiddleware(s.mux))
@@ -70,12 +89,35 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("GET /api/tracks", s.handleListTracks)
 	s.mux.HandleFunc("GET /api/tracks/{id}", s.handleGetTrack)
 	s.mux.HandleFunc("GET /api/tracks/{id}/similar", s.handleSimilarTracks)
+	s.mux.HandleFunc("GET /api/tracks/similar", s.handleSimilarToSeeds)
+	s.mux.HandleFunc("POST /api/tracks/similar", s.handleSimilarToSeeds)
 	s.mux.HandleFunc("POST /api/scan", s.handleScan)
+	s.mux.HandleFunc("GET /api/library/roots", s.handleListLibraryRoots)
+	s.mux.HandleFunc("POST /api/library/roots", s.handleAddLibraryRoot)
+	s.mux.HandleFunc("DELETE /api/library/roots/{id}", s.handleRemoveLibraryRoot)
+	s.mux.HandleFunc("POST /api/library/health", s.handleLibraryHealth)
+	s.mux.HandleFunc("POST /api/library/relocate", s.handleRelocateTracks)
+	s.mux.HandleFunc("GET /api/library/duplicates", s.handleListDuplicates)
 	s.mux.HandleFunc("POST /api/analyze", s.handleAnalyze)
 	s.mux.HandleFunc("POST /api/set/propose", s.handleProposeSet)
+	s.mux.HandleFunc("GET /api/transitions", s.handleSuggestTransition)
+	s.mux.HandleFunc("POST /api/live", s.handleStartLiveSession)
+	s.mux.HandleFunc("GET /api/live/{id}", s.handleGetLiveSession)
+	s.mux.HandleFunc("POST /api/live/{id}/play", s.handleRecordLivePlay)
+	s.mux.HandleFunc("POST /api/live/{id}/end", s.handleEndLiveSession)
+	s.mux.HandleFunc("GET /api/live/{id}/events", s.handleWatchLiveSession)
 	s.mux.HandleFunc("POST /api/export", s.handleExport)
+	s.mux.HandleFunc("GET /api/sets", s.handleListSets)
+	s.mux.HandleFunc("POST /api/sets", s.handleCreateSet)
+	s.mux.HandleFunc("GET /api/sets/{id}", s.handleGetSet)
+	s.mux.HandleFunc("PUT /api/sets/{id}", s.handleUpdateSet)
+	s.mux.HandleFunc("DELETE /api/sets/{id}", s.handleDeleteSet)
+	s.mux.HandleFunc("GET /api/sets/{id}/versions", s.handleListSetVersions)
 	s.mux.HandleFunc("GET /api/ml/settings", s.handleGetMLSettings)
 	s.mux.HandleFunc("PUT /api/ml/settings", s.handleUpdateMLSettings)
+	s.mux.HandleFunc("GET /api/ml/profiles", s.handleListSimilarityProfiles)
+	s.mux.HandleFunc("PUT /api/ml/profiles/{name}", s.handleSaveSimilarityProfile)
+	s.mux.HandleFunc("DELETE /api/ml/profiles/{name}", s.handleDeleteSimilarityProfile)
 
 	// Training endpoints
 	s.mux.HandleFunc("GET /api/training/labels", s.handleListTrainingLabels)
@@ -229,9 +271,17 @@ type ScanRequest struct {
 
 // ScanResponse is the JSON response for library scanning.
 type ScanResponse struct {
-	Processed int64    `json:"processed"`
-	Total     int64    `json:"total"`
-	NewTracks []string `json:"new_tracks"`
+	Processed  int64           `json:"processed"`
+	Total      int64           `json:"total"`
+	NewTracks  []string        `json:"new_tracks"`
+	Collisions []ScanCollision `json:"collisions,omitempty"`
+}
+
+// ScanCollision reports a file whose audio is already owned by another track.
+type ScanCollision struct {
+	Path         string `json:"path"`
+	CollidesWith string `json:"collides_with"`
+	ContentHash  string `json:"content_hash"`
 }
 
 func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
@@ -251,8 +301,9 @@ func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
 
 	progress := make(chan scanner.ScanProgress)
 	var scanErr error
-	var newTrackIDs []int64
+	var newTrackIDs, changedTrackIDs []int64
 	var newPaths []string
+	var collisions []ScanCollision
 
 	go func() {
 		scanErr = s.scanner.Scan(ctx, req.Roots, req.ForceRescan, progress)
@@ -264,6 +315,12 @@ func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
 			newTrackIDs = append(newTrackIDs, p.TrackID)
 			newPaths = append(newPaths, p.Path)
 		}
+		if p.Changed {
+			changedTrackIDs = append(changedTrackIDs, p.TrackID)
+		}
+		if p.CollidesWith != "" {
+			collisions = append(collisions, ScanCollision{Path: p.Path, CollidesWith: p.CollidesWith, ContentHash: p.ContentHash})
+		}
 		lastProcessed = p.Processed
 		lastTotal = p.Total
 	}
@@ -278,19 +335,238 @@ func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
 			s.logger.Warn("failed to enqueue analysis jobs", "error", err)
 		}
 	}
+	if len(changedTrackIDs) > 0 {
+		if err := s.scanner.EnqueueReanalysis(changedTrackIDs, 0); err != nil {
+			s.logger.Warn("failed to enqueue re-analysis jobs", "error", err)
+		}
+	}
 
 	writeJSON(w, http.StatusOK, ScanResponse{
-		Processed: lastProcessed,
-		Total:     lastTotal,
-		NewTracks: newPaths,
+		Processed:  lastProcessed,
+		Total:      lastTotal,
+		NewTracks:  newPaths,
+		Collisions: collisions,
+	})
+}
+
+// LibraryRootRequest is the JSON request for adding a library root.
+type LibraryRootRequest struct {
+	Path string `json:"path"`
+}
+
+// LibraryRootResponse is the JSON response for library roots.
+type LibraryRootResponse struct {
+	ID            int64   `json:"id"`
+	Path          string  `json:"path"`
+	Watching      bool    `json:"watching"`
+	CreatedAt     string  `json:"created_at"`
+	LastScannedAt *string `json:"last_scanned_at,omitempty"`
+}
+
+func (s *Server) libraryRootResponse(root *storage.LibraryRoot) LibraryRootResponse {
+	resp := LibraryRootResponse{
+		ID:        root.ID,
+		Path:      root.Path,
+		Watching:  s.watcher.Watching(),
+		CreatedAt: root.CreatedAt.Format(time.RFC3339),
+	}
+	if !root.LastScannedAt.IsZero() {
+		scanned := root.LastScannedAt.Format(time.RFC3339)
+		resp.LastScannedAt = &scanned
+	}
+	return resp
+}
+
+func (s *Server) handleListLibraryRoots(w http.ResponseWriter, _ *http.Request) {
+	if s.watcher == nil {
+		writeError(w, http.StatusServiceUnavailable, "library watcher not configured")
+		return
+	}
+
+	roots, err := s.watcher.Roots()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list library roots: "+err.Error())
+		return
+	}
+
+	resp := make([]LibraryRootResponse, 0, len(roots))
+	for _, root := range roots {
+		resp = append(resp, s.libraryRootResponse(root))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleAddLibraryRoot(w http.ResponseWriter, r *http.Request) {
+	if s.watcher == nil {
+		writeError(w, http.StatusServiceUnavailable, "library watcher not configured")
+		return
+	}
+
+	var req LibraryRootRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.Path == "" {
+		writeError(w, http.StatusBadRequest, "path is required")
+		return
+	}
+
+	root, err := s.watcher.AddRoot(req.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		writeError(w, http.StatusNotFound, "path not found: "+req.Path)
+		return
+	case errors.Is(err, scanner.ErrNotDirectory):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "failed to add library root: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, s.libraryRootResponse(root))
+}
+
+func (s *Server) handleRemoveLibraryRoot(w http.ResponseWriter, r *http.Request) {
+	if s.watcher == nil {
+		writeError(w, http.StatusServiceUnavailable, "library watcher not configured")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid library root id")
+		return
+	}
+
+	if err := s.watcher.RemoveRoot(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "library root not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to remove library root: "+err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "library root removed"})
+}
+
+// MissingTrackResponse describes a track whose file is gone.
+type MissingTrackResponse struct {
+	TrackID      int64  `json:"track_id"`
+	ContentHash  string `json:"content_hash"`
+	Path         string `json:"path"`
+	MissingSince string `json:"missing_since"`
+}
+
+// LibraryHealthResponse is the JSON response for a library health pass.
+type LibraryHealthResponse struct {
+	Checked      int                    `json:"checked"`
+	NewlyMissing int                    `json:"newly_missing"`
+	Recovered    int                    `json:"recovered"`
+	Missing      []MissingTrackResponse `json:"missing"`
+}
+
+func (s *Server) handleLibraryHealth(w http.ResponseWriter, r *http.Request) {
+	report, err := s.scanner.CheckHealth(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "library health check failed: "+err.Error())
+		return
+	}
+
+	resp := LibraryHealthResponse{
+		Checked:      report.Checked,
+		NewlyMissing: report.NewlyMissing,
+		Recovered:    report.Recovered,
+		Missing:      make([]MissingTrackResponse, 0, len(report.Missing)),
+	}
+	for _, t := range report.Missing {
+		resp.Missing = append(resp.Missing, MissingTrackResponse{
+			TrackID:      t.ID,
+			ContentHash:  t.ContentHash,
+			Path:         t.Path,
+			MissingSince: t.MissingAt.Format(time.RFC3339),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RelocateRequest is the JSON request for relocating missing tracks. Set either
+// old_prefix and new_prefix, or search_root.
+type RelocateRequest struct {
+	OldPrefix  string `json:"old_prefix,omitempty"`
+	NewPrefix  string `json:"new_prefix,omitempty"`
+	SearchRoot string `json:"search_root,omitempty"`
+	DryRun     bool   `json:"dry_run"`
+}
+
+// RelocationResponse is the outcome for one missing track.
+type RelocationResponse struct {
+	TrackID     int64  `json:"track_id"`
+	ContentHash string `json:"content_hash"`
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path,omitempty"`
+	Status      string `json:"status"`
+}
+
+// RelocateResponse is the JSON response for relocating missing tracks.
+type RelocateResponse struct {
+	DryRun      bool                 `json:"dry_run"`
+	Matched     int                  `json:"matched"`
+	Unmatched   int                  `json:"unmatched"`
+	Relocations []RelocationResponse `json:"relocations"`
+}
+
+func (s *Server) handleRelocateTracks(w http.ResponseWriter, r *http.Request) {
+	var req RelocateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	report, err := s.scanner.Relocate(r.Context(), scanner.RelocateOptions{
+		OldPrefix:  req.OldPrefix,
+		NewPrefix:  req.NewPrefix,
+		SearchRoot: req.SearchRoot,
+		DryRun:     req.DryRun,
 	})
+	switch {
+	case errors.Is(err, scanner.ErrInvalidRelocation), errors.Is(err, scanner.ErrNotDirectory):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, os.ErrNotExist):
+		writeError(w, http.StatusNotFound, "search root not found: "+req.SearchRoot)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "relocate failed: "+err.Error())
+		return
+	}
+
+	resp := RelocateResponse{
+		DryRun:      report.DryRun,
+		Matched:     report.Matched,
+		Unmatched:   report.Unmatched,
+		Relocations: make([]RelocationResponse, 0, len(report.Relocations)),
+	}
+	for _, rel := range report.Relocations {
+		resp.Relocations = append(resp.Relocations, RelocationResponse{
+			TrackID:     rel.TrackID,
+			ContentHash: rel.ContentHash,
+			OldPath:     rel.OldPath,
+			NewPath:     rel.NewPath,
+			Status:      rel.Status,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // AnalyzeRequest is the JSON request for track analysis.
 type AnalyzeRequest struct {
-	Paths    []string `json:"paths"`
-	TrackIDs []string `json:"track_ids"`
-	Force    bool     `json:"force"`
+	Paths       []string `json:"paths"`
+	TrackIDs    []string `json:"track_ids"`
+	Force       bool     `json:"force"`
+	MaxParallel int      `json:"max_parallel"`
 }
 
 // AnalyzeResponse is the JSON response for track analysis.
@@ -300,6 +576,9 @@ type AnalyzeResponse struct {
 	Errors   []string `json:"errors"`
 }
 
+// maxAnalyzeParallel caps fan-out so a single request cannot flood the analyzer worker.
+const maxAnalyzeParallel = 16
+
 func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
 	var req AnalyzeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -317,6 +596,13 @@ func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
 
 	var analyzed, skipped, errors []string
 
+	// Resolve inputs first; label is what we report back for each track.
+	type pending struct {
+		track *storage.Track
+		label string
+	}
+	var work []pending
+
 	// Process paths
 	for _, path := range req.Paths {
 		hash, err := scanner.ComputeHash(path)
@@ -330,15 +616,7 @@ func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
 			errors = append(errors, fmt.Sprintf("%s: track not found", path))
 			continue
 		}
-
-		result, err := s.analyzeTrack(ctx, track, req.Force)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("%s: %v", path, err))
-		} else if result == "skipped" {
-			skipped = append(skipped, path)
-		} else {
-			analyzed = append(analyzed, path)
-		}
+		work = append(work, pending{track: track, label: path})
 	}
 
 	// Process track IDs
@@ -348,14 +626,50 @@ func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
 			errors = append(errors, fmt.Sprintf("%s: track not found", id))
 			continue
 		}
+		work = append(work, pending{track: track, label: id})
+	}
 
-		result, err := s.analyzeTrack(ctx, track, req.Force)
-		if err != nil {
-			errors = append(errors, fmt.Sprintf("%s: %v", id, err))
-		} else if result == "skipped" {
-			skipped = append(skipped, track.Path)
-		} else {
-			analyzed = append(analyzed, track.Path)
+	parallel := req.MaxParallel
+	if parallel <= 0 {
+		parallel = 1
+	}
+	if parallel > maxAnalyzeParallel {
+		parallel = maxAnalyzeParallel
+	}
+
+	// Fan out, then collect in input order so the response is deterministic.
+	type outcome struct {
+		result string
+		err    error
+	}
+	outcomes := make([]outcome, len(work))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, item := range work {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			outcomes[i] = outcome{err: ctx.Err()}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, track *storage.Track) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result, err := s.analyzeTrack(ctx, track, req.Force)
+			outcomes[i] = outcome{result: result, err: err}
+		}(i, item.track)
+	}
+	wg.Wait()
+
+	for i, item := range work {
+		switch out := outcomes[i]; {
+		case out.err != nil:
+			errors = append(errors, fmt.Sprintf("%s: %v", item.label, out.err))
+		case out.result == "skipped":
+			skipped = append(skipped, item.track.Path)
+		default:
+			analyzed = append(analyzed, item.track.Path)
 		}
 	}
 
@@ -375,27 +689,10 @@ func (s *Server) analyzeTrack(ctx context.Context, track *storage.Track, force b
 		}
 	}
 
-	job := &analyzerpb.AnalyzeJob{
-		Id: &common.TrackId{
-			ContentHash: track.ContentHash,
-			Path:        track.Path,
-		},
-		Path: track.Path,
-		Decode: &analyzerpb.DecodeParams{
-			TargetSampleRate: 48000,
-			Mono:             false,
-		},
-		Beatgrid: &analyzerpb.BeatgridParams{
-			DynamicAllowed: true,
-			TempoFloor:     60,
-			TempoCeil:      180,
-		},
-		Cues: &analyzerpb.CueParams{
-			MaxCues:        8,
-			SnapToDownbeat: true,
-		},
-		AnalysisVersion: version,
-	}
+	job := analyzer.NewJob(&common.TrackId{
+		ContentHash: track.ContentHash,
+		Path:        track.Path,
+	}, track.Path, version)
 
 	res, err := s.analyzer.AnalyzeTrack(ctx, job)
 	if err != nil {
@@ -410,10 +707,86 @@ func (s *Server) analyzeTrack(ctx context.Context, track *storage.Track, force b
 	if err := s.db.UpsertAnalysis(rec); err != nil {
 		return "", fmt.Errorf("persist analysis failed: %w", err)
 	}
+	if err := s.db.SaveOpenL3Windows(track.ID, version, storage.OpenL3WindowsFromProto(res.GetOpenl3Windows())); err != nil {
+		return "", fmt.Errorf("persist window embeddings failed: %w", err)
+	}
 
 	return "analyzed", nil
 }
 
+// DuplicateMemberResponse is one copy of a duplicated recording.
+type DuplicateMemberResponse struct {
+	TrackID         int64   `json:"track_id"`
+	ContentHash     string  `json:"content_hash"`
+	Path            string  `json:"path"`
+	Title           string  `json:"title"`
+	Artist          string  `json:"artist"`
+	Format          string  `json:"format"`
+	Lossless        bool    `json:"lossless"`
+	BitrateKbps     float64 `json:"bitrate_kbps"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	VibeMatch       float64 `json:"vibe_match"`
+	Preferred       bool    `json:"preferred"`
+}
+
+// DuplicateGroupResponse is a set of copies of one recording, best quality first.
+type DuplicateGroupResponse struct {
+	Preferred string                    `json:"preferred"` // content hash
+	Reason    string                    `json:"reason"`
+	Members   []DuplicateMemberResponse `json:"members"`
+}
+
+func (s *Server) handleListDuplicates(w http.ResponseWriter, r *http.Request) {
+	var opts duplicates.Options
+	if v := r.URL.Query().Get("min_vibe"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			writeError(w, http.StatusBadRequest, "min_vibe must be in (0, 1]")
+			return
+		}
+		opts.MinVibe = f
+	}
+	if v := r.URL.Query().Get("max_duration_delta"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 {
+			writeError(w, http.StatusBadRequest, "max_duration_delta must be a positive number of seconds")
+			return
+		}
+		opts.MaxDurationDelta = f
+	}
+
+	groups, err := s.db.FindDuplicates(opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "duplicate detection failed: "+err.Error())
+		return
+	}
+
+	resp := make([]DuplicateGroupResponse, 0, len(groups))
+	for _, g := range groups {
+		group := DuplicateGroupResponse{Reason: g.Reason}
+		for _, m := range g.Members {
+			if m.TrackID == g.Preferred {
+				group.Preferred = m.ContentHash
+			}
+			group.Members = append(group.Members, DuplicateMemberResponse{
+				TrackID:         m.TrackID,
+				ContentHash:     m.ContentHash,
+				Path:            m.Path,
+				Title:           m.Title,
+				Artist:          m.Artist,
+				Format:          m.Format,
+				Lossless:        m.Lossless,
+				BitrateKbps:     m.BitrateKbps,
+				DurationSeconds: m.DurationSeconds,
+				VibeMatch:       m.VibeMatch,
+				Preferred:       m.TrackID == g.Preferred,
+			})
+		}
+		resp = append(resp, group)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"groups": resp})
+}
+
 // ProposeSetRequest is the JSON request for set planning.
 type ProposeSetRequest struct {
 	TrackIDs      []string `json:"track_ids"`
@@ -422,6 +795,158 @@ type ProposeSetRequest struct {
 	MaxBpmStep    float64  `json:"max_bpm_step"`
 	MustPlay      []string `json:"must_play"`
 	Ban           []string `json:"ban"`
+	// CollapseDuplicates plans one copy per duplicate group: a must-play copy if
+	// any, otherwise the best quality copy that isn't banned.
+	CollapseDuplicates bool `json:"collapse_duplicates"`
+	TimeBudgetMs       int  `json:"time_budget_ms"` // optimizer search time; 250 when zero
+	// EnergyCurve is an optional target energy arc.
+	EnergyCurve *EnergyCurveRequest `json:"energy_curve,omitempty"`
+	// TargetMinutes plans the subset of the tracks that fits a set of this
+	// length; every track is planned when zero.
+	TargetMinutes    float64 `json:"target_minutes,omitempty"`
+	ToleranceMinutes float64 `json:"tolerance_minutes,omitempty"` // 5% of the target when zero
+	PlayFraction     float64 `json:"play_fraction,omitempty"`     // share of each track played; 1 when zero
+	// KeyWeights overrides the score of key moves by name, e.g. {"energy_boost": 3}.
+	KeyWeights map[string]float64 `json:"key_weights,omitempty"`
+	// PitchRange is how far either way a deck can pitch, in percent; 8 when zero.
+	PitchRange  float64 `json:"pitch_range,omitempty"`
+	MasterTempo bool    `json:"master_tempo,omitempty"` // key lock on: pitching doesn't shift keys
+	// VibeWeight scales the OpenL3 vibe term; 3 when zero, off when negative.
+	VibeWeight float64 `json:"vibe_weight,omitempty"`
+	// Opener and Closer pin the first and last tracks, by content hash.
+	Opener string `json:"opener,omitempty"`
+	Closer string `json:"closer,omitempty"`
+	// LockedChains are runs of content hashes that play back to back, in order.
+	LockedChains  [][]string             `json:"locked_chains,omitempty"`
+	Precedences   []PrecedenceRequest    `json:"precedences,omitempty"`
+	ArtistSpacing []ArtistSpacingRequest `json:"artist_spacing,omitempty"`
+	// Alternatives asks for up to this many runner-up plans (at most 10), each
+	// making at least MinDifferentEdges transitions no earlier plan makes; a
+	// quarter of the set's when zero.
+	Alternatives      int  `json:"alternatives,omitempty"`
+	MinDifferentEdges int  `json:"min_different_edges,omitempty"`
+	DifferentOpeners  bool `json:"different_openers,omitempty"` // each alternative opens with a new track
+}
+
+// PrecedenceRequest asks for one track to play somewhere before another.
+type PrecedenceRequest struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ArtistSpacingRequest asks for at least MinTracks other tracks between any
+// track by ArtistA and any by ArtistB, which may be the same artist.
+type ArtistSpacingRequest struct {
+	ArtistA   string `json:"artist_a"`
+	ArtistB   string `json:"artist_b"`
+	MinTracks int    `json:"min_tracks"`
+}
+
+// EnergyCurveRequest is a target energy arc: a preset such as "slow_burn",
+// "double_peak" or "sunrise_closer", or explicit points.
+type EnergyCurveRequest struct {
+	Preset string               `json:"preset,omitempty"`
+	Points []EnergyPointRequest `json:"points,omitempty"`
+	Weight float32              `json:"weight,omitempty"`
+}
+
+// EnergyPointRequest places a target energy (1-10) by position through the set
+// (0-1) or by minutes from its start.
+type EnergyPointRequest struct {
+	Position *float64 `json:"position,omitempty"`
+	Minutes  *float64 `json:"minutes,omitempty"`
+	Energy   float32  `json:"energy"`
+}
+
+func (c *EnergyCurveRequest) toProto() (*engine.EnergyCurve, error) {
+	curve := &engine.EnergyCurve{Preset: c.Preset, Weight: c.Weight}
+	for _, p := range c.Points {
+		point := &engine.EnergyPoint{Energy: p.Energy}
+		switch {
+		case p.Position != nil && p.Minutes != nil:
+			return nil, fmt.Errorf("energy point has both position and minutes")
+		case p.Position != nil:
+			point.At = &engine.EnergyPoint_Position{Position: *p.Position}
+		case p.Minutes != nil:
+			point.At = &engine.EnergyPoint_Minutes{Minutes: *p.Minutes}
+		}
+		curve.Points = append(curve.Points, point)
+	}
+	return curve, nil
+}
+
+// toProto converts the request to the plan request a live session starts
+// from.
+func (r *ProposeSetRequest) toProto() (*engine.SetPlanRequest, error) {
+	req := &engine.SetPlanRequest{
+		TrackIds:           trackIDs(r.TrackIDs),
+		Mode:               parseSetMode(r.Mode),
+		AllowKeyJumps:      r.AllowKeyJumps,
+		MaxBpmStep:         r.MaxBpmStep,
+		MustPlay:           trackIDs(r.MustPlay),
+		Ban:                trackIDs(r.Ban),
+		CollapseDuplicates: r.CollapseDuplicates,
+		TimeBudgetMs:       int32(r.TimeBudgetMs),
+		TargetMinutes:      r.TargetMinutes,
+		ToleranceMinutes:   r.ToleranceMinutes,
+		PlayFraction:       r.PlayFraction,
+		KeyWeights:         r.KeyWeights,
+		PitchRange:         r.PitchRange,
+		MasterTempo:        r.MasterTempo,
+		VibeWeight:         r.VibeWeight,
+		Alternatives:       int32(r.Alternatives),
+		MinDifferentEdges:  int32(r.MinDifferentEdges),
+		DifferentOpeners:   r.DifferentOpeners,
+	}
+	if r.Opener != "" {
+		req.Opener = &common.TrackId{ContentHash: r.Opener}
+	}
+	if r.Closer != "" {
+		req.Closer = &common.TrackId{ContentHash: r.Closer}
+	}
+	for _, chain := range r.LockedChains {
+		req.LockedChains = append(req.LockedChains, &engine.LockedChain{Tracks: trackIDs(chain)})
+	}
+	for _, p := range r.Precedences {
+		req.Precedences = append(req.Precedences, &engine.Precedence{
+			Before: &common.TrackId{ContentHash: p.Before},
+			After:  &common.TrackId{ContentHash: p.After},
+		})
+	}
+	for _, a := range r.ArtistSpacing {
+		req.ArtistSpacing = append(req.ArtistSpacing, &engine.ArtistSpacing{
+			ArtistA:   a.ArtistA,
+			ArtistB:   a.ArtistB,
+			MinTracks: int32(a.MinTracks),
+		})
+	}
+	if r.EnergyCurve != nil {
+		curve, err := r.EnergyCurve.toProto()
+		if err != nil {
+			return nil, err
+		}
+		req.EnergyCurve = curve
+	}
+	return req, nil
+}
+
+// parseSetMode reads a set mode by name; peak time when unrecognized.
+func parseSetMode(name string) engine.SetMode {
+	switch strings.ToUpper(name) {
+	case "WARM_UP", "SET_MODE_WARM_UP":
+		return engine.SetMode_WARM_UP
+	case "OPEN_FORMAT", "SET_MODE_OPEN_FORMAT":
+		return engine.SetMode_OPEN_FORMAT
+	}
+	return engine.SetMode_PEAK_TIME
+}
+
+func trackIDs(hashes []string) []*common.TrackId {
+	ids := make([]*common.TrackId, 0, len(hashes))
+	for _, h := range hashes {
+		ids = append(ids, &common.TrackId{ContentHash: h})
+	}
+	return ids
 }
 
 func (s *Server) handleProposeSet(w http.ResponseWriter, r *http.Request) {
@@ -437,55 +962,379 @@ func (s *Server) handleProposeSet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	analyses := []*common.TrackAnalysis{}
+	var tracks []*storage.Track
+	mix := make(map[string]planner.MixEmbeddings)
+	artists := make(map[string]string)
 	for _, id := range req.TrackIDs {
 		track, err := s.db.ResolveTrack(&common.TrackId{ContentHash: id})
 		if err != nil {
 			writeError(w, http.StatusNotFound, fmt.Sprintf("track not found: %s", id))
 			return
 		}
-		analysis, err := s.db.LatestCompleteAnalysis(track.ID)
+		analysis, err := s.db.LatestCompleteAnalysis(track.ID)
+		if err != nil {
+			writeError(w, http.StatusPreconditionFailed, fmt.Sprintf("missing analysis for %s", track.Path))
+			return
+		}
+		windows, err := s.db.GetOpenL3Windows(track.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "openl3 window lookup failed: "+err.Error())
+			return
+		}
+		if len(windows) > 0 {
+			mix[track.ContentHash] = planner.MixEmbeddingsFromWindows(windows, analysis.GetDurationSeconds())
+		}
+		artists[track.ContentHash] = track.Artist
+		analyses = append(analyses, analysis)
+		tracks = append(tracks, track)
+	}
+
+	mode := parseSetMode(req.Mode)
+
+	mustPlay := make(map[string]bool)
+	for _, h := range req.MustPlay {
+		mustPlay[h] = true
+	}
+	ban := make(map[string]bool)
+	for _, h := range req.Ban {
+		ban[h] = true
+	}
+
+	precedences := make([]planner.Precedence, 0, len(req.Precedences))
+	for _, p := range req.Precedences {
+		precedences = append(precedences, planner.Precedence{Before: p.Before, After: p.After})
+	}
+	spacing := make([]planner.ArtistSpacing, 0, len(req.ArtistSpacing))
+	for _, a := range req.ArtistSpacing {
+		spacing = append(spacing, planner.ArtistSpacing{ArtistA: a.ArtistA, ArtistB: a.ArtistB, MinTracks: a.MinTracks})
+	}
+
+	collapsed := []string{}
+	if req.CollapseDuplicates {
+		// Keep the copies the constraints name, like must-play copies.
+		keep := maps.Clone(mustPlay)
+		for _, h := range append([]string{req.Opener, req.Closer}, slices.Concat(req.LockedChains...)...) {
+			keep[h] = true
+		}
+		for _, p := range precedences {
+			keep[p.Before], keep[p.After] = true, true
+		}
+		groups, err := s.db.FindDuplicates(duplicates.Options{})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "duplicate detection failed: "+err.Error())
+			return
+		}
+		ids := make([]int64, len(tracks))
+		prefer, avoid := make(map[int64]bool), make(map[int64]bool)
+		for i, t := range tracks {
+			ids[i] = t.ID
+			prefer[t.ID] = keep[t.ContentHash]
+			avoid[t.ID] = ban[t.ContentHash]
+		}
+		_, dropped := duplicates.NewIndex(groups).Collapse(ids, prefer, avoid)
+		drop := make(map[int64]bool, len(dropped))
+		for _, id := range dropped {
+			drop[id] = true
+		}
+		kept := make([]*common.TrackAnalysis, 0, len(analyses))
+		for i, t := range tracks {
+			if drop[t.ID] {
+				collapsed = append(collapsed, t.ContentHash)
+				continue
+			}
+			kept = append(kept, analyses[i])
+		}
+		analyses = kept
+	}
+
+	opts := planner.Options{
+		Mode:              mode,
+		AllowKeyJumps:     req.AllowKeyJumps,
+		MaxBpmStep:        req.MaxBpmStep,
+		MustPlayHashes:    mustPlay,
+		BanHashes:         ban,
+		TimeBudget:        time.Duration(req.TimeBudgetMs) * time.Millisecond,
+		TargetLength:      time.Duration(req.TargetMinutes * float64(time.Minute)),
+		LengthTolerance:   time.Duration(req.ToleranceMinutes * float64(time.Minute)),
+		PlayFraction:      req.PlayFraction,
+		PitchRange:        req.PitchRange / 100,
+		MasterTempo:       req.MasterTempo,
+		VibeWeight:        req.VibeWeight,
+		MixEmbeddings:     mix,
+		Opener:            req.Opener,
+		Closer:            req.Closer,
+		LockedChains:      req.LockedChains,
+		Precedences:       precedences,
+		ArtistSpacing:     spacing,
+		Artists:           artists,
+		Alternatives:      req.Alternatives,
+		MinDifferentEdges: req.MinDifferentEdges,
+		DifferentOpeners:  req.DifferentOpeners,
+	}
+	if len(req.KeyWeights) > 0 {
+		weights, err := planner.DefaultKeyWeights.Override(req.KeyWeights)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.KeyWeights = weights
+	}
+	if req.EnergyCurve != nil {
+		curve, err := req.EnergyCurve.toProto()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.EnergyCurve = curve
+	}
+
+	result, err := planner.Optimize(analyses, opts)
+	if errors.Is(err, planner.ErrInvalidCurve) || errors.Is(err, planner.ErrSetLength) || errors.Is(err, planner.ErrConstraints) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "set planning failed: "+err.Error())
+		return
+	}
+	alternatives := result.Alternatives
+	if alternatives == nil {
+		alternatives = []*engine.AlternativePlan{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"order":             result.Order,
+		"explanations":      result.Explanations,
+		"collapsed":         collapsed,
+		"total_score":       result.TotalScore,
+		"weakest_edges":     result.WeakestEdges,
+		"energy_slots":      result.EnergySlots,
+		"energy_error":      result.EnergyError,
+		"estimated_seconds": result.EstimatedSeconds,
+		"alternatives":      alternatives,
+	})
+}
+
+// LivePlayRequest is the JSON request for recording the track playing now.
+type LivePlayRequest struct {
+	TrackID   string `json:"track_id"`
+	StartedAt int64  `json:"started_at,omitempty"` // Unix timestamp; now when zero
+}
+
+// liveKeepAlive is how often an idle live event stream sends a comment, so
+// proxies don't close it.
+const liveKeepAlive = 15 * time.Second
+
+// handleStartLiveSession plans a set as /api/set/propose does and starts
+// following it.
+func (s *Server) handleStartLiveSession(w http.ResponseWriter, r *http.Request) {
+	if s.live == nil {
+		writeError(w, http.StatusServiceUnavailable, "live sessions not configured")
+		return
+	}
+	var req ProposeSetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	planReq, err := req.toProto()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	session, err := s.live.Start(planReq)
+	if err != nil {
+		writeLiveError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, session)
+}
+
+func (s *Server) handleGetLiveSession(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.liveSessionID(w, r)
+	if !ok {
+		return
+	}
+	session, err := s.live.Get(id)
+	if err != nil {
+		writeLiveError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, session)
+}
+
+// handleRecordLivePlay records the track playing now and returns the rest of
+// the set replanned from it.
+func (s *Server) handleRecordLivePlay(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.liveSessionID(w, r)
+	if !ok {
+		return
+	}
+	var req LivePlayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.TrackID == "" {
+		writeError(w, http.StatusBadRequest, "track_id is required")
+		return
+	}
+	var at time.Time
+	if req.StartedAt > 0 {
+		at = time.Unix(req.StartedAt, 0)
+	}
+	session, err := s.live.Play(id, &common.TrackId{ContentHash: req.TrackID}, at)
+	if err != nil {
+		writeLiveError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, session)
+}
+
+func (s *Server) handleEndLiveSession(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.liveSessionID(w, r)
+	if !ok {
+		return
+	}
+	session, err := s.live.End(id)
+	if err != nil {
+		writeLiveError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, session)
+}
+
+// handleWatchLiveSession streams the session as server-sent events: one now
+// and one after every replan, until the set ends or the client goes away.
+func (s *Server) handleWatchLiveSession(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.liveSessionID(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	updates, stop, err := s.live.Watch(id)
+	if err != nil {
+		writeLiveError(w, err)
+		return
+	}
+	defer stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(liveKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case session, ok := <-updates:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(session)
+			if err != nil {
+				s.logger.Error("failed to encode live session", "error", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// liveSessionID reads the session id from the path, writing the error response
+// when it can't or live sessions aren't configured.
+func (s *Server) liveSessionID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	if s.live == nil {
+		writeError(w, http.StatusServiceUnavailable, "live sessions not configured")
+		return 0, false
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid live session id")
+		return 0, false
+	}
+	return id, true
+}
+
+func writeLiveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, "live session not found")
+	case errors.Is(err, live.ErrTrackNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, live.ErrInvalidRequest):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, live.ErrNoAnalysis):
+		writeError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, storage.ErrLiveSessionEnded):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "live session failed: "+err.Error())
+	}
+}
+
+// handleSuggestTransition ranks places to mix between two tracks, given as
+// ?from=<hash>&to=<hash>, with optional limit, pitch_range (percent) and
+// master_tempo.
+func (s *Server) handleSuggestTransition(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var analyses [2]*common.TrackAnalysis
+	for i, param := range []string{"from", "to"} {
+		hash := q.Get(param)
+		if hash == "" {
+			writeError(w, http.StatusBadRequest, param+" is required")
+			return
+		}
+		track, err := s.db.ResolveTrack(&common.TrackId{ContentHash: hash})
+		if err != nil {
+			writeError(w, http.StatusNotFound, "track not found: "+hash)
+			return
+		}
+		analyses[i], err = s.db.LatestCompleteAnalysis(track.ID)
 		if err != nil {
-			writeError(w, http.StatusPreconditionFailed, fmt.Sprintf("missing analysis for %s", track.Path))
+			writeError(w, http.StatusNotFound, "analysis not found: "+hash)
 			return
 		}
-		analyses = append(analyses, analysis)
-	}
-
-	mode := engine.SetMode_PEAK_TIME
-	switch strings.ToUpper(req.Mode) {
-	case "WARM_UP", "SET_MODE_WARM_UP":
-		mode = engine.SetMode_WARM_UP
-	case "OPEN_FORMAT", "SET_MODE_OPEN_FORMAT":
-		mode = engine.SetMode_OPEN_FORMAT
 	}
 
-	mustPlay := make(map[string]bool)
-	for _, h := range req.MustPlay {
-		mustPlay[h] = true
-	}
-	ban := make(map[string]bool)
-	for _, h := range req.Ban {
-		ban[h] = true
+	var limit int
+	if v := q.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = l
 	}
-
-	opts := planner.Options{
-		Mode:           mode,
-		AllowKeyJumps:  req.AllowKeyJumps,
-		MaxBpmStep:     req.MaxBpmStep,
-		MustPlayHashes: mustPlay,
-		BanHashes:      ban,
+	opts := planner.Options{MasterTempo: q.Get("master_tempo") == "true"}
+	if v := q.Get("pitch_range"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 {
+			writeError(w, http.StatusBadRequest, "pitch_range must be a positive percentage")
+			return
+		}
+		opts.PitchRange = f / 100
 	}
 
-	order, explanations, err := planner.Plan(analyses, opts)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "set planning failed: "+err.Error())
-		return
+	suggestions := planner.SuggestTransition(analyses[0], analyses[1], limit, opts)
+	if suggestions == nil {
+		suggestions = []*common.TransitionSuggestion{}
 	}
-
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"order":        order,
-		"explanations": explanations,
-	})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"suggestions": suggestions})
 }
 
 // ExportRequest is the JSON request for exporting a set.
@@ -494,6 +1343,10 @@ type ExportRequest struct {
 	PlaylistName string   `json:"playlist_name"`
 	OutputDir    string   `json:"output_dir"`
 	Formats      []string `json:"formats"`
+	// SetID exports a saved set instead of TrackIDs, at SetVersion or the
+	// latest version when zero.
+	SetID      int64 `json:"set_id,omitempty"`
+	SetVersion int   `json:"set_version,omitempty"`
 }
 
 // ExportResponse is the JSON response for exporting a set.
@@ -512,6 +1365,21 @@ func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.SetID != 0 {
+		if len(req.TrackIDs) > 0 {
+			writeError(w, http.StatusBadRequest, "set_id and track_ids are exclusive")
+			return
+		}
+		saved, err := s.db.GetSavedSet(req.SetID, req.SetVersion)
+		if err != nil {
+			writeSavedSetError(w, err)
+			return
+		}
+		req.TrackIDs = saved.Tracks
+		if req.PlaylistName == "" {
+			req.PlaylistName = saved.Name
+		}
+	}
 	if len(req.TrackIDs) == 0 {
 		writeError(w, http.StatusBadRequest, "track_ids are required")
 		return
@@ -537,6 +1405,13 @@ func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 		tracks = append(tracks, exporter.TrackExport{
 			Path:     track.Path,
 			Analysis: analysis,
+			Title:    track.Title,
+			Artist:   track.Artist,
+			Album:    track.Album,
+			Genre:    track.Genre,
+			Label:    track.Label,
+			Year:     track.Year,
+			Comment:  track.Comment,
 		})
 	}
 
@@ -588,9 +1463,170 @@ func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// SaveSetRequest is the JSON request for saving a set or a new version of one.
+type SaveSetRequest struct {
+	Name     string   `json:"name"`
+	Notes    string   `json:"notes"`
+	TrackIDs []string `json:"track_ids"` // in play order
+	// Explanations are the set's transitions, one per pair of tracks, as
+	// /api/set/propose returns them. Optional.
+	Explanations []*common.EdgeExplanation `json:"explanations,omitempty"`
+}
+
+func (s *Server) handleListSets(w http.ResponseWriter, _ *http.Request) {
+	sets, err := s.db.ListSavedSets()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list sets: "+err.Error())
+		return
+	}
+	resp := make([]*engine.SavedSet, 0, len(sets))
+	for _, set := range sets {
+		resp = append(resp, savedSetToProto(set))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sets": resp})
+}
+
+func (s *Server) handleCreateSet(w http.ResponseWriter, r *http.Request) {
+	set, ok := s.decodeSavedSet(w, r)
+	if !ok {
+		return
+	}
+	saved, err := s.db.CreateSavedSet(set)
+	if err != nil {
+		writeSavedSetError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, savedSetToProto(saved))
+}
+
+// handleGetSet returns a saved set, at ?version=N or its latest version.
+func (s *Server) handleGetSet(w http.ResponseWriter, r *http.Request) {
+	id, ok := savedSetID(w, r)
+	if !ok {
+		return
+	}
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		var err error
+		if version, err = strconv.Atoi(v); err != nil || version < 0 {
+			writeError(w, http.StatusBadRequest, "invalid version")
+			return
+		}
+	}
+	saved, err := s.db.GetSavedSet(id, version)
+	if err != nil {
+		writeSavedSetError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, savedSetToProto(saved))
+}
+
+// handleUpdateSet saves the body as the next version of the set.
+func (s *Server) handleUpdateSet(w http.ResponseWriter, r *http.Request) {
+	id, ok := savedSetID(w, r)
+	if !ok {
+		return
+	}
+	set, ok := s.decodeSavedSet(w, r)
+	if !ok {
+		return
+	}
+	set.ID = id
+	saved, err := s.db.UpdateSavedSet(set)
+	if err != nil {
+		writeSavedSetError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, savedSetToProto(saved))
+}
+
+func (s *Server) handleDeleteSet(w http.ResponseWriter, r *http.Request) {
+	id, ok := savedSetID(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.DeleteSavedSet(id); err != nil {
+		writeSavedSetError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "set deleted"})
+}
+
+func (s *Server) handleListSetVersions(w http.ResponseWriter, r *http.Request) {
+	id, ok := savedSetID(w, r)
+	if !ok {
+		return
+	}
+	versions, err := s.db.ListSavedSetVersions(id)
+	if err != nil {
+		writeSavedSetError(w, err)
+		return
+	}
+	resp := make([]*engine.SavedSet, 0, len(versions))
+	for _, v := range versions {
+		resp = append(resp, savedSetToProto(v))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"versions": resp})
+}
+
+// decodeSavedSet reads a save request and resolves its tracks to the
+// library's content hashes, writing the error response when it can't.
+func (s *Server) decodeSavedSet(w http.ResponseWriter, r *http.Request) (*storage.SavedSet, bool) {
+	var req SaveSetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return nil, false
+	}
+	set := &storage.SavedSet{Name: req.Name, Notes: req.Notes, Explanations: req.Explanations}
+	for _, id := range req.TrackIDs {
+		track, err := s.db.ResolveTrack(&common.TrackId{ContentHash: id})
+		if err != nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("track not found: %s", id))
+			return nil, false
+		}
+		set.Tracks = append(set.Tracks, track.ContentHash)
+	}
+	return set, true
+}
+
+func savedSetID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid set id")
+		return 0, false
+	}
+	return id, true
+}
+
+func savedSetToProto(set *storage.SavedSet) *engine.SavedSet {
+	out := &engine.SavedSet{
+		SetId:        set.ID,
+		Version:      int32(set.Version),
+		Name:         set.Name,
+		Notes:        set.Notes,
+		TrackIds:     trackIDs(set.Tracks),
+		Explanations: set.Explanations,
+		CreatedAt:    set.CreatedAt.Unix(),
+		UpdatedAt:    set.UpdatedAt.Unix(),
+	}
+	return out
+}
+
+func writeSavedSetError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, "set not found")
+	case errors.Is(err, storage.ErrInvalidSavedSet):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "saved set failed: "+err.Error())
+	}
+}
+
 // SimilarTracksResponse is the JSON response for similar tracks.
 type SimilarTracksResponse struct {
-	Query   TrackSummaryResponse        `json:"query"`
+	Query   TrackSummaryResponse          `json:"query"`
+	Section *similarity.Section           `json:"section,omitempty"` // the part of the query track compared, in section queries
 	Similar []similarity.SimilarityResult `json:"similar"`
 }
 
@@ -630,14 +1666,11 @@ func (s *Server) handleSimilarTracks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get all candidate tracks (excluding query)
-	candidates, err := s.db.GetTrackFeaturesExcluding([]int64{track.ID})
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to fetch candidates: "+err.Error())
-		return
-	}
-
-	if len(candidates) == 0 {
+	var querySection *similarity.Section
+	respond := func(similar []similarity.SimilarityResult) {
+		if similar == nil {
+			similar = []similarity.SimilarityResult{}
+		}
 		writeJSON(w, http.StatusOK, SimilarTracksResponse{
 			Query: TrackSummaryResponse{
 				ContentHash: track.ContentHash,
@@ -648,45 +1681,317 @@ func (s *Server) handleSimilarTracks(w http.ResponseWriter, r *http.Request) {
 				Key:         queryFeatures.KeyValue,
 				Energy:      queryFeatures.Energy,
 			},
-			Similar: []similarity.SimilarityResult{},
+			Section: querySection,
+			Similar: similar,
 		})
+	}
+
+	exact := r.URL.Query().Get("exact") == "true"
+	collapse := r.URL.Query().Get("collapse_duplicates") == "true"
+	profile := r.URL.Query().Get("profile")
+	override, err := parseWeightsQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	weights, err := s.db.ResolveSimilarityWeights(profile, override)
+	if err != nil {
+		writeSimilarityWeightsError(w, err)
+		return
+	}
+
+	// Compare a section with other tracks' sections when one is asked for,
+	// e.g. ?section=outro&match_section=intro
+	sq, err := parseSectionQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	near := similarity.BytesToFloats(queryFeatures.OpenL3Embedding)
+	if sq != nil {
+		if querySection, err = s.db.ResolveSection(track.ID, sq.label, sq.from, sq.to); err != nil {
+			writeSectionError(w, err)
+			return
+		}
+		near = querySection.Embedding
+	}
+
+	// Serve the precomputed similarity graph while it is fresh and scored
+	// with the same weights
+	if !exact && !collapse && profile == "" && override.IsZero() && sq == nil {
+		cached, ok, err := s.db.CachedSimilarTracks(track.ID, limit, 0)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to read similarity cache: "+err.Error())
+			return
+		}
+		if ok {
+			respond(cached)
+			return
+		}
+	}
+
+	// Get candidate tracks: the nearest by vibe from the embedding index, or
+	// every other track with ?exact=true
+	candidates, err := s.db.SimilarityCandidatesNear([][]float32{near}, similarity.CandidatePool(limit), []int64{track.ID}, exact)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to fetch candidates: "+err.Error())
+		return
+	}
+
+	var duplicateCounts map[int64]int
+	if collapse {
+		groups, err := s.db.FindDuplicates(duplicates.Options{})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "duplicate detection failed: "+err.Error())
+			return
+		}
+		candidates, duplicateCounts = duplicates.NewIndex(groups).CollapseSimilar([]int64{track.ID}, candidates)
+	}
+
+	// Find similar tracks, or sections
+	var similar []similarity.SimilarityResult
+	if sq != nil {
+		ids := make([]int64, len(candidates))
+		for i, c := range candidates {
+			ids[i] = c.TrackID
+		}
+		sections, err := s.db.GetSectionsForTracks(ids, sq.matchLabel)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to fetch sections: "+err.Error())
+			return
+		}
+		similar = similarity.FindSimilarSections(queryFeatures, *querySection, candidates, sections, limit, weights)
+	} else {
+		similar = similarity.FindSimilarWeighted(queryFeatures, candidates, limit, weights)
+	}
+	for i := range similar {
+		similar[i].DuplicateCount = duplicateCounts[similar[i].TrackID]
+	}
+	respond(similar)
+}
+
+// sectionQuery picks the part of a track a section query compares, and the
+// sections of other tracks it is compared with.
+type sectionQuery struct {
+	label      string  // the query track's first section with this label
+	from, to   float64 // or this time range, when to is past from
+	matchLabel string  // only compare other tracks' sections with this label
+}
+
+// parseSectionQuery reads a section query given as section, start, end and
+// match_section query parameters. It returns nil when there is none.
+func parseSectionQuery(q url.Values) (*sectionQuery, error) {
+	if !q.Has("section") && !q.Has("start") && !q.Has("end") && !q.Has("match_section") {
+		return nil, nil
+	}
+	var sq sectionQuery
+	for _, p := range []struct {
+		name  string
+		value *string
+	}{
+		{"section", &sq.label},
+		{"match_section", &sq.matchLabel},
+	} {
+		label, err := storage.ParseSectionLabel(q.Get(p.name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", p.name, err)
+		}
+		*p.value = storage.SectionLabelName(label)
+	}
+	for _, p := range []struct {
+		name  string
+		value *float64
+	}{
+		{"start", &sq.from},
+		{"end", &sq.to},
+	} {
+		if v := q.Get(p.name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %v", p.name, err)
+			}
+			*p.value = f
+		}
+	}
+	return &sq, nil
+}
+
+func writeSectionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrInvalidSection):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, storage.ErrNoSectionEmbedding):
+		writeError(w, http.StatusPreconditionFailed, "track has no window embeddings for the section - re-analyze with OpenL3 enabled")
+	default:
+		writeError(w, http.StatusInternalServerError, "section lookup failed: "+err.Error())
+	}
+}
+
+// SimilarToSeedsRequest is the JSON request for tracks similar to several
+// seeds. GET takes the same fields as query parameters, with like and unlike
+// comma-separated and weights as vibe_weight, tempo_weight, key_weight and
+// energy_weight.
+type SimilarToSeedsRequest struct {
+	Like               []string            `json:"like"`                  // content hashes to be similar to
+	Unlike             []string            `json:"unlike,omitempty"`      // content hashes to be unlike
+	Aggregation        string              `json:"aggregation,omitempty"` // centroid (default) or max
+	Limit              int                 `json:"limit,omitempty"`
+	Profile            string              `json:"profile,omitempty"`
+	Weights            *similarity.Weights `json:"weights,omitempty"` // overrides the profile
+	Exact              bool                `json:"exact,omitempty"`
+	CollapseDuplicates bool                `json:"collapse_duplicates,omitempty"`
+}
+
+// SimilarToSeedsResponse is the JSON response for tracks similar to several
+// seeds. Each result's seed is the like seed it relates to.
+type SimilarToSeedsResponse struct {
+	Like        []TrackSummaryResponse        `json:"like"`
+	Unlike      []TrackSummaryResponse        `json:"unlike"`
+	Aggregation similarity.Aggregation        `json:"aggregation"`
+	Similar     []similarity.SimilarityResult `json:"similar"`
+}
+
+func (s *Server) handleSimilarToSeeds(w http.ResponseWriter, r *http.Request) {
+	var req SimilarToSeedsRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+	} else {
+		q := r.URL.Query()
+		req.Like = splitList(q["like"])
+		req.Unlike = splitList(q["unlike"])
+		req.Aggregation = q.Get("aggregation")
+		req.Limit, _ = strconv.Atoi(q.Get("limit"))
+		req.Profile = q.Get("profile")
+		override, err := parseWeightsQuery(q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		req.Weights = &override
+		req.Exact = q.Get("exact") == "true"
+		req.CollapseDuplicates = q.Get("collapse_duplicates") == "true"
+	}
+
+	if len(req.Like) == 0 {
+		writeError(w, http.StatusBadRequest, "like is required")
+		return
+	}
+	aggregation, err := similarity.ParseAggregation(req.Aggregation)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit := 10
+	if req.Limit > 0 && req.Limit <= 50 {
+		limit = req.Limit
+	}
+	var override similarity.Weights
+	if req.Weights != nil {
+		override = *req.Weights
+	}
+	weights, err := s.db.ResolveSimilarityWeights(req.Profile, override)
+	if err != nil {
+		writeSimilarityWeightsError(w, err)
+		return
+	}
+
+	query := similarity.SeedQuery{Aggregation: aggregation, Weights: weights}
+	resp := SimilarToSeedsResponse{Aggregation: aggregation}
+	var ok bool
+	if query.Like, resp.Like, ok = s.resolveSeeds(w, req.Like); !ok {
+		return
+	}
+	if query.Unlike, resp.Unlike, ok = s.resolveSeeds(w, req.Unlike); !ok {
 		return
 	}
 
-	// Find similar tracks
-	similar := similarity.FindSimilar(queryFeatures, candidates, limit)
+	// Get candidate tracks: the nearest to the seeds by vibe from the
+	// embedding index, or every other track with exact
+	candidates, err := s.db.SimilarityCandidatesNear(query.Near(), similarity.CandidatePool(limit), query.SeedIDs(), req.Exact)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to fetch candidates: "+err.Error())
+		return
+	}
+
+	var duplicateCounts map[int64]int
+	if req.CollapseDuplicates {
+		groups, err := s.db.FindDuplicates(duplicates.Options{})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "duplicate detection failed: "+err.Error())
+			return
+		}
+		candidates, duplicateCounts = duplicates.NewIndex(groups).CollapseSimilar(query.SeedIDs(), candidates)
+	}
 
-	// Cache results for future queries
-	for _, sim := range similar {
-		_ = s.db.CacheSimilarity(
-			track.ID, sim.TrackID,
-			sim.VibeMatch/100, sim.Score,
-			sim.TempoMatch/100, sim.KeyMatch/100, sim.EnergyMatch/100,
-			sim.Explanation,
-		)
+	resp.Similar = similarity.FindSimilarToSeeds(query, candidates, limit)
+	if resp.Similar == nil {
+		resp.Similar = []similarity.SimilarityResult{}
+	}
+	for i := range resp.Similar {
+		resp.Similar[i].DuplicateCount = duplicateCounts[resp.Similar[i].TrackID]
 	}
+	writeJSON(w, http.StatusOK, resp)
+}
 
-	writeJSON(w, http.StatusOK, SimilarTracksResponse{
-		Query: TrackSummaryResponse{
+// resolveSeeds looks up the seed tracks of a similarity query by content hash.
+// It writes the error response and returns false when one is missing or has
+// no embedding.
+func (s *Server) resolveSeeds(w http.ResponseWriter, hashes []string) ([]*similarity.TrackFeatures, []TrackSummaryResponse, bool) {
+	seeds := make([]*similarity.TrackFeatures, 0, len(hashes))
+	summaries := make([]TrackSummaryResponse, 0, len(hashes))
+	for _, hash := range hashes {
+		track, err := s.db.ResolveTrack(&common.TrackId{ContentHash: hash})
+		if err != nil {
+			writeError(w, http.StatusNotFound, "seed track not found: "+hash)
+			return nil, nil, false
+		}
+		features, err := s.db.GetTrackFeaturesForSimilarity(track.ID)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "seed track analysis not found: "+hash)
+			return nil, nil, false
+		}
+		if len(features.OpenL3Embedding) == 0 {
+			writeError(w, http.StatusPreconditionFailed, "seed track "+hash+" has no ML embedding - re-analyze with OpenL3 enabled")
+			return nil, nil, false
+		}
+		seeds = append(seeds, features)
+		summaries = append(summaries, TrackSummaryResponse{
 			ContentHash: track.ContentHash,
 			Path:        track.Path,
-			Title:       queryFeatures.Title,
-			Artist:      queryFeatures.Artist,
-			BPM:         queryFeatures.BPM,
-			Key:         queryFeatures.KeyValue,
-			Energy:      queryFeatures.Energy,
-		},
-		Similar: similar,
-	})
+			Title:       features.Title,
+			Artist:      features.Artist,
+			BPM:         features.BPM,
+			Key:         features.KeyValue,
+			Energy:      features.Energy,
+		})
+	}
+	return seeds, summaries, true
+}
+
+// splitList reads a query parameter given repeated, comma-separated or both.
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
 }
 
 // MLSettingsResponse is the JSON response for ML settings.
 type MLSettingsResponse struct {
-	OpenL3Enabled         bool    `json:"openl3_enabled"`
-	SoundAnalysisEnabled  bool    `json:"sound_analysis_enabled"`
-	CustomModelEnabled    bool    `json:"custom_model_enabled"`
-	MinSimilarityThreshold float64 `json:"min_similarity_threshold"`
-	ShowExplanations      bool    `json:"show_explanations"`
+	OpenL3Enabled          bool               `json:"openl3_enabled"`
+	SoundAnalysisEnabled   bool               `json:"sound_analysis_enabled"`
+	CustomModelEnabled     bool               `json:"custom_model_enabled"`
+	MinSimilarityThreshold float64            `json:"min_similarity_threshold"`
+	ShowExplanations       bool               `json:"show_explanations"`
+	SimilarityWeights      similarity.Weights `json:"similarity_weights"`
 }
 
 func (s *Server) handleGetMLSettings(w http.ResponseWriter, _ *http.Request) {
@@ -697,11 +2002,11 @@ func (s *Server) handleGetMLSettings(w http.ResponseWriter, _ *http.Request) {
 	}
 
 	response := MLSettingsResponse{
-		OpenL3Enabled:         settings["openl3_enabled"] == "true",
-		SoundAnalysisEnabled:  settings["sound_analysis_enabled"] == "true",
-		CustomModelEnabled:    settings["custom_model_enabled"] == "true",
+		OpenL3Enabled:          settings["openl3_enabled"] == "true",
+		SoundAnalysisEnabled:   settings["sound_analysis_enabled"] == "true",
+		CustomModelEnabled:     settings["custom_model_enabled"] == "true",
 		MinSimilarityThreshold: 0.5,
-		ShowExplanations:      settings["show_explanations"] != "false",
+		ShowExplanations:       settings["show_explanations"] != "false",
 	}
 
 	if threshold, ok := settings["min_similarity_threshold"]; ok {
@@ -709,17 +2014,22 @@ func (s *Server) handleGetMLSettings(w http.ResponseWriter, _ *http.Request) {
 			response.MinSimilarityThreshold = val
 		}
 	}
+	if response.SimilarityWeights, err = s.db.GetSimilarityWeights(); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get similarity weights: "+err.Error())
+		return
+	}
 
 	writeJSON(w, http.StatusOK, response)
 }
 
 // MLSettingsRequest is the JSON request for updating ML settings.
 type MLSettingsRequest struct {
-	OpenL3Enabled         *bool    `json:"openl3_enabled,omitempty"`
-	SoundAnalysisEnabled  *bool    `json:"sound_analysis_enabled,omitempty"`
-	CustomModelEnabled    *bool    `json:"custom_model_enabled,omitempty"`
-	MinSimilarityThreshold *float64 `json:"min_similarity_threshold,omitempty"`
-	ShowExplanations      *bool    `json:"show_explanations,omitempty"`
+	OpenL3Enabled          *bool               `json:"openl3_enabled,omitempty"`
+	SoundAnalysisEnabled   *bool               `json:"sound_analysis_enabled,omitempty"`
+	CustomModelEnabled     *bool               `json:"custom_model_enabled,omitempty"`
+	MinSimilarityThreshold *float64            `json:"min_similarity_threshold,omitempty"`
+	ShowExplanations       *bool               `json:"show_explanations,omitempty"`
+	SimilarityWeights      *similarity.Weights `json:"similarity_weights,omitempty"` // all zero restores the defaults
 }
 
 func (s *Server) handleUpdateMLSettings(w http.ResponseWriter, r *http.Request) {
@@ -728,6 +2038,12 @@ func (s *Server) handleUpdateMLSettings(w http.ResponseWriter, r *http.Request)
 		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
 		return
 	}
+	if req.SimilarityWeights != nil {
+		if _, err := req.SimilarityWeights.Normalized(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
 
 	if req.OpenL3Enabled != nil {
 		_ = s.db.SetMLSetting("openl3_enabled", fmt.Sprintf("%t", *req.OpenL3Enabled))
@@ -744,11 +2060,93 @@ func (s *Server) handleUpdateMLSettings(w http.ResponseWriter, r *http.Request)
 	if req.ShowExplanations != nil {
 		_ = s.db.SetMLSetting("show_explanations", fmt.Sprintf("%t", *req.ShowExplanations))
 	}
+	if req.SimilarityWeights != nil {
+		if err := s.db.SetSimilarityWeights(*req.SimilarityWeights); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to update similarity weights: "+err.Error())
+			return
+		}
+	}
 
 	// Return updated settings
 	s.handleGetMLSettings(w, r)
 }
 
+// SimilarityProfileResponse is the JSON form of a saved similarity profile.
+type SimilarityProfileResponse struct {
+	Name    string             `json:"name"`
+	Weights similarity.Weights `json:"weights"`
+}
+
+func (s *Server) handleListSimilarityProfiles(w http.ResponseWriter, _ *http.Request) {
+	profiles, err := s.db.ListSimilarityProfiles()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list similarity profiles: "+err.Error())
+		return
+	}
+	out := make([]SimilarityProfileResponse, len(profiles))
+	for i, p := range profiles {
+		out[i] = SimilarityProfileResponse{Name: p.Name, Weights: p.Weights}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"profiles": out})
+}
+
+func (s *Server) handleSaveSimilarityProfile(w http.ResponseWriter, r *http.Request) {
+	var weights similarity.Weights
+	if err := json.NewDecoder(r.Body).Decode(&weights); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	p, err := s.db.SaveSimilarityProfile(storage.SimilarityProfile{Name: r.PathValue("name"), Weights: weights})
+	if err != nil {
+		writeSimilarityWeightsError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, SimilarityProfileResponse{Name: p.Name, Weights: p.Weights})
+}
+
+func (s *Server) handleDeleteSimilarityProfile(w http.ResponseWriter, r *http.Request) {
+	if err := s.db.DeleteSimilarityProfile(r.PathValue("name")); err != nil {
+		writeSimilarityWeightsError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "profile deleted"})
+}
+
+// parseWeightsQuery reads similarity weights overrides given as vibe_weight,
+// tempo_weight, key_weight and energy_weight query parameters.
+func parseWeightsQuery(q url.Values) (similarity.Weights, error) {
+	var weights similarity.Weights
+	for _, p := range []struct {
+		name  string
+		value *float64
+	}{
+		{"vibe_weight", &weights.Vibe},
+		{"tempo_weight", &weights.Tempo},
+		{"key_weight", &weights.Key},
+		{"energy_weight", &weights.Energy},
+	} {
+		if v := q.Get(p.name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return similarity.Weights{}, fmt.Errorf("invalid %s: %v", p.name, err)
+			}
+			*p.value = f
+		}
+	}
+	return weights, nil
+}
+
+func writeSimilarityWeightsError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, "similarity profile not found")
+	case errors.Is(err, similarity.ErrInvalidWeights), errors.Is(err, storage.ErrInvalidSimilarityProfile):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "similarity weights failed: "+err.Error())
+	}
+}
+
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -1233,6 +2631,12 @@ func (s *Server) handleAudio(w http.ResponseWriter, r *http.Request) {
 	info, err := os.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
+			// Flag the track so the library health report and relocate pick it up.
+			if track, err := s.db.GetTrackByPath(path); err == nil {
+				if err := s.db.SetTrackMissing(track.ID, true); err != nil {
+					s.logger.Warn("failed to flag missing track", "path", path, "error", err)
+				}
+			}
 			writeError(w, http.StatusNotFound, "audio file not found")
 		} else {
 			writeError(w, http.StatusInternalServerError, "failed to access file: "+err.Error())
@@ -2,9 +2,20 @@ package httpapi
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	"github.com/cartomix/cancun/gen/go/engine"
+	"github.com/cartomix/cancun/internal/config"
+	"github.com/cartomix/cancun/internal/scanner"
+	"github.com/cartomix/cancun/internal/similarity"
+	"github.com/cartomix/cancun/internal/storage"
 )
 
 func TestHealthEndpoint(t *testing.T) {
@@ -149,6 +160,85 @@ func TestProposeSetRequestJSON(t *testing.T) {
 	}
 }
 
+func TestProposeSetConstraintsJSON(t *testing.T) {
+	body := `{"track_ids":["a","b","c","d"],"opener":"a","closer":"d","locked_chains":[["b","c"]],` +
+		`"precedences":[{"before":"b","after":"d"}],"artist_spacing":[{"artist_a":"X","artist_b":"X","min_tracks":2}]}`
+	var req ProposeSetRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	if req.Opener != "a" || req.Closer != "d" {
+		t.Errorf("expected opener a and closer d, got %q and %q", req.Opener, req.Closer)
+	}
+	if len(req.LockedChains) != 1 || len(req.LockedChains[0]) != 2 {
+		t.Errorf("expected one chain of 2, got %v", req.LockedChains)
+	}
+	if len(req.Precedences) != 1 || req.Precedences[0].Before != "b" || req.Precedences[0].After != "d" {
+		t.Errorf("unexpected precedences %+v", req.Precedences)
+	}
+	if len(req.ArtistSpacing) != 1 || req.ArtistSpacing[0].MinTracks != 2 {
+		t.Errorf("unexpected artist spacing %+v", req.ArtistSpacing)
+	}
+}
+
+func TestProposeSetRequestToProto(t *testing.T) {
+	body := `{"track_ids":["a","b","c"],"mode":"warm_up","opener":"a","locked_chains":[["b","c"]],` +
+		`"energy_curve":{"preset":"slow_burn"},"target_minutes":60}`
+	var req ProposeSetRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	planReq, err := req.toProto()
+	if err != nil {
+		t.Fatalf("toProto failed: %v", err)
+	}
+
+	if len(planReq.GetTrackIds()) != 3 || planReq.GetTrackIds()[2].GetContentHash() != "c" {
+		t.Errorf("unexpected track ids %v", planReq.GetTrackIds())
+	}
+	if planReq.GetMode() != engine.SetMode_WARM_UP {
+		t.Errorf("expected WARM_UP, got %v", planReq.GetMode())
+	}
+	if planReq.GetOpener().GetContentHash() != "a" || planReq.GetCloser() != nil {
+		t.Errorf("expected opener a and no closer, got %v and %v", planReq.GetOpener(), planReq.GetCloser())
+	}
+	if len(planReq.GetLockedChains()) != 1 || len(planReq.GetLockedChains()[0].GetTracks()) != 2 {
+		t.Errorf("expected one chain of 2, got %v", planReq.GetLockedChains())
+	}
+	if planReq.GetEnergyCurve().GetPreset() != "slow_burn" || planReq.GetTargetMinutes() != 60 {
+		t.Errorf("unexpected curve %v or target %v", planReq.GetEnergyCurve(), planReq.GetTargetMinutes())
+	}
+}
+
+func TestEnergyCurveRequestJSON(t *testing.T) {
+	body := `{"track_ids":["a"],"energy_curve":{"points":[{"position":0,"energy":3},{"minutes":60,"energy":8}]}}`
+	var req ProposeSetRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+
+	curve, err := req.EnergyCurve.toProto()
+	if err != nil {
+		t.Fatalf("toProto: %v", err)
+	}
+	if len(curve.GetPoints()) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(curve.GetPoints()))
+	}
+	if _, ok := curve.GetPoints()[0].GetAt().(*engine.EnergyPoint_Position); !ok {
+		t.Errorf("first point should be placed by position, got %T", curve.GetPoints()[0].GetAt())
+	}
+	if curve.GetPoints()[1].GetMinutes() != 60 {
+		t.Errorf("expected second point at 60 minutes, got %v", curve.GetPoints()[1].GetAt())
+	}
+
+	both := 0.5
+	req.EnergyCurve.Points[0].Minutes = &both
+	if _, err := req.EnergyCurve.toProto(); err == nil {
+		t.Error("expected an error for a point with both position and minutes")
+	}
+}
+
 func TestExportRequestJSON(t *testing.T) {
 	request := ExportRequest{
 		TrackIDs:     []string{"hash1", "hash2"},
@@ -174,3 +264,325 @@ func TestExportRequestJSON(t *testing.T) {
 		t.Errorf("expected 3 formats, got %d", len(decoded.Formats))
 	}
 }
+
+func TestLibraryRootEndpoints(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	db, err := storage.Open(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	srv := NewServer(&config.Config{}, logger, db, nil)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := do("GET", "/api/library/roots", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without watcher: status %d", rec.Code)
+	}
+	srv.SetWatcher(scanner.NewWatcher(db, logger, scanner.WatchConfig{}))
+
+	dir := t.TempDir()
+	rec := do("POST", "/api/library/roots", `{"path":"`+dir+`"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("add: status %d: %s", rec.Code, rec.Body)
+	}
+	var added LibraryRootResponse
+	if err := json.NewDecoder(rec.Body).Decode(&added); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if added.Path != dir || added.Watching || added.LastScannedAt != nil {
+		t.Errorf("added root = %+v", added)
+	}
+
+	if rec := do("POST", "/api/library/roots", `{"path":"`+dir+`/missing"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("missing path: status %d", rec.Code)
+	}
+
+	rec = do("GET", "/api/library/roots", "")
+	var roots []LibraryRootResponse
+	if err := json.NewDecoder(rec.Body).Decode(&roots); err != nil || len(roots) != 1 {
+		t.Fatalf("list = %v, err %v", roots, err)
+	}
+
+	id := strconv.FormatInt(added.ID, 10)
+	if rec := do("DELETE", "/api/library/roots/"+id, ""); rec.Code != http.StatusOK {
+		t.Fatalf("remove: status %d", rec.Code)
+	}
+	if rec := do("DELETE", "/api/library/roots/"+id, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("remove twice: status %d", rec.Code)
+	}
+}
+
+func TestSavedSetEndpoints(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	db, err := storage.Open(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	for _, hash := range []string{"a", "b"} {
+		if _, err := db.UpsertTrack(&storage.Track{ContentHash: hash, Path: "/music/" + hash + ".mp3"}); err != nil {
+			t.Fatalf("add track: %v", err)
+		}
+	}
+
+	srv := NewServer(&config.Config{}, logger, db, nil)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) *engine.SavedSet {
+		t.Helper()
+		var set engine.SavedSet
+		if err := json.NewDecoder(rec.Body).Decode(&set); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return &set
+	}
+
+	rec := do("POST", "/api/sets", `{"name":"Friday","track_ids":["a","b"],`+
+		`"explanations":[{"from":{"content_hash":"a"},"to":{"content_hash":"b"},"suggestion":{"out_beat":385}}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", rec.Code, rec.Body)
+	}
+	created := decode(rec)
+	if created.GetVersion() != 1 || len(created.GetTrackIds()) != 2 ||
+		created.GetExplanations()[0].GetSuggestion().GetOutBeat() != 385 {
+		t.Errorf("created = %v", created)
+	}
+
+	id := strconv.FormatInt(created.GetSetId(), 10)
+	if rec := do("PUT", "/api/sets/"+id, `{"name":"Friday","track_ids":["b","a"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("update: status %d: %s", rec.Code, rec.Body)
+	}
+	if got := decode(do("GET", "/api/sets/"+id+"?version=1", "")); got.GetTrackIds()[0].GetContentHash() != "a" {
+		t.Errorf("version 1 = %v", got)
+	}
+	if got := decode(do("GET", "/api/sets/"+id, "")); got.GetVersion() != 2 || got.GetTrackIds()[0].GetContentHash() != "b" {
+		t.Errorf("latest = %v", got)
+	}
+
+	if rec := do("POST", "/api/sets", `{"name":"x","track_ids":["missing"]}`); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown track: status %d", rec.Code)
+	}
+	if rec := do("POST", "/api/sets", `{"track_ids":["a"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("no name: status %d", rec.Code)
+	}
+
+	// A re-keyed track keeps its place in saved sets, which still export.
+	a, _ := db.GetTrackByHash("a")
+	for _, hash := range []string{"a", "b"} {
+		track, _ := db.GetTrackByHash(hash)
+		if err := db.UpsertAnalysis(&storage.AnalysisRecord{TrackID: track.ID, Version: 1, Status: storage.AnalysisStatusComplete}); err != nil {
+			t.Fatalf("add analysis: %v", err)
+		}
+	}
+	if err := db.RekeyTrack(a.ID, "a2", "", 2); err != nil {
+		t.Fatalf("rekey: %v", err)
+	}
+	first := decode(do("GET", "/api/sets/"+id+"?version=1", ""))
+	if first.GetTrackIds()[0].GetContentHash() != "a2" || first.GetExplanations()[0].GetFrom().GetContentHash() != "a2" {
+		t.Errorf("version 1 after rekey = %v", first)
+	}
+	rec = do("POST", "/api/export", `{"set_id":`+id+`,"output_dir":"`+t.TempDir()+`"}`)
+	if rec.Code != http.StatusOK {
+		t.Errorf("export after rekey: status %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := do("DELETE", "/api/sets/"+id, ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete: status %d", rec.Code)
+	}
+	if rec := do("GET", "/api/sets/"+id+"/versions", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("versions after delete: status %d", rec.Code)
+	}
+}
+
+func TestSimilarityProfileEndpoints(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	db, err := storage.Open(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	srv := NewServer(&config.Config{}, logger, db, nil)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := do("PUT", "/api/ml/profiles/deep", `{"vibe":3,"energy":1}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"vibe":0.75`) {
+		t.Fatalf("save: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do("PUT", "/api/ml/profiles/bad", `{"key":-1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("negative weight: status %d", rec.Code)
+	}
+	var listed struct {
+		Profiles []SimilarityProfileResponse `json:"profiles"`
+	}
+	if err := json.NewDecoder(do("GET", "/api/ml/profiles", "").Body).Decode(&listed); err != nil || len(listed.Profiles) != 3 {
+		t.Errorf("profiles = %+v, %v", listed.Profiles, err)
+	}
+
+	rec = do("PUT", "/api/ml/settings", `{"similarity_weights":{"key":1}}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"similarity_weights":{"vibe":0,"tempo":0,"key":1,"energy":0}`) {
+		t.Errorf("settings: status %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := do("DELETE", "/api/ml/profiles/deep", ""); rec.Code != http.StatusOK {
+		t.Errorf("delete: status %d", rec.Code)
+	}
+	if rec := do("DELETE", "/api/ml/profiles/deep", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("delete again: status %d", rec.Code)
+	}
+}
+
+func TestSimilarToSeedsEndpoint(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	db, err := storage.Open(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	// Tracks a, b and c sound like nothing else; ab sounds like a and b, ac
+	// like a and c. Track bare was never analyzed.
+	for hash, axes := range map[string][]int{"a": {0}, "b": {1}, "c": {2}, "ab": {0, 1}, "ac": {0, 2}, "bare": nil} {
+		id, err := db.UpsertTrack(&storage.Track{ContentHash: hash, Path: "/music/" + hash + ".mp3"})
+		if err != nil {
+			t.Fatalf("add track: %v", err)
+		}
+		if axes == nil {
+			continue
+		}
+		vec := make([]float32, similarity.EmbeddingDim)
+		for _, axis := range axes {
+			vec[axis] = 1
+		}
+		rec := &storage.AnalysisRecord{TrackID: id, Version: 1, Status: storage.AnalysisStatusComplete,
+			BPM: 124, KeyValue: "8A", EnergyGlobal: 6, OpenL3Embedding: similarity.FloatsToBytes(vec)}
+		if err := db.UpsertAnalysis(rec); err != nil {
+			t.Fatalf("add analysis: %v", err)
+		}
+	}
+
+	srv := NewServer(&config.Config{}, logger, db, nil)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) SimilarToSeedsResponse {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		var resp SimilarToSeedsResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	got := decode(do("GET", "/api/tracks/similar?like=a,b", ""))
+	if len(got.Like) != 2 || got.Aggregation != similarity.AggregateCentroid || got.Similar[0].ContentHash != "ab" {
+		t.Errorf("like a and b = %+v", got)
+	}
+	got = decode(do("POST", "/api/tracks/similar", `{"like":["a"],"unlike":["c"],"aggregation":"max"}`))
+	if last := got.Similar[len(got.Similar)-1]; last.ContentHash != "ac" || last.Seed != "a" {
+		t.Errorf("like a, unlike c = %+v, want ac last", got.Similar)
+	}
+
+	if rec := do("GET", "/api/tracks/similar", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("no seeds: status %d", rec.Code)
+	}
+	if rec := do("GET", "/api/tracks/similar?like=a&aggregation=median", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown aggregation: status %d", rec.Code)
+	}
+	if rec := do("GET", "/api/tracks/similar?like=a,missing", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown seed: status %d", rec.Code)
+	}
+	if rec := do("POST", "/api/tracks/similar", `{"like":["bare"]}`); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("seed without embedding: status %d", rec.Code)
+	}
+}
+
+func TestSimilarSectionsQuery(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	db, err := storage.Open(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	axis := func(i int) []float32 {
+		v := make([]float32, similarity.EmbeddingDim)
+		v[i] = 1
+		return v
+	}
+	// Each track is an 8 second intro then an 8 second outro at 120 BPM,
+	// windowed every 4 seconds. Track a's outro sounds like b's intro and c's outro.
+	add := func(hash string, intro, outro int) {
+		t.Helper()
+		id, err := db.UpsertTrack(&storage.Track{ContentHash: hash, Path: "/music/" + hash + ".mp3"})
+		if err != nil {
+			t.Fatalf("add track: %v", err)
+		}
+		rec, err := storage.AnalysisRecordFromProto(id, 1, &common.TrackAnalysis{
+			DurationSeconds: 16,
+			Beatgrid:        &common.Beatgrid{Beats: []*common.BeatMarker{{Index: 0}}, TempoMap: []*common.TempoMapNode{{Bpm: 120}}},
+			Sections: []*common.Section{
+				{StartBeat: 0, EndBeat: 16, Label: common.SectionLabel_INTRO},
+				{StartBeat: 16, EndBeat: 32, Label: common.SectionLabel_OUTRO},
+			},
+			Openl3Embedding: &common.OpenL3Embedding{Vector: axis(3)},
+		})
+		if err != nil {
+			t.Fatalf("record: %v", err)
+		}
+		if err := db.UpsertAnalysis(rec); err != nil {
+			t.Fatalf("add analysis: %v", err)
+		}
+		var windows []similarity.Window
+		for i, emb := range [][]float32{axis(intro), axis(intro), axis(outro), axis(outro)} {
+			windows = append(windows, similarity.Window{Index: i, StartSeconds: float64(4 * i), DurationSeconds: 4, Embedding: emb})
+		}
+		if err := db.SaveOpenL3Windows(id, 1, windows); err != nil {
+			t.Fatalf("save windows: %v", err)
+		}
+	}
+	add("a", 1, 0)
+	add("b", 0, 2)
+	add("c", 2, 0)
+
+	srv := NewServer(&config.Config{}, logger, db, nil)
+	do := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	rec := do("/api/tracks/a/similar?section=outro&match_section=intro")
+	var got SimilarTracksResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status %d, %v", rec.Code, err)
+	}
+	if got.Section == nil || got.Section.Label != "outro" || got.Section.StartSeconds != 8 {
+		t.Errorf("query section = %+v", got.Section)
+	}
+	if len(got.Similar) != 2 || got.Similar[0].ContentHash != "b" || got.Similar[0].Section.Label != "intro" || got.Similar[0].Section.EndSeconds != 8 {
+		t.Errorf("intros like a's outro = %+v", got.Similar)
+	}
+
+	if rec := do("/api/tracks/a/similar?section=chorus"); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown section: status %d", rec.Code)
+	}
+	if rec := do("/api/tracks/a/similar?section=drop"); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("missing section: status %d", rec.Code)
+	}
+}
@@ -0,0 +1,213 @@
+// Package live follows a set while it is played. It records which track is
+// playing now and which have played, replans the rest of the set from the
+// track playing now, and pushes every new plan to whoever is watching.
+package live
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	eng "github.com/cartomix/cancun/gen/go/engine"
+	"github.com/cartomix/cancun/internal/storage"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrInvalidRequest is returned for a plan request a session can't start from.
+	ErrInvalidRequest = errors.New("invalid live session request")
+	// ErrTrackNotFound is returned for a track that isn't in the library.
+	ErrTrackNotFound = errors.New("track not found")
+	// ErrNoAnalysis is returned when a track in the pool hasn't been analyzed.
+	ErrNoAnalysis = errors.New("missing analysis")
+)
+
+// Manager runs live sessions. It is shared by the gRPC and HTTP APIs, so a
+// play recorded through either reaches the watchers of both.
+type Manager struct {
+	db     *storage.DB
+	logger *slog.Logger
+	now    func() time.Time
+
+	replan   sync.Mutex // keeps plans from going out older than the plays they follow
+	mu       sync.Mutex
+	latest   map[int64]*eng.LiveSession // last plan sent for each session
+	watchers map[int64]map[chan *eng.LiveSession]bool
+}
+
+// NewManager creates a live session manager.
+func NewManager(db *storage.DB, logger *slog.Logger) *Manager {
+	return &Manager{
+		db:       db,
+		logger:   logger,
+		now:      time.Now,
+		latest:   make(map[int64]*eng.LiveSession),
+		watchers: make(map[int64]map[chan *eng.LiveSession]bool),
+	}
+}
+
+// Start plans the request as ProposeSet would and opens a session for it.
+// Requests that can't be planned are rejected without opening one.
+func (m *Manager) Start(req *eng.SetPlanRequest) (*eng.LiveSession, error) {
+	if len(req.GetTrackIds()) == 0 {
+		return nil, fmt.Errorf("%w: track_ids are required", ErrInvalidRequest)
+	}
+	view, err := m.plan(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := m.db.CreateLiveSession(data, m.now())
+	if err != nil {
+		return nil, err
+	}
+	fill(view, stored)
+
+	m.mu.Lock()
+	m.latest[stored.ID] = view
+	m.mu.Unlock()
+	return view, nil
+}
+
+// Get returns a session with the rest of its set as last planned. It returns
+// sql.ErrNoRows when there is no such session.
+func (m *Manager) Get(id int64) (*eng.LiveSession, error) {
+	m.mu.Lock()
+	view, ok := m.latest[id]
+	m.mu.Unlock()
+	if ok {
+		return view, nil
+	}
+	return m.refresh(id)
+}
+
+// Play records that track started playing at at, or now when at is zero, and
+// replans the rest of the set from it. The track doesn't have to be in the
+// session's pool: whatever the DJ plays is what the set continues from.
+func (m *Manager) Play(id int64, track *common.TrackId, at time.Time) (*eng.LiveSession, error) {
+	t, err := m.db.ResolveTrack(track)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTrackNotFound, track.GetContentHash())
+	}
+	if at.IsZero() {
+		at = m.now()
+	}
+	if err := m.db.AddLivePlay(id, t.ContentHash, at); err != nil {
+		return nil, err
+	}
+	return m.refresh(id)
+}
+
+// End ends a session. Its watchers get the ended session and are closed.
+func (m *Manager) End(id int64) (*eng.LiveSession, error) {
+	if err := m.db.EndLiveSession(id, m.now()); err != nil {
+		return nil, err
+	}
+	return m.refresh(id)
+}
+
+// Watch returns a channel that gets the session now and again each time it is
+// replanned, and a function to stop watching. The channel is closed when the
+// session ends. A watcher that falls behind only gets the latest plan.
+func (m *Manager) Watch(id int64) (<-chan *eng.LiveSession, func(), error) {
+	view, err := m.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch := make(chan *eng.LiveSession, 1)
+	ch <- view
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if latest, ok := m.latest[id]; ok && latest != view {
+		// Replanned while this watcher was being set up.
+		<-ch
+		ch <- latest
+		view = latest
+	}
+	if view.GetEndedAt() != 0 {
+		close(ch)
+		return ch, func() {}, nil
+	}
+	if m.watchers[id] == nil {
+		m.watchers[id] = make(map[chan *eng.LiveSession]bool)
+	}
+	m.watchers[id][ch] = true
+	stop := func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.watchers[id][ch] {
+			delete(m.watchers[id], ch)
+			close(ch)
+		}
+	}
+	return ch, stop, nil
+}
+
+// refresh replans a session from what is stored and sends the plan to its
+// watchers.
+func (m *Manager) refresh(id int64) (*eng.LiveSession, error) {
+	m.replan.Lock()
+	defer m.replan.Unlock()
+	stored, err := m.db.GetLiveSession(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var view *eng.LiveSession
+	if stored.Ended() {
+		view = &eng.LiveSession{Note: "the set has ended"}
+	} else {
+		req := &eng.SetPlanRequest{}
+		if err := proto.Unmarshal(stored.Request, req); err != nil {
+			return nil, fmt.Errorf("live session %d request: %w", stored.ID, err)
+		}
+		if view, err = m.plan(req, stored.Plays); err != nil {
+			return nil, err
+		}
+	}
+	fill(view, stored)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latest[stored.ID] = view
+	for ch := range m.watchers[stored.ID] {
+		select {
+		case <-ch: // drop the plan it hasn't read; this one replaces it
+		default:
+		}
+		ch <- view
+		if stored.Ended() {
+			close(ch)
+		}
+	}
+	if stored.Ended() {
+		delete(m.watchers, stored.ID)
+	}
+	return view, nil
+}
+
+// fill copies what was stored about a session into its plan.
+func fill(view *eng.LiveSession, stored *storage.LiveSession) {
+	view.SessionId = stored.ID
+	view.StartedAt = stored.StartedAt.Unix()
+	if stored.Ended() {
+		view.EndedAt = stored.EndedAt.Unix()
+	}
+	view.Played = nil
+	for _, p := range stored.Plays {
+		view.Played = append(view.Played, &eng.LivePlay{
+			Id:        &common.TrackId{ContentHash: p.ContentHash},
+			StartedAt: p.StartedAt.Unix(),
+		})
+	}
+	if n := len(view.Played); n > 0 {
+		view.NowPlaying = view.Played[n-1]
+	}
+}
@@ -0,0 +1,192 @@
+package live
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	eng "github.com/cartomix/cancun/gen/go/engine"
+	"github.com/cartomix/cancun/internal/storage"
+)
+
+// newTestManager opens a library of n analyzed five-minute tracks, t0 to t(n-1).
+func newTestManager(t *testing.T, n int) *Manager {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	dir := t.TempDir()
+	db, err := storage.Open(dir, logger)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for i := range n {
+		hash := fmt.Sprintf("t%d", i)
+		id, err := db.UpsertTrack(&storage.Track{ContentHash: hash, Path: dir + "/" + hash + ".wav", FileModifiedAt: time.Now()})
+		if err != nil {
+			t.Fatalf("upsert track: %v", err)
+		}
+		record, err := storage.AnalysisRecordFromProto(id, 1, &common.TrackAnalysis{
+			Id:              &common.TrackId{ContentHash: hash},
+			DurationSeconds: 300,
+			Beatgrid:        &common.Beatgrid{TempoMap: []*common.TempoMapNode{{Bpm: 120 + float64(i)}}},
+			Key:             &common.MusicalKey{Value: fmt.Sprintf("%dA", i%12+1), Format: common.KeyFormat_CAMELOT},
+			EnergyGlobal:    int32(i%10 + 1),
+		})
+		if err != nil {
+			t.Fatalf("record: %v", err)
+		}
+		if err := db.UpsertAnalysis(record); err != nil {
+			t.Fatalf("upsert analysis: %v", err)
+		}
+	}
+	return NewManager(db, logger)
+}
+
+func ids(hashes ...string) []*common.TrackId {
+	out := make([]*common.TrackId, len(hashes))
+	for i, h := range hashes {
+		out[i] = &common.TrackId{ContentHash: h}
+	}
+	return out
+}
+
+func hashes(ids []*common.TrackId) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.GetContentHash()
+	}
+	return out
+}
+
+func TestReplanFromNowPlaying(t *testing.T) {
+	m := newTestManager(t, 8)
+	start := time.Date(2026, 5, 1, 23, 0, 0, 0, time.UTC)
+
+	session, err := m.Start(&eng.SetPlanRequest{TrackIds: ids("t0", "t1", "t2", "t3", "t4", "t5")})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if len(session.GetNextUp()) != 6 || session.GetNowPlaying() != nil {
+		t.Fatalf("new session plans %v, now playing %v", hashes(session.GetNextUp()), session.GetNowPlaying())
+	}
+
+	watch, stop, err := m.Watch(session.GetSessionId())
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	defer stop()
+	<-watch
+
+	// Off-plan: t3 first, then t7, which isn't in the pool at all.
+	if _, err := m.Play(session.GetSessionId(), &common.TrackId{ContentHash: "t3"}, start); err != nil {
+		t.Fatalf("play t3: %v", err)
+	}
+	got, err := m.Play(session.GetSessionId(), &common.TrackId{ContentHash: "t7"}, start.Add(4*time.Minute))
+	if err != nil {
+		t.Fatalf("play t7: %v", err)
+	}
+	next := hashes(got.GetNextUp())
+	if len(next) != 5 || slices.Contains(next, "t3") || slices.Contains(next, "t7") {
+		t.Errorf("next up = %v, want the 5 unplayed pool tracks", next)
+	}
+	if got.GetNowPlaying().GetId().GetContentHash() != "t7" || len(got.GetPlayed()) != 2 || got.GetElapsedSeconds() != 240 {
+		t.Errorf("now playing %v after %v, %.0fs in", got.GetNowPlaying(), got.GetPlayed(), got.GetElapsedSeconds())
+	}
+	if from := got.GetExplanations()[0].GetFrom().GetContentHash(); from != "t7" {
+		t.Errorf("first transition from %s, want t7", from)
+	}
+
+	if update := <-watch; update.GetNowPlaying().GetId().GetContentHash() != "t7" {
+		t.Errorf("watcher got %v, want the latest plan only", update.GetNowPlaying())
+	}
+
+	if _, err := m.End(session.GetSessionId()); err != nil {
+		t.Fatalf("end: %v", err)
+	}
+	if update, ok := <-watch; !ok || update.GetEndedAt() == 0 || len(update.GetNextUp()) != 0 {
+		t.Errorf("watcher got %v on end, want the ended session", update)
+	}
+	if _, ok := <-watch; ok {
+		t.Error("watch channel still open after the session ended")
+	}
+	if _, err := m.Play(session.GetSessionId(), &common.TrackId{ContentHash: "t0"}, time.Time{}); !errors.Is(err, storage.ErrLiveSessionEnded) {
+		t.Errorf("play after end: err = %v, want ErrLiveSessionEnded", err)
+	}
+}
+
+func TestReplanKeepsRemainingTime(t *testing.T) {
+	m := newTestManager(t, 12)
+	start := time.Date(2026, 5, 1, 23, 0, 0, 0, time.UTC)
+	session, err := m.Start(&eng.SetPlanRequest{
+		TrackIds:      ids("t0", "t1", "t2", "t3", "t4", "t5", "t6", "t7", "t8", "t9", "t10", "t11"),
+		TargetMinutes: 30,
+		Closer:        &common.TrackId{ContentHash: "t5"},
+		EnergyCurve:   &eng.EnergyCurve{Preset: "slow_burn"},
+	})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	id := session.GetSessionId()
+
+	if _, err := m.Play(id, &common.TrackId{ContentHash: "t0"}, start); err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	got, err := m.Play(id, &common.TrackId{ContentHash: "t1"}, start.Add(15*time.Minute))
+	if err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	if got.GetEstimatedSeconds() > 16.5*60 || got.GetEstimatedSeconds() < 13.5*60 {
+		t.Errorf("rest of the set runs %.0fs, want about the 15 minutes left", got.GetEstimatedSeconds())
+	}
+	if next := hashes(got.GetNextUp()); len(next) == 0 || next[len(next)-1] != "t5" {
+		t.Errorf("next up = %v, want it to close with t5", next)
+	}
+
+	late, err := m.Play(id, &common.TrackId{ContentHash: "t2"}, start.Add(31*time.Minute))
+	if err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	if len(late.GetNextUp()) != 0 || late.GetNote() == "" {
+		t.Errorf("past the target, next up = %v with note %q", hashes(late.GetNextUp()), late.GetNote())
+	}
+}
+
+func TestStartRejectsUnplannableRequests(t *testing.T) {
+	m := newTestManager(t, 3)
+	for name, req := range map[string]*eng.SetPlanRequest{
+		"no tracks":     {},
+		"bad curve":     {TrackIds: ids("t0", "t1"), EnergyCurve: &eng.EnergyCurve{Preset: "nope"}},
+		"bad key move":  {TrackIds: ids("t0", "t1"), KeyWeights: map[string]float64{"sideways": 1}},
+		"bad chain":     {TrackIds: ids("t0", "t1"), LockedChains: []*eng.LockedChain{{Tracks: ids("t0", "t2")}}},
+		"unknown track": {TrackIds: ids("t0", "nope")},
+	} {
+		_, err := m.Start(req)
+		want := ErrInvalidRequest
+		if name == "unknown track" {
+			want = ErrTrackNotFound
+		}
+		if !errors.Is(err, want) {
+			t.Errorf("%s: err = %v, want %v", name, err, want)
+		}
+	}
+}
+
+func TestRemainingChains(t *testing.T) {
+	chains := []*eng.LockedChain{
+		{Tracks: ids("a", "b", "c", "d")},
+		{Tracks: ids("e", "f")},
+		{Tracks: ids("g", "h", "i")},
+	}
+	played := map[string]bool{"b": true, "f": true, "h": true}
+	got := remainingChains(chains, played, "h")
+	want := [][]string{{"c", "d"}, {"h", "i"}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("remaining chains = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,262 @@
+package live
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	eng "github.com/cartomix/cancun/gen/go/engine"
+	"github.com/cartomix/cancun/internal/planner"
+	"github.com/cartomix/cancun/internal/storage"
+)
+
+// pool is the analyzed tracks a session plans from, in request order.
+type pool struct {
+	analyses   []*common.TrackAnalysis
+	mix        map[string]planner.MixEmbeddings
+	artists    map[string]string
+	setSeconds float64 // running time of the whole pool, played tracks included
+}
+
+// plan plans the rest of the set after plays, the last of which is playing
+// now. Before the first play it plans the whole request, and any request it
+// can't plan is an error. Once the set is running, constraints or a length the
+// rest can't meet leave next_up empty with a note saying why.
+func (m *Manager) plan(req *eng.SetPlanRequest, plays []storage.LivePlay) (*eng.LiveSession, error) {
+	started := len(plays) > 0
+	played := make(map[string]bool, len(plays))
+	for _, p := range plays {
+		played[p.ContentHash] = true
+	}
+	view := &eng.LiveSession{}
+	var current string
+	if started {
+		current = plays[len(plays)-1].ContentHash
+		view.ElapsedSeconds = plays[len(plays)-1].StartedAt.Sub(plays[0].StartedAt).Seconds()
+	}
+
+	p, err := m.load(req.GetTrackIds(), !started)
+	if err != nil {
+		return nil, err
+	}
+	var analyses []*common.TrackAnalysis
+	if current != "" {
+		if a := m.nowPlaying(p, current); a != nil {
+			analyses = append(analyses, a)
+		} else {
+			current = "" // plan the rest without it
+		}
+	}
+	for _, a := range p.analyses {
+		if !played[a.GetId().GetContentHash()] {
+			analyses = append(analyses, a)
+		}
+	}
+	if len(analyses) == 0 || (current != "" && len(analyses) == 1) {
+		view.Note = "every track in the pool has played"
+		return view, nil
+	}
+
+	opts, err := options(req)
+	if err != nil {
+		return nil, err
+	}
+	opts.MixEmbeddings, opts.Artists = p.mix, p.artists
+	remaining := make(map[string]bool, len(analyses))
+	for _, a := range analyses {
+		remaining[a.GetId().GetContentHash()] = true
+	}
+	opts.MustPlayHashes = make(map[string]bool)
+	for _, id := range req.GetMustPlay() {
+		if h := id.GetContentHash(); remaining[h] && h != current {
+			opts.MustPlayHashes[h] = true
+		}
+	}
+	if started {
+		opts.Opener = current
+		delete(opts.BanHashes, current) // it's playing regardless
+	}
+	if played[opts.Closer] {
+		opts.Closer = ""
+	}
+	opts.LockedChains = remainingChains(req.GetLockedChains(), played, current)
+	for _, pr := range req.GetPrecedences() {
+		before, after := pr.GetBefore().GetContentHash(), pr.GetAfter().GetContentHash()
+		if !played[before] && !played[after] {
+			opts.Precedences = append(opts.Precedences, planner.Precedence{Before: before, After: after})
+		}
+	}
+
+	if target := req.GetTargetMinutes() * 60; target > 0 {
+		left := target - view.ElapsedSeconds
+		if left <= 0 {
+			view.Note = fmt.Sprintf("the set has run its %.0f minutes", req.GetTargetMinutes())
+			return view, nil
+		}
+		opts.TargetLength = time.Duration(left * float64(time.Second))
+		p.setSeconds = target
+	}
+	if req.GetEnergyCurve() != nil && started {
+		// Keep to the part of the arc that's still ahead.
+		if opts.EnergyCurve, err = planner.RemainingCurve(req.GetEnergyCurve(), p.setSeconds, view.ElapsedSeconds); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+	}
+
+	result, err := planner.Optimize(analyses, opts)
+	if errors.Is(err, planner.ErrInvalidCurve) || errors.Is(err, planner.ErrSetLength) || errors.Is(err, planner.ErrConstraints) {
+		if !started {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+		view.Note = err.Error()
+		return view, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	view.NextUp = result.Order
+	if current != "" {
+		view.NextUp = result.Order[1:]
+	}
+	view.Explanations = result.Explanations
+	view.EstimatedSeconds = result.EstimatedSeconds
+	return view, nil
+}
+
+// load resolves and loads the analyzed tracks of a session's pool. When strict,
+// a track that can't be found or hasn't been analyzed is an error; otherwise,
+// as when the library changes mid-set, it's left out of the plan.
+func (m *Manager) load(ids []*common.TrackId, strict bool) (*pool, error) {
+	p := &pool{mix: make(map[string]planner.MixEmbeddings), artists: make(map[string]string)}
+	for _, id := range ids {
+		a, track, err := m.analysis(id)
+		if err != nil {
+			if strict {
+				return nil, err
+			}
+			m.logger.Warn("live session track left out of the plan", "track", id.GetContentHash(), "error", err)
+			continue
+		}
+		p.analyses = append(p.analyses, a)
+		p.setSeconds += a.GetDurationSeconds()
+		if err := m.addDetails(p, track, a); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// nowPlaying returns the analysis of the track playing now, loading it when it
+// isn't in the pool, or nil when it hasn't been analyzed.
+func (m *Manager) nowPlaying(p *pool, hash string) *common.TrackAnalysis {
+	for _, a := range p.analyses {
+		if a.GetId().GetContentHash() == hash {
+			return a
+		}
+	}
+	a, track, err := m.analysis(&common.TrackId{ContentHash: hash})
+	if err != nil {
+		m.logger.Warn("live session planned without the track playing now", "track", hash, "error", err)
+		return nil
+	}
+	if err := m.addDetails(p, track, a); err != nil {
+		m.logger.Warn("live session planned without the track playing now", "track", hash, "error", err)
+		return nil
+	}
+	return a
+}
+
+func (m *Manager) analysis(id *common.TrackId) (*common.TrackAnalysis, *storage.Track, error) {
+	track, err := m.db.ResolveTrack(id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrTrackNotFound, id.GetContentHash())
+	}
+	a, err := m.db.LatestCompleteAnalysis(track.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w for %s", ErrNoAnalysis, track.Path)
+	}
+	return a, track, nil
+}
+
+// addDetails records what the planner needs besides the analysis: windowed
+// vibes and the artist.
+func (m *Manager) addDetails(p *pool, track *storage.Track, a *common.TrackAnalysis) error {
+	windows, err := m.db.GetOpenL3Windows(track.ID)
+	if err != nil {
+		return fmt.Errorf("openl3 window lookup failed: %w", err)
+	}
+	if len(windows) > 0 {
+		p.mix[track.ContentHash] = planner.MixEmbeddingsFromWindows(windows, a.GetDurationSeconds())
+	}
+	p.artists[track.ContentHash] = track.Artist
+	return nil
+}
+
+// options reads the parts of a plan request that don't change as the set is
+// played.
+func options(req *eng.SetPlanRequest) (planner.Options, error) {
+	opts := planner.Options{
+		Mode:            req.GetMode(),
+		AllowKeyJumps:   req.GetAllowKeyJumps(),
+		MaxBpmStep:      req.GetMaxBpmStep(),
+		BanHashes:       make(map[string]bool),
+		TimeBudget:      time.Duration(req.GetTimeBudgetMs()) * time.Millisecond,
+		EnergyCurve:     req.GetEnergyCurve(),
+		LengthTolerance: time.Duration(req.GetToleranceMinutes() * float64(time.Minute)),
+		PlayFraction:    req.GetPlayFraction(),
+		PitchRange:      req.GetPitchRange() / 100,
+		MasterTempo:     req.GetMasterTempo(),
+		VibeWeight:      req.GetVibeWeight(),
+		Opener:          req.GetOpener().GetContentHash(),
+		Closer:          req.GetCloser().GetContentHash(),
+	}
+	for _, id := range req.GetBan() {
+		opts.BanHashes[id.GetContentHash()] = true
+	}
+	for _, a := range req.GetArtistSpacing() {
+		opts.ArtistSpacing = append(opts.ArtistSpacing, planner.ArtistSpacing{
+			ArtistA:   a.GetArtistA(),
+			ArtistB:   a.GetArtistB(),
+			MinTracks: int(a.GetMinTracks()),
+		})
+	}
+	if len(req.GetKeyWeights()) > 0 {
+		weights, err := planner.DefaultKeyWeights.Override(req.GetKeyWeights())
+		if err != nil {
+			return opts, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+		opts.KeyWeights = weights
+	}
+	return opts, nil
+}
+
+// remainingChains splits the locked chains around the tracks already played, so
+// what's left of each still plays back to back. A chain that holds the track
+// playing now continues from it.
+func remainingChains(chains []*eng.LockedChain, played map[string]bool, current string) [][]string {
+	var out [][]string
+	for _, chain := range chains {
+		var run []string
+		flush := func() {
+			if len(run) > 1 {
+				out = append(out, run)
+			}
+			run = nil
+		}
+		for _, id := range chain.GetTracks() {
+			switch h := id.GetContentHash(); {
+			case h == current:
+				flush()
+				run = []string{h}
+			case played[h]:
+				flush()
+			default:
+				run = append(run, h)
+			}
+		}
+		flush()
+	}
+	return out
+}
@@ -0,0 +1,230 @@
+package planner
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	eng "github.com/cartomix/cancun/gen/go/engine"
+)
+
+// MaxAlternatives caps Options.Alternatives.
+const MaxAlternatives = 10
+
+// DefaultAlternativeDifference is the share of a set's transitions an
+// alternative must not share with any earlier plan, when
+// Options.MinDifferentEdges is zero.
+const DefaultAlternativeDifference = 0.25
+
+// alternativeAttempts is how many times each alternative is searched for,
+// doubling the penalty on known transitions each time, before giving up.
+const alternativeAttempts = 3
+
+// alternatives searches for up to opts.Alternatives more plans. Every edge an
+// earlier plan makes is penalized so the search steers away from it; a plan
+// that still makes too few new transitions is searched for again with a
+// steeper penalty. With DifferentOpeners, each plan opens with the best ranked
+// track no earlier plan opened with.
+func (p *planning) alternatives(score [][]float64, best []int, bestResult *Result) []*eng.AlternativePlan {
+	need := p.opts.MinDifferentEdges
+	if need <= 0 {
+		need = max(1, int(math.Round(DefaultAlternativeDifference*float64(len(best)-1))))
+	}
+
+	plans := [][]int{best}
+	openers := make([]bool, len(p.tracks))
+	openers[best[0]] = true
+	ranked := p.rankOpeners()
+	penalty := meanAbs(score)
+	var found []*eng.AlternativePlan
+	for len(found) < min(p.opts.Alternatives, MaxAlternatives) {
+		opener := -1
+		if p.opts.DifferentOpeners {
+			if opener = nextOpener(ranked, openers); opener < 0 {
+				break
+			}
+		} else if p.points == nil || (p.cons != nil && p.cons.opener >= 0) {
+			opener = best[0]
+		}
+
+		var path []int
+		for attempt := range alternativeAttempts {
+			penalized := penalize(score, plans, penalty*math.Pow(2, float64(attempt)))
+			candidate, err := p.search(penalized, opener)
+			if err == nil && differs(candidate, plans, need) {
+				path = candidate
+				break
+			}
+		}
+		if path == nil {
+			break
+		}
+		plans = append(plans, path)
+		openers[path[0]] = true
+
+		result := p.result(path)
+		found = append(found, &eng.AlternativePlan{
+			Order:            result.Order,
+			Explanations:     result.Explanations,
+			TotalScore:       result.TotalScore,
+			WeakestEdges:     result.WeakestEdges,
+			EnergySlots:      result.EnergySlots,
+			EnergyError:      float32(result.EnergyError),
+			EstimatedSeconds: result.EstimatedSeconds,
+			Diff:             p.diff(best, bestResult, path, result),
+		})
+	}
+	slices.SortStableFunc(found, func(a, b *eng.AlternativePlan) int {
+		return cmp.Compare(b.GetTotalScore(), a.GetTotalScore())
+	})
+	return found
+}
+
+// rankOpeners orders the tracks that may open a set, most fitting first: by
+// how well they start the energy curve when there is one, otherwise the way
+// the mode picks its opener. A requested opener leaves no others.
+func (p *planning) rankOpeners() []int {
+	if p.opts.Opener != "" {
+		return nil
+	}
+	var ranked []int
+	for i := range p.tracks {
+		if p.cons == nil || p.cons.canOpen(i) {
+			ranked = append(ranked, i)
+		}
+	}
+	if p.points != nil {
+		target := spanTargets(p.points, 0, 1/float64(len(p.tracks)))
+		slices.SortStableFunc(ranked, func(a, b int) int {
+			return cmp.Compare(curveMiss(p.profiles[a], target), curveMiss(p.profiles[b], target))
+		})
+		return ranked
+	}
+	byIndex := make(map[*common.TrackAnalysis]int, len(ranked))
+	candidates := make([]*common.TrackAnalysis, len(ranked))
+	for k, i := range ranked {
+		byIndex[p.tracks[i]] = i
+		candidates[k] = p.tracks[i]
+	}
+	for k, a := range rankStarts(candidates, p.opts.Mode) {
+		ranked[k] = byIndex[a]
+	}
+	return ranked
+}
+
+// nextOpener returns the best ranked track that hasn't opened a plan yet, or
+// -1 when every one has.
+func nextOpener(ranked []int, used []bool) int {
+	for _, t := range ranked {
+		if !used[t] {
+			return t
+		}
+	}
+	return -1
+}
+
+// penalize returns a copy of score with every edge the plans make lowered by
+// penalty.
+func penalize(score [][]float64, plans [][]int, penalty float64) [][]float64 {
+	out := make([][]float64, len(score))
+	for i := range score {
+		out[i] = slices.Clone(score[i])
+	}
+	for _, path := range plans {
+		for i := 1; i < len(path); i++ {
+			out[path[i-1]][path[i]] -= penalty
+		}
+	}
+	return out
+}
+
+// differs reports whether path makes at least need transitions that each of
+// the plans doesn't.
+func differs(path []int, plans [][]int, need int) bool {
+	for _, plan := range plans {
+		if newEdges(path, plan) < need {
+			return false
+		}
+	}
+	return true
+}
+
+// newEdges counts the transitions of path that plan doesn't make.
+func newEdges(path, plan []int) int {
+	made := edgeSet(plan)
+	count := 0
+	for i := 1; i < len(path); i++ {
+		if !made[[2]int{path[i-1], path[i]}] {
+			count++
+		}
+	}
+	return count
+}
+
+// edgeSet holds the transitions a path makes.
+func edgeSet(path []int) map[[2]int]bool {
+	made := make(map[[2]int]bool, len(path))
+	for i := 1; i < len(path); i++ {
+		made[[2]int{path[i-1], path[i]}] = true
+	}
+	return made
+}
+
+// diff describes how path, planned as result, departs from the best plan.
+func (p *planning) diff(best []int, bestResult *Result, path []int, result *Result) *eng.PlanDiff {
+	d := &eng.PlanDiff{
+		DifferentOpener: path[0] != best[0],
+		FirstDifference: int32(min(len(path), len(best))),
+		ScoreDelta:      result.TotalScore - bestResult.TotalScore,
+	}
+	for i := range min(len(path), len(best)) {
+		if path[i] != best[i] {
+			d.FirstDifference = int32(i)
+			break
+		}
+	}
+
+	made := edgeSet(best)
+	for i := 1; i < len(path); i++ {
+		if !made[[2]int{path[i-1], path[i]}] {
+			d.NewEdges = append(d.NewEdges, result.Explanations[i-1])
+		}
+	}
+	d.DifferentEdges = int32(len(d.NewEdges))
+
+	inBest, inPath := make([]bool, len(p.tracks)), make([]bool, len(p.tracks))
+	for _, t := range best {
+		inBest[t] = true
+	}
+	for _, t := range path {
+		inPath[t] = true
+		if !inBest[t] {
+			d.Added = append(d.Added, p.tracks[t].GetId())
+		}
+	}
+	for _, t := range best {
+		if !inPath[t] {
+			d.Dropped = append(d.Dropped, p.tracks[t].GetId())
+		}
+	}
+	return d
+}
+
+// meanAbs is the mean magnitude of the edge scores, the scale of the first
+// penalty on known transitions.
+func meanAbs(score [][]float64) float64 {
+	var sum float64
+	n := len(score)
+	for i := range score {
+		for j := range score[i] {
+			if i != j {
+				sum += math.Abs(score[i][j])
+			}
+		}
+	}
+	if n < 2 || sum == 0 {
+		return 1
+	}
+	return sum / float64(n*(n-1))
+}
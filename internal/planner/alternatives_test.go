@@ -0,0 +1,122 @@
+package planner
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	eng "github.com/cartomix/cancun/gen/go/engine"
+)
+
+func TestAlternativesDiffer(t *testing.T) {
+	analyses := randomAnalyses(rand.New(rand.NewSource(21)), 16)
+	result, err := Optimize(analyses, Options{
+		Mode:         eng.SetMode_PEAK_TIME,
+		TimeBudget:   100 * time.Millisecond,
+		Alternatives: 3,
+	})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	if len(result.Alternatives) != 3 {
+		t.Fatalf("got %d alternatives, want 3", len(result.Alternatives))
+	}
+
+	plans := [][]string{hashes(result.Order)}
+	for i, alt := range result.Alternatives {
+		order := hashes(alt.GetOrder())
+		if len(order) != len(analyses) {
+			t.Errorf("alternative %d plans %d of %d tracks", i, len(order), len(analyses))
+		}
+		if order[0] != plans[0][0] {
+			t.Errorf("alternative %d opens with %s, want the mode's opener %s", i, order[0], plans[0][0])
+		}
+		diff := alt.GetDiff()
+		if diff.GetDifferentEdges() < 4 || int(diff.GetDifferentEdges()) != len(diff.GetNewEdges()) {
+			t.Errorf("alternative %d differs by %d edges (%d listed), want at least 4",
+				i, diff.GetDifferentEdges(), len(diff.GetNewEdges()))
+		}
+		if math.Abs(diff.GetScoreDelta()-(alt.GetTotalScore()-result.TotalScore)) > 1e-9 {
+			t.Errorf("alternative %d score delta %.2f, totals %.2f vs %.2f",
+				i, diff.GetScoreDelta(), alt.GetTotalScore(), result.TotalScore)
+		}
+		if i > 0 && alt.GetTotalScore() > result.Alternatives[i-1].GetTotalScore() {
+			t.Errorf("alternatives not sorted by total score")
+		}
+		for _, plan := range plans {
+			if slices.Equal(plan, order) {
+				t.Errorf("alternative %d repeats an earlier plan", i)
+			}
+		}
+		plans = append(plans, order)
+	}
+}
+
+func TestAlternativesWithDifferentOpeners(t *testing.T) {
+	analyses := randomAnalyses(rand.New(rand.NewSource(4)), 10)
+	result, err := Optimize(analyses, Options{
+		TimeBudget:       50 * time.Millisecond,
+		Alternatives:     4,
+		DifferentOpeners: true,
+	})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	if len(result.Alternatives) != 4 {
+		t.Fatalf("got %d alternatives, want 4", len(result.Alternatives))
+	}
+	openers := map[string]bool{result.Order[0].GetContentHash(): true}
+	for _, alt := range result.Alternatives {
+		opener := alt.GetOrder()[0].GetContentHash()
+		if openers[opener] || !alt.GetDiff().GetDifferentOpener() || alt.GetDiff().GetFirstDifference() != 0 {
+			t.Errorf("opener %s repeats or isn't reported as new", opener)
+		}
+		openers[opener] = true
+	}
+
+	pinned, err := Optimize(analyses, Options{
+		Opener:           "t003",
+		Alternatives:     2,
+		DifferentOpeners: true,
+	})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	if len(pinned.Alternatives) != 0 {
+		t.Errorf("got %d alternatives with a pinned opener, want none", len(pinned.Alternatives))
+	}
+}
+
+func TestAlternativesToLength(t *testing.T) {
+	analyses := timedAnalyses(rand.New(rand.NewSource(12)), 24)
+	result, err := Optimize(analyses, Options{
+		TargetLength: 45 * time.Minute,
+		TimeBudget:   100 * time.Millisecond,
+		Alternatives: 2,
+	})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	if len(result.Alternatives) == 0 {
+		t.Fatal("no alternatives")
+	}
+	best := hashes(result.Order)
+	for _, alt := range result.Alternatives {
+		order := hashes(alt.GetOrder())
+		for _, id := range alt.GetDiff().GetAdded() {
+			if slices.Contains(best, id.GetContentHash()) || !slices.Contains(order, id.GetContentHash()) {
+				t.Errorf("added %s isn't new to the plan", id.GetContentHash())
+			}
+		}
+		for _, id := range alt.GetDiff().GetDropped() {
+			if !slices.Contains(best, id.GetContentHash()) || slices.Contains(order, id.GetContentHash()) {
+				t.Errorf("dropped %s isn't only in the best plan", id.GetContentHash())
+			}
+		}
+		if got := len(order) - len(best); got != len(alt.GetDiff().GetAdded())-len(alt.GetDiff().GetDropped()) {
+			t.Errorf("added and dropped tracks don't account for %d more tracks", got)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/common"
+)
+
+// ErrSetLength is returned when a target set length is invalid, or no
+// selection of the pool that keeps every must-play track fits it.
+var ErrSetLength = errors.New("set length can't be met")
+
+// DefaultLengthTolerance is how far a set may run over or under its target,
+// as a fraction of the target, when the request doesn't set a tolerance.
+const DefaultLengthTolerance = 0.05
+
+// lengthPenalty is the score lost per second a set runs outside its tolerance
+// while the search is still looking for one that fits.
+const lengthPenalty = 1.0
+
+// timing estimates how long tracks run once mixed: each is heard for its
+// duration times the play fraction, and consecutive tracks overlap for as long
+// as the outgoing track's last transition window and the incoming track's
+// first one both last.
+type timing struct {
+	play    []float64   // play[i] is how long track i is heard, in seconds
+	overlap [][]float64 // overlap[i][j] is how long i and j play together
+}
+
+func newTiming(tracks []*common.TrackAnalysis, fraction float64) timing {
+	t := timing{play: make([]float64, len(tracks)), overlap: make([][]float64, len(tracks))}
+	mixIn := make([]float64, len(tracks))
+	mixOut := make([]float64, len(tracks))
+	for i, a := range tracks {
+		t.play[i] = a.GetDurationSeconds() * fraction
+		mixIn[i], mixOut[i] = mixWindows(a)
+	}
+	for i := range tracks {
+		t.overlap[i] = make([]float64, len(tracks))
+		for j := range tracks {
+			if i != j {
+				// Neither track can spend more than half its time mixing.
+				t.overlap[i][j] = min(mixOut[i], mixIn[j], t.play[i]/2, t.play[j]/2)
+			}
+		}
+	}
+	return t
+}
+
+// mixWindows returns how long, in seconds, a track's first and last transition
+// windows last. Both are zero when the track has no windows or no tempo.
+func mixWindows(a *common.TrackAnalysis) (in, out float64) {
+	bpm := estimateBPM(a)
+	windows := a.GetTransitionWindows()
+	if bpm <= 0 || len(windows) == 0 {
+		return 0, 0
+	}
+	first, last := windows[0], windows[0]
+	for _, w := range windows[1:] {
+		if w.GetStartBeat() < first.GetStartBeat() {
+			first = w
+		}
+		if w.GetStartBeat() > last.GetStartBeat() {
+			last = w
+		}
+	}
+	seconds := func(w *common.TransitionWindow) float64 {
+		return float64(max(0, w.GetEndBeat()-w.GetStartBeat())) * 60 / bpm
+	}
+	return seconds(first), seconds(last)
+}
+
+// starts returns when each track of path comes in, and the set's running time.
+func (t timing) starts(path []int) ([]float64, float64) {
+	starts := make([]float64, len(path))
+	var at float64
+	for i, idx := range path {
+		if i > 0 {
+			at -= t.overlap[path[i-1]][idx]
+		}
+		starts[i] = at
+		at += t.play[idx]
+	}
+	return starts, at
+}
+
+// shortest returns a lower bound on how long the given tracks run together in
+// any order: each may overlap the longest mix in from another, except the one
+// that opens.
+func (t timing) shortest(tracks []int) float64 {
+	var length, opener float64
+	for n, i := range tracks {
+		var in float64
+		for _, j := range tracks {
+			in = max(in, t.overlap[j][i])
+		}
+		length += t.play[i] - in
+		if n == 0 || in < opener {
+			opener = in
+		}
+	}
+	return length + opener
+}
+
+// lengthBounds resolves the requested set length into a target and the range
+// a plan may run, in seconds.
+func lengthBounds(target, tolerance time.Duration) (goal, lower, upper float64, err error) {
+	if tolerance < 0 {
+		return 0, 0, 0, fmt.Errorf("%w: negative tolerance", ErrSetLength)
+	}
+	goal = target.Seconds()
+	slack := tolerance.Seconds()
+	if tolerance == 0 {
+		slack = goal * DefaultLengthTolerance
+	}
+	return goal, max(0, goal-slack), goal + slack, nil
+}
+
+// playFraction validates the share of each track expected to be played; zero
+// means the whole track.
+func playFraction(fraction float64) (float64, error) {
+	switch {
+	case fraction == 0:
+		return 1, nil
+	case fraction < 0 || fraction > 1 || math.IsNaN(fraction):
+		return 0, fmt.Errorf("%w: play fraction %.2f outside 0-1", ErrSetLength, fraction)
+	}
+	return fraction, nil
+}
+
+// budgetSearch picks and orders the subset of the pool that fits a set length.
+// It maximizes the mean transition score, less any miss against the energy
+// curve, with a steep penalty for every second outside the allowed range.
+type budgetSearch struct {
+	clock
+	budget time.Duration
+	timing
+	score              [][]float64
+	fixed              []bool       // fixed[i] when track i must stay in the set
+	opener             int          // pinned first track, or -1 to let the search choose
+	cons               *constraints // ordering rules the set must keep; nil when none
+	goal, lower, upper float64
+	points             []CurvePoint // nil without an energy curve
+	profiles           [][]float64
+	weight             float64
+}
+
+// solve builds a set by best insertion until it reaches the target length, then
+// improves it by adding, removing, swapping, moving and reversing tracks until
+// no move helps or the budget runs out.
+func (b *budgetSearch) solve() ([]int, error) {
+	var must []int
+	var pool float64
+	for i, p := range b.play {
+		if b.fixed[i] || i == b.opener {
+			must = append(must, i)
+		}
+		pool += p
+	}
+	if fixed := b.shortest(must); fixed > b.upper {
+		return nil, fmt.Errorf("%w: must-play tracks alone run at least %s, over the %s limit",
+			ErrSetLength, seconds(fixed), seconds(b.upper))
+	}
+	if pool < b.lower {
+		return nil, fmt.Errorf("%w: the whole pool runs at most %s, under the %s minimum",
+			ErrSetLength, seconds(pool), seconds(b.lower))
+	}
+
+	path := b.initial()
+	value := b.objective(path)
+	for !b.outOfTime() {
+		next, nextValue, ok := b.improve(path, value)
+		if !ok {
+			break
+		}
+		path, value = next, nextValue
+	}
+	if b.cons != nil && b.cons.violations(path) > 0 {
+		// Crowded artists can leave no single move that helps.
+		repairClock := newClock(b.budget)
+		b.cons.repair(path, &repairClock)
+	}
+
+	if _, length := b.starts(path); length < b.lower || length > b.upper {
+		return nil, fmt.Errorf("%w: the closest set runs %s, allowed %s to %s",
+			ErrSetLength, seconds(length), seconds(b.lower), seconds(b.upper))
+	}
+	if b.cons != nil && b.cons.violations(path) > 0 {
+		return nil, fmt.Errorf("%w: no set of %s keeps the artist spacing", ErrConstraints, seconds(b.goal))
+	}
+	return path, nil
+}
+
+// initial seats the opener and must-play tracks, then keeps inserting whichever
+// track and position adds the most transition score until the set reaches the
+// target, skipping any insertion that would overrun the limit. With constraints,
+// the fixed tracks are seated in an order that keeps them, and insertions that
+// would break one are skipped.
+func (b *budgetSearch) initial() []int {
+	var path []int
+	if b.cons != nil {
+		units, _ := b.cons.unitOrder(b.fixed)
+		for _, h := range units {
+			for t := h; t >= 0; t = b.cons.next[t] {
+				path = append(path, t)
+			}
+		}
+	} else {
+		if b.opener >= 0 {
+			path = append(path, b.opener)
+		}
+		for i, f := range b.fixed {
+			if f && i != b.opener {
+				p, _, _ := b.bestInsertion(path, i)
+				path = slices.Insert(path, p, i)
+			}
+		}
+	}
+
+	_, length := b.starts(path)
+	for length < b.goal {
+		in := b.members(path)
+		track, at, bestGain, bestGrowth := -1, 0, math.Inf(-1), 0.0
+		for c := range b.play {
+			if in[c] || b.play[c] <= 0 {
+				continue
+			}
+			p, gain, growth := b.bestInsertion(path, c)
+			if p >= 0 && length+growth <= b.upper && gain > bestGain {
+				track, at, bestGain, bestGrowth = c, p, gain, growth
+			}
+		}
+		if track < 0 {
+			break
+		}
+		path = slices.Insert(path, at, track)
+		length += bestGrowth
+	}
+	return path
+}
+
+// bestInsertion finds where c adds the most transition score to path, and how
+// much score and running time it adds there. With constraints, positions that
+// break more are skipped and those that mend some win; it returns -1 when every
+// position breaks more.
+func (b *budgetSearch) bestInsertion(path []int, c int) (at int, gain, growth float64) {
+	at, gain = -1, math.Inf(-1)
+	broken := 0
+	if b.cons != nil {
+		broken = b.cons.violations(path)
+	}
+	for p := b.firstMovable(); p <= len(path); p++ {
+		mended := 0
+		if b.cons != nil {
+			if mended = broken - b.cons.violations(slices.Insert(slices.Clone(path), p, c)); mended < 0 {
+				continue
+			}
+		}
+		prev, next := -1, -1
+		if p > 0 {
+			prev = path[p-1]
+		}
+		if p < len(path) {
+			next = path[p]
+		}
+		g := b.edgeScore(prev, c) + b.edgeScore(c, next) - b.edgeScore(prev, next) + constraintPenalty*float64(mended)
+		if g > gain {
+			at, gain = p, g
+			growth = b.play[c] - b.edgeOverlap(prev, c) - b.edgeOverlap(c, next) + b.edgeOverlap(prev, next)
+		}
+	}
+	return at, gain, growth
+}
+
+// improve applies the first move that raises the objective.
+func (b *budgetSearch) improve(path []int, value float64) ([]int, float64, bool) {
+	var best []int
+	try := func(candidate []int) bool {
+		if b.outOfTime() {
+			return true
+		}
+		if v := b.objective(candidate); v > value+improvementEpsilon {
+			best, value = candidate, v
+			return true
+		}
+		return false
+	}
+
+	first := b.firstMovable()
+	in := b.members(path)
+	for c := range b.play {
+		if in[c] || b.play[c] <= 0 {
+			continue
+		}
+		for p := first; p <= len(path); p++ {
+			if try(slices.Insert(slices.Clone(path), p, c)) {
+				return best, value, best != nil
+			}
+		}
+	}
+	for p := first; p < len(path); p++ {
+		if b.fixed[path[p]] {
+			continue
+		}
+		if try(slices.Delete(slices.Clone(path), p, p+1)) {
+			return best, value, best != nil
+		}
+		for c := range b.play {
+			if in[c] || b.play[c] <= 0 {
+				continue
+			}
+			candidate := slices.Clone(path)
+			candidate[p] = c
+			if try(candidate) {
+				return best, value, best != nil
+			}
+		}
+	}
+	for p := first; p < len(path); p++ {
+		run := b.run(path, p)
+		for q := first; q <= len(path)-run; q++ {
+			if q == p {
+				continue
+			}
+			candidate := slices.Delete(slices.Clone(path), p, p+run)
+			if try(slices.Insert(candidate, q, path[p:p+run]...)) {
+				return best, value, best != nil
+			}
+		}
+		for q := p + 2; q < len(path); q++ {
+			candidate := slices.Clone(path)
+			slices.Reverse(candidate[p : q+1])
+			if try(candidate) {
+				return best, value, best != nil
+			}
+		}
+	}
+	return nil, value, false
+}
+
+// objective scores a candidate set.
+func (b *budgetSearch) objective(path []int) float64 {
+	if len(path) == 0 {
+		return math.Inf(-1)
+	}
+	starts, length := b.starts(path)
+
+	var value float64
+	if len(path) > 1 {
+		for i := 1; i < len(path); i++ {
+			value += b.score[path[i-1]][path[i]]
+		}
+		value /= float64(len(path) - 1)
+	}
+	if b.points != nil && length > 0 {
+		var missed float64
+		for i, idx := range path {
+			target := spanTargets(b.points, starts[i]/length, (starts[i]+b.play[idx])/length)
+			missed += curveMiss(b.profiles[idx], target)
+		}
+		value -= b.weight * missed / float64(len(path))
+	}
+	switch {
+	case length < b.lower:
+		value -= lengthPenalty * (b.lower - length)
+	case length > b.upper:
+		value -= lengthPenalty * (length - b.upper)
+	}
+	if b.cons != nil {
+		value -= constraintPenalty * float64(b.cons.violations(path))
+	}
+	return value
+}
+
+// run is how many tracks from position p move together: the whole locked chain
+// when p is its head, otherwise just the one.
+func (b *budgetSearch) run(path []int, p int) int {
+	if b.cons == nil || b.cons.head[path[p]] != path[p] {
+		return 1
+	}
+	return min(b.cons.unitLen[path[p]], len(path)-p)
+}
+
+func (b *budgetSearch) firstMovable() int {
+	if b.opener >= 0 {
+		return 1
+	}
+	return 0
+}
+
+func (b *budgetSearch) members(path []int) []bool {
+	in := make([]bool, len(b.play))
+	for _, idx := range path {
+		in[idx] = true
+	}
+	return in
+}
+
+func (b *budgetSearch) edgeScore(from, to int) float64 {
+	if from < 0 || to < 0 {
+		return 0
+	}
+	return b.score[from][to]
+}
+
+func (b *budgetSearch) edgeOverlap(from, to int) float64 {
+	if from < 0 || to < 0 {
+		return 0
+	}
+	return b.overlap[from][to]
+}
+
+// seconds formats a running time for error messages.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(time.Second)
+}
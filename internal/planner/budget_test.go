@@ -0,0 +1,139 @@
+package planner
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	eng "github.com/cartomix/cancun/gen/go/engine"
+)
+
+// timedAnalyses gives each track a four to seven minute duration.
+func timedAnalyses(r *rand.Rand, n int) []*common.TrackAnalysis {
+	analyses := randomAnalyses(r, n)
+	for _, a := range analyses {
+		a.DurationSeconds = 240 + r.Float64()*180
+	}
+	return analyses
+}
+
+func TestTargetLengthSelectsSubset(t *testing.T) {
+	analyses := timedAnalyses(rand.New(rand.NewSource(5)), 40)
+	must := analyses[17].GetId().GetContentHash()
+
+	result, err := Optimize(analyses, Options{
+		Mode:           eng.SetMode_PEAK_TIME,
+		MustPlayHashes: map[string]bool{must: true},
+		TargetLength:   time.Hour,
+		TimeBudget:     time.Second,
+	})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	if len(result.Order) >= len(analyses) {
+		t.Fatalf("planned %d of %d tracks for an hour", len(result.Order), len(analyses))
+	}
+	if result.EstimatedSeconds < 57*60 || result.EstimatedSeconds > 63*60 {
+		t.Fatalf("estimated %.0fs, want 60m ± 5%%", result.EstimatedSeconds)
+	}
+	found := false
+	for _, id := range result.Order {
+		found = found || id.GetContentHash() == must
+	}
+	if !found {
+		t.Fatal("must-play track was dropped")
+	}
+	if start := chooseStart(analyses, eng.SetMode_PEAK_TIME); result.Order[0] != start.GetId() {
+		t.Errorf("opener %s, want mode opener %s", result.Order[0].GetContentHash(), start.GetId().GetContentHash())
+	}
+
+	// Each transition reports when the incoming track starts.
+	var previous float64
+	for i, e := range result.Explanations {
+		if e.GetAtSeconds() <= previous {
+			t.Fatalf("transition %d at %.0fs, previous at %.0fs", i, e.GetAtSeconds(), previous)
+		}
+		previous = e.GetAtSeconds()
+	}
+	if previous >= result.EstimatedSeconds {
+		t.Fatalf("last transition at %.0fs, set ends at %.0fs", previous, result.EstimatedSeconds)
+	}
+}
+
+func TestTargetLengthCountsOverlap(t *testing.T) {
+	var analyses []*common.TrackAnalysis
+	for _, hash := range []string{"a", "b", "c"} {
+		a := makeAnalysis(hash, 120, "8A", 5)
+		a.DurationSeconds = 300
+		// 32 beats at 120 BPM: a 16 second window at each end.
+		a.TransitionWindows = []*common.TransitionWindow{
+			{StartBeat: 0, EndBeat: 32, Tag: "intro"},
+			{StartBeat: 560, EndBeat: 592, Tag: "outro"},
+		}
+		analyses = append(analyses, a)
+	}
+
+	result, err := Optimize(analyses, Options{TargetLength: 13 * time.Minute, LengthTolerance: time.Minute, PlayFraction: 0.9})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	// Three tracks at 270s each, less two 16s overlaps.
+	if want := 3*270.0 - 2*16; math.Abs(result.EstimatedSeconds-want) > 1e-6 {
+		t.Fatalf("estimated %.1fs, want %.1fs", result.EstimatedSeconds, want)
+	}
+	if got := result.Explanations[0].GetAtSeconds(); math.Abs(got-254) > 1e-6 {
+		t.Fatalf("first transition at %.1fs, want 254s", got)
+	}
+}
+
+func TestTargetLengthMustPlayOverlap(t *testing.T) {
+	var analyses []*common.TrackAnalysis
+	must := make(map[string]bool)
+	for _, hash := range []string{"a", "b", "c"} {
+		a := makeAnalysis(hash, 120, "8A", 5)
+		a.DurationSeconds = 300
+		// 64 beats at 120 BPM: a 32 second window at each end.
+		a.TransitionWindows = []*common.TransitionWindow{
+			{StartBeat: 0, EndBeat: 64, Tag: "intro"},
+			{StartBeat: 536, EndBeat: 600, Tag: "outro"},
+		}
+		analyses = append(analyses, a)
+		must[hash] = true
+	}
+
+	// 900s of tracks mix down to 836s, inside 810s ± 30s.
+	result, err := Optimize(analyses, Options{
+		TargetLength:    810 * time.Second,
+		LengthTolerance: 30 * time.Second,
+		MustPlayHashes:  must,
+	})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	if want := 3*300.0 - 2*32; math.Abs(result.EstimatedSeconds-want) > 1e-6 {
+		t.Fatalf("estimated %.1fs, want %.1fs", result.EstimatedSeconds, want)
+	}
+}
+
+func TestTargetLengthUnreachable(t *testing.T) {
+	analyses := timedAnalyses(rand.New(rand.NewSource(9)), 10)
+	must := make(map[string]bool)
+	for _, a := range analyses[:5] {
+		must[a.GetId().GetContentHash()] = true
+	}
+
+	cases := []Options{
+		{TargetLength: 3 * time.Hour},                          // pool too short
+		{TargetLength: 10 * time.Minute, MustPlayHashes: must}, // must-plays too long
+		{TargetLength: time.Hour, PlayFraction: 1.5},
+		{TargetLength: time.Hour, LengthTolerance: -time.Minute},
+	}
+	for i, opts := range cases {
+		if _, err := Optimize(analyses, opts); !errors.Is(err, ErrSetLength) {
+			t.Errorf("case %d: err = %v, want ErrSetLength", i, err)
+		}
+	}
+}
@@ -0,0 +1,495 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"github.com/cartomix/cancun/gen/go/common"
+)
+
+// ErrConstraints is returned when a plan's ordering constraints name tracks
+// that aren't in the pool, contradict each other, or can't all be kept.
+var ErrConstraints = errors.New("set constraints can't be met")
+
+// constraintPenalty is the score lost per broken constraint while the length
+// search is still looking for a set that keeps them all.
+const constraintPenalty = 1e6
+
+// Precedence asks for one track to play somewhere before another.
+type Precedence struct {
+	Before, After string // content hashes
+}
+
+// ArtistSpacing asks for at least MinTracks other tracks between any track by
+// one artist and any track by the other. Both may be the same artist, to spread
+// out one artist's tracks. Artists are matched case-insensitively.
+type ArtistSpacing struct {
+	ArtistA, ArtistB string
+	MinTracks        int
+}
+
+// constraints are a plan's ordering rules, resolved to track indexes. A locked
+// chain, or a track outside any chain, is a unit: it is placed as a whole and
+// named by its first track, its head.
+type constraints struct {
+	hash           []string
+	opener, closer int   // pinned tracks, or -1
+	next, prev     []int // neighbours in a locked chain, or -1
+	head           []int // head of each track's unit
+	unitLen        []int // tracks in the unit, indexed by head
+	unitBefore     [][]int
+	artist         []int   // artist of each track, or -1 when no spacing rule names it
+	gap            [][]int // gap[a][b] is the least tracks between artists a and b
+	maxGap         int
+	pos            []int // scratch for violations: each track's position, or -1
+	unspaced       bool  // allows ignores artist spacing
+}
+
+// newConstraints resolves the ordering constraints in opts against tracks. It
+// returns nil when there are none.
+func newConstraints(tracks []*common.TrackAnalysis, opts Options) (*constraints, error) {
+	if opts.Opener == "" && opts.Closer == "" && len(opts.LockedChains) == 0 &&
+		len(opts.Precedences) == 0 && len(opts.ArtistSpacing) == 0 {
+		return nil, nil
+	}
+
+	n := len(tracks)
+	c := &constraints{
+		hash:       make([]string, n),
+		opener:     -1,
+		closer:     -1,
+		next:       filled(n, -1),
+		prev:       filled(n, -1),
+		head:       make([]int, n),
+		unitLen:    make([]int, n),
+		unitBefore: make([][]int, n),
+		artist:     filled(n, -1),
+		pos:        filled(n, -1),
+	}
+	index := make(map[string]int, n)
+	for i, a := range tracks {
+		c.hash[i] = a.GetId().GetContentHash()
+		index[c.hash[i]] = i
+	}
+	lookup := func(role, hash string) (int, error) {
+		i, ok := index[hash]
+		if !ok {
+			return -1, fmt.Errorf("%w: %s %s is banned or not in the pool", ErrConstraints, role, hash)
+		}
+		return i, nil
+	}
+
+	var err error
+	if opts.Opener != "" {
+		if c.opener, err = lookup("opener", opts.Opener); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Closer != "" {
+		if c.closer, err = lookup("closer", opts.Closer); err != nil {
+			return nil, err
+		}
+	}
+	if c.opener >= 0 && c.opener == c.closer && n > 1 {
+		return nil, fmt.Errorf("%w: %s can't both open and close the set", ErrConstraints, opts.Opener)
+	}
+
+	for _, chain := range opts.LockedChains {
+		ids := make([]int, len(chain))
+		for k, hash := range chain {
+			if ids[k], err = lookup("locked track", hash); err != nil {
+				return nil, err
+			}
+			if slices.Contains(ids[:k], ids[k]) {
+				return nil, fmt.Errorf("%w: %s appears twice in a locked chain", ErrConstraints, hash)
+			}
+		}
+		for k := 1; k < len(ids); k++ {
+			a, b := ids[k-1], ids[k]
+			if (c.next[a] >= 0 && c.next[a] != b) || (c.prev[b] >= 0 && c.prev[b] != a) {
+				return nil, fmt.Errorf("%w: locked chains put different tracks next to %s", ErrConstraints, c.hash[b])
+			}
+			c.next[a], c.prev[b] = b, a
+		}
+	}
+	if c.opener >= 0 && c.prev[c.opener] >= 0 {
+		return nil, fmt.Errorf("%w: opener %s is locked after %s", ErrConstraints, c.hash[c.opener], c.hash[c.prev[c.opener]])
+	}
+	if c.closer >= 0 && c.next[c.closer] >= 0 {
+		return nil, fmt.Errorf("%w: closer %s is locked before %s", ErrConstraints, c.hash[c.closer], c.hash[c.next[c.closer]])
+	}
+
+	// Walk each chain from its head. Tracks never reached from a head sit on a
+	// loop of locks.
+	offset := filled(n, -1) // position within the unit
+	for t := range tracks {
+		if c.prev[t] >= 0 {
+			continue
+		}
+		for m, k := t, 0; m >= 0; m, k = c.next[m], k+1 {
+			c.head[m], offset[m] = t, k
+			c.unitLen[t]++
+		}
+	}
+	for t := range tracks {
+		if offset[t] < 0 {
+			return nil, fmt.Errorf("%w: locked chains loop back through %s", ErrConstraints, c.hash[t])
+		}
+	}
+
+	for _, p := range opts.Precedences {
+		a, err := lookup("track", p.Before)
+		if err != nil {
+			return nil, err
+		}
+		b, err := lookup("track", p.After)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case a == b:
+			return nil, fmt.Errorf("%w: %s can't play before itself", ErrConstraints, p.Before)
+		case c.head[a] == c.head[b] && offset[a] > offset[b]:
+			return nil, fmt.Errorf("%w: %s is locked after %s but has to play before it", ErrConstraints, p.Before, p.After)
+		case c.head[a] != c.head[b]:
+			c.unitBefore[c.head[b]] = append(c.unitBefore[c.head[b]], a)
+		}
+	}
+	if _, err := c.unitOrder(nil); err != nil {
+		return nil, err
+	}
+
+	if err := c.resolveArtists(opts); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// resolveArtists numbers the artists that spacing rules name and records the
+// gap each pair needs.
+func (c *constraints) resolveArtists(opts Options) error {
+	ids := make(map[string]int)
+	id := func(name string) int {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := ids[name]; !ok {
+			ids[name] = len(ids)
+		}
+		return ids[name]
+	}
+	type rule struct{ a, b, gap int }
+	var rules []rule
+	for _, s := range opts.ArtistSpacing {
+		if strings.TrimSpace(s.ArtistA) == "" || strings.TrimSpace(s.ArtistB) == "" {
+			return fmt.Errorf("%w: artist spacing needs two artists", ErrConstraints)
+		}
+		if s.MinTracks < 0 {
+			return fmt.Errorf("%w: negative spacing between %s and %s", ErrConstraints, s.ArtistA, s.ArtistB)
+		}
+		rules = append(rules, rule{id(s.ArtistA), id(s.ArtistB), s.MinTracks})
+		c.maxGap = max(c.maxGap, s.MinTracks)
+	}
+	c.gap = make([][]int, len(ids))
+	for a := range c.gap {
+		c.gap[a] = make([]int, len(ids))
+	}
+	for _, r := range rules {
+		c.gap[r.a][r.b] = max(c.gap[r.a][r.b], r.gap)
+		c.gap[r.b][r.a] = c.gap[r.a][r.b]
+	}
+	for t, hash := range c.hash {
+		name := strings.ToLower(strings.TrimSpace(opts.Artists[hash]))
+		if a, ok := ids[name]; ok && name != "" {
+			c.artist[t] = a
+		}
+	}
+	return nil
+}
+
+// unitOrder lists unit heads in an order that keeps every constraint but artist
+// spacing: the opener's unit first, the closer's last, and every track after
+// those it must follow. With in set, only units with a member in the set are
+// listed, and only their precedences count. It fails when the constraints
+// contradict each other.
+func (c *constraints) unitOrder(in []bool) ([]int, error) {
+	n := len(c.next)
+	member := func(t int) bool { return in == nil || in[t] }
+	unitIn := make([]bool, n)
+	for t := range n {
+		if member(t) {
+			unitIn[c.head[t]] = true
+		}
+	}
+
+	indegree := make([]int, n)
+	later := make([][]int, n)
+	for h := range n {
+		if !unitIn[h] || c.head[h] != h {
+			continue
+		}
+		for _, a := range c.unitBefore[h] {
+			if member(a) {
+				later[c.head[a]] = append(later[c.head[a]], h)
+				indegree[h]++
+			}
+		}
+	}
+
+	closer := -1
+	if c.closer >= 0 && unitIn[c.head[c.closer]] {
+		closer = c.head[c.closer]
+		if len(later[closer]) > 0 {
+			return nil, fmt.Errorf("%w: closer %s has to play before %s", ErrConstraints, c.hash[c.closer], c.hash[later[closer][0]])
+		}
+	}
+
+	var order, ready []int
+	place := func(h int) {
+		order = append(order, h)
+		for _, l := range later[h] {
+			if indegree[l]--; indegree[l] == 0 && l != closer {
+				ready = append(ready, l)
+			}
+		}
+	}
+	if c.opener >= 0 && unitIn[c.opener] {
+		if indegree[c.opener] > 0 {
+			return nil, fmt.Errorf("%w: opener %s has to follow %s", ErrConstraints, c.hash[c.opener], c.hash[c.unitBefore[c.opener][0]])
+		}
+		place(c.opener)
+	}
+	for h := range n {
+		if unitIn[h] && c.head[h] == h && indegree[h] == 0 && h != closer && h != c.opener {
+			ready = append(ready, h)
+		}
+	}
+	for len(ready) > 0 {
+		h := slices.Min(ready)
+		ready = slices.DeleteFunc(ready, func(r int) bool { return r == h })
+		place(h)
+	}
+	if closer >= 0 && indegree[closer] == 0 && closer != c.opener {
+		place(closer)
+	}
+
+	units := 0
+	for h := range n {
+		if unitIn[h] && c.head[h] == h {
+			units++
+		}
+	}
+	if len(order) < units {
+		var stuck []string
+		for h := range n {
+			if unitIn[h] && c.head[h] == h && !slices.Contains(order, h) && len(stuck) < 3 {
+				stuck = append(stuck, c.hash[h])
+			}
+		}
+		return nil, fmt.Errorf("%w: the ordering constraints contradict each other around %s", ErrConstraints, strings.Join(stuck, ", "))
+	}
+	return order, nil
+}
+
+// canOpen reports whether track t may open a set of all n tracks.
+func (c *constraints) canOpen(t int) bool {
+	return c.head[t] == t && len(c.unitBefore[t]) == 0 &&
+		(c.closer < 0 || c.head[c.closer] != t || c.unitLen[t] == len(c.next))
+}
+
+// allows reports whether t may come next after the partial path, where used
+// marks the tracks already placed and left counts those still to place,
+// including t. Every track is assumed to end up in the set. Following these
+// rules never strands the path except on artist spacing.
+func (c *constraints) allows(path []int, used []bool, t, left int) bool {
+	if len(path) == 0 {
+		if c.opener >= 0 && t != c.opener {
+			return false
+		}
+	} else if last := path[len(path)-1]; c.next[last] >= 0 {
+		return t == c.next[last] && c.spaced(path, t)
+	}
+	if c.head[t] != t {
+		return false // chains are entered at their head
+	}
+	for _, a := range c.unitBefore[t] {
+		if !used[a] {
+			return false
+		}
+	}
+	// The closer's unit goes last, and only last.
+	if c.closer >= 0 && !used[c.closer] && (c.head[c.closer] == t) != (left == c.unitLen[t]) {
+		return false
+	}
+	return c.spaced(path, t)
+}
+
+// spaced reports whether t can follow path without coming too close to
+// another artist it must be kept apart from.
+func (c *constraints) spaced(path []int, t int) bool {
+	a := c.artist[t]
+	if a < 0 || c.unspaced {
+		return true
+	}
+	for k := 1; k <= c.maxGap && k <= len(path); k++ {
+		if b := c.artist[path[len(path)-k]]; b >= 0 && c.gap[a][b] >= k {
+			return false
+		}
+	}
+	return true
+}
+
+// brokenRule is what a broken pin, lock or precedence counts for in
+// violations: more than any amount of crowded artists, so trading one for the
+// other never looks like progress.
+const brokenRule = 1 << 20
+
+// violations weighs the constraints path breaks. Tracks missing from path only
+// count against the rules that pin them.
+func (c *constraints) violations(path []int) int {
+	if len(path) == 0 {
+		return 0
+	}
+	pos := c.pos
+	for i, t := range path {
+		pos[t] = i
+	}
+	defer func() {
+		for _, t := range path {
+			pos[t] = -1
+		}
+	}()
+
+	count := 0
+	if c.opener >= 0 && path[0] != c.opener {
+		count += brokenRule
+	}
+	if c.closer >= 0 && path[len(path)-1] != c.closer {
+		count += brokenRule
+	}
+	for i, t := range path {
+		if nt := c.next[t]; nt >= 0 && (i+1 == len(path) || path[i+1] != nt) {
+			count += brokenRule
+		}
+		if pt := c.prev[t]; pt >= 0 && (i == 0 || path[i-1] != pt) {
+			count += brokenRule
+		}
+		if c.head[t] == t {
+			for _, a := range c.unitBefore[t] {
+				if pos[a] > i {
+					count += brokenRule
+				}
+			}
+		}
+		if c.artist[t] >= 0 {
+			for k := 1; k <= c.maxGap && k <= i; k++ {
+				if b := c.artist[path[i-k]]; b >= 0 && c.gap[c.artist[t]][b] >= k {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// repair moves whole units around path until it keeps every constraint. It is
+// a min-conflicts search: each step takes a unit that comes too close to
+// another artist and moves it wherever the fewest constraints break, including
+// sideways moves so it can walk off a plateau. Choices between equals come from
+// a fixed seed, so the same path is always repaired the same way. It reports
+// whether every constraint holds before time runs out.
+func (c *constraints) repair(path []int, clock *clock) bool {
+	r := rand.New(rand.NewSource(1))
+	broken := c.violations(path)
+	candidate := make([]int, 0, len(path))
+	for broken > 0 {
+		units := c.crowded(path)
+		if len(units) == 0 {
+			return false // only spacing can be repaired
+		}
+		i := units[r.Intn(len(units))]
+		length := c.unitLen[path[i]]
+
+		best, moves := broken+1, []int(nil)
+		for k := -1; k < len(path); k++ {
+			if k >= i-1 && k < i+length {
+				continue
+			}
+			if clock.outOfTime() {
+				return false
+			}
+			candidate = moveRun(candidate, path, i, length, k)
+			switch v := c.violations(candidate); {
+			case v < best:
+				best, moves = v, []int{k}
+			case v == best:
+				moves = append(moves, k)
+			}
+		}
+		if best > broken {
+			continue // every move makes it worse; try another unit
+		}
+		copy(path, moveRun(candidate, path, i, length, moves[r.Intn(len(moves))]))
+		broken = best
+	}
+	return true
+}
+
+// moveRun writes path to dst with path[i:i+length] moved to just after the track
+// at index k, or to the front when k is -1, and returns it.
+func moveRun(dst, path []int, i, length, k int) []int {
+	dst = dst[:0]
+	if k < 0 {
+		dst = append(dst, path[i:i+length]...)
+	}
+	for t, track := range path {
+		if t >= i && t < i+length {
+			continue
+		}
+		dst = append(dst, track)
+		if t == k {
+			dst = append(dst, path[i:i+length]...)
+		}
+	}
+	return dst
+}
+
+// crowded lists the positions of the heads of movable units holding a track
+// that comes too close to another artist.
+func (c *constraints) crowded(path []int) []int {
+	var units []int
+	mark := func(i int) {
+		for i > 0 && c.prev[path[i]] >= 0 {
+			i--
+		}
+		if h := path[i]; h != c.opener && h != c.closer && !slices.Contains(units, i) {
+			units = append(units, i)
+		}
+	}
+	for i, t := range path {
+		if c.artist[t] < 0 {
+			continue
+		}
+		for k := 1; k <= c.maxGap && k <= i; k++ {
+			if b := c.artist[path[i-k]]; b >= 0 && c.gap[c.artist[t]][b] >= k {
+				mark(i)
+				mark(i - k)
+			}
+		}
+	}
+	return units
+}
+
+// forced reports whether t must be in any set: pinned and locked tracks are.
+func (c *constraints) forced(t int) bool {
+	return t == c.opener || t == c.closer || c.next[t] >= 0 || c.prev[t] >= 0
+}
+
+func filled(n, v int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = v
+	}
+	return s
+}
@@ -0,0 +1,127 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	eng "github.com/cartomix/cancun/gen/go/engine"
+)
+
+func hashes(ids []*common.TrackId) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.GetContentHash()
+	}
+	return out
+}
+
+func TestOptimizeKeepsConstraints(t *testing.T) {
+	analyses := randomAnalyses(rand.New(rand.NewSource(17)), 24)
+	artists := make(map[string]string)
+	for i, a := range analyses {
+		artists[a.GetId().GetContentHash()] = fmt.Sprintf("Artist %d", i%6)
+	}
+
+	for _, curve := range []*eng.EnergyCurve{nil, {Preset: "slow_burn"}} {
+		result, err := Optimize(analyses, Options{
+			Mode:          eng.SetMode_PEAK_TIME,
+			EnergyCurve:   curve,
+			Opener:        "t005",
+			Closer:        "t011",
+			LockedChains:  [][]string{{"t020", "t003", "t014"}},
+			Precedences:   []Precedence{{Before: "t007", After: "t020"}, {Before: "t001", After: "t002"}},
+			ArtistSpacing: []ArtistSpacing{{ArtistA: "artist 0", ArtistB: "ARTIST 0", MinTracks: 3}, {ArtistA: "Artist 1", ArtistB: "Artist 2", MinTracks: 1}},
+			Artists:       artists,
+			TimeBudget:    200 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("optimize: %v", err)
+		}
+		order := hashes(result.Order)
+		if len(order) != len(analyses) {
+			t.Fatalf("planned %d of %d tracks", len(order), len(analyses))
+		}
+		if order[0] != "t005" || order[len(order)-1] != "t011" {
+			t.Errorf("set runs %s ... %s, want t005 ... t011", order[0], order[len(order)-1])
+		}
+		chain := slices.Index(order, "t020")
+		if chain < 0 || chain+2 >= len(order) || order[chain+1] != "t003" || order[chain+2] != "t014" {
+			t.Errorf("locked chain broken: %v", order)
+		}
+		if slices.Index(order, "t007") > chain || slices.Index(order, "t001") > slices.Index(order, "t002") {
+			t.Errorf("precedence broken: %v", order)
+		}
+		for i, h := range order {
+			for k := 1; k <= 3 && k <= i; k++ {
+				a, b := artists[h], artists[order[i-k]]
+				if a == "Artist 0" && b == "Artist 0" {
+					t.Errorf("Artist 0 at %d and %d", i-k, i)
+				}
+				if k == 1 && (a == "Artist 1" && b == "Artist 2" || a == "Artist 2" && b == "Artist 1") {
+					t.Errorf("Artists 1 and 2 back to back at %d", i)
+				}
+			}
+		}
+	}
+}
+
+func TestTargetLengthKeepsLockedTracks(t *testing.T) {
+	analyses := timedAnalyses(rand.New(rand.NewSource(9)), 30)
+	result, err := Optimize(analyses, Options{
+		TargetLength: 45 * time.Minute,
+		Closer:       "t002",
+		LockedChains: [][]string{{"t010", "t020"}},
+		TimeBudget:   500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	order := hashes(result.Order)
+	if len(order) >= len(analyses) {
+		t.Fatalf("planned %d of %d tracks for 45 minutes", len(order), len(analyses))
+	}
+	if order[len(order)-1] != "t002" {
+		t.Errorf("closer = %s, want t002", order[len(order)-1])
+	}
+	if i := slices.Index(order, "t010"); i < 0 || order[i+1] != "t020" {
+		t.Errorf("locked chain broken: %v", order)
+	}
+}
+
+func TestInfeasibleConstraints(t *testing.T) {
+	analyses := randomAnalyses(rand.New(rand.NewSource(3)), 6)
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"banned opener", Options{Opener: "t001", BanHashes: map[string]bool{"t001": true}}},
+		{"same opener and closer", Options{Opener: "t001", Closer: "t001"}},
+		{"opener inside chain", Options{Opener: "t001", LockedChains: [][]string{{"t002", "t001"}}}},
+		{"conflicting chains", Options{LockedChains: [][]string{{"t001", "t002"}, {"t001", "t003"}}}},
+		{"chain loop", Options{LockedChains: [][]string{{"t001", "t002"}, {"t002", "t001"}}}},
+		{"precedence cycle", Options{Precedences: []Precedence{{"t001", "t002"}, {"t002", "t003"}, {"t003", "t001"}}}},
+		{"precedence splits chain", Options{
+			LockedChains: [][]string{{"t001", "t002"}},
+			Precedences:  []Precedence{{"t001", "t003"}, {"t003", "t002"}},
+		}},
+		{"before the opener", Options{Opener: "t001", Precedences: []Precedence{{"t002", "t001"}}}},
+		{"after the closer", Options{Closer: "t001", Precedences: []Precedence{{"t001", "t002"}}}},
+		{"artist spacing", Options{
+			ArtistSpacing: []ArtistSpacing{{ArtistA: "dj", ArtistB: "dj", MinTracks: 2}},
+			Artists:       map[string]string{"t000": "DJ", "t001": "DJ", "t002": "DJ"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Optimize(analyses, tt.opts)
+			if !errors.Is(err, ErrConstraints) {
+				t.Fatalf("err = %v, want ErrConstraints", err)
+			}
+		})
+	}
+}
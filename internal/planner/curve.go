@@ -0,0 +1,199 @@
+package planner
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	eng "github.com/cartomix/cancun/gen/go/engine"
+)
+
+// ErrInvalidCurve is returned for energy curves that can't be planned against.
+var ErrInvalidCurve = errors.New("invalid energy curve")
+
+// DefaultCurveWeight is the score lost per energy level a track misses the
+// curve by, when the request doesn't set one.
+const DefaultCurveWeight = 1.5
+
+// curveSamples is how many points of each track are compared with the curve.
+const curveSamples = 8
+
+// CurvePoint is a target energy at a position in the set, from 0 at the start of
+// the first track to 1 at the end of the last.
+type CurvePoint struct {
+	Position float64
+	Energy   float64
+}
+
+// CurvePresets are the named energy arcs a request can ask for.
+var CurvePresets = map[string][]CurvePoint{
+	// Start low and build steadily to a late peak, easing off for the last track.
+	"slow_burn": {{0, 3}, {0.6, 6}, {0.9, 9}, {1, 8}},
+	// Two peaks with a breather between them.
+	"double_peak": {{0, 4}, {0.3, 8}, {0.5, 5}, {0.8, 9}, {1, 6}},
+	// Closing set: open near the top, then wind down for the morning.
+	"sunrise_closer": {{0, 7}, {0.3, 9}, {0.65, 6}, {1, 3}},
+}
+
+// resolveCurve turns a requested curve into points sorted by position. Points
+// given in minutes are placed using setSeconds, the set's running time.
+func resolveCurve(c *eng.EnergyCurve, setSeconds float64) ([]CurvePoint, error) {
+	preset := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(c.GetPreset())))
+	switch {
+	case preset != "" && len(c.GetPoints()) > 0:
+		return nil, fmt.Errorf("%w: give a preset or points, not both", ErrInvalidCurve)
+	case preset != "":
+		points, ok := CurvePresets[preset]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown preset %q", ErrInvalidCurve, c.GetPreset())
+		}
+		return points, nil
+	case len(c.GetPoints()) == 0:
+		return nil, fmt.Errorf("%w: no points", ErrInvalidCurve)
+	}
+
+	points := make([]CurvePoint, 0, len(c.GetPoints()))
+	for _, p := range c.GetPoints() {
+		if p.GetEnergy() < 1 || p.GetEnergy() > 10 {
+			return nil, fmt.Errorf("%w: energy %.1f outside 1-10", ErrInvalidCurve, p.GetEnergy())
+		}
+		var pos float64
+		switch at := p.GetAt().(type) {
+		case *eng.EnergyPoint_Position:
+			pos = at.Position
+		case *eng.EnergyPoint_Minutes:
+			if setSeconds <= 0 {
+				return nil, fmt.Errorf("%w: points in minutes need track durations", ErrInvalidCurve)
+			}
+			pos = at.Minutes * 60 / setSeconds
+		default:
+			return nil, fmt.Errorf("%w: point needs a position or minutes", ErrInvalidCurve)
+		}
+		if pos < 0 || math.IsNaN(pos) {
+			return nil, fmt.Errorf("%w: negative position", ErrInvalidCurve)
+		}
+		points = append(points, CurvePoint{Position: min(pos, 1), Energy: float64(p.GetEnergy())})
+	}
+	slices.SortStableFunc(points, func(a, b CurvePoint) int { return cmp.Compare(a.Position, b.Position) })
+	return points, nil
+}
+
+// RemainingCurve is what is left of a curve once elapsedSeconds of a set of
+// setSeconds have played: the same arc from that point on, with positions
+// rescaled to run from 0 to 1 over the rest of the set. Once the set has run
+// its length the curve holds its final energy.
+func RemainingCurve(c *eng.EnergyCurve, setSeconds, elapsedSeconds float64) (*eng.EnergyCurve, error) {
+	points, err := resolveCurve(c, setSeconds)
+	if err != nil {
+		return nil, err
+	}
+	from := 0.0
+	if setSeconds > 0 {
+		from = min(max(elapsedSeconds/setSeconds, 0), 1)
+	}
+	rest := &eng.EnergyCurve{Weight: c.GetWeight()}
+	at := func(pos, energy float64) {
+		rest.Points = append(rest.Points, &eng.EnergyPoint{
+			At:     &eng.EnergyPoint_Position{Position: pos},
+			Energy: float32(energy),
+		})
+	}
+	at(0, curveAt(points, from))
+	if from >= 1 {
+		return rest, nil
+	}
+	for _, p := range points {
+		if p.Position > from {
+			at((p.Position-from)/(1-from), p.Energy)
+		}
+	}
+	return rest, nil
+}
+
+// curveAt interpolates the curve linearly, holding the end values beyond the
+// first and last points.
+func curveAt(points []CurvePoint, pos float64) float64 {
+	if pos <= points[0].Position {
+		return points[0].Energy
+	}
+	for i := 1; i < len(points); i++ {
+		if pos <= points[i].Position {
+			a, b := points[i-1], points[i]
+			if b.Position == a.Position {
+				return b.Energy
+			}
+			return a.Energy + (b.Energy-a.Energy)*(pos-a.Position)/(b.Position-a.Position)
+		}
+	}
+	return points[len(points)-1].Energy
+}
+
+// energyProfile samples a track's energy at curveSamples evenly spaced points,
+// from its energy segments when it has them. It returns nil when the track's
+// energy is unknown.
+func energyProfile(a *common.TrackAnalysis) []float64 {
+	profile := make([]float64, curveSamples)
+	segments := a.GetEnergySegments()
+	var beats int32
+	for _, seg := range segments {
+		beats = max(beats, seg.GetEndBeat())
+	}
+	if beats <= 0 {
+		if a.GetEnergyGlobal() <= 0 {
+			return nil
+		}
+		for m := range profile {
+			profile[m] = float64(a.GetEnergyGlobal())
+		}
+		return profile
+	}
+
+	for m := range profile {
+		beat := (float64(m) + 0.5) / curveSamples * float64(beats)
+		profile[m] = float64(a.GetEnergyGlobal())
+		for _, seg := range segments {
+			if beat >= float64(seg.GetStartBeat()) && beat < float64(seg.GetEndBeat()) {
+				profile[m] = float64(seg.GetLevel())
+				break
+			}
+		}
+	}
+	return profile
+}
+
+// spanTargets samples the curve at curveSamples points between positions from
+// and to, matching the points energyProfile samples within a track.
+func spanTargets(points []CurvePoint, from, to float64) []float64 {
+	targets := make([]float64, curveSamples)
+	for m := range targets {
+		targets[m] = curveAt(points, from+(to-from)*(float64(m)+0.5)/curveSamples)
+	}
+	return targets
+}
+
+// curveMiss is the mean distance between a track's profile and a slot's targets.
+func curveMiss(profile, target []float64) float64 {
+	if profile == nil {
+		return 0
+	}
+	var sum float64
+	for m := range profile {
+		sum += math.Abs(profile[m] - target[m])
+	}
+	return sum / float64(len(profile))
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
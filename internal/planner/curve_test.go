@@ -0,0 +1,133 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	eng "github.com/cartomix/cancun/gen/go/engine"
+)
+
+func TestEnergyCurveShapesSet(t *testing.T) {
+	var analyses []*common.TrackAnalysis
+	for i := 0; i < 10; i++ {
+		analyses = append(analyses, makeAnalysis(fmt.Sprintf("e%d", i), 124, "8A", int32(i%9+1)))
+	}
+
+	result, err := Optimize(analyses, Options{
+		Mode:        eng.SetMode_WARM_UP,
+		EnergyCurve: &eng.EnergyCurve{Preset: "Sunrise Closer"},
+	})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	if len(result.EnergySlots) != len(analyses) {
+		t.Fatalf("energy slots = %d, want %d", len(result.EnergySlots), len(analyses))
+	}
+	first, last := result.EnergySlots[0], result.EnergySlots[len(result.EnergySlots)-1]
+	if first.GetActualEnergy() < 6 {
+		t.Errorf("sunrise closer opened at energy %.0f, despite warm-up mode", first.GetActualEnergy())
+	}
+	if last.GetActualEnergy() > 4 {
+		t.Errorf("sunrise closer ended at energy %.0f", last.GetActualEnergy())
+	}
+	if last.GetTargetEnergy() >= first.GetTargetEnergy() {
+		t.Errorf("targets should fall: first %.1f, last %.1f", first.GetTargetEnergy(), last.GetTargetEnergy())
+	}
+	for i, slot := range result.EnergySlots {
+		if slot.GetId().GetContentHash() != result.Order[i].GetContentHash() {
+			t.Fatalf("slot %d is %s, order has %s", i, slot.GetId().GetContentHash(), result.Order[i].GetContentHash())
+		}
+	}
+
+	// A rising curve reverses the shape.
+	rising, err := Optimize(analyses, Options{EnergyCurve: &eng.EnergyCurve{Points: []*eng.EnergyPoint{
+		{At: &eng.EnergyPoint_Position{Position: 0}, Energy: 1},
+		{At: &eng.EnergyPoint_Position{Position: 1}, Energy: 10},
+	}}})
+	if err != nil {
+		t.Fatalf("optimize rising: %v", err)
+	}
+	if rising.EnergySlots[0].GetActualEnergy() > rising.EnergySlots[9].GetActualEnergy() {
+		t.Errorf("rising curve planned falling energy")
+	}
+	if rising.EnergyError > 1.5 {
+		t.Errorf("rising curve energy error = %.2f", rising.EnergyError)
+	}
+}
+
+func TestResolveCurve(t *testing.T) {
+	invalid := []*eng.EnergyCurve{
+		{},
+		{Preset: "ambient_drift"},
+		{Preset: "slow_burn", Points: []*eng.EnergyPoint{{At: &eng.EnergyPoint_Position{Position: 0}, Energy: 5}}},
+		{Points: []*eng.EnergyPoint{{At: &eng.EnergyPoint_Position{Position: 0}, Energy: 11}}},
+		{Points: []*eng.EnergyPoint{{Energy: 5}}},
+		{Points: []*eng.EnergyPoint{{At: &eng.EnergyPoint_Minutes{Minutes: 10}, Energy: 5}}},
+	}
+	for i, c := range invalid {
+		if _, err := resolveCurve(c, 0); !errors.Is(err, ErrInvalidCurve) {
+			t.Errorf("curve %d: err = %v, want ErrInvalidCurve", i, err)
+		}
+	}
+
+	points, err := resolveCurve(&eng.EnergyCurve{Points: []*eng.EnergyPoint{
+		{At: &eng.EnergyPoint_Minutes{Minutes: 45}, Energy: 8},
+		{At: &eng.EnergyPoint_Minutes{Minutes: 0}, Energy: 4},
+		{At: &eng.EnergyPoint_Minutes{Minutes: 120}, Energy: 6},
+	}}, 90*60)
+	if err != nil {
+		t.Fatalf("resolve minutes: %v", err)
+	}
+	want := []CurvePoint{{0, 4}, {0.5, 8}, {1, 6}}
+	for i := range want {
+		if math.Abs(points[i].Position-want[i].Position) > 1e-9 || points[i].Energy != want[i].Energy {
+			t.Fatalf("points = %v, want %v", points, want)
+		}
+	}
+	if got := curveAt(points, 0.25); math.Abs(got-6) > 1e-9 {
+		t.Errorf("curveAt(0.25) = %v, want 6", got)
+	}
+}
+
+func TestRemainingCurve(t *testing.T) {
+	rest, err := RemainingCurve(&eng.EnergyCurve{Preset: "slow_burn", Weight: 2}, 100*60, 50*60)
+	if err != nil {
+		t.Fatalf("remaining: %v", err)
+	}
+	points, err := resolveCurve(rest, 50*60)
+	if err != nil {
+		t.Fatalf("resolve remaining: %v", err)
+	}
+	want := []CurvePoint{{0, 5.5}, {0.2, 6}, {0.8, 9}, {1, 8}}
+	if len(points) != len(want) || rest.GetWeight() != 2 {
+		t.Fatalf("points = %v (weight %v), want %v", points, rest.GetWeight(), want)
+	}
+	for i := range want {
+		if math.Abs(points[i].Position-want[i].Position) > 1e-6 || math.Abs(points[i].Energy-want[i].Energy) > 1e-6 {
+			t.Fatalf("points = %v, want %v", points, want)
+		}
+	}
+
+	over, err := RemainingCurve(&eng.EnergyCurve{Preset: "slow_burn"}, 60, 120)
+	if err != nil || len(over.GetPoints()) != 1 || over.GetPoints()[0].GetEnergy() != 8 {
+		t.Errorf("curve past the end = %v, %v; want the final energy", over, err)
+	}
+}
+
+func TestEnergyProfileUsesSegments(t *testing.T) {
+	a := makeAnalysis("seg", 124, "8A", 5)
+	a.EnergySegments = []*common.EnergySegment{
+		{StartBeat: 0, EndBeat: 64, Level: 2},
+		{StartBeat: 64, EndBeat: 128, Level: 9},
+	}
+	profile := energyProfile(a)
+	if profile[0] != 2 || profile[curveSamples-1] != 9 {
+		t.Fatalf("profile = %v", profile)
+	}
+	if energyProfile(&common.TrackAnalysis{}) != nil {
+		t.Fatal("unknown energy should have no profile")
+	}
+}
@@ -0,0 +1,368 @@
+package planner
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Search defaults, used when the matching Options field is zero.
+const (
+	DefaultTimeBudget = 250 * time.Millisecond
+	DefaultBeamWidth  = 16
+)
+
+// WeakestEdgeCount is how many of the lowest-scoring transitions a Result reports.
+const WeakestEdgeCount = 3
+
+// improvementEpsilon keeps float noise from counting as an improvement.
+const improvementEpsilon = 1e-9
+
+// searcher finds the ordering that maximizes the summed edge scores of a path,
+// plus per-slot scores when an energy curve is set. Scores are precomputed, so
+// the search itself never calls scoreEdge.
+type searcher struct {
+	clock
+	budget time.Duration
+	score  [][]float64  // score[i][j] is the edge i -> j
+	slot   [][]float64  // slot[i][k] scores track i at position k; nil when unused
+	start  int          // fixed opener, or -1 to let the search choose
+	cons   *constraints // ordering rules the path must keep; nil when none
+	// relaxed lets beam crowd artists on a step where spacing strands it.
+	relaxed bool
+}
+
+func newSearcher(score [][]float64, budget time.Duration) *searcher {
+	return &searcher{clock: newClock(budget), budget: budget, score: score, start: -1}
+}
+
+// clock tracks a search's time budget.
+type clock struct {
+	deadline time.Time
+	checks   int
+	expired  bool
+}
+
+func newClock(budget time.Duration) clock {
+	return clock{deadline: time.Now().Add(budget)}
+}
+
+// outOfTime reports whether the budget is spent. The clock is only read every
+// few hundred calls so inner loops can check it cheaply.
+func (c *clock) outOfTime() bool {
+	if !c.expired {
+		c.checks++
+		if c.checks%256 == 0 && time.Now().After(c.deadline) {
+			c.expired = true
+		}
+	}
+	return c.expired
+}
+
+// edge scores a -> b. An index below zero stands for "before the start" or "past
+// the end of the set" and costs nothing.
+func (s *searcher) edge(a, b int) float64 {
+	if a < 0 || b < 0 {
+		return 0
+	}
+	return s.score[a][b]
+}
+
+func (s *searcher) slotScore(track, pos int) float64 {
+	if s.slot == nil {
+		return 0
+	}
+	return s.slot[track][pos]
+}
+
+func (s *searcher) total(path []int) float64 {
+	var sum float64
+	for i, t := range path {
+		if i > 0 {
+			sum += s.score[path[i-1]][t]
+		}
+		sum += s.slotScore(t, i)
+	}
+	return sum
+}
+
+// firstMovable is the first position local search may change.
+func (s *searcher) firstMovable() int {
+	if s.start >= 0 {
+		return 1
+	}
+	return 0
+}
+
+// solve returns the best ordering it finds: the better of a greedy walk and a
+// beam search, refined by 2-opt and or-opt moves until no move helps or the
+// budget runs out. When artist spacing strands both searches, a greedy walk
+// that crowds artists only where it must is repaired instead, with a fresh
+// budget. It returns nil when no order that keeps the constraints is found.
+func (s *searcher) solve(beamWidth int) []int {
+	best := s.beam(1)
+	if beamWidth > 1 && !s.outOfTime() {
+		if wide := s.beam(beamWidth); wide != nil && (best == nil || s.total(wide) > s.total(best)+improvementEpsilon) {
+			best = wide
+		}
+	}
+	if best == nil && s.cons != nil {
+		s.relaxed = true
+		best = s.beam(1)
+		s.relaxed = false
+		repairClock := newClock(s.budget)
+		if best != nil && !s.cons.repair(best, &repairClock) {
+			best = nil
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	for !s.outOfTime() && (s.twoOpt(best) || s.orOpt(best)) {
+	}
+	return best
+}
+
+type beamState struct {
+	path  []int
+	used  []bool
+	score float64
+}
+
+type beamChild struct {
+	parent *beamState
+	next   int
+	score  float64
+}
+
+// beam grows paths one track at a time, keeping the width best partial paths.
+// A width of 1 is the greedy nearest-neighbour walk. When time runs out the best
+// path so far is finished greedily. Paths the constraints strand are dropped;
+// beam returns nil if none survive.
+func (s *searcher) beam(width int) []int {
+	n := len(s.score)
+	root := &beamState{used: make([]bool, n)}
+	var children []beamChild
+	if s.start >= 0 {
+		children = []beamChild{{root, s.start, s.slotScore(s.start, 0)}}
+	} else {
+		for t := 0; t < n; t++ {
+			if s.allows(root, t) {
+				children = append(children, beamChild{root, t, s.slotScore(t, 0)})
+			}
+		}
+		slices.SortStableFunc(children, compareChildren)
+	}
+	beam := s.advance(children, width)
+	if len(beam) == 0 {
+		return nil
+	}
+
+	for step := 1; step < n; step++ {
+		if s.outOfTime() {
+			beam = beam[:1]
+			width = 1
+		}
+
+		children = children[:0]
+		for _, st := range beam {
+			children = s.expand(children, st, step, width)
+		}
+		if len(children) == 0 && s.relaxed {
+			s.cons.unspaced = true
+			children = s.expand(children, beam[0], step, width)
+			s.cons.unspaced = false
+		}
+		slices.SortStableFunc(children, compareChildren)
+		beam = s.advance(children, width)
+		if len(beam) == 0 {
+			return nil
+		}
+	}
+	return beam[0].path
+}
+
+// expand appends the width best children of st at step to children.
+func (s *searcher) expand(children []beamChild, st *beamState, step, width int) []beamChild {
+	last := st.path[len(st.path)-1]
+	var local []beamChild
+	for next := range s.score {
+		if !st.used[next] && s.allows(st, next) {
+			local = append(local, beamChild{st, next, st.score + s.score[last][next] + s.slotScore(next, step)})
+		}
+	}
+	slices.SortStableFunc(local, compareChildren)
+	return append(children, local[:min(width, len(local))]...)
+}
+
+// allows reports whether the constraints let next extend st.
+func (s *searcher) allows(st *beamState, next int) bool {
+	return s.cons == nil || s.cons.allows(st.path, st.used, next, len(s.score)-len(st.path))
+}
+
+// keeps reports whether path still keeps the constraints once move is applied
+// to a copy of it.
+func (s *searcher) keeps(path []int, move func([]int)) bool {
+	if s.cons == nil {
+		return true
+	}
+	candidate := slices.Clone(path)
+	move(candidate)
+	return s.cons.violations(candidate) == 0
+}
+
+// advance turns the width best children, which must be sorted, into states.
+func (s *searcher) advance(children []beamChild, width int) []*beamState {
+	states := make([]*beamState, 0, width)
+	for _, c := range children[:min(width, len(children))] {
+		path := append(slices.Clip(c.parent.path), c.next)
+		used := slices.Clone(c.parent.used)
+		used[c.next] = true
+		states = append(states, &beamState{path: path, used: used, score: c.score})
+	}
+	return states
+}
+
+func compareChildren(a, b beamChild) int {
+	return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(a.next, b.next))
+}
+
+// prefixSums returns running totals of the path's edges walked forwards and
+// backwards: fwd[k] sums path[t] -> path[t+1] and rev[k] sums path[t+1] -> path[t]
+// for t < k.
+func (s *searcher) prefixSums(path []int) (fwd, rev []float64) {
+	fwd = make([]float64, len(path))
+	rev = make([]float64, len(path))
+	for t := 1; t < len(path); t++ {
+		fwd[t] = fwd[t-1] + s.score[path[t-1]][path[t]]
+		rev[t] = rev[t-1] + s.score[path[t]][path[t-1]]
+	}
+	return fwd, rev
+}
+
+// twoOpt applies the first segment reversal that raises the total score.
+func (s *searcher) twoOpt(path []int) bool {
+	n := len(path)
+	fwd, rev := s.prefixSums(path)
+	at := func(t int) int {
+		if t >= 0 && t < n {
+			return path[t]
+		}
+		return -1
+	}
+
+	for i := s.firstMovable(); i < n-1; i++ {
+		for j := i + 1; j < n; j++ {
+			if s.outOfTime() {
+				return false
+			}
+			before := s.edge(at(i-1), path[i]) + (fwd[j] - fwd[i]) + s.edge(path[j], at(j+1))
+			reversed := s.edge(at(i-1), path[j]) + (rev[j] - rev[i]) + s.edge(path[i], at(j+1))
+			if s.slot != nil {
+				for k := i; k <= j; k++ {
+					reversed += s.slot[path[k]][i+j-k]
+					before += s.slot[path[k]][k]
+				}
+			}
+			if reversed > before+improvementEpsilon && s.keeps(path, func(p []int) { slices.Reverse(p[i : j+1]) }) {
+				slices.Reverse(path[i : j+1])
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// orOpt applies the first move of a run of up to three tracks, optionally
+// reversed, to another position that raises the total score.
+func (s *searcher) orOpt(path []int) bool {
+	n := len(path)
+	fwd, rev := s.prefixSums(path)
+	at := func(t int) int {
+		if t >= 0 && t < n {
+			return path[t]
+		}
+		return -1
+	}
+
+	first := s.firstMovable()
+	for length := 1; length <= 3; length++ {
+		for i := first; i+length <= n; i++ {
+			end := i + length - 1
+			prev, next := at(i-1), at(i+length)
+			removed := s.edge(prev, next) - s.edge(prev, path[i]) - s.edge(path[end], next)
+			inner := fwd[end] - fwd[i]
+
+			// k is the position the run goes after; -1 puts it first.
+			for k := first - 1; k < n; k++ {
+				if k >= i-1 && k <= end {
+					continue
+				}
+				for _, flip := range []bool{false, true} {
+					if flip && length == 1 {
+						continue
+					}
+					if s.outOfTime() {
+						return false
+					}
+					head, tail, innerAfter := path[i], path[end], inner
+					if flip {
+						head, tail, innerAfter = path[end], path[i], rev[end]-rev[i]
+					}
+					inserted := s.edge(at(k), head) + s.edge(tail, at(k+1)) - s.edge(at(k), at(k+1))
+					delta := removed + inserted + innerAfter - inner
+					if s.slot != nil {
+						delta += s.moveSlotDelta(path, i, length, k, flip)
+					}
+					if delta > improvementEpsilon && s.keeps(path, func(p []int) { moveSegment(p, i, length, k, flip) }) {
+						moveSegment(path, i, length, k, flip)
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// moveSlotDelta is the change in slot scores from the moveSegment call with the
+// same arguments: the run lands in new positions and the tracks it jumps over
+// shift by its length.
+func (s *searcher) moveSlotDelta(path []int, i, length, k int, flip bool) float64 {
+	var delta float64
+	dest := k + 1 // where the run starts after the move
+	if k > i {
+		for t := i + length; t <= k; t++ {
+			delta += s.slot[path[t]][t-length] - s.slot[path[t]][t]
+		}
+		dest = k - length + 1
+	} else {
+		for t := k + 1; t < i; t++ {
+			delta += s.slot[path[t]][t+length] - s.slot[path[t]][t]
+		}
+	}
+	for m := 0; m < length; m++ {
+		track := path[i+m]
+		if flip {
+			track = path[i+length-1-m]
+		}
+		delta += s.slot[track][dest+m] - s.slot[path[i+m]][i+m]
+	}
+	return delta
+}
+
+// moveSegment moves path[i:i+length] to just after the track currently at index
+// k, or to the front when k is -1, reversing it when flip is set.
+func moveSegment(path []int, i, length, k int, flip bool) {
+	segment := slices.Clone(path[i : i+length])
+	if flip {
+		slices.Reverse(segment)
+	}
+	pos := 0
+	rest := slices.Delete(slices.Clone(path), i, i+length)
+	if k >= 0 {
+		pos = slices.Index(rest, path[k]) + 1
+	}
+	rest = slices.Insert(rest, pos, segment...)
+	copy(path, rest)
+}
@@ -0,0 +1,182 @@
+package planner
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	eng "github.com/cartomix/cancun/gen/go/engine"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func randomAnalyses(r *rand.Rand, n int) []*common.TrackAnalysis {
+	analyses := make([]*common.TrackAnalysis, n)
+	for i := range analyses {
+		key := fmt.Sprintf("%d%c", r.Intn(12)+1, "AB"[r.Intn(2)])
+		analyses[i] = makeAnalysis(fmt.Sprintf("t%03d", i), 118+r.Float64()*12, key, int32(r.Intn(10)+1))
+	}
+	return analyses
+}
+
+// greedyTotal scores the nearest-neighbour walk the planner used to return.
+func greedyTotal(analyses []*common.TrackAnalysis, opts Options) float64 {
+	start := chooseStart(analyses, opts.Mode)
+	score := make([][]float64, len(analyses))
+	startIndex := 0
+	for i, from := range analyses {
+		if from == start {
+			startIndex = i
+		}
+		score[i] = make([]float64, len(analyses))
+		for j, to := range analyses {
+			if i != j {
+				score[i][j], _ = scoreEdge(from, to, opts)
+			}
+		}
+	}
+	s := newSearcher(score, time.Minute)
+	s.start = startIndex
+	return s.total(s.beam(1))
+}
+
+func TestOptimizeBeatsGreedy(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	improved := 0
+	for trial := 0; trial < 20; trial++ {
+		analyses := randomAnalyses(r, 25)
+		opts := Options{Mode: eng.SetMode_PEAK_TIME, TimeBudget: time.Second}
+
+		result, err := Optimize(analyses, opts)
+		if err != nil {
+			t.Fatalf("optimize: %v", err)
+		}
+		greedy := greedyTotal(analyses, opts)
+		if result.TotalScore < greedy-1e-6 {
+			t.Fatalf("trial %d: optimized %.3f < greedy %.3f", trial, result.TotalScore, greedy)
+		}
+		if result.TotalScore > greedy+1e-6 {
+			improved++
+		}
+	}
+	if improved == 0 {
+		t.Fatal("optimizer never improved on the greedy walk")
+	}
+}
+
+func TestOptimizeReportsScores(t *testing.T) {
+	analyses := randomAnalyses(rand.New(rand.NewSource(3)), 12)
+	result, err := Optimize(analyses, Options{Mode: eng.SetMode_WARM_UP})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+
+	var sum float64
+	for _, e := range result.Explanations {
+		sum += float64(e.GetScore())
+	}
+	if math.Abs(sum-result.TotalScore) > 1e-3 {
+		t.Fatalf("total %.3f, explanations sum to %.3f", result.TotalScore, sum)
+	}
+
+	if len(result.WeakestEdges) != WeakestEdgeCount {
+		t.Fatalf("weakest edges = %d", len(result.WeakestEdges))
+	}
+	if !slices.IsSortedFunc(result.WeakestEdges, func(a, b *common.EdgeExplanation) int {
+		return int(math.Copysign(1, float64(a.GetScore()-b.GetScore())))
+	}) {
+		t.Fatal("weakest edges not sorted weakest first")
+	}
+	for _, e := range result.Explanations {
+		if e.GetScore() < result.WeakestEdges[len(result.WeakestEdges)-1].GetScore() &&
+			!slices.Contains(result.WeakestEdges, e) {
+			t.Fatalf("edge %s -> %s (%.2f) weaker than reported weakest edges",
+				e.GetFrom().GetContentHash(), e.GetTo().GetContentHash(), e.GetScore())
+		}
+	}
+}
+
+func TestOptimizeRespectsTimeBudget(t *testing.T) {
+	analyses := randomAnalyses(rand.New(rand.NewSource(11)), 400)
+	started := time.Now()
+	result, err := Optimize(analyses, Options{Mode: eng.SetMode_OPEN_FORMAT, TimeBudget: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	// Scoring the edge matrix isn't budgeted, so allow generous slack.
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Fatalf("optimize took %v with a 50ms budget", elapsed)
+	}
+	if len(result.Order) != len(analyses) {
+		t.Fatalf("order has %d tracks, want %d", len(result.Order), len(analyses))
+	}
+}
+
+func TestMoveSegment(t *testing.T) {
+	path := []int{0, 1, 2, 3, 4, 5}
+	moveSegment(path, 1, 2, 4, false)
+	if !slices.Equal(path, []int{0, 3, 4, 1, 2, 5}) {
+		t.Fatalf("forward move = %v", path)
+	}
+	path = []int{0, 1, 2, 3, 4, 5}
+	moveSegment(path, 3, 3, 0, true)
+	if !slices.Equal(path, []int{0, 5, 4, 3, 1, 2}) {
+		t.Fatalf("reversed move = %v", path)
+	}
+}
+
+// analyzedTrack is a six minute track at about 128 BPM shaped like a real
+// analysis: a marker on every beat, sections, energy segments and windows.
+func analyzedTrack(r *rand.Rand, i int) *common.TrackAnalysis {
+	key := fmt.Sprintf("%d%c", r.Intn(12)+1, "AB"[r.Intn(2)])
+	bpm := 122 + r.Float64()*12
+	a := makeAnalysis(fmt.Sprintf("t%03d", i), bpm, key, int32(r.Intn(10)+1))
+	a.DurationSeconds = 360
+	beats := int32(360 * bpm / 60)
+	a.Beatgrid.Beats = make([]*common.BeatMarker, beats)
+	for b := range beats {
+		a.Beatgrid.Beats[b] = &common.BeatMarker{
+			Index:      b,
+			Time:       durationpb.New(time.Duration(float64(b) * 60 / bpm * float64(time.Second))),
+			IsDownbeat: b%4 == 0,
+		}
+	}
+	a.Sections = []*common.Section{
+		{StartBeat: 0, EndBeat: 64, Label: common.SectionLabel_INTRO},
+		{StartBeat: 64, EndBeat: 256, Label: common.SectionLabel_VERSE},
+		{StartBeat: 256, EndBeat: 384, Label: common.SectionLabel_DROP},
+		{StartBeat: 384, EndBeat: 512, Label: common.SectionLabel_BREAKDOWN},
+		{StartBeat: 512, EndBeat: 640, Label: common.SectionLabel_DROP},
+		{StartBeat: 640, EndBeat: beats, Label: common.SectionLabel_OUTRO},
+	}
+	a.EnergySegments = []*common.EnergySegment{
+		{StartBeat: 0, EndBeat: 256, Level: 4},
+		{StartBeat: 256, EndBeat: 640, Level: 8},
+		{StartBeat: 640, EndBeat: beats, Level: 5},
+	}
+	a.TransitionWindows = []*common.TransitionWindow{
+		{StartBeat: 0, EndBeat: 64, Tag: "intro"},
+		{StartBeat: 640, EndBeat: 704, Tag: "outro"},
+	}
+	return a
+}
+
+// BenchmarkOptimize500 plans 500 analyzed tracks with the default time
+// budget. Every pair is scored before the budget starts counting, so this
+// shows how far past the budget a large pool runs.
+func BenchmarkOptimize500(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	analyses := make([]*common.TrackAnalysis, 500)
+	for i := range analyses {
+		analyses[i] = analyzedTrack(r, i)
+	}
+	b.ResetTimer()
+	for range b.N {
+		if _, err := Optimize(analyses, Options{Mode: eng.SetMode_PEAK_TIME}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,98 +1,349 @@
 package planner
 
 import (
+	"cmp"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
-	"strconv"
-	"strings"
+	"time"
 
 	"github.com/cartomix/cancun/gen/go/common"
 	eng "github.com/cartomix/cancun/gen/go/engine"
+	"github.com/cartomix/cancun/internal/camelot"
+	"github.com/cartomix/cancun/internal/tempo"
 )
 
 // Options controls how set planning scores transitions.
 type Options struct {
-	Mode           eng.SetMode
-	AllowKeyJumps  bool
-	MaxBpmStep     float64
-	MustPlayHashes map[string]bool
-	BanHashes      map[string]bool
+	Mode            eng.SetMode
+	AllowKeyJumps   bool
+	MaxBpmStep      float64
+	MustPlayHashes  map[string]bool
+	BanHashes       map[string]bool
+	TimeBudget      time.Duration // search time; DefaultTimeBudget when zero
+	BeamWidth       int           // DefaultBeamWidth when zero
+	EnergyCurve     *eng.EnergyCurve
+	TargetLength    time.Duration            // plan a subset that fits; every track when zero
+	LengthTolerance time.Duration            // allowed over/under; DefaultLengthTolerance of the target when zero
+	PlayFraction    float64                  // share of each track expected to play; 1 when zero
+	KeyWeights      camelot.Weights          // scores per key move; DefaultKeyWeights when nil
+	PitchRange      float64                  // fraction either way; tempo.DefaultPitchRange when zero
+	MasterTempo     bool                     // key lock on, so pitching doesn't shift keys
+	VibeWeight      float64                  // OpenL3 vibe term; DefaultVibeWeight when zero, off when negative
+	MixEmbeddings   map[string]MixEmbeddings // windowed vibes by content hash, for outro-to-intro matching
+	Opener          string                   // content hash of the track that must open the set
+	Closer          string                   // content hash of the track that must close it
+	LockedChains    [][]string               // runs of content hashes that play back to back, in order
+	Precedences     []Precedence             // tracks that must play somewhere before others
+	ArtistSpacing   []ArtistSpacing          // least tracks between artists
+	Artists         map[string]string        // artist by content hash, for ArtistSpacing
+	// Alternatives is how many runner-up plans to return besides the best, at
+	// most MaxAlternatives; none when zero.
+	Alternatives      int
+	MinDifferentEdges int  // transitions each alternative must not share with an earlier plan; a quarter of the set's when zero
+	DifferentOpeners  bool // each alternative must open with a track no earlier plan opens with
+}
+
+// Result is a planned set.
+type Result struct {
+	Order            []*common.TrackId
+	Explanations     []*common.EdgeExplanation // one per transition, in set order
+	TotalScore       float64                   // sum of the transition scores
+	WeakestEdges     []*common.EdgeExplanation // lowest-scoring transitions, weakest first
+	EnergySlots      []*eng.EnergySlot         // target vs. actual energy, with an energy curve
+	EnergyError      float64                   // mean energy levels missed per slot
+	EstimatedSeconds float64                   // mixed running time of the set
+	Alternatives     []*eng.AlternativePlan    // runner-up plans that differ from this one, highest total score first
 }
 
 // Plan produces an ordering of tracks with per-edge explanations.
 func Plan(analyses []*common.TrackAnalysis, opts Options) ([]*common.TrackId, []*common.EdgeExplanation, error) {
+	result, err := Optimize(analyses, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Order, result.Explanations, nil
+}
+
+// Optimize orders every track that isn't banned to maximize the summed
+// transition scores. The opener is chosen by mode, unless an energy curve is
+// given: then how closely each track's energy follows the curve at its slot is
+// scored too, and any track may open. The order comes from a beam search refined
+// by 2-opt and or-opt moves within opts.TimeBudget. Given the same input it
+// returns the same plan unless the budget cuts the search short.
+//
+// With a TargetLength, Optimize instead picks the subset of the pool whose mixed
+// running time fits the target, keeping every must-play track, and maximizes the
+// mean transition score of that subset.
+//
+// A pinned opener or closer, locked chains, precedences and artist spacing are
+// kept in every plan; pinned and locked tracks always play. Optimize returns
+// ErrConstraints when they contradict each other or no order keeps them all.
+//
+// With opts.Alternatives, Optimize also searches for runner-up plans that
+// differ from the best and from each other; see Options.MinDifferentEdges and
+// Options.DifferentOpeners. It returns fewer when no more plans differ enough.
+func Optimize(analyses []*common.TrackAnalysis, opts Options) (*Result, error) {
 	if len(analyses) == 0 {
-		return nil, nil, fmt.Errorf("no analyses provided")
+		return nil, fmt.Errorf("no analyses provided")
 	}
 
 	filtered := make([]*common.TrackAnalysis, 0, len(analyses))
+	seen := make(map[string]bool, len(analyses))
 	for _, a := range analyses {
 		if a == nil || a.GetId() == nil {
 			continue
 		}
-		if opts.BanHashes != nil && opts.BanHashes[a.GetId().GetContentHash()] {
+		hash := a.GetId().GetContentHash()
+		if opts.BanHashes != nil && opts.BanHashes[hash] {
 			continue
 		}
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
 		filtered = append(filtered, a)
 	}
 
 	if len(filtered) == 0 {
-		return nil, nil, fmt.Errorf("all tracks were filtered out")
+		return nil, fmt.Errorf("all tracks were filtered out")
 	}
 
-	if len(opts.MustPlayHashes) > 0 {
-		for hash := range opts.MustPlayHashes {
-			found := false
+	for hash := range opts.MustPlayHashes {
+		if !seen[hash] {
+			return nil, fmt.Errorf("must-play track %s missing analysis", hash)
+		}
+	}
+
+	fraction, err := playFraction(opts.PlayFraction)
+	if err != nil {
+		return nil, err
+	}
+	times := newTiming(filtered, fraction)
+
+	var points []CurvePoint
+	var profiles [][]float64
+	weight := DefaultCurveWeight
+	if opts.EnergyCurve != nil {
+		setSeconds := opts.TargetLength.Seconds()
+		if setSeconds <= 0 {
 			for _, a := range filtered {
-				if a.GetId().GetContentHash() == hash {
-					found = true
-					break
-				}
-			}
-			if !found {
-				return nil, nil, fmt.Errorf("must-play track %s missing analysis", hash)
+				setSeconds += a.GetDurationSeconds()
 			}
 		}
+		points, err = resolveCurve(opts.EnergyCurve, setSeconds)
+		if err != nil {
+			return nil, err
+		}
+		profiles = make([][]float64, len(filtered))
+		for i, a := range filtered {
+			profiles[i] = energyProfile(a)
+		}
+		if w := float64(opts.EnergyCurve.GetWeight()); w > 0 {
+			weight = w
+		}
+	}
+
+	cons, err := newConstraints(filtered, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	start := chooseStart(filtered, opts.Mode)
-	order := []*common.TrackAnalysis{start}
-	remaining := map[string]*common.TrackAnalysis{}
-	for _, a := range filtered {
-		if a.GetId().GetContentHash() == start.GetId().GetContentHash() {
-			continue
+	if cons != nil {
+		// The mode picks the opener among the tracks the constraints let open.
+		var openers []*common.TrackAnalysis
+		for i, a := range filtered {
+			if cons.canOpen(i) {
+				openers = append(openers, a)
+			}
+		}
+		if len(openers) > 0 {
+			start = chooseStart(openers, opts.Mode)
+		}
+	}
+	startIndex := 0
+	mixes := make([]*mixPoints, len(filtered))
+	for i, a := range filtered {
+		mixes[i] = newMixPoints(a)
+	}
+	score := make([][]float64, len(filtered))
+	for i, from := range filtered {
+		if from == start {
+			startIndex = i
+		}
+		score[i] = make([]float64, len(filtered))
+		for j, to := range filtered {
+			if i != j {
+				score[i][j] = rateEdge(from, to, mixes[i], mixes[j], opts).total
+			}
 		}
-		remaining[a.GetId().GetContentHash()] = a
 	}
 
-	explanations := []*common.EdgeExplanation{}
-	current := start
+	if cons != nil && cons.opener >= 0 {
+		startIndex = cons.opener
+	}
+
+	budget := opts.TimeBudget
+	if budget <= 0 {
+		budget = DefaultTimeBudget
+	}
 
-	for len(remaining) > 0 {
-		next, explanation := bestNext(current, remaining, opts)
-		if next == nil {
-			// fall back to arbitrary ordering to keep the plan complete
-			for _, leftover := range remaining {
-				order = append(order, leftover)
+	p := &planning{
+		tracks:   filtered,
+		opts:     opts,
+		timing:   times,
+		points:   points,
+		profiles: profiles,
+		weight:   weight,
+		cons:     cons,
+		budget:   budget,
+	}
+	if points != nil && opts.TargetLength <= 0 {
+		n := len(filtered)
+		p.slot = make([][]float64, n)
+		for i := range p.slot {
+			p.slot[i] = make([]float64, n)
+			for k := range p.slot[i] {
+				target := spanTargets(points, float64(k)/float64(n), float64(k+1)/float64(n))
+				p.slot[i][k] = -weight * curveMiss(profiles[i], target)
 			}
-			break
 		}
-		order = append(order, next)
-		explanations = append(explanations, explanation)
-		delete(remaining, next.GetId().GetContentHash())
-		current = next
 	}
 
-	ids := make([]*common.TrackId, 0, len(order))
-	for _, t := range order {
-		ids = append(ids, t.GetId())
+	// Without a curve the mode's opener is pinned like a requested one.
+	opener := -1
+	if points == nil || (cons != nil && cons.opener >= 0) {
+		opener = startIndex
+	}
+	path, err := p.search(score, opener)
+	if err != nil {
+		return nil, err
+	}
+	result := p.result(path)
+	if opts.Alternatives > 0 {
+		result.Alternatives = p.alternatives(score, path, result)
+	}
+	return result, nil
+}
+
+// planning holds what a search needs once the pool and options are resolved,
+// so the same pool can be searched again for alternative plans.
+type planning struct {
+	tracks []*common.TrackAnalysis
+	opts   Options
+	timing
+	points   []CurvePoint // nil without an energy curve
+	profiles [][]float64
+	weight   float64
+	slot     [][]float64 // slot scores for full sets with an energy curve
+	cons     *constraints
+	budget   time.Duration
+}
+
+// search orders the pool by score, or the subset of it that fits the target
+// length, opening with track opener, or any track the search likes when it is
+// -1.
+func (p *planning) search(score [][]float64, opener int) ([]int, error) {
+	if p.cons != nil && opener >= 0 {
+		defer func(pinned int) { p.cons.opener = pinned }(p.cons.opener)
+		p.cons.opener = opener
+	}
+
+	if p.opts.TargetLength > 0 {
+		goal, lower, upper, err := lengthBounds(p.opts.TargetLength, p.opts.LengthTolerance)
+		if err != nil {
+			return nil, err
+		}
+		search := &budgetSearch{
+			clock:    newClock(p.budget),
+			budget:   p.budget,
+			timing:   p.timing,
+			score:    score,
+			fixed:    make([]bool, len(p.tracks)),
+			opener:   opener,
+			cons:     p.cons,
+			goal:     goal,
+			lower:    lower,
+			upper:    upper,
+			points:   p.points,
+			profiles: p.profiles,
+			weight:   p.weight,
+		}
+		for i, a := range p.tracks {
+			search.fixed[i] = p.opts.MustPlayHashes[a.GetId().GetContentHash()] || (p.cons != nil && p.cons.forced(i))
+		}
+		return search.solve()
+	}
+
+	width := p.opts.BeamWidth
+	if width <= 0 {
+		width = DefaultBeamWidth
+	}
+	search := newSearcher(score, p.budget)
+	search.cons = p.cons
+	search.start = opener
+	search.slot = p.slot
+	path := search.solve(width)
+	if path == nil {
+		return nil, fmt.Errorf("%w: no order of the %d tracks keeps the artist spacing", ErrConstraints, len(p.tracks))
+	}
+	return path, nil
+}
+
+// result explains a planned path.
+func (p *planning) result(path []int) *Result {
+	filtered, opts, points, profiles := p.tracks, p.opts, p.points, p.profiles
+	starts, length := p.starts(path)
+	result := &Result{Order: make([]*common.TrackId, 0, len(path)), EstimatedSeconds: length}
+	for i, idx := range path {
+		result.Order = append(result.Order, filtered[idx].GetId())
+		if i == 0 {
+			continue
+		}
+		edgeScore, expl := scoreEdge(filtered[path[i-1]], filtered[idx], opts)
+		expl.AtSeconds = starts[i]
+		result.TotalScore += edgeScore
+		result.Explanations = append(result.Explanations, expl)
 	}
 
-	return ids, explanations, nil
+	weakest := slices.Clone(result.Explanations)
+	slices.SortStableFunc(weakest, func(a, b *common.EdgeExplanation) int {
+		return cmp.Compare(a.GetScore(), b.GetScore())
+	})
+	result.WeakestEdges = weakest[:min(WeakestEdgeCount, len(weakest))]
+
+	if points != nil {
+		var missed float64
+		for k, idx := range path {
+			// Slots are equal shares of the set, or the track's share of the
+			// running time when planning to a length.
+			from, to := float64(k)/float64(len(path)), float64(k+1)/float64(len(path))
+			if opts.TargetLength > 0 && length > 0 {
+				from, to = starts[k]/length, (starts[k]+p.play[idx])/length
+			}
+			target := spanTargets(points, from, to)
+			result.EnergySlots = append(result.EnergySlots, &eng.EnergySlot{
+				Slot:         int32(k),
+				Id:           filtered[idx].GetId(),
+				Position:     from,
+				TargetEnergy: float32(mean(target)),
+				ActualEnergy: float32(mean(profiles[idx])),
+			})
+			missed += curveMiss(profiles[idx], target)
+		}
+		result.EnergyError = missed / float64(len(path))
+	}
+	return result
 }
 
 func chooseStart(analyses []*common.TrackAnalysis, mode eng.SetMode) *common.TrackAnalysis {
+	return rankStarts(analyses, mode)[0]
+}
+
+// rankStarts orders the analyses by how well they open a set in mode.
+func rankStarts(analyses []*common.TrackAnalysis, mode eng.SetMode) []*common.TrackAnalysis {
 	clone := make([]*common.TrackAnalysis, len(analyses))
 	copy(clone, analyses)
 
@@ -111,46 +362,73 @@ func chooseStart(analyses []*common.TrackAnalysis, mode eng.SetMode) *common.Tra
 		})
 	}
 
-	return clone[0]
+	return clone
 }
 
-func bestNext(current *common.TrackAnalysis, remaining map[string]*common.TrackAnalysis, opts Options) (*common.TrackAnalysis, *common.EdgeExplanation) {
-	var (
-		bestTrack *common.TrackAnalysis
-		bestScore = math.Inf(-1)
-		bestEdge  *common.EdgeExplanation
-	)
-
-	for _, cand := range remaining {
-		score, expl := scoreEdge(current, cand, opts)
-		if score > bestScore {
-			bestScore = score
-			bestTrack = cand
-			bestEdge = expl
-		}
-	}
+// mixPointScale is the best SuggestTransition score, an outro into an intro
+// over 32 bars with the drop landing as it ends, which earns an edge the full
+// mix point term of 1. Weaker mix points earn less, down to -1.
+const mixPointScale = 6.0
 
-	return bestTrack, bestEdge
+func scoreEdge(from, to *common.TrackAnalysis, opts Options) (float64, *common.EdgeExplanation) {
+	fm := newMixPoints(from)
+	e := rateEdge(from, to, fm, newMixPoints(to), opts)
+	return e.total, e.explain(from, to, fm)
 }
 
-func scoreEdge(from, to *common.TrackAnalysis, opts Options) (float64, *common.EdgeExplanation) {
-	fromBPM := estimateBPM(from)
-	toBPM := estimateBPM(to)
-	bpmDelta := toBPM - fromBPM
+// edge is how well one track mixes into the next, with what went into it.
+type edge struct {
+	total       float64
+	match       tempo.Match
+	relation    string
+	energyDelta int
+	mix         mix  // best mix point
+	mixed       bool // false without a tempo or room to mix
+	ratio       float64
+	vibe        float64
+	vibeKnown   bool
+}
 
-	tempoScore := 4.0 - math.Abs(bpmDelta)/2
-	if opts.MaxBpmStep > 0 && math.Abs(bpmDelta) > opts.MaxBpmStep {
-		tempoScore -= 4 // heavy penalty for exceeding allowed step
+// rateEdge scores mixing from one track into the next from each track's mix
+// points, without the explanation scoreEdge builds, for scoring every pair.
+func rateEdge(from, to *common.TrackAnalysis, fm, tm *mixPoints, opts Options) edge {
+	match := tempo.Compare(fm.bpm, tm.bpm, tempo.Options{
+		PitchRange:  opts.PitchRange,
+		MasterTempo: opts.MasterTempo,
+	})
+	e := edge{match: match}
+
+	var tempoScore float64 // unknown tempos are neutral
+	if match.Known {
+		tempoScore = 4.0*match.Weight - math.Abs(match.Delta)/2
+		if !match.InRange {
+			tempoScore -= 4 // the deck can't pitch that far
+		}
+		if opts.MaxBpmStep > 0 && math.Abs(match.Delta) > opts.MaxBpmStep {
+			tempoScore -= 4 // heavy penalty for exceeding allowed step
+		}
 	}
 
-	keyScore, relation := keyCompatibility(from.GetKey().GetValue(), to.GetKey().GetValue(), opts.AllowKeyJumps)
+	// Without master tempo the incoming track is heard in its pitched key.
+	toKey := to.GetKey().GetValue()
+	shifted := false
+	if shift := match.KeyShift(); shift != 0 {
+		if k, ok := camelot.Parse(toKey); ok {
+			toKey, shifted = k.Transpose(shift).String(), true
+		}
+	}
+	keyScore, relation := keyCompatibility(opts.KeyWeights, from.GetKey().GetValue(), toKey, opts.AllowKeyJumps)
+	if shifted {
+		relation += " (pitched to " + toKey + ")"
+	}
+	e.relation = relation
 
-	energyDelta := int(to.GetEnergyGlobal() - from.GetEnergyGlobal())
-	energyScore := 2.0 - math.Abs(float64(energyDelta))*0.5
+	e.energyDelta = int(to.GetEnergyGlobal() - from.GetEnergyGlobal())
+	energyScore := 2.0 - math.Abs(float64(e.energyDelta))*0.5
 
 	switch opts.Mode {
 	case eng.SetMode_WARM_UP:
-		if energyDelta > 0 {
+		if e.energyDelta > 0 {
 			energyScore += 1
 		}
 	case eng.SetMode_PEAK_TIME:
@@ -159,88 +437,82 @@ func scoreEdge(from, to *common.TrackAnalysis, opts Options) (float64, *common.E
 		}
 	}
 
-	window := windowOverlap(from, to)
-	windowScore := 0.0
-	if window != "" {
-		windowScore = 1.0
+	// The best phrase-aligned mix point scores the transition windows
+	windowScore := 0.0 // tracks without a tempo are neutral
+	if best, ratio, ok := bestMix(from, to, fm, tm, opts); ok {
+		e.mix, e.mixed, e.ratio = best, true, ratio
+		windowScore = max(-1, min(1, float64(best.score)/mixPointScale))
 	}
 
-	total := keyScore + tempoScore + energyScore + windowScore
-
-	expl := &common.EdgeExplanation{
-		From:          from.GetId(),
-		To:            to.GetId(),
-		Score:         float32(total),
-		TempoDelta:    float32(bpmDelta),
-		EnergyDelta:   int32(energyDelta),
-		KeyRelation:   relation,
-		WindowOverlap: window,
-		Reason:        fmt.Sprintf("%s; Δ%.1f BPM; Δenergy %d", relation, bpmDelta, energyDelta),
+	e.vibe, e.vibeKnown = vibeMatch(from, to, opts)
+	vibeScore := 0.0 // unknown vibes are neutral
+	if e.vibeKnown {
+		vibeScore = vibeWeight(opts) * (2*e.vibe - 1)
 	}
 
-	return total, expl
+	e.total = keyScore + tempoScore + energyScore + windowScore + vibeScore
+	return e
 }
 
-func keyCompatibility(from, to string, allowJumps bool) (float64, string) {
-	if from == "" || to == "" {
-		return -1, "unknown key"
-	}
-
-	fromNum, fromMode, okFrom := parseCamelot(from)
-	toNum, toMode, okTo := parseCamelot(to)
-
-	if !okFrom || !okTo {
-		if allowJumps {
-			return 0, "unverified key jump"
-		}
-		return -3, "key mismatch"
-	}
-
-	if fromNum == toNum && fromMode == toMode {
-		return 4, "same key"
+// explain describes a rated edge, with its best mix point as the suggestion.
+func (e edge) explain(from, to *common.TrackAnalysis, fm *mixPoints) *common.EdgeExplanation {
+	expl := &common.EdgeExplanation{
+		From:        from.GetId(),
+		To:          to.GetId(),
+		Score:       float32(e.total),
+		TempoDelta:  float32(e.match.Delta),
+		EnergyDelta: int32(e.energyDelta),
+		KeyRelation: e.relation,
+		VibeMatch:   float32(e.vibe * 100),
+		Reason:      fmt.Sprintf("%s; Δ%.1f BPM (%s); Δenergy %d", e.relation, e.match.Delta, e.match, e.energyDelta),
 	}
-
-	if fromMode == toMode && int(math.Abs(float64(fromNum-toNum))) == 1 {
-		dir := "+"
-		if toNum < fromNum {
-			dir = "-"
-		}
-		return 3, fmt.Sprintf("%s1 Camelot", dir)
+	if e.mixed {
+		expl.Suggestion = suggest(from, to, e.mix, e.ratio, fm.bpm)
+		expl.WindowOverlap = e.mix.out.label + " → " + e.mix.in.label
 	}
-
-	if allowJumps {
-		return 1, "permitted key jump"
+	if e.vibeKnown {
+		expl.Reason += fmt.Sprintf("; vibe %.0f%%", e.vibe*100)
 	}
-
-	return -2, "distant key"
+	return expl
 }
 
-func parseCamelot(value string) (int, string, bool) {
-	value = strings.TrimSpace(strings.ToUpper(value))
-	if value == "" {
-		return 0, "", false
-	}
+// DefaultKeyWeights scores key changes between tracks when Options.KeyWeights
+// is nil.
+var DefaultKeyWeights = camelot.Weights{
+	camelot.Same:        4,
+	camelot.Relative:    3,
+	camelot.Adjacent:    3,
+	camelot.Diagonal:    2,
+	camelot.EnergyBoost: 2,
+	camelot.Modulation:  1,
+	camelot.Clash:       -2,
+	camelot.Missing:     -1,
+	camelot.Invalid:     -3,
+}
 
-	mode := value[len(value)-1:]
-	numPart := value[:len(value)-1]
-	num, err := strconv.Atoi(numPart)
-	if err != nil || num < 1 || num > 12 {
-		return 0, "", false
-	}
+// Key jumps that AllowKeyJumps lets through score these instead.
+const (
+	permittedJumpScore  = 1.0 // distant keys
+	unverifiedJumpScore = 0.0 // keys that aren't Camelot notation
+)
 
-	if mode != "A" && mode != "B" {
-		return 0, "", false
+// keyCompatibility scores mixing from one key into another. Like
+// camelot.Relate it is directional: an energy boost or modulation up the wheel
+// scores as a clash played the other way.
+func keyCompatibility(weights camelot.Weights, from, to string, allowJumps bool) (float64, string) {
+	if weights == nil {
+		weights = DefaultKeyWeights
 	}
-
-	return num, mode, true
-}
-
-func windowOverlap(from, to *common.TrackAnalysis) string {
-	if len(from.GetTransitionWindows()) == 0 || len(to.GetTransitionWindows()) == 0 {
-		return ""
+	score, relation := weights.Score(from, to)
+	if allowJumps {
+		switch relation.Move {
+		case camelot.Clash:
+			return permittedJumpScore, "permitted key jump"
+		case camelot.Invalid:
+			return unverifiedJumpScore, "unverified key jump"
+		}
 	}
-
-	return fmt.Sprintf("%s → %s", from.GetTransitionWindows()[0].GetTag(), to.GetTransitionWindows()[0].GetTag())
+	return score, relation.String()
 }
 
 func estimateBPM(a *common.TrackAnalysis) float64 {
@@ -153,7 +153,8 @@ func TestExplanationsMatchOrder(t *testing.T) {
 	}
 }
 
-// TestKeyCompatibilitySymmetric verifies key compatibility properties.
+// TestKeyCompatibilityProperties verifies key compatibility is symmetric
+// except for the moves that only go up the wheel.
 func TestKeyCompatibilityProperties(t *testing.T) {
 	testCases := []struct {
 		key1, key2 string
@@ -163,11 +164,12 @@ func TestKeyCompatibilityProperties(t *testing.T) {
 		{"8A", "7A"},   // Adjacent
 		{"8A", "8B"},   // Same wheel, different mode
 		{"1A", "12A"},  // Wrap-around
+		{"8A", "9B"},   // Diagonal
 	}
 
 	for _, tc := range testCases {
-		score1, _ := keyCompatibility(tc.key1, tc.key2, false)
-		score2, _ := keyCompatibility(tc.key2, tc.key1, false)
+		score1, _ := keyCompatibility(nil, tc.key1, tc.key2, false)
+		score2, _ := keyCompatibility(nil, tc.key2, tc.key1, false)
 
 		// Key compatibility should be symmetric
 		if math.Abs(score1-score2) > 0.001 {
@@ -175,6 +177,22 @@ func TestKeyCompatibilityProperties(t *testing.T) {
 				tc.key1, tc.key2, score1, tc.key2, tc.key1, score2)
 		}
 	}
+
+	// Energy boosts and modulations are directional: going back down the
+	// wheel is a clash.
+	for _, tc := range []struct {
+		up, down string
+	}{
+		{"8A", "10A"}, // Energy boost
+		{"8A", "3A"},  // Modulation
+	} {
+		up, _ := keyCompatibility(nil, tc.up, tc.down, false)
+		down, relation := keyCompatibility(nil, tc.down, tc.up, false)
+		if up <= down || relation != "distant key" {
+			t.Errorf("(%s, %s) = %f, reverse = %f %q; want the reverse to score as a clash",
+				tc.up, tc.down, up, down, relation)
+		}
+	}
 }
 
 // TestScoreEdgeBounds verifies that edge scores are bounded.
@@ -1,11 +1,13 @@
 package planner
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/cartomix/cancun/gen/go/common"
 	eng "github.com/cartomix/cancun/gen/go/engine"
+	"github.com/cartomix/cancun/internal/camelot"
 	"google.golang.org/protobuf/types/known/durationpb"
 )
 
@@ -36,22 +38,65 @@ func TestPlanWarmupPrefersEnergyClimb(t *testing.T) {
 }
 
 func TestKeyCompatibilityRespectsJumps(t *testing.T) {
-	_, relation := keyCompatibility("8A", "9A", false)
+	_, relation := keyCompatibility(nil, "8A", "9A", false)
 	if relation != "+1 Camelot" {
 		t.Fatalf("unexpected relation: %s", relation)
 	}
 
-	score, relation := keyCompatibility("8A", "11B", false)
+	score, relation := keyCompatibility(nil, "8A", "11B", false)
 	if score >= 0 {
 		t.Fatalf("expected penalty for distant key, got %f (%s)", score, relation)
 	}
 
-	score, _ = keyCompatibility("8A", "11B", true)
+	score, _ = keyCompatibility(nil, "8A", "11B", true)
 	if score <= -3 {
 		t.Fatalf("allowing jumps should soften penalty, got %f", score)
 	}
 }
 
+func TestKeyCompatibilityWrapsWheel(t *testing.T) {
+	score, relation := keyCompatibility(nil, "12A", "1A", false)
+	if relation != "+1 Camelot" || score != DefaultKeyWeights[camelot.Adjacent] {
+		t.Fatalf("12A -> 1A = %v (%s)", score, relation)
+	}
+
+	weights, err := DefaultKeyWeights.Override(map[string]float64{"energy_boost": 5})
+	if err != nil {
+		t.Fatalf("override: %v", err)
+	}
+	score, relation = keyCompatibility(weights, "8A", "10A", false)
+	if relation != "+2 energy boost" || score != 5 {
+		t.Fatalf("8A -> 10A = %v (%s)", score, relation)
+	}
+}
+
+func TestScoreEdgeMatchesHalfTime(t *testing.T) {
+	dnb := buildAnalysis("dnb", 174, 7, "8A")
+	halftime := buildAnalysis("half", 87, 7, "8A")
+
+	score, expl := scoreEdge(dnb, halftime, Options{MaxBpmStep: 4})
+	if expl.GetTempoDelta() != 0 || score < 10 {
+		t.Fatalf("174 -> 87 scored %.1f (Δ%.1f BPM): %s", score, expl.GetTempoDelta(), expl.GetReason())
+	}
+	if !strings.Contains(expl.GetReason(), "play at 2x") {
+		t.Errorf("reason %q doesn't explain the 2x move", expl.GetReason())
+	}
+}
+
+func TestScoreEdgeShiftsPitchedKey(t *testing.T) {
+	from := buildAnalysis("x", 120, 6, "8A")
+	to := buildAnalysis("y", 126, 6, "3A") // pitched down a semitone lands on 8A
+
+	_, expl := scoreEdge(from, to, Options{})
+	if expl.GetKeyRelation() != "same key (pitched to 8A)" {
+		t.Errorf("master tempo off: relation %q", expl.GetKeyRelation())
+	}
+	_, expl = scoreEdge(from, to, Options{MasterTempo: true})
+	if expl.GetKeyRelation() != "+7 semitone modulation" {
+		t.Errorf("master tempo on: relation %q", expl.GetKeyRelation())
+	}
+}
+
 func TestMaxBpmStepPenalty(t *testing.T) {
 	from := buildAnalysis("x", 124, 6, "8A")
 	to := buildAnalysis("y", 140, 7, "9A")
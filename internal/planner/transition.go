@@ -0,0 +1,359 @@
+package planner
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	"github.com/cartomix/cancun/internal/tempo"
+)
+
+// DefaultSuggestionCount is how many transition suggestions are returned when
+// the caller doesn't ask for a number.
+const DefaultSuggestionCount = 3
+
+// Phrase lengths in beats. Mix points sit on 16-bar phrase boundaries and
+// overlaps last 32 or 16 bars, falling back to shorter blends only when a track
+// has no room left.
+const (
+	beatsPerBar  = 4
+	phraseBeats  = 16 * beatsPerBar
+	overlapLong  = 32 * beatsPerBar
+	overlapShort = 16 * beatsPerBar
+)
+
+// mixPoint is a phrase-aligned beat to mix out of or into a track.
+type mixPoint struct {
+	beat  int
+	label string  // what's there, e.g. "outro" or "intro"
+	bonus float64 // how good a place it is to mix
+}
+
+// SuggestTransition ranks beat-accurate places to mix from one track into the
+// next. Mix-out points come from the outgoing track's outro, breakdowns and later
+// transition windows; mix-in points from the incoming track's first downbeat,
+// intro and early transition windows. Both are snapped to 16-bar phrases, paired
+// with a 32- or 16-bar overlap, and scored on where they land: a blend that
+// brings the incoming drop in as the outgoing track ends scores well, one that
+// runs over the outgoing drop scores badly. Tempos are matched as in scoreEdge,
+// so an incoming track played at double time spans half as many of its own
+// beats. At most limit suggestions are returned, best first.
+func SuggestTransition(from, to *common.TrackAnalysis, limit int, opts Options) []*common.TransitionSuggestion {
+	return suggestTransitions(from, to, newMixPoints(from), newMixPoints(to), limit, opts)
+}
+
+// mixPoints is what suggesting transitions needs from one track, worked out
+// once so a plan can pair the track with every other.
+type mixPoints struct {
+	bpm     float64
+	beats   int
+	out, in []mixPoint
+}
+
+func newMixPoints(a *common.TrackAnalysis) *mixPoints {
+	beats := trackBeats(a)
+	return &mixPoints{
+		bpm:   estimateBPM(a),
+		beats: beats,
+		out:   mixOutPoints(a, beats),
+		in:    mixInPoints(a, beats),
+	}
+}
+
+// mix is a pairing of mix points and how well it plays.
+type mix struct {
+	out, in      mixPoint
+	overlap      int     // outgoing beats
+	span         int     // incoming beats heard during the overlap
+	score        float32 // as TransitionSuggestion.Score
+	dropLands    int     // incoming drop as the outgoing track ends, -1 if none
+	dropRunsOver int     // outgoing drop under the blend, -1 if none
+	energyChange int
+}
+
+// suggestTransitions is SuggestTransition from each track's mix points.
+func suggestTransitions(from, to *common.TrackAnalysis, fm, tm *mixPoints, limit int, opts Options) []*common.TransitionSuggestion {
+	if limit <= 0 {
+		limit = DefaultSuggestionCount
+	}
+	mixes, ratio := rankMixes(from, to, fm, tm, opts)
+	suggestions := make([]*common.TransitionSuggestion, 0, min(limit, len(mixes)))
+	for _, m := range mixes[:min(limit, len(mixes))] {
+		suggestions = append(suggestions, suggest(from, to, m, ratio, fm.bpm))
+	}
+	return suggestions
+}
+
+// rankMixes scores every pairing of from's mix-out points with to's mix-in
+// points that leaves room for an overlap, best first, and returns the tempo
+// ratio to is played at.
+func rankMixes(from, to *common.TrackAnalysis, fm, tm *mixPoints, opts Options) ([]mix, float64) {
+	var mixes []mix
+	ratio := eachMix(from, to, fm, tm, opts, func(m mix) {
+		mixes = append(mixes, m)
+	})
+	slices.SortStableFunc(mixes, compareMixes)
+	return mixes, ratio
+}
+
+// bestMix is the first of rankMixes without ranking the rest. It reports
+// false when no pairing fits.
+func bestMix(from, to *common.TrackAnalysis, fm, tm *mixPoints, opts Options) (mix, float64, bool) {
+	var best mix
+	found := false
+	ratio := eachMix(from, to, fm, tm, opts, func(m mix) {
+		if !found || compareMixes(m, best) < 0 {
+			best, found = m, true
+		}
+	})
+	return best, ratio, found
+}
+
+// compareMixes orders better mixes first.
+func compareMixes(a, b mix) int {
+	if c := cmp.Compare(b.score, a.score); c != 0 {
+		return c
+	}
+	return cmp.Compare(b.out.beat, a.out.beat) // later mix-outs play more of the outgoing track
+}
+
+// eachMix scores every pairing of from's mix-out points with to's mix-in
+// points that leaves room for an overlap, and returns the tempo ratio to is
+// played at. Tracks without a tempo have no pairings.
+func eachMix(from, to *common.TrackAnalysis, fm, tm *mixPoints, opts Options, fn func(mix)) float64 {
+	if fm.bpm <= 0 {
+		return 1
+	}
+	match := tempo.Compare(fm.bpm, tm.bpm, tempo.Options{
+		PitchRange:  opts.PitchRange,
+		MasterTempo: opts.MasterTempo,
+	})
+	ratio := 1.0
+	if match.Known {
+		ratio = match.Ratio
+	}
+
+	for _, out := range fm.out {
+		for _, in := range tm.in {
+			overlap := fitOverlap(fm.beats-out.beat, float64(tm.beats-in.beat)*ratio)
+			if overlap == 0 {
+				continue
+			}
+			fn(scoreMix(from, to, out, in, overlap, ratio))
+		}
+	}
+	return ratio
+}
+
+// scoreMix scores mixing out of from at out and into to at in over overlap
+// beats of the outgoing track.
+func scoreMix(from, to *common.TrackAnalysis, out, in mixPoint, overlap int, ratio float64) mix {
+	m := mix{out: out, in: in, overlap: overlap, dropLands: -1, dropRunsOver: -1}
+	m.span = int(math.Round(float64(overlap) / ratio))
+	score := out.bonus + in.bonus
+	if overlap == overlapLong {
+		score += 0.5 // longer blends are smoother
+	}
+
+	// Bringing the incoming drop in just as the outgoing track ends is the
+	// classic blend; running the outgoing drop under the incoming intro is not.
+	if drop, ok := sectionStart(to, common.SectionLabel_DROP, in.beat+m.span-beatsPerBar, in.beat+m.span+beatsPerBar); ok {
+		score += 1.5
+		m.dropLands = drop
+	}
+	if drop, ok := sectionStart(from, common.SectionLabel_DROP, out.beat+1, out.beat+overlap); ok {
+		score -= 1.5
+		m.dropRunsOver = drop
+	}
+
+	m.energyChange = energyAt(to, in.beat+m.span) - energyAt(from, max(0, out.beat-1))
+	score -= 0.25 * math.Abs(float64(m.energyChange))
+	m.score = float32(score)
+	return m
+}
+
+// suggest explains a scored mix as a suggestion.
+func suggest(from, to *common.TrackAnalysis, m mix, ratio, fromBPM float64) *common.TransitionSuggestion {
+	explanation := fmt.Sprintf("mix out at bar %d (%s), bring in from bar %d (%s) over %d bars",
+		bar(from, m.out.beat), m.out.label, bar(to, m.in.beat), m.in.label, m.overlap/beatsPerBar)
+	if ratio != 1 {
+		explanation += fmt.Sprintf(" at %gx", ratio)
+	}
+	if m.dropLands >= 0 {
+		explanation += fmt.Sprintf("; the drop lands at bar %d as the outgoing track ends", bar(to, m.dropLands))
+	}
+	if m.dropRunsOver >= 0 {
+		explanation += fmt.Sprintf("; runs over the outgoing drop at bar %d", bar(from, m.dropRunsOver))
+	}
+	explanation += fmt.Sprintf("; energy %+d", m.energyChange)
+
+	return &common.TransitionSuggestion{
+		OutBeat:        int32(m.out.beat),
+		InBeat:         int32(m.in.beat),
+		OverlapBeats:   int32(m.overlap),
+		OverlapSeconds: float64(m.overlap) * 60 / fromBPM,
+		OutSeconds:     beatSeconds(from, m.out.beat),
+		InSeconds:      beatSeconds(to, m.in.beat),
+		EnergyChange:   int32(m.energyChange),
+		Score:          m.score,
+		OutLabel:       m.out.label,
+		InLabel:        m.in.label,
+		Explanation:    explanation,
+	}
+}
+
+// mixOutPoints lists phrase-aligned places to start mixing out of a track of
+// total beats, from its later transition windows and sections, plus the last
+// phrases as a fallback.
+func mixOutPoints(a *common.TrackAnalysis, total int) []mixPoint {
+	var points []mixPoint
+	for _, s := range a.GetSections() {
+		switch {
+		case s.GetLabel() == common.SectionLabel_OUTRO:
+			points = append(points, mixPoint{int(s.GetStartBeat()), "outro", 2})
+		case s.GetLabel() == common.SectionLabel_BREAKDOWN && int(s.GetStartBeat()) >= total/2:
+			points = append(points, mixPoint{int(s.GetStartBeat()), "breakdown", 1})
+		}
+	}
+	for _, w := range a.GetTransitionWindows() {
+		if int(w.GetStartBeat()) >= total/2 {
+			points = append(points, mixPoint{int(w.GetStartBeat()), windowLabel(w), 1.5})
+		}
+	}
+	for _, back := range []int{overlapShort, overlapLong} {
+		points = append(points, mixPoint{total - back, "last phrases", 0})
+	}
+	return snapPoints(a, points, total)
+}
+
+// mixInPoints lists phrase-aligned places to bring a track of total beats in,
+// from its first downbeat, intro and early transition windows.
+func mixInPoints(a *common.TrackAnalysis, total int) []mixPoint {
+	points := []mixPoint{{firstDownbeat(a), "first downbeat", 1}}
+	for _, s := range a.GetSections() {
+		if s.GetLabel() == common.SectionLabel_INTRO {
+			points = append(points, mixPoint{int(s.GetStartBeat()), "intro", 2})
+		}
+	}
+	for _, w := range a.GetTransitionWindows() {
+		if int(w.GetStartBeat()) < total/2 {
+			points = append(points, mixPoint{int(w.GetStartBeat()), windowLabel(w), 1.5})
+		}
+	}
+	return snapPoints(a, points, total)
+}
+
+// snapPoints moves points onto the nearest 16-bar phrase boundary inside the
+// track and keeps the best point at each boundary.
+func snapPoints(a *common.TrackAnalysis, points []mixPoint, total int) []mixPoint {
+	anchor := firstDownbeat(a)
+	best := make(map[int]mixPoint)
+	for _, p := range points {
+		phrase := math.Round(float64(p.beat-anchor) / phraseBeats)
+		p.beat = anchor + int(phrase)*phraseBeats
+		if p.beat < anchor || p.beat >= total {
+			continue
+		}
+		if cur, ok := best[p.beat]; !ok || p.bonus > cur.bonus {
+			best[p.beat] = p
+		}
+	}
+	snapped := make([]mixPoint, 0, len(best))
+	for _, p := range best {
+		snapped = append(snapped, p)
+	}
+	slices.SortFunc(snapped, func(a, b mixPoint) int { return cmp.Compare(a.beat, b.beat) })
+	return snapped
+}
+
+// fitOverlap picks the longest phrase-length overlap that both the outgoing
+// track's remaining beats and the incoming track's, counted in outgoing beats,
+// leave room for. It returns 0 when not even four bars fit.
+func fitOverlap(outRoom int, inRoom float64) int {
+	room := min(float64(outRoom), inRoom)
+	for _, overlap := range []int{overlapLong, overlapShort, 8 * beatsPerBar, 4 * beatsPerBar} {
+		if float64(overlap) <= room {
+			return overlap
+		}
+	}
+	return 0
+}
+
+func windowLabel(w *common.TransitionWindow) string {
+	if w.GetTag() == "" {
+		return "transition window"
+	}
+	return strings.ReplaceAll(w.GetTag(), "_", " ") + " window"
+}
+
+// trackBeats estimates how many beats a track has, from its duration and tempo
+// or else the furthest beat its analysis mentions.
+func trackBeats(a *common.TrackAnalysis) int {
+	var beats int32
+	if bpm := estimateBPM(a); bpm > 0 && a.GetDurationSeconds() > 0 {
+		beats = int32(a.GetDurationSeconds() * bpm / 60)
+	}
+	for _, m := range a.GetBeatgrid().GetBeats() {
+		beats = max(beats, m.GetIndex()+1)
+	}
+	for _, s := range a.GetSections() {
+		beats = max(beats, s.GetEndBeat())
+	}
+	for _, s := range a.GetEnergySegments() {
+		beats = max(beats, s.GetEndBeat())
+	}
+	for _, w := range a.GetTransitionWindows() {
+		beats = max(beats, w.GetEndBeat())
+	}
+	return int(beats)
+}
+
+// firstDownbeat is the beat phrases are counted from.
+func firstDownbeat(a *common.TrackAnalysis) int {
+	for _, m := range a.GetBeatgrid().GetBeats() {
+		if m.GetIsDownbeat() {
+			return int(m.GetIndex())
+		}
+	}
+	return 0
+}
+
+// bar numbers beat in bars from the first downbeat, starting at 1.
+func bar(a *common.TrackAnalysis, beat int) int {
+	return (beat-firstDownbeat(a))/beatsPerBar + 1
+}
+
+// beatSeconds places a beat in time from the first beat marker and the tempo.
+func beatSeconds(a *common.TrackAnalysis, beat int) float64 {
+	bpm := estimateBPM(a)
+	markers := a.GetBeatgrid().GetBeats()
+	if bpm <= 0 || len(markers) == 0 {
+		return 0
+	}
+	first := markers[0]
+	return first.GetTime().AsDuration().Seconds() + float64(beat-int(first.GetIndex()))*60/bpm
+}
+
+// energyAt is the track's energy level at beat, from its energy segments or
+// else its global energy.
+func energyAt(a *common.TrackAnalysis, beat int) int {
+	for _, s := range a.GetEnergySegments() {
+		if beat >= int(s.GetStartBeat()) && beat < int(s.GetEndBeat()) {
+			return int(s.GetLevel())
+		}
+	}
+	return int(a.GetEnergyGlobal())
+}
+
+// sectionStart finds a section with label starting between beats lo and hi.
+func sectionStart(a *common.TrackAnalysis, label common.SectionLabel, lo, hi int) (int, bool) {
+	for _, s := range a.GetSections() {
+		if s.GetLabel() == label && int(s.GetStartBeat()) >= lo && int(s.GetStartBeat()) <= hi {
+			return int(s.GetStartBeat()), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,118 @@
+package planner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cartomix/cancun/gen/go/common"
+)
+
+// sectionedAnalysis is a six minute track at 128 BPM (768 beats) with the given
+// sections.
+func sectionedAnalysis(hash string, sections ...*common.Section) *common.TrackAnalysis {
+	a := buildAnalysis(hash, 128, 6, "8A")
+	a.DurationSeconds = 360
+	a.TransitionWindows = nil
+	a.Sections = sections
+	return a
+}
+
+func TestSuggestTransitionLandsDrop(t *testing.T) {
+	from := sectionedAnalysis("out",
+		&common.Section{StartBeat: 0, EndBeat: 64, Label: common.SectionLabel_INTRO},
+		&common.Section{StartBeat: 256, EndBeat: 384, Label: common.SectionLabel_DROP},
+		&common.Section{StartBeat: 640, EndBeat: 768, Label: common.SectionLabel_OUTRO},
+	)
+	from.EnergySegments = []*common.EnergySegment{{StartBeat: 576, EndBeat: 768, Level: 4}}
+	to := sectionedAnalysis("in",
+		&common.Section{StartBeat: 0, EndBeat: 128, Label: common.SectionLabel_INTRO},
+		&common.Section{StartBeat: 128, EndBeat: 256, Label: common.SectionLabel_DROP},
+	)
+	to.EnergySegments = []*common.EnergySegment{{StartBeat: 128, EndBeat: 256, Level: 8}}
+
+	suggestions := SuggestTransition(from, to, 5, Options{})
+	if len(suggestions) == 0 {
+		t.Fatal("no suggestions")
+	}
+	best := suggestions[0]
+	if best.GetOutBeat() != 640 || best.GetInBeat() != 0 || best.GetOverlapBeats() != 128 {
+		t.Fatalf("best = out %d in %d over %d: %s", best.GetOutBeat(), best.GetInBeat(), best.GetOverlapBeats(), best.GetExplanation())
+	}
+	if best.GetOverlapSeconds() != 60 {
+		t.Errorf("32 bars at 128 BPM = %.1fs, want 60s", best.GetOverlapSeconds())
+	}
+	if best.GetEnergyChange() != 4 {
+		t.Errorf("energy change = %d, want +4", best.GetEnergyChange())
+	}
+	if !strings.Contains(best.GetExplanation(), "drop lands at bar 33") {
+		t.Errorf("explanation %q doesn't mention the drop", best.GetExplanation())
+	}
+	for i, s := range suggestions {
+		if s.GetOutBeat()%phraseBeats != 0 || s.GetInBeat()%phraseBeats != 0 {
+			t.Errorf("suggestion %d not phrase aligned: out %d in %d", i, s.GetOutBeat(), s.GetInBeat())
+		}
+		if s.GetOutBeat()+s.GetOverlapBeats() > 768 {
+			t.Errorf("suggestion %d overruns the outgoing track", i)
+		}
+		if i > 0 && s.GetScore() > suggestions[i-1].GetScore() {
+			t.Errorf("suggestions not ranked best first")
+		}
+	}
+}
+
+func TestSuggestTransitionHalfTime(t *testing.T) {
+	from := sectionedAnalysis("dnb", &common.Section{StartBeat: 640, EndBeat: 768, Label: common.SectionLabel_OUTRO})
+	from.Beatgrid.TempoMap[0].Bpm = 174
+	from.DurationSeconds = 768 * 60 / 174.0
+	to := sectionedAnalysis("half", &common.Section{StartBeat: 0, EndBeat: 64, Label: common.SectionLabel_INTRO})
+	to.Beatgrid.TempoMap[0].Bpm = 87
+	to.DurationSeconds = 384 * 60 / 87.0
+
+	best := SuggestTransition(from, to, 1, Options{})
+	if len(best) != 1 {
+		t.Fatalf("suggestions = %d", len(best))
+	}
+	if !strings.Contains(best[0].GetExplanation(), "at 2x") {
+		t.Errorf("explanation %q doesn't mention double time", best[0].GetExplanation())
+	}
+}
+
+func TestOptimizeAttachesSuggestions(t *testing.T) {
+	tracks := []*common.TrackAnalysis{
+		sectionedAnalysis("a", &common.Section{StartBeat: 640, EndBeat: 768, Label: common.SectionLabel_OUTRO}),
+		sectionedAnalysis("b", &common.Section{StartBeat: 0, EndBeat: 64, Label: common.SectionLabel_INTRO}),
+	}
+	result, err := Optimize(tracks, Options{})
+	if err != nil {
+		t.Fatalf("optimize: %v", err)
+	}
+	for _, e := range result.Explanations {
+		if e.GetSuggestion() == nil {
+			t.Fatalf("edge %s -> %s has no suggestion", e.GetFrom().GetContentHash(), e.GetTo().GetContentHash())
+		}
+	}
+}
+
+func TestScoreEdgeUsesBestMixPoint(t *testing.T) {
+	from := sectionedAnalysis("out", &common.Section{StartBeat: 640, EndBeat: 768, Label: common.SectionLabel_OUTRO})
+	// The same track twice, once with its drop where the blend ends and once
+	// with it where the outgoing outro would still be playing.
+	landing := sectionedAnalysis("landing",
+		&common.Section{StartBeat: 0, EndBeat: 128, Label: common.SectionLabel_INTRO},
+		&common.Section{StartBeat: 128, EndBeat: 256, Label: common.SectionLabel_DROP},
+	)
+	early := sectionedAnalysis("early",
+		&common.Section{StartBeat: 0, EndBeat: 32, Label: common.SectionLabel_INTRO},
+		&common.Section{StartBeat: 32, EndBeat: 256, Label: common.SectionLabel_DROP},
+	)
+
+	good, goodExpl := scoreEdge(from, landing, Options{})
+	poor, _ := scoreEdge(from, early, Options{})
+	if good <= poor {
+		t.Errorf("drop landing on the mix = %.2f, early drop = %.2f; want the landing scored higher", good, poor)
+	}
+	best := SuggestTransition(from, landing, 1, Options{})[0]
+	if goodExpl.GetSuggestion().GetOutBeat() != best.GetOutBeat() || goodExpl.GetWindowOverlap() != "outro → intro" {
+		t.Errorf("edge suggestion = %v, window %q; want the best mix point", goodExpl.GetSuggestion(), goodExpl.GetWindowOverlap())
+	}
+}
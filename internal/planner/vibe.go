@@ -0,0 +1,65 @@
+package planner
+
+import (
+	"github.com/cartomix/cancun/gen/go/common"
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+// DefaultVibeWeight scales the OpenL3 vibe term of an edge score when
+// Options.VibeWeight is zero. Identical vibes add the weight, opposite ones
+// subtract it.
+const DefaultVibeWeight = 3.0
+
+// mixWindowSeconds is how much of the start and end of a track is pooled into
+// its intro and outro embeddings.
+const mixWindowSeconds = 60.0
+
+// MixEmbeddings are OpenL3 embeddings of the parts of a track heard during a
+// transition.
+type MixEmbeddings struct {
+	Intro []float32 // pooled over the first minute
+	Outro []float32 // pooled over the last minute
+}
+
+// MixEmbeddingsFromWindows pools a track's windowed OpenL3 embeddings into
+// intro and outro embeddings. The track's end is taken from duration, or else
+// from the last window.
+func MixEmbeddingsFromWindows(windows []similarity.Window, duration float64) MixEmbeddings {
+	if len(windows) == 0 {
+		return MixEmbeddings{}
+	}
+	if duration <= 0 {
+		last := windows[len(windows)-1]
+		duration = last.StartSeconds + last.DurationSeconds
+	}
+	return MixEmbeddings{
+		Intro: similarity.PoolWindows(windows, 0, mixWindowSeconds),
+		Outro: similarity.PoolWindows(windows, duration-mixWindowSeconds, duration),
+	}
+}
+
+// vibeMatch compares how from ends with how to starts, 0-1. Windowed outro and
+// intro embeddings are preferred; otherwise the whole-track embeddings are
+// compared. ok is false when either side has no embedding.
+func vibeMatch(from, to *common.TrackAnalysis, opts Options) (vibe float64, ok bool) {
+	outro := opts.MixEmbeddings[from.GetId().GetContentHash()].Outro
+	intro := opts.MixEmbeddings[to.GetId().GetContentHash()].Intro
+	if len(outro) == 0 || len(outro) != len(intro) {
+		outro = from.GetOpenl3Embedding().GetVector()
+		intro = to.GetOpenl3Embedding().GetVector()
+	}
+	if len(outro) == 0 || len(outro) != len(intro) {
+		return 0, false
+	}
+	return similarity.CosineSimilarity(outro, intro), true
+}
+
+func vibeWeight(opts Options) float64 {
+	switch {
+	case opts.VibeWeight < 0:
+		return 0
+	case opts.VibeWeight == 0:
+		return DefaultVibeWeight
+	}
+	return opts.VibeWeight
+}
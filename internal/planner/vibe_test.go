@@ -0,0 +1,65 @@
+package planner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+func withVibe(a *common.TrackAnalysis, vector ...float32) *common.TrackAnalysis {
+	a.Openl3Embedding = &common.OpenL3Embedding{Vector: vector}
+	return a
+}
+
+func TestScoreEdgeRewardsMatchingVibe(t *testing.T) {
+	from := withVibe(buildAnalysis("from", 128, 6, "8A"), 1, 0)
+	alike := withVibe(buildAnalysis("alike", 128, 6, "8A"), 1, 0.1)
+	apart := withVibe(buildAnalysis("apart", 128, 6, "8A"), -1, 0)
+
+	alikeScore, alikeExpl := scoreEdge(from, alike, Options{})
+	apartScore, apartExpl := scoreEdge(from, apart, Options{})
+	if alikeScore <= apartScore {
+		t.Errorf("matching vibe scored %.2f, clashing vibe %.2f", alikeScore, apartScore)
+	}
+	if alikeExpl.GetVibeMatch() < 99 || apartExpl.GetVibeMatch() != 0 {
+		t.Errorf("vibe match = %.1f / %.1f, want ~100 / 0", alikeExpl.GetVibeMatch(), apartExpl.GetVibeMatch())
+	}
+	if !strings.Contains(alikeExpl.GetReason(), "vibe 100%") {
+		t.Errorf("reason %q doesn't mention the vibe", alikeExpl.GetReason())
+	}
+
+	off, _ := scoreEdge(from, apart, Options{VibeWeight: -1})
+	plain, expl := scoreEdge(buildAnalysis("from", 128, 6, "8A"), buildAnalysis("apart", 128, 6, "8A"), Options{})
+	if off != plain {
+		t.Errorf("negative weight scored %.2f, want %.2f as without embeddings", off, plain)
+	}
+	if expl.GetVibeMatch() != 0 || strings.Contains(expl.GetReason(), "vibe") {
+		t.Errorf("unknown vibe explained as %.1f %q", expl.GetVibeMatch(), expl.GetReason())
+	}
+}
+
+func TestScoreEdgePrefersOutroIntroWindows(t *testing.T) {
+	from := withVibe(buildAnalysis("from", 128, 6, "8A"), 1, 0)
+	to := withVibe(buildAnalysis("to", 128, 6, "8A"), 1, 0)
+	opts := Options{MixEmbeddings: map[string]MixEmbeddings{
+		"from": MixEmbeddingsFromWindows([]similarity.Window{
+			{StartSeconds: 0, DurationSeconds: 60, Embedding: []float32{0, 1}},
+			{StartSeconds: 180, DurationSeconds: 60, Embedding: []float32{0, 1}},
+		}, 240),
+		"to": MixEmbeddingsFromWindows([]similarity.Window{
+			{StartSeconds: 0, DurationSeconds: 60, Embedding: []float32{0, 1}},
+			{StartSeconds: 180, DurationSeconds: 60, Embedding: []float32{1, 0}},
+		}, 0),
+	}}
+
+	_, expl := scoreEdge(from, to, opts)
+	if expl.GetVibeMatch() != 100 {
+		t.Errorf("outro to intro vibe = %.1f, want 100", expl.GetVibeMatch())
+	}
+	_, back := scoreEdge(to, from, opts)
+	if back.GetVibeMatch() != 50 {
+		t.Errorf("reverse vibe = %.1f, want 50 for orthogonal windows", back.GetVibeMatch())
+	}
+}
@@ -0,0 +1,246 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cartomix/cancun/internal/storage"
+)
+
+// HealthReport summarizes a library health pass.
+type HealthReport struct {
+	Checked      int
+	NewlyMissing int              // tracks flagged missing by this pass
+	Recovered    int              // flagged tracks whose file is back at its path
+	Missing      []*storage.Track // every track whose file is gone
+}
+
+// CheckHealth checks that every track's file still exists, flagging tracks whose
+// file is gone and clearing the flag on tracks whose file is back.
+func (s *Scanner) CheckHealth(ctx context.Context) (*HealthReport, error) {
+	tracks, err := s.db.ListTracks("", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &HealthReport{}
+	for _, t := range tracks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		report.Checked++
+		exists := fileExists(t.Path)
+		switch {
+		case !exists && t.MissingAt.IsZero():
+			if err := s.db.SetTrackMissing(t.ID, true); err != nil {
+				return nil, err
+			}
+			report.NewlyMissing++
+		case exists && !t.MissingAt.IsZero():
+			if err := s.db.SetTrackMissing(t.ID, false); err != nil {
+				return nil, err
+			}
+			report.Recovered++
+		}
+	}
+
+	report.Missing, err = s.db.ListMissingTracks()
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ErrInvalidRelocation is returned for RelocateOptions that select no mode, or both.
+var ErrInvalidRelocation = errors.New("relocate needs old and new prefixes, or a search root")
+
+// RelocateOptions selects where Relocate looks for missing files.
+type RelocateOptions struct {
+	OldPrefix  string // rewrite paths below OldPrefix ...
+	NewPrefix  string // ... to the same relative path below NewPrefix
+	SearchRoot string // or look for each file anywhere below SearchRoot
+	DryRun     bool   // report matches without rewriting paths
+}
+
+// Relocation statuses.
+const (
+	RelocateMatched      = "matched"       // found with the same content; rewritten unless dry run
+	RelocateHashMismatch = "hash_mismatch" // a file exists at the new path but holds other audio
+	RelocateConflict     = "conflict"      // another track already owns the new path
+	RelocateNotFound     = "not_found"
+)
+
+// Relocation is the outcome for one missing track.
+type Relocation struct {
+	TrackID     int64
+	ContentHash string
+	OldPath     string
+	NewPath     string // empty when not found
+	Status      string
+}
+
+// RelocateReport lists what Relocate matched, or would match in a dry run.
+type RelocateReport struct {
+	DryRun      bool
+	Matched     int
+	Unmatched   int
+	Relocations []Relocation
+}
+
+// Relocate finds missing tracks at new locations and rewrites their paths in
+// bulk. Files are only accepted when their content hash matches the track's, so
+// analyses and cue edits never end up on different audio.
+func (s *Scanner) Relocate(ctx context.Context, opts RelocateOptions) (*RelocateReport, error) {
+	byPrefix := opts.OldPrefix != "" || opts.NewPrefix != ""
+	switch {
+	case byPrefix && opts.SearchRoot != "",
+		byPrefix && (opts.OldPrefix == "" || opts.NewPrefix == ""),
+		!byPrefix && opts.SearchRoot == "":
+		return nil, ErrInvalidRelocation
+	}
+
+	var candidates []*storage.Track
+	var err error
+	if byPrefix {
+		candidates, err = s.db.TracksUnder(filepath.Clean(opts.OldPrefix))
+	} else {
+		candidates, err = s.db.ListTracks("", 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var missing []*storage.Track
+	for _, t := range candidates {
+		if !fileExists(t.Path) {
+			missing = append(missing, t)
+		}
+	}
+
+	report := &RelocateReport{DryRun: opts.DryRun}
+	if len(missing) == 0 {
+		return report, nil
+	}
+
+	var found map[string]string // content hash -> path, for search mode
+	if !byPrefix {
+		if found, err = s.indexSearchRoot(ctx, opts.SearchRoot, missing); err != nil {
+			return nil, err
+		}
+	}
+
+	moves := make(map[int64]string)
+	for _, t := range missing {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		r := Relocation{TrackID: t.ID, ContentHash: t.ContentHash, OldPath: t.Path, Status: RelocateNotFound}
+		if byPrefix {
+			rel, err := filepath.Rel(filepath.Clean(opts.OldPrefix), t.Path)
+			if err == nil {
+				candidate := filepath.Join(opts.NewPrefix, rel)
+				if fileExists(candidate) {
+					r.NewPath = candidate
+					r.Status = RelocateHashMismatch
+					if s.holdsContent(t, candidate) {
+						r.Status = RelocateMatched
+					}
+				}
+			}
+		} else if path, ok := found[t.ContentHash]; ok {
+			r.NewPath = path
+			r.Status = RelocateMatched
+		}
+		if r.Status == RelocateMatched {
+			if owner, err := s.db.GetTrackByPath(r.NewPath); err == nil && owner.ID != t.ID {
+				r.Status = RelocateConflict
+			}
+		}
+
+		if r.Status == RelocateMatched {
+			moves[t.ID] = r.NewPath
+			report.Matched++
+		} else {
+			report.Unmatched++
+		}
+		report.Relocations = append(report.Relocations, r)
+	}
+
+	if !opts.DryRun && len(moves) > 0 {
+		if err := s.db.RelocateTracks(moves); err != nil {
+			return nil, err
+		}
+		s.logger.Info("relocated tracks", "count", len(moves))
+	}
+	return report, nil
+}
+
+// holdsContent reports whether the file at path has t's content hash, computed the
+// way t's key was.
+func (s *Scanner) holdsContent(t *storage.Track, path string) bool {
+	var hash string
+	var err error
+	if t.HashVersion >= HashVersion {
+		hash, err = ComputeHash(path)
+	} else {
+		hash, err = legacyHash(path)
+	}
+	return err == nil && hash == t.ContentHash
+}
+
+// indexSearchRoot hashes the audio files below root, keyed the way the missing
+// tracks are. Files owned by a track that is still in place reuse its key.
+func (s *Scanner) indexSearchRoot(ctx context.Context, root string, missing []*storage.Track) (map[string]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s: %w", root, ErrNotDirectory)
+	}
+
+	needLegacy := false
+	for _, t := range missing {
+		if t.HashVersion < HashVersion {
+			needLegacy = true
+			break
+		}
+	}
+
+	found := make(map[string]string)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || !SupportedFormats[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+
+		known := false
+		if owner, err := s.db.GetTrackByPath(path); err == nil && owner.MissingAt.IsZero() && owner.HashVersion >= HashVersion {
+			if info, err := d.Info(); err == nil && owner.FileSize == info.Size() && owner.FileModifiedAt.Equal(info.ModTime()) {
+				found[owner.ContentHash] = path
+				known = true
+			}
+		}
+		if !known {
+			if hash, err := ComputeHash(path); err == nil {
+				found[hash] = path
+			}
+		}
+		if needLegacy {
+			if hash, err := legacyHash(path); err == nil {
+				found[hash] = path
+			}
+		}
+		return nil
+	})
+	return found, err
+}
@@ -0,0 +1,169 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cartomix/cancun/internal/storage"
+)
+
+func TestCheckHealthFlagsMissingTracks(t *testing.T) {
+	db := openTestDB(t)
+	s := NewScanner(db, testLogger)
+	dir := t.TempDir()
+
+	kept := filepath.Join(dir, "kept.mp3")
+	gone := filepath.Join(dir, "gone.mp3")
+	writeAudio(t, kept, 1)
+	writeAudio(t, gone, 2)
+	for _, p := range []string{kept, gone} {
+		if r := s.ScanFile(p); r.Error != nil {
+			t.Fatal(r.Error)
+		}
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := s.CheckHealth(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Checked != 2 || report.NewlyMissing != 1 || len(report.Missing) != 1 || report.Missing[0].Path != gone {
+		t.Fatalf("report = %+v", report)
+	}
+
+	// A second pass keeps the flag without counting it again.
+	if report, _ = s.CheckHealth(t.Context()); report.NewlyMissing != 0 || len(report.Missing) != 1 {
+		t.Fatalf("second report = %+v", report)
+	}
+
+	writeAudio(t, gone, 2)
+	if report, _ = s.CheckHealth(t.Context()); report.Recovered != 1 || len(report.Missing) != 0 {
+		t.Fatalf("report after restore = %+v", report)
+	}
+}
+
+func TestRelocate(t *testing.T) {
+	setup := func(t *testing.T) (*Scanner, *storage.DB, string, string, map[string]int64) {
+		db := openTestDB(t)
+		s := NewScanner(db, testLogger)
+		oldRoot := filepath.Join(t.TempDir(), "Music")
+		ids := make(map[string]int64)
+		for i, name := range []string{"a.mp3", "sub/b.flac", "edited.mp3", "lost.mp3"} {
+			p := filepath.Join(oldRoot, name)
+			writeAudio(t, p, byte(10+i))
+			r := s.ScanFile(p)
+			if r.Error != nil {
+				t.Fatal(r.Error)
+			}
+			ids[name] = r.TrackID
+		}
+
+		// The folder moves; one file is re-encoded on the way and one is lost.
+		newRoot := filepath.Join(t.TempDir(), "Volumes", "Music")
+		if err := os.MkdirAll(filepath.Dir(newRoot), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(oldRoot, newRoot); err != nil {
+			t.Fatal(err)
+		}
+		writeAudio(t, filepath.Join(newRoot, "edited.mp3"), 99)
+		if err := os.Remove(filepath.Join(newRoot, "lost.mp3")); err != nil {
+			t.Fatal(err)
+		}
+		return s, db, oldRoot, newRoot, ids
+	}
+
+	statuses := func(report *RelocateReport) map[int64]string {
+		out := make(map[int64]string)
+		for _, r := range report.Relocations {
+			out[r.TrackID] = r.Status
+		}
+		return out
+	}
+
+	t.Run("prefix", func(t *testing.T) {
+		s, db, oldRoot, newRoot, ids := setup(t)
+
+		report, err := s.Relocate(t.Context(), RelocateOptions{OldPrefix: oldRoot, NewPrefix: newRoot, DryRun: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := statuses(report)
+		if report.Matched != 2 || got[ids["a.mp3"]] != RelocateMatched || got[ids["sub/b.flac"]] != RelocateMatched ||
+			got[ids["edited.mp3"]] != RelocateHashMismatch || got[ids["lost.mp3"]] != RelocateNotFound {
+			t.Fatalf("dry run = %+v", report.Relocations)
+		}
+		if tr, _ := db.GetTrackByID(ids["a.mp3"]); tr.Path != filepath.Join(oldRoot, "a.mp3") {
+			t.Fatal("dry run rewrote a path")
+		}
+
+		if _, err := s.Relocate(t.Context(), RelocateOptions{OldPrefix: oldRoot, NewPrefix: newRoot}); err != nil {
+			t.Fatal(err)
+		}
+		if tr, _ := db.GetTrackByID(ids["sub/b.flac"]); tr.Path != filepath.Join(newRoot, "sub", "b.flac") || !tr.MissingAt.IsZero() {
+			t.Fatalf("relocated track = %+v", tr)
+		}
+		if tr, _ := db.GetTrackByID(ids["edited.mp3"]); tr.Path != filepath.Join(oldRoot, "edited.mp3") {
+			t.Fatal("track moved onto different audio")
+		}
+	})
+
+	t.Run("search root", func(t *testing.T) {
+		s, db, _, newRoot, ids := setup(t)
+		// Files were also reorganized inside the new folder.
+		if err := os.Rename(filepath.Join(newRoot, "a.mp3"), filepath.Join(newRoot, "sub", "renamed.mp3")); err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := s.Relocate(t.Context(), RelocateOptions{SearchRoot: filepath.Dir(newRoot)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := statuses(report)
+		if report.Matched != 2 || got[ids["edited.mp3"]] != RelocateNotFound || got[ids["lost.mp3"]] != RelocateNotFound {
+			t.Fatalf("report = %+v", report.Relocations)
+		}
+		if tr, _ := db.GetTrackByID(ids["a.mp3"]); tr.Path != filepath.Join(newRoot, "sub", "renamed.mp3") {
+			t.Fatalf("relocated track = %+v", tr)
+		}
+	})
+
+	t.Run("non-ASCII prefix", func(t *testing.T) {
+		db := openTestDB(t)
+		s := NewScanner(db, testLogger)
+		oldRoot := filepath.Join(t.TempDir(), "Björk", "Homogénic")
+		p := filepath.Join(oldRoot, "jóga.flac")
+		writeAudio(t, p, 20)
+		r := s.ScanFile(p)
+		if r.Error != nil {
+			t.Fatal(r.Error)
+		}
+		newRoot := filepath.Join(t.TempDir(), "Musique", "Björk")
+		if err := os.MkdirAll(filepath.Dir(newRoot), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(oldRoot, newRoot); err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := s.Relocate(t.Context(), RelocateOptions{OldPrefix: oldRoot, NewPrefix: newRoot})
+		if err != nil || report.Matched != 1 {
+			t.Fatalf("report = %+v, %v", report, err)
+		}
+		if tr, _ := db.GetTrackByID(r.TrackID); tr.Path != filepath.Join(newRoot, "jóga.flac") {
+			t.Fatalf("relocated track = %+v", tr)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		s := NewScanner(openTestDB(t), testLogger)
+		for _, opts := range []RelocateOptions{{}, {OldPrefix: "/a"}, {OldPrefix: "/a", NewPrefix: "/b", SearchRoot: "/c"}} {
+			if _, err := s.Relocate(t.Context(), opts); err != ErrInvalidRelocation {
+				t.Errorf("%+v: err = %v", opts, err)
+			}
+		}
+	})
+}
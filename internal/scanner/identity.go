@@ -0,0 +1,362 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+	"strconv"
+)
+
+// HashVersion identifies how ComputeIdentity derives content hashes. Tracks keyed
+// under an older version are re-keyed by RekeyLegacyTracks.
+const HashVersion = 2
+
+// Identity identifies a file's audio independently of its tags.
+type Identity struct {
+	ContentHash string // SHA-256 of the audio payload; unchanged by re-tagging
+	FileHash    string // SHA-256 of the whole file
+}
+
+// byteRange is a half-open [start, end) span of a file.
+type byteRange struct {
+	start, end int64
+}
+
+// ComputeIdentity hashes the audio payload of a file, skipping ID3, APE and
+// Lyrics3 tags, Vorbis/Opus comment headers, FLAC metadata blocks, RIFF/AIFF
+// metadata chunks and MP4 boxes other than mdat. The whole file is hashed in the
+// same pass. Files whose layout isn't recognized are hashed in full.
+func ComputeIdentity(path string) (Identity, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Identity{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return Identity{}, err
+	}
+
+	ranges, err := audioRanges(file, info.Size())
+	if err != nil || len(ranges) == 0 {
+		ranges = []byteRange{{0, info.Size()}}
+	}
+
+	payload, full := sha256.New(), sha256.New()
+	buf := make([]byte, 256*1024)
+	var off int64
+	next := 0
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			chunk := buf[:n]
+			full.Write(chunk)
+			end := off + int64(n)
+			for next < len(ranges) && ranges[next].start < end {
+				r := ranges[next]
+				if s, e := max(r.start, off), min(r.end, end); s < e {
+					payload.Write(chunk[s-off : e-off])
+				}
+				if r.end > end {
+					break
+				}
+				next++
+			}
+			off = end
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Identity{}, err
+		}
+	}
+
+	return Identity{
+		ContentHash: hex.EncodeToString(payload.Sum(nil)),
+		FileHash:    hex.EncodeToString(full.Sum(nil)),
+	}, nil
+}
+
+// ComputeHash returns the audio payload hash used as a track's content_hash.
+func ComputeHash(path string) (string, error) {
+	id, err := ComputeIdentity(path)
+	return id.ContentHash, err
+}
+
+// legacyHash is the HashVersion 1 content hash: SHA-256 of the first 64KB.
+func legacyHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.CopyN(h, file, 64*1024); err != nil && err != io.EOF {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// audioRanges locates the audio payload of a file by sniffing its container.
+func audioRanges(r io.ReaderAt, size int64) ([]byteRange, error) {
+	start := skipID3v2(r, size)
+
+	head := make([]byte, 12)
+	n, _ := r.ReadAt(head, start)
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return flacRanges(r, start, trailingTagsStart(r, size))
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return oggRanges(r, start, size)
+	case len(head) == 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return chunkRanges(r, start+12, size, binary.LittleEndian, "fmt ", "data")
+	case len(head) == 12 && string(head[:4]) == "FORM" && (string(head[8:12]) == "AIFF" || string(head[8:12]) == "AIFC"):
+		return chunkRanges(r, start+12, size, binary.BigEndian, "COMM", "SSND")
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return mp4Ranges(r, start, size)
+	}
+
+	// MPEG audio frames: everything between the leading and trailing tags.
+	end := trailingTagsStart(r, size)
+	if end <= start {
+		return nil, nil
+	}
+	return []byteRange{{start, end}}, nil
+}
+
+// skipID3v2 returns the offset just past any ID3v2 tags at the start of the file.
+func skipID3v2(r io.ReaderAt, size int64) int64 {
+	var off int64
+	header := make([]byte, 10)
+	for {
+		if _, err := r.ReadAt(header, off); err != nil || string(header[:3]) != "ID3" {
+			return off
+		}
+		tagSize := int64(header[6]&0x7f)<<21 | int64(header[7]&0x7f)<<14 | int64(header[8]&0x7f)<<7 | int64(header[9]&0x7f)
+		next := off + 10 + tagSize
+		if header[5]&0x10 != 0 {
+			next += 10 // footer present
+		}
+		if next > size {
+			return off
+		}
+		off = next
+	}
+}
+
+// trailingTagsStart returns where ID3v1, APEv2 and Lyrics3v2 tags at the end of
+// the file begin.
+func trailingTagsStart(r io.ReaderAt, size int64) int64 {
+	end := size
+	buf := make([]byte, 32)
+	for {
+		switch {
+		case end >= 128 && readAt(r, buf[:3], end-128) && string(buf[:3]) == "TAG":
+			end -= 128
+		case end >= 32 && readAt(r, buf, end-32) && string(buf[:8]) == "APETAGEX":
+			tagSize := int64(binary.LittleEndian.Uint32(buf[12:16]))
+			if binary.LittleEndian.Uint32(buf[20:24])&(1<<31) != 0 {
+				tagSize += 32 // header present
+			}
+			if tagSize < 32 || tagSize > end {
+				return end
+			}
+			end -= tagSize
+		case end >= 15 && readAt(r, buf[:15], end-15) && string(buf[6:15]) == "LYRICS200":
+			tagSize, err := strconv.ParseInt(string(buf[:6]), 10, 64)
+			if err != nil || tagSize+15 > end {
+				return end
+			}
+			end -= tagSize + 15
+		default:
+			return end
+		}
+	}
+}
+
+func readAt(r io.ReaderAt, buf []byte, off int64) bool {
+	n, _ := r.ReadAt(buf, off)
+	return n == len(buf)
+}
+
+// flacRanges skips the metadata blocks (STREAMINFO, VORBIS_COMMENT, PICTURE, ...)
+// and returns the audio frames that follow.
+func flacRanges(r io.ReaderAt, start, end int64) ([]byteRange, error) {
+	off := start + 4
+	header := make([]byte, 4)
+	for {
+		if _, err := r.ReadAt(header, off); err != nil {
+			return nil, err
+		}
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		off += 4 + length
+		if header[0]&0x80 != 0 {
+			break
+		}
+	}
+	if off >= end {
+		return nil, nil
+	}
+	return []byteRange{{off, end}}, nil
+}
+
+// oggRanges returns the packet data of every Ogg page except the second packet,
+// which is the comment header in both Vorbis and Opus streams. Page headers are
+// skipped too, since their sequence numbers and CRCs shift when comments grow.
+func oggRanges(r io.ReaderAt, start, size int64) ([]byteRange, error) {
+	var ranges []byteRange
+	off := start
+	header := make([]byte, 27)
+	lacing := make([]byte, 255)
+	packet := 0
+	for off+27 <= size {
+		if _, err := r.ReadAt(header, off); err != nil {
+			return nil, err
+		}
+		if string(header[:4]) != "OggS" {
+			break
+		}
+		segments := lacing[:header[26]]
+		if _, err := r.ReadAt(segments, off+27); err != nil {
+			return nil, err
+		}
+		pos := off + 27 + int64(len(segments))
+		for _, l := range segments {
+			if packet != 1 {
+				ranges = appendRange(ranges, byteRange{pos, pos + int64(l)})
+			}
+			pos += int64(l)
+			if l < 255 {
+				packet++
+			}
+		}
+		off = pos
+	}
+	return clampRanges(ranges, size), nil
+}
+
+// chunkRanges returns the bodies of the wanted chunks of a RIFF or AIFF file.
+func chunkRanges(r io.ReaderAt, off, size int64, order binary.ByteOrder, wanted ...string) ([]byteRange, error) {
+	var ranges []byteRange
+	header := make([]byte, 8)
+	for off+8 <= size {
+		if _, err := r.ReadAt(header, off); err != nil {
+			return nil, err
+		}
+		id := string(header[:4])
+		length := int64(order.Uint32(header[4:8]))
+		body := off + 8
+		for _, w := range wanted {
+			if id == w {
+				ranges = appendRange(ranges, byteRange{body, body + length})
+			}
+		}
+		off = body + length + length%2 // chunks are padded to even sizes
+	}
+	return clampRanges(ranges, size), nil
+}
+
+// mp4Ranges returns the contents of the top-level mdat boxes. Tags live in moov.
+func mp4Ranges(r io.ReaderAt, off, size int64) ([]byteRange, error) {
+	var ranges []byteRange
+	header := make([]byte, 16)
+	for off+8 <= size {
+		if _, err := r.ReadAt(header[:8], off); err != nil {
+			return nil, err
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		headerLen := int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = size - off // extends to the end of the file
+		case 1:
+			if _, err := r.ReadAt(header[8:16], off+8); err != nil {
+				return nil, err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerLen = 16
+		}
+		if boxSize < headerLen {
+			break
+		}
+		if string(header[4:8]) == "mdat" {
+			ranges = appendRange(ranges, byteRange{off + headerLen, off + boxSize})
+		}
+		off += boxSize
+	}
+	return clampRanges(ranges, size), nil
+}
+
+// appendRange adds r to ranges, merging it with the last range when contiguous.
+func appendRange(ranges []byteRange, r byteRange) []byteRange {
+	if r.start >= r.end {
+		return ranges
+	}
+	if n := len(ranges); n > 0 && ranges[n-1].end == r.start {
+		ranges[n-1].end = r.end
+		return ranges
+	}
+	return append(ranges, r)
+}
+
+// clampRanges trims ranges that run past the end of a truncated file.
+func clampRanges(ranges []byteRange, size int64) []byteRange {
+	out := ranges[:0]
+	for _, r := range ranges {
+		r.end = min(r.end, size)
+		if r.start < r.end {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// RekeyResult summarizes a RekeyLegacyTracks pass.
+type RekeyResult struct {
+	Rekeyed    int // tracks moved to the current HashVersion
+	Missing    int // files not found; re-keyed when a scan finds them again
+	Collisions int // audio already owned by another track; left on the old key
+}
+
+// RekeyLegacyTracks recomputes the content hash of tracks keyed under an older
+// HashVersion. Track IDs are kept, so analyses, cue edits and training labels
+// stay attached.
+func (s *Scanner) RekeyLegacyTracks(ctx context.Context) (RekeyResult, error) {
+	var result RekeyResult
+	tracks, err := s.db.TracksBelowHashVersion(HashVersion)
+	if err != nil {
+		return result, err
+	}
+
+	for _, t := range tracks {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		identity, err := ComputeIdentity(t.Path)
+		if err != nil {
+			result.Missing++
+			continue
+		}
+		if other, err := s.db.GetTrackByHash(identity.ContentHash); err == nil && other.ID != t.ID {
+			s.logger.Warn("content hash collision", "path", t.Path, "track_id", t.ID,
+				"existing_path", other.Path, "existing_track_id", other.ID,
+				"identical_file", other.FileHash == identity.FileHash)
+			result.Collisions++
+			continue
+		}
+		if err := s.db.RekeyTrack(t.ID, identity.ContentHash, identity.FileHash, HashVersion); err != nil {
+			return result, err
+		}
+		result.Rekeyed++
+	}
+	return result, nil
+}
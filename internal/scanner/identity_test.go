@@ -0,0 +1,292 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cartomix/cancun/internal/storage"
+)
+
+func writeFile(t *testing.T, dir, name string, parts ...[]byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, bytes.Join(parts, nil), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func identityOf(t *testing.T, path string) Identity {
+	t.Helper()
+	id, err := ComputeIdentity(path)
+	if err != nil {
+		t.Fatalf("identity of %s: %v", path, err)
+	}
+	return id
+}
+
+func filled(n int, b byte) []byte { return bytes.Repeat([]byte{b}, n) }
+
+func id3v2(bodySize int) []byte {
+	header := []byte{'I', 'D', '3', 4, 0, 0,
+		byte(bodySize >> 21 & 0x7f), byte(bodySize >> 14 & 0x7f), byte(bodySize >> 7 & 0x7f), byte(bodySize & 0x7f)}
+	return append(header, filled(bodySize, 'T')...)
+}
+
+func id3v1(title string) []byte {
+	tag := append([]byte("TAG"+title), make([]byte, 125-len(title))...)
+	return tag
+}
+
+func apeTag(items int) []byte {
+	body := filled(items*20, 'a')
+	footer := make([]byte, 32)
+	copy(footer, "APETAGEX")
+	binary.LittleEndian.PutUint32(footer[8:], 2000)
+	binary.LittleEndian.PutUint32(footer[12:], uint32(len(body)+32))
+	binary.LittleEndian.PutUint32(footer[16:], uint32(items))
+	return append(body, footer...)
+}
+
+func flacBlock(kind byte, last bool, body []byte) []byte {
+	if last {
+		kind |= 0x80
+	}
+	n := len(body)
+	return append([]byte{kind, byte(n >> 16), byte(n >> 8), byte(n)}, body...)
+}
+
+func riffChunk(id string, order binary.ByteOrder, body []byte) []byte {
+	out := make([]byte, 8, 8+len(body)+1)
+	copy(out, id)
+	order.PutUint32(out[4:], uint32(len(body)))
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func riff(form, kind string, order binary.ByteOrder, chunks ...[]byte) []byte {
+	body := bytes.Join(chunks, nil)
+	out := make([]byte, 12)
+	copy(out, form)
+	order.PutUint32(out[4:], uint32(4+len(body)))
+	copy(out[8:], kind)
+	return append(out, body...)
+}
+
+func mp4Box(kind string, body []byte) []byte {
+	out := make([]byte, 8)
+	binary.BigEndian.PutUint32(out, uint32(8+len(body)))
+	copy(out[4:], kind)
+	return append(out, body...)
+}
+
+// oggStream lays packets out over Ogg pages whose bodies hold at most maxBody bytes.
+func oggStream(maxBody int, packets ...[]byte) []byte {
+	var lacing []byte
+	var data []byte
+	for _, p := range packets {
+		for n := len(p); ; n -= 255 {
+			if n < 255 {
+				lacing = append(lacing, byte(n))
+				break
+			}
+			lacing = append(lacing, 255)
+		}
+		data = append(data, p...)
+	}
+
+	var out []byte
+	seq := uint32(0)
+	for len(lacing) > 0 {
+		var segs []byte
+		body := 0
+		for len(lacing) > 0 && len(segs) < 255 && body < maxBody {
+			segs = append(segs, lacing[0])
+			body += int(lacing[0])
+			lacing = lacing[1:]
+		}
+		header := make([]byte, 27)
+		copy(header, "OggS")
+		binary.LittleEndian.PutUint32(header[18:], seq)
+		binary.LittleEndian.PutUint32(header[22:], seq*7919) // stand-in CRC
+		header[26] = byte(len(segs))
+		out = append(out, header...)
+		out = append(out, segs...)
+		out = append(out, data[:body]...)
+		data = data[body:]
+		seq++
+	}
+	return out
+}
+
+func TestIdentityIgnoresTags(t *testing.T) {
+	dir := t.TempDir()
+	frames := append([]byte{0xFF, 0xFB, 0x90, 0x64}, filled(8000, 0x55)...)
+	streamInfo := filled(34, 1)
+	frames2 := append([]byte{0xFF, 0xF8}, filled(6000, 0x33)...)
+	ogg := func(comment []byte, audio byte) []byte {
+		return oggStream(512, append([]byte("\x01vorbis"), filled(23, 2)...), comment, filled(300, 3),
+			filled(1200, audio), filled(900, audio+1))
+	}
+	fmtChunk := filled(16, 4)
+	pcm := filled(4001, 5)
+	comm := filled(18, 6)
+
+	cases := []struct {
+		name       string
+		a, b, diff []byte
+	}{
+		{
+			name: "mp3",
+			a:    bytes.Join([][]byte{id3v2(100), frames}, nil),
+			b:    bytes.Join([][]byte{id3v2(70000), frames, apeTag(3), id3v1("retagged")}, nil),
+			diff: bytes.Join([][]byte{id3v2(100), frames[:7000], filled(1004, 0x56)}, nil),
+		},
+		{
+			name: "flac",
+			a:    bytes.Join([][]byte{[]byte("fLaC"), flacBlock(0, false, streamInfo), flacBlock(4, true, filled(40, 'c')), frames2}, nil),
+			b: bytes.Join([][]byte{id3v2(50), []byte("fLaC"), flacBlock(0, false, streamInfo), flacBlock(4, false, filled(400, 'd')),
+				flacBlock(6, true, filled(90000, 'p')), frames2}, nil),
+			diff: bytes.Join([][]byte{[]byte("fLaC"), flacBlock(0, false, streamInfo), flacBlock(4, true, filled(40, 'c')), frames2[:5000], filled(1002, 0x34)}, nil),
+		},
+		{
+			name: "ogg",
+			a:    ogg(append([]byte("\x03vorbis"), filled(40, 'c')...), 7),
+			b:    ogg(append([]byte("\x03vorbis"), filled(2000, 'd')...), 7),
+			diff: ogg(append([]byte("\x03vorbis"), filled(40, 'c')...), 9),
+		},
+		{
+			name: "wav",
+			a:    riff("RIFF", "WAVE", binary.LittleEndian, riffChunk("fmt ", binary.LittleEndian, fmtChunk), riffChunk("data", binary.LittleEndian, pcm)),
+			b: riff("RIFF", "WAVE", binary.LittleEndian, riffChunk("fmt ", binary.LittleEndian, fmtChunk),
+				riffChunk("LIST", binary.LittleEndian, filled(77, 'l')), riffChunk("data", binary.LittleEndian, pcm),
+				riffChunk("id3 ", binary.LittleEndian, id3v2(20))),
+			diff: riff("RIFF", "WAVE", binary.LittleEndian, riffChunk("fmt ", binary.LittleEndian, fmtChunk), riffChunk("data", binary.LittleEndian, filled(4001, 8))),
+		},
+		{
+			name: "aiff",
+			a:    riff("FORM", "AIFF", binary.BigEndian, riffChunk("COMM", binary.BigEndian, comm), riffChunk("SSND", binary.BigEndian, pcm)),
+			b: riff("FORM", "AIFF", binary.BigEndian, riffChunk("COMM", binary.BigEndian, comm),
+				riffChunk("ID3 ", binary.BigEndian, id3v2(300)), riffChunk("SSND", binary.BigEndian, pcm)),
+			diff: riff("FORM", "AIFF", binary.BigEndian, riffChunk("COMM", binary.BigEndian, filled(18, 9)), riffChunk("SSND", binary.BigEndian, pcm)),
+		},
+		{
+			name: "m4a",
+			a:    bytes.Join([][]byte{mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00")), mp4Box("moov", filled(200, 'm')), mp4Box("mdat", pcm)}, nil),
+			b:    bytes.Join([][]byte{mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00")), mp4Box("mdat", pcm), mp4Box("moov", filled(5000, 'n'))}, nil),
+			diff: bytes.Join([][]byte{mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00")), mp4Box("moov", filled(200, 'm')), mp4Box("mdat", filled(4001, 1))}, nil),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := identityOf(t, writeFile(t, dir, tc.name+"-a", tc.a))
+			b := identityOf(t, writeFile(t, dir, tc.name+"-b", tc.b))
+			diff := identityOf(t, writeFile(t, dir, tc.name+"-diff", tc.diff))
+			if a.ContentHash != b.ContentHash {
+				t.Error("re-tagged file has a different content hash")
+			}
+			if a.FileHash == b.FileHash {
+				t.Error("re-tagged file has the same file hash")
+			}
+			if a.ContentHash == diff.ContentHash {
+				t.Error("different audio has the same content hash")
+			}
+		})
+	}
+}
+
+func TestIdentityDistinguishesSharedIntro(t *testing.T) {
+	dir := t.TempDir()
+	intro := append([]byte{0xFF, 0xFB, 0x90, 0x64}, filled(100*1024, 1)...)
+	a := writeFile(t, dir, "radio-edit.mp3", intro, filled(4096, 2))
+	b := writeFile(t, dir, "extended-mix.mp3", intro, filled(4096, 3))
+
+	oldA, _ := legacyHash(a)
+	oldB, _ := legacyHash(b)
+	if oldA != oldB {
+		t.Fatal("fixtures should collide under the legacy hash")
+	}
+	if identityOf(t, a).ContentHash == identityOf(t, b).ContentHash {
+		t.Fatal("edits sharing an intro have the same content hash")
+	}
+}
+
+func TestScanRekeysLegacyTracksAndReportsCollisions(t *testing.T) {
+	db := openTestDB(t)
+	s := NewScanner(db, testLogger)
+	dir := t.TempDir()
+
+	frames := append([]byte{0xFF, 0xFB, 0x90, 0x64}, filled(8000, 0x42)...)
+	atPath := writeFile(t, dir, "kept.mp3", id3v2(64), frames)
+	movedTo := filepath.Join(dir, "moved.mp3")
+	moved := writeFile(t, dir, "moved-src.mp3", id3v2(64), filled(9000, 0x43))
+	sweep := writeFile(t, dir, "sweep.mp3", id3v2(64), filled(9000, 0x44))
+
+	// Tracks as an older engine recorded them.
+	legacy := func(path string) int64 {
+		t.Helper()
+		old, _ := legacyHash(path)
+		id, err := db.UpsertTrack(&storage.Track{ContentHash: old, Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	keptID, movedID, sweepID := legacy(atPath), legacy(moved), legacy(sweep)
+	if err := os.Rename(moved, movedTo); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same path: re-keyed in place, not reported as changed audio.
+	r := s.ScanFile(atPath)
+	if r.Error != nil || r.TrackID != keptID || r.Changed || r.IsNew {
+		t.Fatalf("legacy track at its path: %+v", r)
+	}
+	// Moved before the upgrade: found again through its legacy hash.
+	r = s.ScanFile(movedTo)
+	if r.Error != nil || r.TrackID != movedID || !r.Moved {
+		t.Fatalf("legacy track at a new path: %+v", r)
+	}
+	got, _ := db.GetTrackByID(movedID)
+	if got.HashVersion != HashVersion || got.FileHash == "" || got.Path != movedTo {
+		t.Fatalf("moved track not re-keyed: %+v", got)
+	}
+
+	// A re-tagged copy of kept.mp3 collides with it.
+	copyPath := writeFile(t, dir, "copy.mp3", id3v2(4096), frames, id3v1("copy"))
+	r = s.ScanFile(copyPath)
+	if r.CollidesWith != atPath || r.TrackID != keptID {
+		t.Fatalf("copy: %+v", r)
+	}
+
+	// The startup pass handles what scans haven't reached, and reports collisions.
+	dupe := writeFile(t, dir, "dupe.mp3", id3v2(128), frames)
+	dupeID := legacy(dupe)
+	result, err := s.RekeyLegacyTracks(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Rekeyed != 1 || result.Collisions != 1 {
+		t.Fatalf("rekey result = %+v", result)
+	}
+	if got, _ := db.GetTrackByID(sweepID); got.HashVersion != HashVersion {
+		t.Fatalf("sweep track not re-keyed: %+v", got)
+	}
+	if got, _ := db.GetTrackByID(dupeID); got.HashVersion == HashVersion {
+		t.Fatal("colliding legacy track was re-keyed")
+	}
+
+	// Rewriting a file in place is still reported as changed audio.
+	writeFile(t, dir, "kept.mp3", id3v2(64), filled(8004, 0x45))
+	r = s.ScanFile(atPath)
+	if r.TrackID != keptID || !r.Changed {
+		t.Fatalf("rewritten file: %+v", r)
+	}
+}
@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// notifier reports paths that changed inside watched directories. Watches are not
+// recursive: the Watcher adds each subdirectory itself. An empty path means events
+// were lost and everything should be rescanned.
+type notifier interface {
+	Add(dir string) error
+	Remove(dir string) // also drops every watched directory below dir
+	Events() <-chan string
+	Close() error
+}
+
+// newNotifier prefers the platform's native file notifications and falls back to
+// polling where they are unavailable.
+func newNotifier(pollInterval time.Duration) (notifier, string) {
+	if n, err := newPlatformNotifier(); err == nil {
+		return n, platformNotifierName
+	}
+	return newPoller(pollInterval), "poll"
+}
+
+// isUnder reports whether path is dir or lies below it.
+func isUnder(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// poller detects changes by listing watched directories at a fixed interval.
+type poller struct {
+	interval time.Duration
+	events   chan string
+	done     chan struct{}
+	once     sync.Once
+
+	mu   sync.Mutex
+	dirs map[string]map[string]entryState // dir -> name -> state
+}
+
+type entryState struct {
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+func newPoller(interval time.Duration) *poller {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	p := &poller{
+		interval: interval,
+		events:   make(chan string, 256),
+		done:     make(chan struct{}),
+		dirs:     make(map[string]map[string]entryState),
+	}
+	go p.loop()
+	return p
+}
+
+func (p *poller) Add(dir string) error {
+	entries, err := listDir(dir)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.dirs[dir] = entries
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *poller) Remove(dir string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for d := range p.dirs {
+		if isUnder(d, dir) {
+			delete(p.dirs, d)
+		}
+	}
+}
+
+func (p *poller) Events() <-chan string { return p.events }
+
+func (p *poller) Close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+func (p *poller) loop() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			for _, path := range p.poll() {
+				select {
+				case p.events <- path:
+				case <-p.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// poll re-lists every watched directory and returns the paths that appeared,
+// disappeared or changed size or modification time.
+func (p *poller) poll() []string {
+	p.mu.Lock()
+	dirs := make([]string, 0, len(p.dirs))
+	for d := range p.dirs {
+		dirs = append(dirs, d)
+	}
+	p.mu.Unlock()
+
+	var changed []string
+	for _, dir := range dirs {
+		current, err := listDir(dir)
+		p.mu.Lock()
+		previous, watched := p.dirs[dir]
+		if watched && err == nil {
+			p.dirs[dir] = current
+		}
+		p.mu.Unlock()
+		if !watched || err != nil {
+			continue // removed meanwhile, or gone; the parent reports it
+		}
+		for name, state := range current {
+			if old, ok := previous[name]; !ok || old != state {
+				changed = append(changed, filepath.Join(dir, name))
+			}
+		}
+		for name := range previous {
+			if _, ok := current[name]; !ok {
+				changed = append(changed, filepath.Join(dir, name))
+			}
+		}
+	}
+	return changed
+}
+
+func listDir(dir string) (map[string]entryState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]entryState, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		state := entryState{isDir: e.IsDir()}
+		if !state.isDir {
+			state.size, state.modTime = info.Size(), info.ModTime()
+		}
+		states[e.Name()] = state
+	}
+	return states, nil
+}
@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const platformNotifierName = "inotify"
+
+// inotifyMask covers files finished writing, moved in or out, and deleted, plus new
+// directories (files are reported once closed, not when first created).
+const inotifyMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_FROM |
+	syscall.IN_MOVED_TO | syscall.IN_DELETE | syscall.IN_DELETE_SELF
+
+// inotify watches directories with Linux inotify.
+type inotify struct {
+	fd     int // kept separately: File.Fd would switch the descriptor to blocking mode
+	file   *os.File
+	events chan string
+	done   chan struct{}
+	once   sync.Once
+
+	mu   sync.Mutex
+	wds  map[int32]string
+	dirs map[string]int32
+}
+
+func newPlatformNotifier() (notifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	n := &inotify{
+		fd: fd,
+		// A non-blocking descriptor makes the file pollable, so Close unblocks Read.
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		events: make(chan string, 256),
+		done:   make(chan struct{}),
+		wds:    make(map[int32]string),
+		dirs:   make(map[string]int32),
+	}
+	go n.readLoop()
+	return n, nil
+}
+
+func (n *inotify) Add(dir string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.dirs[dir]; ok {
+		return nil
+	}
+	wd, err := syscall.InotifyAddWatch(n.fd, dir, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	n.wds[int32(wd)] = dir
+	n.dirs[dir] = int32(wd)
+	return nil
+}
+
+func (n *inotify) Remove(dir string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for d, wd := range n.dirs {
+		if isUnder(d, dir) {
+			syscall.InotifyRmWatch(n.fd, uint32(wd))
+			delete(n.dirs, d)
+			delete(n.wds, wd)
+		}
+	}
+}
+
+func (n *inotify) Events() <-chan string { return n.events }
+
+func (n *inotify) Close() error {
+	var err error
+	n.once.Do(func() {
+		close(n.done)
+		err = n.file.Close()
+	})
+	return err
+}
+
+// emit delivers a path unless the notifier is closing.
+func (n *inotify) emit(path string) bool {
+	select {
+	case n.events <- path:
+		return true
+	case <-n.done:
+		return false
+	}
+}
+
+func (n *inotify) readLoop() {
+	defer close(n.events)
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		read, err := n.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= read; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			name := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+
+			if ev.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				if !n.emit("") {
+					return
+				}
+				continue
+			}
+
+			n.mu.Lock()
+			dir, ok := n.wds[ev.Wd]
+			if ev.Mask&syscall.IN_IGNORED != 0 && ok {
+				delete(n.wds, ev.Wd)
+				if n.dirs[dir] == ev.Wd {
+					delete(n.dirs, dir)
+				}
+			}
+			n.mu.Unlock()
+			if !ok || ev.Mask&syscall.IN_IGNORED != 0 {
+				continue
+			}
+			if ev.Mask&syscall.IN_DELETE_SELF != 0 {
+				// Matters for roots, whose parent directory isn't watched.
+				if !n.emit(dir) {
+					return
+				}
+				continue
+			}
+			if ev.Mask&syscall.IN_CREATE != 0 && ev.Mask&syscall.IN_ISDIR == 0 {
+				continue // wait for IN_CLOSE_WRITE
+			}
+			if !n.emit(filepath.Join(dir, strings.TrimRight(string(name), "\x00"))) {
+				return
+			}
+		}
+	}
+}
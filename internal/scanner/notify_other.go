@@ -0,0 +1,12 @@
+//go:build !linux
+
+package scanner
+
+import "errors"
+
+const platformNotifierName = "none"
+
+// newPlatformNotifier has no native implementation here; the Watcher polls instead.
+func newPlatformNotifier() (notifier, error) {
+	return nil, errors.ErrUnsupported
+}
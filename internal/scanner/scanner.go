@@ -2,9 +2,6 @@ package scanner
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
-	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -12,6 +9,7 @@ import (
 	"time"
 
 	"github.com/cartomix/cancun/internal/storage"
+	"github.com/cartomix/cancun/internal/tags"
 )
 
 // SupportedFormats lists the audio formats we can process.
@@ -34,23 +32,29 @@ type Scanner struct {
 
 // ScanResult holds the result of scanning a file.
 type ScanResult struct {
-	Path        string
-	ContentHash string
-	TrackID     int64
-	IsNew       bool
-	Error       error
+	Path         string
+	ContentHash  string
+	TrackID      int64
+	IsNew        bool
+	Updated      bool   // tags re-read for a known track
+	Moved        bool   // known track found under a new path
+	Changed      bool   // known path now holds different audio; needs re-analysis
+	CollidesWith string // path of the track already owning this content hash
+	Error        error
 }
 
 // ScanProgress reports scanning progress with enhanced details.
 type ScanProgress struct {
-	Path        string
-	Status      string // queued, processing, done, skipped, error
-	Error       string
-	Processed   int64
-	Total       int64
-	TrackID     int64
-	IsNew       bool
-	ContentHash string
+	Path         string
+	Status       string // queued, processing, done, updated, moved, changed, collision, skipped, error
+	Error        string
+	Processed    int64
+	Total        int64
+	TrackID      int64
+	IsNew        bool
+	Changed      bool // audio at a known path was replaced; re-analyze with force
+	ContentHash  string
+	CollidesWith string // set with status "collision"
 
 	// Enhanced progress fields (v1.0)
 	CurrentFile    string  // Filename being processed (without path)
@@ -59,6 +63,7 @@ type ScanProgress struct {
 	ETAMs          int64   // Estimated time remaining in milliseconds
 	NewTracksFound int64   // Count of new tracks discovered
 	SkippedCached  int64   // Count of tracks skipped (already in DB)
+	Collisions     int64   // Count of files whose audio another track already owns
 	BytesProcessed int64   // Total bytes processed so far
 	BytesTotal     int64   // Total bytes to process (if known)
 }
@@ -92,6 +97,7 @@ func (s *Scanner) Scan(ctx context.Context, roots []string, forceRescan bool, pr
 	var processed int64
 	var newTracksFound int64
 	var skippedCached int64
+	var collisions int64
 	var bytesProcessed int64
 
 	for _, root := range roots {
@@ -131,6 +137,15 @@ func (s *Scanner) Scan(ctx context.Context, roots []string, forceRescan bool, pr
 			if result.Error != nil {
 				status = "error"
 				errMsg = result.Error.Error()
+			} else if result.CollidesWith != "" {
+				status = "collision"
+				collisions++
+			} else if result.Updated {
+				status = "updated"
+			} else if result.Moved {
+				status = "moved"
+			} else if result.Changed {
+				status = "changed"
 			} else if !result.IsNew {
 				status = "skipped"
 				skippedCached++
@@ -163,13 +178,16 @@ func (s *Scanner) Scan(ctx context.Context, roots []string, forceRescan bool, pr
 				Total:          total,
 				TrackID:        result.TrackID,
 				IsNew:          result.IsNew,
+				Changed:        result.Changed,
 				ContentHash:    result.ContentHash,
+				CollidesWith:   result.CollidesWith,
 				CurrentFile:    filepath.Base(path),
 				Percent:        percent,
 				ElapsedMs:      elapsedMs,
 				ETAMs:          etaMs,
 				NewTracksFound: newTracksFound,
 				SkippedCached:  skippedCached,
+				Collisions:     collisions,
 				BytesProcessed: bytesProcessed,
 				BytesTotal:     bytesTotal,
 			}:
@@ -222,6 +240,11 @@ func (s *Scanner) countFiles(root string) (int64, error) {
 	return count, err
 }
 
+// ScanFile adds or refreshes a single file, as Scan does for each file it finds.
+func (s *Scanner) ScanFile(path string) ScanResult {
+	return s.processFile(path, false)
+}
+
 func (s *Scanner) processFile(path string, forceRescan bool) ScanResult {
 	result := ScanResult{Path: path}
 
@@ -232,33 +255,86 @@ func (s *Scanner) processFile(path string, forceRescan bool) ScanResult {
 		return result
 	}
 
-	// Compute content hash (first 64KB for speed)
-	hash, err := ComputeHash(path)
+	// Files unchanged since their last scan are skipped without hashing.
+	if !forceRescan {
+		if prev, err := s.db.GetTrackByPath(path); err == nil && prev.HashVersion >= HashVersion &&
+			prev.MissingAt.IsZero() && prev.FileSize == info.Size() &&
+			prev.FileModifiedAt.Equal(info.ModTime()) && !prev.TagsReadAt.IsZero() {
+			result.TrackID = prev.ID
+			result.ContentHash = prev.ContentHash
+			return result
+		}
+	}
+
+	identity, err := ComputeIdentity(path)
 	if err != nil {
 		result.Error = err
 		return result
 	}
+	hash := identity.ContentHash
 	result.ContentHash = hash
 
-	// Check if already in database
-	if !forceRescan {
-		existing, err := s.db.GetTrackByHash(hash)
-		if err == nil && existing != nil {
-			result.TrackID = existing.ID
-			result.IsNew = false
-			return result
+	// The same audio already belongs to a track whose file is still in place: a
+	// copy, or the same recording with different tags. Only one can own the hash.
+	existing, err := s.db.GetTrackByHash(hash)
+	if err != nil {
+		existing = nil
+	}
+	if existing != nil && existing.Path != path && existing.MissingAt.IsZero() && fileExists(existing.Path) {
+		result.TrackID = existing.ID
+		result.CollidesWith = existing.Path
+		s.logger.Warn("content hash collision", "path", path, "track_id", existing.ID,
+			"existing_path", existing.Path, "identical_file", existing.FileHash == identity.FileHash)
+		return result
+	}
+
+	// No track owns this audio yet. Either the file at a known track's path was
+	// rewritten in place, or a track keyed under an older HashVersion needs its
+	// key recomputed; both keep the track (and its analyses and cue edits).
+	var replaced bool
+	if existing == nil {
+		prev, err := s.db.GetTrackByPath(path)
+		if err != nil {
+			prev = nil
+		}
+		if prev == nil {
+			prev = s.legacyTrackFor(path)
+		}
+		if prev != nil {
+			replaced = prev.Path == path && !s.sameLegacyContent(prev, path)
+			if err := s.db.RekeyTrack(prev.ID, hash, identity.FileHash, HashVersion); err != nil {
+				result.Error = err
+				return result
+			}
+			existing = prev
 		}
 	}
 
 	// Insert/update track
 	track := &storage.Track{
 		ContentHash:    hash,
+		FileHash:       identity.FileHash,
+		HashVersion:    HashVersion,
 		Path:           path,
 		FileSize:       info.Size(),
 		FileModifiedAt: info.ModTime(),
+		TagsReadAt:     time.Now(),
 	}
 
-	// TODO: Extract metadata (title, artist, album) from tags
+	if t, err := tags.Read(path); err != nil {
+		s.logger.Warn("failed to read tags", "path", path, "error", err)
+	} else {
+		track.Title = t.Title
+		track.Artist = t.Artist
+		track.Album = t.Album
+		track.Genre = t.Genre
+		track.Label = t.Label
+		track.Year = t.Year
+		track.Comment = t.Comment
+		track.TagBPM = t.BPM
+		track.TagKey = t.Key
+		track.ArtworkHash = t.ArtworkHash
+	}
 
 	trackID, err := s.db.UpsertTrack(track)
 	if err != nil {
@@ -267,10 +343,46 @@ func (s *Scanner) processFile(path string, forceRescan bool) ScanResult {
 	}
 
 	result.TrackID = trackID
+	switch {
+	case replaced:
+		result.Changed = true
+		return result
+	case !forceRescan && existing != nil && existing.Path != path:
+		result.Moved = true
+		return result
+	case !forceRescan && existing != nil:
+		// Only the tags were refreshed; the audio is unchanged.
+		result.Updated = true
+		return result
+	}
 	result.IsNew = true
 	return result
 }
 
+// legacyTrackFor finds a track keyed under HashVersion 1 whose file was moved to
+// path before it could be re-keyed.
+func (s *Scanner) legacyTrackFor(path string) *storage.Track {
+	old, err := legacyHash(path)
+	if err != nil {
+		return nil
+	}
+	t, err := s.db.GetTrackByHash(old)
+	if err != nil || t.HashVersion >= HashVersion || (t.Path != path && fileExists(t.Path)) {
+		return nil
+	}
+	return t
+}
+
+// sameLegacyContent reports whether prev, keyed under HashVersion 1, still
+// describes the file at path. Newer keys are compared by the caller.
+func (s *Scanner) sameLegacyContent(prev *storage.Track, path string) bool {
+	if prev.HashVersion >= HashVersion {
+		return false
+	}
+	old, err := legacyHash(path)
+	return err == nil && old == prev.ContentHash
+}
+
 // EnqueueAnalysis creates analysis jobs for the given track IDs.
 func (s *Scanner) EnqueueAnalysis(trackIDs []int64, priority int) error {
 	for _, trackID := range trackIDs {
@@ -284,22 +396,23 @@ func (s *Scanner) EnqueueAnalysis(trackIDs []int64, priority int) error {
 	return nil
 }
 
-// ComputeHash returns a deterministic, fast hash of the first 64KB.
-func ComputeHash(path string) (string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	h := sha256.New()
-	// Hash first 64KB for speed - content hash is just for identity
-	_, err = io.CopyN(h, file, 64*1024)
-	if err != nil && err != io.EOF {
-		return "", err
+// EnqueueReanalysis creates forced analysis jobs for tracks whose audio changed.
+func (s *Scanner) EnqueueReanalysis(trackIDs []int64, priority int) error {
+	for _, trackID := range trackIDs {
+		_, err := s.db.CreateJob(storage.JobTypeAnalyze, priority, map[string]any{
+			"track_id": trackID,
+			"force":    true,
+		})
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return hex.EncodeToString(h.Sum(nil)), nil
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
 
 // HashCache provides a simple in-memory cache for file hashes.
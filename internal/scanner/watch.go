@@ -0,0 +1,365 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cartomix/cancun/internal/storage"
+)
+
+// WatchConfig tunes the library watcher.
+type WatchConfig struct {
+	Debounce     time.Duration // quiet period before a burst of changes is applied
+	PollInterval time.Duration // directory listing interval where inotify is unavailable
+}
+
+// ErrNotDirectory is returned when a library root is not a directory.
+var ErrNotDirectory = errors.New("not a directory")
+
+// maxPendingChanges forces a flush during long bursts (e.g. copying an album).
+const maxPendingChanges = 1000
+
+// Watcher keeps the tracks table in sync with the persisted library roots. Added,
+// modified and moved files are scanned (moves keep their track via content_hash),
+// deleted files are flagged missing, and new work is enqueued as analyze jobs.
+type Watcher struct {
+	db      *storage.DB
+	scanner *Scanner
+	logger  *slog.Logger
+	cfg     WatchConfig
+
+	notifierFactory func(pollInterval time.Duration) (notifier, string)
+
+	mu       sync.Mutex
+	notifier notifier // nil until Start
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewWatcher creates a watcher. Roots can be managed before Start; they are only
+// watched once it runs.
+func NewWatcher(db *storage.DB, logger *slog.Logger, cfg WatchConfig) *Watcher {
+	if cfg.Debounce <= 0 {
+		cfg.Debounce = time.Second
+	}
+	return &Watcher{
+		db:      db,
+		scanner: NewScanner(db, logger),
+		logger:  logger,
+		cfg:     cfg,
+
+		notifierFactory: newNotifier,
+	}
+}
+
+// Start watches every persisted root and rescans each one in the background to
+// pick up changes made while the engine was not running.
+func (w *Watcher) Start(ctx context.Context) error {
+	roots, err := w.db.ListLibraryRoots()
+	if err != nil {
+		return fmt.Errorf("list library roots: %w", err)
+	}
+
+	w.mu.Lock()
+	if w.notifier != nil {
+		w.mu.Unlock()
+		return errors.New("watcher already started")
+	}
+	n, kind := w.notifierFactory(w.cfg.PollInterval)
+	w.notifier = n
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	w.mu.Unlock()
+
+	w.logger.Info("watching library roots", "roots", len(roots), "notifier", kind)
+
+	w.wg.Add(1)
+	go w.run()
+	for _, root := range roots {
+		w.syncRoot(root)
+	}
+	return nil
+}
+
+// Stop stops watching and waits for in-flight scans to finish.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	n, cancel := w.notifier, w.cancel
+	w.mu.Unlock()
+	if n == nil {
+		return
+	}
+	cancel()
+	n.Close()
+	w.wg.Wait()
+}
+
+// Watching reports whether the watcher is running.
+func (w *Watcher) Watching() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.notifier != nil && w.ctx.Err() == nil
+}
+
+// Roots lists the persisted library roots.
+func (w *Watcher) Roots() ([]*storage.LibraryRoot, error) {
+	return w.db.ListLibraryRoots()
+}
+
+// AddRoot persists a library root and, while running, starts watching and scanning it.
+func (w *Watcher) AddRoot(path string) (*storage.LibraryRoot, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s: %w", abs, ErrNotDirectory)
+	}
+
+	root, err := w.db.AddLibraryRoot(abs)
+	if err != nil {
+		return nil, err
+	}
+	if w.Watching() {
+		w.syncRoot(root)
+	}
+	return root, nil
+}
+
+// RemoveRootByPath removes the root registered for path.
+func (w *Watcher) RemoveRootByPath(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	root, err := w.db.GetLibraryRootByPath(abs)
+	if err != nil {
+		return err
+	}
+	return w.RemoveRoot(root.ID)
+}
+
+// RemoveRoot stops watching a root and forgets it. Its tracks are kept.
+func (w *Watcher) RemoveRoot(id int64) error {
+	root, err := w.db.GetLibraryRoot(id)
+	if err != nil {
+		return err
+	}
+	if err := w.db.RemoveLibraryRoot(id); err != nil {
+		return err
+	}
+
+	// Keep watching anything still covered by another root.
+	roots, err := w.db.ListLibraryRoots()
+	if err != nil {
+		return err
+	}
+	for _, other := range roots {
+		if isUnder(root.Path, other.Path) {
+			return nil
+		}
+	}
+	w.mu.Lock()
+	n := w.notifier
+	w.mu.Unlock()
+	if n != nil {
+		n.Remove(root.Path)
+	}
+	return nil
+}
+
+// syncRoot watches and rescans a root in the background.
+func (w *Watcher) syncRoot(root *storage.LibraryRoot) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		if err := w.syncDir(w.ctx, root.Path); err != nil {
+			if w.ctx.Err() == nil {
+				w.logger.Warn("library root sync failed", "root", root.Path, "error", err)
+			}
+			return
+		}
+		if err := w.db.MarkLibraryRootScanned(root.ID); err != nil {
+			w.logger.Warn("failed to record root scan", "root", root.Path, "error", err)
+		}
+	}()
+}
+
+// run collects change notifications and applies them once things go quiet.
+func (w *Watcher) run() {
+	defer w.wg.Done()
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.cfg.Debounce)
+	timer.Stop()
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		w.apply(pending)
+		pending = make(map[string]bool)
+	}
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case path, ok := <-w.notifier.Events():
+			if !ok {
+				return
+			}
+			if path == "" {
+				w.logger.Warn("file notifications overflowed; rescanning library roots")
+				w.resyncAll()
+				continue
+			}
+			pending[path] = true
+			if len(pending) >= maxPendingChanges {
+				flush()
+			}
+			timer.Reset(w.cfg.Debounce)
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+func (w *Watcher) resyncAll() {
+	roots, err := w.db.ListLibraryRoots()
+	if err != nil {
+		w.logger.Warn("failed to list library roots", "error", err)
+		return
+	}
+	for _, root := range roots {
+		w.syncRoot(root)
+	}
+}
+
+// apply brings the tracks table in line with the current state of each path.
+func (w *Watcher) apply(pending map[string]bool) {
+	paths := make([]string, 0, len(pending))
+	for p := range pending {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var newIDs, changedIDs []int64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			w.notifier.Remove(path)
+			if n, err := w.db.MarkTracksMissing(path); err != nil {
+				w.logger.Warn("failed to mark tracks missing", "path", path, "error", err)
+			} else if n > 0 {
+				w.logger.Info("tracks missing", "path", path, "count", n)
+			}
+		case err != nil:
+			w.logger.Warn("failed to stat changed path", "path", path, "error", err)
+		case info.IsDir():
+			// A directory created or moved in: watch it and scan what it brought along.
+			if err := w.syncDir(w.ctx, path); err != nil && w.ctx.Err() == nil {
+				w.logger.Warn("directory sync failed", "path", path, "error", err)
+			}
+		case SupportedFormats[strings.ToLower(filepath.Ext(path))]:
+			result := w.scanner.processFile(path, false)
+			switch {
+			case result.Error != nil:
+				w.logger.Warn("failed to process changed file", "path", path, "error", result.Error)
+			case result.IsNew:
+				w.logger.Info("track added", "path", path)
+				newIDs = append(newIDs, result.TrackID)
+			case result.Changed:
+				w.logger.Info("track changed", "path", path)
+				changedIDs = append(changedIDs, result.TrackID)
+			case result.Moved:
+				w.logger.Info("track moved", "path", path)
+			}
+		}
+	}
+	w.enqueue(newIDs, changedIDs)
+}
+
+// syncDir watches dir and every directory below it, scans it, and flags tracks
+// recorded under it whose files are gone.
+func (w *Watcher) syncDir(ctx context.Context, dir string) error {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if err := w.notifier.Add(path); err != nil {
+				w.logger.Warn("failed to watch directory", "path", path, "error", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	progress := make(chan ScanProgress)
+	scanErr := make(chan error, 1)
+	go func() {
+		scanErr <- w.scanner.Scan(ctx, []string{dir}, false, progress)
+	}()
+	var newIDs, changedIDs []int64
+	for p := range progress {
+		switch {
+		case p.IsNew:
+			newIDs = append(newIDs, p.TrackID)
+		case p.Changed:
+			changedIDs = append(changedIDs, p.TrackID)
+		}
+	}
+	if err := <-scanErr; err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	w.enqueue(newIDs, changedIDs)
+
+	tracks, err := w.db.TracksUnder(dir)
+	if err != nil {
+		return err
+	}
+	for _, t := range tracks {
+		if t.MissingAt.IsZero() && !fileExists(t.Path) {
+			if _, err := w.db.MarkTracksMissing(t.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) enqueue(newIDs, changedIDs []int64) {
+	if len(newIDs) > 0 {
+		if err := w.scanner.EnqueueAnalysis(newIDs, 0); err != nil {
+			w.logger.Warn("failed to enqueue analysis jobs", "error", err)
+		}
+	}
+	if len(changedIDs) > 0 {
+		if err := w.scanner.EnqueueReanalysis(changedIDs, 0); err != nil {
+			w.logger.Warn("failed to enqueue re-analysis jobs", "error", err)
+		}
+	}
+}
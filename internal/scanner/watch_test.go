@@ -0,0 +1,206 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cartomix/cancun/internal/storage"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+func openTestDB(t *testing.T) *storage.DB {
+	t.Helper()
+	db, err := storage.Open(t.TempDir(), testLogger)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newTestWatcher(t *testing.T, cfg WatchConfig) (*Watcher, *storage.DB) {
+	t.Helper()
+	db := openTestDB(t)
+	if cfg.Debounce == 0 {
+		cfg.Debounce = 50 * time.Millisecond
+	}
+	return NewWatcher(db, testLogger, cfg), db
+}
+
+// writeAudio writes a tiny MPEG-looking file whose content hash depends on seed.
+func writeAudio(t *testing.T, path string, seed byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := append([]byte{0xFF, 0xFB, 0x90, seed}, bytes.Repeat([]byte{seed}, 4096)...)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// eventually polls cond until it holds or the deadline passes.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func trackAt(db *storage.DB, path string) *storage.Track {
+	t, err := db.GetTrackByPath(path)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+func pendingJobs(t *testing.T, db *storage.DB) int {
+	t.Helper()
+	n, err := db.GetPendingJobCount(storage.JobTypeAnalyze)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func testWatcherFollowsFiles(t *testing.T, w *Watcher, db *storage.DB) {
+	lib := t.TempDir()
+	first := filepath.Join(lib, "a", "first.mp3")
+	writeAudio(t, first, 1)
+
+	if err := w.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer w.Stop()
+
+	root, err := w.AddRoot(lib)
+	if err != nil {
+		t.Fatalf("add root: %v", err)
+	}
+	eventually(t, "initial scan", func() bool { return trackAt(db, first) != nil })
+	eventually(t, "root marked scanned", func() bool {
+		r, err := db.GetLibraryRoot(root.ID)
+		return err == nil && !r.LastScannedAt.IsZero()
+	})
+	original := trackAt(db, first)
+
+	// Added file, including inside a new directory.
+	second := filepath.Join(lib, "b", "second.flac")
+	writeAudio(t, second, 2)
+	eventually(t, "new file in new dir", func() bool { return trackAt(db, second) != nil })
+
+	// Move keeps the same track row.
+	moved := filepath.Join(lib, "b", "renamed.mp3")
+	if err := os.Rename(first, moved); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "move detected", func() bool {
+		tr := trackAt(db, moved)
+		return tr != nil && tr.ID == original.ID && tr.MissingAt.IsZero()
+	})
+
+	// Rewritten in place: same track, new content, forced re-analysis queued.
+	jobsBefore := pendingJobs(t, db)
+	writeAudio(t, moved, 3)
+	eventually(t, "rewrite detected", func() bool {
+		tr := trackAt(db, moved)
+		return tr != nil && tr.ID == original.ID && tr.ContentHash != original.ContentHash
+	})
+	eventually(t, "re-analysis queued", func() bool { return pendingJobs(t, db) > jobsBefore })
+
+	// Deleted files are flagged missing, not dropped.
+	if err := os.Remove(second); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "delete detected", func() bool {
+		tr := trackAt(db, second)
+		return tr != nil && !tr.MissingAt.IsZero()
+	})
+
+	// Removing the root stops watching it.
+	if err := w.RemoveRoot(root.ID); err != nil {
+		t.Fatalf("remove root: %v", err)
+	}
+	roots, _ := w.Roots()
+	if len(roots) != 0 {
+		t.Fatalf("roots after remove = %d", len(roots))
+	}
+	late := filepath.Join(lib, "late.mp3")
+	writeAudio(t, late, 4)
+	time.Sleep(300 * time.Millisecond)
+	if trackAt(db, late) != nil {
+		t.Fatal("file added after root removal was picked up")
+	}
+}
+
+func TestWatcherFollowsFiles(t *testing.T) {
+	w, db := newTestWatcher(t, WatchConfig{})
+	testWatcherFollowsFiles(t, w, db)
+}
+
+func TestWatcherPollingFallback(t *testing.T) {
+	w, db := newTestWatcher(t, WatchConfig{PollInterval: 50 * time.Millisecond})
+	// Force the portable notifier regardless of platform.
+	w.notifierFactory = func(interval time.Duration) (notifier, string) { return newPoller(interval), "poll" }
+	testWatcherFollowsFiles(t, w, db)
+}
+
+func TestWatcherCatchesUpOnStart(t *testing.T) {
+	w, db := newTestWatcher(t, WatchConfig{})
+	lib := t.TempDir()
+	kept := filepath.Join(lib, "kept.mp3")
+	gone := filepath.Join(lib, "gone.mp3")
+	writeAudio(t, kept, 1)
+	writeAudio(t, gone, 2)
+
+	// Persisted while the engine was "down", then files change before Start.
+	root, err := w.AddRoot(lib)
+	if err != nil {
+		t.Fatalf("add root: %v", err)
+	}
+	if w.Watching() {
+		t.Fatal("watching before Start")
+	}
+	sc := NewScanner(db, w.logger)
+	for _, p := range []string{kept, gone} {
+		if r := sc.processFile(p, false); r.Error != nil {
+			t.Fatal(r.Error)
+		}
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+	added := filepath.Join(lib, "added.mp3")
+	writeAudio(t, added, 3)
+
+	if err := w.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer w.Stop()
+
+	eventually(t, "catch-up scan", func() bool {
+		r, err := db.GetLibraryRoot(root.ID)
+		return err == nil && !r.LastScannedAt.IsZero()
+	})
+	if trackAt(db, added) == nil {
+		t.Fatal("file added while stopped was not picked up")
+	}
+	if tr := trackAt(db, gone); tr == nil || tr.MissingAt.IsZero() {
+		t.Fatal("file deleted while stopped was not flagged missing")
+	}
+	if tr := trackAt(db, kept); tr == nil || !tr.MissingAt.IsZero() {
+		t.Fatal("present file flagged missing")
+	}
+}
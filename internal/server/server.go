@@ -6,10 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	analyzerpb "github.com/cartomix/cancun/gen/go/analyzer"
@@ -17,7 +20,9 @@ import (
 	eng "github.com/cartomix/cancun/gen/go/engine"
 	analyzeriface "github.com/cartomix/cancun/internal/analyzer"
 	"github.com/cartomix/cancun/internal/config"
+	"github.com/cartomix/cancun/internal/duplicates"
 	"github.com/cartomix/cancun/internal/exporter"
+	"github.com/cartomix/cancun/internal/live"
 	"github.com/cartomix/cancun/internal/planner"
 	"github.com/cartomix/cancun/internal/scanner"
 	similaritypkg "github.com/cartomix/cancun/internal/similarity"
@@ -36,6 +41,8 @@ type EngineServer struct {
 	db       *storage.DB
 	analyzer analyzeriface.Analyzer
 	scanner  *scanner.Scanner
+	watcher  *scanner.Watcher
+	live     *live.Manager
 }
 
 func NewEngineServer(cfg *config.Config, logger *slog.Logger, db *storage.DB, analyzer analyzeriface.Analyzer) *EngineServer {
@@ -48,6 +55,16 @@ func NewEngineServer(cfg *config.Config, logger *slog.Logger, db *storage.DB, an
 	}
 }
 
+// SetWatcher enables the library root RPCs.
+func (s *EngineServer) SetWatcher(w *scanner.Watcher) {
+	s.watcher = w
+}
+
+// SetLiveSessions enables the live set RPCs.
+func (s *EngineServer) SetLiveSessions(m *live.Manager) {
+	s.live = m
+}
+
 func (s *EngineServer) ScanLibrary(req *eng.ScanRequest, stream grpc.ServerStreamingServer[eng.ScanProgress]) error {
 	if len(req.GetRoots()) == 0 {
 		return status.Error(codes.InvalidArgument, "at least one root is required")
@@ -56,7 +73,7 @@ func (s *EngineServer) ScanLibrary(req *eng.ScanRequest, stream grpc.ServerStrea
 	ctx := stream.Context()
 	progress := make(chan scanner.ScanProgress)
 	var scanErr error
-	var newTrackIDs []int64
+	var newTrackIDs, changedTrackIDs []int64
 
 	go func() {
 		scanErr = s.scanner.Scan(ctx, req.GetRoots(), req.GetForceRescan(), progress)
@@ -66,6 +83,9 @@ func (s *EngineServer) ScanLibrary(req *eng.ScanRequest, stream grpc.ServerStrea
 		if p.IsNew {
 			newTrackIDs = append(newTrackIDs, p.TrackID)
 		}
+		if p.Changed {
+			changedTrackIDs = append(changedTrackIDs, p.TrackID)
+		}
 		if err := stream.Send(&eng.ScanProgress{
 			Path:           p.Path,
 			Status:         p.Status,
@@ -80,6 +100,9 @@ func (s *EngineServer) ScanLibrary(req *eng.ScanRequest, stream grpc.ServerStrea
 			SkippedCached:  p.SkippedCached,
 			BytesProcessed: p.BytesProcessed,
 			BytesTotal:     p.BytesTotal,
+			ContentHash:    p.ContentHash,
+			CollidesWith:   p.CollidesWith,
+			Collisions:     p.Collisions,
 		}); err != nil {
 			return err
 		}
@@ -94,16 +117,44 @@ func (s *EngineServer) ScanLibrary(req *eng.ScanRequest, stream grpc.ServerStrea
 			s.logger.Warn("failed to enqueue analysis jobs", "error", err)
 		}
 	}
+	if len(changedTrackIDs) > 0 {
+		if err := s.scanner.EnqueueReanalysis(changedTrackIDs, 0); err != nil {
+			s.logger.Warn("failed to enqueue re-analysis jobs", "error", err)
+		}
+	}
 
 	return nil
 }
 
+// maxAnalyzeParallel caps fan-out so a single request cannot flood the analyzer worker.
+const maxAnalyzeParallel = 16
+
+// stageBuffer bounds the per-track queue of worker stage events. Events for tracks
+// the stream is not yet waiting on are dropped once it fills; the final timings are
+// carried on the outcome regardless.
+const stageBuffer = 32
+
+// trackOutcome is the result of analyzing one track inside AnalyzeTracks.
+type trackOutcome struct {
+	skipped  bool
+	analysis *common.TrackAnalysis
+	timings  []*eng.StageTiming
+	err      error // analyzer failure, reported per track
+	fatal    error // persistence failure, aborts the stream
+	duration time.Duration
+}
+
+// trackSlot carries live stage events and the final outcome for one track.
+type trackSlot struct {
+	stages  chan *analyzerpb.StageEvent
+	outcome chan trackOutcome
+}
+
 func (s *EngineServer) AnalyzeTracks(req *eng.AnalyzeRequest, stream grpc.ServerStreamingServer[eng.AnalyzeProgress]) error {
 	if len(req.GetPaths()) == 0 && len(req.GetTrackIds()) == 0 {
 		return status.Error(codes.InvalidArgument, "paths or track_ids are required")
 	}
 
-	ctx := stream.Context()
 	version := req.GetAnalysisVersion()
 	if version == 0 {
 		version = 1
@@ -114,159 +165,183 @@ func (s *EngineServer) AnalyzeTracks(req *eng.AnalyzeRequest, stream grpc.Server
 		return err
 	}
 
-	totalTracks := int32(len(tracks))
-	startTime := time.Now()
-	var completedTimings []*eng.StageTiming
-
-	for i, track := range tracks {
-		trackIndex := int32(i + 1)
-		trackStartTime := time.Now()
+	parallel := int(req.GetMaxParallel())
+	if parallel <= 0 {
+		parallel = 1
+	}
+	if parallel > maxAnalyzeParallel {
+		parallel = maxAnalyzeParallel
+	}
 
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
+	ctx, cancel := context.WithCancel(stream.Context())
 
-		// Calculate overall progress and ETA
-		overallPercent := float32(i) / float32(totalTracks) * 100
-		elapsedMs := time.Since(startTime).Milliseconds()
-		var etaMs int64
-		if i > 0 {
-			avgTimePerTrack := float64(elapsedMs) / float64(i)
-			remainingTracks := int(totalTracks) - i
-			etaMs = int64(avgTimePerTrack * float64(remainingTracks))
+	// Fan out up to `parallel` analyses; each track gets its own buffered slot
+	// so the stream below can emit progress strictly in track order.
+	slots := make([]trackSlot, len(tracks))
+	for i := range slots {
+		slots[i] = trackSlot{
+			stages:  make(chan *analyzerpb.StageEvent, stageBuffer),
+			outcome: make(chan trackOutcome, 1),
 		}
+	}
 
-		currentFile := filepath.Base(track.Path)
+	var completed atomic.Int32
+	var wg sync.WaitGroup
+	// On early return (client gone, send failure) stop launching work and let
+	// in-flight analyses observe the cancellation before we leave.
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
 
-		// Skip if already analyzed and not forced
-		if !req.GetForce() {
-			if rec, err := s.db.LatestAnalysisRecord(track.ID); err == nil && rec.Status == storage.AnalysisStatusComplete && rec.Version >= version {
-				stream.Send(&eng.AnalyzeProgress{
-					Id:             &common.TrackId{ContentHash: track.ContentHash, Path: track.Path},
-					Stage:          "done",
-					Status:         "done",
-					Percent:        100,
-					CurrentFile:    currentFile,
-					TrackIndex:     trackIndex,
-					TotalTracks:    totalTracks,
-					OverallPercent: overallPercent + (100.0 / float32(totalTracks)),
-					ElapsedMs:      elapsedMs,
-					EtaMs:          etaMs,
-					StageMessage:   "Already analyzed (cached)",
-				})
-				continue
+	sem := make(chan struct{}, parallel)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, track := range tracks {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
 			}
+			wg.Add(1)
+			go func(i int, track *storage.Track) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				out := s.analyzeOne(ctx, track, version, req.GetForce(), func(ev *analyzerpb.StageEvent) {
+					select {
+					case slots[i].stages <- ev:
+					default:
+					}
+				})
+				completed.Add(1)
+				slots[i].outcome <- out
+			}(i, track)
 		}
+	}()
 
-		// Analysis stages with timing
-		stages := []struct {
-			name    string
-			percent float32
-			message string
-		}{
-			{"decode", 10, "Decoding audio file..."},
-			{"beatgrid", 25, "Detecting beats and tempo..."},
-			{"key", 40, "Analyzing musical key..."},
-			{"energy", 50, "Calculating energy levels..."},
-			{"loudness", 60, "Measuring loudness (EBU R128)..."},
-			{"embeddings", 75, "Generating ML embeddings..."},
-			{"sections", 85, "Detecting track sections..."},
-			{"cues", 95, "Generating cue points..."},
-		}
+	totalTracks := int32(len(tracks))
+	startTime := time.Now()
 
-		// Send initial decode progress
-		if err := stream.Send(&eng.AnalyzeProgress{
-			Id:             &common.TrackId{ContentHash: track.ContentHash, Path: track.Path},
-			Stage:          "decode",
-			Status:         "running",
-			Percent:        10,
-			CurrentFile:    currentFile,
-			TrackIndex:     trackIndex,
-			TotalTracks:    totalTracks,
-			OverallPercent: overallPercent,
-			ElapsedMs:      time.Since(startTime).Milliseconds(),
-			EtaMs:          etaMs,
-			StageMessage:   "Decoding audio file...",
-		}); err != nil {
-			return err
+	// etaMs extrapolates from tracks finished so far; with N workers the wall-clock
+	// average per track already reflects the parallel speed-up.
+	etaMs := func() int64 {
+		done := completed.Load()
+		if done == 0 {
+			return 0
 		}
+		elapsed := float64(time.Since(startTime).Milliseconds())
+		return int64(elapsed / float64(done) * float64(totalTracks-done))
+	}
 
-		job := &analyzerpb.AnalyzeJob{
-			Id: &common.TrackId{
-				ContentHash: track.ContentHash,
-				Path:        track.Path,
-			},
-			Path: track.Path,
-			Decode: &analyzerpb.DecodeParams{
-				TargetSampleRate: 48000,
-				Mono:             false,
-			},
-			Beatgrid: &analyzerpb.BeatgridParams{
-				DynamicAllowed: true,
-				TempoFloor:     60,
-				TempoCeil:      180,
-			},
-			Cues: &analyzerpb.CueParams{
-				MaxCues:        8,
-				SnapToDownbeat: true,
-			},
-			AnalysisVersion: version,
-		}
-
-		res, err := s.analyzer.AnalyzeTrack(ctx, job)
-		if err != nil {
-			_ = s.db.MarkAnalysisFailure(track.ID, version, err.Error())
-			stream.Send(&eng.AnalyzeProgress{
-				Id:             job.Id,
+	for i, track := range tracks {
+		trackIndex := int32(i + 1)
+		overallPercent := float32(i) / float32(totalTracks) * 100
+		newOverallPercent := float32(i+1) / float32(totalTracks) * 100
+		id := &common.TrackId{ContentHash: track.ContentHash, Path: track.Path}
+		currentFile := filepath.Base(track.Path)
+
+		var out trackOutcome
+		select {
+		case out = <-slots[i].outcome:
+		default:
+			// Still in flight: relay the worker's stage events until the outcome arrives.
+			if err := stream.Send(&eng.AnalyzeProgress{
+				Id:             id,
 				Stage:          "analyze",
-				Status:         "error",
-				Error:          err.Error(),
-				Percent:        100,
+				Status:         "running",
 				CurrentFile:    currentFile,
 				TrackIndex:     trackIndex,
 				TotalTracks:    totalTracks,
 				OverallPercent: overallPercent,
 				ElapsedMs:      time.Since(startTime).Milliseconds(),
-				StageMessage:   "Analysis failed: " + err.Error(),
-			})
-			continue
-		}
+				EtaMs:          etaMs(),
+				StageMessage:   "Waiting for analyzer...",
+			}); err != nil {
+				return err
+			}
 
-		rec, err := storage.AnalysisRecordFromProto(track.ID, version, res.GetAnalysis())
-		if err != nil {
-			return status.Errorf(codes.Internal, "persist analysis marshal failed: %v", err)
+			var timings []*eng.StageTiming
+		wait:
+			for {
+				select {
+				case ev := <-slots[i].stages:
+					if ev.GetStatus() == "completed" {
+						timings = append(timings, &eng.StageTiming{
+							Stage:      ev.GetStage(),
+							DurationMs: ev.GetDurationMs(),
+							Completed:  true,
+						})
+					}
+					if err := stream.Send(&eng.AnalyzeProgress{
+						Id:             id,
+						Stage:          ev.GetStage(),
+						Status:         "running",
+						Percent:        ev.GetPercent(),
+						CurrentFile:    currentFile,
+						TrackIndex:     trackIndex,
+						TotalTracks:    totalTracks,
+						OverallPercent: overallPercent + ev.GetPercent()/float32(totalTracks),
+						ElapsedMs:      time.Since(startTime).Milliseconds(),
+						EtaMs:          etaMs(),
+						StageTimings:   timings,
+						StageMessage:   ev.GetMessage(),
+					}); err != nil {
+						return err
+					}
+				case out = <-slots[i].outcome:
+					break wait
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
 		}
-		if err := s.db.UpsertAnalysis(rec); err != nil {
-			return status.Errorf(codes.Internal, "persist analysis failed: %v", err)
+
+		if out.fatal != nil {
+			return out.fatal
 		}
 
-		// Compute stage timings for this track
-		trackDuration := time.Since(trackStartTime)
-		stageTimings := make([]*eng.StageTiming, len(stages))
-		stageMs := trackDuration.Milliseconds() / int64(len(stages))
-		for j, stage := range stages {
-			stageTimings[j] = &eng.StageTiming{
-				Stage:      stage.name,
-				DurationMs: stageMs,
-				Completed:  true,
+		if out.skipped {
+			if err := stream.Send(&eng.AnalyzeProgress{
+				Id:             id,
+				Stage:          "done",
+				Status:         "done",
+				Percent:        100,
+				CurrentFile:    currentFile,
+				TrackIndex:     trackIndex,
+				TotalTracks:    totalTracks,
+				OverallPercent: newOverallPercent,
+				ElapsedMs:      time.Since(startTime).Milliseconds(),
+				EtaMs:          etaMs(),
+				StageMessage:   "Already analyzed (cached)",
+			}); err != nil {
+				return err
 			}
+			continue
 		}
 
-		// Extract track metadata from track record and analysis
-		title := track.Title
-		artist := track.Artist
-		var durationSeconds float32
-		if analysis := res.GetAnalysis(); analysis != nil {
-			durationSeconds = float32(analysis.GetDurationSeconds())
+		if out.err != nil {
+			if err := stream.Send(&eng.AnalyzeProgress{
+				Id:             id,
+				Stage:          "analyze",
+				Status:         "error",
+				Error:          out.err.Error(),
+				Percent:        100,
+				CurrentFile:    currentFile,
+				TrackIndex:     trackIndex,
+				TotalTracks:    totalTracks,
+				OverallPercent: newOverallPercent,
+				ElapsedMs:      time.Since(startTime).Milliseconds(),
+				EtaMs:          etaMs(),
+				StageMessage:   "Analysis failed: " + out.err.Error(),
+			}); err != nil {
+				return err
+			}
+			continue
 		}
 
-		// Calculate updated overall progress
-		newOverallPercent := float32(i+1) / float32(totalTracks) * 100
-
 		if err := stream.Send(&eng.AnalyzeProgress{
-			Id:              job.Id,
+			Id:              id,
 			Stage:           "done",
 			Status:          "done",
 			Percent:         100,
@@ -275,25 +350,77 @@ func (s *EngineServer) AnalyzeTracks(req *eng.AnalyzeRequest, stream grpc.Server
 			TotalTracks:     totalTracks,
 			OverallPercent:  newOverallPercent,
 			ElapsedMs:       time.Since(startTime).Milliseconds(),
-			EtaMs:           etaMs,
-			StageTimings:    stageTimings,
+			EtaMs:           etaMs(),
+			StageTimings:    out.timings,
 			StageMessage:    "Analysis complete",
-			Title:           title,
-			Artist:          artist,
-			DurationSeconds: durationSeconds,
+			Title:           track.Title,
+			Artist:          track.Artist,
+			DurationSeconds: float32(out.analysis.GetDurationSeconds()),
 		}); err != nil {
 			return err
 		}
+	}
 
-		// Add to completed timings for summary
-		completedTimings = append(completedTimings, &eng.StageTiming{
-			Stage:      fmt.Sprintf("track_%d", trackIndex),
-			DurationMs: trackDuration.Milliseconds(),
-			Completed:  true,
+	return nil
+}
+
+// analyzeOne runs (or skips) analysis for a single track and persists the result.
+// Stage events are forwarded to onStage when the backend can stream them; otherwise
+// the whole run is reported as a single "analyze" timing.
+func (s *EngineServer) analyzeOne(ctx context.Context, track *storage.Track, version int32, force bool, onStage analyzeriface.StageFunc) trackOutcome {
+	start := time.Now()
+
+	if !force {
+		if rec, err := s.db.LatestAnalysisRecord(track.ID); err == nil && rec.Status == storage.AnalysisStatusComplete && rec.Version >= version {
+			return trackOutcome{skipped: true}
+		}
+	}
+
+	job := analyzeriface.NewJob(&common.TrackId{ContentHash: track.ContentHash, Path: track.Path}, track.Path, version)
+
+	var timings []*eng.StageTiming
+	var res *analyzerpb.AnalyzeResult
+	var err error
+	if sa, ok := s.analyzer.(analyzeriface.StageAnalyzer); ok {
+		res, err = sa.AnalyzeTrackStages(ctx, job, func(ev *analyzerpb.StageEvent) {
+			if ev.GetStatus() == "completed" {
+				timings = append(timings, &eng.StageTiming{
+					Stage:      ev.GetStage(),
+					DurationMs: ev.GetDurationMs(),
+					Completed:  true,
+				})
+			}
+			onStage(ev)
 		})
+	} else {
+		res, err = s.analyzer.AnalyzeTrack(ctx, job)
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			_ = s.db.MarkAnalysisFailure(track.ID, version, err.Error())
+		}
+		return trackOutcome{err: err, duration: time.Since(start)}
+	}
+	if len(timings) == 0 {
+		timings = []*eng.StageTiming{{
+			Stage:      "analyze",
+			DurationMs: time.Since(start).Milliseconds(),
+			Completed:  true,
+		}}
 	}
 
-	return nil
+	rec, err := storage.AnalysisRecordFromProto(track.ID, version, res.GetAnalysis())
+	if err != nil {
+		return trackOutcome{fatal: status.Errorf(codes.Internal, "persist analysis marshal failed: %v", err)}
+	}
+	if err := s.db.UpsertAnalysis(rec); err != nil {
+		return trackOutcome{fatal: status.Errorf(codes.Internal, "persist analysis failed: %v", err)}
+	}
+	if err := s.db.SaveOpenL3Windows(track.ID, version, storage.OpenL3WindowsFromProto(res.GetOpenl3Windows())); err != nil {
+		return trackOutcome{fatal: status.Errorf(codes.Internal, "persist window embeddings failed: %v", err)}
+	}
+
+	return trackOutcome{analysis: res.GetAnalysis(), timings: timings, duration: time.Since(start)}
 }
 
 func (s *EngineServer) ListTracks(req *eng.ListTracksRequest, stream grpc.ServerStreamingServer[common.TrackSummary]) error {
@@ -335,12 +462,38 @@ func (s *EngineServer) GetTrack(ctx context.Context, req *eng.GetTrackRequest) (
 	return analysis, nil
 }
 
+func (s *EngineServer) SuggestTransition(ctx context.Context, req *eng.SuggestTransitionRequest) (*eng.SuggestTransitionResponse, error) {
+	if req.GetFrom() == nil || req.GetTo() == nil {
+		return nil, status.Error(codes.InvalidArgument, "from and to are required")
+	}
+	from, err := s.GetTrack(ctx, &eng.GetTrackRequest{Id: req.GetFrom()})
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetTrack(ctx, &eng.GetTrackRequest{Id: req.GetTo()})
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := planner.SuggestTransition(from, to, int(req.GetLimit()), planner.Options{
+		PitchRange:  req.GetPitchRange() / 100,
+		MasterTempo: req.GetMasterTempo(),
+	})
+	return &eng.SuggestTransitionResponse{Suggestions: suggestions}, nil
+}
+
 func (s *EngineServer) ProposeSet(ctx context.Context, req *eng.SetPlanRequest) (*eng.SetPlanResponse, error) {
 	if len(req.GetTrackIds()) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "track_ids are required")
 	}
 
+	mustPlay := toHashSet(req.GetMustPlay())
+	ban := toHashSet(req.GetBan())
+
 	analyses := []*common.TrackAnalysis{}
+	var tracks []*storage.Track
+	mix := make(map[string]planner.MixEmbeddings)
+	artists := make(map[string]string)
 	for _, id := range req.GetTrackIds() {
 		track, err := s.db.ResolveTrack(id)
 		if err != nil {
@@ -353,30 +506,150 @@ func (s *EngineServer) ProposeSet(ctx context.Context, req *eng.SetPlanRequest)
 		if err != nil {
 			return nil, status.Errorf(codes.FailedPrecondition, "missing analysis for %s", track.Path)
 		}
+		windows, err := s.db.GetOpenL3Windows(track.ID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "openl3 window lookup failed: %v", err)
+		}
+		if len(windows) > 0 {
+			mix[track.ContentHash] = planner.MixEmbeddingsFromWindows(windows, analysis.GetDurationSeconds())
+		}
+		artists[track.ContentHash] = track.Artist
 		analyses = append(analyses, analysis)
+		tracks = append(tracks, track)
+	}
+
+	var collapsed []*common.TrackId
+	if req.GetCollapseDuplicates() {
+		groups, err := s.db.FindDuplicates(duplicates.Options{})
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "duplicate detection failed: %v", err)
+		}
+		// Keep the copies the constraints name, like must-play copies.
+		keep := maps.Clone(mustPlay)
+		for _, h := range constrainedHashes(req) {
+			keep[h] = true
+		}
+		analyses, collapsed = collapsePlanDuplicates(duplicates.NewIndex(groups), tracks, analyses, keep, ban)
 	}
 
 	opts := planner.Options{
-		Mode:           req.GetMode(),
-		AllowKeyJumps:  req.GetAllowKeyJumps(),
-		MaxBpmStep:     req.GetMaxBpmStep(),
-		MustPlayHashes: toHashSet(req.GetMustPlay()),
-		BanHashes:      toHashSet(req.GetBan()),
+		Mode:              req.GetMode(),
+		AllowKeyJumps:     req.GetAllowKeyJumps(),
+		MaxBpmStep:        req.GetMaxBpmStep(),
+		MustPlayHashes:    mustPlay,
+		BanHashes:         ban,
+		TimeBudget:        time.Duration(req.GetTimeBudgetMs()) * time.Millisecond,
+		EnergyCurve:       req.GetEnergyCurve(),
+		TargetLength:      time.Duration(req.GetTargetMinutes() * float64(time.Minute)),
+		LengthTolerance:   time.Duration(req.GetToleranceMinutes() * float64(time.Minute)),
+		PlayFraction:      req.GetPlayFraction(),
+		PitchRange:        req.GetPitchRange() / 100,
+		MasterTempo:       req.GetMasterTempo(),
+		VibeWeight:        req.GetVibeWeight(),
+		MixEmbeddings:     mix,
+		Opener:            req.GetOpener().GetContentHash(),
+		Closer:            req.GetCloser().GetContentHash(),
+		Artists:           artists,
+		Alternatives:      int(req.GetAlternatives()),
+		DifferentOpeners:  req.GetDifferentOpeners(),
+		MinDifferentEdges: int(req.GetMinDifferentEdges()),
+	}
+	for _, chain := range req.GetLockedChains() {
+		opts.LockedChains = append(opts.LockedChains, toHashes(chain.GetTracks()))
+	}
+	for _, p := range req.GetPrecedences() {
+		opts.Precedences = append(opts.Precedences, planner.Precedence{
+			Before: p.GetBefore().GetContentHash(),
+			After:  p.GetAfter().GetContentHash(),
+		})
+	}
+	for _, a := range req.GetArtistSpacing() {
+		opts.ArtistSpacing = append(opts.ArtistSpacing, planner.ArtistSpacing{
+			ArtistA:   a.GetArtistA(),
+			ArtistB:   a.GetArtistB(),
+			MinTracks: int(a.GetMinTracks()),
+		})
+	}
+	if len(req.GetKeyWeights()) > 0 {
+		weights, err := planner.DefaultKeyWeights.Override(req.GetKeyWeights())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		opts.KeyWeights = weights
 	}
 
-	order, explanations, err := planner.Plan(analyses, opts)
+	result, err := planner.Optimize(analyses, opts)
+	if errors.Is(err, planner.ErrInvalidCurve) || errors.Is(err, planner.ErrSetLength) || errors.Is(err, planner.ErrConstraints) {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "set planning failed: %v", err)
 	}
 
 	return &eng.SetPlanResponse{
-		Order:        order,
-		Explanations: explanations,
+		Order:            result.Order,
+		Explanations:     result.Explanations,
+		Collapsed:        collapsed,
+		TotalScore:       result.TotalScore,
+		WeakestEdges:     result.WeakestEdges,
+		EnergySlots:      result.EnergySlots,
+		EnergyError:      float32(result.EnergyError),
+		EstimatedSeconds: result.EstimatedSeconds,
+		Alternatives:     result.Alternatives,
 	}, nil
 }
 
+// collapsePlanDuplicates keeps one copy of each duplicated track in a set plan
+// request: a must-play copy if there is one, otherwise the best quality copy that
+// isn't banned. tracks and analyses are parallel slices.
+func collapsePlanDuplicates(ix *duplicates.Index, tracks []*storage.Track, analyses []*common.TrackAnalysis, mustPlay, ban map[string]bool) ([]*common.TrackAnalysis, []*common.TrackId) {
+	ids := make([]int64, len(tracks))
+	prefer, avoid := make(map[int64]bool), make(map[int64]bool)
+	for i, t := range tracks {
+		ids[i] = t.ID
+		prefer[t.ID] = mustPlay[t.ContentHash]
+		avoid[t.ID] = ban[t.ContentHash]
+	}
+	_, dropped := ix.Collapse(ids, prefer, avoid)
+	if len(dropped) == 0 {
+		return analyses, nil
+	}
+
+	drop := make(map[int64]bool, len(dropped))
+	for _, id := range dropped {
+		drop[id] = true
+	}
+	kept := make([]*common.TrackAnalysis, 0, len(analyses)-len(dropped))
+	var collapsed []*common.TrackId
+	for i, t := range tracks {
+		if drop[t.ID] {
+			collapsed = append(collapsed, &common.TrackId{ContentHash: t.ContentHash})
+			continue
+		}
+		kept = append(kept, analyses[i])
+	}
+	return kept, collapsed
+}
+
 func (s *EngineServer) ExportSet(ctx context.Context, req *eng.ExportRequest) (*eng.ExportResponse, error) {
-	if len(req.GetTrackIds()) == 0 {
+	ids := req.GetTrackIds()
+	playlistName := req.GetPlaylistName()
+	if req.GetSetId() != 0 {
+		if len(ids) > 0 {
+			return nil, status.Error(codes.InvalidArgument, "set_id and track_ids are exclusive")
+		}
+		saved, err := s.db.GetSavedSet(req.GetSetId(), int(req.GetSetVersion()))
+		if err != nil {
+			return nil, savedSetStatus(err)
+		}
+		for _, hash := range saved.Tracks {
+			ids = append(ids, &common.TrackId{ContentHash: hash})
+		}
+		if playlistName == "" {
+			playlistName = saved.Name
+		}
+	}
+	if len(ids) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "track_ids are required")
 	}
 
@@ -386,7 +659,7 @@ func (s *EngineServer) ExportSet(ctx context.Context, req *eng.ExportRequest) (*
 	}
 
 	tracks := []exporter.TrackExport{}
-	for _, id := range req.GetTrackIds() {
+	for _, id := range ids {
 		track, err := s.db.ResolveTrack(id)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
@@ -401,10 +674,16 @@ func (s *EngineServer) ExportSet(ctx context.Context, req *eng.ExportRequest) (*
 		tracks = append(tracks, exporter.TrackExport{
 			Path:     track.Path,
 			Analysis: analysis,
+			Title:    track.Title,
+			Artist:   track.Artist,
+			Album:    track.Album,
+			Genre:    track.Genre,
+			Label:    track.Label,
+			Year:     track.Year,
+			Comment:  track.Comment,
 		})
 	}
 
-	playlistName := req.GetPlaylistName()
 	if playlistName == "" {
 		playlistName = "set"
 	}
@@ -422,31 +701,191 @@ func (s *EngineServer) ExportSet(ctx context.Context, req *eng.ExportRequest) (*
 	}, nil
 }
 
+// ============================================================
+// Library Roots
+// ============================================================
+
+func (s *EngineServer) ListLibraryRoots(ctx context.Context, _ *emptypb.Empty) (*eng.ListLibraryRootsResponse, error) {
+	if s.watcher == nil {
+		return nil, status.Error(codes.Unavailable, "library watcher not configured")
+	}
+
+	roots, err := s.watcher.Roots()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list library roots: %v", err)
+	}
+
+	resp := &eng.ListLibraryRootsResponse{Roots: make([]*eng.LibraryRoot, 0, len(roots))}
+	for _, root := range roots {
+		resp.Roots = append(resp.Roots, s.libraryRootToProto(root))
+	}
+	return resp, nil
+}
+
+func (s *EngineServer) AddLibraryRoot(ctx context.Context, req *eng.AddLibraryRootRequest) (*eng.LibraryRoot, error) {
+	if s.watcher == nil {
+		return nil, status.Error(codes.Unavailable, "library watcher not configured")
+	}
+	if req.GetPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "path is required")
+	}
+
+	root, err := s.watcher.AddRoot(req.GetPath())
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, status.Errorf(codes.NotFound, "path not found: %s", req.GetPath())
+	case errors.Is(err, scanner.ErrNotDirectory):
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "failed to add library root: %v", err)
+	}
+	return s.libraryRootToProto(root), nil
+}
+
+func (s *EngineServer) RemoveLibraryRoot(ctx context.Context, req *eng.RemoveLibraryRootRequest) (*emptypb.Empty, error) {
+	if s.watcher == nil {
+		return nil, status.Error(codes.Unavailable, "library watcher not configured")
+	}
+
+	var err error
+	switch {
+	case req.GetId() != 0:
+		err = s.watcher.RemoveRoot(req.GetId())
+	case req.GetPath() != "":
+		err = s.watcher.RemoveRootByPath(req.GetPath())
+	default:
+		return nil, status.Error(codes.InvalidArgument, "id or path is required")
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "library root not found")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to remove library root: %v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *EngineServer) libraryRootToProto(root *storage.LibraryRoot) *eng.LibraryRoot {
+	pb := &eng.LibraryRoot{
+		Id:        root.ID,
+		Path:      root.Path,
+		Watching:  s.watcher.Watching(),
+		CreatedAt: root.CreatedAt.Unix(),
+	}
+	if !root.LastScannedAt.IsZero() {
+		pb.LastScannedAt = root.LastScannedAt.Unix()
+	}
+	return pb
+}
+
+func (s *EngineServer) CheckLibraryHealth(ctx context.Context, _ *emptypb.Empty) (*eng.LibraryHealthResponse, error) {
+	report, err := s.scanner.CheckHealth(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "library health check failed: %v", err)
+	}
+
+	resp := &eng.LibraryHealthResponse{
+		Checked:      int32(report.Checked),
+		NewlyMissing: int32(report.NewlyMissing),
+		Recovered:    int32(report.Recovered),
+		Missing:      make([]*eng.MissingTrack, 0, len(report.Missing)),
+	}
+	for _, t := range report.Missing {
+		resp.Missing = append(resp.Missing, &eng.MissingTrack{
+			TrackId:      t.ID,
+			ContentHash:  t.ContentHash,
+			Path:         t.Path,
+			MissingSince: t.MissingAt.Unix(),
+		})
+	}
+	return resp, nil
+}
+
+func (s *EngineServer) RelocateTracks(ctx context.Context, req *eng.RelocateTracksRequest) (*eng.RelocateTracksResponse, error) {
+	report, err := s.scanner.Relocate(ctx, scanner.RelocateOptions{
+		OldPrefix:  req.GetOldPrefix(),
+		NewPrefix:  req.GetNewPrefix(),
+		SearchRoot: req.GetSearchRoot(),
+		DryRun:     req.GetDryRun(),
+	})
+	switch {
+	case errors.Is(err, scanner.ErrInvalidRelocation), errors.Is(err, scanner.ErrNotDirectory):
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, os.ErrNotExist):
+		return nil, status.Errorf(codes.NotFound, "search root not found: %s", req.GetSearchRoot())
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "relocate failed: %v", err)
+	}
+
+	resp := &eng.RelocateTracksResponse{
+		DryRun:      report.DryRun,
+		Matched:     int32(report.Matched),
+		Unmatched:   int32(report.Unmatched),
+		Relocations: make([]*eng.Relocation, 0, len(report.Relocations)),
+	}
+	for _, r := range report.Relocations {
+		resp.Relocations = append(resp.Relocations, &eng.Relocation{
+			TrackId:     r.TrackID,
+			ContentHash: r.ContentHash,
+			OldPath:     r.OldPath,
+			NewPath:     r.NewPath,
+			Status:      r.Status,
+		})
+	}
+	return resp, nil
+}
+
+func (s *EngineServer) ListDuplicateGroups(ctx context.Context, req *eng.ListDuplicateGroupsRequest) (*eng.ListDuplicateGroupsResponse, error) {
+	groups, err := s.db.FindDuplicates(duplicates.Options{
+		MinVibe:          float64(req.GetMinVibe()),
+		MaxDurationDelta: req.GetMaxDurationDelta(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "duplicate detection failed: %v", err)
+	}
+
+	resp := &eng.ListDuplicateGroupsResponse{Groups: make([]*eng.DuplicateGroup, 0, len(groups))}
+	for _, g := range groups {
+		group := &eng.DuplicateGroup{Reason: g.Reason}
+		for _, m := range g.Members {
+			id := &common.TrackId{ContentHash: m.ContentHash}
+			if m.TrackID == g.Preferred {
+				group.Preferred = id
+			}
+			group.Members = append(group.Members, &eng.DuplicateMember{
+				Id:              id,
+				Path:            m.Path,
+				Title:           m.Title,
+				Artist:          m.Artist,
+				Format:          m.Format,
+				Lossless:        m.Lossless,
+				BitrateKbps:     m.BitrateKbps,
+				DurationSeconds: m.DurationSeconds,
+				VibeMatch:       float32(m.VibeMatch),
+				Preferred:       m.TrackID == g.Preferred,
+			})
+		}
+		resp.Groups = append(resp.Groups, group)
+	}
+	return resp, nil
+}
+
 // collectTracks resolves incoming paths and track IDs into DB-backed Track objects.
 func (s *EngineServer) collectTracks(req *eng.AnalyzeRequest) ([]*storage.Track, error) {
 	tracks := make(map[string]*storage.Track)
 
 	for _, path := range req.GetPaths() {
-		info, err := os.Stat(path)
-		if err != nil {
+		if _, err := os.Stat(path); err != nil {
 			return nil, status.Errorf(codes.NotFound, "path not found: %s", path)
 		}
-		hash, err := scanner.ComputeHash(path)
-		if err != nil {
-			return nil, status.Errorf(codes.Internal, "hash failed for %s: %v", path, err)
+		result := s.scanner.ScanFile(path)
+		if result.Error != nil {
+			return nil, status.Errorf(codes.Internal, "failed to add %s: %v", path, result.Error)
 		}
-
-		track := &storage.Track{
-			ContentHash:    hash,
-			Path:           path,
-			FileSize:       info.Size(),
-			FileModifiedAt: info.ModTime(),
-		}
-		id, err := s.db.UpsertTrack(track)
+		track, err := s.db.GetTrackByID(result.TrackID)
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "track upsert failed: %v", err)
+			return nil, status.Errorf(codes.Internal, "track lookup failed: %v", err)
 		}
-		track.ID = id
 		tracks[track.ContentHash] = track
 	}
 
@@ -472,6 +911,27 @@ func (s *EngineServer) collectTracks(req *eng.AnalyzeRequest) ([]*storage.Track,
 	return list, nil
 }
 
+func toHashes(ids []*common.TrackId) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.GetContentHash())
+	}
+	return out
+}
+
+// constrainedHashes lists the tracks a set plan request's ordering constraints
+// name.
+func constrainedHashes(req *eng.SetPlanRequest) []string {
+	hashes := []string{req.GetOpener().GetContentHash(), req.GetCloser().GetContentHash()}
+	for _, chain := range req.GetLockedChains() {
+		hashes = append(hashes, toHashes(chain.GetTracks())...)
+	}
+	for _, p := range req.GetPrecedences() {
+		hashes = append(hashes, p.GetBefore().GetContentHash(), p.GetAfter().GetContentHash())
+	}
+	return slices.DeleteFunc(hashes, func(h string) bool { return h == "" })
+}
+
 func toHashSet(ids []*common.TrackId) map[string]bool {
 	out := make(map[string]bool, len(ids))
 	for _, id := range ids {
@@ -482,13 +942,249 @@ func toHashSet(ids []*common.TrackId) map[string]bool {
 	return out
 }
 
+// ============================================================
+// Live Sets
+// ============================================================
+
+func (s *EngineServer) StartLiveSession(ctx context.Context, req *eng.SetPlanRequest) (*eng.LiveSession, error) {
+	if s.live == nil {
+		return nil, status.Error(codes.Unavailable, "live sessions not configured")
+	}
+	session, err := s.live.Start(req)
+	if err != nil {
+		return nil, liveStatus(err)
+	}
+	return session, nil
+}
+
+func (s *EngineServer) GetLiveSession(ctx context.Context, req *eng.LiveSessionRequest) (*eng.LiveSession, error) {
+	if s.live == nil {
+		return nil, status.Error(codes.Unavailable, "live sessions not configured")
+	}
+	session, err := s.live.Get(req.GetSessionId())
+	if err != nil {
+		return nil, liveStatus(err)
+	}
+	return session, nil
+}
+
+func (s *EngineServer) RecordLivePlay(ctx context.Context, req *eng.LivePlayRequest) (*eng.LiveSession, error) {
+	if s.live == nil {
+		return nil, status.Error(codes.Unavailable, "live sessions not configured")
+	}
+	if req.GetId() == nil {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	var at time.Time
+	if req.GetStartedAt() > 0 {
+		at = time.Unix(req.GetStartedAt(), 0)
+	}
+	session, err := s.live.Play(req.GetSessionId(), req.GetId(), at)
+	if err != nil {
+		return nil, liveStatus(err)
+	}
+	return session, nil
+}
+
+func (s *EngineServer) EndLiveSession(ctx context.Context, req *eng.LiveSessionRequest) (*eng.LiveSession, error) {
+	if s.live == nil {
+		return nil, status.Error(codes.Unavailable, "live sessions not configured")
+	}
+	session, err := s.live.End(req.GetSessionId())
+	if err != nil {
+		return nil, liveStatus(err)
+	}
+	return session, nil
+}
+
+// WatchLiveSession streams the session now and after every replan, until it
+// ends or the client goes away.
+func (s *EngineServer) WatchLiveSession(req *eng.LiveSessionRequest, stream grpc.ServerStreamingServer[eng.LiveSession]) error {
+	if s.live == nil {
+		return status.Error(codes.Unavailable, "live sessions not configured")
+	}
+	updates, stop, err := s.live.Watch(req.GetSessionId())
+	if err != nil {
+		return liveStatus(err)
+	}
+	defer stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case session, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(session); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// liveStatus maps live session errors to gRPC status codes.
+func liveStatus(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "live session not found")
+	case errors.Is(err, live.ErrTrackNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, live.ErrInvalidRequest):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, live.ErrNoAnalysis), errors.Is(err, storage.ErrLiveSessionEnded):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+	return status.Errorf(codes.Internal, "live session failed: %v", err)
+}
+
+// ============================================================
+// Saved Sets
+// ============================================================
+
+func (s *EngineServer) CreateSet(ctx context.Context, req *eng.SaveSetRequest) (*eng.SavedSet, error) {
+	set, err := s.savedSetFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := s.db.CreateSavedSet(set)
+	if err != nil {
+		return nil, savedSetStatus(err)
+	}
+	return savedSetToProto(saved), nil
+}
+
+// UpdateSet saves the request as the next version of the set, replacing its
+// name, notes, tracks and transitions.
+func (s *EngineServer) UpdateSet(ctx context.Context, req *eng.SaveSetRequest) (*eng.SavedSet, error) {
+	if req.GetSetId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "set_id is required")
+	}
+	set, err := s.savedSetFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := s.db.UpdateSavedSet(set)
+	if err != nil {
+		return nil, savedSetStatus(err)
+	}
+	return savedSetToProto(saved), nil
+}
+
+func (s *EngineServer) GetSet(ctx context.Context, req *eng.GetSetRequest) (*eng.SavedSet, error) {
+	saved, err := s.db.GetSavedSet(req.GetSetId(), int(req.GetVersion()))
+	if err != nil {
+		return nil, savedSetStatus(err)
+	}
+	return savedSetToProto(saved), nil
+}
+
+func (s *EngineServer) ListSets(ctx context.Context, _ *emptypb.Empty) (*eng.ListSetsResponse, error) {
+	sets, err := s.db.ListSavedSets()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list sets: %v", err)
+	}
+	resp := &eng.ListSetsResponse{Sets: make([]*eng.SavedSet, 0, len(sets))}
+	for _, set := range sets {
+		resp.Sets = append(resp.Sets, savedSetToProto(set))
+	}
+	return resp, nil
+}
+
+func (s *EngineServer) ListSetVersions(ctx context.Context, req *eng.ListSetVersionsRequest) (*eng.ListSetVersionsResponse, error) {
+	versions, err := s.db.ListSavedSetVersions(req.GetSetId())
+	if err != nil {
+		return nil, savedSetStatus(err)
+	}
+	resp := &eng.ListSetVersionsResponse{Versions: make([]*eng.SavedSet, 0, len(versions))}
+	for _, v := range versions {
+		resp.Versions = append(resp.Versions, savedSetToProto(v))
+	}
+	return resp, nil
+}
+
+func (s *EngineServer) DeleteSet(ctx context.Context, req *eng.DeleteSetRequest) (*emptypb.Empty, error) {
+	if err := s.db.DeleteSavedSet(req.GetSetId()); err != nil {
+		return nil, savedSetStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// savedSetFromRequest resolves the tracks of a save request to the library's
+// content hashes.
+func (s *EngineServer) savedSetFromRequest(req *eng.SaveSetRequest) (*storage.SavedSet, error) {
+	set := &storage.SavedSet{
+		ID:           req.GetSetId(),
+		Name:         req.GetName(),
+		Notes:        req.GetNotes(),
+		Explanations: req.GetExplanations(),
+	}
+	for _, id := range req.GetTrackIds() {
+		track, err := s.db.ResolveTrack(id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, status.Errorf(codes.NotFound, "track not found for %s", id.GetContentHash())
+			}
+			return nil, status.Errorf(codes.Internal, "track lookup failed: %v", err)
+		}
+		set.Tracks = append(set.Tracks, track.ContentHash)
+	}
+	return set, nil
+}
+
+func savedSetToProto(set *storage.SavedSet) *eng.SavedSet {
+	out := &eng.SavedSet{
+		SetId:        set.ID,
+		Version:      int32(set.Version),
+		Name:         set.Name,
+		Notes:        set.Notes,
+		Explanations: set.Explanations,
+		CreatedAt:    set.CreatedAt.Unix(),
+		UpdatedAt:    set.UpdatedAt.Unix(),
+	}
+	for _, hash := range set.Tracks {
+		out.TrackIds = append(out.TrackIds, &common.TrackId{ContentHash: hash})
+	}
+	return out
+}
+
+// savedSetStatus maps saved set errors to gRPC status codes.
+func savedSetStatus(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "saved set not found")
+	case errors.Is(err, storage.ErrInvalidSavedSet):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return status.Errorf(codes.Internal, "saved set failed: %v", err)
+}
+
 // ============================================================
 // ML & Similarity Services
 // ============================================================
 
 func (s *EngineServer) GetSimilarTracks(ctx context.Context, req *eng.SimilarTracksRequest) (*eng.SimilarTracksResponse, error) {
-	if req.GetTrackId() == nil {
-		return nil, status.Error(codes.InvalidArgument, "track_id is required")
+	if req.GetTrackId() == nil && len(req.GetSeeds()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "track_id or seeds is required")
+	}
+
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = 10
+	}
+
+	override := weightsFromProto(req.GetWeights())
+	weights, err := s.db.ResolveSimilarityWeights(req.GetProfile(), override)
+	if err != nil {
+		return nil, similarityWeightsStatus(err)
+	}
+
+	if len(req.GetSeeds()) > 0 || len(req.GetNegativeSeeds()) > 0 {
+		if req.GetSection() != nil {
+			return nil, status.Error(codes.InvalidArgument, "section queries take a single track_id, not seeds")
+		}
+		return s.getSimilarToSeeds(req, limit, weights)
 	}
 
 	// Resolve the query track
@@ -506,50 +1202,174 @@ func (s *EngineServer) GetSimilarTracks(ctx context.Context, req *eng.SimilarTra
 		return nil, status.Errorf(codes.Internal, "failed to get track features: %v", err)
 	}
 
-	// Get all candidate track features
-	candidates, err := s.db.GetTrackFeaturesExcluding([]int64{track.ID})
+	if req.GetSection() != nil {
+		return s.getSimilarSections(req, track.ID, queryFeatures, limit, weights)
+	}
+
+	// Serve the precomputed similarity graph while it is fresh; it holds no
+	// answer for exact searches, constraints, collapsed duplicates or other
+	// weights than the settings'.
+	var results []similaritypkg.SimilarityResult
+	var duplicateCounts map[int64]int
+	cached := false
+	if constraints := req.GetConstraints(); !req.GetExact() && !req.GetCollapseDuplicates() &&
+		constraints.GetMaxBpmDelta() <= 0 && constraints.GetMaxEnergyDelta() <= 0 &&
+		req.GetProfile() == "" && override.IsZero() {
+		results, cached, err = s.db.CachedSimilarTracks(track.ID, limit, float64(req.GetMinScore()))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to read similarity cache: %v", err)
+		}
+	}
+	if !cached {
+		results, duplicateCounts, err = s.rankSimilarTracks(req, track.ID, queryFeatures, limit, weights)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return similarTracksResponse(req, results, duplicateCounts), nil
+}
+
+// getSimilarToSeeds answers a query seeded with several tracks, or with tracks
+// to be unlike. It always scores the candidates: the similarity graph only
+// holds the neighbours of single tracks.
+func (s *EngineServer) getSimilarToSeeds(req *eng.SimilarTracksRequest, limit int, weights similaritypkg.Weights) (*eng.SimilarTracksResponse, error) {
+	query := similaritypkg.SeedQuery{Aggregation: similaritypkg.AggregateCentroid, Weights: weights}
+	if req.GetAggregation() == eng.SeedAggregation_SEED_AGGREGATION_MAX {
+		query.Aggregation = similaritypkg.AggregateMax
+	}
+
+	like := req.GetSeeds()
+	if req.GetTrackId() != nil {
+		like = append([]*common.TrackId{req.GetTrackId()}, like...)
+	}
+	var err error
+	if query.Like, err = s.seedFeatures(like); err != nil {
+		return nil, err
+	}
+	if query.Unlike, err = s.seedFeatures(req.GetNegativeSeeds()); err != nil {
+		return nil, err
+	}
+	if len(query.Like) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "a seed to be similar to is required")
+	}
+
+	// Get the candidate track features: the nearest to the seeds by vibe from
+	// the embedding index, or every other track
+	candidates, err := s.db.SimilarityCandidatesNear(query.Near(), similaritypkg.CandidatePool(limit), query.SeedIDs(), req.GetExact())
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get candidates: %v", err)
 	}
+	candidates = applySimilarityConstraints(req.GetConstraints(), candidates, query.Like)
 
-	// Apply constraints to filter candidates
-	if constraints := req.GetConstraints(); constraints != nil {
-		filtered := make([]*similaritypkg.TrackFeatures, 0, len(candidates))
-		for _, c := range candidates {
-			// BPM constraint
-			if constraints.MaxBpmDelta > 0 {
-				bpmDiff := queryFeatures.BPM - c.BPM
-				if bpmDiff < 0 {
-					bpmDiff = -bpmDiff
-				}
-				if bpmDiff > constraints.MaxBpmDelta {
-					continue
-				}
-			}
+	var duplicateCounts map[int64]int
+	if req.GetCollapseDuplicates() {
+		if candidates, duplicateCounts, err = s.collapseSimilar(query.SeedIDs(), candidates); err != nil {
+			return nil, err
+		}
+	}
 
-			// Energy constraint
-			if constraints.MaxEnergyDelta > 0 {
-				energyDiff := int32(queryFeatures.Energy) - c.Energy
-				if energyDiff < 0 {
-					energyDiff = -energyDiff
-				}
-				if energyDiff > constraints.MaxEnergyDelta {
-					continue
-				}
-			}
+	results := similaritypkg.FindSimilarToSeeds(query, candidates, limit)
+	return similarTracksResponse(req, results, duplicateCounts), nil
+}
 
-			filtered = append(filtered, c)
+// getSimilarSections answers a section query: the tracks with a section that
+// sounds most like the asked for part of the query track.
+func (s *EngineServer) getSimilarSections(req *eng.SimilarTracksRequest, trackID int64, queryFeatures *similaritypkg.TrackFeatures, limit int, weights similaritypkg.Weights) (*eng.SimilarTracksResponse, error) {
+	q := req.GetSection()
+	section, err := s.db.ResolveSection(trackID, storage.SectionLabelName(q.GetLabel()), q.GetStartSeconds(), q.GetEndSeconds())
+	if err != nil {
+		return nil, sectionStatus(err)
+	}
+
+	// Get the candidate track features: the nearest to the section by vibe
+	// from the embedding index, or every other track
+	candidates, err := s.db.SimilarityCandidatesNear([][]float32{section.Embedding}, similaritypkg.CandidatePool(limit), []int64{trackID}, req.GetExact())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get candidates: %v", err)
+	}
+	candidates = applySimilarityConstraints(req.GetConstraints(), candidates, []*similaritypkg.TrackFeatures{queryFeatures})
+
+	var duplicateCounts map[int64]int
+	if req.GetCollapseDuplicates() {
+		if candidates, duplicateCounts, err = s.collapseSimilar([]int64{trackID}, candidates); err != nil {
+			return nil, err
 		}
-		candidates = filtered
 	}
 
-	// Find similar tracks
-	limit := int(req.GetLimit())
-	if limit == 0 {
-		limit = 10
+	ids := make([]int64, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.TrackID
+	}
+	sections, err := s.db.GetSectionsForTracks(ids, storage.SectionLabelName(q.GetMatchLabel()))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get sections: %v", err)
+	}
+
+	results := similaritypkg.FindSimilarSections(queryFeatures, *section, candidates, sections, limit, weights)
+	resp := similarTracksResponse(req, results, duplicateCounts)
+	resp.QuerySection = sectionToProto(section)
+	return resp, nil
+}
+
+// collapseSimilar keeps one candidate per duplicate group and drops copies of
+// the query tracks.
+func (s *EngineServer) collapseSimilar(queryIDs []int64, candidates []*similaritypkg.TrackFeatures) ([]*similaritypkg.TrackFeatures, map[int64]int, error) {
+	groups, err := s.db.FindDuplicates(duplicates.Options{})
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "duplicate detection failed: %v", err)
+	}
+	kept, counts := duplicates.NewIndex(groups).CollapseSimilar(queryIDs, candidates)
+	return kept, counts, nil
+}
+
+func sectionToProto(section *similaritypkg.Section) *common.SectionSpan {
+	label, _ := storage.ParseSectionLabel(section.Label)
+	return &common.SectionSpan{Label: label, StartSeconds: section.StartSeconds, EndSeconds: section.EndSeconds}
+}
+
+// sectionStatus maps errors resolving the query section to gRPC statuses.
+func sectionStatus(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrInvalidSection):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, storage.ErrNoSectionEmbedding):
+		return status.Error(codes.FailedPrecondition, "track has no window embeddings for the section - re-analyze with OpenL3 enabled")
 	}
-	results := similaritypkg.FindSimilar(queryFeatures, candidates, limit)
+	return status.Errorf(codes.Internal, "section lookup failed: %v", err)
+}
 
+// seedFeatures resolves the seeds of a similarity query, which must all have
+// an OpenL3 embedding.
+func (s *EngineServer) seedFeatures(ids []*common.TrackId) ([]*similaritypkg.TrackFeatures, error) {
+	seeds := make([]*similaritypkg.TrackFeatures, 0, len(ids))
+	for _, id := range ids {
+		track, err := s.db.ResolveTrack(id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				name := id.GetContentHash()
+				if name == "" {
+					name = id.GetPath()
+				}
+				return nil, status.Errorf(codes.NotFound, "seed track not found: %s", name)
+			}
+			return nil, status.Errorf(codes.Internal, "track lookup failed: %v", err)
+		}
+		features, err := s.db.GetTrackFeaturesForSimilarity(track.ID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get track features: %v", err)
+		}
+		if len(features.OpenL3Embedding) == 0 {
+			return nil, status.Errorf(codes.FailedPrecondition, "seed track %s has no ML embedding", track.ContentHash)
+		}
+		seeds = append(seeds, features)
+	}
+	return seeds, nil
+}
+
+// similarTracksResponse drops the results scoring under the query's minimum
+// and converts the rest to proto.
+func similarTracksResponse(req *eng.SimilarTracksRequest, results []similaritypkg.SimilarityResult, duplicateCounts map[int64]int) *eng.SimilarTracksResponse {
 	// Filter by minimum score
 	minScore := req.GetMinScore()
 	if minScore > 0 {
@@ -566,24 +1386,93 @@ func (s *EngineServer) GetSimilarTracks(ctx context.Context, req *eng.SimilarTra
 	similar := make([]*common.SimilarTrack, len(results))
 	for i, r := range results {
 		similar[i] = &common.SimilarTrack{
-			Id:          &common.TrackId{ContentHash: r.ContentHash},
-			Title:       r.Title,
-			Artist:      r.Artist,
-			Score:       float32(r.Score),
-			Explanation: r.Explanation,
-			VibeMatch:   float32(r.VibeMatch),
-			TempoMatch:  float32(r.TempoMatch),
-			KeyMatch:    float32(r.KeyMatch),
-			EnergyMatch: float32(r.EnergyMatch),
-			BpmDelta:    float32(r.BPMDelta),
-			KeyRelation: r.KeyRelation,
+			Id:             &common.TrackId{ContentHash: r.ContentHash},
+			Title:          r.Title,
+			Artist:         r.Artist,
+			Score:          float32(r.Score),
+			Explanation:    r.Explanation,
+			VibeMatch:      float32(r.VibeMatch),
+			TempoMatch:     float32(r.TempoMatch),
+			KeyMatch:       float32(r.KeyMatch),
+			EnergyMatch:    float32(r.EnergyMatch),
+			BpmDelta:       float32(r.BPMDelta),
+			KeyRelation:    r.KeyRelation,
+			DuplicateCount: int32(duplicateCounts[r.TrackID]),
+		}
+		if r.Seed != "" {
+			similar[i].Seed = &common.TrackId{ContentHash: r.Seed}
+		}
+		if r.Section != nil {
+			similar[i].Section = sectionToProto(r.Section)
 		}
 	}
 
 	return &eng.SimilarTracksResponse{
 		QueryTrack: req.GetTrackId(),
 		Similar:    similar,
-	}, nil
+	}
+}
+
+// rankSimilarTracks scores the candidates for a similarity query, returning
+// the best limit and how many copies each collapsed.
+func (s *EngineServer) rankSimilarTracks(req *eng.SimilarTracksRequest, trackID int64, queryFeatures *similaritypkg.TrackFeatures, limit int, weights similaritypkg.Weights) ([]similaritypkg.SimilarityResult, map[int64]int, error) {
+	// Get the candidate track features: the nearest by vibe from the
+	// embedding index, or every other track
+	candidates, err := s.db.SimilarityCandidates(queryFeatures, similaritypkg.CandidatePool(limit), req.GetExact())
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "failed to get candidates: %v", err)
+	}
+
+	// Apply constraints to filter candidates
+	candidates = applySimilarityConstraints(req.GetConstraints(), candidates, []*similaritypkg.TrackFeatures{queryFeatures})
+
+	var duplicateCounts map[int64]int
+	if req.GetCollapseDuplicates() {
+		if candidates, duplicateCounts, err = s.collapseSimilar([]int64{trackID}, candidates); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Find similar tracks
+	return similaritypkg.FindSimilarWeighted(queryFeatures, candidates, limit, weights), duplicateCounts, nil
+}
+
+// applySimilarityConstraints keeps the candidates within the constraints of
+// any one of the query tracks.
+func applySimilarityConstraints(constraints *eng.SimilarityConstraints, candidates, queries []*similaritypkg.TrackFeatures) []*similaritypkg.TrackFeatures {
+	if constraints == nil {
+		return candidates
+	}
+	filtered := make([]*similaritypkg.TrackFeatures, 0, len(candidates))
+	for _, c := range candidates {
+		for _, q := range queries {
+			// BPM constraint
+			if constraints.MaxBpmDelta > 0 {
+				bpmDiff := q.BPM - c.BPM
+				if bpmDiff < 0 {
+					bpmDiff = -bpmDiff
+				}
+				if bpmDiff > constraints.MaxBpmDelta {
+					continue
+				}
+			}
+
+			// Energy constraint
+			if constraints.MaxEnergyDelta > 0 {
+				energyDiff := int32(q.Energy) - c.Energy
+				if energyDiff < 0 {
+					energyDiff = -energyDiff
+				}
+				if energyDiff > constraints.MaxEnergyDelta {
+					continue
+				}
+			}
+
+			filtered = append(filtered, c)
+			break
+		}
+	}
+	return filtered
 }
 
 func (s *EngineServer) GetMLSettings(ctx context.Context, _ *emptypb.Empty) (*common.MLSettings, error) {
@@ -591,6 +1480,10 @@ func (s *EngineServer) GetMLSettings(ctx context.Context, _ *emptypb.Empty) (*co
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get ML settings: %v", err)
 	}
+	weights, err := s.db.GetSimilarityWeights()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get similarity weights: %v", err)
+	}
 
 	return &common.MLSettings{
 		SoundAnalysisEnabled:  settings["sound_analysis_enabled"] == "true",
@@ -598,10 +1491,16 @@ func (s *EngineServer) GetMLSettings(ctx context.Context, _ *emptypb.Empty) (*co
 		DjSectionModelEnabled: settings["dj_section_model_enabled"] == "true",
 		ShowExplanations:      settings["show_explanations"] != "false", // Default true
 		SimilarityThreshold:   parseFloat32(settings["similarity_threshold"], 0.5),
+		SimilarityWeights:     weightsToProto(weights),
 	}, nil
 }
 
 func (s *EngineServer) UpdateMLSettings(ctx context.Context, req *common.MLSettings) (*common.MLSettings, error) {
+	// Check the weights before writing anything
+	weights := weightsFromProto(req.GetSimilarityWeights())
+	if _, err := weights.Normalized(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
 	if err := s.db.SetMLSetting("sound_analysis_enabled", boolToStr(req.GetSoundAnalysisEnabled())); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to update setting: %v", err)
 	}
@@ -617,10 +1516,60 @@ func (s *EngineServer) UpdateMLSettings(ctx context.Context, req *common.MLSetti
 	if err := s.db.SetMLSetting("similarity_threshold", fmt.Sprintf("%.2f", req.GetSimilarityThreshold())); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to update setting: %v", err)
 	}
+	if err := s.db.SetSimilarityWeights(weights); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update setting: %v", err)
+	}
 
 	return s.GetMLSettings(ctx, nil)
 }
 
+func (s *EngineServer) ListSimilarityProfiles(ctx context.Context, _ *emptypb.Empty) (*eng.ListSimilarityProfilesResponse, error) {
+	profiles, err := s.db.ListSimilarityProfiles()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list similarity profiles: %v", err)
+	}
+	resp := &eng.ListSimilarityProfilesResponse{Profiles: make([]*eng.SimilarityProfile, len(profiles))}
+	for i, p := range profiles {
+		resp.Profiles[i] = &eng.SimilarityProfile{Name: p.Name, Weights: weightsToProto(p.Weights)}
+	}
+	return resp, nil
+}
+
+func (s *EngineServer) SaveSimilarityProfile(ctx context.Context, req *eng.SimilarityProfile) (*eng.SimilarityProfile, error) {
+	p, err := s.db.SaveSimilarityProfile(storage.SimilarityProfile{Name: req.GetName(), Weights: weightsFromProto(req.GetWeights())})
+	if err != nil {
+		return nil, similarityWeightsStatus(err)
+	}
+	return &eng.SimilarityProfile{Name: p.Name, Weights: weightsToProto(p.Weights)}, nil
+}
+
+func (s *EngineServer) DeleteSimilarityProfile(ctx context.Context, req *eng.DeleteSimilarityProfileRequest) (*emptypb.Empty, error) {
+	if err := s.db.DeleteSimilarityProfile(req.GetName()); err != nil {
+		return nil, similarityWeightsStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func weightsFromProto(w *common.SimilarityWeights) similaritypkg.Weights {
+	return similaritypkg.Weights{Vibe: w.GetVibe(), Tempo: w.GetTempo(), Key: w.GetKey(), Energy: w.GetEnergy()}
+}
+
+func weightsToProto(w similaritypkg.Weights) *common.SimilarityWeights {
+	return &common.SimilarityWeights{Vibe: w.Vibe, Tempo: w.Tempo, Key: w.Key, Energy: w.Energy}
+}
+
+// similarityWeightsStatus maps errors resolving similarity weights and
+// profiles to gRPC statuses.
+func similarityWeightsStatus(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "similarity profile not found")
+	case errors.Is(err, similaritypkg.ErrInvalidWeights), errors.Is(err, storage.ErrInvalidSimilarityProfile):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return status.Errorf(codes.Internal, "similarity weights failed: %v", err)
+}
+
 // ============================================================
 // Training Label CRUD
 // ============================================================
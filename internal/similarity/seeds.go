@@ -0,0 +1,215 @@
+package similarity
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Aggregation is how a query seeded with several tracks combines their
+// similarity to a candidate.
+type Aggregation string
+
+const (
+	// AggregateCentroid matches vibe against the average of the seeds'
+	// embeddings: candidates that sound like the group as a whole.
+	AggregateCentroid Aggregation = "centroid"
+	// AggregateMax scores candidates by their best match to any one seed:
+	// candidates that sound like some track in the group.
+	AggregateMax Aggregation = "max"
+)
+
+// ErrInvalidAggregation is returned for an aggregation ParseAggregation
+// doesn't know.
+var ErrInvalidAggregation = errors.New("invalid seed aggregation")
+
+// ParseAggregation parses "centroid" or "max". Empty is AggregateCentroid.
+func ParseAggregation(s string) (Aggregation, error) {
+	switch Aggregation(s) {
+	case "", AggregateCentroid:
+		return AggregateCentroid, nil
+	case AggregateMax:
+		return AggregateMax, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidAggregation, s)
+}
+
+// UnlikePenalty is how much of the score a candidate loses for sounding just
+// like a negative seed. It loses nothing for sounding unrelated or opposite.
+const UnlikePenalty = 0.5
+
+// SeedQuery asks for tracks similar to every Like seed and unlike every
+// Unlike seed.
+type SeedQuery struct {
+	Like        []*TrackFeatures
+	Unlike      []*TrackFeatures
+	Aggregation Aggregation
+	Weights     Weights // normalized
+}
+
+// Centroid returns the mean of the seeds' unit-length OpenL3 embeddings,
+// scaled to unit length, so no seed outweighs another. It returns nil when
+// no seed has an embedding.
+func Centroid(seeds []*TrackFeatures) []float32 {
+	var sum []float64
+	for _, s := range seeds {
+		emb := BytesToFloats(s.OpenL3Embedding)
+		norm := vectorNorm(emb)
+		if norm == 0 {
+			continue
+		}
+		if sum == nil {
+			sum = make([]float64, len(emb))
+		}
+		if len(emb) != len(sum) {
+			continue
+		}
+		for i, v := range emb {
+			sum[i] += float64(v) / norm
+		}
+	}
+	norm := 0.0
+	for _, v := range sum {
+		norm += v * v
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+	centroid := make([]float32, len(sum))
+	for i, v := range sum {
+		centroid[i] = float32(v / norm)
+	}
+	return centroid
+}
+
+func vectorNorm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// FindSimilarToSeeds finds tracks similar to the query's Like seeds and unlike
+// its Unlike seeds. Each result relates to the Like seed it matches best,
+// which its Seed names and its tempo, key and energy are compared against.
+// With AggregateCentroid its vibe is matched against the seeds' centroid.
+// Sounding like an Unlike seed costs up to UnlikePenalty of the score.
+func FindSimilarToSeeds(q SeedQuery, candidates []*TrackFeatures, limit int) []SimilarityResult {
+	if len(q.Like) == 0 || len(candidates) == 0 {
+		return nil
+	}
+
+	seeds := make(map[int64]bool, len(q.Like)+len(q.Unlike))
+	likeEmb := make([][]float32, len(q.Like))
+	for i, s := range q.Like {
+		seeds[s.TrackID] = true
+		likeEmb[i] = BytesToFloats(s.OpenL3Embedding)
+	}
+	unlikeEmb := make([][]float32, len(q.Unlike))
+	for i, s := range q.Unlike {
+		seeds[s.TrackID] = true
+		unlikeEmb[i] = BytesToFloats(s.OpenL3Embedding)
+	}
+	var centroid []float32
+	if q.Aggregation != AggregateMax {
+		centroid = Centroid(q.Like)
+	}
+
+	results := make([]SimilarityResult, 0, len(candidates))
+	for _, candidate := range candidates {
+		if seeds[candidate.TrackID] {
+			continue // Skip the seeds themselves
+		}
+		candidateEmb := BytesToFloats(candidate.OpenL3Embedding)
+
+		// The seed the candidate matches best is the one it relates to
+		var best SimilarityResult
+		var related *TrackFeatures
+		for i, seed := range q.Like {
+			r := scoreMatch(seed, likeEmb[i], candidate, candidateEmb, q.Weights)
+			if related == nil || r.Score > best.Score {
+				best, related = r, seed
+			}
+		}
+		if centroid != nil {
+			best = scoreMatch(related, centroid, candidate, candidateEmb, q.Weights)
+		}
+		best.Seed = related.ContentHash
+		explanation := "like " + seedName(related) + "; " + best.Explanation
+
+		// Penalize sounding like the closest negative seed
+		closestVibe := 0.0
+		var closest *TrackFeatures
+		for i, seed := range q.Unlike {
+			if vibe := CosineSimilarity(unlikeEmb[i], candidateEmb); closest == nil || vibe > closestVibe {
+				closestVibe, closest = vibe, seed
+			}
+		}
+		if closest != nil {
+			// CosineSimilarity maps -1..1 to 0..1; only the positive half counts
+			penalty := UnlikePenalty * max(0, 2*closestVibe-1)
+			best.Score = max(0, best.Score-penalty)
+			if closestVibe >= 0.7 {
+				explanation += fmt.Sprintf("; close to unliked %s (%.0f%% vibe, score -%.0f%%)", seedName(closest), closestVibe*100, penalty*100)
+			}
+		}
+		best.Explanation = explanation
+
+		results = append(results, best)
+	}
+
+	// Sort by score descending
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	// Limit results
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// seedName names a seed track in explanations.
+func seedName(f *TrackFeatures) string {
+	switch {
+	case f.Title != "" && f.Artist != "":
+		return f.Artist + " - " + f.Title
+	case f.Title != "":
+		return f.Title
+	}
+	return "track " + f.ContentHash
+}
+
+// SeedIDs returns the track IDs of every seed, Like and Unlike.
+func (q SeedQuery) SeedIDs() []int64 {
+	ids := make([]int64, 0, len(q.Like)+len(q.Unlike))
+	for _, s := range q.Like {
+		ids = append(ids, s.TrackID)
+	}
+	for _, s := range q.Unlike {
+		ids = append(ids, s.TrackID)
+	}
+	return ids
+}
+
+// Near returns the embeddings whose nearest neighbours make good candidates
+// for the query: each Like seed's, and with AggregateCentroid their centroid.
+func (q SeedQuery) Near() [][]float32 {
+	near := make([][]float32, 0, len(q.Like)+1)
+	if q.Aggregation != AggregateMax {
+		if centroid := Centroid(q.Like); centroid != nil {
+			near = append(near, centroid)
+		}
+	}
+	for _, s := range q.Like {
+		if len(s.OpenL3Embedding) > 0 {
+			near = append(near, BytesToFloats(s.OpenL3Embedding))
+		}
+	}
+	return near
+}
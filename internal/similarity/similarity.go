@@ -3,16 +3,20 @@ package similarity
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
 	"strings"
+
+	"github.com/cartomix/cancun/internal/camelot"
+	"github.com/cartomix/cancun/internal/tempo"
 )
 
 // EmbeddingDim is the dimensionality of OpenL3 embeddings.
 const EmbeddingDim = 512
 
-// Weights for combined similarity score.
+// Default weights for combined similarity score.
 const (
 	WeightOpenL3  = 0.50 // OpenL3 embedding similarity
 	WeightTempo   = 0.20 // BPM compatibility
@@ -20,6 +24,58 @@ const (
 	WeightEnergy  = 0.10 // Energy level similarity
 )
 
+// ErrInvalidWeights is returned for weights that can't score anything.
+var ErrInvalidWeights = errors.New("invalid similarity weights")
+
+// Weights are the shares of the combined similarity score each match gets.
+type Weights struct {
+	Vibe   float64 `json:"vibe"`   // OpenL3 embedding similarity
+	Tempo  float64 `json:"tempo"`  // BPM compatibility
+	Key    float64 `json:"key"`    // Key compatibility
+	Energy float64 `json:"energy"` // Energy level similarity
+}
+
+// DefaultWeights are the weights used unless settings or a query say otherwise.
+var DefaultWeights = Weights{Vibe: WeightOpenL3, Tempo: WeightTempo, Key: WeightKey, Energy: WeightEnergy}
+
+// IsZero reports whether no weight is set.
+func (w Weights) IsZero() bool {
+	return w == Weights{}
+}
+
+// Normalized returns the weights scaled to sum to 1, so scores stay 0-1
+// however they were given. All zero weights are the defaults.
+func (w Weights) Normalized() (Weights, error) {
+	if w.IsZero() {
+		return DefaultWeights, nil
+	}
+	for i, v := range []float64{w.Vibe, w.Tempo, w.Key, w.Energy} {
+		if v < 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+			return Weights{}, fmt.Errorf("%w: %s weight is %v", ErrInvalidWeights, [...]string{"vibe", "tempo", "key", "energy"}[i], v)
+		}
+	}
+	sum := w.Vibe + w.Tempo + w.Key + w.Energy
+	return Weights{Vibe: w.Vibe / sum, Tempo: w.Tempo / sum, Key: w.Key / sum, Energy: w.Energy / sum}, nil
+}
+
+// String formats the weights as percentages, e.g. "vibe 50%, tempo 20%, key
+// 20%, energy 10%".
+func (w Weights) String() string {
+	return fmt.Sprintf("vibe %.0f%%, tempo %.0f%%, key %.0f%%, energy %.0f%%", w.Vibe*100, w.Tempo*100, w.Key*100, w.Energy*100)
+}
+
+// MinCandidatePool is the fewest nearest neighbours by vibe a similarity query
+// fetches from the embedding index.
+const MinCandidatePool = 500
+
+// CandidatePool is how many nearest neighbours by vibe to fetch from the
+// embedding index to rank limit results by the combined score. Tempo, key and
+// energy can lift a track with a weaker vibe match, and constraints and
+// duplicate collapsing drop some, so it fetches many more than limit.
+func CandidatePool(limit int) int {
+	return max(MinCandidatePool, 25*limit)
+}
+
 // TrackFeatures contains the features needed for similarity computation.
 type TrackFeatures struct {
 	TrackID         int64
@@ -47,27 +103,24 @@ type SimilarityResult struct {
 	BPMDelta     float64 `json:"bpm_delta"`     // Absolute BPM difference
 	KeyRelation  string  `json:"key_relation"`  // "same", "compatible", "harmonic", "clash"
 	EnergyDelta  int32   `json:"energy_delta"`  // Signed energy difference
+	DuplicateCount int   `json:"duplicate_count,omitempty"` // Other copies collapsed into this result
+	Seed         string  `json:"seed,omitempty"`         // Content hash of the seed a multi-seed match relates to
+	Section      *Section `json:"section,omitempty"`     // The matching section, in section queries
 }
 
-// TransitionMatch represents a potential mix transition point.
-type TransitionMatch struct {
-	TrackID        int64   `json:"track_id"`
-	ContentHash    string  `json:"content_hash"`
-	Title          string  `json:"title"`
-	Artist         string  `json:"artist"`
-	Score          float64 `json:"score"`
-	Explanation    string  `json:"explanation"`
-	SuggestedOutBeat int32 `json:"suggested_out_beat"` // From current track
-	SuggestedInBeat  int32 `json:"suggested_in_beat"`  // Into target track
+// FindSimilar finds tracks similar to the query track with the default weights.
+func FindSimilar(query *TrackFeatures, candidates []*TrackFeatures, limit int) []SimilarityResult {
+	return FindSimilarWeighted(query, candidates, limit, DefaultWeights)
 }
 
-// FindSimilar finds tracks similar to the query track.
-func FindSimilar(query *TrackFeatures, candidates []*TrackFeatures, limit int) []SimilarityResult {
+// FindSimilarWeighted finds tracks similar to the query track, combining the
+// matches with normalized weights.
+func FindSimilarWeighted(query *TrackFeatures, candidates []*TrackFeatures, limit int, weights Weights) []SimilarityResult {
 	if query == nil || len(candidates) == 0 {
 		return nil
 	}
 
-	queryEmb := bytesToFloats(query.OpenL3Embedding)
+	queryEmb := BytesToFloats(query.OpenL3Embedding)
 
 	results := make([]SimilarityResult, 0, len(candidates))
 
@@ -75,34 +128,7 @@ func FindSimilar(query *TrackFeatures, candidates []*TrackFeatures, limit int) [
 		if candidate.TrackID == query.TrackID {
 			continue // Skip self
 		}
-
-		// Compute component similarities
-		vibeMatch := computeCosineSimilarity(queryEmb, bytesToFloats(candidate.OpenL3Embedding))
-		tempoMatch := computeTempoSimilarity(query.BPM, candidate.BPM)
-		keyMatch, keyRelation := computeKeySimilarity(query.KeyValue, candidate.KeyValue)
-		energyMatch := computeEnergySimilarity(query.Energy, candidate.Energy)
-
-		// Combined weighted score
-		score := WeightOpenL3*vibeMatch + WeightTempo*tempoMatch + WeightKey*keyMatch + WeightEnergy*energyMatch
-
-		// Build explanation
-		explanation := buildExplanation(vibeMatch, tempoMatch, keyMatch, keyRelation, energyMatch, query.BPM, candidate.BPM, query.Energy, candidate.Energy)
-
-		results = append(results, SimilarityResult{
-			TrackID:     candidate.TrackID,
-			ContentHash: candidate.ContentHash,
-			Title:       candidate.Title,
-			Artist:      candidate.Artist,
-			Score:       score,
-			Explanation: explanation,
-			VibeMatch:   vibeMatch * 100,
-			TempoMatch:  tempoMatch * 100,
-			KeyMatch:    keyMatch * 100,
-			EnergyMatch: energyMatch * 100,
-			BPMDelta:    math.Abs(query.BPM - candidate.BPM),
-			KeyRelation: keyRelation,
-			EnergyDelta: candidate.Energy - query.Energy,
-		})
+		results = append(results, scoreMatch(query, queryEmb, candidate, BytesToFloats(candidate.OpenL3Embedding), weights))
 	}
 
 	// Sort by score descending
@@ -118,8 +144,41 @@ func FindSimilar(query *TrackFeatures, candidates []*TrackFeatures, limit int) [
 	return results
 }
 
-// computeCosineSimilarity calculates cosine similarity between two embedding vectors.
-func computeCosineSimilarity(a, b []float32) float64 {
+// scoreMatch scores how well candidate follows query, matching vibe by the
+// given embeddings.
+func scoreMatch(query *TrackFeatures, queryEmb []float32, candidate *TrackFeatures, candidateEmb []float32, weights Weights) SimilarityResult {
+	// Compute component similarities
+	vibeMatch := CosineSimilarity(queryEmb, candidateEmb)
+	tempoMatch := computeTempoSimilarity(query.BPM, candidate.BPM)
+	keyMatch, keyRelation := computeKeySimilarity(query.KeyValue, candidate.KeyValue)
+	energyMatch := computeEnergySimilarity(query.Energy, candidate.Energy)
+
+	// Combined weighted score
+	score := weights.Vibe*vibeMatch + weights.Tempo*tempoMatch + weights.Key*keyMatch + weights.Energy*energyMatch
+
+	// Build explanation
+	explanation := buildExplanation(weights, vibeMatch, tempoMatch, keyMatch, keyRelation, energyMatch, query.BPM, candidate.BPM, query.Energy, candidate.Energy)
+
+	return SimilarityResult{
+		TrackID:     candidate.TrackID,
+		ContentHash: candidate.ContentHash,
+		Title:       candidate.Title,
+		Artist:      candidate.Artist,
+		Score:       score,
+		Explanation: explanation,
+		VibeMatch:   vibeMatch * 100,
+		TempoMatch:  tempoMatch * 100,
+		KeyMatch:    keyMatch * 100,
+		EnergyMatch: energyMatch * 100,
+		BPMDelta:    math.Abs(query.BPM - candidate.BPM),
+		KeyRelation: keyRelation,
+		EnergyDelta: candidate.Energy - query.Energy,
+	}
+}
+
+// CosineSimilarity calculates cosine similarity between two embedding vectors,
+// normalized to 0-1.
+func CosineSimilarity(a, b []float32) float64 {
 	if len(a) != len(b) || len(a) == 0 {
 		return 0
 	}
@@ -141,104 +200,46 @@ func computeCosineSimilarity(a, b []float32) float64 {
 	return (sim + 1) / 2
 }
 
-// computeTempoSimilarity returns similarity based on BPM difference.
-// Perfect match = 1.0, >10 BPM difference = 0.0
+// computeTempoSimilarity returns how easily the candidate beatmatches the query,
+// directly or at half, double or 3:2 time, within a ±8% pitch range.
 func computeTempoSimilarity(bpmA, bpmB float64) float64 {
-	if bpmA == 0 || bpmB == 0 {
+	match := tempo.Compare(bpmA, bpmB, tempo.Options{})
+	if !match.Known {
 		return 0.5 // Unknown tempo, neutral score
 	}
+	return match.Score()
+}
 
-	diff := math.Abs(bpmA - bpmB)
-
-	// Also check half/double tempo compatibility
-	halfDiff := math.Min(
-		math.Abs(bpmA - bpmB*2),
-		math.Abs(bpmA*2 - bpmB),
-	)
-	diff = math.Min(diff, halfDiff)
+// KeyWeights scores the key move from a query track to a candidate, 0-1.
+var KeyWeights = camelot.Weights{
+	camelot.Same:        1.0,
+	camelot.Relative:    0.9,
+	camelot.Adjacent:    0.85,
+	camelot.Diagonal:    0.75,
+	camelot.EnergyBoost: 0.7,
+	camelot.Modulation:  0.6,
+	camelot.Clash:       0.2,
+	camelot.Missing:     0.5,
+	camelot.Invalid:     0.3,
+}
 
-	if diff <= 1 {
-		return 1.0
-	} else if diff >= 10 {
-		return 0.0
-	}
-	return 1.0 - (diff / 10.0)
+// keyCategories groups key moves into the coarse relations results report.
+var keyCategories = map[camelot.Move]string{
+	camelot.Same:        "same",
+	camelot.Relative:    "relative",
+	camelot.Adjacent:    "compatible",
+	camelot.Diagonal:    "harmonic",
+	camelot.EnergyBoost: "harmonic",
+	camelot.Modulation:  "harmonic",
+	camelot.Clash:       "clash",
+	camelot.Missing:     "unknown",
+	camelot.Invalid:     "unknown",
 }
 
 // computeKeySimilarity returns key compatibility score and relation type.
 func computeKeySimilarity(keyA, keyB string) (float64, string) {
-	if keyA == "" || keyB == "" {
-		return 0.5, "unknown"
-	}
-
-	keyA = strings.ToUpper(strings.TrimSpace(keyA))
-	keyB = strings.ToUpper(strings.TrimSpace(keyB))
-
-	if keyA == keyB {
-		return 1.0, "same"
-	}
-
-	// Parse Camelot notation (e.g., "8A", "12B")
-	numA, modeA := parseCamelot(keyA)
-	numB, modeB := parseCamelot(keyB)
-
-	if numA == 0 || numB == 0 {
-		return 0.3, "unknown"
-	}
-
-	// Same number, different mode (relative major/minor)
-	if numA == numB && modeA != modeB {
-		return 0.9, "relative"
-	}
-
-	// Adjacent numbers, same mode (+1/-1)
-	if modeA == modeB {
-		diff := (numA - numB + 12) % 12
-		if diff == 1 || diff == 11 {
-			return 0.85, "compatible"
-		}
-	}
-
-	// Energy boost (+1 semitone)
-	if modeA == modeB {
-		diff := (numA - numB + 12) % 12
-		if diff == 2 || diff == 10 {
-			return 0.7, "harmonic"
-		}
-	}
-
-	// Cross-mode adjacent
-	if modeA != modeB {
-		diff := (numA - numB + 12) % 12
-		if diff == 0 || diff == 1 || diff == 11 {
-			return 0.75, "harmonic"
-		}
-	}
-
-	return 0.2, "clash"
-}
-
-// parseCamelot extracts number and mode from Camelot notation.
-func parseCamelot(key string) (int, string) {
-	if len(key) < 2 {
-		return 0, ""
-	}
-
-	mode := string(key[len(key)-1])
-	numStr := key[:len(key)-1]
-
-	var num int
-	fmt.Sscanf(numStr, "%d", &num)
-
-	if num < 1 || num > 12 {
-		return 0, ""
-	}
-
-	if mode != "A" && mode != "B" {
-		return 0, ""
-	}
-
-	return num, mode
+	score, relation := KeyWeights.Score(keyA, keyB)
+	return score, keyCategories[relation.Move]
 }
 
 // computeEnergySimilarity returns similarity based on energy level difference.
@@ -252,54 +253,83 @@ func computeEnergySimilarity(energyA, energyB int32) float64 {
 	return 1.0 - (diff / 5.0)
 }
 
-// buildExplanation creates a human-readable explanation string.
-func buildExplanation(vibeMatch, tempoMatch, keyMatch float64, keyRelation string, energyMatch float64, bpmA, bpmB float64, energyA, energyB int32) string {
-	var parts []string
+// buildExplanation creates a human-readable explanation string. It opens with
+// the match that contributed most to the score, lists the rest by how much
+// they contributed, and leaves out matches weighted zero.
+func buildExplanation(weights Weights, vibeMatch, tempoMatch, keyMatch float64, keyRelation string, energyMatch float64, bpmA, bpmB float64, energyA, energyB int32) string {
+	type term struct {
+		name         string
+		weight       float64
+		contribution float64
+		text         string // empty when there's nothing worth saying
+	}
+	terms := make([]term, 0, 4)
 
 	// Vibe match
+	vibe := term{name: "vibe", weight: weights.Vibe, contribution: weights.Vibe * vibeMatch}
 	if vibeMatch >= 0.7 {
-		parts = append(parts, fmt.Sprintf("similar vibe (%.0f%%)", vibeMatch*100))
+		vibe.text = fmt.Sprintf("similar vibe (%.0f%%)", vibeMatch*100)
 	}
+	terms = append(terms, vibe)
 
 	// Tempo
-	bpmDiff := bpmB - bpmA
-	if math.Abs(bpmDiff) <= 2 {
-		parts = append(parts, "tempo match")
-	} else if bpmDiff > 0 {
-		parts = append(parts, fmt.Sprintf("Δ+%.1f BPM", bpmDiff))
-	} else {
-		parts = append(parts, fmt.Sprintf("Δ%.1f BPM", bpmDiff))
+	bpm := term{name: "tempo", weight: weights.Tempo, contribution: weights.Tempo * tempoMatch}
+	if match := tempo.Compare(bpmA, bpmB, tempo.Options{}); match.Known {
+		bpm.text = match.String()
 	}
+	terms = append(terms, bpm)
 
 	// Key
+	key := term{name: "key", weight: weights.Key, contribution: weights.Key * keyMatch}
 	switch keyRelation {
 	case "same":
-		parts = append(parts, "same key")
+		key.text = "same key"
 	case "relative":
-		parts = append(parts, "relative key")
+		key.text = "relative key"
 	case "compatible":
-		parts = append(parts, "key compatible")
+		key.text = "key compatible"
 	case "harmonic":
-		parts = append(parts, "harmonic key")
+		key.text = "harmonic key"
 	case "clash":
-		parts = append(parts, "key clash ⚠")
+		key.text = "key clash ⚠"
 	}
+	terms = append(terms, key)
 
 	// Energy
+	energy := term{name: "energy", weight: weights.Energy, contribution: weights.Energy * energyMatch}
 	energyDiff := energyB - energyA
 	if energyDiff == 0 {
-		parts = append(parts, "same energy")
+		energy.text = "same energy"
 	} else if energyDiff > 0 {
-		parts = append(parts, fmt.Sprintf("energy +%d", energyDiff))
+		energy.text = fmt.Sprintf("energy +%d", energyDiff)
 	} else {
-		parts = append(parts, fmt.Sprintf("energy %d", energyDiff))
+		energy.text = fmt.Sprintf("energy %d", energyDiff)
+	}
+	terms = append(terms, energy)
+
+	total := 0.0
+	for _, t := range terms {
+		total += t.contribution
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		return terms[i].contribution > terms[j].contribution
+	})
+
+	var parts []string
+	if total > 0 {
+		parts = append(parts, fmt.Sprintf("%s drove %.0f%% of the score", terms[0].name, terms[0].contribution/total*100))
+	}
+	for _, t := range terms {
+		if t.weight > 0 && t.text != "" {
+			parts = append(parts, t.text)
+		}
 	}
 
 	return strings.Join(parts, "; ")
 }
 
-// bytesToFloats converts a byte slice to float32 slice (little-endian).
-func bytesToFloats(data []byte) []float32 {
+// BytesToFloats converts a byte slice to float32 slice (little-endian).
+func BytesToFloats(data []byte) []float32 {
 	if len(data) == 0 {
 		return nil
 	}
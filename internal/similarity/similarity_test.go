@@ -1,7 +1,9 @@
 package similarity
 
 import (
+	"errors"
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -45,9 +47,9 @@ func TestCosineSimilarity(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := computeCosineSimilarity(tt.a, tt.b)
+			got := CosineSimilarity(tt.a, tt.b)
 			if got < tt.wantMin || got > tt.wantMax {
-				t.Errorf("computeCosineSimilarity() = %v, want between %v and %v", got, tt.wantMin, tt.wantMax)
+				t.Errorf("CosineSimilarity() = %v, want between %v and %v", got, tt.wantMin, tt.wantMax)
 			}
 		})
 	}
@@ -91,6 +93,7 @@ func TestComputeKeySimilarity(t *testing.T) {
 		{"relative major/minor", "8A", "8B", 0.9, "relative"},
 		{"adjacent key", "8A", "9A", 0.85, "compatible"},
 		{"adjacent key wrap", "12A", "1A", 0.85, "compatible"},
+		{"energy boost", "8A", "10A", 0.7, "harmonic"},
 		{"distant key", "8A", "2A", 0.2, "clash"},
 		{"unknown key", "", "8A", 0.5, "unknown"},
 	}
@@ -199,7 +202,7 @@ func TestFindSimilar(t *testing.T) {
 func TestBytesFloatsRoundTrip(t *testing.T) {
 	original := []float32{1.5, 2.5, 3.5, -4.5, 0.0}
 	bytes := FloatsToBytes(original)
-	recovered := bytesToFloats(bytes)
+	recovered := BytesToFloats(bytes)
 
 	if len(recovered) != len(original) {
 		t.Fatalf("length mismatch: %d vs %d", len(recovered), len(original))
@@ -211,3 +214,118 @@ func TestBytesFloatsRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+func TestWeightsNormalized(t *testing.T) {
+	got, err := Weights{Vibe: 2, Key: 2}.Normalized()
+	if err != nil || got != (Weights{Vibe: 0.5, Key: 0.5}) {
+		t.Errorf("normalized = %+v, %v", got, err)
+	}
+	if got, err := (Weights{}).Normalized(); err != nil || got != DefaultWeights {
+		t.Errorf("zero weights = %+v, %v; want the defaults", got, err)
+	}
+	if _, err := (Weights{Vibe: 1, Tempo: -1}).Normalized(); !errors.Is(err, ErrInvalidWeights) {
+		t.Errorf("negative weight: got %v", err)
+	}
+}
+
+func TestFindSimilarWeighted(t *testing.T) {
+	embedding := func(axis int) []byte {
+		floats := make([]float32, EmbeddingDim)
+		floats[axis] = 1
+		return FloatsToBytes(floats)
+	}
+	query := &TrackFeatures{TrackID: 1, BPM: 124, KeyValue: "8A", Energy: 6, OpenL3Embedding: embedding(0)}
+	// One sounds alike in a clashing key, the other sounds different in the same key.
+	alike := &TrackFeatures{TrackID: 2, BPM: 124, KeyValue: "3B", Energy: 6, OpenL3Embedding: embedding(0)}
+	harmonic := &TrackFeatures{TrackID: 3, BPM: 124, KeyValue: "8A", Energy: 6, OpenL3Embedding: embedding(1)}
+	candidates := []*TrackFeatures{alike, harmonic}
+
+	vibeFirst := FindSimilarWeighted(query, candidates, 2, Weights{Vibe: 0.8, Tempo: 0.1, Key: 0.05, Energy: 0.05})
+	if vibeFirst[0].TrackID != alike.TrackID || !strings.HasPrefix(vibeFirst[0].Explanation, "vibe drove") {
+		t.Errorf("vibe-first = %+v", vibeFirst)
+	}
+	harmonicFirst := FindSimilarWeighted(query, candidates, 2, Weights{Vibe: 0.1, Tempo: 0.1, Key: 0.7, Energy: 0.1})
+	if harmonicFirst[0].TrackID != harmonic.TrackID || !strings.HasPrefix(harmonicFirst[0].Explanation, "key drove") {
+		t.Errorf("harmonic-first = %+v", harmonicFirst)
+	}
+
+	// Matches weighted zero don't count and aren't explained.
+	keyOnly := FindSimilarWeighted(query, []*TrackFeatures{harmonic}, 1, Weights{Key: 1})
+	if keyOnly[0].Score != 1 || keyOnly[0].Explanation != "key drove 100% of the score; same key" {
+		t.Errorf("key only = %.2f %q", keyOnly[0].Score, keyOnly[0].Explanation)
+	}
+}
+
+func TestFindSimilarToSeeds(t *testing.T) {
+	embedding := func(axes ...int) []byte {
+		floats := make([]float32, EmbeddingDim)
+		for _, axis := range axes {
+			floats[axis] = 1
+		}
+		return FloatsToBytes(floats)
+	}
+	track := func(id int64, hash string, axes ...int) *TrackFeatures {
+		return &TrackFeatures{TrackID: id, ContentHash: hash, Title: hash, BPM: 124, KeyValue: "8A", Energy: 6, OpenL3Embedding: embedding(axes...)}
+	}
+	a, b, c := track(1, "a", 0), track(2, "b", 1), track(3, "c", 2)
+	both := track(4, "both", 0, 1)    // sounds like the group
+	onlyA := track(5, "only-a", 0)    // sounds just like one seed
+	nearC := track(6, "near-c", 0, 2) // as close to a as both, but also close to c
+	candidates := []*TrackFeatures{a, b, c, both, onlyA, nearC}
+	vibeOnly := Weights{Vibe: 1}
+
+	centroid := FindSimilarToSeeds(SeedQuery{Like: []*TrackFeatures{a, b}, Weights: vibeOnly}, candidates, 10)
+	if len(centroid) != 4 || centroid[0].TrackID != both.TrackID || math.Abs(centroid[0].Score-1) > 1e-6 {
+		t.Fatalf("centroid = %+v, want the seeds left out and both first", centroid)
+	}
+
+	maxSim := FindSimilarToSeeds(SeedQuery{Like: []*TrackFeatures{a, b}, Aggregation: AggregateMax, Weights: vibeOnly}, candidates, 10)
+	if maxSim[0].TrackID != onlyA.TrackID || maxSim[0].Seed != "a" || !strings.HasPrefix(maxSim[0].Explanation, "like a; ") {
+		t.Errorf("max = %+v, want only-a first, related to a", maxSim[0])
+	}
+
+	unlike := FindSimilarToSeeds(SeedQuery{Like: []*TrackFeatures{a}, Unlike: []*TrackFeatures{c}, Weights: vibeOnly}, candidates, 10)
+	last := unlike[len(unlike)-1]
+	if last.TrackID != nearC.TrackID || !strings.Contains(last.Explanation, "close to unliked c") {
+		t.Errorf("unlike = %+v, want near-c last", unlike)
+	}
+	for _, r := range unlike {
+		if r.TrackID == c.TrackID {
+			t.Error("returned a negative seed")
+		}
+	}
+
+	if _, err := ParseAggregation("median"); !errors.Is(err, ErrInvalidAggregation) {
+		t.Errorf("parse median: %v", err)
+	}
+}
+
+func TestFindSimilarSections(t *testing.T) {
+	axis := func(i int) []float32 {
+		v := make([]float32, 4)
+		v[i] = 1
+		return v
+	}
+	query := &TrackFeatures{TrackID: 1, BPM: 124, KeyValue: "8A", Energy: 6}
+	outro := Section{Label: "outro", StartSeconds: 180, EndSeconds: 240, Embedding: axis(0)}
+	candidates := []*TrackFeatures{
+		{TrackID: 2, BPM: 124, KeyValue: "8A", Energy: 6},
+		{TrackID: 3, BPM: 124, KeyValue: "8A", Energy: 6},
+		{TrackID: 4, BPM: 124, KeyValue: "8A", Energy: 6}, // never analyzed into sections
+	}
+	sections := map[int64][]Section{
+		2: {{Label: "intro", StartSeconds: 0, EndSeconds: 30, Embedding: axis(1)}, {Label: "drop", StartSeconds: 60, EndSeconds: 90, Embedding: axis(0)}},
+		3: {{Label: "intro", StartSeconds: 0, EndSeconds: 32, Embedding: axis(2)}},
+	}
+
+	results := FindSimilarSections(query, outro, candidates, sections, 10, Weights{Vibe: 1})
+	if len(results) != 2 || results[0].TrackID != 2 {
+		t.Fatalf("results = %+v, want tracks 2 then 3", results)
+	}
+	if s := results[0].Section; s == nil || s.Label != "drop" || s.StartSeconds != 60 {
+		t.Errorf("best section of track 2 = %+v, want its drop", s)
+	}
+	if want := "drop 1:00–1:30 matches the outro 3:00–4:00; "; !strings.HasPrefix(results[0].Explanation, want) {
+		t.Errorf("explanation = %q, want prefix %q", results[0].Explanation, want)
+	}
+}
@@ -0,0 +1,106 @@
+package similarity
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Window is an OpenL3 embedding of one stretch of a track.
+type Window struct {
+	Index           int
+	StartSeconds    float64
+	DurationSeconds float64
+	Embedding       []float32
+}
+
+// PoolWindows averages the embeddings of the windows that overlap from-to
+// seconds. It returns nil when none do.
+func PoolWindows(windows []Window, from, to float64) []float32 {
+	var pooled []float32
+	count := 0
+	for _, w := range windows {
+		if w.StartSeconds >= to || w.StartSeconds+w.DurationSeconds <= from || len(w.Embedding) == 0 {
+			continue
+		}
+		if pooled == nil {
+			pooled = make([]float32, len(w.Embedding))
+		}
+		if len(w.Embedding) != len(pooled) {
+			continue
+		}
+		for i, v := range w.Embedding {
+			pooled[i] += v
+		}
+		count++
+	}
+	for i := range pooled {
+		pooled[i] /= float32(count)
+	}
+	return pooled
+}
+
+// Section is a stretch of a track, usually a labelled one, with the pooled
+// embedding of the windows it spans.
+type Section struct {
+	Label        string    `json:"label,omitempty"` // intro, verse, breakdown, build, drop, outro; empty for a plain time range
+	StartSeconds float64   `json:"start_seconds"`
+	EndSeconds   float64   `json:"end_seconds"`
+	Embedding    []float32 `json:"-"`
+}
+
+// String names the section for explanations, e.g. "intro 0:00–0:32".
+func (s Section) String() string {
+	span := clock(s.StartSeconds) + "–" + clock(s.EndSeconds)
+	if s.Label == "" {
+		return span
+	}
+	return s.Label + " " + span
+}
+
+func clock(seconds float64) string {
+	total := int(math.Round(seconds))
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
+// FindSimilarSections finds the tracks with a section most like section of
+// the query track. Vibe is matched between the sections' embeddings; tempo,
+// key and energy between the whole tracks. Each result reports its best
+// section; candidates without sections are skipped.
+func FindSimilarSections(query *TrackFeatures, section Section, candidates []*TrackFeatures, sections map[int64][]Section, limit int, weights Weights) []SimilarityResult {
+	if query == nil || len(section.Embedding) == 0 {
+		return nil
+	}
+
+	results := make([]SimilarityResult, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.TrackID == query.TrackID {
+			continue // Skip self
+		}
+		var best SimilarityResult
+		var match *Section
+		for i, s := range sections[candidate.TrackID] {
+			if r := scoreMatch(query, section.Embedding, candidate, s.Embedding, weights); match == nil || r.Score > best.Score {
+				best, match = r, &sections[candidate.TrackID][i]
+			}
+		}
+		if match == nil {
+			continue
+		}
+		best.Section = match
+		best.Explanation = fmt.Sprintf("%s matches the %s; %s", match, section, best.Explanation)
+		results = append(results, best)
+	}
+
+	// Sort by score descending
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	// Limit results
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
@@ -8,7 +8,9 @@ import (
 	"strings"
 	"time"
 
+	analyzerpb "github.com/cartomix/cancun/gen/go/analyzer"
 	"github.com/cartomix/cancun/gen/go/common"
+	"github.com/cartomix/cancun/internal/similarity"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -111,12 +113,30 @@ func AnalysisRecordFromProto(trackID int64, version int32, analysis *common.Trac
 		TransitionWindowsJSON: transitionJSON,
 		TempoMapJSON:          tempoMapJSON,
 		Embedding:             analysis.GetEmbedding(),
+		OpenL3Embedding:       similarity.FloatsToBytes(analysis.GetOpenl3Embedding().GetVector()),
+		OpenL3WindowCount:     analysis.GetOpenl3Embedding().GetWindowCount(),
 	}
 
 	return record, nil
 }
 
+// OpenL3WindowsFromProto converts an analyzer's per-window embeddings for
+// SaveOpenL3Windows.
+func OpenL3WindowsFromProto(windows []*analyzerpb.OpenL3Window) []similarity.Window {
+	out := make([]similarity.Window, len(windows))
+	for i, w := range windows {
+		out[i] = similarity.Window{
+			Index:           i,
+			StartSeconds:    w.GetStartSeconds(),
+			DurationSeconds: w.GetDurationSeconds(),
+			Embedding:       w.GetVector(),
+		}
+	}
+	return out
+}
+
 // UpsertAnalysis writes or updates an analysis row (identified by track_id + version).
+// A complete analysis also updates the embedding index, when there is one.
 func (d *DB) UpsertAnalysis(rec *AnalysisRecord) error {
 	_, err := d.db.Exec(`
 		INSERT INTO analyses (
@@ -156,8 +176,21 @@ func (d *DB) UpsertAnalysis(rec *AnalysisRecord) error {
 		rec.EnergyGlobal, rec.IntegratedLufs, rec.TruePeakDb,
 		rec.BeatgridJSON, rec.SectionsJSON, rec.CuePointsJSON, rec.EnergySegmentsJSON, rec.TransitionWindowsJSON, rec.TempoMapJSON,
 		rec.Embedding, rec.OpenL3Embedding, rec.OpenL3WindowCount)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if d.embeddings != nil && rec.Status == AnalysisStatusComplete {
+		if err := d.reindexEmbedding(rec.TrackID); err != nil {
+			d.logger.Warn("failed to update the embedding index", "track_id", rec.TrackID, "error", err)
+		}
+	}
+	if rec.Status == AnalysisStatusComplete {
+		if err := d.invalidateSimilarityGraph(rec.TrackID); err != nil {
+			d.logger.Warn("failed to invalidate the similarity graph", "track_id", rec.TrackID, "error", err)
+		}
+	}
+	return nil
 }
 
 // MarkAnalysisFailure records a failed analysis attempt with the given version.
@@ -241,6 +274,12 @@ func (rec *AnalysisRecord) ToProto(track *Track) (*common.TrackAnalysis, error)
 		},
 		Embedding: rec.Embedding,
 	}
+	if len(rec.OpenL3Embedding) > 0 {
+		analysis.Openl3Embedding = &common.OpenL3Embedding{
+			Vector:      similarity.BytesToFloats(rec.OpenL3Embedding),
+			WindowCount: rec.OpenL3WindowCount,
+		}
+	}
 
 	if rec.BeatgridJSON != "" {
 		analysis.Beatgrid = &common.Beatgrid{}
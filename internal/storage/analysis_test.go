@@ -1,13 +1,16 @@
 package storage
 
 import (
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/cartomix/cancun/gen/go/common"
+	"github.com/cartomix/cancun/internal/similarity"
 	"google.golang.org/protobuf/types/known/durationpb"
 )
 
@@ -125,3 +128,118 @@ func TestMigrationsApplied(t *testing.T) {
 		t.Fatalf("expected at least one migration row")
 	}
 }
+
+func TestOpenL3EmbeddingsRoundTrip(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	dir := t.TempDir()
+	db, err := Open(dir, logger)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	id, err := db.UpsertTrack(&Track{ContentHash: "vibe", Path: filepath.Join(dir, "vibe.wav"), FileModifiedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("upsert track: %v", err)
+	}
+	analysis := &common.TrackAnalysis{
+		Id:              &common.TrackId{ContentHash: "vibe"},
+		DurationSeconds: 120,
+		Openl3Embedding: &common.OpenL3Embedding{Vector: []float32{0.25, -0.5, 1}, WindowCount: 2},
+	}
+	record, err := AnalysisRecordFromProto(id, 1, analysis)
+	if err != nil {
+		t.Fatalf("record from proto: %v", err)
+	}
+	if err := db.UpsertAnalysis(record); err != nil {
+		t.Fatalf("upsert analysis: %v", err)
+	}
+
+	windows := []similarity.Window{
+		{Index: 0, StartSeconds: 0, DurationSeconds: 60, Embedding: []float32{1, 0, 0}},
+		{Index: 1, StartSeconds: 60, DurationSeconds: 60, Embedding: []float32{0, 1, 0}},
+	}
+	if err := db.SaveOpenL3Windows(id, 1, windows); err != nil {
+		t.Fatalf("save windows: %v", err)
+	}
+	if err := db.SaveOpenL3Windows(id, 1, windows); err != nil {
+		t.Fatalf("resave windows: %v", err)
+	}
+
+	loaded, err := db.LatestCompleteAnalysis(id)
+	if err != nil {
+		t.Fatalf("latest analysis: %v", err)
+	}
+	if got := loaded.GetOpenl3Embedding(); !slices.Equal(got.GetVector(), []float32{0.25, -0.5, 1}) || got.GetWindowCount() != 2 {
+		t.Errorf("openl3 embedding = %v", got)
+	}
+
+	got, err := db.GetOpenL3Windows(id)
+	if err != nil {
+		t.Fatalf("load windows: %v", err)
+	}
+	if len(got) != 2 || got[1].StartSeconds != 60 || !slices.Equal(got[1].Embedding, []float32{0, 1, 0}) {
+		t.Errorf("windows = %+v", got)
+	}
+}
+
+func TestSectionEmbeddings(t *testing.T) {
+	db := openTestDB(t)
+	id, err := db.UpsertTrack(&Track{ContentHash: "sections", Path: "/music/sections.wav"})
+	if err != nil {
+		t.Fatalf("upsert track: %v", err)
+	}
+	// 16 beats at 120 BPM: the intro is the first 4 seconds, the outro the last 4.
+	beats := make([]*common.BeatMarker, 16)
+	for i := range beats {
+		beats[i] = &common.BeatMarker{Index: int32(i), Time: durationpb.New(time.Duration(i) * 500 * time.Millisecond)}
+	}
+	analysis := &common.TrackAnalysis{
+		Id:              &common.TrackId{ContentHash: "sections"},
+		DurationSeconds: 8,
+		Beatgrid:        &common.Beatgrid{Beats: beats, TempoMap: []*common.TempoMapNode{{Bpm: 120}}},
+		Sections: []*common.Section{
+			{StartBeat: 0, EndBeat: 8, Label: common.SectionLabel_INTRO},
+			{StartBeat: 8, EndBeat: 16, Label: common.SectionLabel_OUTRO},
+		},
+	}
+	record, err := AnalysisRecordFromProto(id, 1, analysis)
+	if err != nil {
+		t.Fatalf("record from proto: %v", err)
+	}
+	if err := db.UpsertAnalysis(record); err != nil {
+		t.Fatalf("upsert analysis: %v", err)
+	}
+	var windows []similarity.Window
+	for i := range 8 {
+		embedding := []float32{1, 0}
+		if i >= 4 {
+			embedding = []float32{0, 1}
+		}
+		windows = append(windows, similarity.Window{Index: i, StartSeconds: float64(i), DurationSeconds: 1, Embedding: embedding})
+	}
+	if err := db.SaveOpenL3Windows(id, 1, windows); err != nil {
+		t.Fatalf("save windows: %v", err)
+	}
+
+	sections, err := db.GetTrackSections(id, "")
+	if err != nil || len(sections) != 2 {
+		t.Fatalf("sections = %+v, %v", sections, err)
+	}
+	if outro := sections[1]; outro.Label != "outro" || outro.StartSeconds != 4 || outro.EndSeconds != 8 || !slices.Equal(outro.Embedding, []float32{0, 1}) {
+		t.Errorf("outro = %+v", outro)
+	}
+
+	if s, err := db.ResolveSection(id, "intro", 0, 0); err != nil || !slices.Equal(s.Embedding, []float32{1, 0}) {
+		t.Errorf("intro = %+v, %v", s, err)
+	}
+	if s, err := db.ResolveSection(id, "", 3, 5); err != nil || !slices.Equal(s.Embedding, []float32{0.5, 0.5}) {
+		t.Errorf("3-5s = %+v, %v", s, err)
+	}
+	if _, err := db.ResolveSection(id, "drop", 0, 0); !errors.Is(err, ErrNoSectionEmbedding) {
+		t.Errorf("missing drop: %v", err)
+	}
+	if _, err := db.ResolveSection(id, "", 0, 0); !errors.Is(err, ErrInvalidSection) {
+		t.Errorf("no section: %v", err)
+	}
+}
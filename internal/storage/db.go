@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -17,8 +18,11 @@ var migrationsFS embed.FS
 
 // DB wraps the SQLite database connection.
 type DB struct {
-	db     *sql.DB
-	logger *slog.Logger
+	db         *sql.DB
+	logger     *slog.Logger
+	embeddings *embeddingIndex // nil unless EnableEmbeddingIndex was called
+	graphMu    sync.Mutex      // orders refreshing the similarity graph with invalidating it
+	jobQueued  func()          // nil unless OnJobQueued was called
 }
 
 // Open opens the SQLite database at the given path and runs migrations.
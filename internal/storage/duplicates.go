@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/cartomix/cancun/internal/duplicates"
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+// GetDuplicateCandidates fetches every present, analyzed track with the fields
+// duplicate detection compares. Tracks flagged missing are left out so a group's
+// preferred member is always playable.
+func (d *DB) GetDuplicateCandidates() ([]*duplicates.Candidate, error) {
+	rows, err := d.db.Query(`
+		SELECT t.id, t.content_hash, t.path, t.title, t.artist, COALESCE(t.file_size, 0),
+		       COALESCE(a.duration_seconds, 0), COALESCE(a.bpm, 0), COALESCE(a.key_value, ''),
+		       COALESCE(a.openl3_embedding, X'')
+		FROM tracks t
+		INNER JOIN analyses a ON a.id = (
+			SELECT id FROM analyses a2
+			WHERE a2.track_id = t.id AND a2.status = 'complete'
+			ORDER BY a2.version DESC LIMIT 1
+		)
+		WHERE t.missing_at IS NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*duplicates.Candidate
+	for rows.Next() {
+		var c duplicates.Candidate
+		var title, artist sql.NullString
+		var embedding []byte
+
+		if err := rows.Scan(
+			&c.TrackID, &c.ContentHash, &c.Path, &title, &artist, &c.FileSize,
+			&c.DurationSeconds, &c.BPM, &c.Key,
+			&embedding,
+		); err != nil {
+			return nil, err
+		}
+
+		c.Title = title.String
+		c.Artist = artist.String
+		c.Embedding = similarity.BytesToFloats(embedding)
+		results = append(results, &c)
+	}
+
+	return results, rows.Err()
+}
+
+// FindDuplicates groups the library's duplicate tracks.
+func (d *DB) FindDuplicates(opts duplicates.Options) ([]duplicates.Group, error) {
+	candidates, err := d.GetDuplicateCandidates()
+	if err != nil {
+		return nil, err
+	}
+	return duplicates.Find(candidates, opts), nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cartomix/cancun/internal/duplicates"
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	db := openTestDB(t)
+	dir := t.TempDir()
+
+	emb := make([]float32, similarity.EmbeddingDim)
+	for i := range emb {
+		emb[i] = float32(i%7) - 3
+	}
+
+	add := func(hash, name string, size int64, analyzed bool) int64 {
+		t.Helper()
+		id, err := db.UpsertTrack(&Track{
+			ContentHash:    hash,
+			Path:           filepath.Join(dir, name),
+			Title:          "Night Drive",
+			Artist:         "Kova",
+			FileSize:       size,
+			FileModifiedAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("upsert track: %v", err)
+		}
+		if analyzed {
+			if err := db.UpsertAnalysis(&AnalysisRecord{
+				TrackID:         id,
+				Version:         1,
+				Status:          AnalysisStatusComplete,
+				DurationSeconds: 360,
+				BPM:             124,
+				KeyValue:        "8A",
+				OpenL3Embedding: similarity.FloatsToBytes(emb),
+			}); err != nil {
+				t.Fatalf("upsert analysis: %v", err)
+			}
+		}
+		return id
+	}
+
+	mp3 := add("h-mp3", "a.mp3", 14_400_000, true)
+	flac := add("h-flac", "a.flac", 40_000_000, true)
+	wav := add("h-wav", "a.wav", 63_000_000, true)
+	add("h-unanalyzed", "b.mp3", 14_400_000, false)
+	if err := db.SetTrackMissing(wav, true); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := db.FindDuplicates(duplicates.Options{})
+	if err != nil {
+		t.Fatalf("find duplicates: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("groups = %d, want 1", len(groups))
+	}
+	g := groups[0]
+	if g.Preferred != flac || len(g.Members) != 2 || g.Members[1].TrackID != mp3 {
+		t.Fatalf("group = %+v, want FLAC preferred over MP3 and the missing WAV left out", g)
+	}
+	if g.Members[1].Format != "mp3" || g.Members[1].BitrateKbps < 319 || g.Members[1].BitrateKbps > 321 {
+		t.Fatalf("mp3 member = %+v", g.Members[1])
+	}
+}
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cartomix/cancun/internal/ann"
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+// embeddingIndex is the nearest neighbour index over the OpenL3 embeddings of
+// every track's latest complete analysis.
+type embeddingIndex struct {
+	idx   *ann.Index
+	mu    sync.Mutex  // orders reading a track's embedding with indexing it
+	ready atomic.Bool // set once the index has caught up with the database
+}
+
+// EnableEmbeddingIndex has UpsertAnalysis keep idx up to date from now on.
+// Similarity searches use it once SyncEmbeddingIndex has caught it up with
+// what is already stored; until then they compare every track.
+func (d *DB) EnableEmbeddingIndex(idx *ann.Index) {
+	d.embeddings = &embeddingIndex{idx: idx}
+}
+
+// SyncEmbeddingIndex catches the index up with the database: it indexes the
+// tracks it lacks and those analyzed since since, and drops tracks that no
+// longer have an embedding. Pass the zero time to reindex every track. It is
+// safe to run while analyses are being stored.
+func (d *DB) SyncEmbeddingIndex(ctx context.Context, since time.Time) (indexed, dropped int, err error) {
+	e := d.embeddings
+	if e == nil {
+		return 0, 0, nil
+	}
+
+	rows, err := d.db.Query(`
+		SELECT a.track_id, a.updated_at
+		FROM analyses a
+		WHERE a.id = (
+			SELECT id FROM analyses a2
+			WHERE a2.track_id = a.track_id AND a2.status = 'complete'
+			ORDER BY a2.version DESC LIMIT 1
+		)
+		AND a.openl3_embedding IS NOT NULL AND LENGTH(a.openl3_embedding) > 0
+	`)
+	if err != nil {
+		return 0, 0, err
+	}
+	stored := make(map[int64]bool)
+	var stale []int64
+	for rows.Next() {
+		var trackID int64
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&trackID, &updatedAt); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		stored[trackID] = true
+		// updated_at has whole seconds, so anything in the second before
+		// since may be newer than the index too.
+		if !e.idx.Contains(trackID) || !updatedAt.Valid || !updatedAt.Time.Before(since.Add(-time.Second)) {
+			stale = append(stale, trackID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	for _, key := range e.idx.Keys() {
+		if !stored[key] {
+			stale = append(stale, key)
+			dropped++
+		}
+	}
+	for _, trackID := range stale {
+		if err := ctx.Err(); err != nil {
+			return indexed, dropped, err
+		}
+		if err := d.reindexEmbedding(trackID); err != nil {
+			d.logger.Warn("failed to index embedding", "track_id", trackID, "error", err)
+		}
+	}
+	e.ready.Store(true)
+	return len(stale) - dropped, dropped, nil
+}
+
+// reindexEmbedding indexes the embedding of a track's latest complete
+// analysis, or drops the track from the index when it has none.
+func (d *DB) reindexEmbedding(trackID int64) error {
+	e := d.embeddings
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var embedding []byte
+	err := d.db.QueryRow(`
+		SELECT COALESCE(openl3_embedding, X'') FROM analyses
+		WHERE track_id = ? AND status = 'complete'
+		ORDER BY version DESC LIMIT 1
+	`, trackID).Scan(&embedding)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if len(embedding) == 0 {
+		e.idx.Remove(trackID)
+		return nil
+	}
+	return e.idx.Add(trackID, similarity.BytesToFloats(embedding))
+}
+
+// SimilarityCandidates returns the tracks to score a similarity query against:
+// the k nearest to it by OpenL3 embedding, from the index, or every other
+// analyzed track when exact is set, there is no index ready, or the query has
+// no embedding.
+func (d *DB) SimilarityCandidates(query *similarity.TrackFeatures, k int, exact bool) ([]*similarity.TrackFeatures, error) {
+	var near [][]float32
+	if len(query.OpenL3Embedding) > 0 {
+		near = append(near, similarity.BytesToFloats(query.OpenL3Embedding))
+	}
+	return d.SimilarityCandidatesNear(near, k, []int64{query.TrackID}, exact)
+}
+
+// SimilarityCandidatesNear returns the tracks to score a query seeded with
+// several embeddings against: the k nearest to each of near, from the index,
+// leaving out the exclude tracks. It returns every other analyzed track when
+// exact is set, there is no index ready, or near is empty.
+func (d *DB) SimilarityCandidatesNear(near [][]float32, k int, exclude []int64, exact bool) ([]*similarity.TrackFeatures, error) {
+	e := d.embeddings
+	if exact || e == nil || !e.ready.Load() || len(near) == 0 {
+		return d.GetTrackFeaturesExcluding(exclude)
+	}
+
+	excluded := make(map[int64]bool, len(exclude))
+	for _, id := range exclude {
+		excluded[id] = true
+	}
+	seen := make(map[int64]bool)
+	var ids []int64
+	for _, vec := range near {
+		neighbors, err := e.idx.Search(vec, k+len(exclude))
+		if err != nil {
+			d.logger.Warn("embedding index search failed, comparing every track", "exclude", exclude, "error", err)
+			return d.GetTrackFeaturesExcluding(exclude)
+		}
+		found := 0
+		for _, n := range neighbors {
+			if excluded[n.Key] || found == k {
+				continue
+			}
+			found++
+			if !seen[n.Key] {
+				seen[n.Key] = true
+				ids = append(ids, n.Key)
+			}
+		}
+	}
+	return d.getTrackFeatures(ids)
+}
+
+// featureQueryChunk is how many track ids getTrackFeatures looks up per query,
+// well under SQLite's limit on query parameters.
+const featureQueryChunk = 500
+
+// getTrackFeatures fetches features for the given tracks, in the given order.
+func (d *DB) getTrackFeatures(ids []int64) ([]*similarity.TrackFeatures, error) {
+	byID := make(map[int64]*similarity.TrackFeatures, len(ids))
+	for start := 0; start < len(ids); start += featureQueryChunk {
+		chunk := ids[start:min(start+featureQueryChunk, len(ids))]
+		if err := d.loadTrackFeatures(chunk, byID); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]*similarity.TrackFeatures, 0, len(byID))
+	for _, id := range ids {
+		if f, ok := byID[id]; ok {
+			results = append(results, f)
+		}
+	}
+	return results, nil
+}
+
+func (d *DB) loadTrackFeatures(ids []int64, byID map[int64]*similarity.TrackFeatures) error {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := d.db.Query(`
+		SELECT t.id, t.content_hash, t.title, t.artist,
+		       COALESCE(a.bpm, 0), COALESCE(a.key_value, ''), COALESCE(a.energy_global, 5),
+		       COALESCE(a.openl3_embedding, X'')
+		FROM tracks t
+		INNER JOIN analyses a ON a.id = (
+			SELECT id FROM analyses a2
+			WHERE a2.track_id = t.id AND a2.status = 'complete'
+			ORDER BY a2.version DESC LIMIT 1
+		)
+		WHERE a.openl3_embedding IS NOT NULL
+		  AND LENGTH(a.openl3_embedding) > 0
+		  AND t.id IN (?`+strings.Repeat(",?", len(ids)-1)+`)`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var features similarity.TrackFeatures
+		var title, artist sql.NullString
+		if err := rows.Scan(
+			&features.TrackID, &features.ContentHash, &title, &artist,
+			&features.BPM, &features.KeyValue, &features.Energy,
+			&features.OpenL3Embedding,
+		); err != nil {
+			return err
+		}
+		features.Title, features.Artist = title.String, artist.String
+		byID[features.TrackID] = &features
+	}
+	return rows.Err()
+}
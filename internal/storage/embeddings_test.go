@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cartomix/cancun/internal/ann"
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+func TestEmbeddingIndex(t *testing.T) {
+	db := openTestDB(t)
+	// Track i points along its own axis with a shared tilt towards the first,
+	// so track 1's nearest neighbours are tracks 2, 3, ... in order.
+	embedding := func(i int) []float32 {
+		v := make([]float32, similarity.EmbeddingDim)
+		v[0] = 1
+		v[i] = float32(i) / 10
+		return v
+	}
+	store := func(trackID int64, version int32, vec []float32) {
+		t.Helper()
+		rec := &AnalysisRecord{
+			TrackID:         trackID,
+			Version:         version,
+			Status:          AnalysisStatusComplete,
+			OpenL3Embedding: similarity.FloatsToBytes(vec),
+		}
+		if err := db.UpsertAnalysis(rec); err != nil {
+			t.Fatalf("upsert analysis: %v", err)
+		}
+	}
+	ids := make([]int64, 0, 6)
+	for i := 1; i <= 6; i++ {
+		id, err := db.UpsertTrack(&Track{ContentHash: fmt.Sprintf("h%d", i), Path: fmt.Sprintf("/music/%d.mp3", i)})
+		if err != nil {
+			t.Fatalf("upsert track: %v", err)
+		}
+		ids = append(ids, id)
+		if i <= 5 {
+			store(id, 1, embedding(i))
+		}
+	}
+
+	query, err := db.GetTrackFeaturesForSimilarity(ids[0])
+	if err != nil {
+		t.Fatalf("query features: %v", err)
+	}
+	trackIDs := func(features []*similarity.TrackFeatures) []int64 {
+		out := make([]int64, len(features))
+		for i, f := range features {
+			out[i] = f.TrackID
+		}
+		return out
+	}
+
+	idx := ann.New(similarity.EmbeddingDim, ann.Config{})
+	db.EnableEmbeddingIndex(idx)
+	// Not caught up yet: every other analyzed track is a candidate.
+	if got, err := db.SimilarityCandidates(query, 2, false); err != nil || len(got) != 4 {
+		t.Fatalf("before sync = %v, %v", trackIDs(got), err)
+	}
+
+	indexed, dropped, err := db.SyncEmbeddingIndex(context.Background(), time.Time{})
+	if err != nil || indexed != 5 || dropped != 0 {
+		t.Fatalf("sync = %d indexed, %d dropped, %v", indexed, dropped, err)
+	}
+	got, err := db.SimilarityCandidates(query, 2, false)
+	if err != nil {
+		t.Fatalf("candidates: %v", err)
+	}
+	if ids := trackIDs(got); len(ids) != 2 || ids[0] != query.TrackID+1 || ids[1] != query.TrackID+2 {
+		t.Errorf("nearest 2 = %v", ids)
+	}
+	if got, _ := db.SimilarityCandidates(query, 2, true); len(got) != 4 {
+		t.Errorf("exact search = %v, want every other track", trackIDs(got))
+	}
+	// Several seeds share a nearest neighbour; it is a candidate once.
+	got, err = db.SimilarityCandidatesNear([][]float32{embedding(1), embedding(5)}, 1, []int64{ids[0], ids[4]}, false)
+	if ids := trackIDs(got); err != nil || len(ids) != 1 || ids[0] != query.TrackID+1 {
+		t.Errorf("nearest to both seeds = %v, %v", ids, err)
+	}
+
+	// A new analysis updates the index as it is stored.
+	store(ids[5], 1, embedding(1))
+	if got, _ := db.SimilarityCandidates(query, 1, false); len(got) != 1 || got[0].TrackID != ids[5] {
+		t.Errorf("after storing a twin = %v", trackIDs(got))
+	}
+	if idx.Len() != 6 {
+		t.Errorf("index len = %d, want 6", idx.Len())
+	}
+
+	// An index saved earlier catches up with what changed since.
+	stale := ann.New(similarity.EmbeddingDim, ann.Config{})
+	stale.Add(ids[0], embedding(1))
+	stale.Add(999, embedding(2))
+	db.EnableEmbeddingIndex(stale)
+	indexed, dropped, err = db.SyncEmbeddingIndex(context.Background(), time.Now().Add(time.Hour))
+	if err != nil || indexed != 5 || dropped != 1 || stale.Len() != 6 || stale.Contains(999) {
+		t.Errorf("catch-up sync = %d indexed, %d dropped, %v; len %d", indexed, dropped, err, stale.Len())
+	}
+}
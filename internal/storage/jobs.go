@@ -55,10 +55,23 @@ func (d *DB) CreateJob(jobType JobType, priority int, payload map[string]any) (i
 	if err != nil {
 		return 0, err
 	}
+	d.notifyJobQueued()
 
 	return result.LastInsertId()
 }
 
+// OnJobQueued has fn called whenever a job is queued, so a worker pool can
+// pick it up without waiting for its next poll. Call it before jobs are queued.
+func (d *DB) OnJobQueued(fn func()) {
+	d.jobQueued = fn
+}
+
+func (d *DB) notifyJobQueued() {
+	if d.jobQueued != nil {
+		d.jobQueued()
+	}
+}
+
 // ClaimJob atomically claims the next pending job of the given type.
 func (d *DB) ClaimJob(jobType JobType) (*Job, error) {
 	tx, err := d.db.Begin()
@@ -71,9 +84,10 @@ func (d *DB) ClaimJob(jobType JobType) (*Job, error) {
 		SELECT id, type, status, priority, payload_json, attempts, max_attempts, created_at
 		FROM jobs
 		WHERE type = ? AND status = ? AND attempts < max_attempts
+		  AND (run_after IS NULL OR run_after <= ?)
 		ORDER BY priority DESC, created_at ASC
 		LIMIT 1
-	`, string(jobType), string(JobStatusPending))
+	`, string(jobType), string(JobStatusPending), time.Now())
 
 	job := &Job{}
 	var payloadJSON sql.NullString
@@ -150,6 +164,28 @@ func (d *DB) RetryJob(jobID int64) error {
 	return err
 }
 
+// RequeueJob returns a claimed job to the queue without counting the attempt,
+// for work interrupted before it could finish, e.g. by shutdown.
+func (d *DB) RequeueJob(jobID int64) error {
+	_, err := d.db.Exec(`
+		UPDATE jobs SET status = ?, attempts = MAX(attempts - 1, 0), updated_at = ?
+		WHERE id = ? AND status = ?
+	`, string(JobStatusPending), time.Now(), jobID, string(JobStatusRunning))
+
+	return err
+}
+
+// RetryJobAfter returns a job to the queue with the last error recorded,
+// making it claimable again only once runAfter has passed.
+func (d *DB) RetryJobAfter(jobID int64, errMsg string, runAfter time.Time) error {
+	_, err := d.db.Exec(`
+		UPDATE jobs SET status = ?, error = ?, run_after = ?, updated_at = ?
+		WHERE id = ? AND attempts < max_attempts
+	`, string(JobStatusPending), errMsg, runAfter, time.Now(), jobID)
+
+	return err
+}
+
 // GetPendingJobCount returns the count of pending jobs by type.
 func (d *DB) GetPendingJobCount(jobType JobType) (int, error) {
 	var count int
@@ -177,3 +213,18 @@ func (d *DB) ResetStalledJobs(timeout time.Duration) (int64, error) {
 
 	return result.RowsAffected()
 }
+
+// FailStalledJobs marks running jobs that have exhausted their attempts as failed.
+// ResetStalledJobs leaves these behind, so they would otherwise stay "running" forever.
+func (d *DB) FailStalledJobs(timeout time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-timeout)
+	result, err := d.db.Exec(`
+		UPDATE jobs SET status = ?, error = COALESCE(error, 'stalled'), updated_at = CURRENT_TIMESTAMP
+		WHERE status = ? AND started_at < ? AND attempts >= max_attempts
+	`, string(JobStatusFailed), string(JobStatusRunning), cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrLiveSessionEnded is returned when a play is recorded on an ended session.
+var ErrLiveSessionEnded = errors.New("live session has ended")
+
+// LiveSession is a set being played, with the plan request it started from.
+type LiveSession struct {
+	ID        int64
+	Request   []byte // serialized SetPlanRequest
+	StartedAt time.Time
+	EndedAt   time.Time // zero while the set is running
+	Plays     []LivePlay
+}
+
+// LivePlay is a track played during a live session. The last play of a
+// session is the track playing now.
+type LivePlay struct {
+	ContentHash string
+	StartedAt   time.Time
+}
+
+// Ended reports whether the session has been ended.
+func (s *LiveSession) Ended() bool {
+	return !s.EndedAt.IsZero()
+}
+
+// CreateLiveSession starts a live session for a serialized plan request.
+func (d *DB) CreateLiveSession(request []byte, startedAt time.Time) (*LiveSession, error) {
+	result, err := d.db.Exec("INSERT INTO live_sessions (request, started_at) VALUES (?, ?)", request, startedAt)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return d.GetLiveSession(id)
+}
+
+// GetLiveSession retrieves a live session with its plays in order. It returns
+// sql.ErrNoRows when there is no such session.
+func (d *DB) GetLiveSession(id int64) (*LiveSession, error) {
+	s := &LiveSession{}
+	var endedAt sql.NullTime
+	err := d.db.QueryRow("SELECT id, request, started_at, ended_at FROM live_sessions WHERE id = ?", id).
+		Scan(&s.ID, &s.Request, &s.StartedAt, &endedAt)
+	if err != nil {
+		return nil, err
+	}
+	if endedAt.Valid {
+		s.EndedAt = endedAt.Time
+	}
+
+	rows, err := d.db.Query("SELECT content_hash, started_at FROM live_plays WHERE session_id = ? ORDER BY position", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p LivePlay
+		if err := rows.Scan(&p.ContentHash, &p.StartedAt); err != nil {
+			return nil, err
+		}
+		s.Plays = append(s.Plays, p)
+	}
+	return s, rows.Err()
+}
+
+// AddLivePlay records that a track started playing in a live session. It
+// returns sql.ErrNoRows for an unknown session and ErrLiveSessionEnded for an
+// ended one.
+func (d *DB) AddLivePlay(sessionID int64, contentHash string, startedAt time.Time) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var endedAt sql.NullTime
+	if err := tx.QueryRow("SELECT ended_at FROM live_sessions WHERE id = ?", sessionID).Scan(&endedAt); err != nil {
+		return err
+	}
+	if endedAt.Valid {
+		return ErrLiveSessionEnded
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO live_plays (session_id, position, content_hash, started_at)
+		SELECT ?, COALESCE(MAX(position) + 1, 0), ?, ? FROM live_plays WHERE session_id = ?
+	`, sessionID, contentHash, startedAt, sessionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EndLiveSession marks a live session ended. Ending it again keeps the first
+// end time.
+func (d *DB) EndLiveSession(id int64, endedAt time.Time) error {
+	result, err := d.db.Exec("UPDATE live_sessions SET ended_at = COALESCE(ended_at, ?) WHERE id = ?", endedAt, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
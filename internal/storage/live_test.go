@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLiveSessionPlays(t *testing.T) {
+	db := openTestDB(t)
+	start := time.Date(2026, 3, 14, 22, 0, 0, 0, time.UTC)
+
+	s, err := db.CreateLiveSession([]byte("request"), start)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if string(s.Request) != "request" || !s.StartedAt.Equal(start) || s.Ended() || len(s.Plays) != 0 {
+		t.Fatalf("new session = %+v", s)
+	}
+
+	for i, hash := range []string{"a", "b", "c"} {
+		if err := db.AddLivePlay(s.ID, hash, start.Add(time.Duration(i)*5*time.Minute)); err != nil {
+			t.Fatalf("play %s: %v", hash, err)
+		}
+	}
+	got, err := db.GetLiveSession(s.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(got.Plays) != 3 || got.Plays[2].ContentHash != "c" || !got.Plays[1].StartedAt.Equal(start.Add(5*time.Minute)) {
+		t.Fatalf("plays = %+v", got.Plays)
+	}
+
+	// Play history follows a re-keyed track.
+	id, err := db.UpsertTrack(&Track{ContentHash: "b", Path: "/music/b.mp3"})
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if err := db.RekeyTrack(id, "b2", "", 2); err != nil {
+		t.Fatalf("rekey: %v", err)
+	}
+	if got, _ := db.GetLiveSession(s.ID); got.Plays[1].ContentHash != "b2" {
+		t.Errorf("play after rekey = %+v", got.Plays[1])
+	}
+
+	if err := db.EndLiveSession(s.ID, start.Add(time.Hour)); err != nil {
+		t.Fatalf("end: %v", err)
+	}
+	if err := db.EndLiveSession(s.ID, start.Add(2*time.Hour)); err != nil {
+		t.Fatalf("end again: %v", err)
+	}
+	if got, _ := db.GetLiveSession(s.ID); !got.EndedAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("ended at %v, want the first end time", got.EndedAt)
+	}
+	if err := db.AddLivePlay(s.ID, "d", start.Add(time.Hour)); !errors.Is(err, ErrLiveSessionEnded) {
+		t.Errorf("play after end: err = %v, want ErrLiveSessionEnded", err)
+	}
+	if err := db.AddLivePlay(s.ID+1, "d", start); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("play on unknown session: err = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := db.GetLiveSession(s.ID + 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("get unknown session: err = %v, want sql.ErrNoRows", err)
+	}
+}
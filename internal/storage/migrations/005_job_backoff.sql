@@ -0,0 +1,8 @@
+-- Migration 005: Retry backoff for the background job queue
+-- Failed jobs are rescheduled with run_after so workers don't hammer a broken track.
+
+ALTER TABLE jobs ADD COLUMN run_after DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_claim ON jobs(type, status, priority DESC, created_at);
+
+INSERT OR IGNORE INTO schema_migrations (version) VALUES (5);
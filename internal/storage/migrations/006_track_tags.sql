@@ -0,0 +1,13 @@
+-- Migration 006: Tag metadata read during library scans
+-- tag_bpm/tag_key keep what other tools wrote; analysis results stay in analyses.
+
+ALTER TABLE tracks ADD COLUMN genre TEXT;
+ALTER TABLE tracks ADD COLUMN label TEXT;
+ALTER TABLE tracks ADD COLUMN year INTEGER;
+ALTER TABLE tracks ADD COLUMN comment TEXT;
+ALTER TABLE tracks ADD COLUMN tag_bpm REAL;
+ALTER TABLE tracks ADD COLUMN tag_key TEXT;
+ALTER TABLE tracks ADD COLUMN artwork_hash TEXT;
+ALTER TABLE tracks ADD COLUMN tags_read_at DATETIME;
+
+INSERT OR IGNORE INTO schema_migrations (version) VALUES (6);
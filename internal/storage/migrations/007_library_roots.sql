@@ -0,0 +1,16 @@
+-- Migration 007: Watched library roots and missing-file tracking
+-- Tracks whose file disappears are kept (with their analyses and cue edits) and
+-- flagged via missing_at until the file shows up again under a new path.
+
+CREATE TABLE IF NOT EXISTS library_roots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    path TEXT NOT NULL UNIQUE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_scanned_at DATETIME
+);
+
+ALTER TABLE tracks ADD COLUMN missing_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_tracks_missing_at ON tracks(missing_at);
+
+INSERT OR IGNORE INTO schema_migrations (version) VALUES (7);
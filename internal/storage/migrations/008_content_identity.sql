@@ -0,0 +1,13 @@
+-- Migration 008: Audio-payload content identity
+-- content_hash now hashes the audio payload with tag headers skipped; file_hash is
+-- a SHA-256 of the whole file. Existing rows keep their first-64KB hash with
+-- hash_version 1 until the scanner re-keys them in place (track IDs, and with them
+-- analyses and cue edits, are preserved).
+
+ALTER TABLE tracks ADD COLUMN file_hash TEXT;
+ALTER TABLE tracks ADD COLUMN hash_version INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_tracks_file_hash ON tracks(file_hash);
+CREATE INDEX IF NOT EXISTS idx_tracks_hash_version ON tracks(hash_version);
+
+INSERT OR IGNORE INTO schema_migrations (version) VALUES (8);
@@ -0,0 +1,21 @@
+-- Migration 009: Live set sessions
+-- A live session keeps the set plan request it was started with, and every
+-- track played during the set with when it started. The last play is the one
+-- playing now.
+
+CREATE TABLE IF NOT EXISTS live_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    request BLOB NOT NULL,              -- serialized SetPlanRequest
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS live_plays (
+    session_id INTEGER NOT NULL REFERENCES live_sessions(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    content_hash TEXT NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (session_id, position)
+);
+
+INSERT OR IGNORE INTO schema_migrations (version) VALUES (9);
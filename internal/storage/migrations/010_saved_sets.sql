@@ -0,0 +1,31 @@
+-- Migration 010: Saved sets
+-- A saved set is a named, ordered list of tracks with the transitions planned
+-- between them. Every edit adds a version; earlier versions are kept as they
+-- were saved.
+
+CREATE TABLE IF NOT EXISTS saved_sets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version INTEGER NOT NULL DEFAULT 1,  -- latest version
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS saved_set_versions (
+    set_id INTEGER NOT NULL REFERENCES saved_sets(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (set_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS saved_set_tracks (
+    set_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    content_hash TEXT NOT NULL,
+    explanation TEXT,  -- EdgeExplanation (JSON) of the transition into this track
+    PRIMARY KEY (set_id, version, position),
+    FOREIGN KEY (set_id, version) REFERENCES saved_set_versions(set_id, version) ON DELETE CASCADE
+);
+
+INSERT OR IGNORE INTO schema_migrations (version) VALUES (10);
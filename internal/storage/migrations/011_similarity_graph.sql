@@ -0,0 +1,19 @@
+-- Migration 011: Precomputed similarity graph
+-- A background job fills embedding_similarity with each track's nearest
+-- neighbours. similarity_graph records what each track's list was computed
+-- from, so a list is only served while that still holds.
+
+ALTER TABLE embedding_similarity ADD COLUMN bpm_delta REAL;
+ALTER TABLE embedding_similarity ADD COLUMN key_relation TEXT;
+ALTER TABLE embedding_similarity ADD COLUMN energy_delta INTEGER;
+
+CREATE TABLE IF NOT EXISTS similarity_graph (
+    track_id INTEGER PRIMARY KEY REFERENCES tracks(id) ON DELETE CASCADE,
+    analysis_version INTEGER NOT NULL,  -- analysis the neighbours were scored against
+    settings TEXT NOT NULL,             -- threshold and weights they were scored with
+    neighbour_limit INTEGER NOT NULL,   -- most neighbours kept
+    truncated INTEGER NOT NULL DEFAULT 0, -- 1 if the threshold dropped some of them
+    computed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO schema_migrations (version) VALUES (11);
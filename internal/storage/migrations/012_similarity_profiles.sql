@@ -0,0 +1,10 @@
+-- Migration 012: Similarity weights and profiles
+-- The weights similarity search combines its matches with live in ml_settings
+-- as similarity_weight_<match>; named profiles as similarity_profile:<name>
+-- holding their weights as JSON. Two profiles to start from:
+
+INSERT OR IGNORE INTO ml_settings (setting_key, setting_value) VALUES
+    ('similarity_profile:vibe-first', '{"vibe":0.7,"tempo":0.1,"key":0.1,"energy":0.1}'),
+    ('similarity_profile:harmonic-first', '{"vibe":0.25,"tempo":0.25,"key":0.4,"energy":0.1}');
+
+INSERT OR IGNORE INTO schema_migrations (version) VALUES (12);
@@ -0,0 +1,17 @@
+-- Migration 013: Section embeddings
+-- Each labelled section of an analysis with the mean of the windowed OpenL3
+-- embeddings it spans, written alongside openl3_windows, so section-level
+-- similarity search reads a few rows per track instead of every window.
+
+CREATE TABLE IF NOT EXISTS openl3_sections (
+    track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+    analysis_version INTEGER NOT NULL,
+    section_index INTEGER NOT NULL,
+    label TEXT NOT NULL,             -- intro, verse, breakdown, build, drop, outro
+    start_seconds REAL NOT NULL,
+    end_seconds REAL NOT NULL,
+    embedding BLOB NOT NULL,         -- 512 x float32
+    PRIMARY KEY (track_id, analysis_version, section_index)
+);
+
+INSERT OR IGNORE INTO schema_migrations (version) VALUES (13);
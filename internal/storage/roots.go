@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// LibraryRoot is a folder the engine keeps in sync with the tracks table.
+type LibraryRoot struct {
+	ID            int64
+	Path          string
+	CreatedAt     time.Time
+	LastScannedAt time.Time // zero until the first full scan finishes
+}
+
+// AddLibraryRoot persists a library root. Adding an existing path returns it unchanged.
+func (d *DB) AddLibraryRoot(path string) (*LibraryRoot, error) {
+	if _, err := d.db.Exec("INSERT OR IGNORE INTO library_roots (path) VALUES (?)", path); err != nil {
+		return nil, err
+	}
+	return scanLibraryRoot(d.db.QueryRow("SELECT id, path, created_at, last_scanned_at FROM library_roots WHERE path = ?", path))
+}
+
+// GetLibraryRoot retrieves a library root by ID.
+func (d *DB) GetLibraryRoot(id int64) (*LibraryRoot, error) {
+	return scanLibraryRoot(d.db.QueryRow("SELECT id, path, created_at, last_scanned_at FROM library_roots WHERE id = ?", id))
+}
+
+// GetLibraryRootByPath retrieves a library root by its absolute path.
+func (d *DB) GetLibraryRootByPath(path string) (*LibraryRoot, error) {
+	return scanLibraryRoot(d.db.QueryRow("SELECT id, path, created_at, last_scanned_at FROM library_roots WHERE path = ?", path))
+}
+
+// ListLibraryRoots returns all library roots in the order they were added.
+func (d *DB) ListLibraryRoots() ([]*LibraryRoot, error) {
+	rows, err := d.db.Query("SELECT id, path, created_at, last_scanned_at FROM library_roots ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roots []*LibraryRoot
+	for rows.Next() {
+		root, err := scanLibraryRoot(rows)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+	return roots, rows.Err()
+}
+
+// RemoveLibraryRoot deletes a library root. Its tracks are left in place.
+func (d *DB) RemoveLibraryRoot(id int64) error {
+	result, err := d.db.Exec("DELETE FROM library_roots WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// MarkLibraryRootScanned records that a full scan of the root finished.
+func (d *DB) MarkLibraryRootScanned(id int64) error {
+	_, err := d.db.Exec("UPDATE library_roots SET last_scanned_at = CURRENT_TIMESTAMP WHERE id = ?", id)
+	return err
+}
+
+func scanLibraryRoot(row interface{ Scan(...any) error }) (*LibraryRoot, error) {
+	root := &LibraryRoot{}
+	var createdAt, lastScannedAt sql.NullTime
+	if err := row.Scan(&root.ID, &root.Path, &createdAt, &lastScannedAt); err != nil {
+		return nil, err
+	}
+	if createdAt.Valid {
+		root.CreatedAt = createdAt.Time
+	}
+	if lastScannedAt.Valid {
+		root.LastScannedAt = lastScannedAt.Time
+	}
+	return root, nil
+}
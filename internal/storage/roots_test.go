@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLibraryRoots(t *testing.T) {
+	db := openTestDB(t)
+
+	a, err := db.AddLibraryRoot("/music/a")
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := db.AddLibraryRoot("/music/b"); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	again, err := db.AddLibraryRoot("/music/a")
+	if err != nil || again.ID != a.ID {
+		t.Fatalf("re-adding a root returned %+v, %v; want id %d", again, err, a.ID)
+	}
+	if !a.LastScannedAt.IsZero() {
+		t.Fatalf("new root already scanned at %v", a.LastScannedAt)
+	}
+
+	if err := db.MarkLibraryRootScanned(a.ID); err != nil {
+		t.Fatalf("mark scanned: %v", err)
+	}
+	got, err := db.GetLibraryRootByPath("/music/a")
+	if err != nil || got.LastScannedAt.IsZero() {
+		t.Fatalf("scanned root = %+v, %v", got, err)
+	}
+
+	if err := db.RemoveLibraryRoot(a.ID); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := db.RemoveLibraryRoot(a.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("removing twice: err = %v, want sql.ErrNoRows", err)
+	}
+	roots, err := db.ListLibraryRoots()
+	if err != nil || len(roots) != 1 || roots[0].Path != "/music/b" {
+		t.Fatalf("roots = %+v, %v", roots, err)
+	}
+}
+
+func TestMarkTracksMissing(t *testing.T) {
+	db := openTestDB(t)
+
+	modified := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for hash, path := range map[string]string{
+		"one":   "/music/house/one.mp3",
+		"two":   "/music/house/deep/two.mp3",
+		"other": "/music/housework.mp3",
+	} {
+		if _, err := db.UpsertTrack(&Track{ContentHash: hash, Path: path, FileModifiedAt: modified}); err != nil {
+			t.Fatalf("upsert %s: %v", hash, err)
+		}
+	}
+
+	n, err := db.MarkTracksMissing("/music/house")
+	if err != nil || n != 2 {
+		t.Fatalf("marked %d, err %v; want 2", n, err)
+	}
+	if other, _ := db.GetTrackByHash("other"); !other.MissingAt.IsZero() {
+		t.Fatal("sibling path sharing a prefix was marked missing")
+	}
+	under, err := db.TracksUnder("/music/house")
+	if err != nil || len(under) != 2 {
+		t.Fatalf("tracks under = %d, err %v", len(under), err)
+	}
+
+	// Seeing the file again clears the flag and keeps the same row.
+	one, _ := db.GetTrackByHash("one")
+	id, err := db.UpsertTrack(&Track{ContentHash: "one", Path: "/music/moved/one.mp3", FileModifiedAt: modified})
+	if err != nil || id != one.ID {
+		t.Fatalf("upsert returned id %d, err %v; want %d", id, err, one.ID)
+	}
+	if one, _ = db.GetTrackByID(id); !one.MissingAt.IsZero() || one.Path != "/music/moved/one.mp3" {
+		t.Fatalf("re-found track = %+v", one)
+	}
+}
+
+func TestMarkTracksMissingNonASCII(t *testing.T) {
+	db := openTestDB(t)
+
+	for hash, path := range map[string]string{
+		"jóga":  "/music/Björk/Homogenic/jóga.flac",
+		"army":  "/music/Björk/Post/army.flac",
+		"other": "/music/Björkish.flac",
+	} {
+		if _, err := db.UpsertTrack(&Track{ContentHash: hash, Path: path}); err != nil {
+			t.Fatalf("upsert %s: %v", hash, err)
+		}
+	}
+
+	// Prefix lengths are bytes; "ö" is two of them.
+	under, err := db.TracksUnder("/music/Björk")
+	if err != nil || len(under) != 2 {
+		t.Fatalf("tracks under = %d, err %v; want 2", len(under), err)
+	}
+	n, err := db.MarkTracksMissing("/music/Björk/")
+	if err != nil || n != 2 {
+		t.Fatalf("marked %d, err %v; want 2", n, err)
+	}
+	if other, _ := db.GetTrackByHash("other"); !other.MissingAt.IsZero() {
+		t.Fatal("sibling path sharing a prefix was marked missing")
+	}
+}
@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/common"
+)
+
+// ErrInvalidSavedSet is returned for a set that can't be saved as given.
+var ErrInvalidSavedSet = errors.New("invalid saved set")
+
+// SavedSet is one version of a saved set.
+type SavedSet struct {
+	ID      int64
+	Version int
+	Name    string
+	Notes   string
+	Tracks  []string // content hashes in play order
+	// Explanations[i] is the transition from Tracks[i] to Tracks[i+1], with its
+	// chosen mix points. It is empty when none were saved.
+	Explanations []*common.EdgeExplanation
+	CreatedAt    time.Time // when the first version was saved
+	UpdatedAt    time.Time // when this version was saved
+}
+
+// validate checks a set before it is saved.
+func (s *SavedSet) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSavedSet)
+	}
+	if len(s.Tracks) == 0 {
+		return fmt.Errorf("%w: tracks are required", ErrInvalidSavedSet)
+	}
+	if len(s.Explanations) == 0 {
+		return nil
+	}
+	if len(s.Explanations) != len(s.Tracks)-1 {
+		return fmt.Errorf("%w: %d tracks need %d explanations, got %d",
+			ErrInvalidSavedSet, len(s.Tracks), len(s.Tracks)-1, len(s.Explanations))
+	}
+	for i, e := range s.Explanations {
+		from, to := e.GetFrom().GetContentHash(), e.GetTo().GetContentHash()
+		if (from != "" && from != s.Tracks[i]) || (to != "" && to != s.Tracks[i+1]) {
+			return fmt.Errorf("%w: explanation %d is not the transition from %s to %s",
+				ErrInvalidSavedSet, i, s.Tracks[i], s.Tracks[i+1])
+		}
+	}
+	return nil
+}
+
+// CreateSavedSet saves a new set as its first version.
+func (d *DB) CreateSavedSet(s *SavedSet) (*SavedSet, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO saved_sets (version) VALUES (1)")
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := insertSavedSetVersion(tx, id, 1, s); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return d.GetSavedSet(id, 0)
+}
+
+// UpdateSavedSet saves s as the next version of the set with s.ID. It returns
+// sql.ErrNoRows when there is no such set.
+func (d *DB) UpdateSavedSet(s *SavedSet) (*SavedSet, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var version int
+	if err := tx.QueryRow("SELECT version FROM saved_sets WHERE id = ?", s.ID).Scan(&version); err != nil {
+		return nil, err
+	}
+	version++
+	if _, err := tx.Exec("UPDATE saved_sets SET version = ? WHERE id = ?", version, s.ID); err != nil {
+		return nil, err
+	}
+	if err := insertSavedSetVersion(tx, s.ID, version, s); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return d.GetSavedSet(s.ID, version)
+}
+
+func insertSavedSetVersion(tx *sql.Tx, id int64, version int, s *SavedSet) error {
+	if _, err := tx.Exec("INSERT INTO saved_set_versions (set_id, version, name, notes) VALUES (?, ?, ?, ?)",
+		id, version, s.Name, s.Notes); err != nil {
+		return err
+	}
+	for i, hash := range s.Tracks {
+		var explanation sql.NullString
+		if i > 0 && len(s.Explanations) > 0 {
+			data, err := marshalProto(s.Explanations[i-1])
+			if err != nil {
+				return err
+			}
+			explanation = sql.NullString{String: data, Valid: true}
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO saved_set_tracks (set_id, version, position, content_hash, explanation)
+			VALUES (?, ?, ?, ?, ?)
+		`, id, version, i, hash, explanation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSavedSet retrieves a version of a saved set with its transitions, the
+// latest when version is zero. It returns sql.ErrNoRows when there is no such
+// set or version.
+func (d *DB) GetSavedSet(id int64, version int) (*SavedSet, error) {
+	if version == 0 {
+		if err := d.db.QueryRow("SELECT version FROM saved_sets WHERE id = ?", id).Scan(&version); err != nil {
+			return nil, err
+		}
+	}
+	s, err := d.savedSetVersion(id, version)
+	if err != nil {
+		return nil, err
+	}
+	return s, d.loadSavedSetTracks(s, true)
+}
+
+// ListSavedSets returns the latest version of every saved set, most recently
+// edited first, without transitions.
+func (d *DB) ListSavedSets() ([]*SavedSet, error) {
+	return d.listSavedSets(`
+		SELECT s.id, v.version, v.name, v.notes, s.created_at, v.created_at
+		FROM saved_sets s JOIN saved_set_versions v ON v.set_id = s.id AND v.version = s.version
+		ORDER BY v.created_at DESC, s.id DESC
+	`)
+}
+
+// ListSavedSetVersions returns every version of a saved set, newest first,
+// without transitions. It returns sql.ErrNoRows when there is no such set.
+func (d *DB) ListSavedSetVersions(id int64) ([]*SavedSet, error) {
+	sets, err := d.listSavedSets(`
+		SELECT s.id, v.version, v.name, v.notes, s.created_at, v.created_at
+		FROM saved_sets s JOIN saved_set_versions v ON v.set_id = s.id
+		WHERE s.id = ?
+		ORDER BY v.version DESC
+	`, id)
+	if err == nil && len(sets) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return sets, err
+}
+
+// DeleteSavedSet deletes a saved set with all its versions.
+func (d *DB) DeleteSavedSet(id int64) error {
+	result, err := d.db.Exec("DELETE FROM saved_sets WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (d *DB) savedSetVersion(id int64, version int) (*SavedSet, error) {
+	s := &SavedSet{}
+	err := d.db.QueryRow(`
+		SELECT s.id, v.version, v.name, v.notes, s.created_at, v.created_at
+		FROM saved_sets s JOIN saved_set_versions v ON v.set_id = s.id
+		WHERE s.id = ? AND v.version = ?
+	`, id, version).Scan(&s.ID, &s.Version, &s.Name, &s.Notes, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (d *DB) listSavedSets(query string, args ...any) ([]*SavedSet, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var sets []*SavedSet
+	for rows.Next() {
+		s := &SavedSet{}
+		if err := rows.Scan(&s.ID, &s.Version, &s.Name, &s.Notes, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sets = append(sets, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, s := range sets {
+		if err := d.loadSavedSetTracks(s, false); err != nil {
+			return nil, err
+		}
+	}
+	return sets, nil
+}
+
+// loadSavedSetTracks reads the tracks of a set version, and its transitions
+// when withExplanations is set.
+func (d *DB) loadSavedSetTracks(s *SavedSet, withExplanations bool) error {
+	rows, err := d.db.Query(`
+		SELECT content_hash, explanation FROM saved_set_tracks
+		WHERE set_id = ? AND version = ? ORDER BY position
+	`, s.ID, s.Version)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash string
+		var explanation sql.NullString
+		if err := rows.Scan(&hash, &explanation); err != nil {
+			return err
+		}
+		s.Tracks = append(s.Tracks, hash)
+		if withExplanations && explanation.Valid {
+			e := &common.EdgeExplanation{}
+			if err := unmarshalProto(explanation.String, e); err != nil {
+				return fmt.Errorf("saved set %d transition into %s: %w", s.ID, hash, err)
+			}
+			// The tracks may have been re-keyed since the set was saved
+			if len(s.Tracks) > 1 {
+				if e.From != nil {
+					e.From.ContentHash = s.Tracks[len(s.Tracks)-2]
+				}
+				if e.To != nil {
+					e.To.ContentHash = hash
+				}
+			}
+			s.Explanations = append(s.Explanations, e)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/cartomix/cancun/gen/go/common"
+)
+
+func TestSavedSetVersions(t *testing.T) {
+	db := openTestDB(t)
+	edge := func(from, to string, outBeat int32) *common.EdgeExplanation {
+		return &common.EdgeExplanation{
+			From:       &common.TrackId{ContentHash: from},
+			To:         &common.TrackId{ContentHash: to},
+			Score:      4.5,
+			Suggestion: &common.TransitionSuggestion{OutBeat: outBeat, InBeat: 1, OverlapBeats: 64},
+		}
+	}
+
+	created, err := db.CreateSavedSet(&SavedSet{
+		Name:         "Friday",
+		Notes:        "warm-up slot",
+		Tracks:       []string{"a", "b", "c"},
+		Explanations: []*common.EdgeExplanation{edge("a", "b", 385), edge("b", "c", 449)},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.Version != 1 || created.Name != "Friday" || !slices.Equal(created.Tracks, []string{"a", "b", "c"}) {
+		t.Fatalf("created = %+v", created)
+	}
+	if len(created.Explanations) != 2 || created.Explanations[1].GetSuggestion().GetOutBeat() != 449 {
+		t.Fatalf("explanations = %v", created.Explanations)
+	}
+
+	updated, err := db.UpdateSavedSet(&SavedSet{ID: created.ID, Name: "Friday late", Tracks: []string{"c", "a"}})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Version != 2 || updated.Notes != "" || len(updated.Explanations) != 0 || !slices.Equal(updated.Tracks, []string{"c", "a"}) {
+		t.Fatalf("updated = %+v", updated)
+	}
+
+	first, err := db.GetSavedSet(created.ID, 1)
+	if err != nil {
+		t.Fatalf("get version 1: %v", err)
+	}
+	if first.Name != "Friday" || len(first.Explanations) != 2 {
+		t.Errorf("version 1 changed: %+v", first)
+	}
+	latest, err := db.GetSavedSet(created.ID, 0)
+	if err != nil || latest.Version != 2 {
+		t.Errorf("latest = %+v, %v", latest, err)
+	}
+
+	versions, err := db.ListSavedSetVersions(created.ID)
+	if err != nil {
+		t.Fatalf("versions: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 || versions[1].Version != 1 || len(versions[1].Tracks) != 3 {
+		t.Errorf("versions = %+v", versions)
+	}
+	sets, err := db.ListSavedSets()
+	if err != nil || len(sets) != 1 || sets[0].Version != 2 {
+		t.Errorf("sets = %+v, %v", sets, err)
+	}
+
+	if err := db.DeleteSavedSet(created.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := db.GetSavedSet(created.ID, 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("get after delete: %v", err)
+	}
+	if _, err := db.UpdateSavedSet(&SavedSet{ID: created.ID, Name: "x", Tracks: []string{"a"}}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("update after delete: %v", err)
+	}
+	if err := db.DeleteSavedSet(created.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("delete again: %v", err)
+	}
+}
+
+func TestSavedSetValidation(t *testing.T) {
+	db := openTestDB(t)
+	edge := &common.EdgeExplanation{From: &common.TrackId{ContentHash: "b"}, To: &common.TrackId{ContentHash: "a"}}
+
+	for name, s := range map[string]*SavedSet{
+		"no name":           {Tracks: []string{"a"}},
+		"no tracks":         {Name: "x"},
+		"too many edges":    {Name: "x", Tracks: []string{"a"}, Explanations: []*common.EdgeExplanation{edge}},
+		"edge out of order": {Name: "x", Tracks: []string{"a", "b"}, Explanations: []*common.EdgeExplanation{edge}},
+	} {
+		if _, err := db.CreateSavedSet(s); !errors.Is(err, ErrInvalidSavedSet) {
+			t.Errorf("%s: got %v, want ErrInvalidSavedSet", name, err)
+		}
+	}
+}
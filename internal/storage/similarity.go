@@ -2,8 +2,13 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/cartomix/cancun/gen/go/common"
 	"github.com/cartomix/cancun/internal/similarity"
+	"google.golang.org/protobuf/proto"
 )
 
 // GetTrackFeaturesForSimilarity fetches track features needed for similarity search.
@@ -150,14 +155,260 @@ func (d *DB) GetTrackFeaturesExcluding(excludeIDs []int64) ([]*similarity.TrackF
 	return results, rows.Err()
 }
 
-// CacheSimilarity stores a computed similarity result.
-func (d *DB) CacheSimilarity(trackAID, trackBID int64, openL3Sim, combinedScore, tempoSim, keySim, energySim float64, explanation string) error {
-	_, err := d.db.Exec(`
+// SaveOpenL3Windows replaces the windowed OpenL3 embeddings stored for one
+// analysis version of a track, along with the pooled embedding of each of
+// that analysis's sections. Store the analysis first.
+func (d *DB) SaveOpenL3Windows(trackID int64, version int32, windows []similarity.Window) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM openl3_windows WHERE track_id = ? AND analysis_version = ?`, trackID, version); err != nil {
+		return err
+	}
+	for _, w := range windows {
+		if _, err := tx.Exec(`
+			INSERT INTO openl3_windows (track_id, analysis_version, window_index, timestamp_seconds, duration_seconds, embedding)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, trackID, version, w.Index, w.StartSeconds, w.DurationSeconds, similarity.FloatsToBytes(w.Embedding)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM openl3_sections WHERE track_id = ? AND analysis_version = ?`, trackID, version); err != nil {
+		return err
+	}
+	sections, err := analysisSections(tx, trackID, version)
+	if err != nil {
+		return err
+	}
+	for i, s := range sections {
+		embedding := similarity.PoolWindows(windows, s.StartSeconds, s.EndSeconds)
+		if embedding == nil {
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO openl3_sections (track_id, analysis_version, section_index, label, start_seconds, end_seconds, embedding)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, trackID, version, i, s.Label, s.StartSeconds, s.EndSeconds, similarity.FloatsToBytes(embedding)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// analysisSections places the sections of one analysis version in time. It
+// returns none when the analysis isn't stored.
+func analysisSections(tx *sql.Tx, trackID int64, version int32) ([]similarity.Section, error) {
+	var bpm, duration float64
+	var beatgridJSON, sectionsJSON sql.NullString
+	err := tx.QueryRow(`
+		SELECT COALESCE(bpm, 0), COALESCE(duration_seconds, 0), beatgrid_json, sections_json
+		FROM analyses WHERE track_id = ? AND version = ?
+	`, trackID, version).Scan(&bpm, &duration, &beatgridJSON, &sectionsJSON)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	grid := &common.Beatgrid{}
+	if beatgridJSON.String != "" {
+		if err := unmarshalProto(beatgridJSON.String, grid); err != nil {
+			return nil, fmt.Errorf("unmarshal beatgrid: %w", err)
+		}
+	}
+	var sections []similarity.Section
+	if sectionsJSON.String != "" {
+		if err := unmarshalRepeated(sectionsJSON.String, func() proto.Message { return &common.Section{} }, func(msg proto.Message) {
+			s := msg.(*common.Section)
+			end := beatSeconds(grid, bpm, s.GetEndBeat())
+			if duration > 0 {
+				end = min(end, duration)
+			}
+			sections = append(sections, similarity.Section{
+				Label:        SectionLabelName(s.GetLabel()),
+				StartSeconds: beatSeconds(grid, bpm, s.GetStartBeat()),
+				EndSeconds:   end,
+			})
+		}); err != nil {
+			return nil, fmt.Errorf("unmarshal sections: %w", err)
+		}
+	}
+	return sections, nil
+}
+
+// beatSeconds places a beat in time by its marker, or else from the first
+// marker and the tempo.
+func beatSeconds(grid *common.Beatgrid, bpm float64, beat int32) float64 {
+	markers := grid.GetBeats()
+	if len(markers) == 0 || bpm <= 0 {
+		return 0
+	}
+	if int(beat) < len(markers) && markers[beat].GetIndex() == beat {
+		return markers[beat].GetTime().AsDuration().Seconds()
+	}
+	first := markers[0]
+	return first.GetTime().AsDuration().Seconds() + float64(beat-first.GetIndex())*60/bpm
+}
+
+// SectionLabelName is the lower-case name sections are stored and reported
+// under, e.g. "intro", or "" for SECTION_LABEL_UNSPECIFIED.
+func SectionLabelName(label common.SectionLabel) string {
+	if label == common.SectionLabel_SECTION_LABEL_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(label.String())
+}
+
+// ParseSectionLabel is the inverse of SectionLabelName.
+func ParseSectionLabel(name string) (common.SectionLabel, error) {
+	if name == "" {
+		return common.SectionLabel_SECTION_LABEL_UNSPECIFIED, nil
+	}
+	v, ok := common.SectionLabel_value[strings.ToUpper(name)]
+	if !ok || v == 0 {
+		return 0, fmt.Errorf("%w: unknown section %q", ErrInvalidSection, name)
+	}
+	return common.SectionLabel(v), nil
+}
+
+// GetOpenL3Windows returns the windowed OpenL3 embeddings of a track's latest
+// complete analysis, in time order.
+func (d *DB) GetOpenL3Windows(trackID int64) ([]similarity.Window, error) {
+	rows, err := d.db.Query(`
+		SELECT w.window_index, w.timestamp_seconds, w.duration_seconds, w.embedding
+		FROM openl3_windows w
+		WHERE w.track_id = ? AND w.analysis_version = (
+			SELECT version FROM analyses a
+			WHERE a.track_id = w.track_id AND a.status = 'complete'
+			ORDER BY a.version DESC LIMIT 1
+		)
+		ORDER BY w.window_index
+	`, trackID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []similarity.Window
+	for rows.Next() {
+		var w similarity.Window
+		var embedding []byte
+		if err := rows.Scan(&w.Index, &w.StartSeconds, &w.DurationSeconds, &embedding); err != nil {
+			return nil, err
+		}
+		w.Embedding = similarity.BytesToFloats(embedding)
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+// ErrInvalidSection is returned for a section query that names no section.
+var ErrInvalidSection = errors.New("invalid section")
+
+// ErrNoSectionEmbedding is returned when a track has no window embeddings
+// for the section asked about.
+var ErrNoSectionEmbedding = errors.New("no window embeddings for the section")
+
+// ResolveSection returns the part of a track's latest complete analysis a
+// section query compares: the from-to seconds time range when to is past
+// from, else the first section labelled label.
+func (d *DB) ResolveSection(trackID int64, label string, from, to float64) (*similarity.Section, error) {
+	if to > from {
+		windows, err := d.GetOpenL3Windows(trackID)
+		if err != nil {
+			return nil, err
+		}
+		embedding := similarity.PoolWindows(windows, from, to)
+		if embedding == nil {
+			return nil, ErrNoSectionEmbedding
+		}
+		return &similarity.Section{Label: label, StartSeconds: from, EndSeconds: to, Embedding: embedding}, nil
+	}
+	if label == "" {
+		return nil, fmt.Errorf("%w: a section label or time range is required", ErrInvalidSection)
+	}
+	sections, err := d.GetTrackSections(trackID, label)
+	if err != nil {
+		return nil, err
+	}
+	if len(sections) == 0 {
+		return nil, ErrNoSectionEmbedding
+	}
+	return &sections[0], nil
+}
+
+// GetTrackSections returns the sections of a track's latest complete
+// analysis that have embeddings, in time order. A label keeps only those.
+func (d *DB) GetTrackSections(trackID int64, label string) ([]similarity.Section, error) {
+	sections, err := d.GetSectionsForTracks([]int64{trackID}, label)
+	if err != nil {
+		return nil, err
+	}
+	return sections[trackID], nil
+}
+
+// GetSectionsForTracks returns the sections with embeddings of each track's
+// latest complete analysis, in time order. A label keeps only those.
+func (d *DB) GetSectionsForTracks(trackIDs []int64, label string) (map[int64][]similarity.Section, error) {
+	sections := make(map[int64][]similarity.Section, len(trackIDs))
+	for start := 0; start < len(trackIDs); start += featureQueryChunk {
+		chunk := trackIDs[start:min(start+featureQueryChunk, len(trackIDs))]
+		if err := d.loadSections(chunk, label, sections); err != nil {
+			return nil, err
+		}
+	}
+	return sections, nil
+}
+
+func (d *DB) loadSections(trackIDs []int64, label string, sections map[int64][]similarity.Section) error {
+	args := make([]any, 0, len(trackIDs)+2)
+	for _, id := range trackIDs {
+		args = append(args, id)
+	}
+	args = append(args, label, label)
+	rows, err := d.db.Query(`
+		SELECT s.track_id, s.label, s.start_seconds, s.end_seconds, s.embedding
+		FROM openl3_sections s
+		WHERE s.track_id IN (?`+strings.Repeat(",?", len(trackIDs)-1)+`)
+		  AND (? = '' OR s.label = ?)
+		  AND s.analysis_version = (
+			SELECT version FROM analyses a
+			WHERE a.track_id = s.track_id AND a.status = 'complete'
+			ORDER BY a.version DESC LIMIT 1
+		  )
+		ORDER BY s.track_id, s.section_index
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trackID int64
+		var s similarity.Section
+		var embedding []byte
+		if err := rows.Scan(&trackID, &s.Label, &s.StartSeconds, &s.EndSeconds, &embedding); err != nil {
+			return err
+		}
+		s.Embedding = similarity.BytesToFloats(embedding)
+		sections[trackID] = append(sections[trackID], s)
+	}
+	return rows.Err()
+}
+
+// cacheSimilarity stores the similarity of track b to track a.
+func cacheSimilarity(tx *sql.Tx, trackAID int64, r similarity.SimilarityResult) error {
+	_, err := tx.Exec(`
 		INSERT INTO embedding_similarity (
 			track_a_id, track_b_id, openl3_similarity, combined_score,
 			tempo_similarity, key_similarity, energy_similarity, explanation,
-			computed_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+			bpm_delta, key_relation, energy_delta, computed_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 		ON CONFLICT(track_a_id, track_b_id) DO UPDATE SET
 			openl3_similarity = excluded.openl3_similarity,
 			combined_score = excluded.combined_score,
@@ -165,17 +416,24 @@ func (d *DB) CacheSimilarity(trackAID, trackBID int64, openL3Sim, combinedScore,
 			key_similarity = excluded.key_similarity,
 			energy_similarity = excluded.energy_similarity,
 			explanation = excluded.explanation,
+			bpm_delta = excluded.bpm_delta,
+			key_relation = excluded.key_relation,
+			energy_delta = excluded.energy_delta,
 			computed_at = CURRENT_TIMESTAMP
-	`, trackAID, trackBID, openL3Sim, combinedScore, tempoSim, keySim, energySim, explanation)
+	`, trackAID, r.TrackID, r.VibeMatch/100, r.Score,
+		r.TempoMatch/100, r.KeyMatch/100, r.EnergyMatch/100, r.Explanation,
+		r.BPMDelta, r.KeyRelation, r.EnergyDelta)
 	return err
 }
 
-// GetCachedSimilarTracks returns cached similar tracks for a given track.
-func (d *DB) GetCachedSimilarTracks(trackID int64, limit int) ([]*similarity.SimilarityResult, error) {
+// GetCachedSimilarTracks returns cached similar tracks for a given track, most
+// similar first. Use CachedSimilarTracks to only get them while they are fresh.
+func (d *DB) GetCachedSimilarTracks(trackID int64, limit int) ([]similarity.SimilarityResult, error) {
 	rows, err := d.db.Query(`
 		SELECT s.track_b_id, t.content_hash, t.title, t.artist,
 		       s.combined_score, s.openl3_similarity, s.tempo_similarity,
-		       s.key_similarity, s.energy_similarity, s.explanation
+		       s.key_similarity, s.energy_similarity, s.explanation,
+		       COALESCE(s.bpm_delta, 0), COALESCE(s.key_relation, ''), COALESCE(s.energy_delta, 0)
 		FROM embedding_similarity s
 		JOIN tracks t ON t.id = s.track_b_id
 		WHERE s.track_a_id = ?
@@ -187,7 +445,7 @@ func (d *DB) GetCachedSimilarTracks(trackID int64, limit int) ([]*similarity.Sim
 	}
 	defer rows.Close()
 
-	var results []*similarity.SimilarityResult
+	var results []similarity.SimilarityResult
 	for rows.Next() {
 		var r similarity.SimilarityResult
 		var title, artist, explanation sql.NullString
@@ -196,6 +454,7 @@ func (d *DB) GetCachedSimilarTracks(trackID int64, limit int) ([]*similarity.Sim
 			&r.TrackID, &r.ContentHash, &title, &artist,
 			&r.Score, &r.VibeMatch, &r.TempoMatch,
 			&r.KeyMatch, &r.EnergyMatch, &explanation,
+			&r.BPMDelta, &r.KeyRelation, &r.EnergyDelta,
 		); err != nil {
 			return nil, err
 		}
@@ -209,8 +468,13 @@ func (d *DB) GetCachedSimilarTracks(trackID int64, limit int) ([]*similarity.Sim
 		if explanation.Valid {
 			r.Explanation = explanation.String
 		}
+		// Component matches are stored 0-1 and reported as percentages.
+		r.VibeMatch *= 100
+		r.TempoMatch *= 100
+		r.KeyMatch *= 100
+		r.EnergyMatch *= 100
 
-		results = append(results, &r)
+		results = append(results, r)
 	}
 
 	return results, rows.Err()
@@ -245,5 +509,12 @@ func (d *DB) SetMLSetting(key, value string) error {
 			setting_value = excluded.setting_value,
 			updated_at = CURRENT_TIMESTAMP
 	`, key, value)
-	return err
+	if err != nil {
+		return err
+	}
+	if similarityGraphSettingKeys[key] {
+		// The graph was scored with the old value; rebuild it.
+		return d.EnqueueSimilarityGraphRefresh()
+	}
+	return nil
 }
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+// JobTypeSimilarityGraph refreshes the similarity graph of every track whose
+// neighbours are stale. It takes no payload.
+const JobTypeSimilarityGraph JobType = "similarity_graph"
+
+// SimilarityGraphNeighbours is how many nearest neighbours the similarity
+// graph keeps per track.
+const SimilarityGraphNeighbours = 50
+
+// DefaultSimilarityThreshold is the lowest combined score the similarity graph
+// keeps when MLSettings has no similarity_threshold.
+const DefaultSimilarityThreshold = 0.5
+
+// similarityGraphSettingKeys are the ML settings the graph is scored with.
+var similarityGraphSettingKeys = map[string]bool{
+	"similarity_threshold": true,
+	settingWeightVibe:      true,
+	settingWeightTempo:     true,
+	settingWeightKey:       true,
+	settingWeightEnergy:    true,
+}
+
+// similarityGraphSettings returns the score threshold the graph keeps
+// neighbours at or above, the weights it scores them with, and a fingerprint
+// of both.
+func (d *DB) similarityGraphSettings() (threshold float64, weights similarity.Weights, fingerprint string, err error) {
+	settings, err := d.GetMLSettings()
+	if err != nil {
+		return 0, similarity.Weights{}, "", err
+	}
+	threshold = DefaultSimilarityThreshold
+	if v, err := strconv.ParseFloat(settings["similarity_threshold"], 64); err == nil {
+		threshold = v
+	}
+	weights = similarityWeightsFrom(settings)
+	fingerprint = fmt.Sprintf("threshold=%.2f vibe=%g tempo=%g key=%g energy=%g",
+		threshold, weights.Vibe, weights.Tempo, weights.Key, weights.Energy)
+	return threshold, weights, fingerprint, nil
+}
+
+// EnqueueSimilarityGraphRefresh queues a similarity graph job unless one is
+// already waiting.
+func (d *DB) EnqueueSimilarityGraphRefresh() error {
+	result, err := d.db.Exec(`
+		INSERT INTO jobs (type, status, priority, payload_json)
+		SELECT ?, ?, 0, '{}'
+		WHERE NOT EXISTS (SELECT 1 FROM jobs WHERE type = ? AND status = ?)
+	`, string(JobTypeSimilarityGraph), string(JobStatusPending), string(JobTypeSimilarityGraph), string(JobStatusPending))
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		d.notifyJobQueued()
+	}
+	return nil
+}
+
+// StaleSimilarityGraphs returns up to limit analyzed tracks whose neighbours
+// are missing or were scored against an older analysis or other settings.
+func (d *DB) StaleSimilarityGraphs(limit int) ([]int64, error) {
+	_, _, fingerprint, err := d.similarityGraphSettings()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := d.db.Query(`
+		SELECT a.track_id
+		FROM analyses a
+		LEFT JOIN similarity_graph g ON g.track_id = a.track_id
+		WHERE a.id = (
+			SELECT id FROM analyses a2
+			WHERE a2.track_id = a.track_id AND a2.status = 'complete'
+			ORDER BY a2.version DESC LIMIT 1
+		)
+		AND a.openl3_embedding IS NOT NULL AND LENGTH(a.openl3_embedding) > 0
+		AND (g.track_id IS NULL OR g.settings != ? OR g.analysis_version != a.version)
+		ORDER BY a.track_id
+		LIMIT ?
+	`, fingerprint, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stale []int64
+	for rows.Next() {
+		var trackID int64
+		if err := rows.Scan(&trackID); err != nil {
+			return nil, err
+		}
+		stale = append(stale, trackID)
+	}
+	return stale, rows.Err()
+}
+
+// RefreshSimilarityGraph scores a track's nearest neighbours and caches the
+// best SimilarityGraphNeighbours at or above the similarity threshold. Tracks
+// the new list shows should now list this one in turn are marked stale.
+func (d *DB) RefreshSimilarityGraph(trackID int64) error {
+	// Held from reading the analysis to storing what was scored from it, so
+	// an analysis stored meanwhile invalidates the result rather than racing it.
+	d.graphMu.Lock()
+	defer d.graphMu.Unlock()
+
+	threshold, weights, fingerprint, err := d.similarityGraphSettings()
+	if err != nil {
+		return err
+	}
+	var version int32
+	err = d.db.QueryRow(`
+		SELECT version FROM analyses
+		WHERE track_id = ? AND status = 'complete'
+		ORDER BY version DESC LIMIT 1
+	`, trackID).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil // deleted or never analyzed
+	}
+	if err != nil {
+		return err
+	}
+	query, err := d.GetTrackFeaturesForSimilarity(trackID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(query.OpenL3Embedding) == 0 {
+		return nil
+	}
+
+	candidates, err := d.SimilarityCandidates(query, similarity.CandidatePool(SimilarityGraphNeighbours), false)
+	if err != nil {
+		return err
+	}
+	results := similarity.FindSimilarWeighted(query, candidates, SimilarityGraphNeighbours, weights)
+	kept := results
+	for i, r := range results {
+		if r.Score < threshold {
+			kept = results[:i]
+			break
+		}
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM embedding_similarity WHERE track_a_id = ?`, trackID); err != nil {
+		return err
+	}
+	for _, r := range kept {
+		if err := cacheSimilarity(tx, trackID, r); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO similarity_graph (track_id, analysis_version, settings, neighbour_limit, truncated, computed_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(track_id) DO UPDATE SET
+			analysis_version = excluded.analysis_version,
+			settings = excluded.settings,
+			neighbour_limit = excluded.neighbour_limit,
+			truncated = excluded.truncated,
+			computed_at = CURRENT_TIMESTAMP
+	`, trackID, version, fingerprint, SimilarityGraphNeighbours, len(kept) < len(results)); err != nil {
+		return err
+	}
+
+	// A track this one is close to may be missing it: it was scored before
+	// this analysis, or this track was not among its candidates. Mark those
+	// it would now make the cut for stale.
+	features := make(map[int64]*similarity.TrackFeatures, len(candidates))
+	for _, c := range candidates {
+		features[c.TrackID] = c
+	}
+	for _, r := range kept {
+		reverse := similarity.FindSimilarWeighted(features[r.TrackID], []*similarity.TrackFeatures{query}, 1, weights)
+		if len(reverse) == 0 || reverse[0].Score < threshold {
+			continue
+		}
+		if _, err := tx.Exec(`
+			DELETE FROM similarity_graph
+			WHERE track_id = ?1
+			  AND NOT EXISTS (SELECT 1 FROM embedding_similarity WHERE track_a_id = ?1 AND track_b_id = ?2)
+			  AND ((SELECT COUNT(*) FROM embedding_similarity WHERE track_a_id = ?1) < neighbour_limit
+			       OR ?3 > (SELECT MIN(combined_score) FROM embedding_similarity WHERE track_a_id = ?1))
+		`, r.TrackID, trackID, reverse[0].Score); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// invalidateSimilarityGraph marks stale the neighbours of a track whose
+// analysis changed, and those of every track that lists it, and queues their
+// refresh.
+func (d *DB) invalidateSimilarityGraph(trackID int64) error {
+	d.graphMu.Lock()
+	defer d.graphMu.Unlock()
+	if _, err := d.db.Exec(`
+		DELETE FROM similarity_graph
+		WHERE track_id = ?1
+		   OR track_id IN (SELECT track_a_id FROM embedding_similarity WHERE track_b_id = ?1)
+	`, trackID); err != nil {
+		return err
+	}
+	return d.EnqueueSimilarityGraphRefresh()
+}
+
+// CachedSimilarTracks returns up to limit of a track's cached neighbours, most
+// similar first. ok is false when the cache can't answer a query for limit
+// results scoring at least minScore the way scoring every candidate would:
+// the track's neighbours are stale, or more were asked for than it keeps.
+func (d *DB) CachedSimilarTracks(trackID int64, limit int, minScore float64) (results []similarity.SimilarityResult, ok bool, err error) {
+	threshold, _, fingerprint, err := d.similarityGraphSettings()
+	if err != nil {
+		return nil, false, err
+	}
+	var neighbourLimit int
+	var truncated bool
+	err = d.db.QueryRow(`
+		SELECT g.neighbour_limit, g.truncated
+		FROM similarity_graph g
+		WHERE g.track_id = ? AND g.settings = ? AND g.analysis_version = (
+			SELECT version FROM analyses a
+			WHERE a.track_id = g.track_id AND a.status = 'complete'
+			ORDER BY a.version DESC LIMIT 1
+		)
+	`, trackID, fingerprint).Scan(&neighbourLimit, &truncated)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && limit > neighbourLimit) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	results, err = d.GetCachedSimilarTracks(trackID, limit)
+	if err != nil {
+		return nil, false, err
+	}
+	// The threshold cut the list short, and the query wants what it cut.
+	if truncated && len(results) < limit && minScore < threshold {
+		return nil, false, nil
+	}
+	return results, true, nil
+}
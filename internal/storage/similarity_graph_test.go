@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+func TestSimilarityGraph(t *testing.T) {
+	db := openTestDB(t)
+	// As in TestEmbeddingIndex: track 1's nearest neighbours are 2, 3, ... in order.
+	embedding := func(i int) []float32 {
+		v := make([]float32, similarity.EmbeddingDim)
+		v[0] = 1
+		v[i] = float32(i) / 10
+		return v
+	}
+	store := func(trackID int64, vec []float32) {
+		t.Helper()
+		rec := &AnalysisRecord{
+			TrackID:         trackID,
+			Version:         1,
+			Status:          AnalysisStatusComplete,
+			BPM:             124,
+			KeyValue:        "8A",
+			EnergyGlobal:    6,
+			OpenL3Embedding: similarity.FloatsToBytes(vec),
+		}
+		if err := db.UpsertAnalysis(rec); err != nil {
+			t.Fatalf("upsert analysis: %v", err)
+		}
+	}
+	addTrack := func(i int) int64 {
+		t.Helper()
+		id, err := db.UpsertTrack(&Track{ContentHash: fmt.Sprintf("h%d", i), Path: fmt.Sprintf("/music/%d.mp3", i)})
+		if err != nil {
+			t.Fatalf("upsert track: %v", err)
+		}
+		return id
+	}
+	refreshStale := func() []int64 {
+		t.Helper()
+		stale, err := db.StaleSimilarityGraphs(100)
+		if err != nil {
+			t.Fatalf("stale graphs: %v", err)
+		}
+		for _, id := range stale {
+			if err := db.RefreshSimilarityGraph(id); err != nil {
+				t.Fatalf("refresh %d: %v", id, err)
+			}
+		}
+		return stale
+	}
+	staleGraphs := func() []int64 {
+		t.Helper()
+		stale, err := db.StaleSimilarityGraphs(100)
+		if err != nil {
+			t.Fatalf("stale graphs: %v", err)
+		}
+		return stale
+	}
+
+	ids := make([]int64, 0, 5)
+	for i := 1; i <= 5; i++ {
+		id := addTrack(i)
+		ids = append(ids, id)
+		store(id, embedding(i))
+	}
+	if n, err := db.GetPendingJobCount(JobTypeSimilarityGraph); err != nil || n != 1 {
+		t.Errorf("pending refresh jobs = %d, %v; want one for all five analyses", n, err)
+	}
+	if _, ok, err := db.CachedSimilarTracks(ids[0], 3, 0); ok || err != nil {
+		t.Fatalf("served a graph that was never computed: %v", err)
+	}
+
+	if stale := refreshStale(); !slices.Equal(stale, ids) {
+		t.Fatalf("stale = %v, want %v", stale, ids)
+	}
+	if stale := staleGraphs(); len(stale) != 0 {
+		t.Fatalf("still stale after refresh: %v", stale)
+	}
+
+	cached, ok, err := db.CachedSimilarTracks(ids[0], 3, 0)
+	if err != nil || !ok {
+		t.Fatalf("cached = %v, %v", ok, err)
+	}
+	query, _ := db.GetTrackFeaturesForSimilarity(ids[0])
+	candidates, _ := db.GetTrackFeaturesExcluding([]int64{ids[0]})
+	if live := similarity.FindSimilar(query, candidates, 3); !slices.Equal(cached, live) {
+		t.Errorf("cached neighbours differ from scoring every track:\n got %+v\nwant %+v", cached, live)
+	}
+	if _, ok, _ := db.CachedSimilarTracks(ids[0], SimilarityGraphNeighbours+1, 0); ok {
+		t.Error("served more neighbours than the graph keeps")
+	}
+
+	// Re-analysis goes stale along with every track that lists it.
+	store(ids[2], embedding(2))
+	if stale := staleGraphs(); !slices.Equal(stale, ids) {
+		t.Errorf("stale after re-analysis = %v, want %v", stale, ids)
+	}
+	refreshStale()
+
+	// A new track marks the tracks it should be listed by.
+	twin := addTrack(6)
+	store(twin, embedding(1))
+	if stale := refreshStale(); !slices.Equal(stale, []int64{twin}) {
+		t.Fatalf("stale after adding a track = %v", stale)
+	}
+	if stale := staleGraphs(); !slices.Contains(stale, ids[0]) {
+		t.Errorf("track 1 should list its twin, stale = %v", stale)
+	}
+	refreshStale()
+	if cached, ok, _ := db.CachedSimilarTracks(ids[0], 1, 0); !ok || len(cached) != 1 || cached[0].TrackID != twin {
+		t.Errorf("nearest to track 1 = %+v, want its twin", cached)
+	}
+
+	// A new threshold makes every graph stale; one that cut neighbours can
+	// only answer for the scores it kept.
+	if err := db.SetMLSetting("similarity_threshold", "0.99"); err != nil {
+		t.Fatalf("set threshold: %v", err)
+	}
+	if stale := staleGraphs(); len(stale) != 6 {
+		t.Fatalf("stale after changing the threshold = %v", stale)
+	}
+	refreshStale()
+	if _, ok, _ := db.CachedSimilarTracks(ids[4], 3, 0); ok {
+		t.Error("served neighbours the threshold cut")
+	}
+	if _, ok, _ := db.CachedSimilarTracks(ids[4], 3, 0.99); !ok {
+		t.Error("should serve a query that wants no more than the threshold kept")
+	}
+}
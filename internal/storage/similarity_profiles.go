@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+// ML settings holding the weights similarity search uses by default.
+const (
+	settingWeightVibe   = "similarity_weight_vibe"
+	settingWeightTempo  = "similarity_weight_tempo"
+	settingWeightKey    = "similarity_weight_key"
+	settingWeightEnergy = "similarity_weight_energy"
+)
+
+// similarityProfilePrefix starts the ML setting key of a named profile.
+const similarityProfilePrefix = "similarity_profile:"
+
+// ErrInvalidSimilarityProfile is returned for a profile that can't be saved.
+var ErrInvalidSimilarityProfile = errors.New("invalid similarity profile")
+
+// SimilarityProfile is a named set of similarity weights.
+type SimilarityProfile struct {
+	Name    string
+	Weights similarity.Weights // normalized
+}
+
+// similarityWeightsFrom reads the default similarity weights from ML settings.
+// Missing or unusable weights are the package defaults.
+func similarityWeightsFrom(settings map[string]string) similarity.Weights {
+	var w similarity.Weights
+	for key, v := range map[string]*float64{
+		settingWeightVibe:   &w.Vibe,
+		settingWeightTempo:  &w.Tempo,
+		settingWeightKey:    &w.Key,
+		settingWeightEnergy: &w.Energy,
+	} {
+		if f, err := strconv.ParseFloat(settings[key], 64); err == nil {
+			*v = f
+		}
+	}
+	normalized, err := w.Normalized()
+	if err != nil {
+		return similarity.DefaultWeights
+	}
+	return normalized
+}
+
+// GetSimilarityWeights returns the weights similarity search uses by default.
+func (d *DB) GetSimilarityWeights() (similarity.Weights, error) {
+	settings, err := d.GetMLSettings()
+	if err != nil {
+		return similarity.Weights{}, err
+	}
+	return similarityWeightsFrom(settings), nil
+}
+
+// SetSimilarityWeights normalizes w and makes it the default. All zero
+// weights restore the package defaults.
+func (d *DB) SetSimilarityWeights(w similarity.Weights) error {
+	w, err := w.Normalized()
+	if err != nil {
+		return err
+	}
+	for _, s := range []struct {
+		key   string
+		value float64
+	}{
+		{settingWeightVibe, w.Vibe},
+		{settingWeightTempo, w.Tempo},
+		{settingWeightKey, w.Key},
+		{settingWeightEnergy, w.Energy},
+	} {
+		if err := d.SetMLSetting(s.key, strconv.FormatFloat(s.value, 'f', -1, 64)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListSimilarityProfiles returns every saved profile by name.
+func (d *DB) ListSimilarityProfiles() ([]SimilarityProfile, error) {
+	rows, err := d.db.Query(`
+		SELECT setting_key, setting_value FROM ml_settings
+		WHERE `+hasPrefix("setting_key")+`
+		ORDER BY setting_key
+	`, len(similarityProfilePrefix), similarityProfilePrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []SimilarityProfile
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		p, err := decodeSimilarityProfile(strings.TrimPrefix(key, similarityProfilePrefix), value)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *p)
+	}
+	return profiles, rows.Err()
+}
+
+// GetSimilarityProfile returns the named profile, or sql.ErrNoRows.
+func (d *DB) GetSimilarityProfile(name string) (*SimilarityProfile, error) {
+	var value string
+	err := d.db.QueryRow(`SELECT setting_value FROM ml_settings WHERE setting_key = ?`, similarityProfilePrefix+name).Scan(&value)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSimilarityProfile(name, value)
+}
+
+// SaveSimilarityProfile creates or replaces a profile, normalizing its weights.
+func (d *DB) SaveSimilarityProfile(p SimilarityProfile) (*SimilarityProfile, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidSimilarityProfile)
+	}
+	if p.Weights.IsZero() {
+		return nil, fmt.Errorf("%w: weights are required", ErrInvalidSimilarityProfile)
+	}
+	w, err := p.Weights.Normalized()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSimilarityProfile, err)
+	}
+	p.Weights = w
+	value, err := json.Marshal(p.Weights)
+	if err != nil {
+		return nil, err
+	}
+	// Profiles aren't scored into the similarity graph, so this skips
+	// SetMLSetting's refresh.
+	if _, err := d.db.Exec(`
+		INSERT INTO ml_settings (setting_key, setting_value, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(setting_key) DO UPDATE SET
+			setting_value = excluded.setting_value,
+			updated_at = CURRENT_TIMESTAMP
+	`, similarityProfilePrefix+p.Name, string(value)); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DeleteSimilarityProfile deletes the named profile, or returns sql.ErrNoRows.
+func (d *DB) DeleteSimilarityProfile(name string) error {
+	result, err := d.db.Exec(`DELETE FROM ml_settings WHERE setting_key = ?`, similarityProfilePrefix+name)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ResolveSimilarityWeights returns the normalized weights a similarity query
+// scores with: override when set, else the named profile when given, else
+// the default weights. A missing profile is sql.ErrNoRows.
+func (d *DB) ResolveSimilarityWeights(profile string, override similarity.Weights) (similarity.Weights, error) {
+	switch {
+	case !override.IsZero():
+		return override.Normalized()
+	case profile != "":
+		p, err := d.GetSimilarityProfile(profile)
+		if err != nil {
+			return similarity.Weights{}, err
+		}
+		return p.Weights, nil
+	}
+	return d.GetSimilarityWeights()
+}
+
+func decodeSimilarityProfile(name, value string) (*SimilarityProfile, error) {
+	p := &SimilarityProfile{Name: name}
+	if err := json.Unmarshal([]byte(value), &p.Weights); err != nil {
+		return nil, fmt.Errorf("similarity profile %q: %w", name, err)
+	}
+	w, err := p.Weights.Normalized()
+	if err != nil {
+		return nil, fmt.Errorf("similarity profile %q: %w", name, err)
+	}
+	p.Weights = w
+	return p, nil
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/cartomix/cancun/internal/similarity"
+)
+
+func TestSimilarityWeightsAndProfiles(t *testing.T) {
+	db := openTestDB(t)
+
+	if w, err := db.GetSimilarityWeights(); err != nil || w != similarity.DefaultWeights {
+		t.Fatalf("default weights = %+v, %v", w, err)
+	}
+	if err := db.SetSimilarityWeights(similarity.Weights{Vibe: 3, Key: 1}); err != nil {
+		t.Fatalf("set weights: %v", err)
+	}
+	if w, _ := db.GetSimilarityWeights(); w != (similarity.Weights{Vibe: 0.75, Key: 0.25}) {
+		t.Errorf("weights = %+v, want normalized", w)
+	}
+	if err := db.SetSimilarityWeights(similarity.Weights{Tempo: -1}); !errors.Is(err, similarity.ErrInvalidWeights) {
+		t.Errorf("negative weight: got %v", err)
+	}
+
+	profiles, err := db.ListSimilarityProfiles()
+	if err != nil || len(profiles) != 2 || profiles[0].Name != "harmonic-first" || profiles[1].Name != "vibe-first" {
+		t.Fatalf("seeded profiles = %+v, %v", profiles, err)
+	}
+	saved, err := db.SaveSimilarityProfile(SimilarityProfile{Name: " tempo ", Weights: similarity.Weights{Tempo: 2, Energy: 2}})
+	if err != nil || saved.Name != "tempo" || saved.Weights != (similarity.Weights{Tempo: 0.5, Energy: 0.5}) {
+		t.Fatalf("saved = %+v, %v", saved, err)
+	}
+	if _, err := db.SaveSimilarityProfile(SimilarityProfile{Name: "fête", Weights: similarity.Weights{Vibe: 1}}); err != nil {
+		t.Fatalf("save non-ASCII name: %v", err)
+	}
+	if profiles, _ := db.ListSimilarityProfiles(); len(profiles) != 4 || profiles[0].Name != "fête" {
+		t.Errorf("profiles = %+v, want fête listed", profiles)
+	}
+	if err := db.DeleteSimilarityProfile("fête"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := db.SaveSimilarityProfile(SimilarityProfile{Name: "empty"}); !errors.Is(err, ErrInvalidSimilarityProfile) {
+		t.Errorf("profile without weights: got %v", err)
+	}
+
+	for _, c := range []struct {
+		profile  string
+		override similarity.Weights
+		want     similarity.Weights
+	}{
+		{"", similarity.Weights{}, similarity.Weights{Vibe: 0.75, Key: 0.25}},
+		{"tempo", similarity.Weights{}, similarity.Weights{Tempo: 0.5, Energy: 0.5}},
+		{"tempo", similarity.Weights{Key: 5}, similarity.Weights{Key: 1}},
+	} {
+		if got, err := db.ResolveSimilarityWeights(c.profile, c.override); err != nil || got != c.want {
+			t.Errorf("resolve(%q, %+v) = %+v, %v; want %+v", c.profile, c.override, got, err, c.want)
+		}
+	}
+
+	if err := db.DeleteSimilarityProfile("tempo"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := db.ResolveSimilarityWeights("tempo", similarity.Weights{}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("resolve deleted profile: got %v", err)
+	}
+	if err := db.DeleteSimilarityProfile("tempo"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("delete again: got %v", err)
+	}
+}
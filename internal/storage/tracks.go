@@ -3,6 +3,8 @@ package storage
 import (
 	"database/sql"
 	"errors"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/cartomix/cancun/gen/go/common"
@@ -12,174 +14,236 @@ import (
 type Track struct {
 	ID             int64
 	ContentHash    string
+	FileHash       string // SHA-256 of the whole file; empty for legacy-keyed tracks
+	HashVersion    int    // how ContentHash was derived; see scanner.HashVersion
 	Path           string
 	Title          string
 	Artist         string
 	Album          string
+	Genre          string
+	Label          string
+	Year           int
+	Comment        string
+	TagBPM         float64 // BPM tag written by another tool, not our analysis
+	TagKey         string  // key tag written by another tool, not our analysis
+	ArtworkHash    string
 	FileSize       int64
 	FileModifiedAt time.Time
+	TagsReadAt     time.Time // zero until the scanner has read the file's tags
+	MissingAt      time.Time // set while the file is gone from its recorded path
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 }
 
-// UpsertTrack inserts or updates a track by content hash.
-func (d *DB) UpsertTrack(t *Track) (int64, error) {
-	result, err := d.db.Exec(`
-		INSERT INTO tracks (content_hash, path, title, artist, album, file_size, file_modified_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
-		ON CONFLICT(content_hash) DO UPDATE SET
-			path = excluded.path,
-			title = excluded.title,
-			artist = excluded.artist,
-			album = excluded.album,
-			file_size = excluded.file_size,
-			file_modified_at = excluded.file_modified_at,
-			updated_at = CURRENT_TIMESTAMP
-	`, t.ContentHash, t.Path, t.Title, t.Artist, t.Album, t.FileSize, t.FileModifiedAt)
-	if err != nil {
-		return 0, err
-	}
+// trackColumns is the column list scanTrack expects.
+const trackColumns = `id, content_hash, file_hash, hash_version, path, title, artist, album, genre, label, year, comment,
+		tag_bpm, tag_key, artwork_hash, file_size, file_modified_at, tags_read_at, missing_at, created_at, updated_at`
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		// On conflict, fetch the existing ID
-		row := d.db.QueryRow("SELECT id FROM tracks WHERE content_hash = ?", t.ContentHash)
-		if err := row.Scan(&id); err != nil {
-			return 0, err
-		}
-	}
-
-	return id, nil
-}
-
-// GetTrackByHash retrieves a track by content hash.
-func (d *DB) GetTrackByHash(hash string) (*Track, error) {
+// scanTrack reads a row selected with trackColumns.
+func scanTrack(row interface{ Scan(...any) error }) (*Track, error) {
 	t := &Track{}
-	row := d.db.QueryRow(`
-		SELECT id, content_hash, path, title, artist, album, file_size, file_modified_at, created_at, updated_at
-		FROM tracks WHERE content_hash = ?
-	`, hash)
+	var fileModifiedAt, tagsReadAt, missingAt, createdAt, updatedAt sql.NullTime
+	var fileHash, title, artist, album, genre, label, comment, tagKey, artworkHash sql.NullString
+	var year, fileSize sql.NullInt64
+	var tagBPM sql.NullFloat64
 
-	var fileModifiedAt, createdAt, updatedAt sql.NullTime
-	var title, artist, album sql.NullString
-	var fileSize sql.NullInt64
-
-	err := row.Scan(&t.ID, &t.ContentHash, &t.Path, &title, &artist, &album, &fileSize, &fileModifiedAt, &createdAt, &updatedAt)
+	err := row.Scan(&t.ID, &t.ContentHash, &fileHash, &t.HashVersion, &t.Path, &title, &artist, &album, &genre, &label, &year, &comment,
+		&tagBPM, &tagKey, &artworkHash, &fileSize, &fileModifiedAt, &tagsReadAt, &missingAt, &createdAt, &updatedAt)
 	if err != nil {
 		return nil, err
 	}
 
-	if title.Valid {
-		t.Title = title.String
-	}
-	if artist.Valid {
-		t.Artist = artist.String
-	}
-	if album.Valid {
-		t.Album = album.String
-	}
-	if fileSize.Valid {
-		t.FileSize = fileSize.Int64
-	}
+	t.FileHash = fileHash.String
+	t.Title = title.String
+	t.Artist = artist.String
+	t.Album = album.String
+	t.Genre = genre.String
+	t.Label = label.String
+	t.Year = int(year.Int64)
+	t.Comment = comment.String
+	t.TagBPM = tagBPM.Float64
+	t.TagKey = tagKey.String
+	t.ArtworkHash = artworkHash.String
+	t.FileSize = fileSize.Int64
 	if fileModifiedAt.Valid {
 		t.FileModifiedAt = fileModifiedAt.Time
 	}
+	if tagsReadAt.Valid {
+		t.TagsReadAt = tagsReadAt.Time
+	}
+	if missingAt.Valid {
+		t.MissingAt = missingAt.Time
+	}
 	if createdAt.Valid {
 		t.CreatedAt = createdAt.Time
 	}
 	if updatedAt.Valid {
 		t.UpdatedAt = updatedAt.Time
 	}
-
 	return t, nil
 }
 
-// GetTrackByID retrieves a track by ID.
-func (d *DB) GetTrackByID(id int64) (*Track, error) {
-	t := &Track{}
-	row := d.db.QueryRow(`
-		SELECT id, content_hash, path, title, artist, album, file_size, file_modified_at, created_at, updated_at
-		FROM tracks WHERE id = ?
-	`, id)
-
-	var fileModifiedAt, createdAt, updatedAt sql.NullTime
-	var title, artist, album sql.NullString
-	var fileSize sql.NullInt64
-
-	err := row.Scan(&t.ID, &t.ContentHash, &t.Path, &title, &artist, &album, &fileSize, &fileModifiedAt, &createdAt, &updatedAt)
+// UpsertTrack inserts or updates a track by content hash and clears its missing flag.
+// Tag columns are only overwritten when t.TagsReadAt is set, so callers that never
+// read tags don't wipe them.
+func (d *DB) UpsertTrack(t *Track) (int64, error) {
+	hashVersion := t.HashVersion
+	if hashVersion == 0 {
+		hashVersion = 1
+	}
+	var tagsReadAt any
+	if !t.TagsReadAt.IsZero() {
+		tagsReadAt = t.TagsReadAt
+	}
+	var id int64
+	err := d.db.QueryRow(`
+		INSERT INTO tracks (content_hash, file_hash, hash_version, path, title, artist, album, genre, label, year, comment,
+			tag_bpm, tag_key, artwork_hash, file_size, file_modified_at, tags_read_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(content_hash) DO UPDATE SET
+			file_hash = COALESCE(excluded.file_hash, file_hash),
+			hash_version = excluded.hash_version,
+			path = excluded.path,
+			title = CASE WHEN excluded.tags_read_at IS NULL THEN title ELSE excluded.title END,
+			artist = CASE WHEN excluded.tags_read_at IS NULL THEN artist ELSE excluded.artist END,
+			album = CASE WHEN excluded.tags_read_at IS NULL THEN album ELSE excluded.album END,
+			genre = CASE WHEN excluded.tags_read_at IS NULL THEN genre ELSE excluded.genre END,
+			label = CASE WHEN excluded.tags_read_at IS NULL THEN label ELSE excluded.label END,
+			year = CASE WHEN excluded.tags_read_at IS NULL THEN year ELSE excluded.year END,
+			comment = CASE WHEN excluded.tags_read_at IS NULL THEN comment ELSE excluded.comment END,
+			tag_bpm = CASE WHEN excluded.tags_read_at IS NULL THEN tag_bpm ELSE excluded.tag_bpm END,
+			tag_key = CASE WHEN excluded.tags_read_at IS NULL THEN tag_key ELSE excluded.tag_key END,
+			artwork_hash = CASE WHEN excluded.tags_read_at IS NULL THEN artwork_hash ELSE excluded.artwork_hash END,
+			file_size = excluded.file_size,
+			file_modified_at = excluded.file_modified_at,
+			tags_read_at = COALESCE(excluded.tags_read_at, tags_read_at),
+			missing_at = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id
+	`, t.ContentHash, nullString(t.FileHash), hashVersion, t.Path, t.Title, t.Artist, t.Album, t.Genre, t.Label, t.Year, t.Comment,
+		t.TagBPM, t.TagKey, t.ArtworkHash, t.FileSize, t.FileModifiedAt, tagsReadAt).Scan(&id)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	if title.Valid {
-		t.Title = title.String
-	}
-	if artist.Valid {
-		t.Artist = artist.String
-	}
-	if album.Valid {
-		t.Album = album.String
-	}
-	if fileSize.Valid {
-		t.FileSize = fileSize.Int64
-	}
-	if fileModifiedAt.Valid {
-		t.FileModifiedAt = fileModifiedAt.Time
-	}
-	if createdAt.Valid {
-		t.CreatedAt = createdAt.Time
-	}
-	if updatedAt.Valid {
-		t.UpdatedAt = updatedAt.Time
-	}
+	return id, nil
+}
 
-	return t, nil
+// GetTrackByHash retrieves a track by content hash.
+func (d *DB) GetTrackByHash(hash string) (*Track, error) {
+	return scanTrack(d.db.QueryRow("SELECT "+trackColumns+" FROM tracks WHERE content_hash = ?", hash))
+}
+
+// GetTrackByID retrieves a track by ID.
+func (d *DB) GetTrackByID(id int64) (*Track, error) {
+	return scanTrack(d.db.QueryRow("SELECT "+trackColumns+" FROM tracks WHERE id = ?", id))
 }
 
 // GetTrackByPath retrieves a track by its file path.
 func (d *DB) GetTrackByPath(path string) (*Track, error) {
-	t := &Track{}
-	row := d.db.QueryRow(`
-		SELECT id, content_hash, path, title, artist, album, file_size, file_modified_at, created_at, updated_at
-		FROM tracks WHERE path = ?
-	`, path)
+	return scanTrack(d.db.QueryRow("SELECT "+trackColumns+" FROM tracks WHERE path = ?", path))
+}
 
-	var fileModifiedAt, createdAt, updatedAt sql.NullTime
-	var title, artist, album sql.NullString
-	var fileSize sql.NullInt64
+// GetTrackByFileHash retrieves a track by its whole-file hash.
+func (d *DB) GetTrackByFileHash(hash string) (*Track, error) {
+	return scanTrack(d.db.QueryRow("SELECT "+trackColumns+" FROM tracks WHERE file_hash = ? ORDER BY id LIMIT 1", hash))
+}
 
-	err := row.Scan(&t.ID, &t.ContentHash, &t.Path, &title, &artist, &album, &fileSize, &fileModifiedAt, &createdAt, &updatedAt)
+// RekeyTrack points an existing track at new content, e.g. after the file at its
+// path was rewritten or its hash was recomputed under a new HashVersion. Analyses
+// and cue edits stay attached to the track, and saved sets and live play history
+// follow it to the new content hash.
+func (d *DB) RekeyTrack(id int64, contentHash, fileHash string, hashVersion int) error {
+	tx, err := d.db.Begin()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer tx.Rollback()
 
-	if title.Valid {
-		t.Title = title.String
+	var oldHash string
+	if err := tx.QueryRow("SELECT content_hash FROM tracks WHERE id = ?", id).Scan(&oldHash); err != nil {
+		return err
 	}
-	if artist.Valid {
-		t.Artist = artist.String
-	}
-	if album.Valid {
-		t.Album = album.String
+	if _, err := tx.Exec(`
+		UPDATE tracks SET content_hash = ?, file_hash = ?, hash_version = ?, missing_at = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, contentHash, nullString(fileHash), hashVersion, id); err != nil {
+		return err
+	}
+	if oldHash != contentHash {
+		for _, table := range []string{"saved_set_tracks", "live_plays"} {
+			if _, err := tx.Exec("UPDATE "+table+" SET content_hash = ? WHERE content_hash = ?", contentHash, oldHash); err != nil {
+				return err
+			}
+		}
 	}
-	if fileSize.Valid {
-		t.FileSize = fileSize.Int64
+	return tx.Commit()
+}
+
+// TracksBelowHashVersion returns tracks whose content hash predates version.
+func (d *DB) TracksBelowHashVersion(version int) ([]*Track, error) {
+	rows, err := d.db.Query("SELECT "+trackColumns+" FROM tracks WHERE hash_version < ? ORDER BY id", version)
+	if err != nil {
+		return nil, err
 	}
-	if fileModifiedAt.Valid {
-		t.FileModifiedAt = fileModifiedAt.Time
+	defer rows.Close()
+
+	var tracks []*Track
+	for rows.Next() {
+		t, err := scanTrack(rows)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, t)
 	}
-	if createdAt.Valid {
-		t.CreatedAt = createdAt.Time
+	return tracks, rows.Err()
+}
+
+// MarkTracksMissing flags the track at path, and every track below it when path is
+// a directory, as missing. It returns the number of tracks newly flagged.
+func (d *DB) MarkTracksMissing(path string) (int64, error) {
+	path = strings.TrimSuffix(path, string(filepath.Separator))
+	result, err := d.db.Exec(`
+		UPDATE tracks SET missing_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE missing_at IS NULL AND (path = ? OR `+hasPrefix("path")+`)
+	`, path, len(path)+1, path+string(filepath.Separator))
+	if err != nil {
+		return 0, err
 	}
-	if updatedAt.Valid {
-		t.UpdatedAt = updatedAt.Time
+	return result.RowsAffected()
+}
+
+// hasPrefix is a condition that column starts with a prefix, taking the
+// prefix's length in bytes and then the prefix. It compares bytes rather than
+// characters, so the length from Go's len matches for non-ASCII prefixes.
+func hasPrefix(column string) string {
+	return "substr(CAST(" + column + " AS BLOB), 1, ?) = CAST(? AS BLOB)"
+}
+
+// TracksUnder returns the tracks whose path lies below root.
+func (d *DB) TracksUnder(root string) ([]*Track, error) {
+	prefix := strings.TrimSuffix(root, string(filepath.Separator)) + string(filepath.Separator)
+	rows, err := d.db.Query("SELECT "+trackColumns+" FROM tracks WHERE "+hasPrefix("path"), len(prefix), prefix)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return t, nil
+	var tracks []*Track
+	for rows.Next() {
+		t, err := scanTrack(rows)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, t)
+	}
+	return tracks, rows.Err()
 }
 
 // ResolveTrack attempts to find a track using the provided proto TrackId.
-// It prefers content_hash when available, falling back to path.
+// It prefers content_hash when available (also accepting a whole-file hash),
+// falling back to path.
 func (d *DB) ResolveTrack(id *common.TrackId) (*Track, error) {
 	if id == nil {
 		return nil, errors.New("track id is required")
@@ -191,6 +255,11 @@ func (d *DB) ResolveTrack(id *common.TrackId) (*Track, error) {
 		} else if !errors.Is(err, sql.ErrNoRows) {
 			return nil, err
 		}
+		if t, err := d.GetTrackByFileHash(id.ContentHash); err == nil {
+			return t, nil
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
 	}
 
 	if id.Path != "" {
@@ -202,16 +271,13 @@ func (d *DB) ResolveTrack(id *common.TrackId) (*Track, error) {
 
 // ListTracks returns tracks matching the query.
 func (d *DB) ListTracks(query string, limit int) ([]*Track, error) {
-	sqlQuery := `
-		SELECT id, content_hash, path, title, artist, album, file_size, file_modified_at, created_at, updated_at
-		FROM tracks
-	`
+	sqlQuery := "SELECT " + trackColumns + " FROM tracks"
 	args := []any{}
 
 	if query != "" {
-		sqlQuery += " WHERE title LIKE ? OR artist LIKE ? OR path LIKE ?"
+		sqlQuery += " WHERE title LIKE ? OR artist LIKE ? OR album LIKE ? OR genre LIKE ? OR label LIKE ? OR path LIKE ?"
 		pattern := "%" + query + "%"
-		args = append(args, pattern, pattern, pattern)
+		args = append(args, pattern, pattern, pattern, pattern, pattern, pattern)
 	}
 
 	sqlQuery += " ORDER BY updated_at DESC"
@@ -229,39 +295,73 @@ func (d *DB) ListTracks(query string, limit int) ([]*Track, error) {
 
 	var tracks []*Track
 	for rows.Next() {
-		t := &Track{}
-		var fileModifiedAt, createdAt, updatedAt sql.NullTime
-		var title, artist, album sql.NullString
-		var fileSize sql.NullInt64
-
-		if err := rows.Scan(&t.ID, &t.ContentHash, &t.Path, &title, &artist, &album, &fileSize, &fileModifiedAt, &createdAt, &updatedAt); err != nil {
+		t, err := scanTrack(rows)
+		if err != nil {
 			return nil, err
 		}
+		tracks = append(tracks, t)
+	}
 
-		if title.Valid {
-			t.Title = title.String
-		}
-		if artist.Valid {
-			t.Artist = artist.String
-		}
-		if album.Valid {
-			t.Album = album.String
-		}
-		if fileSize.Valid {
-			t.FileSize = fileSize.Int64
-		}
-		if fileModifiedAt.Valid {
-			t.FileModifiedAt = fileModifiedAt.Time
-		}
-		if createdAt.Valid {
-			t.CreatedAt = createdAt.Time
-		}
-		if updatedAt.Valid {
-			t.UpdatedAt = updatedAt.Time
-		}
+	return tracks, rows.Err()
+}
 
-		tracks = append(tracks, t)
+// nullString stores empty strings as NULL.
+func nullString(s string) any {
+	if s == "" {
+		return nil
 	}
+	return s
+}
 
+// SetTrackMissing sets or clears a track's missing flag. Setting it keeps the time
+// the track was first found missing.
+func (d *DB) SetTrackMissing(id int64, missing bool) error {
+	query := "UPDATE tracks SET missing_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND missing_at IS NOT NULL"
+	if missing {
+		query = "UPDATE tracks SET missing_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND missing_at IS NULL"
+	}
+	_, err := d.db.Exec(query, id)
+	return err
+}
+
+// ListMissingTracks returns tracks flagged missing, most recently lost first.
+func (d *DB) ListMissingTracks() ([]*Track, error) {
+	rows, err := d.db.Query("SELECT " + trackColumns + " FROM tracks WHERE missing_at IS NOT NULL ORDER BY missing_at DESC, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tracks []*Track
+	for rows.Next() {
+		t, err := scanTrack(rows)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, t)
+	}
 	return tracks, rows.Err()
 }
+
+// RelocateTracks rewrites track paths (track ID to new path) in one transaction
+// and clears their missing flags.
+func (d *DB) RelocateTracks(paths map[int64]string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("UPDATE tracks SET path = ?, missing_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for id, path := range paths {
+		if _, err := stmt.Exec(path, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
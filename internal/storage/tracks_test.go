@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/common"
+)
+
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	db, err := Open(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestTrackTagsRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+
+	modified := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tagged := &Track{
+		ContentHash:    "tagged",
+		Path:           "/music/tagged.mp3",
+		Title:          "Title",
+		Artist:         "Artist",
+		Album:          "Album",
+		Genre:          "Techno",
+		Label:          "Label",
+		Year:           2020,
+		Comment:        "comment",
+		TagBPM:         128,
+		TagKey:         "8A",
+		ArtworkHash:    "deadbeef",
+		FileSize:       2048,
+		FileModifiedAt: modified,
+		TagsReadAt:     modified,
+	}
+	id, err := db.UpsertTrack(tagged)
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+
+	got, err := db.GetTrackByID(id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Genre != "Techno" || got.Label != "Label" || got.Year != 2020 || got.Comment != "comment" ||
+		got.TagBPM != 128 || got.TagKey != "8A" || got.ArtworkHash != "deadbeef" {
+		t.Fatalf("tags not round-tripped: %+v", got)
+	}
+	if !got.FileModifiedAt.Equal(modified) || !got.TagsReadAt.Equal(modified) {
+		t.Fatalf("times = %v / %v, want %v", got.FileModifiedAt, got.TagsReadAt, modified)
+	}
+
+	// An upsert that never read tags (e.g. AnalyzeTracks by path) keeps them.
+	if _, err := db.UpsertTrack(&Track{ContentHash: "tagged", Path: "/music/moved.mp3", FileSize: 2048, FileModifiedAt: modified}); err != nil {
+		t.Fatalf("upsert without tags: %v", err)
+	}
+	got, _ = db.GetTrackByHash("tagged")
+	if got.Path != "/music/moved.mp3" || got.Title != "Title" || got.Genre != "Techno" || got.TagsReadAt.IsZero() {
+		t.Fatalf("tags lost on untagged upsert: %+v", got)
+	}
+
+	// A re-read replaces them, including clearing removed tags.
+	retagged := *tagged
+	retagged.Title, retagged.Genre, retagged.TagsReadAt = "New Title", "", modified.Add(time.Hour)
+	if _, err := db.UpsertTrack(&retagged); err != nil {
+		t.Fatalf("upsert retagged: %v", err)
+	}
+	got, _ = db.GetTrackByHash("tagged")
+	if got.Title != "New Title" || got.Genre != "" {
+		t.Fatalf("title/genre = %q/%q", got.Title, got.Genre)
+	}
+
+	tracks, err := db.ListTracks("Label", 0)
+	if err != nil || len(tracks) != 1 {
+		t.Fatalf("search by label: %d tracks, err %v", len(tracks), err)
+	}
+}
+
+func TestTrackRekeyKeepsID(t *testing.T) {
+	db := openTestDB(t)
+
+	id, err := db.UpsertTrack(&Track{ContentHash: "legacy", Path: "/music/a.mp3"})
+	if err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	legacy, err := db.TracksBelowHashVersion(2)
+	if err != nil || len(legacy) != 1 || legacy[0].HashVersion != 1 {
+		t.Fatalf("legacy tracks = %+v, err %v", legacy, err)
+	}
+
+	if err := db.RekeyTrack(id, "audio", "file", 2); err != nil {
+		t.Fatalf("rekey: %v", err)
+	}
+	got, err := db.ResolveTrack(&common.TrackId{ContentHash: "file"})
+	if err != nil || got.ID != id || got.ContentHash != "audio" || got.HashVersion != 2 {
+		t.Fatalf("resolve by file hash = %+v, err %v", got, err)
+	}
+	if legacy, _ := db.TracksBelowHashVersion(2); len(legacy) != 0 {
+		t.Fatalf("still legacy: %+v", legacy)
+	}
+
+	// Re-tagging changes only the file hash.
+	if _, err := db.UpsertTrack(&Track{ContentHash: "audio", FileHash: "retagged", HashVersion: 2, Path: "/music/a.mp3"}); err != nil {
+		t.Fatalf("upsert: %v", err)
+	}
+	if got, _ := db.GetTrackByID(id); got.FileHash != "retagged" {
+		t.Fatalf("file hash = %q", got.FileHash)
+	}
+}
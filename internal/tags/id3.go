@@ -0,0 +1,379 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// id3v22Frames maps ID3v2.2 three-letter frame IDs onto their v2.3/v2.4 names.
+var id3v22Frames = map[string]string{
+	"TT2": "TIT2",
+	"TP1": "TPE1",
+	"TAL": "TALB",
+	"TCO": "TCON",
+	"TPB": "TPUB",
+	"TYE": "TYER",
+	"COM": "COMM",
+	"TBP": "TBPM",
+	"TKE": "TKEY",
+	"TXX": "TXXX",
+	"PIC": "APIC",
+}
+
+// readID3v2 parses the ID3v2 tag at off and returns the offset just past it.
+func (b *builder) readID3v2(r io.ReaderAt, off, limit int64) (int64, error) {
+	var hdr [10]byte
+	if _, err := r.ReadAt(hdr[:], off); err != nil {
+		return off, err
+	}
+	if string(hdr[:3]) != "ID3" {
+		return off, nil
+	}
+	major, flags := hdr[3], hdr[5]
+	size := int64(synchsafe(hdr[6:10]))
+	end := off + 10 + size
+	if major >= 4 && flags&0x10 != 0 {
+		end += 10 // footer
+	}
+	if major < 2 || major > 4 || off+10+size > limit {
+		return end, nil
+	}
+
+	body, err := readAt(r, off+10, size)
+	if err != nil {
+		return end, err
+	}
+	if major < 4 && flags&0x80 != 0 {
+		body = removeUnsync(body)
+	}
+	if flags&0x40 != 0 {
+		switch major {
+		case 2:
+			return end, nil // v2.2 "compression" was never defined; skip the tag
+		case 3:
+			if len(body) < 4 {
+				return end, nil
+			}
+			body = skip(body, 4+int(binary.BigEndian.Uint32(body)))
+		case 4:
+			if len(body) < 4 {
+				return end, nil
+			}
+			body = skip(body, int(synchsafe(body[:4])))
+		}
+	}
+
+	b.readID3Frames(body, major)
+	return end, nil
+}
+
+func (b *builder) readID3Frames(body []byte, major byte) {
+	idLen, hdrLen := 4, 10
+	if major == 2 {
+		idLen, hdrLen = 3, 6
+	}
+
+	var comments []id3Comment
+	for len(body) >= hdrLen && body[0] != 0 {
+		id := string(body[:idLen])
+		var size int
+		var formatFlags byte
+		switch major {
+		case 2:
+			size = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(body[4:8]))
+			formatFlags = body[9]
+		default:
+			size = int(synchsafe(body[4:8]))
+			formatFlags = body[9]
+		}
+		if size < 0 || hdrLen+size > len(body) {
+			break
+		}
+		data := body[hdrLen : hdrLen+size]
+		body = body[hdrLen+size:]
+
+		if major == 2 {
+			id = id3v22Frames[id]
+		}
+		if major == 3 {
+			if formatFlags&0xC0 != 0 { // compressed or encrypted
+				continue
+			}
+			if formatFlags&0x20 != 0 { // grouping identity
+				data = skip(data, 1)
+			}
+		}
+		if major == 4 {
+			if formatFlags&0x0C != 0 { // compressed or encrypted
+				continue
+			}
+			if formatFlags&0x40 != 0 { // grouping identity
+				data = skip(data, 1)
+			}
+			if formatFlags&0x01 != 0 { // data length indicator
+				data = skip(data, 4)
+			}
+			if formatFlags&0x02 != 0 {
+				data = removeUnsync(data)
+			}
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		switch id {
+		case "TIT2":
+			b.setTitle(id3Text(data))
+		case "TPE1":
+			b.setArtist(id3Text(data))
+		case "TALB":
+			b.setAlbum(id3Text(data))
+		case "TCON":
+			b.setGenre(id3Genre(id3Text(data)))
+		case "TPUB":
+			b.setLabel(id3Text(data))
+		case "TYER", "TDRC", "TORY", "TDOR":
+			b.setYear(id3Text(data))
+		case "TBPM":
+			b.setBPM(id3Text(data))
+		case "TKEY":
+			b.setKey(id3Text(data))
+		case "TXXX":
+			desc, value := id3UserText(data)
+			switch strings.ToUpper(desc) {
+			case "INITIALKEY", "KEY":
+				b.setKey(value)
+			case "LABEL", "PUBLISHER", "ORGANIZATION":
+				b.setLabel(value)
+			case "BPM":
+				b.setBPM(value)
+			}
+		case "COMM":
+			comments = append(comments, id3ParseComment(data))
+		case "APIC":
+			picture, pictureType := id3Picture(data, major)
+			b.setArtwork(picture, pictureType == 3)
+		}
+	}
+
+	// Prefer the plain comment over descriptor-keyed ones such as iTunNORM.
+	for _, c := range comments {
+		if c.desc == "" {
+			b.setComment(c.text)
+		}
+	}
+	for _, c := range comments {
+		if !strings.HasPrefix(c.desc, "iTun") {
+			b.setComment(c.text)
+		}
+	}
+}
+
+// readID3v1 reads the 128-byte ID3v1 trailer, filling fields ID3v2 left empty.
+func (b *builder) readID3v1(r io.ReaderAt, size int64) {
+	if size < 128 {
+		return
+	}
+	var tag [128]byte
+	if _, err := r.ReadAt(tag[:], size-128); err != nil || string(tag[:3]) != "TAG" {
+		return
+	}
+	field := func(s []byte) string {
+		if i := bytes.IndexByte(s, 0); i >= 0 {
+			s = s[:i]
+		}
+		return latin1(s)
+	}
+	b.setTitle(field(tag[3:33]))
+	b.setArtist(field(tag[33:63]))
+	b.setAlbum(field(tag[63:93]))
+	b.setYear(field(tag[93:97]))
+	b.setComment(field(tag[97:127]))
+	if g := int(tag[127]); g < len(id3Genres) {
+		b.setGenre(id3Genres[g])
+	}
+}
+
+type id3Comment struct {
+	desc, text string
+}
+
+// id3ParseComment splits a COMM frame: encoding, language, description, text.
+func id3ParseComment(data []byte) id3Comment {
+	if len(data) < 4 {
+		return id3Comment{}
+	}
+	enc := data[0]
+	desc, rest := splitTerminated(data[4:], enc)
+	return id3Comment{desc: decodeText(enc, desc), text: decodeText(enc, rest)}
+}
+
+// id3UserText splits a TXXX frame into its description and value.
+func id3UserText(data []byte) (string, string) {
+	enc := data[0]
+	desc, value := splitTerminated(data[1:], enc)
+	return decodeText(enc, desc), decodeText(enc, value)
+}
+
+// id3Text decodes a text frame. ID3v2.4 separates multiple values with NULs; we
+// keep the first.
+func id3Text(data []byte) string {
+	enc := data[0]
+	first, _ := splitTerminated(data[1:], enc)
+	return decodeText(enc, first)
+}
+
+// id3Picture returns the image bytes and picture type of an APIC (or v2.2 PIC) frame.
+func id3Picture(data []byte, major byte) ([]byte, byte) {
+	enc := data[0]
+	rest := data[1:]
+	if major == 2 {
+		rest = skip(rest, 3) // image format, e.g. "JPG"
+	} else {
+		i := bytes.IndexByte(rest, 0)
+		if i < 0 {
+			return nil, 0
+		}
+		rest = rest[i+1:] // MIME type
+	}
+	if len(rest) == 0 {
+		return nil, 0
+	}
+	pictureType := rest[0]
+	_, picture := splitTerminated(rest[1:], enc)
+	return picture, pictureType
+}
+
+// id3Genre resolves ID3v1 genre references: "(13)", "(13)Pop refinement" or "13".
+func id3Genre(s string) string {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n >= 0 && n < len(id3Genres) {
+			return id3Genres[n]
+		}
+		return s
+	}
+	if strings.HasPrefix(s, "(") {
+		if end := strings.IndexByte(s, ')'); end > 0 {
+			if refinement := strings.TrimSpace(s[end+1:]); refinement != "" {
+				return refinement
+			}
+			switch ref := s[1:end]; ref {
+			case "RX":
+				return "Remix"
+			case "CR":
+				return "Cover"
+			default:
+				if n, err := strconv.Atoi(ref); err == nil && n >= 0 && n < len(id3Genres) {
+					return id3Genres[n]
+				}
+			}
+		}
+	}
+	return s
+}
+
+// splitTerminated splits data at the first string terminator for the encoding:
+// a single NUL for ISO-8859-1/UTF-8, an aligned double NUL for UTF-16.
+func splitTerminated(data []byte, enc byte) ([]byte, []byte) {
+	if enc == 1 || enc == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return data[:i], data[i+2:]
+			}
+		}
+		return data, nil
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return data[:i], data[i+1:]
+	}
+	return data, nil
+}
+
+// decodeText converts ID3 text in the given encoding to UTF-8.
+func decodeText(enc byte, data []byte) string {
+	switch enc {
+	case 1, 2:
+		var order binary.ByteOrder = binary.BigEndian
+		if len(data) >= 2 {
+			switch {
+			case data[0] == 0xFF && data[1] == 0xFE:
+				order, data = binary.LittleEndian, data[2:]
+			case data[0] == 0xFE && data[1] == 0xFF:
+				data = data[2:]
+			}
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[2*i:])
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	case 3:
+		return strings.TrimRight(string(data), "\x00")
+	default:
+		return latin1(data)
+	}
+}
+
+func latin1(data []byte) string {
+	runes := make([]rune, 0, len(data))
+	for _, c := range data {
+		if c == 0 {
+			break
+		}
+		runes = append(runes, rune(c))
+	}
+	return string(runes)
+}
+
+// synchsafe decodes a 28-bit integer stored 7 bits per byte.
+func synchsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7F)<<21 | uint32(b[1]&0x7F)<<14 | uint32(b[2]&0x7F)<<7 | uint32(b[3]&0x7F)
+}
+
+// removeUnsync undoes ID3 unsynchronisation (0xFF 0x00 -> 0xFF).
+func removeUnsync(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		out = append(out, data[i])
+		if data[i] == 0xFF && i+1 < len(data) && data[i+1] == 0x00 {
+			i++
+		}
+	}
+	return out
+}
+
+func skip(data []byte, n int) []byte {
+	if n < 0 || n > len(data) {
+		return nil
+	}
+	return data[n:]
+}
+
+// id3Genres is the ID3v1 genre table including the Winamp extensions.
+var id3Genres = []string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge", "Hip-Hop",
+	"Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B", "Rap",
+	"Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska", "Death Metal", "Pranks",
+	"Soundtrack", "Euro-Techno", "Ambient", "Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance",
+	"Classical", "Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"AlternRock", "Bass", "Soul", "Punk", "Space", "Meditative", "Instrumental Pop", "Instrumental Rock",
+	"Ethnic", "Gothic", "Darkwave", "Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream",
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap", "Pop/Funk", "Jungle",
+	"Native American", "Cabaret", "New Wave", "Psychedelic", "Rave", "Showtunes", "Trailer", "Lo-Fi",
+	"Tribal", "Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll", "Hard Rock",
+	"Folk", "Folk-Rock", "National Folk", "Swing", "Fast Fusion", "Bebop", "Latin", "Revival",
+	"Celtic", "Bluegrass", "Avantgarde", "Gothic Rock", "Progressive Rock", "Psychedelic Rock", "Symphonic Rock", "Slow Rock",
+	"Big Band", "Chorus", "Easy Listening", "Acoustic", "Humour", "Speech", "Chanson", "Opera",
+	"Chamber Music", "Sonata", "Symphony", "Booty Bass", "Primus", "Porn Groove", "Satire", "Slow Jam",
+	"Club", "Tango", "Samba", "Folklore", "Ballad", "Power Ballad", "Rhythmic Soul", "Freestyle",
+	"Duet", "Punk Rock", "Drum Solo", "A Cappella", "Euro-House", "Dance Hall", "Goa", "Drum & Bass",
+	"Club-House", "Hardcore Techno", "Terror", "Indie", "BritPop", "Afro-Punk", "Polsk Punk", "Beat",
+	"Christian Gangsta Rap", "Heavy Metal", "Black Metal", "Crossover", "Contemporary Christian", "Christian Rock", "Merengue", "Salsa",
+	"Thrash Metal", "Anime", "JPop", "Synthpop",
+}
@@ -0,0 +1,158 @@
+package tags
+
+import (
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// mp4Atom is a box header: its type and the byte range of its payload.
+type mp4Atom struct {
+	typ        string
+	start, end int64
+}
+
+// mp4Atoms lists the atoms between start and end.
+func mp4Atoms(r io.ReaderAt, start, end int64) ([]mp4Atom, error) {
+	var atoms []mp4Atom
+	var hdr [16]byte
+	for off := start; off+8 <= end; {
+		if _, err := r.ReadAt(hdr[:8], off); err != nil {
+			return atoms, err
+		}
+		size := int64(binary.BigEndian.Uint32(hdr[:4]))
+		hdrLen := int64(8)
+		switch size {
+		case 0: // extends to the end of the enclosing box
+			size = end - off
+		case 1: // 64-bit size follows the type
+			if _, err := r.ReadAt(hdr[8:16], off+8); err != nil {
+				return atoms, err
+			}
+			size = int64(binary.BigEndian.Uint64(hdr[8:16]))
+			hdrLen = 16
+		}
+		if size < hdrLen || off+size > end {
+			break
+		}
+		atoms = append(atoms, mp4Atom{typ: string(hdr[4:8]), start: off + hdrLen, end: off + size})
+		off += size
+	}
+	return atoms, nil
+}
+
+// mp4Find returns the first child atom of the given type, or false.
+func mp4Find(r io.ReaderAt, start, end int64, typ string) (mp4Atom, bool) {
+	atoms, _ := mp4Atoms(r, start, end)
+	for _, a := range atoms {
+		if a.typ == typ {
+			return a, true
+		}
+	}
+	return mp4Atom{}, false
+}
+
+// readMP4 finds the iTunes metadata list (moov/udta/meta/ilst) and reads its items.
+func (b *builder) readMP4(r io.ReaderAt, size int64) error {
+	moov, ok := mp4Find(r, 0, size, "moov")
+	if !ok {
+		return nil
+	}
+	parent := moov
+	if udta, ok := mp4Find(r, moov.start, moov.end, "udta"); ok {
+		parent = udta
+	}
+	meta, ok := mp4Find(r, parent.start, parent.end, "meta")
+	if !ok {
+		return nil
+	}
+	// ISO meta is a full box (4 bytes of version and flags); QuickTime's is not.
+	var versionFlags [4]byte
+	if _, err := r.ReadAt(versionFlags[:], meta.start); err != nil {
+		return err
+	}
+	if versionFlags == [4]byte{} {
+		meta.start += 4
+	}
+	ilst, ok := mp4Find(r, meta.start, meta.end, "ilst")
+	if !ok {
+		return nil
+	}
+
+	items, err := mp4Atoms(r, ilst.start, ilst.end)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		children, err := mp4Atoms(r, item.start, item.end)
+		if err != nil {
+			return err
+		}
+		var name string
+		var values [][]byte
+		for _, c := range children {
+			switch c.typ {
+			case "name":
+				data, err := readAt(r, c.start, c.end-c.start)
+				if err != nil {
+					return err
+				}
+				name = string(skip(data, 4))
+			case "data":
+				data, err := readAt(r, c.start, c.end-c.start)
+				if err != nil {
+					return err
+				}
+				if len(data) >= 8 {
+					values = append(values, data[8:]) // type indicator, locale
+				}
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		value := values[0]
+
+		switch item.typ {
+		case "\xa9nam":
+			b.setTitle(string(value))
+		case "\xa9ART":
+			b.setArtist(string(value))
+		case "\xa9alb":
+			b.setAlbum(string(value))
+		case "\xa9gen":
+			b.setGenre(string(value))
+		case "gnre":
+			if len(value) >= 2 {
+				if g := int(binary.BigEndian.Uint16(value)) - 1; g >= 0 && g < len(id3Genres) {
+					b.setGenre(id3Genres[g])
+				}
+			}
+		case "\xa9day":
+			b.setYear(string(value))
+		case "\xa9cmt":
+			b.setComment(string(value))
+		case "\xa9pub":
+			b.setLabel(string(value))
+		case "tmpo":
+			if len(value) >= 2 {
+				b.setBPM(strconv.Itoa(int(binary.BigEndian.Uint16(value))))
+			}
+		case "covr":
+			for _, v := range values {
+				b.setArtwork(v, false)
+			}
+		case "----":
+			switch strings.ToUpper(name) {
+			case "INITIALKEY", "KEY":
+				b.setKey(string(value))
+			case "LABEL", "PUBLISHER", "ORGANIZATION":
+				b.setLabel(string(value))
+			case "BPM":
+				b.setBPM(string(value))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+// Package tags reads embedded metadata from audio files: ID3v2/ID3v1 (MP3, AIFF,
+// WAV), Vorbis comments (FLAC, Ogg Vorbis, Opus) and iTunes-style MP4 atoms (M4A).
+package tags
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned for containers we can't read tags from.
+var ErrUnsupportedFormat = errors.New("tags: unsupported format")
+
+// maxBlockSize bounds any single tag block read into memory, so a corrupt size
+// field can't make us allocate the whole file.
+const maxBlockSize = 32 << 20
+
+// Tags is the metadata we keep from a file's tags. Empty fields were not tagged.
+type Tags struct {
+	Title   string
+	Artist  string
+	Album   string
+	Genre   string
+	Label   string
+	Year    int
+	Comment string
+
+	// BPM and Key are whatever another tool wrote; we never trust them over analysis.
+	BPM float64
+	Key string
+
+	// ArtworkHash is the SHA-256 of the embedded front cover (or the first picture).
+	ArtworkHash string
+}
+
+// Read parses the tags of the file at path. Files in a supported container without
+// any tags return empty Tags and no error.
+func Read(path string) (*Tags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &builder{}
+	if err := b.readFile(f, info.Size()); err != nil {
+		return nil, fmt.Errorf("read tags %s: %w", path, err)
+	}
+	return &b.tags, nil
+}
+
+// builder accumulates tags from one or more blocks. The first value seen for a
+// field wins, so callers read the most authoritative block first.
+type builder struct {
+	tags       Tags
+	frontCover bool
+}
+
+func (b *builder) readFile(r io.ReaderAt, size int64) error {
+	var magic [12]byte
+	n, _ := r.ReadAt(magic[:], 0)
+	head := magic[:n]
+
+	switch {
+	case bytes.HasPrefix(head, []byte("ID3")):
+		end, err := b.readID3v2(r, 0, size)
+		if err != nil {
+			return err
+		}
+		// FLAC files sometimes carry an ID3v2 tag in front of the stream marker.
+		var marker [4]byte
+		if _, err := r.ReadAt(marker[:], end); err == nil && string(marker[:]) == "fLaC" {
+			return b.readFLAC(r, end, size)
+		}
+		b.readID3v1(r, size)
+		return nil
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return b.readFLAC(r, 0, size)
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return b.readOgg(r, size)
+	case len(head) >= 12 && string(head[:4]) == "FORM" && (string(head[8:12]) == "AIFF" || string(head[8:12]) == "AIFC"):
+		return b.readChunks(r, 12, size, binary.BigEndian)
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return b.readChunks(r, 12, size, binary.LittleEndian)
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return b.readMP4(r, size)
+	case len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		// Bare MPEG audio: the only place left for tags is an ID3v1 trailer.
+		b.readID3v1(r, size)
+		return nil
+	}
+	return ErrUnsupportedFormat
+}
+
+// readChunks walks IFF (AIFF, big-endian) or RIFF (WAV, little-endian) chunks
+// looking for an embedded ID3v2 tag.
+func (b *builder) readChunks(r io.ReaderAt, off, size int64, order binary.ByteOrder) error {
+	var hdr [8]byte
+	for off+8 <= size {
+		if _, err := r.ReadAt(hdr[:], off); err != nil {
+			return err
+		}
+		chunkSize := int64(order.Uint32(hdr[4:]))
+		id := string(hdr[:4])
+		if id == "ID3 " || id == "id3 " {
+			_, err := b.readID3v2(r, off+8, off+8+chunkSize)
+			return err
+		}
+		off += 8 + chunkSize + chunkSize%2
+	}
+	return nil
+}
+
+// readAt reads n bytes at off, refusing blocks larger than maxBlockSize.
+func readAt(r io.ReaderAt, off, n int64) ([]byte, error) {
+	if n < 0 || n > maxBlockSize {
+		return nil, fmt.Errorf("tag block of %d bytes at offset %d", n, off)
+	}
+	buf := make([]byte, n)
+	if _, err := r.ReadAt(buf, off); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (b *builder) setTitle(s string)   { setString(&b.tags.Title, s) }
+func (b *builder) setArtist(s string)  { setString(&b.tags.Artist, s) }
+func (b *builder) setAlbum(s string)   { setString(&b.tags.Album, s) }
+func (b *builder) setGenre(s string)   { setString(&b.tags.Genre, s) }
+func (b *builder) setLabel(s string)   { setString(&b.tags.Label, s) }
+func (b *builder) setComment(s string) { setString(&b.tags.Comment, s) }
+func (b *builder) setKey(s string)     { setString(&b.tags.Key, s) }
+
+func (b *builder) setYear(s string) {
+	if b.tags.Year == 0 {
+		b.tags.Year = parseYear(s)
+	}
+}
+
+func (b *builder) setBPM(s string) {
+	if b.tags.BPM != 0 {
+		return
+	}
+	s = strings.TrimSpace(strings.ReplaceAll(s, ",", "."))
+	if bpm, err := strconv.ParseFloat(s, 64); err == nil && bpm > 0 && bpm < 1000 {
+		b.tags.BPM = bpm
+	}
+}
+
+// setArtwork hashes an embedded picture. A front cover replaces any earlier
+// non-cover picture; otherwise the first picture wins.
+func (b *builder) setArtwork(data []byte, frontCover bool) {
+	if len(data) == 0 || b.frontCover || (b.tags.ArtworkHash != "" && !frontCover) {
+		return
+	}
+	sum := sha256.Sum256(data)
+	b.tags.ArtworkHash = hex.EncodeToString(sum[:])
+	b.frontCover = frontCover
+}
+
+func setString(dst *string, s string) {
+	s = strings.TrimSpace(strings.TrimRight(s, "\x00"))
+	if *dst == "" && s != "" {
+		*dst = s
+	}
+}
+
+// parseYear takes the year from "2019", "2019-05-01" or "2019/05" style dates.
+func parseYear(s string) int {
+	s = strings.TrimSpace(s)
+	if len(s) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(s[:4])
+	if err != nil || year < 1000 {
+		return 0
+	}
+	return year
+}
@@ -0,0 +1,405 @@
+package tags
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+var (
+	coverArt = []byte("\x89PNG fake cover")
+	backArt  = []byte("\xff\xd8 fake back")
+)
+
+func hashOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func readTags(t *testing.T, name string, data []byte) *Tags {
+	t.Helper()
+	tags, err := Read(writeFile(t, name, data))
+	if err != nil {
+		t.Fatalf("Read(%s): %v", name, err)
+	}
+	return tags
+}
+
+func synchsafeBytes(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+}
+
+func utf16Text(s string) []byte {
+	out := []byte{1, 0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = binary.LittleEndian.AppendUint16(out, u)
+	}
+	return out
+}
+
+// id3v2 builds an ID3v2.3 or v2.4 tag from frame IDs and payloads.
+func id3v2(major byte, frames ...any) []byte {
+	var body []byte
+	for i := 0; i < len(frames); i += 2 {
+		id, data := frames[i].(string), frames[i+1].([]byte)
+		body = append(body, id...)
+		if major == 4 {
+			body = append(body, synchsafeBytes(len(data))...)
+		} else {
+			body = binary.BigEndian.AppendUint32(body, uint32(len(data)))
+		}
+		body = append(body, 0, 0)
+		body = append(body, data...)
+	}
+	body = append(body, make([]byte, 32)...) // padding
+	hdr := append([]byte{'I', 'D', '3', major, 0, 0}, synchsafeBytes(len(body))...)
+	return append(hdr, body...)
+}
+
+func mpegFrames() []byte {
+	return bytes.Repeat([]byte{0xFF, 0xFB, 0x90, 0x00}, 64)
+}
+
+func TestReadID3v23(t *testing.T) {
+	apic := func(pictureType byte, img []byte) []byte {
+		return append(append([]byte{0}, "image/png\x00"...), append([]byte{pictureType, 0}, img...)...)
+	}
+	tag := id3v2(3,
+		"TIT2", utf16Text("Nachtschwärmer"),
+		"TPE1", []byte("\x00K\xf6lsch"), // ISO-8859-1
+		"TALB", []byte("\x00Speicher 99"),
+		"TCON", []byte("\x00(35)"),
+		"TPUB", []byte("\x00Kompakt"),
+		"TYER", []byte("\x002019"),
+		"COMM", []byte("\x00engiTunNORM\x00 0000 0001"),
+		"COMM", []byte("\x00eng\x00Peak time weapon"),
+		"TBPM", []byte("\x00124"),
+		"TKEY", []byte("\x00Am"),
+		"APIC", apic(4, backArt),
+		"APIC", apic(3, coverArt),
+	)
+	got := readTags(t, "track.mp3", append(tag, mpegFrames()...))
+
+	want := Tags{
+		Title:       "Nachtschwärmer",
+		Artist:      "Kölsch",
+		Album:       "Speicher 99",
+		Genre:       "House",
+		Label:       "Kompakt",
+		Year:        2019,
+		Comment:     "Peak time weapon",
+		BPM:         124,
+		Key:         "Am",
+		ArtworkHash: hashOf(coverArt),
+	}
+	if *got != want {
+		t.Fatalf("tags = %+v, want %+v", *got, want)
+	}
+}
+
+func TestReadID3v24AndTXXX(t *testing.T) {
+	tag := id3v2(4,
+		"TIT2", []byte("\x03Strobe\x00Radio Edit"),
+		"TPE1", []byte("\x03deadmau5"),
+		"TDRC", []byte("\x032009-09-22"),
+		"TCON", []byte("\x0352"),
+		"TXXX", []byte("\x03INITIALKEY\x008B"),
+		"TXXX", []byte("\x03LABEL\x00mau5trap"),
+		"TBPM", []byte("\x03128.00"),
+	)
+	got := readTags(t, "strobe.mp3", append(tag, mpegFrames()...))
+
+	if got.Title != "Strobe" || got.Artist != "deadmau5" || got.Year != 2009 {
+		t.Fatalf("title/artist/year = %q/%q/%d", got.Title, got.Artist, got.Year)
+	}
+	if got.Genre != "Electronic" || got.Key != "8B" || got.Label != "mau5trap" || got.BPM != 128 {
+		t.Fatalf("genre/key/label/bpm = %q/%q/%q/%v", got.Genre, got.Key, got.Label, got.BPM)
+	}
+}
+
+func TestReadID3v22(t *testing.T) {
+	frame := func(id string, data []byte) []byte {
+		n := len(data)
+		return append(append([]byte(id), byte(n>>16), byte(n>>8), byte(n)), data...)
+	}
+	var body []byte
+	body = append(body, frame("TT2", []byte("\x00Old Tune"))...)
+	body = append(body, frame("TP1", []byte("\x00Old Artist"))...)
+	body = append(body, frame("PIC", append([]byte("\x00JPG\x03\x00"), coverArt...))...)
+	tag := append([]byte{'I', 'D', '3', 2, 0, 0}, synchsafeBytes(len(body))...)
+	got := readTags(t, "old.mp3", append(append(tag, body...), mpegFrames()...))
+
+	if got.Title != "Old Tune" || got.Artist != "Old Artist" || got.ArtworkHash != hashOf(coverArt) {
+		t.Fatalf("tags = %+v", *got)
+	}
+}
+
+func TestReadID3v1Fallback(t *testing.T) {
+	v1 := make([]byte, 128)
+	copy(v1, "TAG")
+	copy(v1[3:], "V1 Title")
+	copy(v1[33:], "V1 Artist")
+	copy(v1[63:], "V1 Album")
+	copy(v1[93:], "1997")
+	v1[127] = 31 // Trance
+
+	got := readTags(t, "v1.mp3", append(mpegFrames(), v1...))
+	if got.Title != "V1 Title" || got.Artist != "V1 Artist" || got.Album != "V1 Album" || got.Year != 1997 || got.Genre != "Trance" {
+		t.Fatalf("tags = %+v", *got)
+	}
+
+	// ID3v2 wins over the v1 trailer; v1 only fills the gaps.
+	tag := id3v2(3, "TIT2", []byte("\x00V2 Title"))
+	got = readTags(t, "both.mp3", append(append(tag, mpegFrames()...), v1...))
+	if got.Title != "V2 Title" || got.Artist != "V1 Artist" {
+		t.Fatalf("title/artist = %q/%q", got.Title, got.Artist)
+	}
+}
+
+func vorbisComments(fields ...string) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, 6)
+	out = append(out, "cancun"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(fields)))
+	for _, f := range fields {
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(f)))
+		out = append(out, f...)
+	}
+	return out
+}
+
+func flacPicture(pictureType uint32, img []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, pictureType)
+	out = binary.BigEndian.AppendUint32(out, 9)
+	out = append(out, "image/png"...)
+	out = binary.BigEndian.AppendUint32(out, 0)
+	out = append(out, make([]byte, 16)...)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(img)))
+	return append(out, img...)
+}
+
+func flacBlock(blockType byte, last bool, data []byte) []byte {
+	if last {
+		blockType |= 0x80
+	}
+	n := len(data)
+	return append([]byte{blockType, byte(n >> 16), byte(n >> 8), byte(n)}, data...)
+}
+
+func TestReadFLAC(t *testing.T) {
+	file := []byte("fLaC")
+	file = append(file, flacBlock(0, false, make([]byte, 34))...) // STREAMINFO
+	file = append(file, flacBlock(flacBlockVorbisComment, false, vorbisComments(
+		"title=Dark Matter",
+		"ARTIST=Sian",
+		"Album=Octopus",
+		"GENRE=Techno",
+		"ORGANIZATION=Octopus Records",
+		"DATE=2016-03-04",
+		"COMMENT=warm up",
+		"BPM=126",
+		"INITIALKEY=5A",
+	))...)
+	file = append(file, flacBlock(flacBlockPicture, true, flacPicture(3, coverArt))...)
+	file = append(file, 0xFF, 0xF8, 0, 0) // first audio frame
+
+	got := readTags(t, "dark.flac", file)
+	want := Tags{
+		Title:       "Dark Matter",
+		Artist:      "Sian",
+		Album:       "Octopus",
+		Genre:       "Techno",
+		Label:       "Octopus Records",
+		Year:        2016,
+		Comment:     "warm up",
+		BPM:         126,
+		Key:         "5A",
+		ArtworkHash: hashOf(coverArt),
+	}
+	if *got != want {
+		t.Fatalf("tags = %+v, want %+v", *got, want)
+	}
+
+	// An ID3v2 tag in front of the FLAC marker is read first, then the Vorbis comments.
+	prefixed := append(id3v2(3, "TIT2", []byte("\x00ID3 Title")), file...)
+	got = readTags(t, "prefixed.flac", prefixed)
+	if got.Title != "ID3 Title" || got.Artist != "Sian" {
+		t.Fatalf("title/artist = %q/%q", got.Title, got.Artist)
+	}
+}
+
+// oggPage wraps whole packets into a single Ogg page.
+func oggPage(serial uint32, seq uint32, packets ...[]byte) []byte {
+	var segments, body []byte
+	for _, p := range packets {
+		n := len(p)
+		for n >= 255 {
+			segments = append(segments, 255)
+			n -= 255
+		}
+		segments = append(segments, byte(n))
+		body = append(body, p...)
+	}
+	hdr := []byte("OggS\x00\x00")
+	hdr = append(hdr, make([]byte, 8)...) // granule position
+	hdr = binary.LittleEndian.AppendUint32(hdr, serial)
+	hdr = binary.LittleEndian.AppendUint32(hdr, seq)
+	hdr = append(hdr, 0, 0, 0, 0) // CRC (not checked)
+	hdr = append(hdr, byte(len(segments)))
+	return append(append(hdr, segments...), body...)
+}
+
+func TestReadOgg(t *testing.T) {
+	picture := base64.StdEncoding.EncodeToString(flacPicture(3, coverArt))
+	long := bytes.Repeat([]byte("x"), 600) // forces 255-byte lacing
+
+	opus := oggPage(7, 0, []byte("OpusHead\x01\x02"))
+	opus = append(opus, oggPage(7, 1, append([]byte("OpusTags"), vorbisComments(
+		"TITLE=Opus Track",
+		"ARTIST=Opus Artist",
+		"DESCRIPTION="+string(long),
+		"METADATA_BLOCK_PICTURE="+picture,
+	)...))...)
+	got := readTags(t, "track.opus", opus)
+	if got.Title != "Opus Track" || got.Artist != "Opus Artist" || len(got.Comment) != 600 || got.ArtworkHash != hashOf(coverArt) {
+		t.Fatalf("opus tags = %+v", *got)
+	}
+
+	vorbis := oggPage(9, 0, []byte("\x01vorbis ident"))
+	vorbis = append(vorbis, oggPage(9, 1, append([]byte("\x03vorbis"), append(vorbisComments("TITLE=Vorbis Track"), 1)...), []byte("\x05vorbis setup"))...)
+	got = readTags(t, "track.ogg", vorbis)
+	if got.Title != "Vorbis Track" {
+		t.Fatalf("vorbis title = %q", got.Title)
+	}
+}
+
+func atom(typ string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(out, typ...), body...)
+}
+
+func mp4Data(kind uint32, value []byte) []byte {
+	payload := binary.BigEndian.AppendUint32(nil, kind)
+	payload = append(payload, 0, 0, 0, 0)
+	return atom("data", append(payload, value...))
+}
+
+func TestReadMP4(t *testing.T) {
+	ilst := atom("ilst",
+		atom("\xa9nam", mp4Data(1, []byte("M4A Title"))),
+		atom("\xa9ART", mp4Data(1, []byte("M4A Artist"))),
+		atom("\xa9alb", mp4Data(1, []byte("M4A Album"))),
+		atom("gnre", mp4Data(0, []byte{0, 36})), // ID3 genre 35 + 1
+		atom("\xa9day", mp4Data(1, []byte("2021-01-01T00:00:00Z"))),
+		atom("\xa9cmt", mp4Data(1, []byte("from the promo pool"))),
+		atom("tmpo", mp4Data(21, []byte{0, 122})),
+		atom("covr", mp4Data(14, coverArt)),
+		atom("----",
+			atom("mean", []byte("\x00\x00\x00\x00com.apple.iTunes")),
+			atom("name", []byte("\x00\x00\x00\x00initialkey")),
+			mp4Data(1, []byte("10A"))),
+		atom("----",
+			atom("mean", []byte("\x00\x00\x00\x00com.apple.iTunes")),
+			atom("name", []byte("\x00\x00\x00\x00LABEL")),
+			mp4Data(1, []byte("Innervisions"))),
+	)
+	meta := atom("meta", append([]byte{0, 0, 0, 0}, append(atom("hdlr", make([]byte, 25)), ilst...)...))
+	file := atom("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	file = append(file, atom("mdat", make([]byte, 64))...) // moov after mdat, as many encoders write it
+	file = append(file, atom("moov", atom("mvhd", make([]byte, 100)), atom("udta", meta))...)
+
+	got := readTags(t, "track.m4a", file)
+	want := Tags{
+		Title:       "M4A Title",
+		Artist:      "M4A Artist",
+		Album:       "M4A Album",
+		Genre:       "House",
+		Label:       "Innervisions",
+		Year:        2021,
+		Comment:     "from the promo pool",
+		BPM:         122,
+		Key:         "10A",
+		ArtworkHash: hashOf(coverArt),
+	}
+	if *got != want {
+		t.Fatalf("tags = %+v, want %+v", *got, want)
+	}
+}
+
+func TestReadAIFFAndWAVChunks(t *testing.T) {
+	tag := id3v2(4, "TIT2", []byte("\x03Chunked"))
+
+	aiffBody := []byte("AIFF")
+	aiffBody = append(aiffBody, "COMM"...)
+	aiffBody = binary.BigEndian.AppendUint32(aiffBody, 18)
+	aiffBody = append(aiffBody, make([]byte, 18)...)
+	aiffBody = append(aiffBody, "ID3 "...)
+	aiffBody = binary.BigEndian.AppendUint32(aiffBody, uint32(len(tag)))
+	aiffBody = append(aiffBody, tag...)
+	aiff := append([]byte("FORM"), binary.BigEndian.AppendUint32(nil, uint32(len(aiffBody)))...)
+	if got := readTags(t, "track.aiff", append(aiff, aiffBody...)); got.Title != "Chunked" {
+		t.Fatalf("aiff title = %q", got.Title)
+	}
+
+	wavBody := []byte("WAVE")
+	wavBody = append(wavBody, "fmt "...)
+	wavBody = binary.LittleEndian.AppendUint32(wavBody, 16)
+	wavBody = append(wavBody, make([]byte, 16)...)
+	wavBody = append(wavBody, "id3 "...)
+	wavBody = binary.LittleEndian.AppendUint32(wavBody, uint32(len(tag)))
+	wavBody = append(wavBody, tag...)
+	wav := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(wavBody)))...)
+	if got := readTags(t, "track.wav", append(wav, wavBody...)); got.Title != "Chunked" {
+		t.Fatalf("wav title = %q", got.Title)
+	}
+}
+
+func TestReadUntaggedAndUnsupported(t *testing.T) {
+	got := readTags(t, "bare.mp3", mpegFrames())
+	if *got != (Tags{}) {
+		t.Fatalf("untagged mp3 = %+v, want empty", *got)
+	}
+
+	_, err := Read(writeFile(t, "notes.mp3", []byte("just some text")))
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("err = %v, want ErrUnsupportedFormat", err)
+	}
+
+	// A corrupt frame size must not panic or read past the tag.
+	corrupt := id3v2(3, "TIT2", []byte("\x00ok"))
+	binary.BigEndian.PutUint32(corrupt[14:18], 1<<30)
+	if got := readTags(t, "corrupt.mp3", append(corrupt, mpegFrames()...)); got.Title != "" {
+		t.Fatalf("corrupt title = %q", got.Title)
+	}
+}
+
+func TestGenreReferences(t *testing.T) {
+	for in, want := range map[string]string{
+		"(18)":        "Techno",
+		"(18)Minimal": "Minimal",
+		"127":         "Drum & Bass",
+		"(RX)":        "Remix",
+		"Deep House":  "Deep House",
+		"(999)":       "(999)",
+	} {
+		if got := id3Genre(in); got != want {
+			t.Errorf("id3Genre(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+const (
+	flacBlockVorbisComment = 4
+	flacBlockPicture       = 6
+)
+
+// readFLAC walks the FLAC metadata blocks following the "fLaC" marker at off.
+func (b *builder) readFLAC(r io.ReaderAt, off, size int64) error {
+	off += 4
+	var hdr [4]byte
+	for off+4 <= size {
+		if _, err := r.ReadAt(hdr[:], off); err != nil {
+			return err
+		}
+		last := hdr[0]&0x80 != 0
+		blockType := hdr[0] & 0x7F
+		length := int64(hdr[1])<<16 | int64(hdr[2])<<8 | int64(hdr[3])
+		off += 4
+
+		switch blockType {
+		case flacBlockVorbisComment:
+			data, err := readAt(r, off, length)
+			if err != nil {
+				return err
+			}
+			b.readVorbisComments(data)
+		case flacBlockPicture:
+			data, err := readAt(r, off, length)
+			if err != nil {
+				return err
+			}
+			b.readFLACPicture(data)
+		}
+
+		off += length
+		if last {
+			break
+		}
+	}
+	return nil
+}
+
+// readOgg reassembles the second packet of the first logical stream, which holds
+// the comment header for both Vorbis ("\x03vorbis") and Opus ("OpusTags").
+func (b *builder) readOgg(r io.ReaderAt, size int64) error {
+	var (
+		off     int64
+		serial  uint32
+		packets int
+		packet  []byte
+		hdr     [27]byte
+	)
+	for off+27 <= size {
+		if _, err := r.ReadAt(hdr[:], off); err != nil {
+			return err
+		}
+		if string(hdr[:4]) != "OggS" {
+			return nil
+		}
+		pageSerial := binary.LittleEndian.Uint32(hdr[14:18])
+		if off == 0 {
+			serial = pageSerial
+		}
+		segments, err := readAt(r, off+27, int64(hdr[26]))
+		if err != nil {
+			return err
+		}
+		off += 27 + int64(len(segments))
+
+		for _, seg := range segments {
+			if pageSerial == serial && packets == 1 {
+				data, err := readAt(r, off, int64(seg))
+				if err != nil {
+					return err
+				}
+				if len(packet)+len(data) > maxBlockSize {
+					return nil
+				}
+				packet = append(packet, data...)
+			}
+			off += int64(seg)
+			if pageSerial == serial && seg < 255 {
+				packets++
+				if packets == 2 {
+					switch {
+					case bytes.HasPrefix(packet, []byte("\x03vorbis")):
+						b.readVorbisComments(packet[7:])
+					case bytes.HasPrefix(packet, []byte("OpusTags")):
+						b.readVorbisComments(packet[8:])
+					}
+					return nil
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readVorbisComments parses a Vorbis comment block: a vendor string followed by
+// NAME=value pairs, all length-prefixed little-endian.
+func (b *builder) readVorbisComments(data []byte) {
+	next := func() ([]byte, bool) {
+		if len(data) < 4 {
+			return nil, false
+		}
+		n := int(binary.LittleEndian.Uint32(data))
+		if n < 0 || 4+n > len(data) {
+			return nil, false
+		}
+		field := data[4 : 4+n]
+		data = data[4+n:]
+		return field, true
+	}
+
+	if _, ok := next(); !ok { // vendor
+		return
+	}
+	if len(data) < 4 {
+		return
+	}
+	count := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+
+	for i := 0; i < count; i++ {
+		field, ok := next()
+		if !ok {
+			return
+		}
+		name, value, ok := strings.Cut(string(field), "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(name) {
+		case "TITLE":
+			b.setTitle(value)
+		case "ARTIST":
+			b.setArtist(value)
+		case "ALBUM":
+			b.setAlbum(value)
+		case "GENRE":
+			b.setGenre(value)
+		case "LABEL", "ORGANIZATION", "PUBLISHER":
+			b.setLabel(value)
+		case "DATE", "YEAR", "ORIGINALDATE":
+			b.setYear(value)
+		case "COMMENT", "DESCRIPTION":
+			b.setComment(value)
+		case "BPM", "TEMPO":
+			b.setBPM(value)
+		case "INITIALKEY", "KEY":
+			b.setKey(value)
+		case "METADATA_BLOCK_PICTURE":
+			if raw, err := base64.StdEncoding.DecodeString(value); err == nil {
+				b.readFLACPicture(raw)
+			}
+		}
+	}
+}
+
+// readFLACPicture parses a FLAC PICTURE block (also used base64-encoded inside
+// Vorbis comments): type, MIME, description, four dimensions, then the image.
+func (b *builder) readFLACPicture(data []byte) {
+	u32 := func() (uint32, bool) {
+		if len(data) < 4 {
+			return 0, false
+		}
+		v := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		return v, true
+	}
+
+	pictureType, ok := u32()
+	if !ok {
+		return
+	}
+	for i := 0; i < 2; i++ { // MIME type, description
+		n, ok := u32()
+		if !ok || int64(n) > int64(len(data)) {
+			return
+		}
+		data = data[n:]
+	}
+	for i := 0; i < 4; i++ { // width, height, depth, colours
+		if _, ok := u32(); !ok {
+			return
+		}
+	}
+	n, ok := u32()
+	if !ok || int64(n) > int64(len(data)) {
+		return
+	}
+	b.setArtwork(data[:n], pictureType == 3)
+}
@@ -0,0 +1,122 @@
+// Package tempo matches the tempos of two tracks the way a DJ beatmatches them:
+// directly, at half or double time, or over a 3:2 polyrhythm, by pitching the
+// incoming track within the deck's pitch range. Without master tempo (key lock)
+// pitching also shifts the incoming track's key.
+package tempo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultPitchRange is the pitch either way a deck allows when Options doesn't
+// set one, as a fraction: ±8% like a CDJ.
+const DefaultPitchRange = 0.08
+
+// Options describes the deck the incoming track is played on.
+type Options struct {
+	PitchRange  float64 // fraction either way; DefaultPitchRange when zero
+	MasterTempo bool    // key lock on: pitching leaves the key alone
+}
+
+// ratio is a way of counting the incoming track against the outgoing one.
+type ratio struct {
+	value  float64 // incoming tempo is heard at this multiple
+	weight float64 // how natural the blend sounds, 0-1
+	name   string
+}
+
+var ratios = []ratio{
+	{1, 1, ""},
+	{2, 1, "2x"},
+	{0.5, 1, "half time"},
+	{1.5, 0.8, "3:2"},
+	{2.0 / 3, 0.8, "2:3"},
+}
+
+// Match is how an incoming track is beatmatched to the outgoing one.
+type Match struct {
+	Known     bool    // both tempos are known
+	Ratio     float64 // the incoming track is played at this multiple of its tempo
+	Weight    float64 // 1 for straight, half and double time; less for 3:2
+	Delta     float64 // BPM the incoming track moves by to meet the outgoing, at Ratio
+	Pitch     float64 // fraction the incoming track is pitched by; negative slows it
+	Semitones float64 // key shift the pitch causes; zero with master tempo
+	InRange   bool    // the pitch is within the deck's range
+	Range     float64 // the deck's pitch range used
+	name      string
+}
+
+// Compare finds the ratio that needs the least pitch to play to after from, given
+// their tempos in BPM.
+func Compare(from, to float64, opts Options) Match {
+	pitchRange := opts.PitchRange
+	if pitchRange <= 0 {
+		pitchRange = DefaultPitchRange
+	}
+	if from <= 0 || to <= 0 {
+		return Match{Range: pitchRange}
+	}
+
+	var best Match
+	for i, r := range ratios {
+		pitch := from/(to*r.value) - 1
+		if i > 0 && math.Abs(pitch) >= math.Abs(best.Pitch) {
+			continue
+		}
+		best = Match{
+			Known:  true,
+			Ratio:  r.value,
+			Weight: r.weight,
+			Delta:  to*r.value - from,
+			Pitch:  pitch,
+			Range:  pitchRange,
+			name:   r.name,
+		}
+	}
+	best.InRange = math.Abs(best.Pitch) <= pitchRange+1e-9
+	if !opts.MasterTempo {
+		best.Semitones = 12 * math.Log2(1+best.Pitch)
+	}
+	return best
+}
+
+// Score rates the match from 0 to 1: 1 for identical tempos, falling with the
+// pitch needed to 0 at the edge of the pitch range. Unknown tempos score 0.
+func (m Match) Score() float64 {
+	if !m.Known || !m.InRange {
+		return 0
+	}
+	return m.Weight * (1 - math.Abs(m.Pitch)/m.Range)
+}
+
+// KeyShift is the whole number of semitones pitching moves the incoming key by.
+func (m Match) KeyShift() int {
+	return int(math.Round(m.Semitones))
+}
+
+// String describes the move, e.g. "play at 2x; +1.6% pitch".
+func (m Match) String() string {
+	if !m.Known {
+		return "unknown tempo"
+	}
+	var parts []string
+	if m.name != "" {
+		parts = append(parts, "play at "+m.name)
+	}
+	if pitch := m.Pitch * 100; math.Abs(pitch) >= 0.05 {
+		part := fmt.Sprintf("%+.1f%% pitch", pitch)
+		if !m.InRange {
+			part += fmt.Sprintf(", beyond ±%.0f%%", m.Range*100)
+		}
+		if shift := m.KeyShift(); shift != 0 {
+			part += fmt.Sprintf(", key %+d st", shift)
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return "tempo match"
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,68 @@
+package tempo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to float64
+		opts     Options
+		ratio    float64
+		inRange  bool
+		describe string
+	}{
+		{"same tempo", 128, 128, Options{}, 1, true, "tempo match"},
+		{"small pitch", 126, 128, Options{}, 1, true, "-1.6% pitch"},
+		{"halftime into dnb", 174, 87, Options{}, 2, true, "play at 2x"},
+		{"dnb into halftime", 87, 174, Options{}, 0.5, true, "play at half time"},
+		{"three against two", 120, 80, Options{}, 1.5, true, "play at 3:2"},
+		{"beyond range", 120, 135, Options{MasterTempo: true}, 1, false, "-11.1% pitch, beyond ±8%"},
+		{"wider deck", 120, 135, Options{PitchRange: 0.16, MasterTempo: true}, 1, true, "-11.1% pitch"},
+		{"key shift", 120, 126, Options{}, 1, true, "-4.8% pitch, key -1 st"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Compare(tt.from, tt.to, tt.opts)
+			if m.Ratio != tt.ratio || m.InRange != tt.inRange {
+				t.Errorf("ratio %v in range %v, want %v %v", m.Ratio, m.InRange, tt.ratio, tt.inRange)
+			}
+			if got := m.String(); got != tt.describe {
+				t.Errorf("String() = %q, want %q", got, tt.describe)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	if s := Compare(128, 128, Options{}).Score(); s != 1 {
+		t.Errorf("identical tempos = %v, want 1", s)
+	}
+	if s := Compare(174, 87, Options{}).Score(); s != 1 {
+		t.Errorf("double time = %v, want 1", s)
+	}
+	half := Compare(100, 104, Options{})
+	if want := 1 - math.Abs(100.0/104-1)/DefaultPitchRange; math.Abs(half.Score()-want) > 1e-9 {
+		t.Errorf("4%% pitch = %v, want %v", half.Score(), want)
+	}
+	if s := Compare(120, 80, Options{}).Score(); s >= 1 || s <= 0 {
+		t.Errorf("3:2 = %v, want between 0 and 1", s)
+	}
+	if s := Compare(120, 140, Options{}).Score(); s != 0 {
+		t.Errorf("out of range = %v, want 0", s)
+	}
+	if m := Compare(0, 128, Options{}); m.Known || m.Score() != 0 {
+		t.Errorf("unknown tempo = %+v", m)
+	}
+}
+
+func TestMasterTempoKeepsKey(t *testing.T) {
+	if shift := Compare(120, 126, Options{}).KeyShift(); shift != -1 {
+		t.Errorf("key shift = %d, want -1", shift)
+	}
+	if shift := Compare(120, 126, Options{MasterTempo: true}).KeyShift(); shift != 0 {
+		t.Errorf("key shift with master tempo = %d, want 0", shift)
+	}
+}
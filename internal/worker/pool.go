@@ -0,0 +1,390 @@
+// Package worker drains the jobs table with a supervised pool of background workers.
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/cartomix/cancun/gen/go/common"
+	"github.com/cartomix/cancun/internal/analyzer"
+	"github.com/cartomix/cancun/internal/storage"
+)
+
+// Handler processes a claimed job and returns an optional result payload.
+type Handler func(ctx context.Context, job *storage.Job) (map[string]any, error)
+
+// Config controls pool concurrency, polling and retry behaviour.
+type Config struct {
+	Concurrency  int           // number of jobs processed in parallel
+	PollInterval time.Duration // how often to look for work when the queue is empty
+	JobTimeout   time.Duration // upper bound for a single job
+	RetryBackoff time.Duration // base delay before a failed job is retried (doubles per attempt)
+	MaxBackoff   time.Duration // cap for the exponential backoff
+}
+
+// DefaultConfig returns sensible defaults for a desktop engine.
+func DefaultConfig() Config {
+	return Config{
+		Concurrency:  2,
+		PollInterval: 2 * time.Second,
+		JobTimeout:   10 * time.Minute,
+		RetryBackoff: 30 * time.Second,
+		MaxBackoff:   30 * time.Minute,
+	}
+}
+
+// Pool claims jobs by priority and dispatches them to a fixed set of workers.
+type Pool struct {
+	db     *storage.DB
+	cfg    Config
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	handlers map[storage.JobType]Handler
+	types    []storage.JobType
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPool creates a pool. Register handlers before calling Start.
+func NewPool(db *storage.DB, cfg Config, logger *slog.Logger) *Pool {
+	def := DefaultConfig()
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = def.Concurrency
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = def.PollInterval
+	}
+	if cfg.JobTimeout <= 0 {
+		cfg.JobTimeout = def.JobTimeout
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = def.RetryBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = def.MaxBackoff
+	}
+
+	return &Pool{
+		db:       db,
+		cfg:      cfg,
+		logger:   logger,
+		handlers: make(map[storage.JobType]Handler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Handle registers the handler for a job type. Types are claimed in registration order.
+func (p *Pool) Handle(jobType storage.JobType, h Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.handlers[jobType]; !ok {
+		p.types = append(p.types, jobType)
+	}
+	p.handlers[jobType] = h
+}
+
+// Start resets jobs left running by a previous process and launches the workers.
+func (p *Pool) Start(ctx context.Context) error {
+	// Nothing can legitimately be running before we start, so every running row is stalled.
+	reset, err := p.db.ResetStalledJobs(0)
+	if err != nil {
+		return fmt.Errorf("reset stalled jobs: %w", err)
+	}
+	failed, err := p.db.FailStalledJobs(0)
+	if err != nil {
+		return fmt.Errorf("fail stalled jobs: %w", err)
+	}
+	if reset > 0 || failed > 0 {
+		p.logger.Info("recovered stalled jobs", "requeued", reset, "failed", failed)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+
+	jobs := make(chan *storage.Job)
+	for i := 0; i < p.cfg.Concurrency; i++ {
+		p.wg.Add(1)
+		go p.supervise(ctx, i, jobs)
+	}
+
+	p.wg.Add(1)
+	go p.dispatch(ctx, jobs)
+
+	p.logger.Info("job worker pool started", "concurrency", p.cfg.Concurrency)
+	return nil
+}
+
+// Stop cancels in-flight jobs and waits for all workers to exit.
+func (p *Pool) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	p.wg.Wait()
+	p.logger.Info("job worker pool stopped")
+}
+
+// Notify wakes the dispatcher so newly enqueued jobs start without waiting for the next poll.
+func (p *Pool) Notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch is the single claimer: it serialises ClaimJob so SQLite never sees competing writers.
+func (p *Pool) dispatch(ctx context.Context, jobs chan<- *storage.Job) {
+	defer p.wg.Done()
+	defer close(jobs)
+
+	ticker := time.NewTicker(p.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := p.claim()
+		if err != nil {
+			p.logger.Warn("failed to claim job", "error", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-p.wake:
+			}
+			continue
+		}
+
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+			// Hand the claimed job back so the next start picks it up.
+			_ = p.db.RequeueJob(job.ID)
+			return
+		}
+	}
+}
+
+func (p *Pool) claim() (*storage.Job, error) {
+	p.mu.Lock()
+	types := append([]storage.JobType(nil), p.types...)
+	p.mu.Unlock()
+
+	for _, t := range types {
+		job, err := p.db.ClaimJob(t)
+		if err != nil {
+			return nil, err
+		}
+		if job != nil {
+			return job, nil
+		}
+	}
+	return nil, nil
+}
+
+// supervise runs a worker loop and restarts it if a handler panics.
+func (p *Pool) supervise(ctx context.Context, id int, jobs <-chan *storage.Job) {
+	defer p.wg.Done()
+	for {
+		if done := p.runWorker(ctx, id, jobs); done {
+			return
+		}
+		p.logger.Warn("restarting job worker after panic", "worker", id)
+	}
+}
+
+func (p *Pool) runWorker(ctx context.Context, id int, jobs <-chan *storage.Job) (done bool) {
+	var current *storage.Job
+	defer func() {
+		if r := recover(); r != nil {
+			p.logger.Error("job worker panic", "worker", id, "panic", r, "stack", string(debug.Stack()))
+			if current != nil {
+				p.finish(ctx, current, nil, fmt.Errorf("panic: %v", r))
+			}
+			done = false
+		}
+	}()
+
+	for job := range jobs {
+		current = job
+		p.process(ctx, job)
+		current = nil
+	}
+	return true
+}
+
+func (p *Pool) process(ctx context.Context, job *storage.Job) {
+	p.mu.Lock()
+	h := p.handlers[job.Type]
+	p.mu.Unlock()
+
+	if h == nil {
+		p.finish(ctx, job, nil, fmt.Errorf("no handler for job type %q", job.Type))
+		return
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, p.cfg.JobTimeout)
+	defer cancel()
+
+	start := time.Now()
+	result, err := h(jobCtx, job)
+	p.logger.Debug("job finished", "job_id", job.ID, "type", job.Type, "attempt", job.Attempts, "duration", time.Since(start), "error", err)
+	p.finish(ctx, job, result, err)
+}
+
+func (p *Pool) finish(ctx context.Context, job *storage.Job, result map[string]any, err error) {
+	if err == nil {
+		if cerr := p.db.CompleteJob(job.ID, result); cerr != nil {
+			p.logger.Error("failed to complete job", "job_id", job.ID, "error", cerr)
+		}
+		return
+	}
+
+	// Shutdown interrupted the job; requeue it immediately without backoff or
+	// counting the attempt.
+	if ctx.Err() != nil {
+		if rerr := p.db.RequeueJob(job.ID); rerr != nil {
+			p.logger.Warn("failed to requeue interrupted job", "job_id", job.ID, "error", rerr)
+		}
+		return
+	}
+
+	if job.Attempts < job.MaxAttempts {
+		delay := p.backoff(job.Attempts)
+		p.logger.Warn("job failed, will retry",
+			"job_id", job.ID,
+			"type", job.Type,
+			"attempt", job.Attempts,
+			"max_attempts", job.MaxAttempts,
+			"retry_in", delay,
+			"error", err,
+		)
+		if rerr := p.db.RetryJobAfter(job.ID, err.Error(), time.Now().Add(delay)); rerr != nil {
+			p.logger.Error("failed to reschedule job", "job_id", job.ID, "error", rerr)
+		}
+		return
+	}
+
+	p.logger.Error("job failed permanently", "job_id", job.ID, "type", job.Type, "attempts", job.Attempts, "error", err)
+	if ferr := p.db.FailJob(job.ID, err.Error()); ferr != nil {
+		p.logger.Error("failed to mark job failed", "job_id", job.ID, "error", ferr)
+	}
+}
+
+// backoff returns RetryBackoff * 2^(attempt-1), capped at MaxBackoff.
+func (p *Pool) backoff(attempt int) time.Duration {
+	delay := p.cfg.RetryBackoff
+	for i := 1; i < attempt && delay < p.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.cfg.MaxBackoff {
+		delay = p.cfg.MaxBackoff
+	}
+	return delay
+}
+
+// AnalyzeHandler returns a handler that runs analyze jobs through the given backend
+// and persists the result. Payload: {"track_id": int, "analysis_version": int, "force": bool}.
+func AnalyzeHandler(db *storage.DB, backend analyzer.Analyzer) Handler {
+	return func(ctx context.Context, job *storage.Job) (map[string]any, error) {
+		trackID, ok := payloadInt(job.Payload, "track_id")
+		if !ok {
+			return nil, errors.New("payload missing track_id")
+		}
+		version := int32(1)
+		if v, ok := payloadInt(job.Payload, "analysis_version"); ok && v > 0 {
+			version = int32(v)
+		}
+		force, _ := job.Payload["force"].(bool)
+
+		track, err := db.GetTrackByID(trackID)
+		if err != nil {
+			return nil, fmt.Errorf("load track %d: %w", trackID, err)
+		}
+
+		if !force {
+			if rec, err := db.LatestAnalysisRecord(track.ID); err == nil && rec.Status == storage.AnalysisStatusComplete && rec.Version >= version {
+				return map[string]any{"track_id": track.ID, "skipped": true}, nil
+			}
+		}
+
+		req := analyzer.NewJob(&common.TrackId{ContentHash: track.ContentHash, Path: track.Path}, track.Path, version)
+		res, err := backend.AnalyzeTrack(ctx, req)
+		if err != nil {
+			if job.Attempts >= job.MaxAttempts && ctx.Err() == nil {
+				_ = db.MarkAnalysisFailure(track.ID, version, err.Error())
+			}
+			return nil, err
+		}
+
+		rec, err := storage.AnalysisRecordFromProto(track.ID, version, res.GetAnalysis())
+		if err != nil {
+			return nil, fmt.Errorf("marshal analysis: %w", err)
+		}
+		if err := db.UpsertAnalysis(rec); err != nil {
+			return nil, fmt.Errorf("persist analysis: %w", err)
+		}
+		if err := db.SaveOpenL3Windows(track.ID, version, storage.OpenL3WindowsFromProto(res.GetOpenl3Windows())); err != nil {
+			return nil, fmt.Errorf("persist window embeddings: %w", err)
+		}
+
+		return map[string]any{"track_id": track.ID, "analysis_version": version}, nil
+	}
+}
+
+// SimilarityGraphHandler returns a handler that refreshes the similarity graph
+// of stale tracks, up to batch per job, queueing another job for the rest.
+func SimilarityGraphHandler(db *storage.DB, batch int) Handler {
+	return func(ctx context.Context, job *storage.Job) (map[string]any, error) {
+		refreshed := 0
+		for refreshed < batch {
+			// Refreshing a track can mark its neighbours stale, so look again
+			// until none are left.
+			stale, err := db.StaleSimilarityGraphs(batch - refreshed)
+			if err != nil {
+				return nil, fmt.Errorf("list stale similarity graphs: %w", err)
+			}
+			if len(stale) == 0 {
+				return map[string]any{"refreshed": refreshed}, nil
+			}
+			for _, trackID := range stale {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				if err := db.RefreshSimilarityGraph(trackID); err != nil {
+					return nil, fmt.Errorf("refresh similarity graph of track %d: %w", trackID, err)
+				}
+				refreshed++
+			}
+		}
+
+		// Let other jobs in before the rest.
+		if err := db.EnqueueSimilarityGraphRefresh(); err != nil {
+			return nil, fmt.Errorf("queue similarity graph refresh: %w", err)
+		}
+		return map[string]any{"refreshed": refreshed, "more": true}, nil
+	}
+}
+
+// payloadInt reads an integer from a JSON-decoded payload (numbers arrive as float64).
+func payloadInt(payload map[string]any, key string) (int64, bool) {
+	switch v := payload[key].(type) {
+	case float64:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,292 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	analyzerpb "github.com/cartomix/cancun/gen/go/analyzer"
+	"github.com/cartomix/cancun/gen/go/common"
+	"github.com/cartomix/cancun/internal/similarity"
+	"github.com/cartomix/cancun/internal/storage"
+)
+
+type fakeAnalyzer struct {
+	mu       sync.Mutex
+	calls    map[string]int
+	failures int // fail this many times per track before succeeding
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (f *fakeAnalyzer) AnalyzeTrack(ctx context.Context, job *analyzerpb.AnalyzeJob) (*analyzerpb.AnalyzeResult, error) {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		p := f.peak.Load()
+		if n <= p || f.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	f.mu.Lock()
+	f.calls[job.GetPath()]++
+	attempt := f.calls[job.GetPath()]
+	f.mu.Unlock()
+
+	if attempt <= f.failures {
+		return nil, errors.New("worker unavailable")
+	}
+
+	return &analyzerpb.AnalyzeResult{
+		Analysis: &common.TrackAnalysis{
+			Id:              job.GetId(),
+			DurationSeconds: 120,
+			Key:             &common.MusicalKey{Value: "8A", Format: common.KeyFormat_CAMELOT},
+			EnergyGlobal:    6,
+		},
+		Openl3Windows: []*analyzerpb.OpenL3Window{
+			{StartSeconds: 0, DurationSeconds: 60, Vector: []float32{1, 0}},
+			{StartSeconds: 60, DurationSeconds: 60, Vector: []float32{0, 1}},
+		},
+	}, nil
+}
+
+func (f *fakeAnalyzer) Close() error { return nil }
+
+func openTestDB(t *testing.T) *storage.DB {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	db, err := storage.Open(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func seedTracks(t *testing.T, db *storage.DB, n int) []int64 {
+	t.Helper()
+	ids := make([]int64, 0, n)
+	for i := 0; i < n; i++ {
+		name := string(rune('a'+i)) + ".wav"
+		id, err := db.UpsertTrack(&storage.Track{
+			ContentHash:    "hash-" + name,
+			Path:           filepath.Join("/music", name),
+			FileModifiedAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("upsert track: %v", err)
+		}
+		if _, err := db.CreateJob(storage.JobTypeAnalyze, 0, map[string]any{"track_id": id}); err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func waitForJobs(t *testing.T, db *storage.DB, status storage.JobStatus, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM jobs WHERE status = ?`, string(status)).Scan(&count); err != nil {
+			t.Fatalf("count jobs: %v", err)
+		}
+		if count == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d %s jobs", want, status)
+}
+
+func testConfig() Config {
+	return Config{
+		Concurrency:  3,
+		PollInterval: 5 * time.Millisecond,
+		JobTimeout:   time.Second,
+		RetryBackoff: time.Millisecond,
+		MaxBackoff:   5 * time.Millisecond,
+	}
+}
+
+func TestPoolDrainsAnalyzeJobs(t *testing.T) {
+	db := openTestDB(t)
+	ids := seedTracks(t, db, 6)
+	backend := &fakeAnalyzer{calls: map[string]int{}}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	pool := NewPool(db, testConfig(), logger)
+	pool.Handle(storage.JobTypeAnalyze, AnalyzeHandler(db, backend))
+	if err := pool.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer pool.Stop()
+
+	waitForJobs(t, db, storage.JobStatusComplete, len(ids))
+
+	for _, id := range ids {
+		rec, err := db.LatestAnalysisRecord(id)
+		if err != nil {
+			t.Fatalf("analysis for track %d: %v", id, err)
+		}
+		if rec.Status != storage.AnalysisStatusComplete {
+			t.Errorf("track %d: expected complete analysis, got %s", id, rec.Status)
+		}
+		if windows, err := db.GetOpenL3Windows(id); err != nil || len(windows) != 2 {
+			t.Errorf("track %d: windows = %+v, %v", id, windows, err)
+		}
+	}
+
+	if peak := backend.peak.Load(); peak < 2 || peak > 3 {
+		t.Errorf("expected concurrency between 2 and 3, observed %d", peak)
+	}
+}
+
+func TestPoolRetriesThenFails(t *testing.T) {
+	db := openTestDB(t)
+	seedTracks(t, db, 2)
+	// Default max_attempts is 3; failing 5 times exhausts every attempt.
+	backend := &fakeAnalyzer{calls: map[string]int{}, failures: 5}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	pool := NewPool(db, testConfig(), logger)
+	pool.Handle(storage.JobTypeAnalyze, AnalyzeHandler(db, backend))
+	if err := pool.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer pool.Stop()
+
+	waitForJobs(t, db, storage.JobStatusFailed, 2)
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	for path, calls := range backend.calls {
+		if calls != 3 {
+			t.Errorf("%s: expected 3 attempts, got %d", path, calls)
+		}
+	}
+}
+
+func TestPoolRecoversStalledJobsOnStart(t *testing.T) {
+	db := openTestDB(t)
+	seedTracks(t, db, 1)
+
+	// Simulate a crash mid-job: the row is left running.
+	if _, err := db.ClaimJob(storage.JobTypeAnalyze); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	waitForJobs(t, db, storage.JobStatusRunning, 1)
+
+	backend := &fakeAnalyzer{calls: map[string]int{}}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	pool := NewPool(db, testConfig(), logger)
+	pool.Handle(storage.JobTypeAnalyze, AnalyzeHandler(db, backend))
+	if err := pool.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer pool.Stop()
+
+	waitForJobs(t, db, storage.JobStatusComplete, 1)
+}
+
+func TestPoolWakesOnQueuedJob(t *testing.T) {
+	db := openTestDB(t)
+	backend := &fakeAnalyzer{calls: map[string]int{}}
+
+	cfg := testConfig()
+	cfg.PollInterval = time.Hour
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	pool := NewPool(db, cfg, logger)
+	pool.Handle(storage.JobTypeAnalyze, AnalyzeHandler(db, backend))
+	db.OnJobQueued(pool.Notify)
+	if err := pool.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer pool.Stop()
+
+	// Queued after the first poll found nothing; only the wake-up runs it.
+	time.Sleep(20 * time.Millisecond)
+	seedTracks(t, db, 1)
+	waitForJobs(t, db, storage.JobStatusComplete, 1)
+}
+
+func TestPoolRequeuesInterruptedJobs(t *testing.T) {
+	db := openTestDB(t)
+	seedTracks(t, db, 1)
+	// The job is on its last attempt.
+	if _, err := db.Exec(`UPDATE jobs SET attempts = max_attempts - 1`); err != nil {
+		t.Fatalf("set attempts: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	pool := NewPool(db, testConfig(), logger)
+	pool.Handle(storage.JobTypeAnalyze, func(ctx context.Context, job *storage.Job) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err := pool.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	waitForJobs(t, db, storage.JobStatusRunning, 1)
+	pool.Stop()
+
+	// Shutdown isn't the job's fault: it is queued again with the attempt back.
+	var status string
+	var attempts, maxAttempts int
+	if err := db.QueryRow(`SELECT status, attempts, max_attempts FROM jobs`).Scan(&status, &attempts, &maxAttempts); err != nil {
+		t.Fatalf("read job: %v", err)
+	}
+	if status != string(storage.JobStatusPending) || attempts != maxAttempts-1 {
+		t.Errorf("interrupted job is %s after %d of %d attempts", status, attempts, maxAttempts)
+	}
+}
+
+func TestBackoffDoublesAndCaps(t *testing.T) {
+	p := &Pool{cfg: Config{RetryBackoff: time.Second, MaxBackoff: 5 * time.Second}}
+
+	cases := map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second}
+	for attempt, want := range cases {
+		if got := p.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestSimilarityGraphHandlerBatches(t *testing.T) {
+	db := openTestDB(t)
+	for i, id := range seedTracks(t, db, 3) {
+		embedding := make([]float32, similarity.EmbeddingDim)
+		embedding[0], embedding[i+1] = 1, 0.5
+		if err := db.UpsertAnalysis(&storage.AnalysisRecord{
+			TrackID:         id,
+			Version:         1,
+			Status:          storage.AnalysisStatusComplete,
+			OpenL3Embedding: similarity.FloatsToBytes(embedding),
+		}); err != nil {
+			t.Fatalf("upsert analysis: %v", err)
+		}
+	}
+
+	handle := SimilarityGraphHandler(db, 2)
+	result, err := handle(context.Background(), &storage.Job{})
+	if err != nil || result["refreshed"] != 2 || result["more"] != true {
+		t.Fatalf("first batch = %v, %v", result, err)
+	}
+	result, err = handle(context.Background(), &storage.Job{})
+	if err != nil || result["refreshed"] != 1 || result["more"] != nil {
+		t.Fatalf("second batch = %v, %v", result, err)
+	}
+	if stale, _ := db.StaleSimilarityGraphs(10); len(stale) != 0 {
+		t.Errorf("stale after both batches: %v", stale)
+	}
+}